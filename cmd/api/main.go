@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
 	"github.com/PavaniTiago/beta-intelligence-api/internal/infrastructure/database"
 	"github.com/PavaniTiago/beta-intelligence-api/internal/interfaces/http/middleware"
 	"github.com/PavaniTiago/beta-intelligence-api/internal/interfaces/http/routes"
@@ -14,6 +20,9 @@ import (
 	"github.com/joho/godotenv"
 )
 
+// shutdownTimeout limita a espera pelas requisições em andamento no encerramento
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	// Definir localização padrão para Brasília (UTC-3) usando a função centralizada
 	brasilLocation := utils.GetBrasilLocation()
@@ -33,6 +42,13 @@ func main() {
 		log.Fatalf("❌ Error setting up database: %v", err)
 	}
 
+	// SIGINT/SIGTERM cancelam os workers em segundo plano e encerram o servidor
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Manter os rollups de métricas atualizados em segundo plano
+	rollupWorker := usecases.NewMetricRollupWorker(repositories.NewMetricRollupRepository(db))
+
 	// Executar a contagem de sessões
 	log.Println("📊 Contando sessões...")
 
@@ -54,7 +70,18 @@ func main() {
 	middleware.SetupMiddlewares(app)
 
 	// Setup routes
-	routes.SetupRoutes(app, db)
+	workers := routes.SetupRoutes(app, db)
+	workers = append(workers, rollupWorker.Run)
+
+	// Iniciar os workers em segundo plano com o contexto cancelado no encerramento
+	var wg sync.WaitGroup
+	for _, run := range workers {
+		wg.Add(1)
+		go func(run func(ctx context.Context)) {
+			defer wg.Done()
+			run(ctx)
+		}(run)
+	}
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -62,5 +89,29 @@ func main() {
 		port = "8080"
 	}
 	log.Printf("🚀 Server is running on port %s", port)
-	log.Fatal(app.Listen(":" + port))
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(":" + port)
+	}()
+
+	var serverErr error
+	select {
+	case serverErr = <-listenErr:
+	case <-ctx.Done():
+		log.Println("🛑 Encerrando: aguardando requisições em andamento e workers em segundo plano")
+	}
+	// Um segundo sinal encerra o processo imediatamente
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := app.ShutdownWithContext(shutdownCtx); err != nil {
+		log.Printf("⚠️ Erro ao encerrar o servidor: %v", err)
+	}
+	wg.Wait()
+
+	if serverErr != nil {
+		log.Fatal(serverErr)
+	}
+	log.Println("👋 Servidor encerrado")
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/infrastructure/database"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+
+	"github.com/joho/godotenv"
+)
+
+// Treina o modelo de propensão de compra fora da API (ex.: agendado após o fechamento de cada ciclo)
+func main() {
+	professionID := flag.Int("profession", 0, "ID da profissão")
+	funnelID := flag.Int("funnel", 0, "ID do funil (0 = ciclo geral da profissão)")
+	cycles := flag.Int("cycles", 12, "Quantidade de ciclos encerrados usados no treino")
+	activate := flag.Bool("activate", true, "Ativar o modelo treinado")
+	flag.Parse()
+
+	if *professionID <= 0 {
+		log.Fatal("❌ Informe a profissão com -profession")
+	}
+
+	time.Local = utils.GetBrasilLocation()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("⚠️ No .env file found, using system environment variables")
+	}
+
+	db, err := database.SetupDatabase()
+	if err != nil {
+		log.Fatalf("❌ Error setting up database: %v", err)
+	}
+
+	propensityUseCase := usecases.NewPropensityUseCase(
+		repositories.NewPropensityRepository(db),
+		repositories.NewWebinarCycleRepository(db),
+	)
+
+	model, err := propensityUseCase.TrainModel(usecases.PropensityTrainParams{
+		ProfessionID: *professionID,
+		FunnelID:     *funnelID,
+		Cycles:       *cycles,
+		Activate:     *activate,
+	})
+	if err != nil {
+		log.Fatalf("❌ Erro ao treinar modelo: %v", err)
+	}
+
+	log.Printf("✅ Modelo %d treinado com %d leads (%d compras) em %d ciclos", model.ModelID, model.Samples, model.Positives, model.CyclesUsed)
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(model.Metrics); err != nil {
+		log.Fatalf("❌ Erro ao exibir métricas: %v", err)
+	}
+}
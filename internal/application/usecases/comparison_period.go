@@ -0,0 +1,83 @@
+package usecases
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+)
+
+// Modos de comparação aceitos no parâmetro compare dos endpoints de dashboard
+const (
+	CompareModePrevious            = "previous"
+	CompareModePreviousYear        = "previous_year"
+	CompareModeSameWeekdayLastWeek = "same_weekday_last_week"
+	CompareModeCustom              = "custom"
+)
+
+// PreviousPeriod determina o período anterior para comparação: a mesma quantidade de buckets do
+// timeFrame (dias, semanas ISO, meses ou anos) imediatamente antes do período atual, preservando o
+// deslocamento dentro do bucket (ex.: 01/03–31/05 mensal compara com 01/12–28/02)
+func PreviousPeriod(from, to time.Time, timeFrame string) (time.Time, time.Time) {
+	timeFrame, ok := entities.NormalizeTimeFrame(timeFrame)
+	if !ok {
+		// Usar a duração exata do período atual
+		duration := to.Sub(from)
+		return from.Add(-duration), to.Add(-duration)
+	}
+
+	periods := entities.CountPeriods(from, to, timeFrame)
+	switch timeFrame {
+	case entities.TimeFrameWeekly:
+		return from.AddDate(0, 0, -7*periods), to.AddDate(0, 0, -7*periods)
+	case entities.TimeFrameMonthly:
+		return addMonthsClamped(from, -periods), addMonthsClamped(to, -periods)
+	case entities.TimeFrameYearly:
+		return addMonthsClamped(from, -12*periods), addMonthsClamped(to, -12*periods)
+	default:
+		return from.AddDate(0, 0, -periods), to.AddDate(0, 0, -periods)
+	}
+}
+
+// ComparisonPeriod calcula o período de comparação de from/to conforme o modo (exceto custom, cujas datas
+// vêm da requisição):
+//   - previous: defaultFrom/defaultTo (período imediatamente anterior ou ciclo anterior)
+//   - previous_year: o mesmo período um ano antes
+//   - same_weekday_last_week: semanas inteiras antes, preservando o dia da semana (uma semana para até 7 dias)
+func ComparisonPeriod(mode string, from, to, defaultFrom, defaultTo time.Time) (time.Time, time.Time, error) {
+	switch mode {
+	case CompareModePrevious:
+		return defaultFrom, defaultTo, nil
+	case CompareModePreviousYear:
+		return addMonthsClamped(from, -12), addMonthsClamped(to, -12), nil
+	case CompareModeSameWeekdayLastWeek:
+		days := entities.CountPeriods(from, to, entities.TimeFrameDaily)
+		weeks := (days + 6) / 7
+		return from.AddDate(0, 0, -7*weeks), to.AddDate(0, 0, -7*weeks), nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("parâmetro 'compare' inválido (use previous, previous_year, same_weekday_last_week ou custom)")
+	}
+}
+
+// CycleDatePeriod converte os limites do ciclo (início da captação ao fim das vendas) em DatePeriod
+func CycleDatePeriod(dates utils.WebinarCycleDates) DatePeriod {
+	return DatePeriod{
+		From:     dates.PesquisaInicio,
+		To:       dates.VendaFim,
+		TimeFrom: dates.PesquisaInicio.Format("15:04"),
+		TimeTo:   dates.VendaFim.Format("15:04"),
+	}
+}
+
+// addMonthsClamped soma meses sem transbordar para o mês seguinte: o dia é limitado ao fim do mês de
+// destino, e o último dia de um mês continua sendo o último dia (30/04 - 1 mês = 31/03)
+func addMonthsClamped(date time.Time, months int) time.Time {
+	target := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, date.Location())
+	lastDay := target.AddDate(0, 1, -1).Day()
+	day := date.Day()
+	if day > lastDay || date.AddDate(0, 0, 1).Day() == 1 {
+		day = lastDay
+	}
+	return time.Date(target.Year(), target.Month(), day, date.Hour(), date.Minute(), date.Second(), date.Nanosecond(), date.Location())
+}
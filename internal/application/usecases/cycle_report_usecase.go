@@ -0,0 +1,398 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// ErrBenchmarkCycleNotFound indica que o ciclo benchmark solicitado não existe
+var ErrBenchmarkCycleNotFound = errors.New("ciclo benchmark não encontrado")
+
+// CycleComparisonParams representa os parâmetros do relatório comparativo de ciclos
+type CycleComparisonParams struct {
+	ProfessionID       int
+	FunnelID           int
+	Cycles             int
+	IncludeCurrent     bool
+	StepHours          int
+	BenchmarkCycleID   int64
+	BenchmarkCycleName string
+}
+
+// CycleComparisonPoint representa os valores acumulados de um ciclo em uma hora da linha do tempo normalizada
+type CycleComparisonPoint struct {
+	Hour               int     `json:"hour"`
+	Leads              int64   `json:"leads"`
+	SurveyResponses    int64   `json:"survey_responses"`
+	SurveyResponseRate float64 `json:"survey_response_rate"`
+	Purchases          int64   `json:"purchases"`
+	SalesConversion    float64 `json:"sales_conversion"`
+	Revenue            float64 `json:"revenue"`
+}
+
+// FaixaShare representa a quantidade e o percentual de respostas em uma faixa
+type FaixaShare struct {
+	Count      int64   `json:"count"`
+	Percentage float64 `json:"percentage"`
+}
+
+// CycleComparisonTotals representa os totais de um ciclo até o último ponto disponível
+type CycleComparisonTotals struct {
+	Leads              int64                 `json:"leads"`
+	SurveyResponses    int64                 `json:"survey_responses"`
+	SurveyResponseRate float64               `json:"survey_response_rate"`
+	Purchases          int64                 `json:"purchases"`
+	SalesConversion    float64               `json:"sales_conversion"`
+	Revenue            float64               `json:"revenue"`
+	FaixaDistribution  map[string]FaixaShare `json:"faixa_distribution"`
+}
+
+// CycleBenchmarkDelta representa a variação percentual de um ciclo contra o benchmark na mesma hora
+type CycleBenchmarkDelta struct {
+	Hour               int     `json:"hour"`
+	Leads              float64 `json:"leads"`
+	SurveyResponseRate float64 `json:"survey_response_rate"`
+	SalesConversion    float64 `json:"sales_conversion"`
+	Revenue            float64 `json:"revenue"`
+}
+
+// CycleComparisonSeries representa um ciclo na linha do tempo normalizada (hora 0 = início da captação)
+type CycleComparisonSeries struct {
+	CycleID     int64                   `json:"cycle_id,omitempty"`
+	Name        string                  `json:"name"`
+	Cycle       utils.WebinarCycleDates `json:"cycle"`
+	IsCurrent   bool                    `json:"is_current"`
+	IsBenchmark bool                    `json:"is_benchmark"`
+	Timeline    []CycleComparisonPoint  `json:"timeline"`
+	Totals      CycleComparisonTotals   `json:"totals"`
+	VsBenchmark *CycleBenchmarkDelta    `json:"vs_benchmark,omitempty"`
+}
+
+// CycleComparisonReport representa o relatório comparativo entre ciclos
+type CycleComparisonReport struct {
+	ProfessionID int                     `json:"profession_id"`
+	FunnelID     int                     `json:"funnel_id,omitempty"`
+	TotalHours   int                     `json:"total_hours"`
+	StepHours    int                     `json:"step_hours"`
+	Cycles       []CycleComparisonSeries `json:"cycles"`
+	Benchmark    *CycleComparisonSeries  `json:"benchmark,omitempty"`
+}
+
+// CycleReportUseCase interface para relatórios comparativos entre ciclos de webinar
+type CycleReportUseCase interface {
+	CompareCycles(params CycleComparisonParams) (*CycleComparisonReport, error)
+}
+
+type cycleReportUseCase struct {
+	cycleRepo    repositories.WebinarCycleRepository
+	forecastRepo repositories.ForecastRepository
+}
+
+func NewCycleReportUseCase(cycleRepo repositories.WebinarCycleRepository, forecastRepo repositories.ForecastRepository) CycleReportUseCase {
+	return &cycleReportUseCase{
+		cycleRepo:    cycleRepo,
+		forecastRepo: forecastRepo,
+	}
+}
+
+// reportCycle identifica um ciclo a ser incluído no relatório
+type reportCycle struct {
+	id    int64
+	name  string
+	dates utils.WebinarCycleDates
+	// professionID e funnelID filtram os eventos do ciclo; o benchmark pode ser de outra profissão
+	professionID int
+	funnelID     int
+}
+
+// CompareCycles alinha os ciclos da profissão em uma linha do tempo normalizada para sobreposição das curvas
+func (uc *cycleReportUseCase) CompareCycles(params CycleComparisonParams) (*CycleComparisonReport, error) {
+	if params.StepHours < 1 {
+		params.StepHours = 1
+	}
+
+	now := time.Now().In(utils.GetBrasilLocation())
+	cycles, err := selectCycles(uc.cycleRepo, params, now)
+	if err != nil {
+		return nil, err
+	}
+
+	benchmark, err := uc.resolveBenchmark(params)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CycleComparisonReport{
+		ProfessionID: params.ProfessionID,
+		FunnelID:     params.FunnelID,
+		StepHours:    params.StepHours,
+		Cycles:       make([]CycleComparisonSeries, 0, len(cycles)),
+	}
+
+	// Todas as linhas do tempo usam o mesmo tamanho (o maior ciclo)
+	for _, cycle := range append(cycles, benchmark...) {
+		hours := int(math.Ceil(cycle.dates.VendaFim.Sub(cycle.dates.PesquisaInicio).Hours()))
+		if hours > report.TotalHours {
+			report.TotalHours = hours
+		}
+	}
+
+	var benchmarkSeries *CycleComparisonSeries
+	if len(benchmark) > 0 {
+		series, err := uc.buildSeries(benchmark[0], params, report.TotalHours, now)
+		if err != nil {
+			return nil, err
+		}
+		series.IsBenchmark = true
+		benchmarkSeries = &series
+		report.Benchmark = benchmarkSeries
+	}
+
+	for _, cycle := range cycles {
+		series, err := uc.buildSeries(cycle, params, report.TotalHours, now)
+		if err != nil {
+			return nil, err
+		}
+		if benchmarkSeries != nil {
+			series.IsBenchmark = cycle.id != 0 && cycle.id == benchmarkSeries.CycleID
+			series.VsBenchmark = compareWithBenchmark(series, *benchmarkSeries)
+		}
+		report.Cycles = append(report.Cycles, series)
+	}
+
+	return report, nil
+}
+
+// selectCycles escolhe os ciclos cadastrados mais recentes da profissão. Sem ciclos cadastrados,
+// usa o cronograma fixo de terça-feira.
+func selectCycles(cycleRepo repositories.WebinarCycleRepository, params CycleComparisonParams, now time.Time) ([]reportCycle, error) {
+	filter := repositories.WebinarCycleFilter{
+		ProfessionID:   params.ProfessionID,
+		FunnelID:       params.FunnelID,
+		ProfessionWide: params.FunnelID == 0,
+		To:             now,
+	}
+
+	stored, _, err := cycleRepo.ListCycles(filter, 1, params.Cycles+1)
+	if err != nil {
+		return nil, err
+	}
+
+	var cycles []reportCycle
+	if len(stored) > 0 {
+		for i := range stored {
+			cycles = append(cycles, reportCycle{
+				id:           stored[i].CycleID,
+				name:         stored[i].Name,
+				dates:        CycleDates(&stored[i]),
+				professionID: params.ProfessionID,
+				funnelID:     params.FunnelID,
+			})
+		}
+	} else {
+		dates := utils.CurrentWebinarCycleDates(now)
+		for i := 0; i <= params.Cycles; i++ {
+			cycles = append(cycles, reportCycle{
+				name:         defaultCycleName(dates),
+				dates:        dates,
+				professionID: params.ProfessionID,
+				funnelID:     params.FunnelID,
+			})
+			dates = dates.PreviousCycle()
+		}
+	}
+
+	// Separar o ciclo em andamento dos ciclos encerrados
+	var current *reportCycle
+	past := make([]reportCycle, 0, params.Cycles)
+	for i := range cycles {
+		if now.Before(cycles[i].dates.VendaFim) {
+			if current == nil {
+				current = &cycles[i]
+			}
+			continue
+		}
+		if len(past) < params.Cycles {
+			past = append(past, cycles[i])
+		}
+	}
+
+	// Ordem cronológica, com o ciclo atual por último
+	sort.Slice(past, func(i, j int) bool {
+		return past[i].dates.VendaInicio.Before(past[j].dates.VendaInicio)
+	})
+	if params.IncludeCurrent && current != nil {
+		past = append(past, *current)
+	}
+
+	return past, nil
+}
+
+func (uc *cycleReportUseCase) resolveBenchmark(params CycleComparisonParams) ([]reportCycle, error) {
+	var cycle *entities.WebinarCycle
+	var err error
+
+	switch {
+	case params.BenchmarkCycleID > 0:
+		cycle, err = uc.cycleRepo.GetCycleByID(params.BenchmarkCycleID)
+	case params.BenchmarkCycleName != "":
+		cycle, err = uc.cycleRepo.GetCycleByName(params.ProfessionID, params.BenchmarkCycleName)
+	default:
+		return nil, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBenchmarkCycleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar ciclo benchmark: %w", err)
+	}
+
+	// Os eventos do benchmark são os da profissão (e do funil) do próprio ciclo
+	funnelID := 0
+	if cycle.FunnelID != nil {
+		funnelID = *cycle.FunnelID
+	} else if cycle.ProfessionID == params.ProfessionID {
+		funnelID = params.FunnelID
+	}
+	return []reportCycle{{
+		id:           cycle.CycleID,
+		name:         cycle.Name,
+		dates:        CycleDates(cycle),
+		professionID: cycle.ProfessionID,
+		funnelID:     funnelID,
+	}}, nil
+}
+
+// buildSeries monta a linha do tempo acumulada de um ciclo. Ciclos em andamento são
+// cortados na hora atual.
+func (uc *cycleReportUseCase) buildSeries(cycle reportCycle, params CycleComparisonParams, totalHours int, now time.Time) (CycleComparisonSeries, error) {
+	curve, err := loadCycleCurve(uc.forecastRepo, cycle.dates, cycle.professionID, cycle.funnelID, totalHours)
+	if err != nil {
+		return CycleComparisonSeries{}, err
+	}
+
+	faixas, err := uc.cycleRepo.GetFaixaDistribution(cycle.dates, cycle.professionID, cycle.funnelID)
+	if err != nil {
+		return CycleComparisonSeries{}, err
+	}
+
+	lastHour := totalHours
+	isCurrent := now.Before(cycle.dates.VendaFim)
+	if isCurrent {
+		lastHour = int(math.Max(0, math.Ceil(now.Sub(cycle.dates.PesquisaInicio).Hours())))
+		if lastHour > totalHours {
+			lastHour = totalHours
+		}
+	}
+
+	series := CycleComparisonSeries{
+		CycleID:   cycle.id,
+		Name:      cycle.name,
+		Cycle:     cycle.dates,
+		IsCurrent: isCurrent,
+		Timeline:  make([]CycleComparisonPoint, 0, lastHour/params.StepHours+2),
+	}
+
+	var leads, responses, purchases, revenue float64
+	for hour := 0; hour < lastHour; hour++ {
+		leads += curve[forecastMetricLeads][hour]
+		responses += curve[forecastMetricSurveyResponses][hour]
+		purchases += curve[forecastMetricPurchases][hour]
+		revenue += curve[forecastMetricRevenue][hour]
+
+		// Pontos no fim de cada passo, sempre incluindo a última hora disponível
+		if (hour+1)%params.StepHours == 0 || hour == lastHour-1 {
+			series.Timeline = append(series.Timeline, newComparisonPoint(hour+1, leads, responses, purchases, revenue))
+		}
+	}
+
+	last := newComparisonPoint(lastHour, leads, responses, purchases, revenue)
+	series.Totals = CycleComparisonTotals{
+		Leads:              last.Leads,
+		SurveyResponses:    last.SurveyResponses,
+		SurveyResponseRate: last.SurveyResponseRate,
+		Purchases:          last.Purchases,
+		SalesConversion:    last.SalesConversion,
+		Revenue:            last.Revenue,
+		FaixaDistribution:  faixaShares(faixas),
+	}
+
+	return series, nil
+}
+
+func newComparisonPoint(hour int, leads, responses, purchases, revenue float64) CycleComparisonPoint {
+	point := CycleComparisonPoint{
+		Hour:            hour,
+		Leads:           int64(leads),
+		SurveyResponses: int64(responses),
+		Purchases:       int64(purchases),
+		Revenue:         math.Round(revenue*100) / 100,
+	}
+	if leads > 0 {
+		point.SurveyResponseRate = math.Round(responses/leads*10000) / 100
+		point.SalesConversion = math.Round(purchases/leads*10000) / 100
+	}
+	return point
+}
+
+func faixaShares(counts map[string]int64) map[string]FaixaShare {
+	var total int64
+	for _, count := range counts {
+		total += count
+	}
+
+	shares := make(map[string]FaixaShare, len(counts))
+	for faixa, count := range counts {
+		share := FaixaShare{Count: count}
+		if total > 0 {
+			share.Percentage = math.Round(float64(count)/float64(total)*10000) / 100
+		}
+		shares[faixa] = share
+	}
+	return shares
+}
+
+// compareWithBenchmark compara o ciclo com o benchmark no último ponto disponível do ciclo,
+// para que ciclos em andamento sejam comparados na mesma hora
+func compareWithBenchmark(series, benchmark CycleComparisonSeries) *CycleBenchmarkDelta {
+	if len(series.Timeline) == 0 || len(benchmark.Timeline) == 0 {
+		return nil
+	}
+
+	last := series.Timeline[len(series.Timeline)-1]
+	reference := benchmark.Timeline[len(benchmark.Timeline)-1]
+	for _, point := range benchmark.Timeline {
+		if point.Hour >= last.Hour {
+			reference = point
+			break
+		}
+	}
+
+	return &CycleBenchmarkDelta{
+		Hour:               last.Hour,
+		Leads:              percentageChange(float64(last.Leads), float64(reference.Leads)),
+		SurveyResponseRate: percentageChange(last.SurveyResponseRate, reference.SurveyResponseRate),
+		SalesConversion:    percentageChange(last.SalesConversion, reference.SalesConversion),
+		Revenue:            percentageChange(last.Revenue, reference.Revenue),
+	}
+}
+
+// percentageChange calcula a variação percentual de current em relação a reference
+func percentageChange(current, reference float64) float64 {
+	if reference == 0 {
+		if current == 0 {
+			return 0
+		}
+		return 100
+	}
+	return math.Round((current-reference)/reference*10000) / 100
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"sort"
 	"strconv"
 	"time"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
 	// Necessário para usar AdvancedFilter
 	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
 	"gorm.io/gorm"
 )
 
@@ -89,19 +91,27 @@ type DashboardResult struct {
 	PeriodCounts        map[string]int64   `json:"period_counts,omitempty"`
 	Filters             map[string]string  `json:"filters"`
 	ConversionRateByDay map[string]float64 `json:"conversion_rate_by_day,omitempty"`
+
+	// Série agrupada conforme time_frame (dia, semana ISO, mês ou ano)
+	ChartData []entities.DashboardPeriodData `json:"chart_data"`
+
+	// Séries atual e de comparação alinhadas ponto a ponto
+	AlignedChartData []entities.DashboardAlignedPeriod `json:"aligned_chart_data"`
 }
 
 // Nova estrutura para dados granulares do período anterior
 type PreviousPeriodDetails struct {
-	SessionsByDay       map[string]int64   `json:"sessions_by_day"`
-	LeadsByDay          map[string]int64   `json:"leads_by_day"`
-	ConversionRateByDay map[string]float64 `json:"conversion_rate_by_day"`
-	HourlyData          *HourlyMetrics     `json:"hourly_data,omitempty"`
+	SessionsByDay       map[string]int64               `json:"sessions_by_day"`
+	LeadsByDay          map[string]int64               `json:"leads_by_day"`
+	ConversionRateByDay map[string]float64             `json:"conversion_rate_by_day"`
+	HourlyData          *HourlyMetrics                 `json:"hourly_data,omitempty"`
+	ChartData           []entities.DashboardPeriodData `json:"chart_data"`
 }
 
 // DashboardUseCase define a interface para operações do dashboard unificado
 type DashboardUseCase interface {
-	GetUnifiedDashboard(params map[string]string, currentPeriod DatePeriod, previousPeriod DatePeriod) (DashboardResult, error)
+	// GetUnifiedDashboard calcula sessões e leads dos dois períodos; segment restringe aos membros de um segmento salvo
+	GetUnifiedDashboard(params map[string]string, currentPeriod DatePeriod, previousPeriod DatePeriod, segment repositories.SegmentFilter) (DashboardResult, error)
 	GetProfessionConversionRates(currentPeriod DatePeriod, previousPeriod DatePeriod) (map[string]interface{}, error)
 }
 
@@ -109,13 +119,13 @@ type DashboardUseCase interface {
 type ISessionRepository interface {
 	CountSessionsByDateRange(from, to time.Time, timeFrom, timeTo, userID, professionID, productID, funnelID string, landingPage string) (int64, error)
 	GetSessionsCountByDays(from, to time.Time, timeFrom, timeTo, userID, professionID, productID, funnelID string, landingPage string) (map[string]int64, error)
-	GetSessions(ctx context.Context, page, limit int, orderBy string, from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string) ([]entities.Session, int64, error)
+	GetSessions(ctx context.Context, page repositories.PageRequest, orderBy string, from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string, segment repositories.SegmentFilter) ([]entities.Session, repositories.PageResult, error)
 }
 
 // IEventRepository adiciona a interface do repositório de eventos necessária para otimização
 type IEventRepository interface {
-	CountEventsByDateRange(from, to time.Time, timeFrom, timeTo string, eventType string, professionIDs, funnelIDs []int, logicalOperator string) (int64, error)
-	GetEvents(ctx context.Context, page, limit int, orderBy string, from, to time.Time, timeFrom, timeTo string, professionIDs, funnelIDs []int, advancedFilters []repositories.AdvancedFilter, filterCondition string) ([]entities.Event, int64, error)
+	CountEventsByDateRange(from, to time.Time, timeFrom, timeTo string, eventType string, professionIDs, funnelIDs []int, logicalOperator string, filter *repositories.FilterNode) (int64, error)
+	GetEvents(ctx context.Context, page repositories.PageRequest, orderBy string, from, to time.Time, timeFrom, timeTo string, professionIDs, funnelIDs []int, filter *repositories.FilterNode, segment repositories.SegmentFilter) ([]entities.Event, repositories.PageResult, error)
 }
 
 // Atualizar struct dashboardUseCase sem o campo etag
@@ -123,14 +133,16 @@ type dashboardUseCase struct {
 	sessionRepository ISessionRepository
 	eventRepository   IEventRepository
 	db                *gorm.DB // Campo db para consultas diretas
+	rollupRepository  repositories.MetricRollupRepository
 }
 
 // Atualizar o construtor sem o etag
-func NewDashboardUseCase(sessionRepo ISessionRepository, eventRepo IEventRepository, db *gorm.DB) *dashboardUseCase {
+func NewDashboardUseCase(sessionRepo ISessionRepository, eventRepo IEventRepository, db *gorm.DB, rollupRepo repositories.MetricRollupRepository) *dashboardUseCase {
 	return &dashboardUseCase{
 		sessionRepository: sessionRepo,
 		eventRepository:   eventRepo,
 		db:                db,
+		rollupRepository:  rollupRepo,
 	}
 }
 
@@ -139,13 +151,16 @@ func (uc *dashboardUseCase) GetUnifiedDashboard(
 	params map[string]string,
 	currentPeriod DatePeriod,
 	previousPeriod DatePeriod,
+	segment repositories.SegmentFilter,
 ) (DashboardResult, error) {
 	// Iniciar timer para medir tempo de processamento interno
 	startTime := time.Now()
 
-	// Verificar se o período é muito grande (limitar a 90 dias)
+	// Verificar se o período é muito grande (limitar a 90 dias quando os rollups não cobrem a consulta).
+	// Os rollups não têm a dimensão de usuário, então consultas por segmento sempre usam os dados brutos.
 	maxDaysAllowed := 90
-	if currentPeriod.To.Sub(currentPeriod.From).Hours() > float64(24*maxDaysAllowed) {
+	if currentPeriod.To.Sub(currentPeriod.From).Hours() > float64(24*maxDaysAllowed) &&
+		(segment.IsSet() || !uc.rollupsCoverDashboard(params, currentPeriod, previousPeriod)) {
 		return DashboardResult{}, fmt.Errorf("período muito longo (máximo %d dias). considere reduzir o intervalo ou usar agregação mensal", maxDaysAllowed)
 	}
 
@@ -169,9 +184,9 @@ func (uc *dashboardUseCase) GetUnifiedDashboard(
 	funnelID := params["funnel_id"]
 	landingPage := params["landingPage"]
 	productID := params["product_id"]
-	timeFrame := params["time_frame"]
-	if timeFrame == "" {
-		timeFrame = "daily"
+	timeFrame, ok := entities.NormalizeTimeFrame(params["time_frame"])
+	if !ok {
+		return result, fmt.Errorf("time_frame inválido: %s", params["time_frame"])
 	}
 
 	// Para dias únicos, garantir que temos dados de comparação
@@ -194,6 +209,8 @@ func (uc *dashboardUseCase) GetUnifiedDashboard(
 		funnelID,
 		landingPage,
 		productID,
+		timeFrame,
+		segment,
 	)
 
 	if err != nil {
@@ -208,8 +225,11 @@ func (uc *dashboardUseCase) GetUnifiedDashboard(
 	result.SessionsByDay = dashboardData.SessionsByDay
 	result.LeadsByDay = dashboardData.LeadsByDay
 	result.ConversionRateByDay = dashboardData.ConversionRateByDay
+	result.ChartData = dashboardData.ChartData
+	result.AlignedChartData = alignDashboardChartData(dashboardData.ChartData, dashboardData.PreviousPeriodData.ChartData)
 	result.HourlyData = dashboardData.HourlyData                 // Transferir dados por hora, se existirem
 	result.PreviousPeriodData = dashboardData.PreviousPeriodData // Transferir dados do período anterior
+	result.Filters["data_source"] = dashboardData.DataSource
 
 	// Preencher o objeto Metrics com os dados da resposta
 	result.Metrics.Sessions = dashboardData.Sessions.Current
@@ -233,6 +253,7 @@ func (uc *dashboardUseCase) GetUnifiedDashboard(
 			productID,
 			funnelID,
 			landingPage,
+			segment,
 		)
 		if err == nil {
 			result.HourlyData = hourlyData
@@ -248,6 +269,7 @@ func (uc *dashboardUseCase) GetUnifiedDashboard(
 			productID,
 			funnelID,
 			landingPage,
+			segment,
 		)
 		if err == nil {
 			result.PreviousPeriodData.HourlyData = previousHourlyData
@@ -338,9 +360,13 @@ type OptimizedDashboardData struct {
 	LeadsByDay          map[string]int64   // Alterado de []DayCount para map
 	ConversionRateByDay map[string]float64 // Alterado para usar map
 	HourlyData          *HourlyMetrics     // Alterado para usar HourlyMetrics
+	ChartData           []entities.DashboardPeriodData
 
 	// NOVO: Dados granulares do período anterior
 	PreviousPeriodData *PreviousPeriodDetails // Dados detalhados do período anterior
+
+	// Origem das contagens: "rollup" (rollups de métricas) ou "raw" (sessions e events)
+	DataSource string
 }
 
 // getOptimizedDashboardData obtém todos os dados do dashboard em uma operação otimizada
@@ -351,6 +377,8 @@ func (uc *dashboardUseCase) getOptimizedDashboardData(
 	funnelID string,
 	landingPage string,
 	productID string,
+	timeFrame string,
+	segment repositories.SegmentFilter,
 ) (OptimizedDashboardData, error) {
 	// Iniciar timer para medir desempenho
 	startTime := time.Now()
@@ -399,6 +427,200 @@ func (uc *dashboardUseCase) getOptimizedDashboardData(
 		previousPeriod.From.Format("2006-01-02 15:04:05"),
 		previousPeriod.To.Format("2006-01-02 15:04:05"))
 
+	// Ler dos rollups quando cobrem os dois períodos (e não há segmento); caso contrário, consultar sessions e events diretamente
+	var results []dashboardCountRow
+	var fromRollups bool
+	var err error
+	if !segment.IsSet() {
+		results, fromRollups, err = uc.queryRollupDashboardRows(currentPeriod, previousPeriod, professionID, funnelID, landingPage, productID)
+		if err != nil {
+			log.Printf("Erro ao ler rollups do dashboard, usando dados brutos: %v", err)
+		}
+	}
+	result.DataSource = "rollup"
+	if !fromRollups {
+		result.DataSource = "raw"
+		results, err = uc.queryRawDashboardRows(currentPeriod, previousPeriod, professionID, funnelID, landingPage, productID, segment)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	// Processar resultados
+	var currentSessions, previousSessions int64
+	var currentLeads, previousLeads int64
+
+	for _, row := range results {
+		switch row.Type {
+		case "counts":
+			if row.Periodo == "current" {
+				currentSessions = row.Total
+			} else if row.Periodo == "previous" {
+				previousSessions = row.Total
+			}
+		case "leads":
+			if row.Periodo == "current" {
+				currentLeads = row.Total
+			} else if row.Periodo == "previous" {
+				previousLeads = row.Total
+			}
+		case "daily":
+			result.SessionsByDay[row.Periodo] = row.Total
+			result.PeriodCounts[row.Periodo] = row.Total
+		case "daily_leads":
+			result.LeadsByDay[row.Periodo] = row.Total
+		case "previous_daily":
+			result.PreviousPeriodData.SessionsByDay[row.Periodo] = row.Total
+		case "previous_daily_leads":
+			result.PreviousPeriodData.LeadsByDay[row.Periodo] = row.Total
+		}
+	}
+
+	// Calcular taxas de conversão por dia
+	for day, sessions := range result.PeriodCounts {
+		leads := result.LeadsByDay[day]
+		var conversionRate float64
+		if sessions > 0 {
+			conversionRate = float64(leads) / float64(sessions) * 100
+			// Arredondar para duas casas decimais
+			conversionRate = math.Round(conversionRate*100) / 100
+		}
+		result.ConversionRateByDay[day] = conversionRate
+	}
+
+	// Calcular taxas de conversão por dia para o período anterior
+	for day, sessions := range result.PreviousPeriodData.SessionsByDay {
+		leads := result.PreviousPeriodData.LeadsByDay[day]
+		var conversionRate float64
+		if sessions > 0 {
+			conversionRate = float64(leads) / float64(sessions) * 100
+			// Arredondar para duas casas decimais
+			conversionRate = math.Round(conversionRate*100) / 100
+		}
+		result.PreviousPeriodData.ConversionRateByDay[day] = conversionRate
+	}
+
+	// Agrupar as séries diárias nos buckets do time_frame
+	result.ChartData = buildDashboardChartData(currentPeriod, result.SessionsByDay, result.LeadsByDay, timeFrame)
+	result.PreviousPeriodData.ChartData = buildDashboardChartData(previousPeriod,
+		result.PreviousPeriodData.SessionsByDay, result.PreviousPeriodData.LeadsByDay, timeFrame)
+
+	// Métricas de sessões
+	var percentSessionChange float64
+	if previousSessions > 0 {
+		percentSessionChange = float64(currentSessions-previousSessions) / float64(previousSessions) * 100
+		// Arredondar para duas casas decimais
+		percentSessionChange = math.Round(percentSessionChange*100) / 100
+	}
+	result.Sessions = MetricResult{
+		Current:      currentSessions,
+		Previous:     previousSessions,
+		Percentage:   percentSessionChange,
+		IsIncreasing: currentSessions > previousSessions,
+	}
+
+	// Métricas de leads
+	var percentLeadChange float64
+	if previousLeads > 0 {
+		percentLeadChange = float64(currentLeads-previousLeads) / float64(previousLeads) * 100
+		// Arredondar para duas casas decimais
+		percentLeadChange = math.Round(percentLeadChange*100) / 100
+	}
+	result.Leads = MetricResult{
+		Current:      currentLeads,
+		Previous:     previousLeads,
+		Percentage:   percentLeadChange,
+		IsIncreasing: currentLeads > previousLeads,
+	}
+
+	// Métricas de taxa de conversão
+	var currentConversionRate, previousConversionRate float64
+	if currentSessions > 0 {
+		currentConversionRate = float64(currentLeads) / float64(currentSessions) * 100
+		// Arredondar para duas casas decimais
+		currentConversionRate = math.Round(currentConversionRate*100) / 100
+	}
+	if previousSessions > 0 {
+		previousConversionRate = float64(previousLeads) / float64(previousSessions) * 100
+		// Arredondar para duas casas decimais
+		previousConversionRate = math.Round(previousConversionRate*100) / 100
+	}
+
+	var percentConversionChange float64
+	if previousConversionRate > 0 {
+		percentConversionChange = (currentConversionRate - previousConversionRate) / previousConversionRate * 100
+		// Arredondar para duas casas decimais
+		percentConversionChange = math.Round(percentConversionChange*100) / 100
+	}
+
+	result.ConversionRate = MetricResult{
+		Current:      int64(math.Round(currentConversionRate)),
+		Previous:     int64(math.Round(previousConversionRate)),
+		Percentage:   percentConversionChange,
+		IsIncreasing: currentConversionRate > previousConversionRate,
+	}
+
+	// Buscar dados por hora se for um único dia
+	if isSingleDayQuery {
+		hourlyData, err := uc.GetHourlyData(
+			currentPeriod.From,
+			currentPeriod.To,
+			professionID,
+			productID,
+			funnelID,
+			landingPage,
+			segment,
+		)
+		if err == nil {
+			result.HourlyData = hourlyData
+		} else {
+			log.Printf("Erro ao buscar dados por hora: %v", err)
+		}
+
+		// Buscar dados por hora do período anterior
+		previousHourlyData, err := uc.GetHourlyData(
+			previousPeriod.From,
+			previousPeriod.To,
+			professionID,
+			productID,
+			funnelID,
+			landingPage,
+			segment,
+		)
+		if err == nil {
+			result.PreviousPeriodData.HourlyData = previousHourlyData
+		} else {
+			log.Printf("Erro ao buscar dados por hora do período anterior: %v", err)
+		}
+	}
+
+	// Loggar tempo de execução
+	executionTime := time.Since(startTime)
+	log.Printf("Consulta unificada do dashboard executada em %v", executionTime)
+
+	return result, nil
+}
+
+// dashboardCountRow é uma linha das contagens do dashboard: totais por período ("counts"/"leads") ou
+// contagens por dia ("daily", "daily_leads", "previous_daily", "previous_daily_leads")
+type dashboardCountRow struct {
+	Type    string `gorm:"column:type"`
+	Periodo string `gorm:"column:periodo"`
+	Total   int64  `gorm:"column:total"`
+}
+
+// queryRawDashboardRows obtém as contagens do dashboard diretamente de sessions e events em uma consulta unificada
+func (uc *dashboardUseCase) queryRawDashboardRows(
+	currentPeriod DatePeriod,
+	previousPeriod DatePeriod,
+	professionID string,
+	funnelID string,
+	landingPage string,
+	productID string,
+	segment repositories.SegmentFilter,
+) ([]dashboardCountRow, error) {
+	segmentClause, segmentArgs := segmentDashboardClause(segment)
+
 	// OTIMIZAÇÃO PRINCIPAL: Consulta unificada para sessões e leads
 	// Isso elimina múltiplas chamadas ao banco de dados
 	unifiedQuery := `
@@ -438,6 +660,8 @@ func (uc *dashboardUseCase) getOptimizedDashboardData(
 		unifiedQuery += ` AND funnel_id = ?`
 		queryArgs = append(queryArgs, funnelID)
 	}
+	unifiedQuery += segmentClause
+	queryArgs = append(queryArgs, segmentArgs...)
 
 	unifiedQuery += `
 			GROUP BY periodo
@@ -474,6 +698,8 @@ func (uc *dashboardUseCase) getOptimizedDashboardData(
 		unifiedQuery += ` AND funnel_id = ?`
 		queryArgs = append(queryArgs, funnelID)
 	}
+	unifiedQuery += segmentClause
+	queryArgs = append(queryArgs, segmentArgs...)
 
 	unifiedQuery += `
 			GROUP BY periodo
@@ -507,6 +733,8 @@ func (uc *dashboardUseCase) getOptimizedDashboardData(
 		unifiedQuery += ` AND funnel_id = ?`
 		queryArgs = append(queryArgs, funnelID)
 	}
+	unifiedQuery += segmentClause
+	queryArgs = append(queryArgs, segmentArgs...)
 
 	unifiedQuery += `
 			GROUP BY dia
@@ -536,6 +764,8 @@ func (uc *dashboardUseCase) getOptimizedDashboardData(
 		unifiedQuery += ` AND funnel_id = ?`
 		queryArgs = append(queryArgs, funnelID)
 	}
+	unifiedQuery += segmentClause
+	queryArgs = append(queryArgs, segmentArgs...)
 
 	unifiedQuery += `
 			GROUP BY dia
@@ -569,6 +799,8 @@ func (uc *dashboardUseCase) getOptimizedDashboardData(
 		unifiedQuery += ` AND funnel_id = ?`
 		queryArgs = append(queryArgs, funnelID)
 	}
+	unifiedQuery += segmentClause
+	queryArgs = append(queryArgs, segmentArgs...)
 
 	unifiedQuery += `
 			GROUP BY dia
@@ -598,6 +830,8 @@ func (uc *dashboardUseCase) getOptimizedDashboardData(
 		unifiedQuery += ` AND funnel_id = ?`
 		queryArgs = append(queryArgs, funnelID)
 	}
+	unifiedQuery += segmentClause
+	queryArgs = append(queryArgs, segmentArgs...)
 
 	unifiedQuery += `
 			GROUP BY dia
@@ -615,164 +849,126 @@ func (uc *dashboardUseCase) getOptimizedDashboardData(
 		SELECT 'previous_daily_leads' as type, dia, leads FROM previous_daily_lead_data
 	`
 
-	// Estrutura para receber os resultados unificados
-	type ResultRow struct {
-		Type    string `gorm:"column:type"`
-		Periodo string `gorm:"column:periodo"`
-		Total   int64  `gorm:"column:total"`
-	}
-
-	var results []ResultRow
+	var results []dashboardCountRow
 	if err := uc.db.Raw(unifiedQuery, queryArgs...).Scan(&results).Error; err != nil {
-		return result, fmt.Errorf("erro na consulta unificada: %w", err)
+		return nil, fmt.Errorf("erro na consulta unificada: %w", err)
 	}
+	return results, nil
+}
 
-	// Processar resultados
-	var currentSessions, previousSessions int64
-	var currentLeads, previousLeads int64
+// queryRollupDashboardRows obtém as contagens do dashboard dos rollups de métricas. Retorna false quando os
+// rollups não cobrem os dois períodos (limites fora de horas cheias, antes do histórico calculado ou filtros
+// não numéricos). Como na consulta direta, a landing page filtra apenas as sessões.
+func (uc *dashboardUseCase) queryRollupDashboardRows(
+	currentPeriod DatePeriod,
+	previousPeriod DatePeriod,
+	professionID string,
+	funnelID string,
+	landingPage string,
+	productID string,
+) ([]dashboardCountRow, bool, error) {
+	profession, okProfession := rollupDimension(professionID)
+	product, okProduct := rollupDimension(productID)
+	funnel, okFunnel := rollupDimension(funnelID)
+	if !okProfession || !okProduct || !okFunnel {
+		return nil, false, nil
+	}
+
+	rolledUntil, ok := metricRollupCoverage(uc.rollupRepository,
+		currentPeriod.From, currentPeriod.To, previousPeriod.From, previousPeriod.To)
+	if !ok {
+		return nil, false, nil
+	}
+
+	loc := utils.GetBrasilLocation()
+	var results []dashboardCountRow
+	periods := []struct {
+		period     DatePeriod
+		name       string
+		dailyType  string
+		dailyLeads string
+	}{
+		{currentPeriod, "current", "daily", "daily_leads"},
+		{previousPeriod, "previous", "previous_daily", "previous_daily_leads"},
+	}
+	for _, p := range periods {
+		from, to, _ := rollupRange(p.period.From, p.period.To)
+		query := repositories.MetricRollupQuery{
+			From:        from,
+			To:          to,
+			Granularity: repositories.MetricRollupDay,
+			ProductID:   product,
+			FunnelID:    funnel,
+		}
+		if profession > 0 {
+			query.ProfessionIDs = []int{profession}
+		}
 
-	for _, row := range results {
-		switch row.Type {
-		case "counts":
-			if row.Periodo == "current" {
-				currentSessions = row.Total
-			} else if row.Periodo == "previous" {
-				previousSessions = row.Total
-			}
-		case "leads":
-			if row.Periodo == "current" {
-				currentLeads = row.Total
-			} else if row.Periodo == "previous" {
-				previousLeads = row.Total
+		leadRows, err := uc.rollupRepository.GetSeries(query, rolledUntil)
+		if err != nil {
+			return nil, false, err
+		}
+		sessionRows := leadRows
+		if landingPage != "" {
+			query.LandingPage = landingPage
+			if sessionRows, err = uc.rollupRepository.GetSeries(query, rolledUntil); err != nil {
+				return nil, false, err
 			}
-		case "daily":
-			result.SessionsByDay[row.Periodo] = row.Total
-			result.PeriodCounts[row.Periodo] = row.Total
-		case "daily_leads":
-			result.LeadsByDay[row.Periodo] = row.Total
-		case "previous_daily":
-			result.PreviousPeriodData.SessionsByDay[row.Periodo] = row.Total
-		case "previous_daily_leads":
-			result.PreviousPeriodData.LeadsByDay[row.Periodo] = row.Total
 		}
-	}
 
-	// Calcular taxas de conversão por dia
-	for day, sessions := range result.PeriodCounts {
-		leads := result.LeadsByDay[day]
-		var conversionRate float64
-		if sessions > 0 {
-			conversionRate = float64(leads) / float64(sessions) * 100
-			// Arredondar para duas casas decimais
-			conversionRate = math.Round(conversionRate*100) / 100
+		var sessions, leads int64
+		for _, row := range sessionRows {
+			sessions += row.Sessions
+			if row.Sessions > 0 {
+				results = append(results, dashboardCountRow{Type: p.dailyType, Periodo: row.Bucket.In(loc).Format("2006-01-02"), Total: row.Sessions})
+			}
 		}
-		result.ConversionRateByDay[day] = conversionRate
-	}
-
-	// Calcular taxas de conversão por dia para o período anterior
-	for day, sessions := range result.PreviousPeriodData.SessionsByDay {
-		leads := result.PreviousPeriodData.LeadsByDay[day]
-		var conversionRate float64
-		if sessions > 0 {
-			conversionRate = float64(leads) / float64(sessions) * 100
-			// Arredondar para duas casas decimais
-			conversionRate = math.Round(conversionRate*100) / 100
+		for _, row := range leadRows {
+			leads += row.Leads
+			if row.Leads > 0 {
+				results = append(results, dashboardCountRow{Type: p.dailyLeads, Periodo: row.Bucket.In(loc).Format("2006-01-02"), Total: row.Leads})
+			}
 		}
-		result.PreviousPeriodData.ConversionRateByDay[day] = conversionRate
-	}
-
-	// Métricas de sessões
-	var percentSessionChange float64
-	if previousSessions > 0 {
-		percentSessionChange = float64(currentSessions-previousSessions) / float64(previousSessions) * 100
-		// Arredondar para duas casas decimais
-		percentSessionChange = math.Round(percentSessionChange*100) / 100
-	}
-	result.Sessions = MetricResult{
-		Current:      currentSessions,
-		Previous:     previousSessions,
-		Percentage:   percentSessionChange,
-		IsIncreasing: currentSessions > previousSessions,
+		results = append(results,
+			dashboardCountRow{Type: "counts", Periodo: p.name, Total: sessions},
+			dashboardCountRow{Type: "leads", Periodo: p.name, Total: leads},
+		)
 	}
 
-	// Métricas de leads
-	var percentLeadChange float64
-	if previousLeads > 0 {
-		percentLeadChange = float64(currentLeads-previousLeads) / float64(previousLeads) * 100
-		// Arredondar para duas casas decimais
-		percentLeadChange = math.Round(percentLeadChange*100) / 100
-	}
-	result.Leads = MetricResult{
-		Current:      currentLeads,
-		Previous:     previousLeads,
-		Percentage:   percentLeadChange,
-		IsIncreasing: currentLeads > previousLeads,
-	}
+	return results, true, nil
+}
 
-	// Métricas de taxa de conversão
-	var currentConversionRate, previousConversionRate float64
-	if currentSessions > 0 {
-		currentConversionRate = float64(currentLeads) / float64(currentSessions) * 100
-		// Arredondar para duas casas decimais
-		currentConversionRate = math.Round(currentConversionRate*100) / 100
-	}
-	if previousSessions > 0 {
-		previousConversionRate = float64(previousLeads) / float64(previousSessions) * 100
-		// Arredondar para duas casas decimais
-		previousConversionRate = math.Round(previousConversionRate*100) / 100
+// rollupsCoverDashboard indica se os rollups de métricas podem responder ao dashboard com os filtros e períodos informados
+func (uc *dashboardUseCase) rollupsCoverDashboard(params map[string]string, currentPeriod, previousPeriod DatePeriod) bool {
+	for _, key := range []string{"profession_id", "product_id", "funnel_id"} {
+		if _, ok := rollupDimension(params[key]); !ok {
+			return false
+		}
 	}
+	_, ok := metricRollupCoverage(uc.rollupRepository,
+		currentPeriod.From, currentPeriod.To, previousPeriod.From, previousPeriod.To)
+	return ok
+}
 
-	var percentConversionChange float64
-	if previousConversionRate > 0 {
-		percentConversionChange = (currentConversionRate - previousConversionRate) / previousConversionRate * 100
-		// Arredondar para duas casas decimais
-		percentConversionChange = math.Round(percentConversionChange*100) / 100
+// segmentDashboardClause retorna a restrição aos membros do segmento para as consultas brutas do dashboard
+func segmentDashboardClause(segment repositories.SegmentFilter) (string, []interface{}) {
+	if !segment.IsSet() {
+		return "", nil
 	}
+	condition, args := segment.Condition("user_id")
+	return " AND " + condition, args
+}
 
-	result.ConversionRate = MetricResult{
-		Current:      int64(math.Round(currentConversionRate)),
-		Previous:     int64(math.Round(previousConversionRate)),
-		Percentage:   percentConversionChange,
-		IsIncreasing: currentConversionRate > previousConversionRate,
+// rollupDimension converte um filtro de dimensão em ID para os rollups ("" não filtra)
+func rollupDimension(value string) (int, bool) {
+	if value == "" {
+		return 0, true
 	}
-
-	// Buscar dados por hora se for um único dia
-	if isSingleDayQuery {
-		hourlyData, err := uc.GetHourlyData(
-			currentPeriod.From,
-			currentPeriod.To,
-			professionID,
-			productID,
-			funnelID,
-			landingPage,
-		)
-		if err == nil {
-			result.HourlyData = hourlyData
-		} else {
-			log.Printf("Erro ao buscar dados por hora: %v", err)
-		}
-
-		// Buscar dados por hora do período anterior
-		previousHourlyData, err := uc.GetHourlyData(
-			previousPeriod.From,
-			previousPeriod.To,
-			professionID,
-			productID,
-			funnelID,
-			landingPage,
-		)
-		if err == nil {
-			result.PreviousPeriodData.HourlyData = previousHourlyData
-		} else {
-			log.Printf("Erro ao buscar dados por hora do período anterior: %v", err)
-		}
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		return 0, false
 	}
-
-	// Loggar tempo de execução
-	executionTime := time.Since(startTime)
-	log.Printf("Consulta unificada do dashboard executada em %v", executionTime)
-
-	return result, nil
+	return id, true
 }
 
 // generateDateRange gera um array de strings de datas entre from e to
@@ -792,6 +988,103 @@ func generateDateRange(from, to time.Time) []string {
 	return dates
 }
 
+// periodBucket agrupa dias (YYYY-MM-DD) em um bucket do time_frame
+type periodBucket struct {
+	Key     string
+	Display string
+	Days    []string
+}
+
+// groupDaysIntoPeriods distribui os dias do período e os dias com dados nos buckets do time_frame
+// (semana ISO, mês de calendário ou ano), em ordem cronológica. Buckets nas bordas podem ser parciais.
+func groupDaysIntoPeriods(period DatePeriod, timeFrame string, dataDays ...[]string) []periodBucket {
+	days := generateDateRange(period.From, period.To)
+	for _, extra := range dataDays {
+		days = append(days, extra...)
+	}
+	sort.Strings(days)
+
+	var buckets []periodBucket
+	for i, day := range days {
+		if i > 0 && day == days[i-1] {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02", day, utils.GetBrasilLocation())
+		if err != nil {
+			continue
+		}
+		start := entities.PeriodStart(date, timeFrame)
+		key := entities.PeriodKey(start, timeFrame)
+		if len(buckets) == 0 || buckets[len(buckets)-1].Key != key {
+			buckets = append(buckets, periodBucket{
+				Key:     key,
+				Display: entities.FormatDisplayPeriod(start, timeFrame),
+			})
+		}
+		buckets[len(buckets)-1].Days = append(buckets[len(buckets)-1].Days, day)
+	}
+	return buckets
+}
+
+// buildDashboardChartData soma sessões e leads diários por bucket do time_frame
+func buildDashboardChartData(period DatePeriod, sessionsByDay, leadsByDay map[string]int64, timeFrame string) []entities.DashboardPeriodData {
+	buckets := groupDaysIntoPeriods(period, timeFrame, mapKeys(sessionsByDay), mapKeys(leadsByDay))
+	chart := make([]entities.DashboardPeriodData, 0, len(buckets))
+	for _, bucket := range buckets {
+		point := entities.DashboardPeriodData{
+			Period:        bucket.Key,
+			DisplayPeriod: bucket.Display,
+		}
+		for _, day := range bucket.Days {
+			point.Sessions += sessionsByDay[day]
+			point.Leads += leadsByDay[day]
+		}
+		if point.Sessions > 0 {
+			point.Conversions = math.Round(float64(point.Leads)/float64(point.Sessions)*100*100) / 100
+		}
+		chart = append(chart, point)
+	}
+	return chart
+}
+
+// alignDashboardChartData alinha os buckets do período atual e do período de comparação pela posição
+func alignDashboardChartData(current, previous []entities.DashboardPeriodData) []entities.DashboardAlignedPeriod {
+	size := max(len(current), len(previous))
+	aligned := make([]entities.DashboardAlignedPeriod, size)
+	for i := range aligned {
+		aligned[i].Index = i
+		if i < len(current) {
+			aligned[i].Current = &current[i]
+		}
+		if i < len(previous) {
+			aligned[i].Previous = &previous[i]
+		}
+		if aligned[i].Current != nil && aligned[i].Previous != nil {
+			aligned[i].SessionsChange = alignedChange(float64(current[i].Sessions), float64(previous[i].Sessions))
+			aligned[i].LeadsChange = alignedChange(float64(current[i].Leads), float64(previous[i].Leads))
+		}
+	}
+	return aligned
+}
+
+// alignedChange retorna a variação percentual (duas casas), ou 0 quando o valor anterior é zero,
+// como as demais comparações do dashboard
+func alignedChange(current, previous float64) float64 {
+	if previous == 0 {
+		return 0
+	}
+	return math.Round((current-previous)/previous*100*100) / 100
+}
+
+// mapKeys retorna as chaves de um mapa diário
+func mapKeys[V int64 | float64](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
 // GetHourlyData obtém dados agrupados por hora para um único dia
 func (u *dashboardUseCase) GetHourlyData(
 	fromDate time.Time,
@@ -800,7 +1093,10 @@ func (u *dashboardUseCase) GetHourlyData(
 	productID,
 	funnelID string,
 	landingPage string,
+	segment repositories.SegmentFilter,
 ) (*HourlyMetrics, error) {
+	segmentClause, segmentArgs := segmentDashboardClause(segment)
+
 	startTime := time.Now()
 	dateStr := fromDate.Format("2006-01-02")
 
@@ -884,6 +1180,8 @@ func (u *dashboardUseCase) GetHourlyData(
 		sessionHourlyQuery += ` AND funnel_id = ?`
 		sessionQueryArgs = append(sessionQueryArgs, funnelID)
 	}
+	sessionHourlyQuery += segmentClause
+	sessionQueryArgs = append(sessionQueryArgs, segmentArgs...)
 
 	// Agrupar e ordenar
 	sessionHourlyQuery += `
@@ -952,6 +1250,8 @@ func (u *dashboardUseCase) GetHourlyData(
 		leadHourlyQuery += ` AND funnel_id = ?`
 		leadQueryArgs = append(leadQueryArgs, funnelID)
 	}
+	leadHourlyQuery += segmentClause
+	leadQueryArgs = append(leadQueryArgs, segmentArgs...)
 
 	// Agrupar e ordenar
 	leadHourlyQuery += `
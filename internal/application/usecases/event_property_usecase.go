@@ -0,0 +1,227 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+)
+
+// ErrInvalidPropertyDiscovery indica parâmetros de descoberta de propriedades inválidos
+var ErrInvalidPropertyDiscovery = errors.New("parâmetros de descoberta de propriedades inválidos")
+
+// Padrões e limites da amostra de descoberta de propriedades
+const (
+	defaultPropertySampleSize = 1000
+	maxPropertySampleSize     = 10000
+	defaultPropertyDays       = 30
+	maxPropertyDays           = 365
+	defaultPropertyTopValues  = 10
+	maxPropertyTopValues      = 50
+)
+
+// EventPropertyDiscoveryInput são os parâmetros da descoberta (zero aplica o padrão)
+type EventPropertyDiscoveryInput struct {
+	EventType  string
+	SampleSize int
+	Days       int
+	TopValues  int
+}
+
+// EventPropertyValue é um dos valores mais frequentes de uma propriedade
+type EventPropertyValue struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// EventPropertyInfo descreve uma propriedade observada no JSON dos eventos
+type EventPropertyInfo struct {
+	// Path é o caminho da propriedade, com chaves aninhadas separadas por ponto
+	Path string `json:"path"`
+	// FilterProperty é o valor de property a usar em advanced_filters (com o tipo, quando não é texto)
+	FilterProperty string `json:"filter_property"`
+	// Type é o tipo inferido: text, number, date, boolean ou array
+	Type string `json:"type"`
+	// JSONTypes conta as ocorrências por tipo JSON (string, number, boolean, array, null)
+	JSONTypes   map[string]int64     `json:"json_types"`
+	Occurrences int64                `json:"occurrences"`
+	Coverage    float64              `json:"coverage"`
+	TopValues   []EventPropertyValue `json:"top_values"`
+}
+
+// EventTypeProperties agrupa as propriedades observadas em um tipo de evento
+type EventTypeProperties struct {
+	EventType     string              `json:"event_type"`
+	SampledEvents int64               `json:"sampled_events"`
+	Properties    []EventPropertyInfo `json:"properties"`
+}
+
+// EventPropertyDiscoveryResult é o resultado da descoberta de propriedades
+type EventPropertyDiscoveryResult struct {
+	EventTypes []EventTypeProperties `json:"event_types"`
+	SampleSize int                   `json:"sample_size"`
+	Days       int                   `json:"days"`
+	TopValues  int                   `json:"top_values"`
+}
+
+// EventPropertyUseCase define a descoberta das propriedades do JSON dos eventos
+type EventPropertyUseCase interface {
+	// DiscoverProperties amostra eventos recentes por tipo e reporta as propriedades observadas,
+	// seus tipos inferidos e valores mais frequentes, para montar seletores de filtros
+	DiscoverProperties(input EventPropertyDiscoveryInput) (*EventPropertyDiscoveryResult, error)
+}
+
+type eventPropertyUseCase struct {
+	propertyRepo repositories.EventPropertyRepository
+}
+
+func NewEventPropertyUseCase(propertyRepo repositories.EventPropertyRepository) EventPropertyUseCase {
+	return &eventPropertyUseCase{
+		propertyRepo: propertyRepo,
+	}
+}
+
+func (uc *eventPropertyUseCase) DiscoverProperties(input EventPropertyDiscoveryInput) (*EventPropertyDiscoveryResult, error) {
+	if err := validatePropertyDiscoveryInput(&input); err != nil {
+		return nil, err
+	}
+
+	rows, err := uc.propertyRepo.DiscoverProperties(repositories.EventPropertyDiscoveryQuery{
+		EventType:  input.EventType,
+		SampleSize: input.SampleSize,
+		Days:       input.Days,
+		TopValues:  input.TopValues,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	type propertyStats struct {
+		info           *EventPropertyInfo
+		numericStrings int64
+		dateStrings    int64
+	}
+	sampled := make(map[string]int64)
+	properties := make(map[string]map[string]*propertyStats)
+	stats := func(eventType, path string) *propertyStats {
+		if properties[eventType] == nil {
+			properties[eventType] = make(map[string]*propertyStats)
+		}
+		p, ok := properties[eventType][path]
+		if !ok {
+			p = &propertyStats{info: &EventPropertyInfo{
+				Path:      path,
+				JSONTypes: make(map[string]int64),
+				TopValues: []EventPropertyValue{},
+			}}
+			properties[eventType][path] = p
+		}
+		return p
+	}
+
+	for _, row := range rows {
+		switch row.Kind {
+		case repositories.EventPropertyRowSample:
+			sampled[row.EventType] = row.Occurrences
+		case repositories.EventPropertyRowKey:
+			p := stats(row.EventType, row.Path)
+			p.info.JSONTypes[row.JSONType] += row.Occurrences
+			p.info.Occurrences += row.Occurrences
+			p.numericStrings += row.NumericStrings
+			p.dateStrings += row.DateStrings
+		case repositories.EventPropertyRowValue:
+			p := stats(row.EventType, row.Path)
+			p.info.TopValues = append(p.info.TopValues, EventPropertyValue{Value: row.Value, Count: row.Occurrences})
+		}
+	}
+
+	result := &EventPropertyDiscoveryResult{
+		EventTypes: []EventTypeProperties{},
+		SampleSize: input.SampleSize,
+		Days:       input.Days,
+		TopValues:  input.TopValues,
+	}
+	for eventType, count := range sampled {
+		group := EventTypeProperties{EventType: eventType, SampledEvents: count, Properties: []EventPropertyInfo{}}
+		for _, p := range properties[eventType] {
+			info := *p.info
+			info.Type = inferPropertyType(info.JSONTypes, p.numericStrings, p.dateStrings)
+			info.FilterProperty = repositories.EventPropertiesPrefix + info.Path
+			if info.Type != "text" && info.Type != "array" {
+				info.FilterProperty += ":" + info.Type
+			}
+			if count > 0 {
+				info.Coverage = math.Round(float64(info.Occurrences)/float64(count)*10000) / 100
+			}
+			sort.SliceStable(info.TopValues, func(i, j int) bool {
+				return info.TopValues[i].Count > info.TopValues[j].Count
+			})
+			group.Properties = append(group.Properties, info)
+		}
+		sort.Slice(group.Properties, func(i, j int) bool {
+			if group.Properties[i].Occurrences != group.Properties[j].Occurrences {
+				return group.Properties[i].Occurrences > group.Properties[j].Occurrences
+			}
+			return group.Properties[i].Path < group.Properties[j].Path
+		})
+		result.EventTypes = append(result.EventTypes, group)
+	}
+	sort.Slice(result.EventTypes, func(i, j int) bool {
+		return result.EventTypes[i].EventType < result.EventTypes[j].EventType
+	})
+
+	return result, nil
+}
+
+// inferPropertyType infere o tipo de uma propriedade a partir dos tipos JSON observados (nulos ignorados).
+// Strings numéricas ou com data ISO contam como number/date quando todas as ocorrências seguem o padrão.
+func inferPropertyType(jsonTypes map[string]int64, numericStrings, dateStrings int64) string {
+	var total int64
+	for jsonType, count := range jsonTypes {
+		if jsonType != "null" {
+			total += count
+		}
+	}
+	if total == 0 {
+		return "text"
+	}
+
+	strs := jsonTypes["string"]
+	switch {
+	case jsonTypes["number"]+numericStrings == total:
+		return "number"
+	case jsonTypes["boolean"] == total:
+		return "boolean"
+	case strs == total && dateStrings == strs:
+		return "date"
+	case jsonTypes["array"] == total:
+		return "array"
+	default:
+		return "text"
+	}
+}
+
+// validatePropertyDiscoveryInput aplica os padrões e valida os limites da amostra
+func validatePropertyDiscoveryInput(input *EventPropertyDiscoveryInput) error {
+	if input.SampleSize == 0 {
+		input.SampleSize = defaultPropertySampleSize
+	}
+	if input.SampleSize < 1 || input.SampleSize > maxPropertySampleSize {
+		return fmt.Errorf("%w: sample deve estar entre 1 e %d", ErrInvalidPropertyDiscovery, maxPropertySampleSize)
+	}
+	if input.Days == 0 {
+		input.Days = defaultPropertyDays
+	}
+	if input.Days < 1 || input.Days > maxPropertyDays {
+		return fmt.Errorf("%w: days deve estar entre 1 e %d", ErrInvalidPropertyDiscovery, maxPropertyDays)
+	}
+	if input.TopValues == 0 {
+		input.TopValues = defaultPropertyTopValues
+	}
+	if input.TopValues < 1 || input.TopValues > maxPropertyTopValues {
+		return fmt.Errorf("%w: top deve estar entre 1 e %d", ErrInvalidPropertyDiscovery, maxPropertyTopValues)
+	}
+	return nil
+}
@@ -9,9 +9,10 @@ import (
 )
 
 type EventUseCase interface {
-	GetEvents(ctx context.Context, page, limit int, orderBy string, from, to time.Time, timeFrom, timeTo string, professionIDs, funnelIDs []int, advancedFilters []repositories.AdvancedFilter, filterCondition string) ([]entities.Event, int64, error)
-	CountEvents(from, to time.Time, timeFrom, timeTo string, eventType string, professionIDs, funnelIDs []int, advancedFilters []repositories.AdvancedFilter, filterCondition string) (int64, error)
-	CountEventsByPeriods(periods []string, eventType string, advancedFilters []repositories.AdvancedFilter, funnelID int, professionID int) (map[string]int64, error)
+	GetEvents(ctx context.Context, page repositories.PageRequest, orderBy string, from, to time.Time, timeFrom, timeTo string, professionIDs, funnelIDs []int, filter *repositories.FilterNode, segment repositories.SegmentFilter) ([]entities.Event, repositories.PageResult, error)
+	ExportEvents(ctx context.Context, columns []repositories.ExportColumn, orderBy string, from, to time.Time, timeFrom, timeTo string, professionIDs, funnelIDs []int, filter *repositories.FilterNode, segment repositories.SegmentFilter, fn repositories.ExportRowFunc) error
+	CountEvents(from, to time.Time, timeFrom, timeTo string, eventType string, professionIDs, funnelIDs []int, filter *repositories.FilterNode, segment repositories.SegmentFilter) (int64, error)
+	CountEventsByPeriods(periods []string, eventType string, filter *repositories.FilterNode, funnelID int, professionID int, segment repositories.SegmentFilter) (map[string]int64, error)
 	GetEventsDateRange(eventType string) (time.Time, time.Time, error)
 }
 
@@ -23,26 +24,30 @@ func NewEventUseCase(eventRepo repositories.EventRepository) EventUseCase {
 	return &eventUseCase{eventRepo}
 }
 
-func (uc *eventUseCase) GetEvents(ctx context.Context, page, limit int, orderBy string, from, to time.Time, timeFrom, timeTo string, professionIDs, funnelIDs []int, advancedFilters []repositories.AdvancedFilter, filterCondition string) ([]entities.Event, int64, error) {
-	if page < 1 {
-		page = 1
+func (uc *eventUseCase) GetEvents(ctx context.Context, page repositories.PageRequest, orderBy string, from, to time.Time, timeFrom, timeTo string, professionIDs, funnelIDs []int, filter *repositories.FilterNode, segment repositories.SegmentFilter) ([]entities.Event, repositories.PageResult, error) {
+	if page.Page < 1 {
+		page.Page = 1
 	}
-	if limit < 1 {
-		limit = 20
+	if page.Limit < 1 {
+		page.Limit = 20
 	}
 	if orderBy == "" {
 		orderBy = "event_time desc"
 	}
 
-	return uc.eventRepo.GetEvents(ctx, page, limit, orderBy, from, to, timeFrom, timeTo, professionIDs, funnelIDs, advancedFilters, filterCondition)
+	return uc.eventRepo.GetEvents(ctx, page, orderBy, from, to, timeFrom, timeTo, professionIDs, funnelIDs, filter, segment)
 }
 
-func (uc *eventUseCase) CountEvents(from, to time.Time, timeFrom, timeTo string, eventType string, professionIDs, funnelIDs []int, advancedFilters []repositories.AdvancedFilter, filterCondition string) (int64, error) {
-	return uc.eventRepo.CountEvents(from, to, timeFrom, timeTo, eventType, professionIDs, funnelIDs, advancedFilters, filterCondition)
+func (uc *eventUseCase) ExportEvents(ctx context.Context, columns []repositories.ExportColumn, orderBy string, from, to time.Time, timeFrom, timeTo string, professionIDs, funnelIDs []int, filter *repositories.FilterNode, segment repositories.SegmentFilter, fn repositories.ExportRowFunc) error {
+	return uc.eventRepo.ExportEvents(ctx, columns, orderBy, from, to, timeFrom, timeTo, professionIDs, funnelIDs, filter, segment, fn)
 }
 
-func (uc *eventUseCase) CountEventsByPeriods(periods []string, eventType string, advancedFilters []repositories.AdvancedFilter, funnelID int, professionID int) (map[string]int64, error) {
-	return uc.eventRepo.CountEventsByPeriods(periods, eventType, advancedFilters, funnelID, professionID)
+func (uc *eventUseCase) CountEvents(from, to time.Time, timeFrom, timeTo string, eventType string, professionIDs, funnelIDs []int, filter *repositories.FilterNode, segment repositories.SegmentFilter) (int64, error) {
+	return uc.eventRepo.CountEvents(from, to, timeFrom, timeTo, eventType, professionIDs, funnelIDs, filter, segment)
+}
+
+func (uc *eventUseCase) CountEventsByPeriods(periods []string, eventType string, filter *repositories.FilterNode, funnelID int, professionID int, segment repositories.SegmentFilter) (map[string]int64, error) {
+	return uc.eventRepo.CountEventsByPeriods(periods, eventType, filter, funnelID, professionID, segment)
 }
 
 func (uc *eventUseCase) GetEventsDateRange(eventType string) (time.Time, time.Time, error) {
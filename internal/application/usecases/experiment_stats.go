@@ -0,0 +1,68 @@
+package usecases
+
+import "math"
+
+// Nível de significância usado nos testes de experimentos
+const experimentSignificanceLevel = 0.05
+
+// ProportionTest compara a taxa de uma variante com a do controle
+type ProportionTest struct {
+	// Lift é a diferença relativa (%) da taxa da variante em relação ao controle
+	Lift        float64 `json:"lift"`
+	ZScore      float64 `json:"z_score"`
+	PValue      float64 `json:"p_value"`
+	Significant bool    `json:"significant"`
+	// ProbabilityToBeatControl é a probabilidade a posteriori (Beta(1,1) a priori) de a variante ter taxa maior que o controle
+	ProbabilityToBeatControl float64 `json:"probability_to_beat_control"`
+}
+
+// compareProportions aplica o teste z de duas proporções e calcula a probabilidade bayesiana de a
+// variante superar o controle. Retorna nil quando algum dos grupos não tem amostras.
+func compareProportions(controlSuccesses, controlTotal, variantSuccesses, variantTotal int64) *ProportionTest {
+	if controlTotal == 0 || variantTotal == 0 {
+		return nil
+	}
+
+	controlRate := float64(controlSuccesses) / float64(controlTotal)
+	variantRate := float64(variantSuccesses) / float64(variantTotal)
+
+	test := &ProportionTest{PValue: 1}
+	if controlRate > 0 {
+		test.Lift = math.Round((variantRate-controlRate)/controlRate*10000) / 100
+	}
+
+	pooled := float64(controlSuccesses+variantSuccesses) / float64(controlTotal+variantTotal)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/float64(controlTotal) + 1/float64(variantTotal)))
+	if se > 0 {
+		z := (variantRate - controlRate) / se
+		test.ZScore = math.Round(z*10000) / 10000
+		test.PValue = math.Round(math.Erfc(math.Abs(z)/math.Sqrt2)*10000) / 10000
+	}
+	test.Significant = test.PValue < experimentSignificanceLevel
+
+	test.ProbabilityToBeatControl = math.Round(probabilityToBeat(
+		controlSuccesses, controlTotal-controlSuccesses,
+		variantSuccesses, variantTotal-variantSuccesses,
+	)*10000) / 10000
+	return test
+}
+
+// probabilityToBeat calcula P(pB > pA) com pA ~ Beta(1+sucessosA, 1+falhasA) e pB ~ Beta(1+sucessosB, 1+falhasB),
+// pela soma exata para parâmetros inteiros
+func probabilityToBeat(successesA, failuresA, successesB, failuresB int64) float64 {
+	alphaA, betaA := float64(successesA+1), float64(failuresA+1)
+	alphaB, betaB := float64(successesB+1), float64(failuresB+1)
+
+	total := 0.0
+	for i := 0.0; i < alphaB; i++ {
+		total += math.Exp(logBeta(alphaA+i, betaA+betaB) - math.Log(betaB+i) - logBeta(1+i, betaB) - logBeta(alphaA, betaA))
+	}
+	return math.Min(math.Max(total, 0), 1)
+}
+
+func logBeta(a, b float64) float64 {
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	return la + lb - lab
+}
@@ -0,0 +1,595 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrExperimentNotFound indica que o experimento solicitado não existe
+	ErrExperimentNotFound = errors.New("experimento não encontrado")
+	// ErrInvalidExperiment indica que os dados do experimento não são válidos
+	ErrInvalidExperiment = errors.New("experimento inválido")
+)
+
+// Veredito da comparação de uma variante com o controle (e do experimento como um todo)
+const (
+	ExperimentVerdictWinner           = "winner"
+	ExperimentVerdictLoser            = "loser"
+	ExperimentVerdictInconclusive     = "inconclusive"
+	ExperimentVerdictInsufficientData = "insufficient_data"
+	// ExperimentVerdictControl indica que todas as variantes perderam significativamente para o controle
+	ExperimentVerdictControl = "control"
+)
+
+const (
+	// Janela de conversão padrão após o fim do experimento
+	defaultExperimentConversionWindowDays = 7
+	// Efeito mínimo detectável padrão (relativo) usado no cálculo do tamanho de amostra
+	defaultExperimentMinimumDetectableEffect = 0.10
+	// Poder estatístico desejado no cálculo do tamanho de amostra
+	experimentTargetPower = 0.8
+	// Amostra mínima por variante para emitir um veredito
+	experimentMinVisitors = 100
+	experimentMinLeads    = 5
+
+	// Quantis da normal para o nível de significância bilateral e o poder desejado
+	experimentZAlpha = 1.959964
+	experimentZBeta  = 0.841621
+)
+
+// ExperimentVariantInput representa uma landing page do experimento
+type ExperimentVariantInput struct {
+	Name            string `json:"name"`
+	LandingPagePath string `json:"landing_page_path"`
+	IsControl       bool   `json:"is_control"`
+}
+
+// ExperimentInput representa os dados de criação/alteração de um experimento.
+// Na alteração, apenas os campos informados são modificados; variants, se informado, substitui as variantes.
+type ExperimentInput struct {
+	Name                 *string                  `json:"name"`
+	FunnelID             *int                     `json:"funnel_id"`
+	StartDate            *time.Time               `json:"start_date"`
+	EndDate              *time.Time               `json:"end_date"`
+	ConversionWindowDays *int                     `json:"conversion_window_days"`
+	Notes                *string                  `json:"notes"`
+	Variants             []ExperimentVariantInput `json:"variants"`
+}
+
+// ConfidenceInterval representa um intervalo de confiança de 95%
+type ConfidenceInterval struct {
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// ExperimentComparison representa a comparação de uma variante com o controle
+type ExperimentComparison struct {
+	// Conversion compara a conversão visitante → lead (métrica principal do veredito)
+	Conversion *ProportionTest `json:"conversion"`
+	// ConversionDifference é o intervalo da diferença absoluta de conversão, em pontos percentuais
+	ConversionDifference *ConfidenceInterval `json:"conversion_difference,omitempty"`
+	// Purchase compara a conversão visitante → compra
+	Purchase *ProportionTest `json:"purchase"`
+	// Diferença relativa (%) e p-valor (teste de Welch) da receita por visitante
+	RevenuePerVisitorLift   float64 `json:"revenue_per_visitor_lift"`
+	RevenuePerVisitorPValue float64 `json:"revenue_per_visitor_p_value"`
+	Verdict                 string  `json:"verdict"`
+}
+
+// ExperimentVariantResult representa os resultados de uma variante do experimento
+type ExperimentVariantResult struct {
+	entities.ExperimentVariant
+	Sessions int64 `json:"sessions"`
+	// Visitors são os usuários cuja primeira sessão no experimento caiu nesta variante
+	Visitors            int64                 `json:"visitors"`
+	Leads               int64                 `json:"leads"`
+	ConversionRate      float64               `json:"conversion_rate"`
+	ConversionCI        ConfidenceInterval    `json:"conversion_ci"`
+	Purchasers          int64                 `json:"purchasers"`
+	Purchases           int64                 `json:"purchases"`
+	PurchaseRate        float64               `json:"purchase_rate"`
+	PurchaseCI          ConfidenceInterval    `json:"purchase_ci"`
+	Revenue             float64               `json:"revenue"`
+	RevenuePerVisitor   float64               `json:"revenue_per_visitor"`
+	RevenuePerVisitorCI ConfidenceInterval    `json:"revenue_per_visitor_ci"`
+	ComparedToControl   *ExperimentComparison `json:"compared_to_control,omitempty"`
+	revenueSquares      float64
+}
+
+// ExperimentPower representa a orientação de tamanho de amostra e poder do experimento
+type ExperimentPower struct {
+	// MinimumDetectableEffect é o efeito relativo (%) sobre a conversão do controle que se deseja detectar
+	MinimumDetectableEffect float64 `json:"minimum_detectable_effect"`
+	BaselineConversionRate  float64 `json:"baseline_conversion_rate"`
+	TargetPower             float64 `json:"target_power"`
+	// RequiredVisitorsPerVariant é 0 quando ainda não há conversão no controle para estimar a amostra
+	RequiredVisitorsPerVariant int64 `json:"required_visitors_per_variant"`
+	// Progress é o percentual da amostra necessária atingido pela menor variante
+	Progress     float64 `json:"progress"`
+	CurrentPower float64 `json:"current_power"`
+	// EstimatedDaysRemaining é estimado pelo tráfego diário observado (ausente se não for possível estimar)
+	EstimatedDaysRemaining *int `json:"estimated_days_remaining,omitempty"`
+	// ReachableByEndDate indica se a amostra necessária deve ser atingida até o fim do experimento
+	ReachableByEndDate bool `json:"reachable_by_end_date"`
+}
+
+// ExperimentDailyVisitors representa os novos visitantes do experimento em um dia
+type ExperimentDailyVisitors struct {
+	Date     string `json:"date"`
+	Visitors int64  `json:"visitors"`
+}
+
+// ExperimentResults representa os resultados completos de um experimento
+type ExperimentResults struct {
+	Experiment        *entities.Experiment      `json:"experiment"`
+	SignificanceLevel float64                   `json:"significance_level"`
+	ControlVariantID  int64                     `json:"control_variant_id,omitempty"`
+	Verdict           string                    `json:"verdict"`
+	WinnerVariantID   int64                     `json:"winner_variant_id,omitempty"`
+	Variants          []ExperimentVariantResult `json:"variants"`
+	Power             ExperimentPower           `json:"power"`
+	DailyVisitors     []ExperimentDailyVisitors `json:"daily_visitors"`
+}
+
+// ExperimentUseCase interface para casos de uso de experimentos de landing page
+type ExperimentUseCase interface {
+	ListExperiments(funnelID int) ([]entities.Experiment, error)
+	GetExperiment(experimentID int64) (*entities.Experiment, error)
+	CreateExperiment(input ExperimentInput) (*entities.Experiment, error)
+	UpdateExperiment(experimentID int64, input ExperimentInput) (*entities.Experiment, error)
+	DeleteExperiment(experimentID int64) error
+
+	// GetResults calcula os resultados por variante, os testes contra o controle e a orientação de amostra
+	// para o efeito mínimo detectável informado (relativo, ex.: 0.1 = 10%; 0 usa o padrão)
+	GetResults(experimentID int64, minimumDetectableEffect float64) (*ExperimentResults, error)
+	// ListLandingPages lista os caminhos de landing page com tráfego no funil, para montar as variantes
+	ListLandingPages(funnelID int, from, to time.Time) ([]repositories.ExperimentLandingPageRow, error)
+}
+
+type experimentUseCase struct {
+	experimentRepo repositories.ExperimentRepository
+}
+
+func NewExperimentUseCase(experimentRepo repositories.ExperimentRepository) ExperimentUseCase {
+	return &experimentUseCase{
+		experimentRepo: experimentRepo,
+	}
+}
+
+func (uc *experimentUseCase) ListExperiments(funnelID int) ([]entities.Experiment, error) {
+	return uc.experimentRepo.ListExperiments(funnelID)
+}
+
+func (uc *experimentUseCase) GetExperiment(experimentID int64) (*entities.Experiment, error) {
+	experiment, err := uc.experimentRepo.GetExperiment(experimentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrExperimentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar experimento: %w", err)
+	}
+	return experiment, nil
+}
+
+func (uc *experimentUseCase) CreateExperiment(input ExperimentInput) (*entities.Experiment, error) {
+	experiment := &entities.Experiment{ConversionWindowDays: defaultExperimentConversionWindowDays}
+	if input.Variants == nil {
+		return nil, fmt.Errorf("%w: informe as variantes", ErrInvalidExperiment)
+	}
+	if err := input.apply(experiment); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	experiment.CreatedAt = now
+	experiment.UpdatedAt = now
+	if err := uc.experimentRepo.CreateExperiment(experiment); err != nil {
+		return nil, err
+	}
+	return experiment, nil
+}
+
+func (uc *experimentUseCase) UpdateExperiment(experimentID int64, input ExperimentInput) (*entities.Experiment, error) {
+	experiment, err := uc.GetExperiment(experimentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := input.apply(experiment); err != nil {
+		return nil, err
+	}
+
+	experiment.UpdatedAt = time.Now()
+	// Sem variants no corpo, as variantes existentes (e seus IDs) são mantidas
+	if err := uc.experimentRepo.UpdateExperiment(experiment, input.Variants != nil); err != nil {
+		return nil, err
+	}
+	return experiment, nil
+}
+
+func (uc *experimentUseCase) DeleteExperiment(experimentID int64) error {
+	err := uc.experimentRepo.DeleteExperiment(experimentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrExperimentNotFound
+	}
+	return err
+}
+
+func (uc *experimentUseCase) ListLandingPages(funnelID int, from, to time.Time) ([]repositories.ExperimentLandingPageRow, error) {
+	return uc.experimentRepo.GetLandingPages(funnelID, from, to)
+}
+
+func (uc *experimentUseCase) GetResults(experimentID int64, minimumDetectableEffect float64) (*ExperimentResults, error) {
+	if minimumDetectableEffect <= 0 {
+		minimumDetectableEffect = defaultExperimentMinimumDetectableEffect
+	}
+
+	experiment, err := uc.GetExperiment(experimentID)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := uc.experimentRepo.GetExperimentMetrics(experiment)
+	if err != nil {
+		return nil, err
+	}
+	daily, err := uc.experimentRepo.GetExperimentDailyVisitors(experiment)
+	if err != nil {
+		return nil, err
+	}
+
+	metricsByVariant := make(map[int64]repositories.ExperimentVariantMetricsRow, len(metrics))
+	for _, row := range metrics {
+		metricsByVariant[row.VariantID] = row
+	}
+
+	results := &ExperimentResults{
+		Experiment:        experiment,
+		SignificanceLevel: experimentSignificanceLevel,
+		Verdict:           ExperimentVerdictInsufficientData,
+		Variants:          make([]ExperimentVariantResult, 0, len(experiment.Variants)),
+		DailyVisitors:     make([]ExperimentDailyVisitors, 0, len(daily)),
+	}
+
+	controlIndex := -1
+	for _, variant := range experiment.Variants {
+		row := metricsByVariant[variant.VariantID]
+		result := ExperimentVariantResult{
+			ExperimentVariant: variant,
+			Sessions:          row.Sessions,
+			Visitors:          row.Visitors,
+			Leads:             row.Leads,
+			ConversionRate:    crosstabPercentage(row.Leads, row.Visitors),
+			ConversionCI:      wilsonInterval(row.Leads, row.Visitors),
+			Purchasers:        row.Purchasers,
+			Purchases:         row.Purchases,
+			PurchaseRate:      crosstabPercentage(row.Purchasers, row.Visitors),
+			PurchaseCI:        wilsonInterval(row.Purchasers, row.Visitors),
+			Revenue:           math.Round(row.Revenue*100) / 100,
+			revenueSquares:    row.RevenueSquares,
+		}
+		if row.Visitors > 0 {
+			mean, ci := revenuePerVisitor(row.Revenue, row.RevenueSquares, row.Visitors)
+			result.RevenuePerVisitor = math.Round(mean*100) / 100
+			result.RevenuePerVisitorCI = ci
+		}
+
+		results.Variants = append(results.Variants, result)
+		if variant.IsControl {
+			controlIndex = len(results.Variants) - 1
+			results.ControlVariantID = variant.VariantID
+		}
+	}
+
+	for _, row := range daily {
+		results.DailyVisitors = append(results.DailyVisitors, ExperimentDailyVisitors{
+			Date:     row.Day.Format("2006-01-02"),
+			Visitors: row.Visitors,
+		})
+	}
+
+	if controlIndex < 0 {
+		return results, nil
+	}
+	control := results.Variants[controlIndex]
+	for i := range results.Variants {
+		if results.Variants[i].IsControl {
+			continue
+		}
+		results.Variants[i].ComparedToControl = compareExperimentVariant(control, results.Variants[i])
+	}
+
+	results.Verdict, results.WinnerVariantID = experimentVerdict(results.Variants)
+	results.Power = experimentPower(experiment, results.Variants, control, minimumDetectableEffect, time.Now())
+	return results, nil
+}
+
+// compareExperimentVariant compara conversão, compras e receita por visitante da variante com o controle
+func compareExperimentVariant(control, variant ExperimentVariantResult) *ExperimentComparison {
+	comparison := &ExperimentComparison{
+		Conversion:              compareProportions(control.Leads, control.Visitors, variant.Leads, variant.Visitors),
+		Purchase:                compareProportions(control.Purchasers, control.Visitors, variant.Purchasers, variant.Visitors),
+		RevenuePerVisitorPValue: 1,
+		Verdict:                 ExperimentVerdictInsufficientData,
+	}
+
+	if control.Visitors > 0 && variant.Visitors > 0 {
+		controlRate := float64(control.Leads) / float64(control.Visitors)
+		variantRate := float64(variant.Leads) / float64(variant.Visitors)
+		diff := variantRate - controlRate
+		se := math.Sqrt(controlRate*(1-controlRate)/float64(control.Visitors) + variantRate*(1-variantRate)/float64(variant.Visitors))
+		comparison.ConversionDifference = &ConfidenceInterval{
+			Lower: math.Round((diff-experimentZAlpha*se)*10000) / 100,
+			Upper: math.Round((diff+experimentZAlpha*se)*10000) / 100,
+		}
+
+		controlMean, controlVariance := revenueMoments(control.Revenue, control.revenueSquares, control.Visitors)
+		variantMean, variantVariance := revenueMoments(variant.Revenue, variant.revenueSquares, variant.Visitors)
+		if controlMean > 0 {
+			comparison.RevenuePerVisitorLift = math.Round((variantMean-controlMean)/controlMean*10000) / 100
+		}
+		if se := math.Sqrt(controlVariance/float64(control.Visitors) + variantVariance/float64(variant.Visitors)); se > 0 {
+			comparison.RevenuePerVisitorPValue = math.Round(math.Erfc(math.Abs(variantMean-controlMean)/se/math.Sqrt2)*10000) / 10000
+		}
+	}
+
+	if comparison.Conversion == nil ||
+		control.Visitors < experimentMinVisitors || variant.Visitors < experimentMinVisitors ||
+		control.Leads+variant.Leads < experimentMinLeads {
+		return comparison
+	}
+	switch {
+	case !comparison.Conversion.Significant:
+		comparison.Verdict = ExperimentVerdictInconclusive
+	case comparison.Conversion.ZScore > 0:
+		comparison.Verdict = ExperimentVerdictWinner
+	default:
+		comparison.Verdict = ExperimentVerdictLoser
+	}
+	return comparison
+}
+
+// experimentVerdict resume as comparações: vence a variante significativamente melhor com maior conversão;
+// o controle vence quando todas as variantes perdem significativamente
+func experimentVerdict(variants []ExperimentVariantResult) (string, int64) {
+	var winnerID int64
+	winnerRate := -1.0
+	compared, losers, insufficient := 0, 0, 0
+	for _, variant := range variants {
+		if variant.ComparedToControl == nil {
+			continue
+		}
+		compared++
+		switch variant.ComparedToControl.Verdict {
+		case ExperimentVerdictWinner:
+			if variant.ConversionRate > winnerRate {
+				winnerID, winnerRate = variant.VariantID, variant.ConversionRate
+			}
+		case ExperimentVerdictLoser:
+			losers++
+		case ExperimentVerdictInsufficientData:
+			insufficient++
+		}
+	}
+
+	switch {
+	case winnerID != 0:
+		return ExperimentVerdictWinner, winnerID
+	case compared > 0 && losers == compared:
+		return ExperimentVerdictControl, 0
+	case compared == 0 || insufficient == compared:
+		return ExperimentVerdictInsufficientData, 0
+	default:
+		return ExperimentVerdictInconclusive, 0
+	}
+}
+
+// experimentPower calcula a amostra necessária por variante para detectar o efeito mínimo sobre a conversão
+// do controle, o poder atual com a menor variante e a estimativa de dias restantes pelo tráfego observado
+func experimentPower(experiment *entities.Experiment, variants []ExperimentVariantResult, control ExperimentVariantResult, mde float64, now time.Time) ExperimentPower {
+	power := ExperimentPower{
+		MinimumDetectableEffect: math.Round(mde*10000) / 100,
+		BaselineConversionRate:  control.ConversionRate,
+		TargetPower:             experimentTargetPower,
+	}
+
+	smallest := int64(-1)
+	for _, variant := range variants {
+		if smallest < 0 || variant.Visitors < smallest {
+			smallest = variant.Visitors
+		}
+	}
+	if control.Visitors == 0 || control.Leads == 0 || smallest < 0 {
+		return power
+	}
+
+	p1 := float64(control.Leads) / float64(control.Visitors)
+	p2 := math.Min(p1*(1+mde), 0.9999)
+	delta := p2 - p1
+	pooled := (p1 + p2) / 2
+	nullSD := math.Sqrt(2 * pooled * (1 - pooled))
+	altSD := math.Sqrt(p1*(1-p1) + p2*(1-p2))
+	if delta <= 0 {
+		return power
+	}
+
+	required := math.Pow(experimentZAlpha*nullSD+experimentZBeta*altSD, 2) / (delta * delta)
+	power.RequiredVisitorsPerVariant = int64(math.Ceil(required))
+	power.Progress = math.Round(math.Min(float64(smallest)/required, 1)*10000) / 100
+	if smallest > 0 {
+		z := (delta*math.Sqrt(float64(smallest)) - experimentZAlpha*nullSD) / altSD
+		power.CurrentPower = math.Round(normalCDF(z)*10000) / 10000
+	}
+
+	remaining := power.RequiredVisitorsPerVariant - smallest
+	if remaining <= 0 {
+		zero := 0
+		power.EstimatedDaysRemaining = &zero
+		power.ReachableByEndDate = true
+		return power
+	}
+
+	// Tráfego diário da menor variante desde o início do experimento
+	elapsedUntil := now
+	if experiment.EndDate.Before(elapsedUntil) {
+		elapsedUntil = experiment.EndDate
+	}
+	elapsedDays := elapsedUntil.Sub(experiment.StartDate).Hours() / 24
+	if elapsedDays <= 0 || smallest == 0 {
+		return power
+	}
+	dailyRate := float64(smallest) / math.Max(elapsedDays, 1)
+	days := int(math.Ceil(float64(remaining) / dailyRate))
+	power.EstimatedDaysRemaining = &days
+	power.ReachableByEndDate = now.AddDate(0, 0, days).Before(experiment.EndDate)
+	return power
+}
+
+// wilsonInterval calcula o intervalo de Wilson de 95% de uma proporção, em percentual
+func wilsonInterval(successes, total int64) ConfidenceInterval {
+	if total == 0 {
+		return ConfidenceInterval{}
+	}
+	n := float64(total)
+	p := float64(successes) / n
+	z2 := experimentZAlpha * experimentZAlpha
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	margin := experimentZAlpha * math.Sqrt(p*(1-p)/n+z2/(4*n*n)) / (1 + z2/n)
+	return ConfidenceInterval{
+		Lower: math.Round(math.Max(center-margin, 0)*10000) / 100,
+		Upper: math.Round(math.Min(center+margin, 1)*10000) / 100,
+	}
+}
+
+// revenuePerVisitor calcula a receita média por visitante e seu intervalo de 95% pela aproximação normal
+func revenuePerVisitor(revenue, revenueSquares float64, visitors int64) (float64, ConfidenceInterval) {
+	mean, variance := revenueMoments(revenue, revenueSquares, visitors)
+	margin := experimentZAlpha * math.Sqrt(variance/float64(visitors))
+	return mean, ConfidenceInterval{
+		Lower: math.Round(math.Max(mean-margin, 0)*100) / 100,
+		Upper: math.Round((mean+margin)*100) / 100,
+	}
+}
+
+// revenueMoments retorna a média e a variância amostral da receita por visitante
+// (visitantes sem compra contam com receita zero)
+func revenueMoments(revenue, revenueSquares float64, visitors int64) (float64, float64) {
+	if visitors == 0 {
+		return 0, 0
+	}
+	n := float64(visitors)
+	mean := revenue / n
+	if visitors < 2 {
+		return mean, 0
+	}
+	variance := (revenueSquares - n*mean*mean) / (n - 1)
+	return mean, math.Max(variance, 0)
+}
+
+// normalCDF é a função de distribuição acumulada da normal padrão
+func normalCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// apply valida e aplica os campos informados no experimento
+func (input ExperimentInput) apply(experiment *entities.Experiment) error {
+	if input.Name != nil {
+		experiment.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.FunnelID != nil {
+		experiment.FunnelID = *input.FunnelID
+	}
+	if input.StartDate != nil {
+		experiment.StartDate = *input.StartDate
+	}
+	if input.EndDate != nil {
+		experiment.EndDate = *input.EndDate
+	}
+	if input.ConversionWindowDays != nil {
+		experiment.ConversionWindowDays = *input.ConversionWindowDays
+	}
+	if input.Notes != nil {
+		experiment.Notes = strings.TrimSpace(*input.Notes)
+	}
+
+	if experiment.Name == "" {
+		return fmt.Errorf("%w: name é obrigatório", ErrInvalidExperiment)
+	}
+	if experiment.FunnelID <= 0 {
+		return fmt.Errorf("%w: funnel_id é obrigatório", ErrInvalidExperiment)
+	}
+	if experiment.StartDate.IsZero() || experiment.EndDate.IsZero() || !experiment.StartDate.Before(experiment.EndDate) {
+		return fmt.Errorf("%w: start_date e end_date são obrigatórios e start_date deve ser anterior a end_date", ErrInvalidExperiment)
+	}
+	if experiment.ConversionWindowDays < 0 {
+		return fmt.Errorf("%w: conversion_window_days não pode ser negativo", ErrInvalidExperiment)
+	}
+
+	if input.Variants == nil {
+		return nil
+	}
+	if len(input.Variants) < 2 {
+		return fmt.Errorf("%w: informe ao menos duas variantes", ErrInvalidExperiment)
+	}
+
+	variants := make([]entities.ExperimentVariant, 0, len(input.Variants))
+	seen := make(map[string]bool, len(input.Variants))
+	controls := 0
+	for _, in := range input.Variants {
+		path := normalizeLandingPagePath(in.LandingPagePath)
+		if path == "" {
+			return fmt.Errorf("%w: landing_page_path é obrigatório em todas as variantes", ErrInvalidExperiment)
+		}
+		if seen[path] {
+			return fmt.Errorf("%w: a landing page %s aparece em mais de uma variante", ErrInvalidExperiment, path)
+		}
+		seen[path] = true
+
+		name := strings.TrimSpace(in.Name)
+		if name == "" {
+			name = path
+		}
+		if in.IsControl {
+			controls++
+		}
+		variants = append(variants, entities.ExperimentVariant{
+			ExperimentID:    experiment.ExperimentID,
+			Name:            name,
+			LandingPagePath: path,
+			IsControl:       in.IsControl,
+		})
+	}
+	switch {
+	case controls > 1:
+		return fmt.Errorf("%w: apenas uma variante pode ser o controle", ErrInvalidExperiment)
+	case controls == 0:
+		// A primeira variante é o controle quando nenhuma é indicada
+		variants[0].IsControl = true
+	}
+
+	experiment.Variants = variants
+	return nil
+}
+
+// normalizeLandingPagePath normaliza o caminho da landing page como na atribuição das sessões:
+// minúsculo, sem query string e sem barras finais (exceto na raiz)
+func normalizeLandingPagePath(path string) string {
+	path = strings.ToLower(strings.TrimSpace(path))
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	if trimmed := strings.TrimRight(path, "/"); trimmed != "" {
+		return trimmed
+	}
+	if path != "" {
+		return "/"
+	}
+	return ""
+}
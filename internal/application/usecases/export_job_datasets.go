@@ -0,0 +1,327 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+)
+
+// Datasets das exportações assíncronas
+const (
+	exportDatasetEvents   = "events"
+	exportDatasetSessions = "sessions"
+	exportDatasetLeads    = "leads"
+	exportDatasetClients  = "clients"
+)
+
+// exportDataset descreve um dataset exportável: as colunas (as mesmas da exportação síncrona)
+// e os filtros aceitos, pelo nome JSON em ExportJobFilters
+type exportDataset struct {
+	columns []repositories.ExportColumn
+	filters []string
+}
+
+var exportDatasets = map[string]exportDataset{
+	exportDatasetEvents: {
+		columns: repositories.EventExportColumns,
+		filters: []string{"from", "to", "sortBy", "sortDirection", "segment_id", "profession_ids", "funnel_ids", "advanced_filters", "filter_condition"},
+	},
+	exportDatasetSessions: {
+		columns: repositories.SessionExportColumns,
+		filters: []string{"from", "to", "time_from", "time_to", "sortDirection", "segment_id", "user_id", "profession_id", "product_id", "funnel_id", "landing_page", "is_active"},
+	},
+	exportDatasetLeads: {
+		columns: repositories.LeadExportColumns,
+		filters: []string{"from", "to", "time_from", "time_to", "sortBy", "sortDirection", "segment_id", "min_score", "max_score", "tier"},
+	},
+	exportDatasetClients: {
+		columns: repositories.ClientExportColumns,
+		filters: []string{"from", "to", "time_from", "time_to", "sortBy", "sortDirection"},
+	},
+}
+
+// exportTimeOfDayPattern valida time_from e time_to (HH:MM)
+var exportTimeOfDayPattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// ExportJobFilters são os filtros de uma exportação assíncrona, com os nomes dos parâmetros das listagens.
+// Cada dataset aceita apenas os filtros da sua listagem.
+type ExportJobFilters struct {
+	// Datas RFC3339 ou YYYY-MM-DD (início ou fim do dia em Brasília), resolvidas quando o job é executado
+	From          string `json:"from,omitempty"`
+	To            string `json:"to,omitempty"`
+	TimeFrom      string `json:"time_from,omitempty"`
+	TimeTo        string `json:"time_to,omitempty"`
+	SortBy        string `json:"sortBy,omitempty"`
+	SortDirection string `json:"sortDirection,omitempty"`
+	SegmentID     int64  `json:"segment_id,omitempty"`
+
+	// Eventos. AdvancedFilters é a árvore de grupos ou a lista plana unida por FilterCondition, como em /events.
+	ProfessionIDs   []int           `json:"profession_ids,omitempty"`
+	FunnelIDs       []int           `json:"funnel_ids,omitempty"`
+	AdvancedFilters json.RawMessage `json:"advanced_filters,omitempty"`
+	FilterCondition string          `json:"filter_condition,omitempty"`
+
+	// Sessões
+	UserID       string `json:"user_id,omitempty"`
+	ProfessionID int    `json:"profession_id,omitempty"`
+	ProductID    int    `json:"product_id,omitempty"`
+	FunnelID     int    `json:"funnel_id,omitempty"`
+	LandingPage  string `json:"landing_page,omitempty"`
+	IsActive     *bool  `json:"is_active,omitempty"`
+
+	// Leads
+	MinScore *float64 `json:"min_score,omitempty"`
+	MaxScore *float64 `json:"max_score,omitempty"`
+	Tier     string   `json:"tier,omitempty"`
+}
+
+// exportJobQuery são os filtros de um job resolvidos nos parâmetros das consultas de exportação
+type exportJobQuery struct {
+	from, to time.Time
+	orderBy  string
+	segment  repositories.SegmentFilter
+	filter   *repositories.FilterNode
+}
+
+// ExportDatasetNames lista os datasets exportáveis em ordem alfabética
+func ExportDatasetNames() []string {
+	names := make([]string, 0, len(exportDatasets))
+	for name := range exportDatasets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (uc *exportJobUseCase) ExportColumns(job *entities.ExportJob) ([]repositories.ExportColumn, error) {
+	dataset, ok := exportDatasets[job.Dataset]
+	if !ok {
+		return nil, fmt.Errorf("%w: dataset inválido: %s", ErrInvalidExportJob, job.Dataset)
+	}
+
+	var requested []string
+	for _, column := range strings.Split(job.Columns, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			requested = append(requested, column)
+		}
+	}
+	columns, err := repositories.SelectExportColumns(dataset.columns, requested)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v (disponíveis: %s)", ErrInvalidExportJob, err,
+			strings.Join(repositories.ExportColumnNames(dataset.columns), ", "))
+	}
+	return columns, nil
+}
+
+func (uc *exportJobUseCase) ExportRows(ctx context.Context, job *entities.ExportJob, columns []repositories.ExportColumn, fn repositories.ExportRowFunc) error {
+	filters, err := decodeExportJobFilters(job.Dataset, json.RawMessage(job.Query))
+	if err != nil {
+		return err
+	}
+	query, err := uc.resolveExportJobQuery(job.Dataset, filters, time.Now())
+	if err != nil {
+		return err
+	}
+
+	switch job.Dataset {
+	case exportDatasetEvents:
+		// Como em /events, a exportação de eventos não filtra por horário do dia
+		return uc.eventRepo.ExportEvents(ctx, columns, query.orderBy, query.from, query.to, "", "",
+			filters.ProfessionIDs, filters.FunnelIDs, query.filter, query.segment, fn)
+	case exportDatasetSessions:
+		return uc.sessionRepo.ExportSessions(ctx, columns, query.orderBy, query.from, query.to, filters.TimeFrom, filters.TimeTo,
+			filters.UserID, optionalID(filters.ProfessionID), optionalID(filters.ProductID), optionalID(filters.FunnelID),
+			filters.IsActive, filters.LandingPage, query.segment, fn)
+	case exportDatasetLeads:
+		scoreFilter := repositories.LeadScoreFilter{MinScore: filters.MinScore, MaxScore: filters.MaxScore, Tier: filters.Tier}
+		return uc.userRepo.ExportLeads(ctx, columns, filters.SortBy, filters.SortDirection, query.from, query.to,
+			filters.TimeFrom, filters.TimeTo, scoreFilter, query.segment, fn)
+	default:
+		return uc.userRepo.ExportClients(ctx, columns, filters.SortBy, filters.SortDirection, query.from, query.to,
+			filters.TimeFrom, filters.TimeTo, fn)
+	}
+}
+
+// decodeExportJobFilters lê os filtros de um job, recusando campos desconhecidos e filtros que o dataset
+// não aceita, e preenche a ordenação padrão da listagem do dataset
+func decodeExportJobFilters(dataset string, raw json.RawMessage) (ExportJobFilters, error) {
+	var filters ExportJobFilters
+	accepted, ok := exportDatasets[dataset]
+	if !ok {
+		return filters, fmt.Errorf("%w: dataset inválido: %q (disponíveis: %s)", ErrInvalidExportJob, dataset, strings.Join(ExportDatasetNames(), ", "))
+	}
+
+	if raw = bytes.TrimSpace(raw); len(raw) > 0 && string(raw) != "null" {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&filters); err != nil {
+			return filters, fmt.Errorf("%w: filters inválido: %v", ErrInvalidExportJob, err)
+		}
+
+		// Os filtros informados são os campos que sobrevivem ao omitempty
+		var present map[string]json.RawMessage
+		encoded, err := json.Marshal(filters)
+		if err == nil {
+			err = json.Unmarshal(encoded, &present)
+		}
+		if err != nil {
+			return filters, fmt.Errorf("%w: filters inválido: %v", ErrInvalidExportJob, err)
+		}
+		for name := range present {
+			if !containsString(accepted.filters, name) {
+				return filters, fmt.Errorf("%w: o filtro %s não é aceito no dataset %s (aceitos: %s)",
+					ErrInvalidExportJob, name, dataset, strings.Join(accepted.filters, ", "))
+			}
+		}
+	}
+
+	filters.SortDirection = strings.ToLower(filters.SortDirection)
+	if filters.SortDirection == "" {
+		filters.SortDirection = "desc"
+	}
+	if filters.SortBy == "" {
+		switch dataset {
+		case exportDatasetEvents:
+			filters.SortBy = "event_time"
+		case exportDatasetLeads, exportDatasetClients:
+			filters.SortBy = "created_at"
+		}
+	}
+	return filters, nil
+}
+
+// resolveExportJobQuery valida os filtros e os converte nos parâmetros das consultas, com as datas relativas a now
+func (uc *exportJobUseCase) resolveExportJobQuery(dataset string, filters ExportJobFilters, now time.Time) (*exportJobQuery, error) {
+	loc := utils.GetBrasilLocation()
+	query := &exportJobQuery{to: now.In(loc)}
+
+	// Como nas listagens: eventos sem from cobrem os últimos 30 dias; os demais datasets, todo o histórico
+	if dataset == exportDatasetEvents {
+		query.from = query.to.AddDate(0, 0, -30)
+	}
+	var err error
+	if filters.From != "" {
+		if query.from, err = parseExportJobDate(filters.From, false, loc); err != nil {
+			return nil, fmt.Errorf("%w: from inválido: use YYYY-MM-DD ou RFC3339", ErrInvalidExportJob)
+		}
+	}
+	if filters.To != "" {
+		if query.to, err = parseExportJobDate(filters.To, true, loc); err != nil {
+			return nil, fmt.Errorf("%w: to inválido: use YYYY-MM-DD ou RFC3339", ErrInvalidExportJob)
+		}
+	}
+	if query.from.After(query.to) {
+		return nil, fmt.Errorf("%w: from deve ser anterior a to", ErrInvalidExportJob)
+	}
+	for _, value := range []string{filters.TimeFrom, filters.TimeTo} {
+		if value != "" && !exportTimeOfDayPattern.MatchString(value) {
+			return nil, fmt.Errorf("%w: time_from e time_to usam o formato HH:MM", ErrInvalidExportJob)
+		}
+	}
+	if filters.SortDirection != "asc" && filters.SortDirection != "desc" {
+		return nil, fmt.Errorf("%w: sortDirection deve ser asc ou desc", ErrInvalidExportJob)
+	}
+
+	if filters.SegmentID != 0 {
+		if query.segment, err = uc.segmentUseCase.ResolveFilter(filters.SegmentID); err != nil {
+			return nil, err
+		}
+	}
+
+	switch dataset {
+	case exportDatasetEvents:
+		column, ok := repositories.EventSortColumns[filters.SortBy]
+		if !ok {
+			return nil, fmt.Errorf("%w: sortBy=%s não é aceito na exportação de eventos", ErrInvalidExportJob, filters.SortBy)
+		}
+		query.orderBy = column + " " + filters.SortDirection
+		if query.filter, err = decodeExportFilterTree(filters.AdvancedFilters, filters.FilterCondition); err != nil {
+			return nil, err
+		}
+	case exportDatasetSessions:
+		query.orderBy = `"sessionStart" ` + strings.ToUpper(filters.SortDirection)
+	case exportDatasetLeads:
+		if query.orderBy, err = repositories.LeadExportOrder(filters.SortBy, filters.SortDirection); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExportJob, err)
+		}
+	case exportDatasetClients:
+		if query.orderBy, err = repositories.ClientExportOrder(filters.SortBy, filters.SortDirection); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExportJob, err)
+		}
+	}
+	return query, nil
+}
+
+// decodeExportFilterTree lê advanced_filters: a lista plana unida por filter_condition ou a árvore de grupos
+func decodeExportFilterTree(raw json.RawMessage, condition string) (*repositories.FilterNode, error) {
+	if raw = bytes.TrimSpace(raw); len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	if condition != "" && condition != repositories.FilterConditionAnd && condition != repositories.FilterConditionOr {
+		return nil, fmt.Errorf("%w: filter_condition deve ser AND ou OR", ErrInvalidExportJob)
+	}
+
+	var filter *repositories.FilterNode
+	if raw[0] == '[' {
+		var flat []repositories.AdvancedFilter
+		if err := json.Unmarshal(raw, &flat); err != nil {
+			return nil, fmt.Errorf("%w: advanced_filters inválido: %v", ErrInvalidExportJob, err)
+		}
+		filter = repositories.NewFlatFilterTree(flat, condition)
+	} else {
+		filter = &repositories.FilterNode{}
+		if err := json.Unmarshal(raw, filter); err != nil {
+			return nil, fmt.Errorf("%w: advanced_filters inválido: %v", ErrInvalidExportJob, err)
+		}
+	}
+	if filter == nil {
+		return nil, nil
+	}
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: advanced_filters inválido: %v", ErrInvalidExportJob, err)
+	}
+	return filter, nil
+}
+
+// parseExportJobDate lê uma data RFC3339 ou YYYY-MM-DD; uma data sem hora é o início do dia em Brasília,
+// ou o fim do dia quando endOfDay
+func parseExportJobDate(value string, endOfDay bool, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(loc), nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return day, nil
+}
+
+// optionalID converte um ID opcional (0 quando ausente) no texto usado pelos filtros de sessão
+func optionalID(id int) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,440 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/infrastructure/exporter"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrExportJobNotFound indica que o job não existe (ou que o token de download não confere)
+	ErrExportJobNotFound = errors.New("job de exportação não encontrado")
+	// ErrInvalidExportJob indica um pedido de exportação inválido
+	ErrInvalidExportJob = errors.New("job de exportação inválido")
+	// ErrExportJobNotReady indica que o arquivo do job ainda não foi gerado
+	ErrExportJobNotReady = errors.New("exportação ainda não concluída")
+	// ErrExportJobExpired indica que o arquivo passou do prazo de retenção
+	ErrExportJobExpired = errors.New("exportação expirada")
+)
+
+const (
+	// Workers de exportação em paralelo por instância
+	defaultExportWorkers = 2
+	// Horas em que o arquivo gerado fica disponível para download
+	defaultExportRetentionHours = 24
+	// Intervalo entre as buscas por jobs na fila
+	defaultExportPollSeconds = 2
+	// Diretório padrão dos arquivos gerados
+	defaultExportDir = "exports"
+
+	// exportJobLease é o prazo de um job em execução; é renovado pelo heartbeat e, se vencer
+	// (worker parado), o job volta a ser executado
+	exportJobLease = 2 * time.Minute
+	// exportJobHeartbeat é o intervalo entre as atualizações de andamento
+	exportJobHeartbeat = 5 * time.Second
+	// exportJobMaxAttempts limita as execuções de um job interrompido
+	exportJobMaxAttempts = 3
+	// exportJobCleanupInterval é o intervalo entre as remoções de arquivos vencidos
+	exportJobCleanupInterval = 10 * time.Minute
+	// exportJobListLimit é o número de jobs retornados na listagem
+	exportJobListLimit = 50
+)
+
+// ExportJobInput representa o pedido de uma exportação assíncrona
+type ExportJobInput struct {
+	Dataset string
+	Format  string
+	// Filters são os filtros em JSON, no formato de ExportJobFilters
+	Filters json.RawMessage
+	Columns []string
+	MaskPII bool
+}
+
+// ExportJobUseCase define as operações da fila de exportações assíncronas
+type ExportJobUseCase interface {
+	// CreateJob valida o dataset, o formato, as colunas e os filtros e enfileira o job
+	CreateJob(input ExportJobInput) (*entities.ExportJob, error)
+	GetJob(jobID uuid.UUID) (*entities.ExportJob, error)
+	ListJobs() ([]entities.ExportJob, error)
+	// GetDownload valida o token de download e retorna o job concluído, com o caminho do arquivo
+	GetDownload(jobID uuid.UUID, token string) (*entities.ExportJob, error)
+	// ExportColumns resolve as colunas do job entre as disponíveis no dataset
+	ExportColumns(job *entities.ExportJob) ([]repositories.ExportColumn, error)
+	// ExportRows percorre as linhas do job direto do cursor do banco, com os filtros resolvidos na execução
+	ExportRows(ctx context.Context, job *entities.ExportJob, columns []repositories.ExportColumn, fn repositories.ExportRowFunc) error
+}
+
+type exportJobUseCase struct {
+	jobRepo        repositories.ExportJobRepository
+	eventRepo      repositories.EventRepository
+	sessionRepo    repositories.ISessionRepository
+	userRepo       repositories.IUserRepository
+	segmentUseCase SegmentUseCase
+}
+
+func NewExportJobUseCase(jobRepo repositories.ExportJobRepository, eventRepo repositories.EventRepository, sessionRepo repositories.ISessionRepository, userRepo repositories.IUserRepository, segmentUseCase SegmentUseCase) ExportJobUseCase {
+	return &exportJobUseCase{
+		jobRepo:        jobRepo,
+		eventRepo:      eventRepo,
+		sessionRepo:    sessionRepo,
+		userRepo:       userRepo,
+		segmentUseCase: segmentUseCase,
+	}
+}
+
+func (uc *exportJobUseCase) CreateJob(input ExportJobInput) (*entities.ExportJob, error) {
+	dataset := strings.ToLower(strings.TrimSpace(input.Dataset))
+	format := strings.ToLower(strings.TrimSpace(input.Format))
+	if !containsString(exporter.Formats, format) {
+		return nil, fmt.Errorf("%w: format deve ser %s", ErrInvalidExportJob, strings.Join(exporter.Formats, ", "))
+	}
+
+	// Valida os filtros e as colunas sem executar a exportação; as datas são resolvidas de novo na execução
+	filters, err := decodeExportJobFilters(dataset, input.Filters)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := uc.resolveExportJobQuery(dataset, filters, time.Now()); err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(filters)
+	if err != nil {
+		return nil, fmt.Errorf("%w: filters inválido: %v", ErrInvalidExportJob, err)
+	}
+
+	// O token é entregue apenas na criação: quem criou o job acompanha o andamento e baixa o arquivo com ele
+	token, err := newDownloadToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &entities.ExportJob{
+		JobID:         uuid.New(),
+		DownloadToken: token,
+		Dataset:       dataset,
+		Format:        format,
+		Query:         string(encoded),
+		Columns:       strings.Join(input.Columns, ","),
+		MaskPII:       input.MaskPII,
+		Status:        entities.ExportJobQueued,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if _, err := uc.ExportColumns(job); err != nil {
+		return nil, err
+	}
+	if err := uc.jobRepo.CreateJob(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (uc *exportJobUseCase) GetJob(jobID uuid.UUID) (*entities.ExportJob, error) {
+	job, err := uc.jobRepo.GetJob(jobID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExportJobNotFound
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+func (uc *exportJobUseCase) ListJobs() ([]entities.ExportJob, error) {
+	return uc.jobRepo.ListJobs(exportJobListLimit)
+}
+
+func (uc *exportJobUseCase) GetDownload(jobID uuid.UUID, token string) (*entities.ExportJob, error) {
+	job, err := uc.GetJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+	if !HasDownloadToken(job, token) {
+		return nil, ErrExportJobNotFound
+	}
+
+	switch {
+	case job.Status == entities.ExportJobExpired,
+		job.Status == entities.ExportJobDone && job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt):
+		return nil, ErrExportJobExpired
+	case job.Status != entities.ExportJobDone:
+		return nil, ErrExportJobNotReady
+	}
+	return job, nil
+}
+
+// HasDownloadToken indica se token é o token de download do job, entregue a quem o criou
+func HasDownloadToken(job *entities.ExportJob, token string) bool {
+	return job.DownloadToken != "" && subtle.ConstantTimeCompare([]byte(job.DownloadToken), []byte(token)) == 1
+}
+
+// ExportJobWorker executa os jobs da fila de exportações, gravando os arquivos em disco local,
+// e remove os arquivos que passaram do prazo de retenção
+type ExportJobWorker struct {
+	jobRepo    repositories.ExportJobRepository
+	jobUseCase ExportJobUseCase
+	dir        string
+	workers    int
+	retention  time.Duration
+	poll       time.Duration
+}
+
+// NewExportJobWorker cria o worker com a configuração das variáveis EXPORT_WORKERS, EXPORT_DIR,
+// EXPORT_RETENTION_HOURS e EXPORT_POLL_SECONDS
+func NewExportJobWorker(jobRepo repositories.ExportJobRepository, jobUseCase ExportJobUseCase) *ExportJobWorker {
+	dir := os.Getenv("EXPORT_DIR")
+	if dir == "" {
+		dir = defaultExportDir
+	}
+	return &ExportJobWorker{
+		jobRepo:    jobRepo,
+		jobUseCase: jobUseCase,
+		dir:        dir,
+		workers:    envInt("EXPORT_WORKERS", defaultExportWorkers),
+		retention:  time.Duration(envInt("EXPORT_RETENTION_HOURS", defaultExportRetentionHours)) * time.Hour,
+		poll:       time.Duration(envInt("EXPORT_POLL_SECONDS", defaultExportPollSeconds)) * time.Second,
+	}
+}
+
+// Run inicia os workers e a limpeza de arquivos vencidos e aguarda até o contexto ser cancelado.
+// Jobs em execução no cancelamento são interrompidos, têm o arquivo parcial removido e voltam à fila.
+func (w *ExportJobWorker) Run(ctx context.Context) {
+	dir, err := filepath.Abs(w.dir)
+	if err == nil {
+		err = os.MkdirAll(dir, 0o755)
+	}
+	if err != nil {
+		log.Printf("⚠️ Worker de exportações desativado: diretório %s indisponível: %v", w.dir, err)
+		return
+	}
+	w.dir = dir
+	log.Printf("📦 Worker de exportações iniciado (%d workers, diretório %s, retenção %v)", w.workers, w.dir, w.retention)
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.work(ctx)
+		}()
+	}
+
+	for {
+		if err := w.CleanupExpired(); err != nil {
+			log.Printf("⚠️ Erro ao remover exportações vencidas: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-time.After(exportJobCleanupInterval):
+		}
+	}
+}
+
+// work executa jobs enquanto houver fila, aguardando poll quando ela está vazia
+func (w *ExportJobWorker) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := w.jobRepo.ClaimJob(exportJobLease, exportJobMaxAttempts)
+		if err != nil {
+			log.Printf("⚠️ Erro ao buscar job de exportação: %v", err)
+		}
+		if job != nil {
+			w.runJob(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(w.poll):
+		}
+	}
+}
+
+// runJob gera o arquivo do job em um arquivo temporário, renomeado ao final, e registra o resultado
+func (w *ExportJobWorker) runJob(ctx context.Context, job *entities.ExportJob) {
+	log.Printf("📤 Exportação %s iniciada (%s, %s, tentativa %d)", job.JobID, job.Dataset, job.Format, job.Attempts)
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	filePath := filepath.Join(w.dir, fmt.Sprintf("%s.%s", job.JobID, job.Format))
+	partPath := filePath + ".part"
+	file, err := os.Create(partPath)
+	if err != nil {
+		w.fail(job, fmt.Errorf("erro ao criar arquivo: %w", err))
+		return
+	}
+
+	var rows, bytes atomic.Int64
+	counter := &countingWriter{w: file, n: &bytes}
+
+	// Heartbeat: registra o andamento e renova o prazo; se o job foi assumido por outra execução, interrompe esta
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		ticker := time.NewTicker(exportJobHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-jobCtx.Done():
+				return
+			case <-ticker.C:
+				owned, err := w.jobRepo.Heartbeat(job.JobID, job.Attempts, rows.Load(), bytes.Load(), exportJobLease)
+				if err != nil {
+					log.Printf("⚠️ Erro no heartbeat da exportação %s: %v", job.JobID, err)
+				} else if !owned {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	total, err := w.render(jobCtx, job, counter, func(n int64) { rows.Store(n) })
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	cancel()
+	<-heartbeatDone
+
+	if err != nil {
+		os.Remove(partPath)
+		if ctx.Err() != nil {
+			// Encerramento do servidor: o job volta à fila para outra instância ou para o próximo início
+			log.Printf("⚠️ Exportação %s interrompida pelo encerramento do worker", job.JobID)
+			if err := w.jobRepo.ReleaseJob(job.JobID, job.Attempts); err != nil {
+				log.Printf("⚠️ %v", err)
+			}
+			return
+		}
+		w.fail(job, err)
+		return
+	}
+	if err := os.Rename(partPath, filePath); err != nil {
+		os.Remove(partPath)
+		w.fail(job, fmt.Errorf("erro ao gravar arquivo: %w", err))
+		return
+	}
+
+	finishedAt := time.Now()
+	fileName := fmt.Sprintf("%s-%s.%s", job.Dataset, finishedAt.In(utils.GetBrasilLocation()).Format("20060102-150405"), job.Format)
+	if err := w.jobRepo.CompleteJob(job.JobID, job.Attempts, total, bytes.Load(), filePath, fileName, finishedAt.Add(w.retention)); err != nil {
+		os.Remove(filePath)
+		log.Printf("⚠️ Erro ao concluir a exportação %s: %v", job.JobID, err)
+		return
+	}
+	log.Printf("✅ Exportação %s concluída: %d linhas, %d bytes", job.JobID, total, bytes.Load())
+}
+
+// render escreve em out o arquivo do job, com as colunas, os filtros e o formato guardados, no mesmo formato
+// das exportações síncronas. progress recebe o número de linhas escritas a cada lote. Retorna o total de linhas.
+func (w *ExportJobWorker) render(ctx context.Context, job *entities.ExportJob, out io.Writer, progress func(rows int64)) (int64, error) {
+	columns, err := w.jobUseCase.ExportColumns(job)
+	if err != nil {
+		return 0, err
+	}
+	return exporter.Write(ctx, out, job.Format, columns, job.MaskPII, func(ctx context.Context, fn repositories.ExportRowFunc) error {
+		return w.jobUseCase.ExportRows(ctx, job, columns, fn)
+	}, func(n int64) error {
+		progress(n)
+		return nil
+	})
+}
+
+func (w *ExportJobWorker) fail(job *entities.ExportJob, cause error) {
+	log.Printf("⚠️ Exportação %s falhou: %v", job.JobID, cause)
+	if err := w.jobRepo.FailJob(job.JobID, job.Attempts, cause.Error()); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+}
+
+// CleanupExpired marca como expirados os jobs que passaram do prazo de retenção, removendo seus arquivos, e
+// remove os arquivos parciais (.part) órfãos: de jobs que não existem mais ou que não estão em execução com
+// o prazo (lease) em dia. Os demais arquivos do diretório não são tocados.
+func (w *ExportJobWorker) CleanupExpired() error {
+	now := time.Now()
+	jobs, err := w.jobRepo.ListExpiredJobs(now)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if err := os.Remove(job.FilePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("erro ao remover arquivo da exportação %s: %w", job.JobID, err)
+		}
+		if err := w.jobRepo.MarkExpired(job.JobID); err != nil {
+			return err
+		}
+	}
+
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return fmt.Errorf("erro ao listar diretório de exportações: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".part") {
+			continue
+		}
+		// Um arquivo alterado dentro do prazo pode ser de um job recém-assumido por outro worker
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < exportJobLease {
+			continue
+		}
+		jobID, err := uuid.Parse(strings.SplitN(name, ".", 2)[0])
+		if err != nil {
+			continue
+		}
+		job, err := w.jobRepo.GetJob(jobID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if job != nil && job.Status == entities.ExportJobRunning && job.LockedUntil != nil && job.LockedUntil.After(now) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(w.dir, name)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("erro ao remover arquivo parcial de exportação %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// newDownloadToken gera o token aleatório exigido no link de download
+func newDownloadToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("erro ao gerar token de download: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// countingWriter conta os bytes escritos, para o andamento do job
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,116 @@
+package usecases
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeExportJobRepository guarda os jobs em memória; só GetJob, ListExpiredJobs e MarkExpired são usados
+type fakeExportJobRepository struct {
+	repositories.ExportJobRepository
+	jobs    map[uuid.UUID]*entities.ExportJob
+	expired []uuid.UUID
+}
+
+func (r *fakeExportJobRepository) GetJob(jobID uuid.UUID) (*entities.ExportJob, error) {
+	job, ok := r.jobs[jobID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	clone := *job
+	return &clone, nil
+}
+
+func (r *fakeExportJobRepository) ListExpiredJobs(now time.Time) ([]entities.ExportJob, error) {
+	var jobs []entities.ExportJob
+	for _, job := range r.jobs {
+		if job.Status == entities.ExportJobDone && job.ExpiresAt != nil && job.ExpiresAt.Before(now) {
+			jobs = append(jobs, *job)
+		}
+	}
+	return jobs, nil
+}
+
+func (r *fakeExportJobRepository) MarkExpired(jobID uuid.UUID) error {
+	r.expired = append(r.expired, jobID)
+	r.jobs[jobID].Status = entities.ExportJobExpired
+	return nil
+}
+
+func TestExportJobWorkerCleanupExpired(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	repo := &fakeExportJobRepository{jobs: map[uuid.UUID]*entities.ExportJob{}}
+
+	// Os nomes seguem o padrão do worker: <job_id>.<formato> e <job_id>.<formato>.part
+	write := func(name string, modTime time.Time) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	job := func(status string, lockedUntil, expiresAt *time.Time) uuid.UUID {
+		id := uuid.New()
+		repo.jobs[id] = &entities.ExportJob{JobID: id, Status: status, LockedUntil: lockedUntil, ExpiresAt: expiresAt}
+		return id
+	}
+
+	expiredID := job(entities.ExportJobDone, nil, &past)
+	repo.jobs[expiredID].FilePath = write(expiredID.String()+".csv", old)
+	extendedID := job(entities.ExportJobDone, nil, &future)
+	repo.jobs[extendedID].FilePath = write(extendedID.String()+".csv", old)
+	runningID := job(entities.ExportJobRunning, &future, nil)
+	write(runningID.String()+".csv.part", old)
+	staleID := job(entities.ExportJobRunning, &past, nil)
+	write(staleID.String()+".csv.part", old)
+	failedID := job(entities.ExportJobFailed, nil, nil)
+	write(failedID.String()+".csv.part", old)
+	write(uuid.New().String()+".csv.part", old)
+	reclaimedID := job(entities.ExportJobRunning, &past, nil)
+	write(reclaimedID.String()+".csv.part", now)
+	write("notas.txt", old)
+
+	worker := &ExportJobWorker{jobRepo: repo, dir: dir, retention: 24 * time.Hour}
+	if err := worker.CleanupExpired(); err != nil {
+		t.Fatalf("CleanupExpired: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Name())
+	}
+	want := []string{
+		extendedID.String() + ".csv",
+		runningID.String() + ".csv.part",
+		reclaimedID.String() + ".csv.part",
+		"notas.txt",
+	}
+	sort.Strings(got)
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("arquivos restantes = %v; esperado %v", got, want)
+	}
+	if len(repo.expired) != 1 || repo.expired[0] != expiredID {
+		t.Errorf("jobs expirados = %v; esperado [%s]", repo.expired, expiredID)
+	}
+}
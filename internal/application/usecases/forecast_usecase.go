@@ -0,0 +1,314 @@
+package usecases
+
+import (
+	"math"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+)
+
+// Métricas projetadas em cada ciclo
+const (
+	forecastMetricLeads           = "leads"
+	forecastMetricSurveyResponses = "survey_responses"
+	forecastMetricPurchases       = "purchases"
+	forecastMetricRevenue         = "revenue"
+)
+
+// Janela usada para calcular o ritmo horário recente
+const forecastPaceWindowHours = 24.0
+
+// ForecastMetric representa a projeção de uma métrica para o fechamento do ciclo
+type ForecastMetric struct {
+	Current    float64 `json:"current"`
+	Forecast   float64 `json:"forecast"`
+	Lower      float64 `json:"lower"`
+	Upper      float64 `json:"upper"`
+	HourlyPace float64 `json:"hourly_pace"`
+	Method     string  `json:"method"`
+	Samples    int     `json:"samples"`
+}
+
+// ForecastCycleSummary representa os totais finais de um ciclo passado usado como referência
+type ForecastCycleSummary struct {
+	VendaInicio     time.Time `json:"venda_inicio"`
+	Leads           int64     `json:"leads"`
+	SurveyResponses int64     `json:"survey_responses"`
+	Purchases       int64     `json:"purchases"`
+	Revenue         float64   `json:"revenue"`
+}
+
+// CycleForecast representa a projeção completa de um ciclo de webinar em andamento
+type CycleForecast struct {
+	ProfessionID    int                     `json:"profession_id"`
+	FunnelID        int                     `json:"funnel_id,omitempty"`
+	Cycle           utils.WebinarCycleDates `json:"cycle"`
+	GeneratedAt     time.Time               `json:"generated_at"`
+	ElapsedHours    float64                 `json:"elapsed_hours"`
+	TotalHours      int                     `json:"total_hours"`
+	ConfidenceLevel float64                 `json:"confidence_level"`
+	Leads           ForecastMetric          `json:"leads"`
+	SurveyResponses ForecastMetric          `json:"survey_responses"`
+	Purchases       ForecastMetric          `json:"purchases"`
+	Revenue         ForecastMetric          `json:"revenue"`
+	History         []ForecastCycleSummary  `json:"history"`
+}
+
+// ForecastUseCase interface para projeções de ciclos de webinar
+type ForecastUseCase interface {
+	ForecastCycle(cycle utils.WebinarCycleDates, professionID, funnelID, historyCycles int, confidence float64) (*CycleForecast, error)
+}
+
+type forecastUseCase struct {
+	forecastRepo repositories.ForecastRepository
+}
+
+func NewForecastUseCase(forecastRepo repositories.ForecastRepository) ForecastUseCase {
+	return &forecastUseCase{
+		forecastRepo: forecastRepo,
+	}
+}
+
+// cycleCurve guarda os valores por hora (desde pesquisa_inicio) de cada métrica de um ciclo
+type cycleCurve map[string][]float64
+
+func (c cycleCurve) total(metric string) float64 {
+	var sum float64
+	for _, v := range c[metric] {
+		sum += v
+	}
+	return sum
+}
+
+// cumulativeAt retorna o acumulado da métrica até a hora (fracionária) informada,
+// interpolando linearmente dentro da hora corrente
+func (c cycleCurve) cumulativeAt(metric string, hour float64) float64 {
+	series := c[metric]
+	if hour <= 0 {
+		return 0
+	}
+
+	var sum float64
+	full := int(hour)
+	for i := 0; i < full && i < len(series); i++ {
+		sum += series[i]
+	}
+	if full < len(series) {
+		sum += series[full] * (hour - float64(full))
+	}
+	return sum
+}
+
+// ForecastCycle projeta leads, respostas de pesquisa e vendas do ciclo informado
+// a partir das curvas acumuladas dos ciclos anteriores da mesma profissão
+func (uc *forecastUseCase) ForecastCycle(cycle utils.WebinarCycleDates, professionID, funnelID, historyCycles int, confidence float64) (*CycleForecast, error) {
+	totalHours := int(math.Ceil(cycle.VendaFim.Sub(cycle.PesquisaInicio).Hours()))
+	captureHours := cycle.PesquisaFim.Sub(cycle.PesquisaInicio).Hours()
+
+	now := time.Now().In(cycle.PesquisaInicio.Location())
+	elapsed := math.Max(0, math.Min(now.Sub(cycle.PesquisaInicio).Hours(), float64(totalHours)))
+
+	current, err := loadCycleCurve(uc.forecastRepo, cycle, professionID, funnelID, totalHours)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ciclos anteriores sem nenhum lead (semanas sem webinar) não servem de referência
+	history := make([]cycleCurve, 0, historyCycles)
+	summaries := make([]ForecastCycleSummary, 0, historyCycles)
+	past := cycle
+	for i := 0; i < historyCycles; i++ {
+		past = past.PreviousCycle()
+		curve, err := loadCycleCurve(uc.forecastRepo, past, professionID, funnelID, totalHours)
+		if err != nil {
+			return nil, err
+		}
+		if curve.total(forecastMetricLeads) == 0 {
+			continue
+		}
+		history = append(history, curve)
+		summaries = append(summaries, ForecastCycleSummary{
+			VendaInicio:     past.VendaInicio,
+			Leads:           int64(curve.total(forecastMetricLeads)),
+			SurveyResponses: int64(curve.total(forecastMetricSurveyResponses)),
+			Purchases:       int64(curve.total(forecastMetricPurchases)),
+			Revenue:         curve.total(forecastMetricRevenue),
+		})
+	}
+
+	z := math.Sqrt2 * math.Erfinv(confidence)
+
+	result := &CycleForecast{
+		ProfessionID:    professionID,
+		FunnelID:        funnelID,
+		Cycle:           cycle,
+		GeneratedAt:     now,
+		ElapsedHours:    math.Round(elapsed*100) / 100,
+		TotalHours:      totalHours,
+		ConfidenceLevel: confidence,
+		History:         summaries,
+	}
+
+	result.Leads = projectMetric(forecastMetricLeads, current, history, elapsed, captureHours, z, nil)
+	result.SurveyResponses = projectMetric(forecastMetricSurveyResponses, current, history, elapsed, captureHours, z, &result.Leads)
+	result.Purchases = projectMetric(forecastMetricPurchases, current, history, elapsed, float64(totalHours), z, &result.Leads)
+	result.Revenue = projectMetric(forecastMetricRevenue, current, history, elapsed, float64(totalHours), z, &result.Leads)
+
+	return result, nil
+}
+
+// loadCycleCurve busca os eventos do ciclo e monta as séries horárias de cada métrica
+func loadCycleCurve(forecastRepo repositories.ForecastRepository, cycle utils.WebinarCycleDates, professionID, funnelID, totalHours int) (cycleCurve, error) {
+	rows, err := forecastRepo.GetCycleHourlyCounts(cycle, professionID, funnelID)
+	if err != nil {
+		return nil, err
+	}
+
+	curve := cycleCurve{
+		forecastMetricLeads:           make([]float64, totalHours),
+		forecastMetricSurveyResponses: make([]float64, totalHours),
+		forecastMetricPurchases:       make([]float64, totalHours),
+		forecastMetricRevenue:         make([]float64, totalHours),
+	}
+
+	for _, row := range rows {
+		if row.Hour < 0 || row.Hour >= totalHours {
+			continue
+		}
+		switch row.EventType {
+		case "LEAD":
+			curve[forecastMetricLeads][row.Hour] += float64(row.Count)
+		case "PESQUISA_LEAD":
+			curve[forecastMetricSurveyResponses][row.Hour] += float64(row.Count)
+		case "PURCHASE":
+			curve[forecastMetricPurchases][row.Hour] += float64(row.Count)
+			curve[forecastMetricRevenue][row.Hour] += row.Revenue
+		}
+	}
+
+	return curve, nil
+}
+
+// projectMetric calcula a projeção de uma métrica.
+//
+// Para cada ciclo passado são combinadas duas estimativas: a proporção do total final que
+// aquele ciclo já tinha atingido na mesma hora (curva) e o ritmo das últimas 24h comparado ao
+// ritmo do ciclo passado no mesmo ponto (pace). Quando a métrica ainda não começou a acumular
+// (ex.: faturamento antes da janela de vendas), usa a taxa de conversão histórica sobre a
+// projeção de leads.
+func projectMetric(metric string, current cycleCurve, history []cycleCurve, elapsed, windowEnd, z float64, leads *ForecastMetric) ForecastMetric {
+	cur := current.total(metric)
+	paceWindow := math.Min(forecastPaceWindowHours, elapsed)
+
+	result := ForecastMetric{
+		Current:  cur,
+		Forecast: cur,
+		Lower:    cur,
+		Upper:    cur,
+		Method:   "actual",
+	}
+	if paceWindow > 0 {
+		result.HourlyPace = (cur - current.cumulativeAt(metric, elapsed-paceWindow)) / paceWindow
+	}
+
+	// Janela da métrica já encerrada: o valor atual é o final
+	if elapsed >= windowEnd {
+		return roundForecast(result)
+	}
+
+	var samples []float64
+	method := "curve"
+
+	if cur > 0 {
+		for _, past := range history {
+			reached := past.cumulativeAt(metric, elapsed)
+			final := past.total(metric)
+			if reached <= 0 {
+				continue
+			}
+
+			estimate := cur * final / reached
+			if paceWindow > 0 {
+				pastPace := (reached - past.cumulativeAt(metric, elapsed-paceWindow)) / paceWindow
+				if pastPace > 0 {
+					paceEstimate := cur + result.HourlyPace*(final-reached)/pastPace
+					estimate = (estimate + paceEstimate) / 2
+				}
+			}
+			samples = append(samples, estimate)
+		}
+	}
+
+	if len(samples) == 0 && leads != nil && leads.Forecast > 0 {
+		method = "conversion"
+		for _, past := range history {
+			pastLeads := past.total(forecastMetricLeads)
+			if pastLeads > 0 {
+				samples = append(samples, leads.Forecast*past.total(metric)/pastLeads)
+			}
+		}
+	}
+
+	if len(samples) == 0 {
+		// Sem histórico: extrapolar o ritmo atual até o fim da janela
+		result.Method = "pace"
+		result.Forecast = cur + result.HourlyPace*(windowEnd-elapsed)
+		result.Lower = cur
+		result.Upper = result.Forecast
+		return roundForecast(result)
+	}
+
+	mean, stdDev := meanStdDev(samples)
+	margin := 0.0
+	if len(samples) > 1 {
+		// Intervalo de predição para um novo ciclo
+		margin = z * stdDev * math.Sqrt(1+1/float64(len(samples)))
+	}
+	if method == "conversion" && leads.Forecast > 0 {
+		// Propagar a incerteza relativa da projeção de leads
+		leadsMargin := mean * (leads.Upper - leads.Forecast) / leads.Forecast
+		margin = math.Sqrt(margin*margin + leadsMargin*leadsMargin)
+	}
+
+	result.Method = method
+	result.Samples = len(samples)
+	result.Forecast = math.Max(cur, mean)
+	result.Lower = math.Max(cur, mean-margin)
+	result.Upper = math.Max(result.Forecast, mean+margin)
+
+	return roundForecast(result)
+}
+
+// meanStdDev calcula média e desvio padrão amostral
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	if len(values) < 2 {
+		return mean, 0
+	}
+
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)-1))
+}
+
+func roundForecast(m ForecastMetric) ForecastMetric {
+	round := func(v float64) float64 { return math.Round(v*100) / 100 }
+	m.Current = round(m.Current)
+	m.Forecast = round(m.Forecast)
+	m.Lower = round(m.Lower)
+	m.Upper = round(m.Upper)
+	m.HourlyPace = round(m.HourlyPace)
+	return m
+}
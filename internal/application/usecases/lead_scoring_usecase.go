@@ -0,0 +1,388 @@
+package usecases
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrLeadScoringRulesNotFound indica que a versão de regras solicitada não existe
+	ErrLeadScoringRulesNotFound = errors.New("versão de regras de pontuação não encontrada")
+	// ErrNoActiveLeadScoringRules indica que nenhuma versão de regras está ativa
+	ErrNoActiveLeadScoringRules = errors.New("nenhuma versão de regras de pontuação ativa")
+	// ErrLeadScoreNotFound indica que o lead ainda não foi pontuado na versão ativa
+	ErrLeadScoreNotFound = errors.New("pontuação do lead não encontrada")
+)
+
+// Quantidade de leads processados por lote no recálculo
+const leadScoringBatchSize = 1000
+
+// LeadScoringRules representa as regras de pontuação: respostas da pesquisa, sinais de comportamento e faixas (tiers)
+type LeadScoringRules struct {
+	Survey   LeadSurveyRules   `json:"survey"`
+	Behavior LeadBehaviorRules `json:"behavior"`
+	// Tiers são avaliados da maior para a menor pontuação mínima
+	Tiers []LeadScoreTier `json:"tiers"`
+}
+
+// LeadSurveyRules pondera o SurveyAnswer.Score de cada pergunta.
+// Perguntas sem peso definido usam DefaultWeight.
+type LeadSurveyRules struct {
+	DefaultWeight   float64            `json:"default_weight"`
+	QuestionWeights map[string]float64 `json:"question_weights,omitempty"`
+}
+
+// LeadSignalRule atribui Points por unidade do sinal, limitado a Max (0 = sem limite)
+type LeadSignalRule struct {
+	Points float64 `json:"points"`
+	Max    float64 `json:"max,omitempty"`
+}
+
+// LeadBehaviorRules representa os pontos por sinais de comportamento do lead
+type LeadBehaviorRules struct {
+	SessionCount LeadSignalRule `json:"session_count"`
+	// TimeOnPage é pontuado por minuto somado de todas as sessões
+	TimeOnPage LeadSignalRule `json:"time_on_page"`
+	// ReturningVisits considera as sessões após a primeira
+	ReturningVisits LeadSignalRule `json:"returning_visits"`
+	// UtmSource atribui pontos fixos pela origem inicial do lead (sem diferenciar maiúsculas)
+	UtmSource map[string]float64 `json:"utm_source,omitempty"`
+}
+
+// LeadScoreTier representa uma faixa de classificação do lead
+type LeadScoreTier struct {
+	Name     string  `json:"name"`
+	MinScore float64 `json:"min_score"`
+}
+
+// LeadScoreBreakdown detalha a contribuição de cada componente na pontuação
+type LeadScoreBreakdown struct {
+	Survey          float64 `json:"survey"`
+	SessionCount    float64 `json:"session_count"`
+	TimeOnPage      float64 `json:"time_on_page"`
+	ReturningVisits float64 `json:"returning_visits"`
+	UtmSource       float64 `json:"utm_source"`
+}
+
+// LeadScoringRuleSetInput representa os dados de criação de uma nova versão de regras
+type LeadScoringRuleSetInput struct {
+	Name     string           `json:"name"`
+	Rules    LeadScoringRules `json:"rules"`
+	Activate bool             `json:"activate"`
+}
+
+// LeadScoreRecomputeResult representa o resultado de um recálculo de pontuações
+type LeadScoreRecomputeResult struct {
+	RuleVersion int64            `json:"rule_version"`
+	Scored      int              `json:"scored"`
+	Removed     int64            `json:"removed"`
+	Tiers       map[string]int   `json:"tiers"`
+	StartedAt   time.Time        `json:"started_at"`
+	FinishedAt  time.Time        `json:"finished_at"`
+	Rules       LeadScoringRules `json:"rules"`
+}
+
+// LeadScoringUseCase interface para casos de uso de pontuação de leads
+type LeadScoringUseCase interface {
+	ListRuleSets() ([]entities.LeadScoringRuleSet, error)
+	GetRuleSet(version int64) (*entities.LeadScoringRuleSet, error)
+	CreateRuleSet(input LeadScoringRuleSetInput) (*entities.LeadScoringRuleSet, error)
+	ActivateRuleSet(version int64) (*entities.LeadScoringRuleSet, error)
+
+	// RecomputeScores recalcula a pontuação de todos os leads. version 0 usa a versão ativa.
+	RecomputeScores(version int64) (*LeadScoreRecomputeResult, error)
+	// GetLeadScore retorna a pontuação do lead na versão ativa
+	GetLeadScore(userID string) (*entities.LeadScore, error)
+}
+
+type leadScoringUseCase struct {
+	leadScoreRepo repositories.LeadScoreRepository
+}
+
+func NewLeadScoringUseCase(leadScoreRepo repositories.LeadScoreRepository) LeadScoringUseCase {
+	return &leadScoringUseCase{
+		leadScoreRepo: leadScoreRepo,
+	}
+}
+
+func (uc *leadScoringUseCase) ListRuleSets() ([]entities.LeadScoringRuleSet, error) {
+	return uc.leadScoreRepo.ListRuleSets()
+}
+
+func (uc *leadScoringUseCase) GetRuleSet(version int64) (*entities.LeadScoringRuleSet, error) {
+	ruleSet, err := uc.leadScoreRepo.GetRuleSet(version)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLeadScoringRulesNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar regras de pontuação: %w", err)
+	}
+	return ruleSet, nil
+}
+
+func (uc *leadScoringUseCase) CreateRuleSet(input LeadScoringRuleSetInput) (*entities.LeadScoringRuleSet, error) {
+	if err := input.Rules.Validate(); err != nil {
+		return nil, err
+	}
+
+	rules, err := json.Marshal(input.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar regras: %w", err)
+	}
+
+	ruleSet := &entities.LeadScoringRuleSet{
+		Name:     strings.TrimSpace(input.Name),
+		IsActive: input.Activate,
+		Rules:    rules,
+	}
+	if err := uc.leadScoreRepo.CreateRuleSet(ruleSet); err != nil {
+		return nil, err
+	}
+	return ruleSet, nil
+}
+
+func (uc *leadScoringUseCase) ActivateRuleSet(version int64) (*entities.LeadScoringRuleSet, error) {
+	err := uc.leadScoreRepo.ActivateRuleSet(version)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLeadScoringRulesNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return uc.GetRuleSet(version)
+}
+
+func (uc *leadScoringUseCase) RecomputeScores(version int64) (*LeadScoreRecomputeResult, error) {
+	ruleSet, err := uc.resolveRuleSet(version)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules LeadScoringRules
+	if err := json.Unmarshal(ruleSet.Rules, &rules); err != nil {
+		return nil, fmt.Errorf("regras da versão %d inválidas: %w", ruleSet.Version, err)
+	}
+	rules.sortTiers()
+
+	result := &LeadScoreRecomputeResult{
+		RuleVersion: ruleSet.Version,
+		Tiers:       make(map[string]int, len(rules.Tiers)),
+		StartedAt:   time.Now(),
+		Rules:       rules,
+	}
+
+	// Percorre os leads em lotes ordenados por user_id para não carregar a base inteira em memória
+	cursor := ""
+	for {
+		features, err := uc.leadScoreRepo.GetLeadFeatures(cursor, leadScoringBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(features) == 0 {
+			break
+		}
+
+		computedAt := time.Now()
+		scores := make([]entities.LeadScore, 0, len(features))
+		for _, f := range features {
+			breakdown := rules.Score(f)
+			score := breakdown.Total()
+			tier := rules.TierFor(score)
+
+			raw, err := json.Marshal(breakdown)
+			if err != nil {
+				return nil, fmt.Errorf("erro ao serializar detalhamento da pontuação: %w", err)
+			}
+
+			scores = append(scores, entities.LeadScore{
+				UserID:      f.UserID,
+				RuleVersion: ruleSet.Version,
+				Score:       score,
+				Tier:        tier,
+				Breakdown:   raw,
+				ComputedAt:  computedAt,
+			})
+			result.Tiers[tier]++
+		}
+
+		if err := uc.leadScoreRepo.SaveLeadScores(scores); err != nil {
+			return nil, err
+		}
+
+		result.Scored += len(scores)
+		cursor = features[len(features)-1].UserID
+		if len(features) < leadScoringBatchSize {
+			break
+		}
+	}
+
+	// Leads que deixaram de ser leads (viraram clientes) não são recalculados e saem da versão
+	removed, err := uc.leadScoreRepo.DeleteStaleScores(ruleSet.Version, result.StartedAt)
+	if err != nil {
+		return nil, err
+	}
+	result.Removed = removed
+	result.FinishedAt = time.Now()
+
+	return result, nil
+}
+
+func (uc *leadScoringUseCase) GetLeadScore(userID string) (*entities.LeadScore, error) {
+	ruleSet, err := uc.resolveRuleSet(0)
+	if err != nil {
+		return nil, err
+	}
+
+	score, err := uc.leadScoreRepo.GetLeadScore(userID, ruleSet.Version)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLeadScoreNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar pontuação do lead: %w", err)
+	}
+	return score, nil
+}
+
+func (uc *leadScoringUseCase) resolveRuleSet(version int64) (*entities.LeadScoringRuleSet, error) {
+	if version > 0 {
+		return uc.GetRuleSet(version)
+	}
+
+	ruleSet, err := uc.leadScoreRepo.GetActiveRuleSet()
+	if err != nil {
+		return nil, err
+	}
+	if ruleSet == nil {
+		return nil, ErrNoActiveLeadScoringRules
+	}
+	return ruleSet, nil
+}
+
+// Validate verifica se as regras podem ser aplicadas
+func (r *LeadScoringRules) Validate() error {
+	if len(r.Tiers) == 0 {
+		return fmt.Errorf("é necessário definir ao menos um tier")
+	}
+
+	seen := make(map[string]bool, len(r.Tiers))
+	for _, tier := range r.Tiers {
+		name := strings.TrimSpace(tier.Name)
+		if name == "" {
+			return fmt.Errorf("todos os tiers devem ter nome")
+		}
+		if seen[name] {
+			return fmt.Errorf("tier '%s' duplicado", name)
+		}
+		seen[name] = true
+		if !isFinite(tier.MinScore) {
+			return fmt.Errorf("min_score inválido no tier '%s'", name)
+		}
+	}
+
+	values := []float64{
+		r.Survey.DefaultWeight,
+		r.Behavior.SessionCount.Points, r.Behavior.SessionCount.Max,
+		r.Behavior.TimeOnPage.Points, r.Behavior.TimeOnPage.Max,
+		r.Behavior.ReturningVisits.Points, r.Behavior.ReturningVisits.Max,
+	}
+	for _, weight := range r.Survey.QuestionWeights {
+		values = append(values, weight)
+	}
+	for _, points := range r.Behavior.UtmSource {
+		values = append(values, points)
+	}
+	for _, v := range values {
+		if !isFinite(v) {
+			return fmt.Errorf("as regras contêm pesos inválidos")
+		}
+	}
+	for _, rule := range []LeadSignalRule{r.Behavior.SessionCount, r.Behavior.TimeOnPage, r.Behavior.ReturningVisits} {
+		if rule.Max < 0 {
+			return fmt.Errorf("o limite 'max' não pode ser negativo")
+		}
+	}
+
+	r.sortTiers()
+	return nil
+}
+
+// Score calcula a contribuição de cada componente para os sinais do lead
+func (r LeadScoringRules) Score(f repositories.LeadScoringFeatures) LeadScoreBreakdown {
+	var breakdown LeadScoreBreakdown
+
+	for questionID, answerScore := range f.SurveyScores {
+		weight, ok := r.Survey.QuestionWeights[questionID]
+		if !ok {
+			weight = r.Survey.DefaultWeight
+		}
+		breakdown.Survey += weight * float64(answerScore)
+	}
+
+	returning := f.SessionCount - 1
+	if returning < 0 {
+		returning = 0
+	}
+
+	breakdown.SessionCount = r.Behavior.SessionCount.apply(float64(f.SessionCount))
+	breakdown.TimeOnPage = r.Behavior.TimeOnPage.apply(f.TotalDuration / 60)
+	breakdown.ReturningVisits = r.Behavior.ReturningVisits.apply(float64(returning))
+
+	source := strings.ToLower(strings.TrimSpace(f.UtmSource))
+	for key, points := range r.Behavior.UtmSource {
+		if strings.ToLower(key) == source {
+			breakdown.UtmSource = points
+			break
+		}
+	}
+
+	breakdown.Survey = math.Round(breakdown.Survey*100) / 100
+	breakdown.SessionCount = math.Round(breakdown.SessionCount*100) / 100
+	breakdown.TimeOnPage = math.Round(breakdown.TimeOnPage*100) / 100
+	breakdown.ReturningVisits = math.Round(breakdown.ReturningVisits*100) / 100
+	return breakdown
+}
+
+// TierFor retorna o tier da pontuação; abaixo de todos os mínimos, o tier de menor pontuação
+func (r LeadScoringRules) TierFor(score float64) string {
+	if len(r.Tiers) == 0 {
+		return ""
+	}
+	for _, tier := range r.Tiers {
+		if score >= tier.MinScore {
+			return tier.Name
+		}
+	}
+	return r.Tiers[len(r.Tiers)-1].Name
+}
+
+// Total retorna a pontuação final do lead
+func (b LeadScoreBreakdown) Total() float64 {
+	return math.Round((b.Survey+b.SessionCount+b.TimeOnPage+b.ReturningVisits+b.UtmSource)*100) / 100
+}
+
+func (r *LeadScoringRules) sortTiers() {
+	sort.SliceStable(r.Tiers, func(i, j int) bool {
+		return r.Tiers[i].MinScore > r.Tiers[j].MinScore
+	})
+}
+
+func (s LeadSignalRule) apply(value float64) float64 {
+	points := s.Points * value
+	if s.Max > 0 && points > s.Max {
+		return s.Max
+	}
+	return points
+}
+
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
@@ -0,0 +1,385 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+)
+
+var (
+	// ErrLiveUnavailable indica que a instância atingiu o limite de clientes do feed ao vivo
+	ErrLiveUnavailable = errors.New("limite de conexões do feed ao vivo atingido")
+	// ErrLiveStopped indica que o feed ao vivo foi encerrado junto com o servidor
+	ErrLiveStopped = errors.New("feed ao vivo encerrado")
+)
+
+const (
+	defaultLivePollSeconds     = 2
+	defaultLiveCountersSeconds = 10
+	defaultLiveMaxClients      = 500
+	// liveBufferSize é o número de mensagens pendentes por cliente; quem fica para trás é desconectado
+	// e retoma pelo Last-Event-ID
+	liveBufferSize = 256
+	// liveBatchSize limita os eventos lidos por consulta
+	liveBatchSize = 500
+	// liveReplayLimit limita os eventos reenviados em uma reconexão
+	liveReplayLimit = 1000
+	// liveRecentWindow é a janela dos leads recentes e das sessões ativas
+	liveRecentWindow = 5 * time.Minute
+	// liveSettleDelay deixa de fora os eventos dos últimos segundos, para que o cursor não passe por
+	// eventos de transações ainda não confirmadas
+	liveSettleDelay = 2 * time.Second
+)
+
+// Tipos de mensagem do feed ao vivo
+const (
+	// LiveMessageEvent é um novo evento de lead, pesquisa ou compra
+	LiveMessageEvent = "event"
+	// LiveMessageCounters traz os contadores atualizados
+	LiveMessageCounters = "counters"
+	// LiveMessageReset indica que a reconexão perdeu mais eventos do que o replay reenvia; o cliente
+	// deve recarregar os dados do dashboard
+	LiveMessageReset = "reset"
+)
+
+// LiveMessage é uma mensagem do feed ao vivo. Eventos trazem em ID a sua posição, usada como Last-Event-ID.
+type LiveMessage struct {
+	Kind     string
+	ID       string
+	Event    *entities.LiveEvent
+	Counters *entities.LiveCounters
+
+	cursor repositories.PageCursor
+}
+
+// LiveSubscription é a inscrição de um cliente no feed ao vivo
+type LiveSubscription struct {
+	// Replay são as mensagens iniciais: os contadores e, na reconexão, os eventos após o Last-Event-ID
+	Replay []LiveMessage
+	// Messages recebe as mensagens seguintes. É fechado quando o cliente fica para trás ou o feed para.
+	Messages <-chan LiveMessage
+
+	feed   *liveUseCase
+	filter repositories.LiveFilter
+	ch     chan LiveMessage
+	// position é a posição do último evento entregue no Replay
+	position repositories.PageCursor
+}
+
+// Delivered indica se a mensagem é um evento já entregue no Replay
+func (s *LiveSubscription) Delivered(msg LiveMessage) bool {
+	return msg.Kind == LiveMessageEvent && !liveCursorAfter(msg.cursor, s.position)
+}
+
+// Close encerra a inscrição
+func (s *LiveSubscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	s.feed.remove(s)
+}
+
+// LiveUseCase distribui os novos eventos e os contadores do feed ao vivo
+type LiveUseCase interface {
+	// Subscribe inscreve um cliente. Com lastEventID, reenvia os eventos posteriores a ele.
+	Subscribe(ctx context.Context, filter repositories.LiveFilter, lastEventID string) (*LiveSubscription, error)
+	// Run consulta periodicamente os novos eventos e os contadores enquanto houver clientes. Ao cancelar
+	// o contexto, encerra as inscrições e recusa as novas.
+	Run(ctx context.Context)
+}
+
+type liveUseCase struct {
+	liveRepo         repositories.LiveRepository
+	pollInterval     time.Duration
+	countersInterval time.Duration
+	maxClients       int
+
+	mu          sync.Mutex
+	cursor      *repositories.PageCursor
+	subscribers map[*LiveSubscription]struct{}
+	counters    map[string]*entities.LiveCounters
+	// stopped indica que Run terminou e o feed não recebe mais inscrições
+	stopped bool
+}
+
+// NewLiveUseCase cria o feed ao vivo. Intervalos e limite de clientes vêm de LIVE_POLL_SECONDS,
+// LIVE_COUNTERS_SECONDS e LIVE_MAX_CLIENTS.
+func NewLiveUseCase(liveRepo repositories.LiveRepository) LiveUseCase {
+	return &liveUseCase{
+		liveRepo:         liveRepo,
+		pollInterval:     time.Duration(envInt("LIVE_POLL_SECONDS", defaultLivePollSeconds)) * time.Second,
+		countersInterval: time.Duration(envInt("LIVE_COUNTERS_SECONDS", defaultLiveCountersSeconds)) * time.Second,
+		maxClients:       envInt("LIVE_MAX_CLIENTS", defaultLiveMaxClients),
+		subscribers:      make(map[*LiveSubscription]struct{}),
+		counters:         make(map[string]*entities.LiveCounters),
+	}
+}
+
+func (uc *liveUseCase) Subscribe(ctx context.Context, filter repositories.LiveFilter, lastEventID string) (*LiveSubscription, error) {
+	var resume *repositories.PageCursor
+	if lastEventID != "" {
+		cursor, err := repositories.DecodeCursor(lastEventID)
+		if err != nil {
+			return nil, err
+		}
+		resume = cursor
+	}
+
+	sub := &LiveSubscription{
+		feed:   uc,
+		filter: filter,
+		ch:     make(chan LiveMessage, liveBufferSize),
+	}
+	sub.Messages = sub.ch
+
+	// A inscrição e a leitura da posição do feed são atômicas: o canal recebe todos os eventos após start.
+	// Com o feed parado, a posição inicial é consultada fora do lock, para não bloquear o broadcaster e as
+	// outras inscrições; o laço termina com o lock adquirido e a posição definida.
+	var latest *repositories.PageCursor
+	for {
+		uc.mu.Lock()
+		if uc.stopped {
+			uc.mu.Unlock()
+			return nil, ErrLiveStopped
+		}
+		if len(uc.subscribers) >= uc.maxClients {
+			uc.mu.Unlock()
+			return nil, ErrLiveUnavailable
+		}
+		if uc.cursor == nil && latest != nil {
+			uc.cursor = latest
+		}
+		if uc.cursor != nil {
+			break
+		}
+		uc.mu.Unlock()
+
+		cursor, err := uc.liveRepo.LatestCursor(ctx, time.Now().Add(-liveSettleDelay))
+		if err != nil {
+			return nil, err
+		}
+		latest = &cursor
+	}
+	start := *uc.cursor
+	counters := uc.counters[filter.Key()]
+	uc.subscribers[sub] = struct{}{}
+	uc.mu.Unlock()
+
+	if counters == nil || time.Since(counters.At) > uc.countersInterval {
+		var err error
+		if counters, err = uc.loadCounters(ctx, filter); err != nil {
+			sub.Close()
+			return nil, err
+		}
+		uc.mu.Lock()
+		uc.counters[filter.Key()] = counters
+		uc.mu.Unlock()
+	}
+	sub.Replay = append(sub.Replay, LiveMessage{Kind: LiveMessageCounters, Counters: counters})
+
+	sub.position = start
+	if resume != nil {
+		// O cliente pode estar à frente desta instância (por exemplo, ao reconectar em outra)
+		if liveCursorAfter(*resume, start) {
+			sub.position = *resume
+		}
+		if err := uc.replay(ctx, sub, *resume); err != nil {
+			sub.Close()
+			return nil, err
+		}
+	}
+	return sub, nil
+}
+
+// replay reenvia os eventos do filtro após from, até liveReplayLimit eventos
+func (uc *liveUseCase) replay(ctx context.Context, sub *LiveSubscription, from repositories.PageCursor) error {
+	after := from
+	until := time.Now().Add(-liveSettleDelay)
+	for replayed := 0; ; {
+		if replayed >= liveReplayLimit {
+			sub.Replay = append(sub.Replay, LiveMessage{Kind: LiveMessageReset})
+			return nil
+		}
+		limit := liveBatchSize
+		if remaining := liveReplayLimit - replayed; remaining < limit {
+			limit = remaining
+		}
+		events, err := uc.liveRepo.ListEventsAfter(ctx, after, until, sub.filter, limit)
+		if err != nil {
+			return err
+		}
+		for i := range events {
+			msg := liveEventMessage(events[i])
+			sub.Replay = append(sub.Replay, msg)
+			after = msg.cursor
+		}
+		replayed += len(events)
+		if liveCursorAfter(after, sub.position) {
+			sub.position = after
+		}
+		if len(events) < limit {
+			return nil
+		}
+	}
+}
+
+func (uc *liveUseCase) Run(ctx context.Context) {
+	log.Printf("📡 Feed ao vivo iniciado (intervalo %v, contadores a cada %v)", uc.pollInterval, uc.countersInterval)
+	var countersAt time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			uc.mu.Lock()
+			uc.stopped = true
+			for sub := range uc.subscribers {
+				uc.remove(sub)
+			}
+			uc.mu.Unlock()
+			return
+		case <-time.After(uc.pollInterval):
+		}
+
+		if err := uc.pollEvents(ctx); err != nil {
+			log.Printf("⚠️ Erro no feed ao vivo: %v", err)
+		}
+		if time.Since(countersAt) >= uc.countersInterval {
+			uc.refreshCounters(ctx)
+			countersAt = time.Now()
+		}
+	}
+}
+
+// pollEvents lê os eventos após a posição do feed e os distribui aos clientes cujo filtro atendem.
+// Sem clientes, o feed fica parado e volta a partir do evento mais recente na próxima inscrição.
+func (uc *liveUseCase) pollEvents(ctx context.Context) error {
+	uc.mu.Lock()
+	if len(uc.subscribers) == 0 {
+		uc.cursor = nil
+		uc.counters = make(map[string]*entities.LiveCounters)
+		uc.mu.Unlock()
+		return nil
+	}
+	after := *uc.cursor
+	uc.mu.Unlock()
+
+	until := time.Now().Add(-liveSettleDelay)
+	for {
+		events, err := uc.liveRepo.ListEventsAfter(ctx, after, until, repositories.LiveFilter{}, liveBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		uc.mu.Lock()
+		// O feed foi reiniciado durante a consulta: a posição nova já cobre estes eventos
+		if uc.cursor == nil || !sameLiveCursor(*uc.cursor, after) {
+			uc.mu.Unlock()
+			return nil
+		}
+		for i := range events {
+			msg := liveEventMessage(events[i])
+			for sub := range uc.subscribers {
+				if sub.filter.Matches(events[i]) {
+					uc.send(sub, msg)
+				}
+			}
+			after = msg.cursor
+		}
+		position := after
+		uc.cursor = &position
+		uc.mu.Unlock()
+
+		if len(events) < liveBatchSize {
+			return nil
+		}
+	}
+}
+
+// refreshCounters recalcula os contadores de cada filtro em uso e os envia aos clientes
+func (uc *liveUseCase) refreshCounters(ctx context.Context) {
+	uc.mu.Lock()
+	filters := make(map[string]repositories.LiveFilter)
+	for sub := range uc.subscribers {
+		filters[sub.filter.Key()] = sub.filter
+	}
+	uc.mu.Unlock()
+
+	for key, filter := range filters {
+		counters, err := uc.loadCounters(ctx, filter)
+		if err != nil {
+			log.Printf("⚠️ Erro nos contadores do feed ao vivo: %v", err)
+			continue
+		}
+
+		msg := LiveMessage{Kind: LiveMessageCounters, Counters: counters}
+		uc.mu.Lock()
+		uc.counters[key] = counters
+		for sub := range uc.subscribers {
+			if sub.filter.Key() == key {
+				uc.send(sub, msg)
+			}
+		}
+		uc.mu.Unlock()
+	}
+}
+
+// loadCounters calcula os contadores do filtro; "hoje" começa à meia-noite de America/Sao_Paulo
+func (uc *liveUseCase) loadCounters(ctx context.Context, filter repositories.LiveFilter) (*entities.LiveCounters, error) {
+	now := time.Now().In(utils.GetBrasilLocation())
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	counters, err := uc.liveRepo.GetCounters(ctx, filter, dayStart, now.Add(-liveRecentWindow))
+	if err != nil {
+		return nil, err
+	}
+	counters.At = now
+	return counters, nil
+}
+
+// send entrega a mensagem sem bloquear o feed; o cliente com o buffer cheio é desconectado.
+// Deve ser chamado com uc.mu bloqueado.
+func (uc *liveUseCase) send(sub *LiveSubscription, msg LiveMessage) {
+	select {
+	case sub.ch <- msg:
+	default:
+		log.Printf("⚠️ Cliente do feed ao vivo desconectado por não acompanhar as mensagens")
+		uc.remove(sub)
+	}
+}
+
+// remove desfaz a inscrição e fecha o canal. Deve ser chamado com uc.mu bloqueado.
+func (uc *liveUseCase) remove(sub *LiveSubscription) {
+	if _, ok := uc.subscribers[sub]; ok {
+		delete(uc.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+func liveEventMessage(event entities.LiveEvent) LiveMessage {
+	cursor := repositories.PageCursor{Time: event.EventTime, ID: event.EventID.String()}
+	return LiveMessage{
+		Kind:   LiveMessageEvent,
+		ID:     repositories.EncodeCursor(cursor),
+		Event:  &event,
+		cursor: cursor,
+	}
+}
+
+// liveCursorAfter compara posições na ordem (event_time, event_id) usada pelas consultas
+func liveCursorAfter(a, b repositories.PageCursor) bool {
+	if !a.Time.Equal(b.Time) {
+		return a.Time.After(b.Time)
+	}
+	return a.ID > b.ID
+}
+
+func sameLiveCursor(a, b repositories.PageCursor) bool {
+	return a.Time.Equal(b.Time) && a.ID == b.ID
+}
@@ -0,0 +1,402 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+)
+
+// ErrInvalidBreakdown indica parâmetros de breakdown inválidos
+var ErrInvalidBreakdown = errors.New("breakdown inválido")
+
+// Métricas aceitas no breakdown
+const (
+	BreakdownMetricSessions   = "sessions"
+	BreakdownMetricLeads      = "leads"
+	BreakdownMetricClients    = "clients"
+	BreakdownMetricPurchases  = "purchases"
+	BreakdownMetricRevenue    = "revenue"
+	BreakdownMetricConversion = "conversion"
+)
+
+// breakdownMetrics lista as métricas na ordem de exibição
+var breakdownMetrics = []string{
+	BreakdownMetricSessions, BreakdownMetricLeads, BreakdownMetricClients,
+	BreakdownMetricPurchases, BreakdownMetricRevenue, BreakdownMetricConversion,
+}
+
+// Limites de linhas retornadas no breakdown
+const (
+	defaultBreakdownLimit = 100
+	maxBreakdownLimit     = 1000
+)
+
+// Rótulo de valores ausentes nas dimensões
+const breakdownEmptyLabel = "(não informado)"
+
+// weekdayLabels traduz o dia da semana ISO (1 = segunda-feira)
+var weekdayLabels = map[string]string{
+	"1": "Segunda-feira", "2": "Terça-feira", "3": "Quarta-feira", "4": "Quinta-feira",
+	"5": "Sexta-feira", "6": "Sábado", "7": "Domingo",
+}
+
+// MetricBreakdownInput são os parâmetros de um breakdown
+type MetricBreakdownInput struct {
+	Metrics      []string
+	Dimensions   []string
+	CurrentFrom  time.Time
+	CurrentTo    time.Time
+	PreviousFrom time.Time
+	PreviousTo   time.Time
+	ProfessionID int
+	ProductID    int
+	FunnelID     int
+	// SortBy é uma métrica ou "dimension"; padrão: a primeira métrica
+	SortBy string
+	// Order é "asc" ou "desc"; padrão: decrescente para métricas e crescente para dimensões
+	Order string
+	Limit int
+}
+
+// BreakdownValue representa uma métrica no período atual e no de comparação
+type BreakdownValue struct {
+	Current  float64 `json:"current"`
+	Previous float64 `json:"previous"`
+	// Share é a participação (%) da linha no total do período atual; ausente para taxas
+	Share        *float64 `json:"share,omitempty"`
+	Change       float64  `json:"change"`
+	IsIncreasing bool     `json:"is_increasing"`
+}
+
+// BreakdownDimensionValue representa o valor de uma dimensão e seu rótulo legível
+type BreakdownDimensionValue struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+}
+
+// MetricBreakdownRow representa uma linha da tabela de breakdown
+type MetricBreakdownRow struct {
+	Dimensions map[string]BreakdownDimensionValue `json:"dimensions"`
+	Metrics    map[string]BreakdownValue          `json:"metrics"`
+}
+
+// MetricBreakdownResult representa a tabela de breakdown com totais
+type MetricBreakdownResult struct {
+	Metrics    []string                  `json:"metrics"`
+	Dimensions []string                  `json:"dimensions"`
+	Totals     map[string]BreakdownValue `json:"totals"`
+	Rows       []MetricBreakdownRow      `json:"rows"`
+	TotalRows  int                       `json:"total_rows"`
+	SortBy     string                    `json:"sort_by"`
+	Order      string                    `json:"order"`
+}
+
+// breakdownCounts acumula as contagens de uma linha em um período
+type breakdownCounts struct {
+	sessions, leads, clients, purchases int64
+	revenue                             float64
+}
+
+// value retorna a métrica calculada a partir das contagens
+func (c breakdownCounts) value(metric string) float64 {
+	switch metric {
+	case BreakdownMetricSessions:
+		return float64(c.sessions)
+	case BreakdownMetricLeads:
+		return float64(c.leads)
+	case BreakdownMetricClients:
+		return float64(c.clients)
+	case BreakdownMetricPurchases:
+		return float64(c.purchases)
+	case BreakdownMetricRevenue:
+		return math.Round(c.revenue*100) / 100
+	case BreakdownMetricConversion:
+		if c.sessions == 0 {
+			return 0
+		}
+		return math.Round(float64(c.leads)/float64(c.sessions)*100*100) / 100
+	}
+	return 0
+}
+
+// add soma uma linha do repositório às contagens
+func (c *breakdownCounts) add(row repositories.MetricBreakdownRow) {
+	c.sessions += row.Sessions
+	c.leads += row.Leads
+	c.clients += row.Clients
+	c.purchases += row.Purchases
+	c.revenue += row.Revenue
+}
+
+// MetricBreakdownUseCase interface para breakdowns dimensionais de métricas
+type MetricBreakdownUseCase interface {
+	GetBreakdown(input MetricBreakdownInput) (*MetricBreakdownResult, error)
+}
+
+type metricBreakdownUseCase struct {
+	breakdownRepo repositories.MetricBreakdownRepository
+}
+
+func NewMetricBreakdownUseCase(breakdownRepo repositories.MetricBreakdownRepository) MetricBreakdownUseCase {
+	return &metricBreakdownUseCase{
+		breakdownRepo: breakdownRepo,
+	}
+}
+
+func (uc *metricBreakdownUseCase) GetBreakdown(input MetricBreakdownInput) (*MetricBreakdownResult, error) {
+	if err := validateBreakdownInput(&input); err != nil {
+		return nil, err
+	}
+
+	query := repositories.MetricBreakdownQuery{
+		Dimensions:   input.Dimensions,
+		CurrentFrom:  input.CurrentFrom,
+		CurrentTo:    input.CurrentTo,
+		PreviousFrom: input.PreviousFrom,
+		PreviousTo:   input.PreviousTo,
+		ProfessionID: input.ProfessionID,
+		ProductID:    input.ProductID,
+		FunnelID:     input.FunnelID,
+	}
+	for _, metric := range input.Metrics {
+		switch metric {
+		case BreakdownMetricSessions:
+			query.IncludeSessions = true
+		case BreakdownMetricConversion:
+			query.IncludeSessions = true
+			query.IncludeEvents = true
+		default:
+			query.IncludeEvents = true
+		}
+	}
+
+	rows, err := uc.breakdownRepo.GetBreakdown(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// Agrupar as linhas dos dois períodos por combinação de dimensões
+	type groupedRow struct {
+		dims              []BreakdownDimensionValue
+		current, previous breakdownCounts
+	}
+	groups := make(map[string]*groupedRow)
+	var order []string
+	var totalCurrent, totalPrevious breakdownCounts
+	for _, row := range rows {
+		key := row.Dim1 + "\x00" + row.Dim2
+		group, ok := groups[key]
+		if !ok {
+			group = &groupedRow{dims: []BreakdownDimensionValue{
+				breakdownDimensionValue(input.Dimensions[0], row.Dim1, row.Label1),
+			}}
+			if len(input.Dimensions) > 1 {
+				group.dims = append(group.dims, breakdownDimensionValue(input.Dimensions[1], row.Dim2, row.Label2))
+			}
+			groups[key] = group
+			order = append(order, key)
+		}
+		if row.Period == "current" {
+			group.current.add(row)
+			totalCurrent.add(row)
+		} else {
+			group.previous.add(row)
+			totalPrevious.add(row)
+		}
+	}
+
+	result := &MetricBreakdownResult{
+		Metrics:    input.Metrics,
+		Dimensions: input.Dimensions,
+		Totals:     make(map[string]BreakdownValue, len(input.Metrics)),
+		Rows:       make([]MetricBreakdownRow, 0, len(groups)),
+		SortBy:     input.SortBy,
+		Order:      input.Order,
+	}
+	for _, metric := range input.Metrics {
+		result.Totals[metric] = breakdownValue(metric, totalCurrent, totalPrevious, totalCurrent)
+	}
+
+	type sortableRow struct {
+		row      MetricBreakdownRow
+		dims     []BreakdownDimensionValue
+		sortedBy float64
+	}
+	sortable := make([]sortableRow, 0, len(groups))
+	for _, key := range order {
+		group := groups[key]
+		row := MetricBreakdownRow{
+			Dimensions: make(map[string]BreakdownDimensionValue, len(input.Dimensions)),
+			Metrics:    make(map[string]BreakdownValue, len(input.Metrics)),
+		}
+		for i, name := range input.Dimensions {
+			row.Dimensions[name] = group.dims[i]
+		}
+		for _, metric := range input.Metrics {
+			row.Metrics[metric] = breakdownValue(metric, group.current, group.previous, totalCurrent)
+		}
+		sortBy := 0.0
+		if input.SortBy != "dimension" {
+			sortBy = row.Metrics[input.SortBy].Current
+		}
+		sortable = append(sortable, sortableRow{row: row, dims: group.dims, sortedBy: sortBy})
+	}
+
+	descending := input.Order == "desc"
+	sort.SliceStable(sortable, func(i, j int) bool {
+		a, b := sortable[i], sortable[j]
+		if a.sortedBy != b.sortedBy {
+			if descending {
+				return a.sortedBy > b.sortedBy
+			}
+			return a.sortedBy < b.sortedBy
+		}
+		// Empates (e a ordenação por dimensão) seguem a ordem das dimensões
+		cmp := compareBreakdownDimensions(input.Dimensions, a.dims, b.dims)
+		if input.SortBy == "dimension" && descending {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	result.TotalRows = len(sortable)
+	for i, item := range sortable {
+		if i >= input.Limit {
+			break
+		}
+		result.Rows = append(result.Rows, item.row)
+	}
+	return result, nil
+}
+
+// validateBreakdownInput valida métricas, dimensões, ordenação e limite, aplicando os padrões
+func validateBreakdownInput(input *MetricBreakdownInput) error {
+	if len(input.Metrics) == 0 {
+		input.Metrics = []string{BreakdownMetricSessions, BreakdownMetricLeads, BreakdownMetricConversion}
+	}
+	seen := make(map[string]bool)
+	for _, metric := range input.Metrics {
+		if !isBreakdownMetric(metric) {
+			return fmt.Errorf("%w: métrica desconhecida '%s' (use %s)", ErrInvalidBreakdown, metric, strings.Join(breakdownMetrics, ", "))
+		}
+		if seen[metric] {
+			return fmt.Errorf("%w: métrica repetida '%s'", ErrInvalidBreakdown, metric)
+		}
+		seen[metric] = true
+	}
+
+	if len(input.Dimensions) == 0 || len(input.Dimensions) > 2 {
+		return fmt.Errorf("%w: informe uma ou duas dimensões", ErrInvalidBreakdown)
+	}
+	for _, dimension := range input.Dimensions {
+		if !repositories.IsBreakdownDimension(dimension) {
+			return fmt.Errorf("%w: dimensão desconhecida '%s' (use %s ou %s<caminho>)", ErrInvalidBreakdown, dimension,
+				strings.Join(repositories.BreakdownDimensionNames(), ", "), repositories.EventPropertiesPrefix)
+		}
+		if repositories.IsEventOnlyBreakdownDimension(dimension) && (seen[BreakdownMetricSessions] || seen[BreakdownMetricConversion]) {
+			return fmt.Errorf("%w: a dimensão '%s' vem dos eventos e não se aplica a sessions/conversion", ErrInvalidBreakdown, dimension)
+		}
+	}
+	if len(input.Dimensions) == 2 && input.Dimensions[0] == input.Dimensions[1] {
+		return fmt.Errorf("%w: as duas dimensões devem ser diferentes", ErrInvalidBreakdown)
+	}
+
+	if input.CurrentFrom.IsZero() || input.CurrentTo.IsZero() || input.CurrentTo.Before(input.CurrentFrom) {
+		return fmt.Errorf("%w: período atual inválido", ErrInvalidBreakdown)
+	}
+
+	if input.SortBy == "" {
+		input.SortBy = input.Metrics[0]
+	} else if input.SortBy != "dimension" && !seen[input.SortBy] {
+		return fmt.Errorf("%w: ordenação deve ser uma das métricas pedidas ou 'dimension'", ErrInvalidBreakdown)
+	}
+
+	switch input.Order {
+	case "":
+		input.Order = "desc"
+		if input.SortBy == "dimension" {
+			input.Order = "asc"
+		}
+	case "asc", "desc":
+	default:
+		return fmt.Errorf("%w: ordem deve ser 'asc' ou 'desc'", ErrInvalidBreakdown)
+	}
+
+	if input.Limit <= 0 {
+		input.Limit = defaultBreakdownLimit
+	}
+	if input.Limit > maxBreakdownLimit {
+		input.Limit = maxBreakdownLimit
+	}
+	return nil
+}
+
+// isBreakdownMetric indica se a métrica é suportada
+func isBreakdownMetric(metric string) bool {
+	for _, m := range breakdownMetrics {
+		if m == metric {
+			return true
+		}
+	}
+	return false
+}
+
+// breakdownValue calcula a métrica de uma linha, sua participação no total atual e a variação
+func breakdownValue(metric string, current, previous, total breakdownCounts) BreakdownValue {
+	value := BreakdownValue{
+		Current:  current.value(metric),
+		Previous: previous.value(metric),
+	}
+	value.Change = alignedChange(value.Current, value.Previous)
+	value.IsIncreasing = value.Current > value.Previous
+	if metric != BreakdownMetricConversion {
+		share := 0.0
+		if totalValue := total.value(metric); totalValue > 0 {
+			share = math.Round(value.Current/totalValue*100*100) / 100
+		}
+		value.Share = &share
+	}
+	return value
+}
+
+// breakdownDimensionValue monta o valor de uma dimensão com rótulo legível
+func breakdownDimensionValue(dimension, value, label string) BreakdownDimensionValue {
+	switch {
+	case value == "":
+		label = breakdownEmptyLabel
+	case dimension == "weekday":
+		label = weekdayLabels[value]
+	case dimension == "hour":
+		if hour, err := strconv.Atoi(value); err == nil {
+			label = fmt.Sprintf("%02dh", hour)
+		}
+	case label == "":
+		label = value
+	}
+	return BreakdownDimensionValue{Value: value, Label: label}
+}
+
+// compareBreakdownDimensions compara duas linhas pelas dimensões; valores numéricos (IDs, dia da semana
+// e hora) são comparados como números
+func compareBreakdownDimensions(dimensions []string, a, b []BreakdownDimensionValue) int {
+	for i := range dimensions {
+		x, errX := strconv.Atoi(a[i].Value)
+		y, errY := strconv.Atoi(b[i].Value)
+		switch {
+		case errX == nil && errY == nil && x != y:
+			if x < y {
+				return -1
+			}
+			return 1
+		case (errX != nil || errY != nil) && a[i].Label != b[i].Label:
+			return strings.Compare(a[i].Label, b[i].Label)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,149 @@
+package usecases
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+)
+
+const (
+	// Intervalo padrão entre as atualizações dos rollups
+	defaultRollupInterval = time.Minute
+	// Horas antes da marca d'água recalculadas a cada atualização, para absorver dados que chegam atrasados
+	defaultRollupLookbackHours = 3
+	// Dias de histórico calculados na primeira execução
+	defaultRollupBackfillDays = 400
+	// Tamanho máximo da janela calculada por atualização (o histórico é preenchido em etapas)
+	rollupMaxWindow = 24 * time.Hour
+)
+
+// MetricRollupWorker mantém as tabelas de rollups de métricas atualizadas em segundo plano.
+// Cada atualização recalcula a janela entre a marca d'água (menos o lookback) e o momento atual.
+type MetricRollupWorker struct {
+	rollupRepo   repositories.MetricRollupRepository
+	interval     time.Duration
+	lookback     time.Duration
+	backfillDays int
+}
+
+// NewMetricRollupWorker cria o worker com a configuração das variáveis ROLLUP_INTERVAL_SECONDS,
+// ROLLUP_LOOKBACK_HOURS e ROLLUP_BACKFILL_DAYS
+func NewMetricRollupWorker(rollupRepo repositories.MetricRollupRepository) *MetricRollupWorker {
+	return &MetricRollupWorker{
+		rollupRepo:   rollupRepo,
+		interval:     time.Duration(envInt("ROLLUP_INTERVAL_SECONDS", int(defaultRollupInterval/time.Second))) * time.Second,
+		lookback:     time.Duration(envInt("ROLLUP_LOOKBACK_HOURS", defaultRollupLookbackHours)) * time.Hour,
+		backfillDays: envInt("ROLLUP_BACKFILL_DAYS", defaultRollupBackfillDays),
+	}
+}
+
+// Run executa as atualizações até o contexto ser cancelado
+func (w *MetricRollupWorker) Run(ctx context.Context) {
+	log.Printf("📦 Worker de rollups iniciado (intervalo %v, lookback %v)", w.interval, w.lookback)
+	for {
+		if err := w.RunOnce(ctx); err != nil {
+			log.Printf("⚠️ Erro ao atualizar rollups de métricas: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.interval):
+		}
+	}
+}
+
+// RunOnce atualiza os rollups até o momento atual, em janelas de no máximo um dia
+func (w *MetricRollupWorker) RunOnce(ctx context.Context) error {
+	for ctx.Err() == nil {
+		state, err := w.rollupRepo.GetState()
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		var from, coveredFrom time.Time
+		if state == nil {
+			// Primeira execução: preencher o histórico a partir do início do dia, há backfillDays dias
+			start := now.In(utils.GetBrasilLocation()).AddDate(0, 0, -w.backfillDays)
+			coveredFrom = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+			from = coveredFrom
+		} else {
+			coveredFrom = state.CoveredFrom
+			from = state.Watermark
+			if recent := now.Add(-w.lookback); recent.Before(from) {
+				from = recent
+			}
+		}
+		from = from.Truncate(time.Hour)
+
+		to := now
+		if to.Sub(from) > rollupMaxWindow {
+			to = from.Add(rollupMaxWindow)
+		}
+
+		acquired, err := w.rollupRepo.Refresh(from, to, coveredFrom)
+		if err != nil {
+			return err
+		}
+		// Outra instância está atualizando, ou a janela alcançou o momento atual
+		if !acquired || !to.Before(now) {
+			return nil
+		}
+	}
+	return ctx.Err()
+}
+
+// metricRollupCoverage retorna até onde os rollups estão calculados quando todos os intervalos informados
+// (pares início/fim) podem ser lidos deles: limites alinhados à hora e início dentro do histórico calculado
+func metricRollupCoverage(rollupRepo repositories.MetricRollupRepository, bounds ...time.Time) (time.Time, bool) {
+	if rollupRepo == nil || len(bounds)%2 != 0 {
+		return time.Time{}, false
+	}
+	for i := 0; i < len(bounds); i += 2 {
+		if _, _, ok := rollupRange(bounds[i], bounds[i+1]); !ok {
+			return time.Time{}, false
+		}
+	}
+
+	state, err := rollupRepo.GetState()
+	if err != nil {
+		log.Printf("⚠️ Rollups indisponíveis, usando dados brutos: %v", err)
+		return time.Time{}, false
+	}
+	if state == nil {
+		return time.Time{}, false
+	}
+	for i := 0; i < len(bounds); i += 2 {
+		if bounds[i].Before(state.CoveredFrom) {
+			return time.Time{}, false
+		}
+	}
+	return state.Watermark, true
+}
+
+// rollupRange converte um período inclusivo (ex.: 00:00:00 até 23:59:59) no intervalo [from, to) dos rollups.
+// Só é válido quando os dois limites caem em horas cheias.
+func rollupRange(from, to time.Time) (time.Time, time.Time, bool) {
+	if from.IsZero() || to.IsZero() || !from.Before(to) {
+		return time.Time{}, time.Time{}, false
+	}
+	end := to.Truncate(time.Second).Add(time.Second)
+	if !from.Equal(from.Truncate(time.Hour)) || !end.Equal(end.Truncate(time.Hour)) {
+		return time.Time{}, time.Time{}, false
+	}
+	return from, end, true
+}
+
+// envInt lê uma variável de ambiente inteira positiva, com valor padrão
+func envInt(name string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(name)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
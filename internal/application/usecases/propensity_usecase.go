@@ -0,0 +1,713 @@
+package usecases
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrPropensityModelNotFound indica que o modelo solicitado não existe
+	ErrPropensityModelNotFound = errors.New("modelo de propensão não encontrado")
+	// ErrNoActivePropensityModel indica que a profissão/funil ainda não tem modelo treinado
+	ErrNoActivePropensityModel = errors.New("nenhum modelo de propensão ativo para a profissão/funil")
+	// ErrInsufficientTrainingData indica que os ciclos encerrados não têm compras ou leads suficientes
+	ErrInsufficientTrainingData = errors.New("dados insuficientes para treinar o modelo")
+)
+
+const (
+	// Uma categoria só vira feature se aparecer em pelo menos N leads do treino
+	propensityMinSupport = 10
+	// Limite de features categóricas (as mais frequentes)
+	propensityMaxFeatures = 300
+	// Gradiente descendente em lote com regularização L2
+	propensityIterations   = 500
+	propensityLearningRate = 0.5
+	propensityL2           = 0.01
+	// Mínimo de compras para treinar
+	propensityMinPositives = 5
+	// Quantidade de faixas no relatório de calibração
+	propensityCalibrationBins = 10
+	// Quantidade de fatores explicativos por lead
+	propensityTopFactors = 3
+)
+
+// Nomes das features numéricas (padronizadas)
+const (
+	propensityFeatureSessions   = "sessions"
+	propensityFeatureEngagement = "engagement_minutes"
+)
+
+// PropensityNumericFeature representa uma feature numérica com sua padronização
+type PropensityNumericFeature struct {
+	Name   string  `json:"name"`
+	Mean   float64 `json:"mean"`
+	Std    float64 `json:"std"`
+	Weight float64 `json:"weight"`
+}
+
+// PropensityCoefficients representa o modelo treinado: intercepto, features numéricas e pesos das categorias
+type PropensityCoefficients struct {
+	Intercept   float64                    `json:"intercept"`
+	Numeric     []PropensityNumericFeature `json:"numeric"`
+	Categorical map[string]float64         `json:"categorical"`
+}
+
+// PropensityCalibrationBin compara a probabilidade prevista com a taxa de compra observada em uma faixa
+type PropensityCalibrationBin struct {
+	Count         int     `json:"count"`
+	MinPredicted  float64 `json:"min_predicted"`
+	MaxPredicted  float64 `json:"max_predicted"`
+	MeanPredicted float64 `json:"mean_predicted"`
+	ObservedRate  float64 `json:"observed_rate"`
+}
+
+// PropensityFeatureWeight representa o peso de uma feature no modelo
+type PropensityFeatureWeight struct {
+	Feature string  `json:"feature"`
+	Weight  float64 `json:"weight"`
+}
+
+// PropensityMetrics representa a avaliação do modelo. As métricas de validação usam os ciclos mais
+// recentes como holdout (treino nos ciclos anteriores), simulando o uso em um ciclo futuro.
+type PropensityMetrics struct {
+	TrainSamples    int                        `json:"train_samples"`
+	HoldoutCycles   int                        `json:"holdout_cycles"`
+	HoldoutSamples  int                        `json:"holdout_samples"`
+	BaseRate        float64                    `json:"base_rate"`
+	TrainAUC        float64                    `json:"train_auc"`
+	HoldoutAUC      *float64                   `json:"holdout_auc,omitempty"`
+	HoldoutLogLoss  *float64                   `json:"holdout_log_loss,omitempty"`
+	HoldoutBrier    *float64                   `json:"holdout_brier,omitempty"`
+	Calibration     []PropensityCalibrationBin `json:"calibration"`
+	CalibrationSet  string                     `json:"calibration_set"`
+	TopPositive     []PropensityFeatureWeight  `json:"top_positive"`
+	TopNegative     []PropensityFeatureWeight  `json:"top_negative"`
+	FeatureCount    int                        `json:"feature_count"`
+	TrainingSeconds float64                    `json:"training_seconds"`
+}
+
+// PropensityTrainParams representa os parâmetros de treino do modelo
+type PropensityTrainParams struct {
+	ProfessionID int  `json:"profession_id"`
+	FunnelID     int  `json:"funnel_id"`
+	Cycles       int  `json:"cycles"`
+	Activate     bool `json:"activate"`
+}
+
+// PropensityFactor representa a contribuição de uma feature na probabilidade de um lead
+type PropensityFactor struct {
+	Feature      string  `json:"feature"`
+	Contribution float64 `json:"contribution"`
+}
+
+// PropensityLeadScore representa a probabilidade de compra de um lead no ciclo
+type PropensityLeadScore struct {
+	Rank        int                `json:"rank"`
+	Probability float64            `json:"probability"`
+	UserID      string             `json:"user_id"`
+	Fullname    string             `json:"fullname"`
+	Email       string             `json:"email"`
+	Phone       string             `json:"phone"`
+	Faixa       string             `json:"faixa"`
+	Channel     string             `json:"channel"`
+	Device      string             `json:"device"`
+	Region      string             `json:"region"`
+	Sessions    int64              `json:"sessions"`
+	Purchased   bool               `json:"purchased"`
+	TopFactors  []PropensityFactor `json:"top_factors"`
+}
+
+// PropensityScoreResult representa o ranking de leads do ciclo
+type PropensityScoreResult struct {
+	ModelID   int64                   `json:"model_id"`
+	TrainedAt time.Time               `json:"trained_at"`
+	Cycle     utils.WebinarCycleDates `json:"cycle"`
+	Total     int                     `json:"total"`
+	Page      int                     `json:"page"`
+	Limit     int                     `json:"limit"`
+	Leads     []PropensityLeadScore   `json:"leads"`
+}
+
+// PropensityUseCase interface para o modelo de propensão de compra
+type PropensityUseCase interface {
+	TrainModel(params PropensityTrainParams) (*entities.PropensityModel, error)
+	ListModels(professionID int) ([]entities.PropensityModel, error)
+	GetModel(modelID int64) (*entities.PropensityModel, error)
+	// ScoreLeads ranqueia os leads do ciclo pela probabilidade de compra, usando o modelo ativo
+	ScoreLeads(professionID, funnelID int, cycle utils.WebinarCycleDates, page, limit int) (*PropensityScoreResult, error)
+}
+
+type propensityUseCase struct {
+	propensityRepo repositories.PropensityRepository
+	cycleRepo      repositories.WebinarCycleRepository
+}
+
+func NewPropensityUseCase(propensityRepo repositories.PropensityRepository, cycleRepo repositories.WebinarCycleRepository) PropensityUseCase {
+	return &propensityUseCase{
+		propensityRepo: propensityRepo,
+		cycleRepo:      cycleRepo,
+	}
+}
+
+// propensityRow representa um lead codificado: índices das categorias ativas e valores numéricos padronizados
+type propensityRow struct {
+	categories []int
+	numeric    []float64
+	label      float64
+}
+
+func (uc *propensityUseCase) TrainModel(params PropensityTrainParams) (*entities.PropensityModel, error) {
+	startedAt := time.Now()
+
+	if params.ProfessionID <= 0 {
+		return nil, fmt.Errorf("profession_id é obrigatório")
+	}
+
+	cycles, err := selectCycles(uc.cycleRepo, CycleComparisonParams{
+		ProfessionID: params.ProfessionID,
+		FunnelID:     params.FunnelID,
+		Cycles:       params.Cycles,
+	}, time.Now().In(utils.GetBrasilLocation()))
+	if err != nil {
+		return nil, err
+	}
+	if len(cycles) == 0 {
+		return nil, ErrInsufficientTrainingData
+	}
+
+	// Amostras por ciclo, em ordem cronológica
+	perCycle := make([][]repositories.PropensitySample, len(cycles))
+	for i, cycle := range cycles {
+		samples, err := uc.propensityRepo.GetCycleSamples(cycle.dates, params.ProfessionID, params.FunnelID, cycle.dates.PesquisaFim)
+		if err != nil {
+			return nil, err
+		}
+		perCycle[i] = samples
+	}
+
+	all := flattenSamples(perCycle)
+	positives := countPositives(all)
+	if positives < propensityMinPositives || positives == len(all) {
+		return nil, fmt.Errorf("%w: %d leads e %d compras em %d ciclos", ErrInsufficientTrainingData, len(all), positives, len(cycles))
+	}
+
+	metrics := PropensityMetrics{
+		TrainSamples: len(all),
+		BaseRate:     float64(positives) / float64(len(all)),
+	}
+
+	// Validação temporal: os ciclos mais recentes ficam fora do treino
+	holdoutCycles := len(cycles) / 4
+	if holdoutCycles < 1 && len(cycles) >= 2 {
+		holdoutCycles = 1
+	}
+	if holdoutCycles > 0 {
+		train := flattenSamples(perCycle[:len(cycles)-holdoutCycles])
+		holdout := flattenSamples(perCycle[len(cycles)-holdoutCycles:])
+		trainPositives := countPositives(train)
+		if trainPositives >= propensityMinPositives && trainPositives < len(train) && len(holdout) > 0 {
+			model := fitPropensityModel(train)
+			predictions, labels := predictSamples(model, holdout)
+
+			metrics.HoldoutCycles = holdoutCycles
+			metrics.HoldoutSamples = len(holdout)
+			if auc, ok := rocAUC(predictions, labels); ok {
+				metrics.HoldoutAUC = &auc
+			}
+			logLoss, brier := probabilityLosses(predictions, labels)
+			metrics.HoldoutLogLoss = &logLoss
+			metrics.HoldoutBrier = &brier
+			metrics.Calibration = calibrationBins(predictions, labels)
+			metrics.CalibrationSet = "holdout"
+		}
+	}
+
+	// Modelo final treinado em todos os ciclos
+	coefficients := fitPropensityModel(all)
+	predictions, labels := predictSamples(coefficients, all)
+	metrics.TrainAUC, _ = rocAUC(predictions, labels)
+	if metrics.Calibration == nil {
+		metrics.Calibration = calibrationBins(predictions, labels)
+		metrics.CalibrationSet = "train"
+	}
+	metrics.TopPositive, metrics.TopNegative = topWeights(coefficients, 10)
+	metrics.FeatureCount = len(coefficients.Categorical) + len(coefficients.Numeric)
+	metrics.TrainingSeconds = math.Round(time.Since(startedAt).Seconds()*100) / 100
+
+	rawCoefficients, err := json.Marshal(coefficients)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar coeficientes: %w", err)
+	}
+	rawMetrics, err := json.Marshal(metrics)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar métricas: %w", err)
+	}
+
+	model := &entities.PropensityModel{
+		ProfessionID: params.ProfessionID,
+		IsActive:     params.Activate,
+		CyclesUsed:   len(cycles),
+		TrainingFrom: cycles[0].dates.PesquisaInicio,
+		TrainingTo:   cycles[len(cycles)-1].dates.VendaFim,
+		Samples:      len(all),
+		Positives:    positives,
+		Coefficients: rawCoefficients,
+		Metrics:      rawMetrics,
+		TrainedAt:    time.Now(),
+	}
+	if params.FunnelID > 0 {
+		funnelID := params.FunnelID
+		model.FunnelID = &funnelID
+	}
+
+	if err := uc.propensityRepo.CreateModel(model); err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+func (uc *propensityUseCase) ListModels(professionID int) ([]entities.PropensityModel, error) {
+	return uc.propensityRepo.ListModels(professionID)
+}
+
+func (uc *propensityUseCase) GetModel(modelID int64) (*entities.PropensityModel, error) {
+	model, err := uc.propensityRepo.GetModel(modelID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPropensityModelNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar modelo de propensão: %w", err)
+	}
+	return model, nil
+}
+
+func (uc *propensityUseCase) ScoreLeads(professionID, funnelID int, cycle utils.WebinarCycleDates, page, limit int) (*PropensityScoreResult, error) {
+	model, err := uc.propensityRepo.GetActiveModel(professionID, funnelID)
+	if err != nil {
+		return nil, err
+	}
+	if model == nil {
+		return nil, ErrNoActivePropensityModel
+	}
+
+	var coefficients PropensityCoefficients
+	if err := json.Unmarshal(model.Coefficients, &coefficients); err != nil {
+		return nil, fmt.Errorf("coeficientes do modelo %d inválidos: %w", model.ModelID, err)
+	}
+
+	// Engajamento até o início das vendas (como no treino), ou até agora se a captação está em andamento
+	cutoff := cycle.PesquisaFim
+	if now := time.Now(); now.Before(cutoff) {
+		cutoff = now
+	}
+
+	samples, err := uc.propensityRepo.GetCycleSamples(cycle, professionID, funnelID, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	leads := make([]PropensityLeadScore, len(samples))
+	for i, s := range samples {
+		probability, factors := coefficients.explain(s)
+		leads[i] = PropensityLeadScore{
+			Probability: math.Round(probability*10000) / 10000,
+			UserID:      s.UserID,
+			Fullname:    s.Fullname,
+			Email:       s.Email,
+			Phone:       s.Phone,
+			Faixa:       s.Faixa,
+			Channel:     s.Channel,
+			Device:      s.Device,
+			Region:      s.Region,
+			Sessions:    s.SessionCount,
+			Purchased:   s.Purchased,
+			TopFactors:  factors,
+		}
+	}
+
+	sort.SliceStable(leads, func(i, j int) bool {
+		return leads[i].Probability > leads[j].Probability
+	})
+	for i := range leads {
+		leads[i].Rank = i + 1
+	}
+
+	result := &PropensityScoreResult{
+		ModelID:   model.ModelID,
+		TrainedAt: model.TrainedAt,
+		Cycle:     cycle,
+		Total:     len(leads),
+		Page:      page,
+		Limit:     limit,
+		Leads:     []PropensityLeadScore{},
+	}
+
+	start := (page - 1) * limit
+	if start < len(leads) {
+		end := start + limit
+		if end > len(leads) {
+			end = len(leads)
+		}
+		result.Leads = leads[start:end]
+	}
+	return result, nil
+}
+
+// propensityCategories retorna as features categóricas de um lead (faixa, canal, dispositivo, região e respostas)
+func propensityCategories(s repositories.PropensitySample) []string {
+	normalize := func(v string) string { return strings.ToLower(strings.TrimSpace(v)) }
+
+	var keys []string
+	for name, value := range map[string]string{
+		"faixa":   s.Faixa,
+		"channel": s.Channel,
+		"device":  s.Device,
+		"region":  s.Region,
+	} {
+		if v := normalize(value); v != "" {
+			keys = append(keys, name+"="+v)
+		}
+	}
+	for questionID, value := range s.Answers {
+		if v := normalize(value); v != "" {
+			keys = append(keys, "answer:"+questionID+"="+v)
+		}
+	}
+	return keys
+}
+
+// propensityNumeric retorna as features numéricas brutas (escala log) de um lead
+func propensityNumeric(s repositories.PropensitySample) []float64 {
+	return []float64{
+		math.Log1p(float64(s.SessionCount)),
+		math.Log1p(s.TotalDuration / 60),
+	}
+}
+
+// fitPropensityModel treina a regressão logística por gradiente descendente em lote com regularização L2
+func fitPropensityModel(samples []repositories.PropensitySample) PropensityCoefficients {
+	// Vocabulário: categorias com suporte mínimo, limitadas às mais frequentes
+	support := make(map[string]int)
+	for _, s := range samples {
+		for _, key := range propensityCategories(s) {
+			support[key]++
+		}
+	}
+	vocabulary := make([]string, 0, len(support))
+	for key, count := range support {
+		if count >= propensityMinSupport && count < len(samples) {
+			vocabulary = append(vocabulary, key)
+		}
+	}
+	sort.Slice(vocabulary, func(i, j int) bool {
+		if support[vocabulary[i]] != support[vocabulary[j]] {
+			return support[vocabulary[i]] > support[vocabulary[j]]
+		}
+		return vocabulary[i] < vocabulary[j]
+	})
+	if len(vocabulary) > propensityMaxFeatures {
+		vocabulary = vocabulary[:propensityMaxFeatures]
+	}
+	index := make(map[string]int, len(vocabulary))
+	for i, key := range vocabulary {
+		index[key] = i
+	}
+
+	// Padronização das features numéricas
+	names := []string{propensityFeatureSessions, propensityFeatureEngagement}
+	numeric := make([]PropensityNumericFeature, len(names))
+	columns := make([][]float64, len(names))
+	for _, s := range samples {
+		for j, v := range propensityNumeric(s) {
+			columns[j] = append(columns[j], v)
+		}
+	}
+	for j, name := range names {
+		mean, std := meanStdDev(columns[j])
+		if std == 0 {
+			std = 1
+		}
+		numeric[j] = PropensityNumericFeature{Name: name, Mean: mean, Std: std}
+	}
+
+	rows := make([]propensityRow, len(samples))
+	positives := 0.0
+	for i, s := range samples {
+		for _, key := range propensityCategories(s) {
+			if idx, ok := index[key]; ok {
+				rows[i].categories = append(rows[i].categories, idx)
+			}
+		}
+		raw := propensityNumeric(s)
+		rows[i].numeric = make([]float64, len(raw))
+		for j, v := range raw {
+			rows[i].numeric[j] = (v - numeric[j].Mean) / numeric[j].Std
+		}
+		if s.Purchased {
+			rows[i].label = 1
+			positives++
+		}
+	}
+
+	// Intercepto inicia no logit da taxa base para acelerar a convergência
+	baseRate := positives / float64(len(rows))
+	intercept := math.Log(baseRate / (1 - baseRate))
+	weights := make([]float64, len(vocabulary))
+	numericWeights := make([]float64, len(numeric))
+
+	n := float64(len(rows))
+	gradWeights := make([]float64, len(weights))
+	gradNumeric := make([]float64, len(numericWeights))
+	for iter := 0; iter < propensityIterations; iter++ {
+		for i := range gradWeights {
+			gradWeights[i] = 0
+		}
+		for j := range gradNumeric {
+			gradNumeric[j] = 0
+		}
+		gradIntercept := 0.0
+
+		for _, row := range rows {
+			z := intercept
+			for _, idx := range row.categories {
+				z += weights[idx]
+			}
+			for j, v := range row.numeric {
+				z += numericWeights[j] * v
+			}
+			e := sigmoid(z) - row.label
+
+			gradIntercept += e
+			for _, idx := range row.categories {
+				gradWeights[idx] += e
+			}
+			for j, v := range row.numeric {
+				gradNumeric[j] += e * v
+			}
+		}
+
+		intercept -= propensityLearningRate * gradIntercept / n
+		for i := range weights {
+			weights[i] -= propensityLearningRate * (gradWeights[i]/n + propensityL2*weights[i])
+		}
+		for j := range numericWeights {
+			numericWeights[j] -= propensityLearningRate * (gradNumeric[j]/n + propensityL2*numericWeights[j])
+		}
+	}
+
+	coefficients := PropensityCoefficients{
+		Intercept:   intercept,
+		Numeric:     numeric,
+		Categorical: make(map[string]float64, len(vocabulary)),
+	}
+	for j := range coefficients.Numeric {
+		coefficients.Numeric[j].Weight = numericWeights[j]
+	}
+	for i, key := range vocabulary {
+		coefficients.Categorical[key] = weights[i]
+	}
+	return coefficients
+}
+
+// explain retorna a probabilidade de compra do lead e as features que mais contribuíram para ela
+func (m PropensityCoefficients) explain(s repositories.PropensitySample) (float64, []PropensityFactor) {
+	z := m.Intercept
+	factors := make([]PropensityFactor, 0)
+
+	for _, key := range propensityCategories(s) {
+		if weight, ok := m.Categorical[key]; ok {
+			z += weight
+			factors = append(factors, PropensityFactor{Feature: key, Contribution: weight})
+		}
+	}
+	for j, v := range propensityNumeric(s) {
+		if j >= len(m.Numeric) {
+			break
+		}
+		feature := m.Numeric[j]
+		contribution := feature.Weight * (v - feature.Mean) / feature.Std
+		z += contribution
+		factors = append(factors, PropensityFactor{Feature: feature.Name, Contribution: contribution})
+	}
+
+	sort.Slice(factors, func(i, j int) bool {
+		return factors[i].Contribution > factors[j].Contribution
+	})
+	top := make([]PropensityFactor, 0, propensityTopFactors)
+	for _, factor := range factors {
+		if factor.Contribution <= 0 || len(top) == propensityTopFactors {
+			break
+		}
+		factor.Contribution = math.Round(factor.Contribution*1000) / 1000
+		top = append(top, factor)
+	}
+
+	return sigmoid(z), top
+}
+
+func predictSamples(m PropensityCoefficients, samples []repositories.PropensitySample) ([]float64, []bool) {
+	predictions := make([]float64, len(samples))
+	labels := make([]bool, len(samples))
+	for i, s := range samples {
+		predictions[i], _ = m.explain(s)
+		labels[i] = s.Purchased
+	}
+	return predictions, labels
+}
+
+// rocAUC calcula a área sob a curva ROC pela estatística de Mann-Whitney (empates recebem o posto médio)
+func rocAUC(predictions []float64, labels []bool) (float64, bool) {
+	order := make([]int, len(predictions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return predictions[order[a]] < predictions[order[b]]
+	})
+
+	var positives, negatives, positiveRankSum float64
+	for i := 0; i < len(order); {
+		j := i
+		for j < len(order) && predictions[order[j]] == predictions[order[i]] {
+			j++
+		}
+		// Postos i+1..j (base 1)
+		averageRank := float64(i+1+j) / 2
+		for k := i; k < j; k++ {
+			if labels[order[k]] {
+				positives++
+				positiveRankSum += averageRank
+			} else {
+				negatives++
+			}
+		}
+		i = j
+	}
+
+	if positives == 0 || negatives == 0 {
+		return 0, false
+	}
+	auc := (positiveRankSum - positives*(positives+1)/2) / (positives * negatives)
+	return math.Round(auc*10000) / 10000, true
+}
+
+// probabilityLosses retorna a log loss e o Brier score das previsões
+func probabilityLosses(predictions []float64, labels []bool) (float64, float64) {
+	const eps = 1e-15
+	var logLoss, brier float64
+	for i, p := range predictions {
+		p = math.Min(math.Max(p, eps), 1-eps)
+		y := 0.0
+		if labels[i] {
+			y = 1
+		}
+		logLoss -= y*math.Log(p) + (1-y)*math.Log(1-p)
+		brier += (p - y) * (p - y)
+	}
+	n := float64(len(predictions))
+	return math.Round(logLoss/n*10000) / 10000, math.Round(brier/n*10000) / 10000
+}
+
+// calibrationBins agrupa as previsões em faixas de mesmo tamanho (decis) e compara com a taxa observada
+func calibrationBins(predictions []float64, labels []bool) []PropensityCalibrationBin {
+	order := make([]int, len(predictions))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return predictions[order[a]] < predictions[order[b]]
+	})
+
+	bins := make([]PropensityCalibrationBin, 0, propensityCalibrationBins)
+	for b := 0; b < propensityCalibrationBins; b++ {
+		start := b * len(order) / propensityCalibrationBins
+		end := (b + 1) * len(order) / propensityCalibrationBins
+		if start == end {
+			continue
+		}
+
+		bin := PropensityCalibrationBin{
+			Count:        end - start,
+			MinPredicted: predictions[order[start]],
+			MaxPredicted: predictions[order[end-1]],
+		}
+		var predicted, observed float64
+		for _, idx := range order[start:end] {
+			predicted += predictions[idx]
+			if labels[idx] {
+				observed++
+			}
+		}
+		bin.MeanPredicted = math.Round(predicted/float64(bin.Count)*10000) / 10000
+		bin.ObservedRate = math.Round(observed/float64(bin.Count)*10000) / 10000
+		bin.MinPredicted = math.Round(bin.MinPredicted*10000) / 10000
+		bin.MaxPredicted = math.Round(bin.MaxPredicted*10000) / 10000
+		bins = append(bins, bin)
+	}
+	return bins
+}
+
+// topWeights retorna as features com maior peso positivo e negativo
+func topWeights(m PropensityCoefficients, limit int) ([]PropensityFeatureWeight, []PropensityFeatureWeight) {
+	weights := make([]PropensityFeatureWeight, 0, len(m.Categorical)+len(m.Numeric))
+	for key, weight := range m.Categorical {
+		weights = append(weights, PropensityFeatureWeight{Feature: key, Weight: math.Round(weight*1000) / 1000})
+	}
+	for _, feature := range m.Numeric {
+		weights = append(weights, PropensityFeatureWeight{Feature: feature.Name, Weight: math.Round(feature.Weight*1000) / 1000})
+	}
+	sort.Slice(weights, func(i, j int) bool {
+		if weights[i].Weight != weights[j].Weight {
+			return weights[i].Weight > weights[j].Weight
+		}
+		return weights[i].Feature < weights[j].Feature
+	})
+
+	positive := make([]PropensityFeatureWeight, 0, limit)
+	for _, w := range weights {
+		if w.Weight <= 0 || len(positive) == limit {
+			break
+		}
+		positive = append(positive, w)
+	}
+	negative := make([]PropensityFeatureWeight, 0, limit)
+	for i := len(weights) - 1; i >= 0; i-- {
+		if weights[i].Weight >= 0 || len(negative) == limit {
+			break
+		}
+		negative = append(negative, weights[i])
+	}
+	return positive, negative
+}
+
+func flattenSamples(perCycle [][]repositories.PropensitySample) []repositories.PropensitySample {
+	var all []repositories.PropensitySample
+	for _, samples := range perCycle {
+		all = append(all, samples...)
+	}
+	return all
+}
+
+func countPositives(samples []repositories.PropensitySample) int {
+	count := 0
+	for _, s := range samples {
+		if s.Purchased {
+			count++
+		}
+	}
+	return count
+}
+
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
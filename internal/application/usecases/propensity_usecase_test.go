@@ -0,0 +1,164 @@
+package usecases
+
+import (
+	"math"
+	"testing"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+)
+
+func TestRocAUC(t *testing.T) {
+	tests := []struct {
+		name        string
+		predictions []float64
+		labels      []bool
+		want        float64
+		ok          bool
+	}{
+		{"separação perfeita", []float64{0.1, 0.2, 0.8, 0.9}, []bool{false, false, true, true}, 1, true},
+		{"ordem invertida", []float64{0.9, 0.8, 0.2, 0.1}, []bool{false, false, true, true}, 0, true},
+		{"um par invertido", []float64{0.1, 0.4, 0.35, 0.8}, []bool{false, false, true, true}, 0.75, true},
+		{"empates contam meio", []float64{0.5, 0.5, 0.5, 0.5}, []bool{false, true, false, true}, 0.5, true},
+		{"empate parcial", []float64{0.2, 0.5, 0.5, 0.9}, []bool{false, false, true, true}, 0.875, true},
+		{"só positivos", []float64{0.2, 0.7}, []bool{true, true}, 0, false},
+		{"só negativos", []float64{0.2, 0.7}, []bool{false, false}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := rocAUC(tt.predictions, tt.labels)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("rocAUC() = %v, %v; esperado %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestProbabilityLosses(t *testing.T) {
+	tests := []struct {
+		name        string
+		predictions []float64
+		labels      []bool
+		logLoss     float64
+		brier       float64
+	}{
+		{"chute de 50%", []float64{0.5, 0.5}, []bool{true, false}, 0.6931, 0.25},
+		{"previsão perfeita", []float64{1, 0}, []bool{true, false}, 0, 0},
+		{"confiante e certo ou errado", []float64{0.9, 0.9}, []bool{true, false}, 1.2040, 0.41},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logLoss, brier := probabilityLosses(tt.predictions, tt.labels)
+			if logLoss != tt.logLoss || brier != tt.brier {
+				t.Errorf("probabilityLosses() = %v, %v; esperado %v, %v", logLoss, brier, tt.logLoss, tt.brier)
+			}
+		})
+	}
+}
+
+func TestCalibrationBins(t *testing.T) {
+	// 20 previsões i/20, embaralhadas; compram os leads com previsão >= 0,5
+	predictions := make([]float64, 20)
+	labels := make([]bool, 20)
+	for i := range predictions {
+		p := float64((i*7)%20) / 20
+		predictions[i] = p
+		labels[i] = p >= 0.5
+	}
+
+	bins := calibrationBins(predictions, labels)
+	if len(bins) != propensityCalibrationBins {
+		t.Fatalf("%d faixas; esperado %d", len(bins), propensityCalibrationBins)
+	}
+	for b, bin := range bins {
+		wantMin := float64(2*b) / 20
+		wantMax := float64(2*b+1) / 20
+		wantObserved := 0.0
+		if b >= 5 {
+			wantObserved = 1
+		}
+		if bin.Count != 2 || bin.MinPredicted != wantMin || bin.MaxPredicted != wantMax ||
+			bin.MeanPredicted != math.Round((wantMin+wantMax)/2*10000)/10000 || bin.ObservedRate != wantObserved {
+			t.Errorf("faixa %d = %+v; esperado count 2, min %v, max %v, observada %v", b, bin, wantMin, wantMax, wantObserved)
+		}
+	}
+
+	// Menos previsões que faixas: faixas vazias são omitidas
+	if got := calibrationBins([]float64{0.2, 0.8}, []bool{false, true}); len(got) != 2 {
+		t.Errorf("%d faixas para 2 previsões; esperado 2", len(got))
+	}
+}
+
+// propensitySamples gera n leads da faixa, dos quais purchased compraram
+func propensitySamples(faixa string, n, purchased int) []repositories.PropensitySample {
+	samples := make([]repositories.PropensitySample, n)
+	for i := range samples {
+		samples[i] = repositories.PropensitySample{Faixa: faixa, Purchased: i < purchased}
+	}
+	return samples
+}
+
+func TestFitPropensityModel(t *testing.T) {
+	t.Run("apenas intercepto", func(t *testing.T) {
+		// Uma única faixa (presente em todos os leads) não entra no vocabulário e as features numéricas
+		// são constantes: o modelo é o logit da taxa base
+		model := fitPropensityModel(propensitySamples("a", 40, 10))
+
+		if want := math.Log(0.25 / 0.75); math.Abs(model.Intercept-want) > 1e-9 {
+			t.Errorf("intercepto = %v; esperado %v", model.Intercept, want)
+		}
+		if len(model.Categorical) != 0 {
+			t.Errorf("features categóricas = %v; esperado nenhuma", model.Categorical)
+		}
+		for _, feature := range model.Numeric {
+			if feature.Weight != 0 || feature.Std != 1 {
+				t.Errorf("feature %s = %+v; esperado peso 0 e desvio 1", feature.Name, feature)
+			}
+		}
+	})
+
+	t.Run("duas faixas", func(t *testing.T) {
+		// Faixa a compra 2/3 e faixa b 1/3, com taxa base 1/2: o intercepto é 0 e os pesos são simétricos,
+		// com probabilidades próximas às taxas observadas (a regularização L2 as puxa para a taxa base)
+		samples := append(propensitySamples("a", 30, 20), propensitySamples("b", 30, 10)...)
+		model := fitPropensityModel(samples)
+
+		weightA, weightB := model.Categorical["faixa=a"], model.Categorical["faixa=b"]
+		if math.Abs(model.Intercept) > 1e-6 || math.Abs(weightA+weightB) > 1e-6 {
+			t.Errorf("intercepto = %v, pesos = %v e %v; esperado intercepto 0 e pesos simétricos", model.Intercept, weightA, weightB)
+		}
+		// Ótimo com L2: 0,5·(sigmoid(w) - 2/3) + 0,01·w = 0
+		if gradient := 0.5*(sigmoid(weightA)-2.0/3) + propensityL2*weightA; math.Abs(gradient) > 1e-6 {
+			t.Errorf("peso de faixa=a = %v não é o ótimo regularizado (gradiente %v)", weightA, gradient)
+		}
+		if p := sigmoid(model.Intercept + weightA); p < 0.6 || p > 2.0/3 {
+			t.Errorf("probabilidade da faixa a = %v; esperado entre 0,6 e 2/3", p)
+		}
+
+		// Positivos: 20 em a e 10 em b; negativos: 10 em a e 20 em b.
+		// AUC = (20·20 + 0,5·20·10 + 0,5·10·20) / (30·30) = 2/3
+		predictions, labels := predictSamples(model, samples)
+		if auc, ok := rocAUC(predictions, labels); !ok || auc != 0.6667 {
+			t.Errorf("AUC = %v; esperado 0,6667", auc)
+		}
+
+		probability, factors := model.explain(samples[0])
+		if len(factors) != 1 || factors[0].Feature != "faixa=a" || probability != sigmoid(model.Intercept+weightA) {
+			t.Errorf("explain() = %v, %+v; esperado o fator faixa=a", probability, factors)
+		}
+	})
+}
+
+func TestTopWeights(t *testing.T) {
+	model := PropensityCoefficients{
+		Numeric:     []PropensityNumericFeature{{Name: "sessions", Weight: 0.5}},
+		Categorical: map[string]float64{"faixa=a": 1.2, "faixa=b": -0.8, "device=mobile": -0.1, "channel=x": 0},
+	}
+
+	positive, negative := topWeights(model, 2)
+	if len(positive) != 2 || positive[0].Feature != "faixa=a" || positive[1].Feature != "sessions" {
+		t.Errorf("positivos = %+v; esperado faixa=a e sessions", positive)
+	}
+	if len(negative) != 2 || negative[0].Feature != "faixa=b" || negative[1].Feature != "device=mobile" {
+		t.Errorf("negativos = %+v; esperado faixa=b e device=mobile", negative)
+	}
+}
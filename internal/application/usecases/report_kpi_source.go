@@ -0,0 +1,175 @@
+package usecases
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+)
+
+// ReportKPISource busca os KPIs de um envio. A implementação padrão chama os usecases do dashboard e do
+// faturamento com os mesmos períodos de /dashboard/unified e /dashboard/revenue-by-profession, de modo que
+// os números do e-mail coincidem com os da tela.
+type ReportKPISource interface {
+	Dashboard(period ReportPeriod, filters entities.ReportFilters) (DashboardResult, error)
+	RevenueByProfession(period ReportPeriod, filters entities.ReportFilters) ([]repositories.RevenueComparisonData, error)
+}
+
+type reportKPISource struct {
+	dashboardUseCase DashboardUseCase
+	revenueUseCase   RevenueUseCase
+	cycleUseCase     WebinarCycleUseCase
+	segmentUseCase   SegmentUseCase
+}
+
+func NewReportKPISource(dashboardUseCase DashboardUseCase, revenueUseCase RevenueUseCase, cycleUseCase WebinarCycleUseCase, segmentUseCase SegmentUseCase) ReportKPISource {
+	return &reportKPISource{
+		dashboardUseCase: dashboardUseCase,
+		revenueUseCase:   revenueUseCase,
+		cycleUseCase:     cycleUseCase,
+		segmentUseCase:   segmentUseCase,
+	}
+}
+
+// Dashboard calcula os KPIs de sessões e leads do período, como /dashboard/unified
+func (s *reportKPISource) Dashboard(period ReportPeriod, filters entities.ReportFilters) (DashboardResult, error) {
+	params := map[string]string{
+		"profession_id": reportFilterID(int64(filters.ProfessionID)),
+		"funnel_id":     reportFilterID(int64(filters.FunnelID)),
+		"product_id":    reportFilterID(int64(filters.ProductID)),
+		"segment_id":    reportFilterID(filters.SegmentID),
+		"landingPage":   "",
+		"landing_page":  "",
+		"user_id":       "",
+		"time_frame":    reportTimeFrame(filters),
+	}
+
+	var segment repositories.SegmentFilter
+	if filters.SegmentID > 0 {
+		var err error
+		if segment, err = s.segmentUseCase.ResolveFilter(filters.SegmentID); err != nil {
+			return DashboardResult{}, err
+		}
+	}
+
+	current, previous, err := s.periods(period, filters, 23*time.Hour+59*time.Minute+59*time.Second)
+	if err != nil {
+		return DashboardResult{}, err
+	}
+	if cycle := period.Cycle; cycle != nil {
+		params["cycle_id"] = strconv.FormatInt(cycle.CycleID, 10)
+		if params["profession_id"] == "" {
+			params["profession_id"] = strconv.Itoa(cycle.ProfessionID)
+		}
+		if params["funnel_id"] == "" && cycle.FunnelID != nil {
+			params["funnel_id"] = strconv.Itoa(*cycle.FunnelID)
+		}
+		params["from"] = current.From.Format(time.RFC3339)
+		params["to"] = current.To.Format(time.RFC3339)
+	} else {
+		params["from"] = current.From.Format("2006-01-02")
+		params["to"] = current.To.Format("2006-01-02")
+	}
+	params["time_from"] = current.TimeFrom
+	params["time_to"] = current.TimeTo
+	params["compare"] = reportCompareMode(filters)
+	params["compare_from"] = previous.From.Format(time.RFC3339)
+	params["compare_to"] = previous.To.Format(time.RFC3339)
+
+	return s.dashboardUseCase.GetUnifiedDashboard(params, current, previous, segment)
+}
+
+// RevenueByProfession calcula leads, vendas e faturamento por profissão no período, como
+// /dashboard/revenue-by-profession (que filtra apenas por profissão)
+func (s *reportKPISource) RevenueByProfession(period ReportPeriod, filters entities.ReportFilters) ([]repositories.RevenueComparisonData, error) {
+	timeFrame, _ := entities.NormalizeTimeFrame(reportTimeFrame(filters))
+	current, previous, err := s.periods(period, filters, 24*time.Hour-time.Nanosecond)
+	if err != nil {
+		return nil, err
+	}
+
+	var professionIDs []int
+	if filters.ProfessionID > 0 {
+		professionIDs = []int{filters.ProfessionID}
+	} else if period.Cycle != nil {
+		professionIDs = []int{period.Cycle.ProfessionID}
+	}
+
+	data, err := s.revenueUseCase.GetRevenueComparisonByProfession(current.From, current.To, previous.From, previous.To, professionIDs, timeFrame)
+	if err != nil {
+		return nil, err
+	}
+
+	// Dia único: dados por hora, os mesmos para todas as profissões
+	if len(data) > 0 && current.From.Format("2006-01-02") == current.To.Format("2006-01-02") {
+		if hourlyData, err := s.revenueUseCase.GetHourlyRevenueData(current.From, professionIDs); err == nil {
+			for i := range data {
+				data[i].HourlyData = hourlyData
+			}
+		}
+		if previousHourlyData, err := s.revenueUseCase.GetHourlyRevenueData(previous.From, professionIDs); err == nil {
+			for i := range data {
+				data[i].PreviousPeriodData.HourlyData = previousHourlyData
+			}
+		}
+	}
+	return data, nil
+}
+
+// periods retorna o período atual e o de comparação do envio. Fora de um ciclo, as datas são dias de
+// calendário em UTC, como as recebidas em from/to pelos endpoints, e o fim vai até endOfDay do último dia.
+func (s *reportKPISource) periods(period ReportPeriod, filters entities.ReportFilters, endOfDay time.Duration) (DatePeriod, DatePeriod, error) {
+	var current, previous DatePeriod
+	if period.Cycle != nil {
+		cycle, previousDates, err := s.cycleUseCase.ResolveCycle(period.Cycle.CycleID)
+		if err != nil {
+			return DatePeriod{}, DatePeriod{}, err
+		}
+		current, previous = CycleDatePeriod(CycleDates(cycle)), CycleDatePeriod(previousDates)
+	} else {
+		from := time.Date(period.From.Year(), period.From.Month(), period.From.Day(), 0, 0, 0, 0, time.UTC)
+		to := time.Date(period.To.Year(), period.To.Month(), period.To.Day(), 0, 0, 0, 0, time.UTC).Add(endOfDay)
+		previousFrom, previousTo := PreviousPeriod(from, to, reportTimeFrame(filters))
+		current = DatePeriod{From: from, To: to, TimeFrom: "00:00", TimeTo: "23:59"}
+		previous = DatePeriod{From: previousFrom, To: previousTo, TimeFrom: "00:00", TimeTo: "23:59"}
+	}
+
+	mode := reportCompareMode(filters)
+	if mode == CompareModePrevious {
+		return current, previous, nil
+	}
+	compareFrom, compareTo, err := ComparisonPeriod(mode, current.From, current.To, previous.From, previous.To)
+	if err != nil {
+		return DatePeriod{}, DatePeriod{}, err
+	}
+	previous = DatePeriod{
+		From:     compareFrom,
+		To:       compareTo,
+		TimeFrom: compareFrom.Format("15:04"),
+		TimeTo:   compareTo.Format("15:04"),
+	}
+	return current, previous, nil
+}
+
+func reportTimeFrame(filters entities.ReportFilters) string {
+	if filters.TimeFrame == "" {
+		return "daily"
+	}
+	return filters.TimeFrame
+}
+
+func reportCompareMode(filters entities.ReportFilters) string {
+	if filters.Compare == "" {
+		return CompareModePrevious
+	}
+	return filters.Compare
+}
+
+// reportFilterID formata um ID de filtro como no parâmetro de query (vazio quando não informado)
+func reportFilterID(id int64) string {
+	if id <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,554 @@
+package usecases
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	mailer "github.com/PavaniTiago/beta-intelligence-api/internal/infrastructure/mail"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/infrastructure/sparkline"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+)
+
+// ReportData reúne os KPIs de um envio: /dashboard/unified e /dashboard/revenue-by-profession do período
+type ReportData struct {
+	Report    *entities.ScheduledReport
+	Filters   entities.ReportFilters
+	Period    ReportPeriod
+	Dashboard DashboardResult
+	Revenue   []repositories.RevenueComparisonData
+}
+
+// ReportContent é o e-mail gerado: HTML com os minigráficos embutidos (cid:), versão em texto e CSVs anexos
+type ReportContent struct {
+	Subject     string
+	HTML        string
+	Text        string
+	Inline      []mailer.Attachment
+	Attachments []mailer.Attachment
+}
+
+// Cores das variações no HTML
+const (
+	reportColorUp      = "#15803d"
+	reportColorDown    = "#b91c1c"
+	reportColorNeutral = "#6b7280"
+)
+
+// reportSeries é uma série temporal de um KPI, usada nos minigráficos e em series.csv
+type reportSeries struct {
+	Periods []string
+	Values  []float64
+}
+
+type reportKPIRow struct {
+	Label       string
+	Current     string
+	Previous    string
+	Change      string
+	ChangeColor string
+	Sparkline   string
+}
+
+// reportSection é uma tabela do e-mail
+type reportSection struct {
+	Title  string
+	Header string
+	Rows   []reportKPIRow
+}
+
+type reportView struct {
+	Title       string
+	Period      string
+	Comparison  string
+	Filters     string
+	Sections    []reportSection
+	GeneratedAt string
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="pt-BR">
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body style="margin:0;padding:24px;background:#f3f4f6;font-family:Arial,Helvetica,sans-serif;color:#111827;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="max-width:720px;margin:0 auto;background:#ffffff;border-radius:8px;">
+<tr><td style="padding:24px;">
+<h1 style="margin:0 0 4px;font-size:20px;">{{.Title}}</h1>
+<p style="margin:0;color:#374151;font-size:14px;">{{.Period}}</p>
+{{if .Comparison}}<p style="margin:4px 0 0;color:#6b7280;font-size:13px;">{{.Comparison}}</p>{{end}}
+{{if .Filters}}<p style="margin:4px 0 0;color:#6b7280;font-size:13px;">{{.Filters}}</p>{{end}}
+{{range .Sections}}{{if .Rows}}
+<h2 style="margin:24px 0 8px;font-size:16px;">{{.Title}}</h2>
+<table role="presentation" width="100%" cellpadding="6" cellspacing="0" style="border-collapse:collapse;font-size:14px;">
+<tr style="background:#f9fafb;color:#6b7280;font-size:12px;text-align:left;">
+<th style="border-bottom:1px solid #e5e7eb;">{{.Header}}</th>
+<th style="border-bottom:1px solid #e5e7eb;text-align:right;">Atual</th>
+<th style="border-bottom:1px solid #e5e7eb;text-align:right;">Anterior</th>
+<th style="border-bottom:1px solid #e5e7eb;text-align:right;">Variação</th>
+<th style="border-bottom:1px solid #e5e7eb;">Tendência</th>
+</tr>
+{{range .Rows}}<tr>
+<td style="border-bottom:1px solid #f3f4f6;">{{.Label}}</td>
+<td style="border-bottom:1px solid #f3f4f6;text-align:right;font-weight:bold;">{{.Current}}</td>
+<td style="border-bottom:1px solid #f3f4f6;text-align:right;color:#6b7280;">{{.Previous}}</td>
+<td style="border-bottom:1px solid #f3f4f6;text-align:right;color:{{.ChangeColor}};">{{.Change}}</td>
+<td style="border-bottom:1px solid #f3f4f6;">{{if .Sparkline}}<img src="cid:{{.Sparkline}}" width="120" height="32" alt="" style="display:block;">{{end}}</td>
+</tr>
+{{end}}</table>
+{{end}}{{end}}
+<p style="margin:24px 0 0;color:#9ca3af;font-size:12px;">Gerado em {{.GeneratedAt}}. Os dados completos estão nos CSVs anexos.</p>
+</td></tr>
+</table>
+</body>
+</html>`))
+
+// renderReport monta o e-mail a partir dos KPIs
+func renderReport(data *ReportData) (*ReportContent, error) {
+	content := &ReportContent{
+		Subject: fmt.Sprintf("%s — %s", data.Report.Name, data.Period.Label),
+	}
+	sparklineFor := func(series reportSeries) (string, error) {
+		if len(series.Values) < 2 {
+			return "", nil
+		}
+		image, err := sparkline.PNG(series.Values, sparkline.DefaultWidth, sparkline.DefaultHeight)
+		if err != nil {
+			return "", err
+		}
+		n := len(content.Inline) + 1
+		contentID := fmt.Sprintf("sparkline-%d@report", n)
+		content.Inline = append(content.Inline, mailer.Attachment{
+			Filename:    fmt.Sprintf("sparkline-%d.png", n),
+			ContentType: "image/png",
+			ContentID:   contentID,
+			Data:        image,
+		})
+		return contentID, nil
+	}
+
+	view := reportView{
+		Title:       data.Report.Name,
+		Period:      "Período: " + data.Period.Label,
+		Comparison:  reportComparisonLabel(data.Dashboard.Filters),
+		Filters:     reportFiltersLabel(data),
+		GeneratedAt: time.Now().In(utils.GetBrasilLocation()).Format("02/01/2006 15:04"),
+	}
+
+	// Tráfego e captação (/dashboard/unified)
+	metrics := data.Dashboard.Metrics
+	if metrics == nil {
+		metrics = &Metrics{}
+	}
+	sessions, leads, conversion := dashboardSeries(data.Dashboard)
+	kpis := []struct {
+		label             string
+		current, previous float64
+		format            func(float64) string
+		series            reportSeries
+	}{
+		{"Sessões", float64(metrics.Sessions), float64(metrics.PrevSessions), formatReportInt, sessions},
+		{"Leads", float64(metrics.Leads), float64(metrics.PrevLeads), formatReportInt, leads},
+		{"Taxa de conversão", conversionRate(metrics.Leads, metrics.Sessions), conversionRate(metrics.PrevLeads, metrics.PrevSessions), formatReportRate, conversion},
+	}
+	traffic := reportSection{Title: "Tráfego e captação", Header: "Métrica"}
+	for _, kpi := range kpis {
+		row, err := kpiRow(kpi.label, kpi.current, kpi.previous, kpi.format, kpi.series, sparklineFor)
+		if err != nil {
+			return nil, err
+		}
+		traffic.Rows = append(traffic.Rows, row)
+	}
+
+	// Vendas (/dashboard/revenue-by-profession): totais e uma linha por profissão
+	var totalLeads, totalPurchases repositories.RevenueMetricResult
+	var totalRevenue repositories.RevenueMetricResultFloat
+	for _, profession := range data.Revenue {
+		totalLeads.Current += profession.Leads.Current
+		totalLeads.Previous += profession.Leads.Previous
+		totalPurchases.Current += profession.Purchases.Current
+		totalPurchases.Previous += profession.Purchases.Previous
+		totalRevenue.Current += profession.Revenue.Current
+		totalRevenue.Previous += profession.Revenue.Previous
+	}
+	revenueLeads, revenuePurchases, revenueTotal := revenueTotalSeries(data.Revenue)
+	sales := []struct {
+		label             string
+		current, previous float64
+		format            func(float64) string
+		series            reportSeries
+	}{
+		{"Leads", float64(totalLeads.Current), float64(totalLeads.Previous), formatReportInt, revenueLeads},
+		{"Vendas", float64(totalPurchases.Current), float64(totalPurchases.Previous), formatReportInt, revenuePurchases},
+		{"Faturamento", totalRevenue.Current, totalRevenue.Previous, formatReportMoney, revenueTotal},
+	}
+	salesSection := reportSection{Title: "Vendas", Header: "Métrica"}
+	for _, kpi := range sales {
+		row, err := kpiRow(kpi.label, kpi.current, kpi.previous, kpi.format, kpi.series, sparklineFor)
+		if err != nil {
+			return nil, err
+		}
+		salesSection.Rows = append(salesSection.Rows, row)
+	}
+	professions := reportSection{Title: "Faturamento por profissão", Header: "Profissão"}
+	for _, profession := range data.Revenue {
+		row, err := kpiRow(reportProfessionName(profession), profession.Revenue.Current, profession.Revenue.Previous,
+			formatReportMoney, revenueSeries(profession, func(p repositories.RevenuePeriodData) float64 { return p.Revenue },
+				func(h *repositories.HourlyRevenueMetrics) map[string]float64 { return h.RevenueByHour }), sparklineFor)
+		if err != nil {
+			return nil, err
+		}
+		professions.Rows = append(professions.Rows, row)
+	}
+	view.Sections = []reportSection{traffic, salesSection, professions}
+
+	var html bytes.Buffer
+	if err := reportTemplate.Execute(&html, view); err != nil {
+		return nil, fmt.Errorf("erro ao montar o relatório: %w", err)
+	}
+	content.HTML = html.String()
+	content.Text = reportText(view)
+
+	attachments, err := reportAttachments(data, sessions, leads, conversion)
+	if err != nil {
+		return nil, err
+	}
+	content.Attachments = attachments
+	return content, nil
+}
+
+func kpiRow(label string, current, previous float64, format func(float64) string, series reportSeries, sparklineFor func(reportSeries) (string, error)) (reportKPIRow, error) {
+	row := reportKPIRow{
+		Label:       label,
+		Current:     format(current),
+		Previous:    format(previous),
+		Change:      "—",
+		ChangeColor: reportColorNeutral,
+	}
+	if change, ok := percentChange(current, previous); ok {
+		row.Change = formatReportChange(change)
+		switch {
+		case change > 0:
+			row.ChangeColor = reportColorUp
+		case change < 0:
+			row.ChangeColor = reportColorDown
+		}
+	}
+	contentID, err := sparklineFor(series)
+	if err != nil {
+		return row, err
+	}
+	row.Sparkline = contentID
+	return row, nil
+}
+
+// dashboardSeries extrai as séries de sessões, leads e conversão do gráfico do dashboard.
+// Com um único ponto (ex.: relatório de um dia), usa os dados por hora.
+func dashboardSeries(dashboard DashboardResult) (sessions, leads, conversion reportSeries) {
+	if len(dashboard.ChartData) < 2 && dashboard.HourlyData != nil {
+		for _, hour := range sortedKeys(dashboard.HourlyData.SessionsByHour) {
+			s, l := dashboard.HourlyData.SessionsByHour[hour], dashboard.HourlyData.LeadsByHour[hour]
+			sessions = appendPoint(sessions, hour, float64(s))
+			leads = appendPoint(leads, hour, float64(l))
+			conversion = appendPoint(conversion, hour, conversionRate(l, s))
+		}
+		return
+	}
+	for _, point := range dashboard.ChartData {
+		sessions = appendPoint(sessions, point.Period, float64(point.Sessions))
+		leads = appendPoint(leads, point.Period, float64(point.Leads))
+		conversion = appendPoint(conversion, point.Period, conversionRate(point.Leads, point.Sessions))
+	}
+	return
+}
+
+// revenueSeries extrai uma série do gráfico de uma profissão, ou dos dados por hora com um único ponto
+func revenueSeries(data repositories.RevenueComparisonData, value func(repositories.RevenuePeriodData) float64, hourly func(*repositories.HourlyRevenueMetrics) map[string]float64) reportSeries {
+	var series reportSeries
+	if len(data.ChartData) < 2 && data.HourlyData != nil {
+		values := hourly(data.HourlyData)
+		for _, hour := range sortedKeys(values) {
+			series = appendPoint(series, hour, values[hour])
+		}
+		return series
+	}
+	for _, point := range data.ChartData {
+		series = appendPoint(series, point.Period, value(point))
+	}
+	return series
+}
+
+// revenueTotalSeries soma as séries de leads, vendas e faturamento de todas as profissões, período a período
+func revenueTotalSeries(revenue []repositories.RevenueComparisonData) (leads, purchases, total reportSeries) {
+	sum := func(value func(repositories.RevenuePeriodData) float64, hourly func(*repositories.HourlyRevenueMetrics) map[string]float64) reportSeries {
+		totals := map[string]float64{}
+		for _, profession := range revenue {
+			series := revenueSeries(profession, value, hourly)
+			for i, period := range series.Periods {
+				totals[period] += series.Values[i]
+			}
+		}
+		var series reportSeries
+		for _, period := range sortedKeys(totals) {
+			series = appendPoint(series, period, totals[period])
+		}
+		return series
+	}
+
+	// Os dados por hora são os mesmos em todas as profissões (já filtrados), então basta a primeira
+	if len(revenue) > 0 && len(revenue[0].ChartData) < 2 && revenue[0].HourlyData != nil {
+		revenue = revenue[:1]
+	}
+	leads = sum(func(p repositories.RevenuePeriodData) float64 { return float64(p.Leads) },
+		func(h *repositories.HourlyRevenueMetrics) map[string]float64 { return int64Map(h.LeadsByHour) })
+	purchases = sum(func(p repositories.RevenuePeriodData) float64 { return float64(p.Purchases) },
+		func(h *repositories.HourlyRevenueMetrics) map[string]float64 { return int64Map(h.PurchasesByHour) })
+	total = sum(func(p repositories.RevenuePeriodData) float64 { return p.Revenue },
+		func(h *repositories.HourlyRevenueMetrics) map[string]float64 { return h.RevenueByHour })
+	return
+}
+
+// reportAttachments gera os CSVs anexos: KPIs, faturamento por profissão e as séries
+func reportAttachments(data *ReportData, sessions, leads, conversion reportSeries) ([]mailer.Attachment, error) {
+	metrics := data.Dashboard.Metrics
+	if metrics == nil {
+		metrics = &Metrics{}
+	}
+
+	kpis := [][]string{{"metric", "current", "previous", "change_pct"}}
+	addKPI := func(name string, current, previous float64) {
+		change := ""
+		if value, ok := percentChange(current, previous); ok {
+			change = formatCSVNumber(value)
+		}
+		kpis = append(kpis, []string{name, formatCSVNumber(current), formatCSVNumber(previous), change})
+	}
+	addKPI("sessions", float64(metrics.Sessions), float64(metrics.PrevSessions))
+	addKPI("leads", float64(metrics.Leads), float64(metrics.PrevLeads))
+	addKPI("conversion_rate", conversionRate(metrics.Leads, metrics.Sessions), conversionRate(metrics.PrevLeads, metrics.PrevSessions))
+
+	var revenueLeads, revenuePurchases int64
+	var revenue, previousRevenue float64
+	professions := [][]string{{"profession_id", "profession_name", "leads", "leads_previous", "purchases", "purchases_previous", "revenue", "revenue_previous", "revenue_change_pct"}}
+	revenueSeriesRows := [][]string{{"profession_id", "profession_name", "period", "leads", "purchases", "revenue"}}
+	for _, profession := range data.Revenue {
+		revenueLeads += profession.Leads.Current
+		revenuePurchases += profession.Purchases.Current
+		revenue += profession.Revenue.Current
+		previousRevenue += profession.Revenue.Previous
+
+		change := ""
+		if value, ok := percentChange(profession.Revenue.Current, profession.Revenue.Previous); ok {
+			change = formatCSVNumber(value)
+		}
+		professions = append(professions, []string{
+			strconv.Itoa(profession.ProfessionID), profession.ProfessionName,
+			strconv.FormatInt(profession.Leads.Current, 10), strconv.FormatInt(profession.Leads.Previous, 10),
+			strconv.FormatInt(profession.Purchases.Current, 10), strconv.FormatInt(profession.Purchases.Previous, 10),
+			formatCSVNumber(profession.Revenue.Current), formatCSVNumber(profession.Revenue.Previous), change,
+		})
+		for _, point := range profession.ChartData {
+			revenueSeriesRows = append(revenueSeriesRows, []string{
+				strconv.Itoa(profession.ProfessionID), profession.ProfessionName, point.Period,
+				strconv.FormatInt(point.Leads, 10), strconv.FormatInt(point.Purchases, 10), formatCSVNumber(point.Revenue),
+			})
+		}
+	}
+	addKPI("revenue", revenue, previousRevenue)
+
+	series := [][]string{{"period", "sessions", "leads", "conversion_rate"}}
+	for i, period := range sessions.Periods {
+		series = append(series, []string{period, formatCSVNumber(sessions.Values[i]), formatCSVNumber(leads.Values[i]), formatCSVNumber(conversion.Values[i])})
+	}
+
+	files := []struct {
+		name string
+		rows [][]string
+	}{
+		{"kpis.csv", kpis},
+		{"revenue_by_profession.csv", professions},
+		{"series.csv", series},
+		{"revenue_series.csv", revenueSeriesRows},
+	}
+	attachments := make([]mailer.Attachment, 0, len(files))
+	for _, file := range files {
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		if err := w.WriteAll(file.rows); err != nil {
+			return nil, fmt.Errorf("erro ao gerar %s: %w", file.name, err)
+		}
+		attachments = append(attachments, mailer.Attachment{
+			Filename:    file.name,
+			ContentType: "text/csv; charset=utf-8",
+			Data:        buf.Bytes(),
+		})
+	}
+	return attachments, nil
+}
+
+// reportText é a versão em texto do e-mail, para clientes que não exibem HTML
+func reportText(view reportView) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n%s\n", view.Title, view.Period)
+	if view.Comparison != "" {
+		fmt.Fprintf(&b, "%s\n", view.Comparison)
+	}
+	if view.Filters != "" {
+		fmt.Fprintf(&b, "%s\n", view.Filters)
+	}
+	for _, section := range view.Sections {
+		if len(section.Rows) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s\n", section.Title)
+		for _, row := range section.Rows {
+			fmt.Fprintf(&b, "- %s: %s (anterior %s, %s)\n", row.Label, row.Current, row.Previous, row.Change)
+		}
+	}
+	fmt.Fprintf(&b, "\nGerado em %s. Os dados completos estão nos CSVs anexos.\n", view.GeneratedAt)
+	return b.String()
+}
+
+// reportComparisonLabel descreve o período de comparação aplicado pelo dashboard
+func reportComparisonLabel(filters map[string]string) string {
+	from, errFrom := time.Parse(time.RFC3339, filters["compare_from"])
+	to, errTo := time.Parse(time.RFC3339, filters["compare_to"])
+	if errFrom != nil || errTo != nil {
+		return ""
+	}
+	label := "Comparado com " + from.Format("02/01/2006")
+	if to.Format("2006-01-02") != from.Format("2006-01-02") {
+		label += " a " + to.Format("02/01/2006")
+	}
+	return label
+}
+
+func reportFiltersLabel(data *ReportData) string {
+	var parts []string
+	if data.Filters.ProfessionID > 0 {
+		name := strconv.Itoa(data.Filters.ProfessionID)
+		for _, profession := range data.Revenue {
+			if profession.ProfessionID == data.Filters.ProfessionID && profession.ProfessionName != "" {
+				name = profession.ProfessionName
+			}
+		}
+		parts = append(parts, "Profissão: "+name)
+	}
+	if data.Filters.FunnelID > 0 {
+		parts = append(parts, fmt.Sprintf("Funil: %d", data.Filters.FunnelID))
+	}
+	if data.Filters.ProductID > 0 {
+		parts = append(parts, fmt.Sprintf("Produto: %d", data.Filters.ProductID))
+	}
+	if data.Filters.SegmentID > 0 {
+		parts = append(parts, fmt.Sprintf("Segmento: %d", data.Filters.SegmentID))
+	}
+	if len(parts) > 0 && (data.Filters.FunnelID > 0 || data.Filters.ProductID > 0 || data.Filters.SegmentID > 0) {
+		// Faturamento por profissão não tem esses filtros
+		parts = append(parts, "(funil, produto e segmento não se aplicam às vendas)")
+	}
+	return strings.Join(parts, " · ")
+}
+
+func reportProfessionName(data repositories.RevenueComparisonData) string {
+	if data.ProfessionName != "" {
+		return data.ProfessionName
+	}
+	return fmt.Sprintf("Profissão %d", data.ProfessionID)
+}
+
+func appendPoint(series reportSeries, period string, value float64) reportSeries {
+	series.Periods = append(series.Periods, period)
+	series.Values = append(series.Values, value)
+	return series
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func int64Map(values map[string]int64) map[string]float64 {
+	converted := make(map[string]float64, len(values))
+	for key, value := range values {
+		converted[key] = float64(value)
+	}
+	return converted
+}
+
+// conversionRate é a taxa de leads por sessão, em porcentagem, como no dashboard
+func conversionRate(leads, sessions int64) float64 {
+	if sessions == 0 {
+		return 0
+	}
+	return float64(leads) / float64(sessions) * 100
+}
+
+// percentChange retorna a variação percentual; sem valor anterior não há variação
+func percentChange(current, previous float64) (float64, bool) {
+	if previous == 0 {
+		return 0, false
+	}
+	return (current - previous) / previous * 100, true
+}
+
+// formatReportNumber formata no padrão brasileiro: ponto nos milhares e vírgula nos decimais
+func formatReportNumber(value float64, decimals int) string {
+	negative := value < 0
+	formatted := strconv.FormatFloat(math.Abs(value), 'f', decimals, 64)
+	integer, fraction := formatted, ""
+	if i := strings.IndexByte(formatted, '.'); i >= 0 {
+		integer, fraction = formatted[:i], formatted[i+1:]
+	}
+
+	var b strings.Builder
+	if negative {
+		b.WriteByte('-')
+	}
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(digit)
+	}
+	if fraction != "" {
+		b.WriteByte(',')
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+func formatReportInt(value float64) string {
+	return formatReportNumber(value, 0)
+}
+
+func formatReportRate(value float64) string {
+	return formatReportNumber(value, 2) + "%"
+}
+
+func formatReportMoney(value float64) string {
+	return "R$ " + formatReportNumber(value, 2)
+}
+
+func formatReportChange(value float64) string {
+	sign := ""
+	if value > 0 {
+		sign = "+"
+	}
+	return sign + formatReportNumber(value, 1) + "%"
+}
+
+// formatCSVNumber formata os números dos CSVs com ponto decimal, para leitura por planilhas e scripts
+func formatCSVNumber(value float64) string {
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64)
+}
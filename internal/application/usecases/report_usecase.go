@@ -0,0 +1,522 @@
+package usecases
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	mailer "github.com/PavaniTiago/beta-intelligence-api/internal/infrastructure/mail"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrReportNotFound indica que o relatório solicitado não existe
+	ErrReportNotFound = errors.New("relatório não encontrado")
+	// ErrInvalidReport indica que a configuração do relatório não é válida
+	ErrInvalidReport = errors.New("relatório inválido")
+)
+
+const (
+	// Intervalo padrão entre as verificações de relatórios com envio vencido
+	defaultReportPollSeconds = 30
+	// reportMisfireGrace é o atraso máximo de um envio agendado; envios mais atrasados (API fora do ar)
+	// são registrados como perdidos em vez de enviados fora de hora
+	reportMisfireGrace = time.Hour
+	// reportMaxRecipients limita os destinatários de um relatório
+	reportMaxRecipients = 50
+)
+
+// Períodos de comparação aceitos nos relatórios (os mesmos do parâmetro compare do dashboard, exceto custom)
+var reportCompareModes = map[string]bool{CompareModePrevious: true, CompareModePreviousYear: true, CompareModeSameWeekdayLastWeek: true}
+
+// ReportMailer entrega as mensagens dos relatórios
+type ReportMailer interface {
+	Configured() bool
+	Send(msg *mailer.Message) error
+}
+
+// ReportInput representa os dados de criação/alteração de um relatório.
+// Na alteração, apenas os campos informados são modificados.
+type ReportInput struct {
+	Name       *string                 `json:"name"`
+	Schedule   *string                 `json:"schedule"`
+	Period     *string                 `json:"period"`
+	Filters    *entities.ReportFilters `json:"filters"`
+	Recipients *[]string               `json:"recipients"`
+	Enabled    *bool                   `json:"enabled"`
+}
+
+// ReportDelivery é o resultado de um envio
+type ReportDelivery struct {
+	ReportID   int64     `json:"report_id"`
+	Subject    string    `json:"subject"`
+	Recipients []string  `json:"recipients"`
+	SentAt     time.Time `json:"sent_at"`
+}
+
+// ReportUseCase define as operações dos relatórios de KPIs enviados por e-mail
+type ReportUseCase interface {
+	ListReports() ([]entities.ScheduledReport, error)
+	GetReport(reportID int64) (*entities.ScheduledReport, error)
+	CreateReport(input ReportInput) (*entities.ScheduledReport, error)
+	UpdateReport(reportID int64, input ReportInput) (*entities.ScheduledReport, error)
+	DeleteReport(reportID int64) error
+
+	// SendReport gera o relatório do período atual e o envia imediatamente, fora do agendamento
+	SendReport(reportID int64) (*ReportDelivery, error)
+	// PreviewReport gera o HTML do relatório do período atual, com as imagens embutidas, sem enviar
+	PreviewReport(reportID int64) (string, error)
+	// RunDue envia os relatórios com envio vencido em now
+	RunDue(now time.Time)
+}
+
+type reportUseCase struct {
+	reportRepo   repositories.ReportRepository
+	cycleUseCase WebinarCycleUseCase
+	kpiSource    ReportKPISource
+	mailer       ReportMailer
+}
+
+func NewReportUseCase(reportRepo repositories.ReportRepository, cycleUseCase WebinarCycleUseCase, kpiSource ReportKPISource, mailer ReportMailer) ReportUseCase {
+	return &reportUseCase{
+		reportRepo:   reportRepo,
+		cycleUseCase: cycleUseCase,
+		kpiSource:    kpiSource,
+		mailer:       mailer,
+	}
+}
+
+func (uc *reportUseCase) ListReports() ([]entities.ScheduledReport, error) {
+	return uc.reportRepo.ListReports()
+}
+
+func (uc *reportUseCase) GetReport(reportID int64) (*entities.ScheduledReport, error) {
+	report, err := uc.reportRepo.GetReport(reportID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReportNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar relatório: %w", err)
+	}
+	return report, nil
+}
+
+func (uc *reportUseCase) CreateReport(input ReportInput) (*entities.ScheduledReport, error) {
+	if input.Schedule == nil || input.Period == nil || input.Recipients == nil {
+		return nil, fmt.Errorf("%w: schedule, period e recipients são obrigatórios", ErrInvalidReport)
+	}
+	report := &entities.ScheduledReport{
+		Enabled:    true,
+		Filters:    json.RawMessage(`{}`),
+		Recipients: json.RawMessage(`[]`),
+	}
+	if err := input.apply(report); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report.NextRunAt = nextReportRun(report, now)
+	report.CreatedAt = now
+	report.UpdatedAt = now
+	if err := uc.reportRepo.CreateReport(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (uc *reportUseCase) UpdateReport(reportID int64, input ReportInput) (*entities.ScheduledReport, error) {
+	report, err := uc.GetReport(reportID)
+	if err != nil {
+		return nil, err
+	}
+	schedule, enabled := report.Schedule, report.Enabled
+	if err := input.apply(report); err != nil {
+		return nil, err
+	}
+
+	// O próximo envio só muda com o agendamento; recalculá-lo sempre poderia pular um envio já vencido
+	now := time.Now()
+	if report.Schedule != schedule || report.Enabled != enabled || report.NextRunAt == nil {
+		report.NextRunAt = nextReportRun(report, now)
+	}
+	report.UpdatedAt = now
+	if err := uc.reportRepo.UpdateReport(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (uc *reportUseCase) DeleteReport(reportID int64) error {
+	err := uc.reportRepo.DeleteReport(reportID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrReportNotFound
+	}
+	return err
+}
+
+func (uc *reportUseCase) SendReport(reportID int64) (*ReportDelivery, error) {
+	report, err := uc.GetReport(reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := uc.deliver(report, time.Now())
+	status, errMsg := entities.ReportStatusSent, ""
+	if err != nil {
+		status, errMsg = entities.ReportStatusFailed, err.Error()
+	}
+	if recordErr := uc.reportRepo.RecordRun(report.ReportID, time.Now(), status, errMsg); recordErr != nil {
+		log.Printf("⚠️ %v", recordErr)
+	}
+	return delivery, err
+}
+
+func (uc *reportUseCase) PreviewReport(reportID int64) (string, error) {
+	report, err := uc.GetReport(reportID)
+	if err != nil {
+		return "", err
+	}
+	content, err := uc.render(report, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	// No navegador as imagens cid: não existem: embutir como data URI
+	html := content.HTML
+	for _, image := range content.Inline {
+		html = strings.ReplaceAll(html, "cid:"+image.ContentID,
+			"data:"+image.ContentType+";base64,"+base64.StdEncoding.EncodeToString(image.Data))
+	}
+	return html, nil
+}
+
+// RunDue envia os relatórios vencidos. Cada envio é reservado avançando next_run_at para a próxima
+// ocorrência antes de enviar, de modo que, com várias instâncias, cada ocorrência é enviada uma única vez.
+func (uc *reportUseCase) RunDue(now time.Time) {
+	reports, err := uc.reportRepo.ListDueReports(now)
+	if err != nil {
+		log.Printf("⚠️ %v", err)
+		return
+	}
+
+	for i := range reports {
+		report := &reports[i]
+		scheduledFor := *report.NextRunAt
+		claimed, err := uc.reportRepo.ClaimRun(report.ReportID, scheduledFor, nextReportRun(report, now))
+		if err != nil {
+			log.Printf("⚠️ %v", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		status, errMsg := entities.ReportStatusSent, ""
+		if late := now.Sub(scheduledFor); late > reportMisfireGrace {
+			status, errMsg = entities.ReportStatusMissed, fmt.Sprintf("envio de %s perdido (%s de atraso)",
+				scheduledFor.In(utils.GetBrasilLocation()).Format("02/01/2006 15:04"), late.Round(time.Minute))
+			log.Printf("⚠️ Relatório %d: %s", report.ReportID, errMsg)
+		} else if _, err := uc.deliver(report, scheduledFor); err != nil {
+			status, errMsg = entities.ReportStatusFailed, err.Error()
+			log.Printf("⚠️ Erro ao enviar relatório %d (%s): %v", report.ReportID, report.Name, err)
+		} else {
+			log.Printf("📧 Relatório %d (%s) enviado", report.ReportID, report.Name)
+		}
+		if err := uc.reportRepo.RecordRun(report.ReportID, time.Now(), status, errMsg); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+	}
+}
+
+// deliver gera o relatório do período relativo a at e o envia aos destinatários
+func (uc *reportUseCase) deliver(report *entities.ScheduledReport, at time.Time) (*ReportDelivery, error) {
+	var recipients []string
+	if err := json.Unmarshal(report.Recipients, &recipients); err != nil || len(recipients) == 0 {
+		return nil, fmt.Errorf("%w: o relatório não tem destinatários", ErrInvalidReport)
+	}
+	if !uc.mailer.Configured() {
+		return nil, mailer.ErrNotConfigured
+	}
+
+	content, err := uc.render(report, at)
+	if err != nil {
+		return nil, err
+	}
+	msg := &mailer.Message{
+		To:          recipients,
+		Subject:     content.Subject,
+		HTML:        content.HTML,
+		Text:        content.Text,
+		Inline:      content.Inline,
+		Attachments: content.Attachments,
+	}
+	if err := uc.mailer.Send(msg); err != nil {
+		return nil, err
+	}
+	return &ReportDelivery{
+		ReportID:   report.ReportID,
+		Subject:    content.Subject,
+		Recipients: recipients,
+		SentAt:     time.Now(),
+	}, nil
+}
+
+// render busca os KPIs do período relativo a at e monta o e-mail
+func (uc *reportUseCase) render(report *entities.ScheduledReport, at time.Time) (*ReportContent, error) {
+	var filters entities.ReportFilters
+	if len(report.Filters) > 0 {
+		if err := json.Unmarshal(report.Filters, &filters); err != nil {
+			return nil, fmt.Errorf("%w: filtros inválidos: %v", ErrInvalidReport, err)
+		}
+	}
+
+	period, err := uc.resolvePeriod(report.Period, filters, at)
+	if err != nil {
+		return nil, err
+	}
+	data := &ReportData{Report: report, Filters: filters, Period: period}
+
+	if data.Dashboard, err = uc.kpiSource.Dashboard(period, filters); err != nil {
+		return nil, err
+	}
+	if data.Revenue, err = uc.kpiSource.RevenueByProfession(period, filters); err != nil {
+		return nil, err
+	}
+
+	return renderReport(data)
+}
+
+// ReportPeriod é o período coberto por um envio: um intervalo de datas ou um ciclo de webinar
+type ReportPeriod struct {
+	From  time.Time
+	To    time.Time
+	Cycle *entities.WebinarCycle
+	Label string
+}
+
+// resolvePeriod calcula o período relativo no dia de at (America/Sao_Paulo)
+func (uc *reportUseCase) resolvePeriod(period string, filters entities.ReportFilters, at time.Time) (ReportPeriod, error) {
+	now := at.In(utils.GetBrasilLocation())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var from, to time.Time
+	switch period {
+	case entities.ReportPeriodToday:
+		from, to = today, today
+	case entities.ReportPeriodYesterday:
+		from, to = today.AddDate(0, 0, -1), today.AddDate(0, 0, -1)
+	case entities.ReportPeriodLast7Days:
+		from, to = today.AddDate(0, 0, -7), today.AddDate(0, 0, -1)
+	case entities.ReportPeriodLast30Days:
+		from, to = today.AddDate(0, 0, -30), today.AddDate(0, 0, -1)
+	case entities.ReportPeriodMonthToDate:
+		from, to = today.AddDate(0, 0, 1-today.Day()), today
+	case entities.ReportPeriodLastMonth:
+		to = today.AddDate(0, 0, -today.Day())
+		from = to.AddDate(0, 0, 1-to.Day())
+	case entities.ReportPeriodLastCycle:
+		cycle, err := uc.lastEndedCycle(filters, at)
+		if err != nil {
+			return ReportPeriod{}, err
+		}
+		return ReportPeriod{
+			From:  cycle.PesquisaInicio,
+			To:    cycle.VendaFim,
+			Cycle: cycle,
+			Label: fmt.Sprintf("ciclo %s (%s a %s)", cycle.Name,
+				cycle.PesquisaInicio.In(now.Location()).Format("02/01/2006"), cycle.VendaFim.In(now.Location()).Format("02/01/2006")),
+		}, nil
+	default:
+		return ReportPeriod{}, fmt.Errorf("%w: period desconhecido: %s", ErrInvalidReport, period)
+	}
+
+	label := from.Format("02/01/2006")
+	if !to.Equal(from) {
+		label += " a " + to.Format("02/01/2006")
+	}
+	return ReportPeriod{From: from, To: to, Label: label}, nil
+}
+
+// lastEndedCycle retorna o ciclo de webinar mais recente da profissão/funil já encerrado em at
+func (uc *reportUseCase) lastEndedCycle(filters entities.ReportFilters, at time.Time) (*entities.WebinarCycle, error) {
+	cycles, _, err := uc.cycleUseCase.ListCycles(repositories.WebinarCycleFilter{
+		ProfessionID: filters.ProfessionID,
+		FunnelID:     filters.FunnelID,
+		To:           at,
+	}, 1, 10, false)
+	if err != nil {
+		return nil, err
+	}
+	for i := range cycles {
+		if cycles[i].VendaFim.Before(at) {
+			return &cycles[i].WebinarCycle, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: nenhum ciclo de webinar encerrado para a profissão %d", ErrWebinarCycleNotFound, filters.ProfessionID)
+}
+
+// nextReportRun retorna a próxima ocorrência do agendamento após now, ou nil se o relatório está desativado
+func nextReportRun(report *entities.ScheduledReport, now time.Time) *time.Time {
+	if !report.Enabled {
+		return nil
+	}
+	schedule, err := utils.ParseCronSchedule(report.Schedule)
+	if err != nil {
+		return nil
+	}
+	next := schedule.Next(now.In(utils.GetBrasilLocation()))
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
+
+func (input ReportInput) apply(report *entities.ScheduledReport) error {
+	if input.Name != nil {
+		report.Name = strings.TrimSpace(*input.Name)
+	}
+	if report.Name == "" {
+		return fmt.Errorf("%w: name é obrigatório", ErrInvalidReport)
+	}
+	if input.Schedule != nil {
+		schedule, err := utils.ParseCronSchedule(*input.Schedule)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidReport, err)
+		}
+		if schedule.Next(time.Now()).IsZero() {
+			return fmt.Errorf("%w: o agendamento %q nunca ocorre", ErrInvalidReport, *input.Schedule)
+		}
+		report.Schedule = schedule.String()
+	}
+	if input.Period != nil {
+		report.Period = strings.ToLower(strings.TrimSpace(*input.Period))
+	}
+	switch report.Period {
+	case entities.ReportPeriodToday, entities.ReportPeriodYesterday, entities.ReportPeriodLast7Days,
+		entities.ReportPeriodLast30Days, entities.ReportPeriodMonthToDate, entities.ReportPeriodLastMonth,
+		entities.ReportPeriodLastCycle:
+	default:
+		return fmt.Errorf("%w: period deve ser today, yesterday, last_7_days, last_30_days, month_to_date, last_month ou last_cycle", ErrInvalidReport)
+	}
+	if input.Enabled != nil {
+		report.Enabled = *input.Enabled
+	}
+
+	if input.Recipients != nil {
+		recipients, err := normalizeRecipients(*input.Recipients)
+		if err != nil {
+			return err
+		}
+		raw, err := json.Marshal(recipients)
+		if err != nil {
+			return fmt.Errorf("erro ao serializar destinatários: %w", err)
+		}
+		report.Recipients = raw
+	}
+
+	var filters entities.ReportFilters
+	if input.Filters != nil {
+		filters = *input.Filters
+	} else if len(report.Filters) > 0 {
+		if err := json.Unmarshal(report.Filters, &filters); err != nil {
+			return fmt.Errorf("%w: filtros inválidos: %v", ErrInvalidReport, err)
+		}
+	}
+	if err := validateReportFilters(&filters, report.Period); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(filters)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar filtros: %w", err)
+	}
+	report.Filters = raw
+	return nil
+}
+
+// normalizeRecipients valida os endereços e remove duplicados
+func normalizeRecipients(recipients []string) ([]string, error) {
+	seen := make(map[string]bool, len(recipients))
+	normalized := make([]string, 0, len(recipients))
+	for _, recipient := range recipients {
+		recipient = strings.TrimSpace(recipient)
+		if recipient == "" {
+			continue
+		}
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return nil, fmt.Errorf("%w: destinatário inválido: %s", ErrInvalidReport, recipient)
+		}
+		key := strings.ToLower(address.Address)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, recipient)
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("%w: informe ao menos um destinatário", ErrInvalidReport)
+	}
+	if len(normalized) > reportMaxRecipients {
+		return nil, fmt.Errorf("%w: no máximo %d destinatários", ErrInvalidReport, reportMaxRecipients)
+	}
+	return normalized, nil
+}
+
+func validateReportFilters(filters *entities.ReportFilters, period string) error {
+	if filters.TimeFrame != "" {
+		if _, ok := entities.NormalizeTimeFrame(filters.TimeFrame); !ok {
+			return fmt.Errorf("%w: time_frame deve ser daily, weekly, monthly ou yearly", ErrInvalidReport)
+		}
+		filters.TimeFrame = strings.ToLower(filters.TimeFrame)
+	}
+	if filters.Compare != "" {
+		filters.Compare = strings.ToLower(filters.Compare)
+		if !reportCompareModes[filters.Compare] {
+			return fmt.Errorf("%w: compare deve ser previous, previous_year ou same_weekday_last_week", ErrInvalidReport)
+		}
+	}
+	if filters.ProfessionID < 0 || filters.FunnelID < 0 || filters.ProductID < 0 || filters.SegmentID < 0 {
+		return fmt.Errorf("%w: os IDs dos filtros devem ser positivos", ErrInvalidReport)
+	}
+	if period == entities.ReportPeriodLastCycle && filters.ProfessionID == 0 {
+		return fmt.Errorf("%w: o período last_cycle exige filters.profession_id", ErrInvalidReport)
+	}
+	return nil
+}
+
+// ReportScheduler verifica periodicamente os relatórios com envio vencido e os envia
+type ReportScheduler struct {
+	reportUseCase ReportUseCase
+	interval      time.Duration
+}
+
+// NewReportScheduler cria o agendador com o intervalo da variável REPORT_POLL_SECONDS
+func NewReportScheduler(reportUseCase ReportUseCase) *ReportScheduler {
+	return &ReportScheduler{
+		reportUseCase: reportUseCase,
+		interval:      time.Duration(envInt("REPORT_POLL_SECONDS", defaultReportPollSeconds)) * time.Second,
+	}
+}
+
+// Run verifica os relatórios vencidos até o contexto ser cancelado
+func (s *ReportScheduler) Run(ctx context.Context) {
+	log.Printf("📧 Agendador de relatórios iniciado (intervalo %v)", s.interval)
+	for {
+		s.reportUseCase.RunDue(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.interval):
+		}
+	}
+}
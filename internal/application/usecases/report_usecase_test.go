@@ -0,0 +1,207 @@
+package usecases
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	netmail "net/mail"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	mailer "github.com/PavaniTiago/beta-intelligence-api/internal/infrastructure/mail"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/infrastructure/mail/mailtest"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// fakeReportRepository guarda os relatórios em memória e registra os envios
+type fakeReportRepository struct {
+	reports map[int64]*entities.ScheduledReport
+	runs    []string
+}
+
+func (r *fakeReportRepository) ListReports() ([]entities.ScheduledReport, error) { return nil, nil }
+func (r *fakeReportRepository) GetReport(reportID int64) (*entities.ScheduledReport, error) {
+	report, ok := r.reports[reportID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	clone := *report
+	return &clone, nil
+}
+func (r *fakeReportRepository) CreateReport(report *entities.ScheduledReport) error { return nil }
+func (r *fakeReportRepository) UpdateReport(report *entities.ScheduledReport) error { return nil }
+func (r *fakeReportRepository) DeleteReport(reportID int64) error                   { return nil }
+func (r *fakeReportRepository) ListDueReports(now time.Time) ([]entities.ScheduledReport, error) {
+	return nil, nil
+}
+func (r *fakeReportRepository) ClaimRun(reportID int64, scheduledFor time.Time, next *time.Time) (bool, error) {
+	return true, nil
+}
+func (r *fakeReportRepository) RecordRun(reportID int64, at time.Time, status, errMsg string) error {
+	r.runs = append(r.runs, status)
+	return nil
+}
+
+// fakeReportKPISource retorna KPIs fixos e guarda o período pedido
+type fakeReportKPISource struct {
+	period  ReportPeriod
+	filters entities.ReportFilters
+}
+
+func (s *fakeReportKPISource) Dashboard(period ReportPeriod, filters entities.ReportFilters) (DashboardResult, error) {
+	s.period, s.filters = period, filters
+	return DashboardResult{
+		Metrics: &Metrics{Sessions: 120, Leads: 30, PrevSessions: 100, PrevLeads: 20},
+		ChartData: []entities.DashboardPeriodData{
+			{Period: "2024-06-01", Sessions: 50, Leads: 10},
+			{Period: "2024-06-02", Sessions: 70, Leads: 20},
+		},
+	}, nil
+}
+
+func (s *fakeReportKPISource) RevenueByProfession(period ReportPeriod, filters entities.ReportFilters) ([]repositories.RevenueComparisonData, error) {
+	return []repositories.RevenueComparisonData{{
+		ProfessionID:   3,
+		ProfessionName: "Nutrição",
+		Leads:          repositories.RevenueMetricResult{Current: 30, Previous: 20},
+		Purchases:      repositories.RevenueMetricResult{Current: 4, Previous: 2},
+		Revenue:        repositories.RevenueMetricResultFloat{Current: 1990.5, Previous: 995},
+	}}, nil
+}
+
+func TestReportUseCaseSendReport(t *testing.T) {
+	server := mailtest.NewServer(t)
+	sender := mailer.NewSender(mailer.Config{
+		Host: server.Host(),
+		Port: server.Port(),
+		From: "relatorios@example.com",
+		TLS:  mailer.TLSNone,
+	})
+	repo := &fakeReportRepository{reports: map[int64]*entities.ScheduledReport{
+		1: {
+			ReportID:   1,
+			Name:       "Resumo diário",
+			Schedule:   "0 8 * * *",
+			Period:     entities.ReportPeriodYesterday,
+			Filters:    json.RawMessage(`{"profession_id":3,"compare":"previous_year"}`),
+			Recipients: json.RawMessage(`["ana@example.com","Bruno <bruno@example.com>"]`),
+			Enabled:    true,
+		},
+		2: {ReportID: 2, Name: "Sem destinatários", Period: entities.ReportPeriodToday, Recipients: json.RawMessage(`[]`)},
+	}}
+	kpis := &fakeReportKPISource{}
+	uc := NewReportUseCase(repo, nil, kpis, sender)
+
+	delivery, err := uc.SendReport(1)
+	if err != nil {
+		t.Fatalf("SendReport(1): %v", err)
+	}
+
+	// Período de ontem no fuso de São Paulo, com os filtros do relatório
+	now := time.Now().In(utils.GetBrasilLocation())
+	yesterday := time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, now.Location())
+	if !kpis.period.From.Equal(yesterday) || !kpis.period.To.Equal(yesterday) {
+		t.Errorf("período dos KPIs = %v a %v; esperado %v", kpis.period.From, kpis.period.To, yesterday)
+	}
+	if kpis.filters.ProfessionID != 3 || kpis.filters.Compare != CompareModePreviousYear {
+		t.Errorf("filtros dos KPIs = %+v; esperado profissão 3 e compare previous_year", kpis.filters)
+	}
+	if want := "Resumo diário — " + yesterday.Format("02/01/2006"); delivery.Subject != want {
+		t.Errorf("assunto = %q; esperado %q", delivery.Subject, want)
+	}
+	if !reflect.DeepEqual(repo.runs, []string{entities.ReportStatusSent}) {
+		t.Errorf("envios registrados = %v; esperado [%s]", repo.runs, entities.ReportStatusSent)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("%d mensagens recebidas; esperado 1", len(messages))
+	}
+	if want := []string{"ana@example.com", "bruno@example.com"}; !reflect.DeepEqual(messages[0].To, want) {
+		t.Errorf("RCPT TO = %v; esperado %v", messages[0].To, want)
+	}
+	msg, err := netmail.ReadMessage(bytes.NewReader(messages[0].Data))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); subject != delivery.Subject {
+		t.Errorf("Subject = %q; esperado %q", subject, delivery.Subject)
+	}
+
+	files := reportMessageFiles(t, msg)
+	for _, name := range []string{"kpis.csv", "revenue_by_profession.csv", "series.csv", "revenue_series.csv", "sparkline-1.png"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("arquivo %s ausente da mensagem", name)
+		}
+	}
+	for _, line := range []string{"sessions,120,100,20", "leads,30,20,50", "revenue,1990.5,995,100.05"} {
+		if !strings.Contains(files["kpis.csv"], line+"\n") {
+			t.Errorf("kpis.csv sem a linha %q:\n%s", line, files["kpis.csv"])
+		}
+	}
+	if !strings.Contains(files["revenue_by_profession.csv"], "3,Nutrição,30,20,4,2,1990.5,995,") {
+		t.Errorf("revenue_by_profession.csv sem a profissão 3:\n%s", files["revenue_by_profession.csv"])
+	}
+
+	// Sem destinatários: falha registrada e nenhuma mensagem enviada
+	if _, err := uc.SendReport(2); !errors.Is(err, ErrInvalidReport) {
+		t.Errorf("SendReport(2) = %v; esperado ErrInvalidReport", err)
+	}
+	if got := repo.runs[len(repo.runs)-1]; got != entities.ReportStatusFailed {
+		t.Errorf("envio sem destinatários registrado como %s; esperado %s", got, entities.ReportStatusFailed)
+	}
+	if _, err := uc.SendReport(3); !errors.Is(err, ErrReportNotFound) {
+		t.Errorf("SendReport(3) = %v; esperado ErrReportNotFound", err)
+	}
+	if got := len(server.Messages()); got != 1 {
+		t.Errorf("%d mensagens recebidas; esperado 1", got)
+	}
+}
+
+// reportMessageFiles retorna os arquivos (anexos e imagens embutidas) da mensagem pelo nome
+func reportMessageFiles(t *testing.T, msg *netmail.Message) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	var walk func(contentType string, body io.Reader)
+	walk = func(contentType string, body io.Reader) {
+		_, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			t.Fatalf("Content-Type %q: %v", contentType, err)
+		}
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				t.Fatalf("NextPart: %v", err)
+			}
+			partType := part.Header.Get("Content-Type")
+			if strings.HasPrefix(partType, "multipart/") {
+				walk(partType, part)
+				continue
+			}
+			_, disposition, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+			if disposition["filename"] == "" {
+				continue
+			}
+			data, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+			if err != nil {
+				t.Fatalf("conteúdo de %s: %v", disposition["filename"], err)
+			}
+			files[disposition["filename"]] = string(data)
+		}
+	}
+	walk(msg.Header.Get("Content-Type"), msg.Body)
+	return files
+}
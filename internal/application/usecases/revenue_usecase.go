@@ -1,9 +1,13 @@
 package usecases
 
 import (
+	"log"
+	"math"
+	"sort"
 	"time"
 
 	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
 )
 
 // RevenueUseCase interface para casos de uso de faturamento
@@ -11,9 +15,9 @@ type RevenueUseCase interface {
 	GetUnifiedDataByProfession(from, to time.Time, professionIDs []int) ([]repositories.UnifiedData, error)
 	GetUnifiedDataGeneral(from, to time.Time) (repositories.UnifiedData, error)
 
-	// Novos métodos para dados comparativos
-	GetRevenueComparisonGeneral(currentFrom, currentTo, previousFrom, previousTo time.Time) (repositories.RevenueComparisonData, error)
-	GetRevenueComparisonByProfession(currentFrom, currentTo, previousFrom, previousTo time.Time, professionIDs []int) ([]repositories.RevenueComparisonData, error)
+	// Novos métodos para dados comparativos; timeFrame define os buckets de chart_data (entities.TimeFrame*)
+	GetRevenueComparisonGeneral(currentFrom, currentTo, previousFrom, previousTo time.Time, timeFrame string) (repositories.RevenueComparisonData, error)
+	GetRevenueComparisonByProfession(currentFrom, currentTo, previousFrom, previousTo time.Time, professionIDs []int, timeFrame string) ([]repositories.RevenueComparisonData, error)
 
 	// Método para dados por hora
 	GetHourlyRevenueData(date time.Time, professionIDs []int) (*repositories.HourlyRevenueMetrics, error)
@@ -21,11 +25,13 @@ type RevenueUseCase interface {
 
 type revenueUseCase struct {
 	revenueRepo repositories.RevenueRepository
+	rollupRepo  repositories.MetricRollupRepository
 }
 
-func NewRevenueUseCase(revenueRepo repositories.RevenueRepository) RevenueUseCase {
+func NewRevenueUseCase(revenueRepo repositories.RevenueRepository, rollupRepo repositories.MetricRollupRepository) RevenueUseCase {
 	return &revenueUseCase{
 		revenueRepo: revenueRepo,
+		rollupRepo:  rollupRepo,
 	}
 }
 
@@ -37,14 +43,246 @@ func (uc *revenueUseCase) GetUnifiedDataGeneral(from, to time.Time) (repositorie
 	return uc.revenueRepo.GetUnifiedDataGeneral(from, to)
 }
 
-func (uc *revenueUseCase) GetRevenueComparisonGeneral(currentFrom, currentTo, previousFrom, previousTo time.Time) (repositories.RevenueComparisonData, error) {
-	return uc.revenueRepo.GetRevenueComparisonGeneral(currentFrom, currentTo, previousFrom, previousTo)
+func (uc *revenueUseCase) GetRevenueComparisonGeneral(currentFrom, currentTo, previousFrom, previousTo time.Time, timeFrame string) (repositories.RevenueComparisonData, error) {
+	current, previous, ok := uc.rollupRevenueRows(currentFrom, currentTo, previousFrom, previousTo, nil)
+	if !ok {
+		result, err := uc.revenueRepo.GetRevenueComparisonGeneral(currentFrom, currentTo, previousFrom, previousTo)
+		if err != nil {
+			return result, err
+		}
+		applyRevenueChartData(&result, currentFrom, currentTo, previousFrom, previousTo, timeFrame)
+		return result, nil
+	}
+
+	result := buildRevenueComparison(current, previous)
+	result.ProfessionSummary = []repositories.ProfessionSummary{}
+	for _, data := range groupRevenueByProfession(current, previous) {
+		if data.Leads.Current+data.Purchases.Current == 0 {
+			continue
+		}
+		result.ProfessionSummary = append(result.ProfessionSummary, repositories.ProfessionSummary{
+			ProfessionID:   data.ProfessionID,
+			ProfessionName: data.ProfessionName,
+			Leads:          data.Leads,
+			Purchases:      data.Purchases,
+			Revenue:        data.Revenue,
+		})
+	}
+	sort.SliceStable(result.ProfessionSummary, func(i, j int) bool {
+		a, b := result.ProfessionSummary[i], result.ProfessionSummary[j]
+		if a.Revenue.Current != b.Revenue.Current {
+			return a.Revenue.Current > b.Revenue.Current
+		}
+		return a.Leads.Current > b.Leads.Current
+	})
+	applyRevenueChartData(&result, currentFrom, currentTo, previousFrom, previousTo, timeFrame)
+	return result, nil
 }
 
-func (uc *revenueUseCase) GetRevenueComparisonByProfession(currentFrom, currentTo, previousFrom, previousTo time.Time, professionIDs []int) ([]repositories.RevenueComparisonData, error) {
-	return uc.revenueRepo.GetRevenueComparisonByProfession(currentFrom, currentTo, previousFrom, previousTo, professionIDs)
+func (uc *revenueUseCase) GetRevenueComparisonByProfession(currentFrom, currentTo, previousFrom, previousTo time.Time, professionIDs []int, timeFrame string) ([]repositories.RevenueComparisonData, error) {
+	var results []repositories.RevenueComparisonData
+	current, previous, ok := uc.rollupRevenueRows(currentFrom, currentTo, previousFrom, previousTo, professionIDs)
+	if ok {
+		results = groupRevenueByProfession(current, previous)
+	} else {
+		var err error
+		results, err = uc.revenueRepo.GetRevenueComparisonByProfession(currentFrom, currentTo, previousFrom, previousTo, professionIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range results {
+		applyRevenueChartData(&results[i], currentFrom, currentTo, previousFrom, previousTo, timeFrame)
+	}
+	return results, nil
 }
 
 func (uc *revenueUseCase) GetHourlyRevenueData(date time.Time, professionIDs []int) (*repositories.HourlyRevenueMetrics, error) {
 	return uc.revenueRepo.GetHourlyRevenueData(date, professionIDs)
 }
+
+// rollupRevenueRows lê dos rollups as métricas diárias por profissão dos dois períodos.
+// Retorna false quando os rollups não cobrem os períodos e a consulta deve ir direto aos eventos.
+func (uc *revenueUseCase) rollupRevenueRows(currentFrom, currentTo, previousFrom, previousTo time.Time, professionIDs []int) ([]repositories.MetricRollupRow, []repositories.MetricRollupRow, bool) {
+	rolledUntil, ok := metricRollupCoverage(uc.rollupRepo, currentFrom, currentTo, previousFrom, previousTo)
+	if !ok {
+		return nil, nil, false
+	}
+
+	var series [2][]repositories.MetricRollupRow
+	for i, period := range [2][2]time.Time{{currentFrom, currentTo}, {previousFrom, previousTo}} {
+		from, to, _ := rollupRange(period[0], period[1])
+		rows, err := uc.rollupRepo.GetSeries(repositories.MetricRollupQuery{
+			From:              from,
+			To:                to,
+			Granularity:       repositories.MetricRollupDay,
+			ProfessionIDs:     professionIDs,
+			GroupByProfession: true,
+		}, rolledUntil)
+		if err != nil {
+			log.Printf("⚠️ Erro ao ler rollups de faturamento, usando dados brutos: %v", err)
+			return nil, nil, false
+		}
+		series[i] = rows
+	}
+	return series[0], series[1], true
+}
+
+// buildRevenueComparison monta a comparação de leads, compras e faturamento a partir das linhas dos rollups.
+// Como na consulta direta, os mapas diários só têm os dias com dados.
+func buildRevenueComparison(current, previous []repositories.MetricRollupRow) repositories.RevenueComparisonData {
+	result := repositories.RevenueComparisonData{
+		LeadsByDay:     make(map[string]int64),
+		PurchasesByDay: make(map[string]int64),
+		RevenueByDay:   make(map[string]float64),
+		PreviousPeriodData: &repositories.PreviousRevenueData{
+			LeadsByDay:     make(map[string]int64),
+			PurchasesByDay: make(map[string]int64),
+			RevenueByDay:   make(map[string]float64),
+		},
+	}
+
+	currentLeads, currentPurchases, currentRevenue := accumulateRevenueByDay(current, result.LeadsByDay, result.PurchasesByDay, result.RevenueByDay)
+	previousLeads, previousPurchases, previousRevenue := accumulateRevenueByDay(previous,
+		result.PreviousPeriodData.LeadsByDay, result.PreviousPeriodData.PurchasesByDay, result.PreviousPeriodData.RevenueByDay)
+
+	result.Leads = revenueMetricComparison(currentLeads, previousLeads)
+	result.Purchases = revenueMetricComparison(currentPurchases, previousPurchases)
+	result.Revenue = revenueFloatMetricComparison(currentRevenue, previousRevenue)
+	return result
+}
+
+// groupRevenueByProfession monta uma comparação por profissão com leads ou compras em algum dos períodos,
+// ordenada pelo faturamento atual (maior para menor)
+func groupRevenueByProfession(current, previous []repositories.MetricRollupRow) []repositories.RevenueComparisonData {
+	byProfession := make(map[int][2][]repositories.MetricRollupRow)
+	names := make(map[int]string)
+	for i, rows := range [2][]repositories.MetricRollupRow{current, previous} {
+		for _, row := range rows {
+			if row.ProfessionID == 0 || row.Leads+row.Purchases == 0 {
+				continue
+			}
+			names[row.ProfessionID] = row.ProfessionName
+			periods := byProfession[row.ProfessionID]
+			periods[i] = append(periods[i], row)
+			byProfession[row.ProfessionID] = periods
+		}
+	}
+
+	results := make([]repositories.RevenueComparisonData, 0, len(byProfession))
+	for professionID, periods := range byProfession {
+		data := buildRevenueComparison(periods[0], periods[1])
+		data.ProfessionID = professionID
+		data.ProfessionName = names[professionID]
+		results = append(results, data)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Revenue.Current != results[j].Revenue.Current {
+			return results[i].Revenue.Current > results[j].Revenue.Current
+		}
+		return results[i].ProfessionID < results[j].ProfessionID
+	})
+	return results
+}
+
+// accumulateRevenueByDay soma as linhas nos mapas diários (chave no fuso de Brasília) e retorna os totais
+func accumulateRevenueByDay(rows []repositories.MetricRollupRow, leadsByDay, purchasesByDay map[string]int64, revenueByDay map[string]float64) (int64, int64, float64) {
+	loc := utils.GetBrasilLocation()
+	var leads, purchases int64
+	var revenue float64
+	for _, row := range rows {
+		day := row.Bucket.In(loc).Format("2006-01-02")
+		if row.Leads > 0 {
+			leadsByDay[day] += row.Leads
+		}
+		if row.Purchases > 0 {
+			purchasesByDay[day] += row.Purchases
+			revenueByDay[day] = math.Round((revenueByDay[day]+row.Revenue)*100) / 100
+		}
+		leads += row.Leads
+		purchases += row.Purchases
+		revenue += row.Revenue
+	}
+	return leads, purchases, revenue
+}
+
+// revenueMetricComparison compara um total inteiro com o período anterior
+func revenueMetricComparison(current, previous int64) repositories.RevenueMetricResult {
+	var percentage float64
+	if previous > 0 {
+		percentage = math.Round(float64(current-previous)/float64(previous)*100*100) / 100
+	}
+	return repositories.RevenueMetricResult{
+		Current:      current,
+		Previous:     previous,
+		Percentage:   percentage,
+		IsIncreasing: current > previous,
+	}
+}
+
+// revenueFloatMetricComparison compara um valor monetário com o período anterior
+func revenueFloatMetricComparison(current, previous float64) repositories.RevenueMetricResultFloat {
+	var percentage float64
+	if previous > 0 {
+		percentage = math.Round((current-previous)/previous*100*100) / 100
+	}
+	return repositories.RevenueMetricResultFloat{
+		Current:      math.Round(current*100) / 100,
+		Previous:     math.Round(previous*100) / 100,
+		Percentage:   percentage,
+		IsIncreasing: current > previous,
+	}
+}
+
+// applyRevenueChartData agrupa as séries diárias dos dois períodos nos buckets do time_frame
+func applyRevenueChartData(data *repositories.RevenueComparisonData, currentFrom, currentTo, previousFrom, previousTo time.Time, timeFrame string) {
+	data.ChartData = buildRevenueChartData(DatePeriod{From: currentFrom, To: currentTo},
+		data.LeadsByDay, data.PurchasesByDay, data.RevenueByDay, timeFrame)
+	if data.PreviousPeriodData != nil {
+		data.PreviousPeriodData.ChartData = buildRevenueChartData(DatePeriod{From: previousFrom, To: previousTo},
+			data.PreviousPeriodData.LeadsByDay, data.PreviousPeriodData.PurchasesByDay, data.PreviousPeriodData.RevenueByDay, timeFrame)
+		data.AlignedChartData = alignRevenueChartData(data.ChartData, data.PreviousPeriodData.ChartData)
+	}
+}
+
+// alignRevenueChartData alinha os buckets do período atual e do período de comparação pela posição
+func alignRevenueChartData(current, previous []repositories.RevenuePeriodData) []repositories.RevenueAlignedPeriod {
+	size := max(len(current), len(previous))
+	aligned := make([]repositories.RevenueAlignedPeriod, size)
+	for i := range aligned {
+		aligned[i].Index = i
+		if i < len(current) {
+			aligned[i].Current = &current[i]
+		}
+		if i < len(previous) {
+			aligned[i].Previous = &previous[i]
+		}
+		if aligned[i].Current != nil && aligned[i].Previous != nil {
+			aligned[i].LeadsChange = alignedChange(float64(current[i].Leads), float64(previous[i].Leads))
+			aligned[i].PurchasesChange = alignedChange(float64(current[i].Purchases), float64(previous[i].Purchases))
+			aligned[i].RevenueChange = alignedChange(current[i].Revenue, previous[i].Revenue)
+		}
+	}
+	return aligned
+}
+
+// buildRevenueChartData soma leads, compras e faturamento diários por bucket do time_frame
+func buildRevenueChartData(period DatePeriod, leadsByDay, purchasesByDay map[string]int64, revenueByDay map[string]float64, timeFrame string) []repositories.RevenuePeriodData {
+	buckets := groupDaysIntoPeriods(period, timeFrame, mapKeys(leadsByDay), mapKeys(purchasesByDay), mapKeys(revenueByDay))
+	chart := make([]repositories.RevenuePeriodData, 0, len(buckets))
+	for _, bucket := range buckets {
+		point := repositories.RevenuePeriodData{
+			Period:        bucket.Key,
+			DisplayPeriod: bucket.Display,
+		}
+		for _, day := range bucket.Days {
+			point.Leads += leadsByDay[day]
+			point.Purchases += purchasesByDay[day]
+			point.Revenue += revenueByDay[day]
+		}
+		point.Revenue = math.Round(point.Revenue*100) / 100
+		chart = append(chart, point)
+	}
+	return chart
+}
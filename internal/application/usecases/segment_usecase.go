@@ -0,0 +1,205 @@
+package usecases
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSegmentNotFound indica que o segmento solicitado não existe
+	ErrSegmentNotFound = errors.New("segmento não encontrado")
+	// ErrInvalidSegment indica que os dados ou a definição do segmento não são válidos
+	ErrInvalidSegment = errors.New("segmento inválido")
+)
+
+// SegmentInput representa os dados de criação/alteração de um segmento.
+// Na alteração, apenas os campos informados são modificados.
+type SegmentInput struct {
+	Name        *string                     `json:"name"`
+	Description *string                     `json:"description"`
+	Definition  *entities.SegmentDefinition `json:"definition"`
+}
+
+// SegmentSize representa o tamanho de um segmento no momento da consulta
+type SegmentSize struct {
+	SegmentID  int64     `json:"segment_id,omitempty"`
+	Size       int64     `json:"size"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// SegmentUseCase define as operações de segmentos salvos de usuários
+type SegmentUseCase interface {
+	ListSegments() ([]entities.Segment, error)
+	GetSegment(segmentID int64) (*entities.Segment, error)
+	CreateSegment(input SegmentInput) (*entities.Segment, error)
+	UpdateSegment(segmentID int64, input SegmentInput) (*entities.Segment, error)
+	DeleteSegment(segmentID int64) error
+
+	// GetSize conta os membros do segmento no momento da consulta
+	GetSize(segmentID int64) (*SegmentSize, error)
+	// GetMembers lista uma página dos membros do segmento
+	GetMembers(segmentID int64, page, limit int) ([]entities.User, error)
+	// PreviewSize conta os membros de uma definição ainda não salva
+	PreviewSize(definition entities.SegmentDefinition) (*SegmentSize, error)
+	// ResolveFilter retorna o filtro de membros do segmento para as consultas de leads, eventos, sessões e dashboard
+	ResolveFilter(segmentID int64) (repositories.SegmentFilter, error)
+}
+
+type segmentUseCase struct {
+	segmentRepo repositories.SegmentRepository
+}
+
+func NewSegmentUseCase(segmentRepo repositories.SegmentRepository) SegmentUseCase {
+	return &segmentUseCase{
+		segmentRepo: segmentRepo,
+	}
+}
+
+func (uc *segmentUseCase) ListSegments() ([]entities.Segment, error) {
+	return uc.segmentRepo.ListSegments()
+}
+
+func (uc *segmentUseCase) GetSegment(segmentID int64) (*entities.Segment, error) {
+	segment, err := uc.segmentRepo.GetSegment(segmentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSegmentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar segmento: %w", err)
+	}
+	return segment, nil
+}
+
+func (uc *segmentUseCase) CreateSegment(input SegmentInput) (*entities.Segment, error) {
+	if input.Definition == nil {
+		return nil, fmt.Errorf("%w: definition é obrigatório", ErrInvalidSegment)
+	}
+	segment := &entities.Segment{}
+	if err := input.apply(segment); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	segment.CreatedAt = now
+	segment.UpdatedAt = now
+	if err := uc.segmentRepo.CreateSegment(segment); err != nil {
+		return nil, err
+	}
+	return segment, nil
+}
+
+func (uc *segmentUseCase) UpdateSegment(segmentID int64, input SegmentInput) (*entities.Segment, error) {
+	segment, err := uc.GetSegment(segmentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := input.apply(segment); err != nil {
+		return nil, err
+	}
+
+	segment.UpdatedAt = time.Now()
+	if err := uc.segmentRepo.UpdateSegment(segment); err != nil {
+		return nil, err
+	}
+	return segment, nil
+}
+
+func (uc *segmentUseCase) DeleteSegment(segmentID int64) error {
+	err := uc.segmentRepo.DeleteSegment(segmentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrSegmentNotFound
+	}
+	return err
+}
+
+func (uc *segmentUseCase) GetSize(segmentID int64) (*SegmentSize, error) {
+	filter, err := uc.ResolveFilter(segmentID)
+	if err != nil {
+		return nil, err
+	}
+	size, err := uc.segmentRepo.CountMembers(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &SegmentSize{SegmentID: segmentID, Size: size, ComputedAt: time.Now()}, nil
+}
+
+func (uc *segmentUseCase) GetMembers(segmentID int64, page, limit int) ([]entities.User, error) {
+	filter, err := uc.ResolveFilter(segmentID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := uc.segmentRepo.ListMembers(filter, page, limit)
+	if err != nil {
+		return nil, err
+	}
+	if members == nil {
+		members = []entities.User{}
+	}
+	return members, nil
+}
+
+func (uc *segmentUseCase) PreviewSize(definition entities.SegmentDefinition) (*SegmentSize, error) {
+	filter, err := repositories.NewSegmentFilter(0, definition)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSegment, err)
+	}
+	size, err := uc.segmentRepo.CountMembers(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &SegmentSize{Size: size, ComputedAt: time.Now()}, nil
+}
+
+func (uc *segmentUseCase) ResolveFilter(segmentID int64) (repositories.SegmentFilter, error) {
+	segment, err := uc.GetSegment(segmentID)
+	if err != nil {
+		return repositories.SegmentFilter{}, err
+	}
+	var definition entities.SegmentDefinition
+	if err := json.Unmarshal(segment.Definition, &definition); err != nil {
+		return repositories.SegmentFilter{}, fmt.Errorf("definição do segmento %d corrompida: %w", segmentID, err)
+	}
+	filter, err := repositories.NewSegmentFilter(segmentID, definition)
+	if err != nil {
+		return repositories.SegmentFilter{}, fmt.Errorf("%w: %v", ErrInvalidSegment, err)
+	}
+	return filter, nil
+}
+
+// apply aplica o input ao segmento e valida nome e definição
+func (input SegmentInput) apply(segment *entities.Segment) error {
+	if input.Name != nil {
+		segment.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Description != nil {
+		segment.Description = strings.TrimSpace(*input.Description)
+	}
+	if segment.Name == "" {
+		return fmt.Errorf("%w: name é obrigatório", ErrInvalidSegment)
+	}
+
+	if input.Definition == nil {
+		return nil
+	}
+	definition := *input.Definition
+	if definition.Match == "" {
+		definition.Match = entities.SegmentMatchAll
+	}
+	if _, err := repositories.NewSegmentFilter(segment.SegmentID, definition); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSegment, err)
+	}
+	raw, err := json.Marshal(definition)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar definição do segmento: %w", err)
+	}
+	segment.Definition = raw
+	return nil
+}
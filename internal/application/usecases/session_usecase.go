@@ -10,10 +10,11 @@ import (
 
 // ISessionUseCase define a interface para operações de sessão
 type ISessionUseCase interface {
-	GetSessions(ctx context.Context, page, limit int, orderBy string, from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string) ([]entities.Session, int64, error)
+	GetSessions(ctx context.Context, page repositories.PageRequest, orderBy string, from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string, segment repositories.SegmentFilter) ([]entities.Session, repositories.PageResult, error)
+	ExportSessions(ctx context.Context, columns []repositories.ExportColumn, orderBy string, from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string, segment repositories.SegmentFilter, fn repositories.ExportRowFunc) error
 	FindSessionByID(ctx context.Context, id string) (*entities.Session, error)
-	CountSessions(from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string) (int64, error)
-	CountSessionsByPeriods(periods []string, landingPage string, funnelID string, professionID string) (map[string]int64, error)
+	CountSessions(from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string, segment repositories.SegmentFilter) (int64, error)
+	CountSessionsByPeriods(periods []string, landingPage string, funnelID string, professionID string, segment repositories.SegmentFilter) (map[string]int64, error)
 	GetSessionsDateRange() (time.Time, time.Time, error)
 	FindActiveSessions(page, limit int, orderBy string, landingPage string, funnelID string, professionID string) ([]entities.Session, int64, error)
 	CountActiveSessions(professionID string, funnelID string, landingPage string) (int64, error)
@@ -32,8 +33,13 @@ func NewSessionUseCase(sessionRepo repositories.ISessionRepository) *SessionUseC
 }
 
 // GetSessions obtém uma lista paginada de sessões com filtros
-func (uc *SessionUseCase) GetSessions(ctx context.Context, page, limit int, orderBy string, from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string) ([]entities.Session, int64, error) {
-	return uc.sessionRepo.GetSessions(ctx, page, limit, orderBy, from, to, timeFrom, timeTo, userID, professionID, productID, funnelID, isActive, landingPage)
+func (uc *SessionUseCase) GetSessions(ctx context.Context, page repositories.PageRequest, orderBy string, from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string, segment repositories.SegmentFilter) ([]entities.Session, repositories.PageResult, error) {
+	return uc.sessionRepo.GetSessions(ctx, page, orderBy, from, to, timeFrom, timeTo, userID, professionID, productID, funnelID, isActive, landingPage, segment)
+}
+
+// ExportSessions percorre as sessões filtradas entregando as colunas pedidas linha a linha
+func (uc *SessionUseCase) ExportSessions(ctx context.Context, columns []repositories.ExportColumn, orderBy string, from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string, segment repositories.SegmentFilter, fn repositories.ExportRowFunc) error {
+	return uc.sessionRepo.ExportSessions(ctx, columns, orderBy, from, to, timeFrom, timeTo, userID, professionID, productID, funnelID, isActive, landingPage, segment, fn)
 }
 
 // FindSessionByID busca uma sessão pelo ID
@@ -42,13 +48,13 @@ func (uc *SessionUseCase) FindSessionByID(ctx context.Context, id string) (*enti
 }
 
 // CountSessions conta o número de sessões com filtros
-func (uc *SessionUseCase) CountSessions(from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string) (int64, error) {
-	return uc.sessionRepo.CountSessions(from, to, timeFrom, timeTo, userID, professionID, productID, funnelID, isActive, landingPage)
+func (uc *SessionUseCase) CountSessions(from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string, segment repositories.SegmentFilter) (int64, error) {
+	return uc.sessionRepo.CountSessions(from, to, timeFrom, timeTo, userID, professionID, productID, funnelID, isActive, landingPage, segment)
 }
 
 // CountSessionsByPeriods conta sessões agrupadas por períodos
-func (uc *SessionUseCase) CountSessionsByPeriods(periods []string, landingPage string, funnelID string, professionID string) (map[string]int64, error) {
-	return uc.sessionRepo.CountSessionsByPeriods(periods, landingPage, funnelID, professionID)
+func (uc *SessionUseCase) CountSessionsByPeriods(periods []string, landingPage string, funnelID string, professionID string, segment repositories.SegmentFilter) (map[string]int64, error) {
+	return uc.sessionRepo.CountSessionsByPeriods(periods, landingPage, funnelID, professionID, segment)
 }
 
 // GetSessionsDateRange obtém o intervalo de datas das sessões
@@ -0,0 +1,288 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSurveyNotFound indica que a pesquisa solicitada não existe
+	ErrSurveyNotFound = errors.New("pesquisa não encontrada")
+	// ErrSurveyQuestionNotFound indica que a pergunta nunca foi respondida na pesquisa
+	ErrSurveyQuestionNotFound = errors.New("pergunta não encontrada na pesquisa")
+)
+
+// SurveyCrosstabDimension descreve uma dimensão do cruzamento (pergunta ou faixa)
+type SurveyCrosstabDimension struct {
+	QuestionID   string   `json:"question_id"`
+	QuestionText string   `json:"question_text"`
+	Values       []string `json:"values"`
+}
+
+// SurveyCrosstabCell representa uma combinação de respostas no cruzamento
+type SurveyCrosstabCell struct {
+	Row             string  `json:"row"`
+	Col             string  `json:"col"`
+	Count           int64   `json:"count"`
+	RowPercentage   float64 `json:"row_percentage"`
+	ColPercentage   float64 `json:"col_percentage"`
+	TotalPercentage float64 `json:"total_percentage"`
+	Purchases       int64   `json:"purchases"`
+	ConversionRate  float64 `json:"conversion_rate"`
+}
+
+// SurveyCrosstabTotal representa o total de uma linha ou coluna do cruzamento
+type SurveyCrosstabTotal struct {
+	Value          string  `json:"value"`
+	Count          int64   `json:"count"`
+	Percentage     float64 `json:"percentage"`
+	Purchases      int64   `json:"purchases"`
+	ConversionRate float64 `json:"conversion_rate"`
+}
+
+// SurveyCrosstab representa o cruzamento das respostas de duas perguntas de uma pesquisa
+type SurveyCrosstab struct {
+	SurveyID       int64                   `json:"survey_id"`
+	Row            SurveyCrosstabDimension `json:"row"`
+	Col            SurveyCrosstabDimension `json:"col"`
+	Cells          []SurveyCrosstabCell    `json:"cells"`
+	RowTotals      []SurveyCrosstabTotal   `json:"row_totals"`
+	ColTotals      []SurveyCrosstabTotal   `json:"col_totals"`
+	Total          int64                   `json:"total"`
+	Purchases      int64                   `json:"purchases"`
+	ConversionRate float64                 `json:"conversion_rate"`
+}
+
+// GetSurveyCrosstab cruza as respostas de duas perguntas (ou a faixa, com "faixa") da pesquisa, com
+// percentuais por linha/coluna e a conversão em compra de cada célula
+func (u *SurveyUseCase) GetSurveyCrosstab(surveyID int64, rowDimension, colDimension string, params map[string]interface{}) (*SurveyCrosstab, error) {
+	report := &SurveyCrosstab{
+		SurveyID: surveyID,
+		Row:      SurveyCrosstabDimension{QuestionID: rowDimension},
+		Col:      SurveyCrosstabDimension{QuestionID: colDimension},
+	}
+
+	rows, err := u.surveyRepo.GetSurveyCrosstab(surveyID, rowDimension, colDimension, params)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSurveyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dimension := range []*SurveyCrosstabDimension{&report.Row, &report.Col} {
+		if dimension.QuestionID == repositories.SurveyCrosstabFaixa {
+			dimension.QuestionText = "Faixa"
+			continue
+		}
+		text, err := u.surveyRepo.GetQuestionText(surveyID, dimension.QuestionID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrSurveyQuestionNotFound, dimension.QuestionID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		dimension.QuestionText = text
+	}
+
+	rowTotals := make(map[string]*SurveyCrosstabTotal)
+	colTotals := make(map[string]*SurveyCrosstabTotal)
+	for _, row := range rows {
+		if rowTotals[row.RowValue] == nil {
+			rowTotals[row.RowValue] = &SurveyCrosstabTotal{Value: row.RowValue}
+		}
+		if colTotals[row.ColValue] == nil {
+			colTotals[row.ColValue] = &SurveyCrosstabTotal{Value: row.ColValue}
+		}
+		rowTotals[row.RowValue].Count += row.Respondents
+		rowTotals[row.RowValue].Purchases += row.Purchases
+		colTotals[row.ColValue].Count += row.Respondents
+		colTotals[row.ColValue].Purchases += row.Purchases
+		report.Total += row.Respondents
+		report.Purchases += row.Purchases
+	}
+
+	report.Cells = make([]SurveyCrosstabCell, 0, len(rows))
+	for _, row := range rows {
+		report.Cells = append(report.Cells, SurveyCrosstabCell{
+			Row:             row.RowValue,
+			Col:             row.ColValue,
+			Count:           row.Respondents,
+			RowPercentage:   crosstabPercentage(row.Respondents, rowTotals[row.RowValue].Count),
+			ColPercentage:   crosstabPercentage(row.Respondents, colTotals[row.ColValue].Count),
+			TotalPercentage: crosstabPercentage(row.Respondents, report.Total),
+			Purchases:       row.Purchases,
+			ConversionRate:  crosstabPercentage(row.Purchases, row.Respondents),
+		})
+	}
+
+	report.RowTotals = sortedCrosstabTotals(rowTotals, report.Total)
+	report.ColTotals = sortedCrosstabTotals(colTotals, report.Total)
+	for _, total := range report.RowTotals {
+		report.Row.Values = append(report.Row.Values, total.Value)
+	}
+	for _, total := range report.ColTotals {
+		report.Col.Values = append(report.Col.Values, total.Value)
+	}
+	report.ConversionRate = crosstabPercentage(report.Purchases, report.Total)
+
+	return report, nil
+}
+
+// sortedCrosstabTotals ordena os totais pela quantidade de respondentes (maior primeiro)
+func sortedCrosstabTotals(totals map[string]*SurveyCrosstabTotal, grandTotal int64) []SurveyCrosstabTotal {
+	result := make([]SurveyCrosstabTotal, 0, len(totals))
+	for _, total := range totals {
+		total.Percentage = crosstabPercentage(total.Count, grandTotal)
+		total.ConversionRate = crosstabPercentage(total.Purchases, total.Count)
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Value < result[j].Value
+	})
+	return result
+}
+
+func crosstabPercentage(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*10000/float64(total)) / 100
+}
+
+// Limites usados para sinalizar perguntas problemáticas no funil da pesquisa.
+// Uma pergunta é sinalizada quando fica acima de N vezes a mediana das perguntas da pesquisa.
+const (
+	surveySlowQuestionFactor      = 2.0
+	surveyConfusingQuestionFactor = 2.0
+	surveyConfusingMinChangedRate = 10.0
+	surveyDropOffFactor           = 2.0
+	surveyDropOffMinRate          = 5.0
+	// Perguntas com poucos respondentes não são sinalizadas
+	surveyFlagMinReached = 20
+)
+
+// Sinalizações de perguntas no funil da pesquisa
+const (
+	SurveyQuestionFlagSlow      = "slow"
+	SurveyQuestionFlagConfusing = "confusing"
+	SurveyQuestionFlagDropOff   = "high_drop_off"
+)
+
+// SurveyQuestionFunnelStep representa uma pergunta no funil da pesquisa
+type SurveyQuestionFunnelStep struct {
+	Position     int     `json:"position"`
+	QuestionID   string  `json:"question_id"`
+	QuestionText string  `json:"question_text"`
+	Reached      int64   `json:"reached"`
+	ReachedRate  float64 `json:"reached_rate"`
+	// StepRate é o percentual de quem chegou na pergunta anterior e chegou nesta
+	StepRate        float64  `json:"step_rate"`
+	DroppedAfter    int64    `json:"dropped_after"`
+	AbandonmentRate float64  `json:"abandonment_rate"`
+	MedianTime      float64  `json:"median_time_to_answer"`
+	P90Time         float64  `json:"p90_time_to_answer"`
+	ChangedRate     float64  `json:"changed_rate"`
+	Flags           []string `json:"flags"`
+}
+
+// SurveyQuestionFunnel representa o funil por pergunta de uma pesquisa
+type SurveyQuestionFunnel struct {
+	SurveyID       int64                      `json:"survey_id"`
+	Started        int64                      `json:"started"`
+	Completed      int64                      `json:"completed"`
+	CompletionRate float64                    `json:"completion_rate"`
+	Questions      []SurveyQuestionFunnelStep `json:"questions"`
+	// Medianas da pesquisa usadas como referência para as sinalizações
+	MedianTime        float64 `json:"median_time_to_answer"`
+	MedianChangedRate float64 `json:"median_changed_rate"`
+	MedianAbandonment float64 `json:"median_abandonment_rate"`
+}
+
+// GetSurveyQuestionFunnel retorna o funil por pergunta da pesquisa: alcance, abandono, tempos de resposta
+// e alterações, sinalizando perguntas lentas, confusas ou com abandono alto
+func (u *SurveyUseCase) GetSurveyQuestionFunnel(surveyID int64, params map[string]interface{}) (*SurveyQuestionFunnel, error) {
+	rows, totals, err := u.surveyRepo.GetSurveyQuestionFunnel(surveyID, params)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSurveyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	funnel := &SurveyQuestionFunnel{
+		SurveyID:       surveyID,
+		Started:        totals.Started,
+		Completed:      totals.Completed,
+		CompletionRate: crosstabPercentage(totals.Completed, totals.Started),
+		Questions:      make([]SurveyQuestionFunnelStep, 0, len(rows)),
+	}
+
+	var times, changed, abandonment []float64
+	previousReached := totals.Started
+	for i, row := range rows {
+		step := SurveyQuestionFunnelStep{
+			Position:        i + 1,
+			QuestionID:      row.QuestionID,
+			QuestionText:    row.QuestionText,
+			Reached:         row.Reached,
+			ReachedRate:     crosstabPercentage(row.Reached, totals.Started),
+			StepRate:        crosstabPercentage(row.Reached, previousReached),
+			DroppedAfter:    row.DroppedAfter,
+			AbandonmentRate: crosstabPercentage(row.DroppedAfter, row.Reached),
+			MedianTime:      math.Round(row.MedianTime*100) / 100,
+			P90Time:         math.Round(row.P90Time*100) / 100,
+			ChangedRate:     math.Round(row.ChangedRate*10000) / 100,
+			Flags:           []string{},
+		}
+		funnel.Questions = append(funnel.Questions, step)
+		previousReached = row.Reached
+
+		times = append(times, step.MedianTime)
+		changed = append(changed, step.ChangedRate)
+		abandonment = append(abandonment, step.AbandonmentRate)
+	}
+
+	funnel.MedianTime = median(times)
+	funnel.MedianChangedRate = median(changed)
+	funnel.MedianAbandonment = median(abandonment)
+
+	for i := range funnel.Questions {
+		step := &funnel.Questions[i]
+		if step.Reached < surveyFlagMinReached {
+			continue
+		}
+		if funnel.MedianTime > 0 && step.MedianTime > surveySlowQuestionFactor*funnel.MedianTime {
+			step.Flags = append(step.Flags, SurveyQuestionFlagSlow)
+		}
+		if step.ChangedRate >= surveyConfusingMinChangedRate && step.ChangedRate > surveyConfusingQuestionFactor*funnel.MedianChangedRate {
+			step.Flags = append(step.Flags, SurveyQuestionFlagConfusing)
+		}
+		if step.AbandonmentRate >= surveyDropOffMinRate && step.AbandonmentRate > surveyDropOffFactor*funnel.MedianAbandonment {
+			step.Flags = append(step.Flags, SurveyQuestionFlagDropOff)
+		}
+	}
+
+	return funnel, nil
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return math.Round((sorted[mid-1]+sorted[mid])/2*100) / 100
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,441 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSurveyDefinitionNotFound indica que a versão da definição solicitada não existe
+	ErrSurveyDefinitionNotFound = errors.New("versão da definição da pesquisa não encontrada")
+	// ErrNoActiveSurveyDefinition indica que a pesquisa não possui versão ativa
+	ErrNoActiveSurveyDefinition = errors.New("nenhuma versão ativa da definição da pesquisa")
+	// ErrSurveyDefinitionInUse indica que a versão está ativa ou já possui respostas e não pode ser removida
+	ErrSurveyDefinitionInUse = errors.New("a versão está ativa ou possui respostas coletadas")
+	// ErrInvalidSurveyDefinition indica que a definição enviada não é válida
+	ErrInvalidSurveyDefinition = errors.New("definição de pesquisa inválida")
+)
+
+// SurveyOptionInput representa uma opção de resposta na criação de uma versão
+type SurveyOptionInput struct {
+	Value string `json:"value"`
+	Label string `json:"label"`
+	Score int    `json:"score"`
+}
+
+// SurveyQuestionInput representa uma pergunta na criação de uma versão.
+// Required é verdadeiro quando não informado.
+type SurveyQuestionInput struct {
+	QuestionID string              `json:"question_id"`
+	Text       string              `json:"question_text"`
+	Type       string              `json:"type"`
+	Required   *bool               `json:"required"`
+	Options    []SurveyOptionInput `json:"options"`
+}
+
+// SurveyFaixaInput representa uma faixa de pontuação na criação de uma versão
+type SurveyFaixaInput struct {
+	Name     string `json:"name"`
+	MinScore int    `json:"min_score"`
+}
+
+// SurveyDefinitionInput representa os dados de criação de uma nova versão da pesquisa
+type SurveyDefinitionInput struct {
+	Notes     string                `json:"notes"`
+	Activate  bool                  `json:"activate"`
+	Questions []SurveyQuestionInput `json:"questions"`
+	Faixas    []SurveyFaixaInput    `json:"faixas"`
+}
+
+// SurveyDefinitionWithUsage representa uma versão com a quantidade de respostas coletadas nela
+type SurveyDefinitionWithUsage struct {
+	entities.SurveyDefinition
+	Responses int64 `json:"responses"`
+}
+
+// SurveyAnswerInput representa a resposta de uma pergunta enviada para validação
+type SurveyAnswerInput struct {
+	QuestionID string `json:"question_id"`
+	Value      string `json:"value"`
+}
+
+// SurveyScoredAnswer representa uma resposta validada com a pontuação da versão
+type SurveyScoredAnswer struct {
+	QuestionID   string `json:"question_id"`
+	QuestionText string `json:"question_text"`
+	Value        string `json:"value"`
+	Score        int    `json:"score"`
+}
+
+// SurveyAnswerError descreve uma resposta rejeitada pela definição
+type SurveyAnswerError struct {
+	QuestionID string `json:"question_id"`
+	Error      string `json:"error"`
+}
+
+// SurveyAnswerValidation representa o resultado da validação de respostas contra uma versão
+type SurveyAnswerValidation struct {
+	SurveyID   int64                `json:"survey_id"`
+	Version    int                  `json:"version"`
+	Valid      bool                 `json:"valid"`
+	Complete   bool                 `json:"complete"`
+	Answers    []SurveyScoredAnswer `json:"answers"`
+	Errors     []SurveyAnswerError  `json:"errors"`
+	TotalScore int                  `json:"total_score"`
+	Faixa      string               `json:"faixa"`
+}
+
+// SurveyDefinitionUseCase interface para casos de uso de definições de pesquisa
+type SurveyDefinitionUseCase interface {
+	ListDefinitions(surveyID int64) ([]SurveyDefinitionWithUsage, error)
+	GetDefinition(surveyID int64, version int) (*SurveyDefinitionWithUsage, error)
+	GetActiveDefinition(surveyID int64) (*entities.SurveyDefinition, error)
+	CreateDefinition(surveyID int64, input SurveyDefinitionInput) (*entities.SurveyDefinition, error)
+	ActivateDefinition(surveyID int64, version int) (*entities.SurveyDefinition, error)
+	DeleteDefinition(surveyID int64, version int) error
+
+	// ValidateAnswers valida e pontua as respostas contra a versão ativa. Com complete, as
+	// perguntas obrigatórias não respondidas também são rejeitadas.
+	ValidateAnswers(surveyID int64, answers []SurveyAnswerInput, complete bool) (*SurveyAnswerValidation, error)
+}
+
+type surveyDefinitionUseCase struct {
+	definitionRepo repositories.SurveyDefinitionRepository
+}
+
+func NewSurveyDefinitionUseCase(definitionRepo repositories.SurveyDefinitionRepository) SurveyDefinitionUseCase {
+	return &surveyDefinitionUseCase{
+		definitionRepo: definitionRepo,
+	}
+}
+
+func (uc *surveyDefinitionUseCase) ListDefinitions(surveyID int64) ([]SurveyDefinitionWithUsage, error) {
+	if err := uc.ensureSurvey(surveyID); err != nil {
+		return nil, err
+	}
+
+	definitions, err := uc.definitionRepo.ListDefinitions(surveyID)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := uc.definitionRepo.CountResponsesByVersion(surveyID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]SurveyDefinitionWithUsage, len(definitions))
+	for i, definition := range definitions {
+		result[i] = SurveyDefinitionWithUsage{
+			SurveyDefinition: definition,
+			Responses:        counts[definition.Version],
+		}
+	}
+	return result, nil
+}
+
+func (uc *surveyDefinitionUseCase) GetDefinition(surveyID int64, version int) (*SurveyDefinitionWithUsage, error) {
+	definition, err := uc.definitionRepo.GetDefinition(surveyID, version)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSurveyDefinitionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar definição da pesquisa: %w", err)
+	}
+
+	counts, err := uc.definitionRepo.CountResponsesByVersion(surveyID)
+	if err != nil {
+		return nil, err
+	}
+	return &SurveyDefinitionWithUsage{
+		SurveyDefinition: *definition,
+		Responses:        counts[version],
+	}, nil
+}
+
+func (uc *surveyDefinitionUseCase) GetActiveDefinition(surveyID int64) (*entities.SurveyDefinition, error) {
+	definition, err := uc.definitionRepo.GetActiveDefinition(surveyID)
+	if err != nil {
+		return nil, err
+	}
+	if definition == nil {
+		if err := uc.ensureSurvey(surveyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrNoActiveSurveyDefinition
+	}
+	return definition, nil
+}
+
+func (uc *surveyDefinitionUseCase) CreateDefinition(surveyID int64, input SurveyDefinitionInput) (*entities.SurveyDefinition, error) {
+	definition, err := input.toEntity(surveyID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.definitionRepo.CreateDefinition(definition)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSurveyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return definition, nil
+}
+
+func (uc *surveyDefinitionUseCase) ActivateDefinition(surveyID int64, version int) (*entities.SurveyDefinition, error) {
+	err := uc.definitionRepo.ActivateDefinition(surveyID, version)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSurveyDefinitionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	definition, err := uc.GetDefinition(surveyID, version)
+	if err != nil {
+		return nil, err
+	}
+	return &definition.SurveyDefinition, nil
+}
+
+func (uc *surveyDefinitionUseCase) DeleteDefinition(surveyID int64, version int) error {
+	definition, err := uc.GetDefinition(surveyID, version)
+	if err != nil {
+		return err
+	}
+	// Versões com respostas são mantidas para que os relatórios históricos continuem válidos
+	if definition.IsActive || definition.Responses > 0 {
+		return ErrSurveyDefinitionInUse
+	}
+
+	err = uc.definitionRepo.DeleteDefinition(surveyID, version)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrSurveyDefinitionNotFound
+	}
+	return err
+}
+
+func (uc *surveyDefinitionUseCase) ValidateAnswers(surveyID int64, answers []SurveyAnswerInput, complete bool) (*SurveyAnswerValidation, error) {
+	definition, err := uc.GetActiveDefinition(surveyID)
+	if err != nil {
+		return nil, err
+	}
+	return ScoreSurveyAnswers(definition, answers, complete), nil
+}
+
+func (uc *surveyDefinitionUseCase) ensureSurvey(surveyID int64) error {
+	exists, err := uc.definitionRepo.SurveyExists(surveyID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrSurveyNotFound
+	}
+	return nil
+}
+
+// ScoreSurveyAnswers valida as respostas contra a versão informada e calcula a pontuação total e a faixa.
+// As respostas rejeitadas não entram na pontuação.
+func ScoreSurveyAnswers(definition *entities.SurveyDefinition, answers []SurveyAnswerInput, complete bool) *SurveyAnswerValidation {
+	result := &SurveyAnswerValidation{
+		SurveyID: definition.SurveyID,
+		Version:  definition.Version,
+		Complete: complete,
+		Answers:  make([]SurveyScoredAnswer, 0, len(answers)),
+		Errors:   []SurveyAnswerError{},
+	}
+
+	questions := make(map[string]*entities.SurveyDefinitionQuestion, len(definition.Questions))
+	for i := range definition.Questions {
+		questions[definition.Questions[i].QuestionID] = &definition.Questions[i]
+	}
+
+	answered := make(map[string]bool, len(answers))
+	for _, answer := range answers {
+		questionID := strings.TrimSpace(answer.QuestionID)
+		question := questions[questionID]
+		switch {
+		case question == nil:
+			result.Errors = append(result.Errors, SurveyAnswerError{QuestionID: questionID, Error: "pergunta não existe na versão"})
+			continue
+		case answered[questionID]:
+			result.Errors = append(result.Errors, SurveyAnswerError{QuestionID: questionID, Error: "pergunta respondida mais de uma vez"})
+			continue
+		}
+		answered[questionID] = true
+
+		value := strings.TrimSpace(answer.Value)
+		if value == "" {
+			result.Errors = append(result.Errors, SurveyAnswerError{QuestionID: questionID, Error: "resposta vazia"})
+			continue
+		}
+
+		scored := SurveyScoredAnswer{QuestionID: questionID, QuestionText: question.Text, Value: value}
+		if question.Type != entities.SurveyQuestionTypeText {
+			option := findSurveyOption(question, value)
+			if option == nil {
+				result.Errors = append(result.Errors, SurveyAnswerError{QuestionID: questionID, Error: fmt.Sprintf("opção '%s' não existe na pergunta", value)})
+				continue
+			}
+			scored.Value = option.Value
+			scored.Score = option.Score
+		}
+
+		result.Answers = append(result.Answers, scored)
+		result.TotalScore += scored.Score
+	}
+
+	if complete {
+		for _, question := range definition.Questions {
+			if question.Required && !answered[question.QuestionID] {
+				result.Errors = append(result.Errors, SurveyAnswerError{QuestionID: question.QuestionID, Error: "pergunta obrigatória não respondida"})
+			}
+		}
+	}
+
+	result.Valid = len(result.Errors) == 0
+	result.Faixa = SurveyFaixaFor(definition, result.TotalScore)
+	return result
+}
+
+// SurveyFaixaFor retorna a faixa com o maior min_score que não ultrapassa a pontuação; abaixo de todos
+// os mínimos, a faixa de menor pontuação
+func SurveyFaixaFor(definition *entities.SurveyDefinition, score int) string {
+	var match, lowest *entities.SurveyFaixa
+	for i := range definition.Faixas {
+		faixa := &definition.Faixas[i]
+		if score >= faixa.MinScore && (match == nil || faixa.MinScore > match.MinScore) {
+			match = faixa
+		}
+		if lowest == nil || faixa.MinScore < lowest.MinScore {
+			lowest = faixa
+		}
+	}
+	if match != nil {
+		return match.Name
+	}
+	if lowest != nil {
+		return lowest.Name
+	}
+	return ""
+}
+
+// findSurveyOption localiza a opção pelo valor, sem diferenciar maiúsculas e minúsculas
+func findSurveyOption(question *entities.SurveyDefinitionQuestion, value string) *entities.SurveyQuestionOption {
+	for i := range question.Options {
+		if strings.EqualFold(question.Options[i].Value, value) {
+			return &question.Options[i]
+		}
+	}
+	return nil
+}
+
+// toEntity valida a definição enviada e a converte para a entidade, preservando a ordem das perguntas e opções
+func (input SurveyDefinitionInput) toEntity(surveyID int64) (*entities.SurveyDefinition, error) {
+	if len(input.Questions) == 0 {
+		return nil, fmt.Errorf("%w: é necessário definir ao menos uma pergunta", ErrInvalidSurveyDefinition)
+	}
+	if len(input.Faixas) == 0 {
+		return nil, fmt.Errorf("%w: é necessário definir ao menos uma faixa", ErrInvalidSurveyDefinition)
+	}
+
+	definition := &entities.SurveyDefinition{
+		SurveyID: surveyID,
+		IsActive: input.Activate,
+		Notes:    strings.TrimSpace(input.Notes),
+	}
+
+	seenQuestions := make(map[string]bool, len(input.Questions))
+	for i, q := range input.Questions {
+		questionID := strings.TrimSpace(q.QuestionID)
+		if questionID == "" {
+			return nil, fmt.Errorf("%w: todas as perguntas devem ter question_id", ErrInvalidSurveyDefinition)
+		}
+		if seenQuestions[questionID] {
+			return nil, fmt.Errorf("%w: pergunta '%s' duplicada", ErrInvalidSurveyDefinition, questionID)
+		}
+		seenQuestions[questionID] = true
+
+		text := strings.TrimSpace(q.Text)
+		if text == "" {
+			return nil, fmt.Errorf("%w: a pergunta '%s' não tem texto", ErrInvalidSurveyDefinition, questionID)
+		}
+
+		questionType := strings.TrimSpace(q.Type)
+		if questionType == "" {
+			questionType = entities.SurveyQuestionTypeSingleChoice
+		}
+
+		question := entities.SurveyDefinitionQuestion{
+			QuestionID: questionID,
+			Text:       text,
+			Type:       questionType,
+			Position:   i + 1,
+			Required:   q.Required == nil || *q.Required,
+		}
+
+		switch questionType {
+		case entities.SurveyQuestionTypeSingleChoice:
+			if len(q.Options) == 0 {
+				return nil, fmt.Errorf("%w: a pergunta '%s' não tem opções", ErrInvalidSurveyDefinition, questionID)
+			}
+		case entities.SurveyQuestionTypeText:
+			if len(q.Options) > 0 {
+				return nil, fmt.Errorf("%w: a pergunta '%s' é de texto livre e não aceita opções", ErrInvalidSurveyDefinition, questionID)
+			}
+		default:
+			return nil, fmt.Errorf("%w: tipo '%s' inválido na pergunta '%s' (use %s ou %s)", ErrInvalidSurveyDefinition,
+				questionType, questionID, entities.SurveyQuestionTypeSingleChoice, entities.SurveyQuestionTypeText)
+		}
+
+		seenOptions := make(map[string]bool, len(q.Options))
+		for j, o := range q.Options {
+			value := strings.TrimSpace(o.Value)
+			if value == "" {
+				return nil, fmt.Errorf("%w: a pergunta '%s' tem opção sem valor", ErrInvalidSurveyDefinition, questionID)
+			}
+			if seenOptions[strings.ToLower(value)] {
+				return nil, fmt.Errorf("%w: opção '%s' duplicada na pergunta '%s'", ErrInvalidSurveyDefinition, value, questionID)
+			}
+			seenOptions[strings.ToLower(value)] = true
+
+			question.Options = append(question.Options, entities.SurveyQuestionOption{
+				Value:    value,
+				Label:    strings.TrimSpace(o.Label),
+				Score:    o.Score,
+				Position: j + 1,
+			})
+		}
+
+		definition.Questions = append(definition.Questions, question)
+	}
+
+	seenFaixas := make(map[string]bool, len(input.Faixas))
+	seenMinScores := make(map[int]bool, len(input.Faixas))
+	for _, f := range input.Faixas {
+		name := strings.TrimSpace(f.Name)
+		if name == "" {
+			return nil, fmt.Errorf("%w: todas as faixas devem ter nome", ErrInvalidSurveyDefinition)
+		}
+		if seenFaixas[name] {
+			return nil, fmt.Errorf("%w: faixa '%s' duplicada", ErrInvalidSurveyDefinition, name)
+		}
+		seenFaixas[name] = true
+		if seenMinScores[f.MinScore] {
+			return nil, fmt.Errorf("%w: mais de uma faixa com min_score %d", ErrInvalidSurveyDefinition, f.MinScore)
+		}
+		seenMinScores[f.MinScore] = true
+		definition.Faixas = append(definition.Faixas, entities.SurveyFaixa{Name: name, MinScore: f.MinScore})
+	}
+	sort.SliceStable(definition.Faixas, func(i, j int) bool {
+		return definition.Faixas[i].MinScore > definition.Faixas[j].MinScore
+	})
+
+	return definition, nil
+}
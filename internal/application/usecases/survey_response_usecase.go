@@ -0,0 +1,249 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSurveyResponseNotFound indica que a resposta informada não existe na pesquisa
+	ErrSurveyResponseNotFound = errors.New("resposta de pesquisa não encontrada")
+	// ErrSurveyResponseClosed indica que a resposta já foi finalizada e não aceita novas respostas
+	ErrSurveyResponseClosed = errors.New("a resposta da pesquisa já foi finalizada e não pode ser alterada")
+	// ErrPesquisaEventNotFound indica que não há evento PESQUISA_LEAD para vincular a resposta
+	ErrPesquisaEventNotFound = errors.New("evento PESQUISA_LEAD não encontrado")
+	// ErrInvalidSurveyAnswers indica que as respostas não são válidas para a definição da pesquisa
+	ErrInvalidSurveyAnswers = errors.New("respostas inválidas para a definição da pesquisa")
+)
+
+// SurveyResponseAnswerInput representa a resposta de uma pergunta enviada pelo cliente
+type SurveyResponseAnswerInput struct {
+	QuestionID   string     `json:"question_id"`
+	Value        string     `json:"value"`
+	TimeToAnswer float64    `json:"time_to_answer"`
+	Changed      bool       `json:"changed"`
+	AnsweredAt   *time.Time `json:"answered_at"`
+}
+
+// SurveyResponseInput representa o envio (parcial ou final) de uma resposta de pesquisa.
+// A resposta é identificada por response_id ou pelo evento PESQUISA_LEAD (event_id, ou o mais
+// recente do user_id no funil da pesquisa).
+type SurveyResponseInput struct {
+	ResponseID string                      `json:"response_id"`
+	EventID    string                      `json:"event_id"`
+	UserID     string                      `json:"user_id"`
+	Answers    []SurveyResponseAnswerInput `json:"answers"`
+	Completed  bool                        `json:"completed"`
+}
+
+// SurveyResponseResult representa a resposta gravada e a validação contra a definição
+type SurveyResponseResult struct {
+	Response   *entities.SurveyResponse `json:"response,omitempty"`
+	Validation *SurveyAnswerValidation  `json:"validation"`
+}
+
+// SurveyResponseUseCase interface para casos de uso de envio de respostas de pesquisa
+type SurveyResponseUseCase interface {
+	// SubmitResponse grava as respostas, calculando pontuação e faixa pela definição da pesquisa.
+	// Envios parciais podem ser continuados até o envio com completed.
+	SubmitResponse(surveyID int64, input SurveyResponseInput) (*SurveyResponseResult, error)
+	GetResponse(surveyID int64, responseID string) (*entities.SurveyResponse, error)
+}
+
+type surveyResponseUseCase struct {
+	responseRepo   repositories.SurveyResponseRepository
+	definitionRepo repositories.SurveyDefinitionRepository
+}
+
+func NewSurveyResponseUseCase(responseRepo repositories.SurveyResponseRepository, definitionRepo repositories.SurveyDefinitionRepository) SurveyResponseUseCase {
+	return &surveyResponseUseCase{
+		responseRepo:   responseRepo,
+		definitionRepo: definitionRepo,
+	}
+}
+
+func (uc *surveyResponseUseCase) SubmitResponse(surveyID int64, input SurveyResponseInput) (*SurveyResponseResult, error) {
+	survey, err := uc.responseRepo.GetSurvey(surveyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSurveyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar pesquisa: %w", err)
+	}
+
+	response, err := uc.resolveResponse(survey, input)
+	if err != nil {
+		return nil, err
+	}
+	if response.Completed {
+		return nil, ErrSurveyResponseClosed
+	}
+
+	definition, err := uc.resolveDefinition(response)
+	if err != nil {
+		return nil, err
+	}
+
+	// Respostas já gravadas, atualizadas pelas respostas enviadas agora (a última resposta de cada pergunta prevalece)
+	answers := make(map[string]*entities.SurveyAnswer, len(response.Answers)+len(input.Answers))
+	for i := range response.Answers {
+		answers[response.Answers[i].QuestionID] = &response.Answers[i]
+	}
+	touched := make(map[string]bool, len(input.Answers))
+	now := time.Now()
+	for _, in := range input.Answers {
+		questionID := strings.TrimSpace(in.QuestionID)
+		value := strings.TrimSpace(in.Value)
+		answeredAt := now
+		if in.AnsweredAt != nil {
+			answeredAt = *in.AnsweredAt
+		}
+
+		answer, exists := answers[questionID]
+		if !exists {
+			answer = &entities.SurveyAnswer{
+				ID:               uuid.NewString(),
+				SurveyResponseID: response.ID,
+				QuestionID:       questionID,
+			}
+			answers[questionID] = answer
+		}
+		// Uma pergunta respondida novamente soma o tempo gasto e é marcada como alterada se o valor mudou
+		answer.Changed = answer.Changed || in.Changed || (exists && !strings.EqualFold(answer.Value, value))
+		answer.Value = value
+		answer.TimeToAnswer += in.TimeToAnswer
+		answer.Timestamp = answeredAt
+		touched[questionID] = true
+	}
+
+	merged := make([]SurveyAnswerInput, 0, len(answers))
+	for questionID, answer := range answers {
+		merged = append(merged, SurveyAnswerInput{QuestionID: questionID, Value: answer.Value})
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].QuestionID < merged[j].QuestionID })
+
+	validation := ScoreSurveyAnswers(definition, merged, input.Completed)
+	if !validation.Valid {
+		return &SurveyResponseResult{Validation: validation}, ErrInvalidSurveyAnswers
+	}
+
+	toSave := make([]entities.SurveyAnswer, 0, len(touched))
+	for _, scored := range validation.Answers {
+		answer := answers[scored.QuestionID]
+		answer.Value = scored.Value
+		answer.QuestionText = scored.QuestionText
+		answer.Score = scored.Score
+		if touched[scored.QuestionID] {
+			toSave = append(toSave, *answer)
+		}
+	}
+
+	response.TotalScore = validation.TotalScore
+	response.Faixa = validation.Faixa
+	response.Completed = input.Completed
+	response.DefinitionVersion = &definition.Version
+	if err := uc.responseRepo.SaveResponse(response, toSave); err != nil {
+		return nil, err
+	}
+
+	response.Answers = make([]entities.SurveyAnswer, 0, len(answers))
+	for _, answer := range answers {
+		response.Answers = append(response.Answers, *answer)
+	}
+	sort.Slice(response.Answers, func(i, j int) bool {
+		return response.Answers[i].Timestamp.Before(response.Answers[j].Timestamp)
+	})
+
+	return &SurveyResponseResult{Response: response, Validation: validation}, nil
+}
+
+func (uc *surveyResponseUseCase) GetResponse(surveyID int64, responseID string) (*entities.SurveyResponse, error) {
+	response, err := uc.responseRepo.GetResponse(responseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && response.SurveyID != surveyID) {
+		return nil, ErrSurveyResponseNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar resposta da pesquisa: %w", err)
+	}
+	return response, nil
+}
+
+// resolveResponse retorna a resposta em andamento (por response_id ou pelo evento) ou uma nova resposta
+// vinculada ao evento PESQUISA_LEAD do usuário
+func (uc *surveyResponseUseCase) resolveResponse(survey *entities.Survey, input SurveyResponseInput) (*entities.SurveyResponse, error) {
+	if input.ResponseID != "" {
+		return uc.GetResponse(survey.SurveyID, input.ResponseID)
+	}
+
+	var event *entities.Event
+	var err error
+	switch {
+	case input.EventID != "":
+		event, err = uc.responseRepo.GetPesquisaEvent(input.EventID)
+	case input.UserID != "":
+		event, err = uc.responseRepo.GetLatestPesquisaEvent(input.UserID, survey.FunnelID)
+	default:
+		return nil, fmt.Errorf("%w: informe response_id, event_id ou user_id", ErrPesquisaEventNotFound)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPesquisaEventNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar evento da pesquisa: %w", err)
+	}
+
+	if event.FunnelID != survey.FunnelID {
+		return nil, fmt.Errorf("%w: o evento pertence a outro funil", ErrPesquisaEventNotFound)
+	}
+
+	eventID := event.EventID.String()
+	response, err := uc.responseRepo.GetResponseByEvent(survey.SurveyID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if response != nil {
+		return response, nil
+	}
+
+	return &entities.SurveyResponse{
+		ID:        uuid.NewString(),
+		SurveyID:  survey.SurveyID,
+		EventID:   eventID,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// resolveDefinition retorna a versão em que a resposta começou a ser coletada, ou a versão ativa
+// para uma resposta nova
+func (uc *surveyResponseUseCase) resolveDefinition(response *entities.SurveyResponse) (*entities.SurveyDefinition, error) {
+	if len(response.Answers) == 0 && response.DefinitionVersion == nil {
+		definition, err := uc.definitionRepo.GetActiveDefinition(response.SurveyID)
+		if err != nil {
+			return nil, err
+		}
+		if definition == nil {
+			return nil, ErrNoActiveSurveyDefinition
+		}
+		return definition, nil
+	}
+
+	if response.DefinitionVersion == nil {
+		return nil, fmt.Errorf("%w: coletada antes das definições da pesquisa", ErrSurveyResponseClosed)
+	}
+	definition, err := uc.definitionRepo.GetDefinition(response.SurveyID, *response.DefinitionVersion)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSurveyDefinitionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar definição da pesquisa: %w", err)
+	}
+	return definition, nil
+}
@@ -0,0 +1,301 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrSurveyVariantNotFound indica que a variante solicitada não existe
+	ErrSurveyVariantNotFound = errors.New("variante de pesquisa não encontrada")
+	// ErrNoSurveyVariants indica que o funil não possui variantes com tráfego
+	ErrNoSurveyVariants = errors.New("o funil não possui variantes de pesquisa com tráfego")
+	// ErrInvalidSurveyVariant indica que os dados da variante não são válidos
+	ErrInvalidSurveyVariant = errors.New("variante de pesquisa inválida")
+)
+
+// SurveyVariantInput representa os dados de criação/alteração de uma variante.
+// Na alteração, apenas os campos informados são modificados.
+type SurveyVariantInput struct {
+	SurveyID  int64   `json:"survey_id"`
+	Name      *string `json:"name"`
+	Weight    *int    `json:"weight"`
+	IsControl *bool   `json:"is_control"`
+}
+
+// SurveyVariantFaixa representa a distribuição de faixas de uma variante
+type SurveyVariantFaixa struct {
+	Faixa       string  `json:"faixa"`
+	Respondents int64   `json:"respondents"`
+	Percentage  float64 `json:"percentage"`
+}
+
+// SurveyVariantReport representa as métricas de uma variante e a comparação com o controle
+type SurveyVariantReport struct {
+	entities.SurveyVariant
+	SurveyName string `json:"survey_name"`
+	// TrafficShare é o percentual de tráfego configurado; ObservedShare, o percentual de respondentes observado
+	TrafficShare   float64              `json:"traffic_share"`
+	ObservedShare  float64              `json:"observed_share"`
+	Respondents    int64                `json:"respondents"`
+	Completed      int64                `json:"completed"`
+	CompletionRate float64              `json:"completion_rate"`
+	Purchasers     int64                `json:"purchasers"`
+	ConversionRate float64              `json:"conversion_rate"`
+	Faixas         []SurveyVariantFaixa `json:"faixas"`
+	// Comparações com o controle (ausentes no próprio controle)
+	CompletionTest *ProportionTest `json:"completion_test,omitempty"`
+	ConversionTest *ProportionTest `json:"conversion_test,omitempty"`
+}
+
+// SurveyVariantExperiment representa o experimento de variantes de pesquisa de um funil
+type SurveyVariantExperiment struct {
+	FunnelID          int                   `json:"funnel_id"`
+	ControlVariantID  int64                 `json:"control_variant_id,omitempty"`
+	SignificanceLevel float64               `json:"significance_level"`
+	Variants          []SurveyVariantReport `json:"variants"`
+}
+
+// SurveyVariantUseCase interface para casos de uso de variantes de pesquisa
+type SurveyVariantUseCase interface {
+	ListVariants(funnelID int) ([]entities.SurveyVariant, error)
+	CreateVariant(funnelID int, input SurveyVariantInput) (*entities.SurveyVariant, error)
+	UpdateVariant(variantID int64, input SurveyVariantInput) (*entities.SurveyVariant, error)
+	DeleteVariant(variantID int64) error
+
+	// AssignVariant sorteia a variante do usuário pelo peso de tráfego. O sorteio é determinístico por
+	// usuário, e quem já respondeu uma variante do funil continua nela.
+	AssignVariant(funnelID int, userID string) (*entities.SurveyVariant, error)
+	// CompareVariants retorna as métricas por variante no período e os testes contra o controle
+	CompareVariants(funnelID int, params map[string]interface{}) (*SurveyVariantExperiment, error)
+}
+
+type surveyVariantUseCase struct {
+	variantRepo repositories.SurveyVariantRepository
+	surveyRepo  *repositories.SurveyRepository
+}
+
+func NewSurveyVariantUseCase(variantRepo repositories.SurveyVariantRepository, surveyRepo *repositories.SurveyRepository) SurveyVariantUseCase {
+	return &surveyVariantUseCase{
+		variantRepo: variantRepo,
+		surveyRepo:  surveyRepo,
+	}
+}
+
+func (uc *surveyVariantUseCase) ListVariants(funnelID int) ([]entities.SurveyVariant, error) {
+	return uc.variantRepo.ListVariants(funnelID)
+}
+
+func (uc *surveyVariantUseCase) CreateVariant(funnelID int, input SurveyVariantInput) (*entities.SurveyVariant, error) {
+	if input.SurveyID <= 0 {
+		return nil, fmt.Errorf("%w: survey_id é obrigatório", ErrInvalidSurveyVariant)
+	}
+	survey, err := uc.surveyRepo.GetSurvey(input.SurveyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSurveyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar pesquisa: %w", err)
+	}
+	if survey.FunnelID != funnelID {
+		return nil, fmt.Errorf("%w: a pesquisa %d pertence ao funil %d", ErrInvalidSurveyVariant, survey.SurveyID, survey.FunnelID)
+	}
+
+	existing, err := uc.variantRepo.ListVariants(funnelID)
+	if err != nil {
+		return nil, err
+	}
+	for _, variant := range existing {
+		if variant.SurveyID == input.SurveyID {
+			return nil, fmt.Errorf("%w: a pesquisa %d já é uma variante do funil", ErrInvalidSurveyVariant, input.SurveyID)
+		}
+	}
+
+	variant := &entities.SurveyVariant{
+		FunnelID: funnelID,
+		SurveyID: input.SurveyID,
+		Name:     survey.Name,
+		Weight:   1,
+		// A primeira variante do funil é o controle
+		IsControl: len(existing) == 0,
+	}
+	if err := input.apply(variant); err != nil {
+		return nil, err
+	}
+
+	if err := uc.variantRepo.CreateVariant(variant); err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+func (uc *surveyVariantUseCase) UpdateVariant(variantID int64, input SurveyVariantInput) (*entities.SurveyVariant, error) {
+	variant, err := uc.variantRepo.GetVariant(variantID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSurveyVariantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar variante de pesquisa: %w", err)
+	}
+	if input.SurveyID != 0 && input.SurveyID != variant.SurveyID {
+		return nil, fmt.Errorf("%w: a pesquisa de uma variante não pode ser alterada", ErrInvalidSurveyVariant)
+	}
+
+	if err := input.apply(variant); err != nil {
+		return nil, err
+	}
+	if err := uc.variantRepo.UpdateVariant(variant); err != nil {
+		return nil, err
+	}
+	return variant, nil
+}
+
+func (uc *surveyVariantUseCase) DeleteVariant(variantID int64) error {
+	err := uc.variantRepo.DeleteVariant(variantID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrSurveyVariantNotFound
+	}
+	return err
+}
+
+func (uc *surveyVariantUseCase) AssignVariant(funnelID int, userID string) (*entities.SurveyVariant, error) {
+	assigned, err := uc.variantRepo.GetUserVariant(funnelID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if assigned != nil {
+		return assigned, nil
+	}
+
+	variants, err := uc.variantRepo.ListVariants(funnelID)
+	if err != nil {
+		return nil, err
+	}
+	totalWeight := 0
+	for _, variant := range variants {
+		totalWeight += variant.Weight
+	}
+	if totalWeight == 0 {
+		return nil, ErrNoSurveyVariants
+	}
+
+	hash := fnv.New32a()
+	fmt.Fprintf(hash, "%d:%s", funnelID, userID)
+	bucket := int(hash.Sum32() % uint32(totalWeight))
+	for i := range variants {
+		if bucket < variants[i].Weight {
+			return &variants[i], nil
+		}
+		bucket -= variants[i].Weight
+	}
+	return nil, ErrNoSurveyVariants
+}
+
+func (uc *surveyVariantUseCase) CompareVariants(funnelID int, params map[string]interface{}) (*SurveyVariantExperiment, error) {
+	variants, err := uc.variantRepo.ListVariants(funnelID)
+	if err != nil {
+		return nil, err
+	}
+	if len(variants) == 0 {
+		return nil, ErrNoSurveyVariants
+	}
+
+	surveyIDs := make([]int64, len(variants))
+	totalWeight := 0
+	for i, variant := range variants {
+		surveyIDs[i] = variant.SurveyID
+		totalWeight += variant.Weight
+	}
+
+	metrics, faixas, err := uc.surveyRepo.GetSurveyVariantMetrics(funnelID, surveyIDs, params)
+	if err != nil {
+		return nil, err
+	}
+	metricsBySurvey := make(map[int64]repositories.SurveyVariantMetricsRow, len(metrics))
+	var totalRespondents int64
+	for _, row := range metrics {
+		metricsBySurvey[row.SurveyID] = row
+		totalRespondents += row.Respondents
+	}
+	faixasBySurvey := make(map[int64][]repositories.SurveyVariantFaixaRow)
+	for _, row := range faixas {
+		faixasBySurvey[row.SurveyID] = append(faixasBySurvey[row.SurveyID], row)
+	}
+
+	experiment := &SurveyVariantExperiment{
+		FunnelID:          funnelID,
+		SignificanceLevel: experimentSignificanceLevel,
+		Variants:          make([]SurveyVariantReport, 0, len(variants)),
+	}
+
+	controlIndex := -1
+	for _, variant := range variants {
+		row := metricsBySurvey[variant.SurveyID]
+		report := SurveyVariantReport{
+			SurveyVariant:  variant,
+			Respondents:    row.Respondents,
+			Completed:      row.Completed,
+			CompletionRate: crosstabPercentage(row.Completed, row.Respondents),
+			Purchasers:     row.Purchasers,
+			ConversionRate: crosstabPercentage(row.Purchasers, row.Respondents),
+			ObservedShare:  crosstabPercentage(row.Respondents, totalRespondents),
+			Faixas:         make([]SurveyVariantFaixa, 0, len(faixasBySurvey[variant.SurveyID])),
+		}
+		if totalWeight > 0 {
+			report.TrafficShare = crosstabPercentage(int64(variant.Weight), int64(totalWeight))
+		}
+		if survey, err := uc.surveyRepo.GetSurvey(variant.SurveyID); err == nil {
+			report.SurveyName = survey.Name
+		}
+		for _, faixa := range faixasBySurvey[variant.SurveyID] {
+			report.Faixas = append(report.Faixas, SurveyVariantFaixa{
+				Faixa:       faixa.Faixa,
+				Respondents: faixa.Respondents,
+				Percentage:  crosstabPercentage(faixa.Respondents, row.Respondents),
+			})
+		}
+
+		experiment.Variants = append(experiment.Variants, report)
+		if variant.IsControl {
+			controlIndex = len(experiment.Variants) - 1
+			experiment.ControlVariantID = variant.VariantID
+		}
+	}
+
+	if controlIndex >= 0 {
+		control := experiment.Variants[controlIndex]
+		for i := range experiment.Variants {
+			report := &experiment.Variants[i]
+			if report.IsControl {
+				continue
+			}
+			report.CompletionTest = compareProportions(control.Completed, control.Respondents, report.Completed, report.Respondents)
+			report.ConversionTest = compareProportions(control.Purchasers, control.Respondents, report.Purchasers, report.Respondents)
+		}
+	}
+
+	return experiment, nil
+}
+
+// apply valida e aplica os campos informados na variante
+func (input SurveyVariantInput) apply(variant *entities.SurveyVariant) error {
+	if input.Name != nil {
+		variant.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Weight != nil {
+		if *input.Weight < 0 {
+			return fmt.Errorf("%w: weight não pode ser negativo", ErrInvalidSurveyVariant)
+		}
+		variant.Weight = *input.Weight
+	}
+	if input.IsControl != nil {
+		variant.IsControl = *input.IsControl
+	}
+	return nil
+}
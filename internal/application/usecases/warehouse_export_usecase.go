@@ -0,0 +1,447 @@
+package usecases
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/infrastructure/parquet"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrWarehouseExportRunning indica que já há uma exportação do warehouse em andamento nesta instância
+	ErrWarehouseExportRunning = errors.New("exportação do warehouse já em andamento")
+	// ErrInvalidWarehouseExport indica parâmetros de exportação do warehouse inválidos
+	ErrInvalidWarehouseExport = errors.New("exportação do warehouse inválida")
+	// ErrWarehouseFileNotFound indica que o arquivo solicitado não existe (ou que o token de download não confere)
+	ErrWarehouseFileNotFound = errors.New("arquivo do warehouse não encontrado")
+)
+
+const (
+	// Diretório padrão dos arquivos Parquet
+	defaultWarehouseDir = "warehouse"
+	// Minutos recentes deixados de fora de cada exportação, para absorver linhas que chegam atrasadas
+	defaultWarehouseLagMinutes = 15
+	// warehouseFileListLimit limita os arquivos retornados em uma consulta ao manifesto
+	warehouseFileListLimit = 1000
+)
+
+// WarehouseDatasetReport é o resultado da exportação de um dataset
+type WarehouseDatasetReport struct {
+	Dataset string    `json:"dataset"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Files   int       `json:"files"`
+	Rows    int64     `json:"rows"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// WarehouseRunReport é o resultado de uma execução das exportações do warehouse
+type WarehouseRunReport struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// Skipped indica que outra instância estava exportando e nada foi feito
+	Skipped  bool                     `json:"skipped,omitempty"`
+	Datasets []WarehouseDatasetReport `json:"datasets"`
+}
+
+// WarehouseExportStatus representa o estado das exportações do warehouse
+type WarehouseExportStatus struct {
+	Running  bool                            `json:"running"`
+	Schedule string                          `json:"schedule"`
+	LastRun  *WarehouseRunReport             `json:"last_run,omitempty"`
+	States   []entities.WarehouseExportState `json:"states"`
+}
+
+// WarehouseExportUseCase exporta events, sessions, users e survey_answers em arquivos Parquet particionados
+// por dia, de forma incremental a partir da marca d'água de cada dataset. As exportações rodam diariamente
+// (WAREHOUSE_EXPORT_HOUR) ou sob demanda, uma por vez entre todas as instâncias.
+type WarehouseExportUseCase struct {
+	warehouseRepo repositories.WarehouseRepository
+	dir           string
+	lag           time.Duration
+	// hour é a hora diária (America/Sao_Paulo) da exportação agendada, ou -1 sem agendamento
+	hour int
+	// token é o token exigido nos downloads (WAREHOUSE_TOKEN); vazio desativa os downloads
+	token string
+
+	// triggers leva a Run as exportações sob demanda, já reservadas por claim
+	triggers chan warehouseTrigger
+
+	mu      sync.Mutex
+	running bool
+	lastRun *WarehouseRunReport
+}
+
+// warehouseTrigger é uma exportação sob demanda aguardando Run
+type warehouseTrigger struct {
+	datasets []repositories.WarehouseDataset
+	since    *time.Time
+}
+
+// NewWarehouseExportUseCase cria o caso de uso com a configuração das variáveis WAREHOUSE_DIR,
+// WAREHOUSE_LAG_MINUTES, WAREHOUSE_EXPORT_HOUR e WAREHOUSE_TOKEN
+func NewWarehouseExportUseCase(warehouseRepo repositories.WarehouseRepository) *WarehouseExportUseCase {
+	dir := os.Getenv("WAREHOUSE_DIR")
+	if dir == "" {
+		dir = defaultWarehouseDir
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+
+	hour := -1
+	if value, err := strconv.Atoi(os.Getenv("WAREHOUSE_EXPORT_HOUR")); err == nil && value >= 0 && value <= 23 {
+		hour = value
+	}
+
+	return &WarehouseExportUseCase{
+		warehouseRepo: warehouseRepo,
+		dir:           dir,
+		lag:           time.Duration(envInt("WAREHOUSE_LAG_MINUTES", defaultWarehouseLagMinutes)) * time.Minute,
+		hour:          hour,
+		token:         os.Getenv("WAREHOUSE_TOKEN"),
+		triggers:      make(chan warehouseTrigger, 1),
+	}
+}
+
+// Run executa a exportação agendada todo dia na hora configurada e as exportações sob demanda, até o
+// contexto ser cancelado. Sem WAREHOUSE_EXPORT_HOUR, as exportações só rodam sob demanda. Uma exportação
+// interrompida pelo cancelamento descarta o arquivo parcial e continua da marca d'água na próxima execução.
+func (uc *WarehouseExportUseCase) Run(ctx context.Context) {
+	if uc.hour < 0 {
+		log.Println("📦 Exportação agendada do warehouse desativada (WAREHOUSE_EXPORT_HOUR não definido)")
+	} else {
+		log.Printf("📦 Exportação do warehouse agendada para as %02d:00 (diretório %s)", uc.hour, uc.dir)
+	}
+
+	for {
+		var scheduled <-chan time.Time
+		if uc.hour >= 0 {
+			now := time.Now().In(utils.GetBrasilLocation())
+			next := time.Date(now.Year(), now.Month(), now.Day(), uc.hour, 0, 0, 0, now.Location())
+			if !next.After(now) {
+				next = next.AddDate(0, 0, 1)
+			}
+			scheduled = time.After(time.Until(next))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case trigger := <-uc.triggers:
+			if _, err := uc.run(ctx, trigger.datasets, trigger.since); err != nil {
+				log.Printf("⚠️ Erro na exportação do warehouse: %v", err)
+			}
+		case <-scheduled:
+			if _, err := uc.RunOnce(ctx, nil, nil); err != nil {
+				log.Printf("⚠️ Erro na exportação agendada do warehouse: %v", err)
+			}
+		}
+	}
+}
+
+// Trigger agenda a exportação dos datasets informados (todos se vazio), executada em segundo plano por Run.
+// Com since, reexporta a partir desse instante em vez da marca d'água.
+func (uc *WarehouseExportUseCase) Trigger(datasets []string, since *time.Time) error {
+	selected, err := resolveWarehouseDatasets(datasets)
+	if err != nil {
+		return err
+	}
+	if !uc.claim() {
+		return ErrWarehouseExportRunning
+	}
+
+	// claim garante uma exportação por vez, então o buffer do canal sempre tem espaço
+	uc.triggers <- warehouseTrigger{datasets: selected, since: since}
+	return nil
+}
+
+// RunOnce exporta os datasets informados (todos se vazio) até o momento atual menos o atraso configurado
+func (uc *WarehouseExportUseCase) RunOnce(ctx context.Context, datasets []string, since *time.Time) (*WarehouseRunReport, error) {
+	selected, err := resolveWarehouseDatasets(datasets)
+	if err != nil {
+		return nil, err
+	}
+	if !uc.claim() {
+		return nil, ErrWarehouseExportRunning
+	}
+	return uc.run(ctx, selected, since)
+}
+
+// claim marca uma exportação em andamento nesta instância; retorna false se já houver uma
+func (uc *WarehouseExportUseCase) claim() bool {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	if uc.running {
+		return false
+	}
+	uc.running = true
+	return true
+}
+
+// run executa uma exportação reservada por claim, sob o lock entre instâncias
+func (uc *WarehouseExportUseCase) run(ctx context.Context, selected []repositories.WarehouseDataset, since *time.Time) (*WarehouseRunReport, error) {
+	report := &WarehouseRunReport{StartedAt: time.Now()}
+	defer func() {
+		report.FinishedAt = time.Now()
+		uc.mu.Lock()
+		uc.running = false
+		uc.lastRun = report
+		uc.mu.Unlock()
+	}()
+
+	unlock, acquired, err := uc.warehouseRepo.TryLock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		report.Skipped = true
+		log.Println("📦 Exportação do warehouse em andamento em outra instância; execução ignorada")
+		return report, nil
+	}
+	defer unlock()
+
+	until := time.Now().Add(-uc.lag).Truncate(time.Second)
+	var failed error
+	for _, dataset := range selected {
+		result := uc.exportDataset(ctx, dataset, since, until)
+		if result.Error != "" && failed == nil {
+			failed = fmt.Errorf("%s: %s", dataset.Name, result.Error)
+		}
+		report.Datasets = append(report.Datasets, result)
+	}
+	return report, failed
+}
+
+// exportDataset exporta o dataset da marca d'água (ou de since) até until, um arquivo por dia com linhas.
+// A marca d'água avança a cada dia concluído, de modo que uma execução interrompida continua de onde parou.
+func (uc *WarehouseExportUseCase) exportDataset(ctx context.Context, dataset repositories.WarehouseDataset, since *time.Time, until time.Time) WarehouseDatasetReport {
+	result := WarehouseDatasetReport{Dataset: dataset.Name, To: until}
+
+	from, err := uc.startOf(ctx, dataset, since, until)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.From = from
+	if !from.Before(until) {
+		return result
+	}
+
+	loc := utils.GetBrasilLocation()
+	for from.Before(until) {
+		if err := ctx.Err(); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+
+		day := from.In(loc)
+		dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+		to := dayStart.AddDate(0, 0, 1)
+		if to.After(until) {
+			to = until
+		}
+
+		file, err := uc.writePartition(ctx, dataset, dayStart.Format("2006-01-02"), from, to)
+		if err == nil {
+			err = uc.warehouseRepo.SavePartition(dataset.Name, file, to)
+		}
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		if file != nil {
+			result.Files++
+			result.Rows += file.Rows
+		}
+		from = to
+	}
+
+	log.Printf("📦 Warehouse: %s exportado até %s (%d arquivos, %d linhas)", dataset.Name, until.Format(time.RFC3339), result.Files, result.Rows)
+	return result
+}
+
+// startOf retorna o início da exportação: since, a marca d'água ou, na primeira exportação, a primeira linha do dataset
+func (uc *WarehouseExportUseCase) startOf(ctx context.Context, dataset repositories.WarehouseDataset, since *time.Time, until time.Time) (time.Time, error) {
+	if since != nil {
+		return *since, nil
+	}
+
+	state, err := uc.warehouseRepo.GetState(dataset.Name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if state != nil {
+		return state.Watermark, nil
+	}
+
+	earliest, err := uc.warehouseRepo.EarliestTime(ctx, dataset)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if earliest == nil {
+		// Dataset vazio: a próxima exportação começa daqui
+		return until, uc.warehouseRepo.SavePartition(dataset.Name, nil, until)
+	}
+	return *earliest, nil
+}
+
+// writePartition grava as linhas de [from, to) em <dir>/<dataset>/dt=<partition>/part-<from>-<to>.parquet.
+// Retorna nil, sem arquivo, quando o intervalo não tem linhas.
+func (uc *WarehouseExportUseCase) writePartition(ctx context.Context, dataset repositories.WarehouseDataset, partition string, from, to time.Time) (*entities.WarehouseExportFile, error) {
+	relPath := filepath.Join(dataset.Name, "dt="+partition,
+		fmt.Sprintf("part-%s-%s.parquet", from.UTC().Format("20060102T150405Z"), to.UTC().Format("20060102T150405Z")))
+	path := filepath.Join(uc.dir, relPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório da partição: %w", err)
+	}
+
+	partPath := path + ".part"
+	out, err := os.Create(partPath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar arquivo da partição: %w", err)
+	}
+	defer os.Remove(partPath)
+
+	columns := make([]parquet.Column, len(dataset.Columns))
+	for i, column := range dataset.Columns {
+		columns[i] = parquet.Column{Name: column.Name, Type: warehouseParquetType(column.Type)}
+	}
+	writer := parquet.NewWriter(out, columns, map[string]string{
+		"dataset":        dataset.Name,
+		"schema_version": strconv.Itoa(repositories.WarehouseSchemaVersion),
+		"partition":      partition,
+		"range_from":     from.UTC().Format(time.RFC3339),
+		"range_to":       to.UTC().Format(time.RFC3339),
+	})
+
+	err = uc.warehouseRepo.StreamRows(ctx, dataset, from, to, writer.Write)
+	if err == nil {
+		err = writer.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao exportar %s de %s: %w", dataset.Name, partition, err)
+	}
+	if writer.NumRows() == 0 {
+		return nil, nil
+	}
+
+	info, err := os.Stat(partPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(partPath, path); err != nil {
+		return nil, fmt.Errorf("erro ao gravar arquivo da partição: %w", err)
+	}
+
+	return &entities.WarehouseExportFile{
+		Dataset:   dataset.Name,
+		Partition: partition,
+		Path:      filepath.ToSlash(relPath),
+		Rows:      writer.NumRows(),
+		Bytes:     info.Size(),
+		RangeFrom: from,
+		RangeTo:   to,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// Status retorna a execução em andamento, a última execução desta instância e as marcas d'água
+func (uc *WarehouseExportUseCase) Status() (*WarehouseExportStatus, error) {
+	states, err := uc.warehouseRepo.GetStates()
+	if err != nil {
+		return nil, err
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	schedule := "sob demanda"
+	if uc.hour >= 0 {
+		schedule = fmt.Sprintf("diária às %02d:00", uc.hour)
+	}
+	return &WarehouseExportStatus{
+		Running:  uc.running,
+		Schedule: schedule,
+		LastRun:  uc.lastRun,
+		States:   states,
+	}, nil
+}
+
+// ListFiles retorna o manifesto dos arquivos criados após since (dataset vazio lista todos)
+func (uc *WarehouseExportUseCase) ListFiles(dataset string, since time.Time) ([]entities.WarehouseExportFile, error) {
+	if dataset != "" {
+		if _, ok := repositories.FindWarehouseDataset(dataset); !ok {
+			return nil, fmt.Errorf("%w: dataset desconhecido: %s", ErrInvalidWarehouseExport, dataset)
+		}
+	}
+	return uc.warehouseRepo.ListFiles(dataset, since, warehouseFileListLimit)
+}
+
+// HasDownloadToken indica se token é o token de download do warehouse (WAREHOUSE_TOKEN)
+func (uc *WarehouseExportUseCase) HasDownloadToken(token string) bool {
+	return uc.token != "" && subtle.ConstantTimeCompare([]byte(uc.token), []byte(token)) == 1
+}
+
+// OpenFile valida o token de download e retorna o registro e o caminho local de um arquivo do manifesto
+func (uc *WarehouseExportUseCase) OpenFile(fileID int64, token string) (*entities.WarehouseExportFile, string, error) {
+	if !uc.HasDownloadToken(token) {
+		return nil, "", ErrWarehouseFileNotFound
+	}
+	file, err := uc.warehouseRepo.GetFile(fileID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", ErrWarehouseFileNotFound
+		}
+		return nil, "", err
+	}
+	return file, filepath.Join(uc.dir, filepath.FromSlash(file.Path)), nil
+}
+
+// resolveWarehouseDatasets valida os nomes informados; vazio seleciona todos os datasets
+func resolveWarehouseDatasets(names []string) ([]repositories.WarehouseDataset, error) {
+	if len(names) == 0 {
+		return repositories.WarehouseDatasets, nil
+	}
+
+	selected := make([]repositories.WarehouseDataset, 0, len(names))
+	for _, name := range names {
+		dataset, ok := repositories.FindWarehouseDataset(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: dataset desconhecido: %s", ErrInvalidWarehouseExport, name)
+		}
+		selected = append(selected, dataset)
+	}
+	return selected, nil
+}
+
+func warehouseParquetType(kind string) parquet.ColumnType {
+	switch kind {
+	case repositories.WarehouseBoolean:
+		return parquet.Boolean
+	case repositories.WarehouseInt32:
+		return parquet.Int32
+	case repositories.WarehouseDouble:
+		return parquet.Double
+	case repositories.WarehouseJSON:
+		return parquet.JSON
+	case repositories.WarehouseTimestamp:
+		return parquet.Timestamp
+	default:
+		return parquet.String
+	}
+}
@@ -0,0 +1,325 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// ErrWebinarCycleNotFound indica que o ciclo solicitado não existe
+var ErrWebinarCycleNotFound = errors.New("ciclo não encontrado")
+
+// Limite de ciclos gerados em uma única chamada (~2 anos)
+const maxGeneratedCycles = 104
+
+// WebinarCycleWithMetrics representa um ciclo com seus totais de leads, pesquisas e vendas
+type WebinarCycleWithMetrics struct {
+	entities.WebinarCycle
+	Metrics *repositories.WebinarCycleMetrics `json:"metrics,omitempty"`
+}
+
+// WebinarCycleInput representa os dados de criação/alteração de um ciclo.
+// Os limites não informados são calculados a partir do cronograma fixo de terça-feira; um venda_inicio à
+// meia-noite (só a data) recebe o horário padrão do início das vendas.
+type WebinarCycleInput struct {
+	ProfessionID   int        `json:"profession_id"`
+	FunnelID       *int       `json:"funnel_id"`
+	Name           *string    `json:"name"`
+	Notes          *string    `json:"notes"`
+	PesquisaInicio *time.Time `json:"pesquisa_inicio"`
+	PesquisaFim    *time.Time `json:"pesquisa_fim"`
+	VendaInicio    *time.Time `json:"venda_inicio"`
+	VendaFim       *time.Time `json:"venda_fim"`
+}
+
+// WebinarCycleUseCase interface para casos de uso de ciclos de webinar
+type WebinarCycleUseCase interface {
+	ListCycles(filter repositories.WebinarCycleFilter, page, limit int, includeMetrics bool) ([]WebinarCycleWithMetrics, int64, error)
+	GetCycle(cycleID int64, includeMetrics bool) (*WebinarCycleWithMetrics, error)
+	CreateCycle(input WebinarCycleInput) (*entities.WebinarCycle, error)
+	UpdateCycle(cycleID int64, input WebinarCycleInput) (*entities.WebinarCycle, error)
+	DeleteCycle(cycleID int64) error
+	GenerateCycles(professionID int, funnelID *int, from, to time.Time) ([]entities.WebinarCycle, error)
+
+	// ResolveCycle retorna o ciclo e os limites do ciclo anterior, usados como período de comparação
+	ResolveCycle(cycleID int64) (*entities.WebinarCycle, utils.WebinarCycleDates, error)
+}
+
+type webinarCycleUseCase struct {
+	cycleRepo repositories.WebinarCycleRepository
+}
+
+func NewWebinarCycleUseCase(cycleRepo repositories.WebinarCycleRepository) WebinarCycleUseCase {
+	return &webinarCycleUseCase{
+		cycleRepo: cycleRepo,
+	}
+}
+
+// CycleDates converte um ciclo persistido para os limites usados nas consultas
+func CycleDates(cycle *entities.WebinarCycle) utils.WebinarCycleDates {
+	loc := utils.GetBrasilLocation()
+	return utils.WebinarCycleDates{
+		PesquisaInicio: cycle.PesquisaInicio.In(loc),
+		PesquisaFim:    cycle.PesquisaFim.In(loc),
+		VendaInicio:    cycle.VendaInicio.In(loc),
+		VendaFim:       cycle.VendaFim.In(loc),
+	}
+}
+
+func (uc *webinarCycleUseCase) ListCycles(filter repositories.WebinarCycleFilter, page, limit int, includeMetrics bool) ([]WebinarCycleWithMetrics, int64, error) {
+	cycles, total, err := uc.cycleRepo.ListCycles(filter, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result, err := uc.withMetrics(cycles, includeMetrics)
+	if err != nil {
+		return nil, 0, err
+	}
+	return result, total, nil
+}
+
+func (uc *webinarCycleUseCase) GetCycle(cycleID int64, includeMetrics bool) (*WebinarCycleWithMetrics, error) {
+	cycle, err := uc.getCycle(cycleID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := uc.withMetrics([]entities.WebinarCycle{*cycle}, includeMetrics)
+	if err != nil {
+		return nil, err
+	}
+	return &result[0], nil
+}
+
+func (uc *webinarCycleUseCase) CreateCycle(input WebinarCycleInput) (*entities.WebinarCycle, error) {
+	if input.ProfessionID <= 0 {
+		return nil, fmt.Errorf("profession_id é obrigatório")
+	}
+	if input.VendaInicio == nil {
+		return nil, fmt.Errorf("venda_inicio é obrigatório")
+	}
+
+	cycle := &entities.WebinarCycle{
+		ProfessionID: input.ProfessionID,
+		FunnelID:     input.FunnelID,
+	}
+	applyCycleInput(cycle, input)
+
+	if err := validateCycleBoundaries(cycle); err != nil {
+		return nil, err
+	}
+
+	if err := uc.cycleRepo.CreateCycle(cycle); err != nil {
+		return nil, fmt.Errorf("erro ao criar ciclo: %w", err)
+	}
+	return cycle, nil
+}
+
+func (uc *webinarCycleUseCase) UpdateCycle(cycleID int64, input WebinarCycleInput) (*entities.WebinarCycle, error) {
+	cycle, err := uc.getCycle(cycleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.ProfessionID > 0 {
+		cycle.ProfessionID = input.ProfessionID
+	}
+	if input.FunnelID != nil {
+		cycle.FunnelID = input.FunnelID
+	}
+	applyCycleInput(cycle, input)
+
+	if err := validateCycleBoundaries(cycle); err != nil {
+		return nil, err
+	}
+
+	if err := uc.cycleRepo.UpdateCycle(cycle); err != nil {
+		return nil, fmt.Errorf("erro ao atualizar ciclo: %w", err)
+	}
+	return cycle, nil
+}
+
+func (uc *webinarCycleUseCase) DeleteCycle(cycleID int64) error {
+	err := uc.cycleRepo.DeleteCycle(cycleID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrWebinarCycleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao remover ciclo: %w", err)
+	}
+	return nil
+}
+
+// GenerateCycles cria os ciclos do cronograma padrão para cada terça-feira do intervalo.
+// Semanas que já possuem ciclo (gerado ou sobrescrito) são mantidas como estão.
+func (uc *webinarCycleUseCase) GenerateCycles(professionID int, funnelID *int, from, to time.Time) ([]entities.WebinarCycle, error) {
+	if professionID <= 0 {
+		return nil, fmt.Errorf("profession_id é obrigatório")
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("data final deve ser posterior à data inicial")
+	}
+
+	loc := utils.GetBrasilLocation()
+	day := from.In(loc)
+	for day.Weekday() != time.Tuesday {
+		day = day.AddDate(0, 0, 1)
+	}
+
+	created := make([]entities.WebinarCycle, 0)
+	for count := 0; !day.After(to); count++ {
+		if count >= maxGeneratedCycles {
+			return nil, fmt.Errorf("intervalo muito longo (máximo %d ciclos)", maxGeneratedCycles)
+		}
+
+		dates := utils.CalculateWebinarCycleDates(day)
+		cycle := &entities.WebinarCycle{
+			ProfessionID:   professionID,
+			FunnelID:       funnelID,
+			Name:           defaultCycleName(dates),
+			PesquisaInicio: dates.PesquisaInicio,
+			PesquisaFim:    dates.PesquisaFim,
+			VendaInicio:    dates.VendaInicio,
+			VendaFim:       dates.VendaFim,
+		}
+
+		ok, err := uc.cycleRepo.CreateGeneratedCycle(cycle)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			created = append(created, *cycle)
+		}
+
+		day = day.AddDate(0, 0, 7)
+	}
+
+	return created, nil
+}
+
+func (uc *webinarCycleUseCase) ResolveCycle(cycleID int64) (*entities.WebinarCycle, utils.WebinarCycleDates, error) {
+	cycle, err := uc.getCycle(cycleID)
+	if err != nil {
+		return nil, utils.WebinarCycleDates{}, err
+	}
+
+	previous, err := uc.cycleRepo.GetPreviousCycle(cycle)
+	if err != nil {
+		return nil, utils.WebinarCycleDates{}, err
+	}
+	if previous != nil {
+		return cycle, CycleDates(previous), nil
+	}
+
+	// Sem ciclo anterior cadastrado: usar o cronograma padrão da semana anterior
+	return cycle, CycleDates(cycle).PreviousCycle(), nil
+}
+
+func (uc *webinarCycleUseCase) getCycle(cycleID int64) (*entities.WebinarCycle, error) {
+	cycle, err := uc.cycleRepo.GetCycleByID(cycleID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWebinarCycleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar ciclo: %w", err)
+	}
+	return cycle, nil
+}
+
+func (uc *webinarCycleUseCase) withMetrics(cycles []entities.WebinarCycle, includeMetrics bool) ([]WebinarCycleWithMetrics, error) {
+	result := make([]WebinarCycleWithMetrics, len(cycles))
+	for i := range cycles {
+		result[i].WebinarCycle = cycles[i]
+	}
+	if !includeMetrics || len(cycles) == 0 {
+		return result, nil
+	}
+
+	ids := make([]int64, len(cycles))
+	for i, cycle := range cycles {
+		ids[i] = cycle.CycleID
+	}
+
+	metrics, err := uc.cycleRepo.GetCycleMetrics(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range result {
+		m := metrics[result[i].CycleID]
+		result[i].Metrics = &m
+	}
+	return result, nil
+}
+
+// applyCycleInput aplica os campos informados. Ao mudar o dia de vendas os limites são
+// recalculados pelo cronograma padrão antes de aplicar os limites explícitos.
+func applyCycleInput(cycle *entities.WebinarCycle, input WebinarCycleInput) {
+	if input.VendaInicio != nil {
+		// O nome padrão acompanha a nova data; um nome escolhido pelo usuário é mantido
+		defaultName := cycle.Name == "" ||
+			cycle.Name == defaultCycleName(utils.CalculateWebinarCycleDates(cycle.VendaInicio.In(utils.GetBrasilLocation())))
+
+		vendaInicio := input.VendaInicio.In(utils.GetBrasilLocation())
+		dates := utils.CalculateWebinarCycleDates(vendaInicio)
+		cycle.PesquisaInicio = dates.PesquisaInicio
+		cycle.PesquisaFim = dates.PesquisaFim
+		// Só a data (meia-noite em São Paulo): usa o horário padrão do início das vendas
+		if vendaInicio.Equal(time.Date(vendaInicio.Year(), vendaInicio.Month(), vendaInicio.Day(), 0, 0, 0, 0, vendaInicio.Location())) {
+			cycle.VendaInicio = dates.VendaInicio
+		} else {
+			cycle.VendaInicio = *input.VendaInicio
+		}
+		cycle.VendaFim = dates.VendaFim
+		if defaultName {
+			cycle.Name = defaultCycleName(dates)
+		}
+	}
+	if input.PesquisaInicio != nil {
+		cycle.PesquisaInicio = *input.PesquisaInicio
+	}
+	if input.PesquisaFim != nil {
+		cycle.PesquisaFim = *input.PesquisaFim
+	}
+	if input.VendaFim != nil {
+		cycle.VendaFim = *input.VendaFim
+	}
+	if input.Name != nil {
+		cycle.Name = *input.Name
+	}
+	if input.Notes != nil {
+		cycle.Notes = *input.Notes
+	}
+
+	// O ciclo é marcado como sobrescrito quando foge do cronograma padrão
+	vendaDay := cycle.VendaInicio.In(utils.GetBrasilLocation())
+	standard := utils.CalculateWebinarCycleDates(vendaDay)
+	cycle.IsOverride = vendaDay.Weekday() != time.Tuesday ||
+		!cycle.PesquisaInicio.Equal(standard.PesquisaInicio) ||
+		!cycle.PesquisaFim.Equal(standard.PesquisaFim) ||
+		!cycle.VendaInicio.Equal(standard.VendaInicio) ||
+		!cycle.VendaFim.Equal(standard.VendaFim)
+}
+
+func validateCycleBoundaries(cycle *entities.WebinarCycle) error {
+	if !cycle.PesquisaInicio.Before(cycle.PesquisaFim) {
+		return fmt.Errorf("pesquisa_inicio deve ser anterior a pesquisa_fim")
+	}
+	if cycle.VendaInicio.Before(cycle.PesquisaFim) {
+		return fmt.Errorf("venda_inicio não pode ser anterior a pesquisa_fim")
+	}
+	if !cycle.VendaInicio.Before(cycle.VendaFim) {
+		return fmt.Errorf("venda_inicio deve ser anterior a venda_fim")
+	}
+	return nil
+}
+
+func defaultCycleName(dates utils.WebinarCycleDates) string {
+	return fmt.Sprintf("Ciclo %s", dates.VendaInicio.Format("02/01/2006"))
+}
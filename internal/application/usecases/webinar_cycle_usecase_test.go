@@ -0,0 +1,41 @@
+package usecases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+)
+
+func TestApplyCycleInputVendaInicio(t *testing.T) {
+	loc := utils.GetBrasilLocation()
+	tuesday := time.Date(2025, 4, 22, 0, 0, 0, 0, loc)
+	tests := []struct {
+		name         string
+		vendaInicio  time.Time
+		wantInicio   time.Time
+		wantOverride bool
+	}{
+		{"só a data", tuesday, time.Date(2025, 4, 22, 20, 30, 0, 0, loc), false},
+		{"só a data em UTC", tuesday.UTC(), time.Date(2025, 4, 22, 20, 30, 0, 0, loc), false},
+		{"horário padrão", time.Date(2025, 4, 22, 20, 30, 0, 0, loc), time.Date(2025, 4, 22, 20, 30, 0, 0, loc), false},
+		{"horário explícito", time.Date(2025, 4, 22, 21, 0, 0, 0, loc), time.Date(2025, 4, 22, 21, 0, 0, 0, loc), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cycle := &entities.WebinarCycle{}
+			vendaInicio := tt.vendaInicio
+			applyCycleInput(cycle, WebinarCycleInput{VendaInicio: &vendaInicio})
+			if !cycle.VendaInicio.Equal(tt.wantInicio) {
+				t.Errorf("VendaInicio = %v; esperado %v", cycle.VendaInicio, tt.wantInicio)
+			}
+			if cycle.IsOverride != tt.wantOverride {
+				t.Errorf("IsOverride = %v; esperado %v", cycle.IsOverride, tt.wantOverride)
+			}
+			if err := validateCycleBoundaries(cycle); err != nil {
+				t.Errorf("validateCycleBoundaries: %v", err)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/md5"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -61,6 +62,16 @@ type DashboardPeriodData struct {
 	Conversions   float64 `json:"conversions"`
 }
 
+// DashboardAlignedPeriod alinha o i-ésimo bucket do período atual ao i-ésimo bucket do período de comparação.
+// Current ou Previous é nil quando um dos períodos tem menos buckets.
+type DashboardAlignedPeriod struct {
+	Index          int                  `json:"index"`
+	Current        *DashboardPeriodData `json:"current"`
+	Previous       *DashboardPeriodData `json:"previous"`
+	SessionsChange float64              `json:"sessions_change"`
+	LeadsChange    float64              `json:"leads_change"`
+}
+
 // DashboardPreviousPeriod contém metadados sobre o período anterior
 type DashboardPreviousPeriod struct {
 	From  string `json:"from"`
@@ -88,6 +99,102 @@ func (d *DashboardUnified) CalculateETag() string {
 	return d.ETag
 }
 
+// Granularidades aceitas em time_frame
+const (
+	TimeFrameDaily   = "Daily"
+	TimeFrameWeekly  = "Weekly"
+	TimeFrameMonthly = "Monthly"
+	TimeFrameYearly  = "Yearly"
+)
+
+// NormalizeTimeFrame converte o time_frame da requisição (daily, weekly, monthly, yearly, em qualquer caixa)
+// para as constantes TimeFrame*. Vazio e hourly usam buckets diários; valores desconhecidos retornam false.
+func NormalizeTimeFrame(timeFrame string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(timeFrame)) {
+	case "", "daily", "hourly":
+		return TimeFrameDaily, true
+	case "weekly":
+		return TimeFrameWeekly, true
+	case "monthly":
+		return TimeFrameMonthly, true
+	case "yearly":
+		return TimeFrameYearly, true
+	default:
+		return "", false
+	}
+}
+
+// PeriodStart retorna o início do bucket que contém a data: o dia, a semana ISO (segunda-feira),
+// o mês ou o ano, no fuso da própria data
+func PeriodStart(date time.Time, timeFrame string) time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	switch timeFrame {
+	case TimeFrameWeekly:
+		// Semana ISO: segunda-feira é o primeiro dia
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case TimeFrameMonthly:
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	case TimeFrameYearly:
+		return time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, date.Location())
+	default:
+		return day
+	}
+}
+
+// AddPeriods avança n buckets a partir do início de um bucket
+func AddPeriods(start time.Time, timeFrame string, n int) time.Time {
+	switch timeFrame {
+	case TimeFrameWeekly:
+		return start.AddDate(0, 0, 7*n)
+	case TimeFrameMonthly:
+		return start.AddDate(0, n, 0)
+	case TimeFrameYearly:
+		return start.AddDate(n, 0, 0)
+	default:
+		return start.AddDate(0, 0, n)
+	}
+}
+
+// CountPeriods retorna quantos buckets o intervalo [from, to] alcança
+func CountPeriods(from, to time.Time, timeFrame string) int {
+	start := PeriodStart(from, timeFrame)
+	end := PeriodStart(to, timeFrame)
+	switch timeFrame {
+	case TimeFrameMonthly:
+		return (end.Year()-start.Year())*12 + int(end.Month()-start.Month()) + 1
+	case TimeFrameYearly:
+		return end.Year() - start.Year() + 1
+	case TimeFrameWeekly:
+		return daysBetween(start, end)/7 + 1
+	default:
+		return daysBetween(start, end) + 1
+	}
+}
+
+// PeriodKey retorna a chave do bucket que contém a data: 2025-03-14 (dia), 2025-W11 (semana ISO),
+// 2025-03 (mês) ou 2025 (ano)
+func PeriodKey(date time.Time, timeFrame string) string {
+	switch timeFrame {
+	case TimeFrameWeekly:
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case TimeFrameMonthly:
+		return date.Format("2006-01")
+	case TimeFrameYearly:
+		return date.Format("2006")
+	default:
+		return date.Format("2006-01-02")
+	}
+}
+
+// daysBetween conta os dias de calendário entre duas datas à meia-noite (imune ao horário de verão)
+func daysBetween(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
 // GetPeriodLabel retorna a descrição textual do período comparativo
 func GetPeriodLabel(timeFrame string) string {
 	switch timeFrame {
@@ -110,7 +217,8 @@ func FormatDisplayPeriod(date time.Time, timeFrame string) string {
 	case "Daily":
 		return date.Format("02/01")
 	case "Weekly":
-		return fmt.Sprintf("Sem %d", date.Day()/7+1)
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("Sem %02d/%d", week, year)
 	case "Monthly":
 		return date.Format("01/2006")
 	case "Yearly":
@@ -0,0 +1,39 @@
+package entities
+
+import "time"
+
+// Experiment representa um teste A/B de landing pages de um funil em um período.
+// As sessões são atribuídas às variantes pelo landingPagePath.
+type Experiment struct {
+	ExperimentID int64     `json:"experiment_id" gorm:"primaryKey;autoIncrement;column:experiment_id"`
+	Name         string    `json:"name" gorm:"column:name"`
+	FunnelID     int       `json:"funnel_id" gorm:"column:funnel_id"`
+	StartDate    time.Time `json:"start_date" gorm:"column:start_date"`
+	EndDate      time.Time `json:"end_date" gorm:"column:end_date"`
+	// ConversionWindowDays é quantos dias após o fim do experimento leads e compras ainda são atribuídos
+	ConversionWindowDays int       `json:"conversion_window_days" gorm:"column:conversion_window_days"`
+	Notes                string    `json:"notes,omitempty" gorm:"column:notes"`
+	CreatedAt            time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt            time.Time `json:"updated_at" gorm:"column:updated_at"`
+
+	// Relações
+	Variants []ExperimentVariant `json:"variants" gorm:"foreignKey:ExperimentID"`
+}
+
+func (Experiment) TableName() string {
+	return "experiments"
+}
+
+// ExperimentVariant representa uma landing page testada no experimento
+type ExperimentVariant struct {
+	VariantID    int64  `json:"variant_id" gorm:"primaryKey;autoIncrement;column:variant_id"`
+	ExperimentID int64  `json:"-" gorm:"column:experiment_id"`
+	Name         string `json:"name" gorm:"column:name"`
+	// LandingPagePath é gravado normalizado (minúsculo e sem barras finais, exceto na raiz)
+	LandingPagePath string `json:"landing_page_path" gorm:"column:landing_page_path"`
+	IsControl       bool   `json:"is_control" gorm:"column:is_control"`
+}
+
+func (ExperimentVariant) TableName() string {
+	return "experiment_variants"
+}
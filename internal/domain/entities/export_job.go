@@ -0,0 +1,51 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExportJob representa uma exportação assíncrona: o pedido (dataset, filtros e formato), o andamento
+// do worker que gera o arquivo e o arquivo gerado, disponível para download até ExpiresAt
+type ExportJob struct {
+	JobID   uuid.UUID `json:"job_id" gorm:"primaryKey;type:uuid;column:job_id"`
+	Dataset string    `json:"dataset" gorm:"column:dataset"`
+	Format  string    `json:"format" gorm:"column:format"`
+	// Query são os filtros do job em JSON, no formato de usecases.ExportJobFilters (ex.: {"from":"2024-01-01","profession_id":3})
+	Query   string `json:"query" gorm:"column:query"`
+	Columns string `json:"columns,omitempty" gorm:"column:columns"`
+	MaskPII bool   `json:"mask_pii" gorm:"column:mask_pii"`
+
+	Status   string `json:"status" gorm:"column:status"`
+	Attempts int    `json:"attempts" gorm:"column:attempts"`
+	Rows     int64  `json:"rows" gorm:"column:rows"`
+	Bytes    int64  `json:"bytes" gorm:"column:bytes"`
+	Error    string `json:"error,omitempty" gorm:"column:error"`
+
+	FilePath      string `json:"-" gorm:"column:file_path"`
+	FileName      string `json:"file_name,omitempty" gorm:"column:file_name"`
+	DownloadToken string `json:"-" gorm:"column:download_token"`
+
+	// LockedUntil é o prazo do worker que executa o job; vencido, o job volta a ser executado (ex.: após um restart)
+	LockedUntil *time.Time `json:"-" gorm:"column:locked_until"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"column:created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty" gorm:"column:started_at"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"column:updated_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty" gorm:"column:finished_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" gorm:"column:expires_at"`
+}
+
+func (ExportJob) TableName() string {
+	return "export_jobs"
+}
+
+// Situações de um job de exportação
+const (
+	ExportJobQueued  = "queued"
+	ExportJobRunning = "running"
+	ExportJobDone    = "done"
+	ExportJobFailed  = "failed"
+	// ExportJobExpired indica que o arquivo passou do prazo de retenção e foi removido
+	ExportJobExpired = "expired"
+)
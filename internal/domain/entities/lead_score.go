@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// LeadScoringRuleSet representa uma versão das regras de pontuação de leads.
+// As versões são imutáveis: alterar as regras cria uma nova versão, e apenas uma fica ativa.
+type LeadScoringRuleSet struct {
+	Version   int64           `json:"version" gorm:"primaryKey;autoIncrement;column:version"`
+	Name      string          `json:"name" gorm:"column:name"`
+	IsActive  bool            `json:"is_active" gorm:"column:is_active"`
+	Rules     json.RawMessage `json:"rules" gorm:"column:rules;type:jsonb"`
+	CreatedAt time.Time       `json:"created_at" gorm:"column:created_at"`
+}
+
+func (LeadScoringRuleSet) TableName() string {
+	return "lead_scoring_rules"
+}
+
+// LeadScore representa a pontuação calculada de um lead para uma versão das regras
+type LeadScore struct {
+	UserID      string          `json:"user_id" gorm:"primaryKey;column:user_id"`
+	RuleVersion int64           `json:"rule_version" gorm:"primaryKey;column:rule_version"`
+	Score       float64         `json:"score" gorm:"column:score"`
+	Tier        string          `json:"tier" gorm:"column:tier"`
+	Breakdown   json.RawMessage `json:"breakdown" gorm:"column:breakdown;type:jsonb"`
+	ComputedAt  time.Time       `json:"computed_at" gorm:"column:computed_at"`
+}
+
+func (LeadScore) TableName() string {
+	return "lead_scores"
+}
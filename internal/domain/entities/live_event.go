@@ -0,0 +1,37 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LiveEventTypes são os tipos de evento enviados pelo feed ao vivo
+var LiveEventTypes = []string{"LEAD", "PESQUISA_LEAD", "PURCHASE"}
+
+// LiveEvent é um evento de lead, pesquisa ou compra enviado pelo feed ao vivo
+type LiveEvent struct {
+	EventID        uuid.UUID `json:"event_id" gorm:"column:event_id"`
+	EventType      string    `json:"event_type" gorm:"column:event_type"`
+	EventTime      time.Time `json:"event_time" gorm:"column:event_time"`
+	UserID         string    `json:"user_id" gorm:"column:user_id"`
+	ProfessionID   int       `json:"profession_id" gorm:"column:profession_id"`
+	ProfessionName string    `json:"profession_name" gorm:"column:profession_name"`
+	FunnelID       int       `json:"funnel_id" gorm:"column:funnel_id"`
+	FunnelName     string    `json:"funnel_name" gorm:"column:funnel_name"`
+	ProductID      int       `json:"product_id" gorm:"column:product_id"`
+	ProductName    string    `json:"product_name" gorm:"column:product_name"`
+	// Value é o valor da compra (apenas PURCHASE com valor numérico)
+	Value *float64 `json:"value,omitempty" gorm:"column:value"`
+}
+
+// LiveCounters são os contadores do feed ao vivo. "Hoje" é o dia corrente em America/Sao_Paulo.
+type LiveCounters struct {
+	LeadsLast5Minutes int64     `json:"leads_last_5_minutes" gorm:"column:leads_last_5_minutes"`
+	LeadsToday        int64     `json:"leads_today" gorm:"column:leads_today"`
+	SurveyLeadsToday  int64     `json:"survey_leads_today" gorm:"column:survey_leads_today"`
+	PurchasesToday    int64     `json:"purchases_today" gorm:"column:purchases_today"`
+	RevenueToday      float64   `json:"revenue_today" gorm:"column:revenue_today"`
+	ActiveSessions    int64     `json:"active_sessions" gorm:"-"`
+	At                time.Time `json:"at" gorm:"-"`
+}
@@ -0,0 +1,16 @@
+package entities
+
+import "time"
+
+// MetricRollupState registra até onde os rollups de métricas foram calculados.
+// Os rollups cobrem o intervalo [CoveredFrom, Watermark).
+type MetricRollupState struct {
+	Name        string    `json:"name" gorm:"primaryKey;column:name"`
+	CoveredFrom time.Time `json:"covered_from" gorm:"column:covered_from"`
+	Watermark   time.Time `json:"watermark" gorm:"column:watermark"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (MetricRollupState) TableName() string {
+	return "metric_rollup_state"
+}
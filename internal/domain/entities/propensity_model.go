@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PropensityModel representa um modelo de propensão de compra (regressão logística)
+// treinado nos ciclos de webinar encerrados de uma profissão/funil.
+type PropensityModel struct {
+	ModelID      int64           `json:"model_id" gorm:"primaryKey;autoIncrement;column:model_id"`
+	ProfessionID int             `json:"profession_id" gorm:"column:profession_id"`
+	FunnelID     *int            `json:"funnel_id,omitempty" gorm:"column:funnel_id"`
+	IsActive     bool            `json:"is_active" gorm:"column:is_active"`
+	CyclesUsed   int             `json:"cycles_used" gorm:"column:cycles_used"`
+	TrainingFrom time.Time       `json:"training_from" gorm:"column:training_from"`
+	TrainingTo   time.Time       `json:"training_to" gorm:"column:training_to"`
+	Samples      int             `json:"samples" gorm:"column:samples"`
+	Positives    int             `json:"positives" gorm:"column:positives"`
+	Coefficients json.RawMessage `json:"coefficients" gorm:"column:coefficients;type:jsonb"`
+	Metrics      json.RawMessage `json:"metrics" gorm:"column:metrics;type:jsonb"`
+	TrainedAt    time.Time       `json:"trained_at" gorm:"column:trained_at"`
+}
+
+func (PropensityModel) TableName() string {
+	return "propensity_models"
+}
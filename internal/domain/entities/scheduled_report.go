@@ -0,0 +1,59 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ScheduledReport representa um relatório de KPIs do dashboard enviado por e-mail conforme uma expressão cron
+// (no fuso America/Sao_Paulo). Cada envio cobre o período relativo Period, calculado no momento do envio.
+type ScheduledReport struct {
+	ReportID   int64           `json:"report_id" gorm:"primaryKey;autoIncrement;column:report_id"`
+	Name       string          `json:"name" gorm:"column:name"`
+	Schedule   string          `json:"schedule" gorm:"column:schedule"`
+	Period     string          `json:"period" gorm:"column:period"`
+	Filters    json.RawMessage `json:"filters" gorm:"column:filters;type:jsonb"`
+	Recipients json.RawMessage `json:"recipients" gorm:"column:recipients;type:jsonb"`
+	Enabled    bool            `json:"enabled" gorm:"column:enabled"`
+	NextRunAt  *time.Time      `json:"next_run_at" gorm:"column:next_run_at"`
+	LastRunAt  *time.Time      `json:"last_run_at" gorm:"column:last_run_at"`
+	LastStatus string          `json:"last_status,omitempty" gorm:"column:last_status"`
+	LastError  string          `json:"last_error,omitempty" gorm:"column:last_error"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt  time.Time       `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (ScheduledReport) TableName() string {
+	return "scheduled_reports"
+}
+
+// Períodos relativos de um relatório, calculados no dia do envio
+const (
+	ReportPeriodToday       = "today"
+	ReportPeriodYesterday   = "yesterday"
+	ReportPeriodLast7Days   = "last_7_days"
+	ReportPeriodLast30Days  = "last_30_days"
+	ReportPeriodMonthToDate = "month_to_date"
+	ReportPeriodLastMonth   = "last_month"
+	// ReportPeriodLastCycle é o ciclo de webinar mais recente já encerrado (resumo pós-webinar)
+	ReportPeriodLastCycle = "last_cycle"
+)
+
+// Situação do último envio de um relatório
+const (
+	ReportStatusSent   = "sent"
+	ReportStatusFailed = "failed"
+	// ReportStatusMissed indica um envio agendado perdido com a API fora do ar por mais que a tolerância
+	ReportStatusMissed = "missed"
+)
+
+// ReportFilters representa os filtros aplicados aos KPIs do relatório. ProfessionID e FunnelID também
+// escolhem o ciclo de webinar do período last_cycle.
+type ReportFilters struct {
+	ProfessionID int    `json:"profession_id,omitempty"`
+	FunnelID     int    `json:"funnel_id,omitempty"`
+	ProductID    int    `json:"product_id,omitempty"`
+	SegmentID    int64  `json:"segment_id,omitempty"`
+	TimeFrame    string `json:"time_frame,omitempty"`
+	Compare      string `json:"compare,omitempty"`
+}
@@ -0,0 +1,69 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Segment representa um segmento salvo de usuários, definido por condições sobre atributos do usuário,
+// atributos das sessões, histórico de eventos e respostas de pesquisa
+type Segment struct {
+	SegmentID   int64           `json:"segment_id" gorm:"primaryKey;autoIncrement;column:segment_id"`
+	Name        string          `json:"name" gorm:"column:name"`
+	Description string          `json:"description,omitempty" gorm:"column:description"`
+	Definition  json.RawMessage `json:"definition" gorm:"column:definition;type:jsonb"`
+	CreatedAt   time.Time       `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt   time.Time       `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (Segment) TableName() string {
+	return "segments"
+}
+
+// Combinação das condições de um segmento
+const (
+	SegmentMatchAll = "all"
+	SegmentMatchAny = "any"
+)
+
+// Fontes das condições de um segmento
+const (
+	// SegmentSourceUser filtra atributos do próprio usuário
+	SegmentSourceUser = "user"
+	// SegmentSourceSession exige ao menos uma sessão do usuário com o atributo
+	SegmentSourceSession = "session"
+	// SegmentSourceEvent exige ao menos um evento do usuário (ex.: LEAD, PURCHASE)
+	SegmentSourceEvent = "event"
+	// SegmentSourceSurvey exige ao menos uma resposta do usuário à pesquisa
+	SegmentSourceSurvey = "survey"
+)
+
+// SegmentDefinition representa as condições de um segmento
+type SegmentDefinition struct {
+	// Match é "all" (todas as condições, padrão) ou "any" (qualquer condição)
+	Match      string             `json:"match"`
+	Conditions []SegmentCondition `json:"conditions"`
+}
+
+// SegmentCondition representa uma condição do segmento. Field/Operator/Value filtram um atributo da fonte;
+// as condições de evento e de pesquisa usam também os campos específicos abaixo.
+type SegmentCondition struct {
+	Source   string `json:"source"`
+	Field    string `json:"field,omitempty"`
+	Operator string `json:"operator,omitempty"`
+	Value    string `json:"value,omitempty"`
+
+	// EventType é o tipo de evento exigido (source=event)
+	EventType string `json:"event_type,omitempty"`
+	// WithinDays restringe eventos e respostas aos últimos N dias (0 não restringe)
+	WithinDays int `json:"within_days,omitempty"`
+
+	// Pesquisa respondida (source=survey): faixa e, opcionalmente, a resposta a uma pergunta
+	SurveyID   int64  `json:"survey_id,omitempty"`
+	Faixa      string `json:"faixa,omitempty"`
+	QuestionID string `json:"question_id,omitempty"`
+	Answer     string `json:"answer,omitempty"`
+
+	// Negate inverte a condição (ex.: usuários sem compra)
+	Negate bool `json:"negate,omitempty"`
+}
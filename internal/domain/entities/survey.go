@@ -25,6 +25,8 @@ type SurveyResponse struct {
 	Completed  bool      `json:"completed" gorm:"column:completed"`
 	CreatedAt  time.Time `json:"created_at" gorm:"column:created_at"`
 	Faixa      string    `json:"faixa" gorm:"column:faixa"`
+	// DefinitionVersion é a versão da definição da pesquisa em que a resposta foi coletada
+	DefinitionVersion *int `json:"definition_version,omitempty" gorm:"column:definition_version"`
 
 	// Relações
 	Survey  Survey         `json:"survey,omitempty" gorm:"foreignKey:SurveyID"`
@@ -0,0 +1,74 @@
+package entities
+
+import "time"
+
+// Tipos de pergunta da definição de pesquisa
+const (
+	SurveyQuestionTypeSingleChoice = "single_choice"
+	SurveyQuestionTypeText         = "text"
+)
+
+// SurveyDefinition representa uma versão da definição de uma pesquisa (perguntas, opções,
+// pontuações e faixas). As versões são imutáveis: alterar a pesquisa cria uma nova versão,
+// e apenas uma fica ativa por pesquisa.
+type SurveyDefinition struct {
+	DefinitionID int64     `json:"definition_id" gorm:"primaryKey;autoIncrement;column:definition_id"`
+	SurveyID     int64     `json:"survey_id" gorm:"column:survey_id;type:int8"`
+	Version      int       `json:"version" gorm:"column:version"`
+	IsActive     bool      `json:"is_active" gorm:"column:is_active"`
+	Notes        string    `json:"notes,omitempty" gorm:"column:notes"`
+	CreatedAt    time.Time `json:"created_at" gorm:"column:created_at"`
+
+	// Relações
+	Questions []SurveyDefinitionQuestion `json:"questions" gorm:"foreignKey:DefinitionID"`
+	Faixas    []SurveyFaixa              `json:"faixas" gorm:"foreignKey:DefinitionID"`
+}
+
+func (SurveyDefinition) TableName() string {
+	return "survey_definitions"
+}
+
+// SurveyDefinitionQuestion representa uma pergunta de uma versão da pesquisa
+type SurveyDefinitionQuestion struct {
+	ID           int64  `json:"-" gorm:"primaryKey;autoIncrement;column:id"`
+	DefinitionID int64  `json:"-" gorm:"column:definition_id"`
+	QuestionID   string `json:"question_id" gorm:"column:question_id"`
+	Text         string `json:"question_text" gorm:"column:question_text"`
+	Type         string `json:"type" gorm:"column:type"`
+	Position     int    `json:"position" gorm:"column:position"`
+	Required     bool   `json:"required" gorm:"column:required"`
+
+	// Relações
+	Options []SurveyQuestionOption `json:"options" gorm:"foreignKey:QuestionRowID"`
+}
+
+func (SurveyDefinitionQuestion) TableName() string {
+	return "survey_definition_questions"
+}
+
+// SurveyQuestionOption representa uma opção de resposta e sua pontuação
+type SurveyQuestionOption struct {
+	ID            int64  `json:"-" gorm:"primaryKey;autoIncrement;column:id"`
+	QuestionRowID int64  `json:"-" gorm:"column:question_row_id"`
+	Value         string `json:"value" gorm:"column:value"`
+	Label         string `json:"label,omitempty" gorm:"column:label"`
+	Score         int    `json:"score" gorm:"column:score"`
+	Position      int    `json:"position" gorm:"column:position"`
+}
+
+func (SurveyQuestionOption) TableName() string {
+	return "survey_question_options"
+}
+
+// SurveyFaixa representa uma faixa de pontuação da pesquisa. A resposta recebe a faixa com o
+// maior min_score que não ultrapassa sua pontuação total.
+type SurveyFaixa struct {
+	ID           int64  `json:"-" gorm:"primaryKey;autoIncrement;column:id"`
+	DefinitionID int64  `json:"-" gorm:"column:definition_id"`
+	Name         string `json:"name" gorm:"column:name"`
+	MinScore     int    `json:"min_score" gorm:"column:min_score"`
+}
+
+func (SurveyFaixa) TableName() string {
+	return "survey_faixas"
+}
@@ -0,0 +1,20 @@
+package entities
+
+import "time"
+
+// SurveyVariant representa uma variante de pesquisa (ordem ou redação diferente das perguntas)
+// sorteada entre os leads de um funil de acordo com o peso de tráfego
+type SurveyVariant struct {
+	VariantID int64     `json:"variant_id" gorm:"primaryKey;autoIncrement;column:variant_id"`
+	FunnelID  int       `json:"funnel_id" gorm:"column:funnel_id"`
+	SurveyID  int64     `json:"survey_id" gorm:"column:survey_id;type:int8"`
+	Name      string    `json:"name" gorm:"column:name"`
+	Weight    int       `json:"weight" gorm:"column:weight"`
+	IsControl bool      `json:"is_control" gorm:"column:is_control"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (SurveyVariant) TableName() string {
+	return "survey_variants"
+}
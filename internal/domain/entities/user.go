@@ -41,4 +41,8 @@ type User struct {
 	InitialReferrerQuery      string    `json:"initialReferrerQuery" gorm:"column:initialReferrerQuery;type:text"`
 	InitialReferrerHostname   string    `json:"initialReferrerHostname" gorm:"column:initialReferrerHostname;type:text"`
 	InitialReferrerPath       string    `json:"initialReferrerPath" gorm:"column:initialReferrerPath;type:text"`
+
+	// Pontuação do lead na versão ativa das regras (somente leitura, preenchida na listagem de leads)
+	LeadScore *float64 `json:"lead_score,omitempty" gorm:"column:lead_score;->"`
+	LeadTier  *string  `json:"lead_tier,omitempty" gorm:"column:lead_tier;->"`
 }
@@ -0,0 +1,33 @@
+package entities
+
+import "time"
+
+// WarehouseExportState registra até onde um dataset foi exportado para o data warehouse.
+// As exportações incrementais cobrem as linhas a partir de Watermark.
+type WarehouseExportState struct {
+	Dataset   string    `json:"dataset" gorm:"primaryKey;column:dataset"`
+	Watermark time.Time `json:"watermark" gorm:"column:watermark"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (WarehouseExportState) TableName() string {
+	return "warehouse_export_state"
+}
+
+// WarehouseExportFile é um arquivo Parquet gerado para o data warehouse: as linhas de um dataset em
+// [RangeFrom, RangeTo), todas da mesma partição diária (dia em America/Sao_Paulo)
+type WarehouseExportFile struct {
+	FileID    int64     `json:"file_id" gorm:"primaryKey;autoIncrement;column:file_id"`
+	Dataset   string    `json:"dataset" gorm:"column:dataset"`
+	Partition string    `json:"partition" gorm:"column:partition_date"`
+	Path      string    `json:"path" gorm:"column:path"`
+	Rows      int64     `json:"rows" gorm:"column:rows"`
+	Bytes     int64     `json:"bytes" gorm:"column:bytes"`
+	RangeFrom time.Time `json:"range_from" gorm:"column:range_from"`
+	RangeTo   time.Time `json:"range_to" gorm:"column:range_to"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (WarehouseExportFile) TableName() string {
+	return "warehouse_export_files"
+}
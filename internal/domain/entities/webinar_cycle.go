@@ -0,0 +1,25 @@
+package entities
+
+import "time"
+
+// WebinarCycle representa um ciclo de webinar (captação + vendas) de uma profissão/funil.
+// Os ciclos são gerados a partir do cronograma fixo de terça-feira e podem ter seus limites
+// sobrescritos manualmente (feriados, mudanças de agenda).
+type WebinarCycle struct {
+	CycleID        int64     `json:"cycle_id" gorm:"primaryKey;autoIncrement;column:cycle_id"`
+	ProfessionID   int       `json:"profession_id" gorm:"column:profession_id"`
+	FunnelID       *int      `json:"funnel_id,omitempty" gorm:"column:funnel_id"`
+	Name           string    `json:"name" gorm:"column:name"`
+	PesquisaInicio time.Time `json:"pesquisa_inicio" gorm:"column:pesquisa_inicio"`
+	PesquisaFim    time.Time `json:"pesquisa_fim" gorm:"column:pesquisa_fim"`
+	VendaInicio    time.Time `json:"venda_inicio" gorm:"column:venda_inicio"`
+	VendaFim       time.Time `json:"venda_fim" gorm:"column:venda_fim"`
+	IsOverride     bool      `json:"is_override" gorm:"column:is_override"`
+	Notes          string    `json:"notes,omitempty" gorm:"column:notes"`
+	CreatedAt      time.Time `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt      time.Time `json:"updated_at" gorm:"column:updated_at"`
+}
+
+func (WebinarCycle) TableName() string {
+	return "webinar_cycles"
+}
@@ -0,0 +1,683 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Limites da árvore de filtros, para evitar consultas patológicas
+const (
+	maxFilterDepth        = 8
+	maxFilterLeafs        = 100
+	maxFilterListValues   = 1000
+	maxFilterRegexLength  = 500
+	maxFilterRegexRepeat  = 255
+	maxFilterRelativeDays = 3650
+)
+
+// ErrInvalidFilterRegex indica uma regex de filtro fora do subconjunto aceito ou rejeitada pelo Postgres
+var ErrInvalidFilterRegex = errors.New("regex inválida")
+
+// Tipos de coluna usados para validar os operadores dos filtros
+const (
+	filterTypeText    = "text"
+	filterTypeNumber  = "number"
+	filterTypeDate    = "date"
+	filterTypeBoolean = "boolean"
+)
+
+// filterColumnTypes lista as colunas não textuais que os filtros podem referenciar; as demais são texto
+var filterColumnTypes = map[string]string{
+	"e.event_time":              filterTypeDate,
+	"e.profession_id":           filterTypeNumber,
+	"e.product_id":              filterTypeNumber,
+	"e.funnel_id":               filterTypeNumber,
+	`s."sessionStart"`:          filterTypeDate,
+	`s."lastActivity"`:          filterTypeDate,
+	`s."sessionEnd"`:            filterTypeDate,
+	"s.duration":                filterTypeNumber,
+	`s."isActive"`:              filterTypeBoolean,
+	"u.created_at":              filterTypeDate,
+	`u."isClient"`:              filterTypeBoolean,
+	`u."isIdentified"`:          filterTypeBoolean,
+	"professions.profession_id": filterTypeNumber,
+	"products.product_id":       filterTypeNumber,
+	"funnels.funnel_id":         filterTypeNumber,
+}
+
+var (
+	filterAllTypes        = []string{filterTypeText, filterTypeNumber, filterTypeDate, filterTypeBoolean}
+	filterTextTypes       = []string{filterTypeText}
+	filterDateTypes       = []string{filterTypeDate}
+	filterOrderedTypes    = []string{filterTypeNumber, filterTypeDate}
+	filterEnumerableTypes = []string{filterTypeText, filterTypeNumber}
+)
+
+// filterOperatorTypes lista os tipos de coluna aceitos por cada operador
+var filterOperatorTypes = map[string][]string{
+	"equals":           filterAllTypes,
+	"not_equals":       filterAllTypes,
+	"contains":         filterTextTypes,
+	"not_contains":     filterTextTypes,
+	"starts_with":      filterTextTypes,
+	"ends_with":        filterTextTypes,
+	"regex":            filterTextTypes,
+	"not_regex":        filterTextTypes,
+	"in":               filterEnumerableTypes,
+	"not_in":           filterEnumerableTypes,
+	"gt":               filterOrderedTypes,
+	"gte":              filterOrderedTypes,
+	"lt":               filterOrderedTypes,
+	"lte":              filterOrderedTypes,
+	"between":          filterOrderedTypes,
+	"is_empty":         filterAllTypes,
+	"is_not_empty":     filterAllTypes,
+	"in_last_days":     filterDateTypes,
+	"not_in_last_days": filterDateTypes,
+	"in_last_hours":    filterDateTypes,
+}
+
+// filterComparisonOperators mapeia os operadores de comparação para SQL
+var filterComparisonOperators = map[string]string{
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+}
+
+// Combinações aceitas nos grupos da árvore de filtros
+const (
+	FilterConditionAnd = "AND"
+	FilterConditionOr  = "OR"
+	FilterConditionNot = "NOT"
+)
+
+// EventPropertiesPrefix é o prefixo das propriedades que leem o JSON event_propeties (ex.: event.properties.product_type)
+const EventPropertiesPrefix = "event.properties."
+
+// maxEventPropertyDepth limita o número de chaves de um caminho de propriedade
+const maxEventPropertyDepth = 5
+
+// eventPropertyKeyPattern restringe as chaves de caminhos de propriedades do evento
+var eventPropertyKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// filterColumnPattern aceita apenas colunas qualificadas por alias (ex.: e.event_type, s."utmSource")
+var filterColumnPattern = regexp.MustCompile(`^[a-z_]+\.("[A-Za-z_][A-Za-z0-9_]*"|[a-z_][a-z0-9_]*)$`)
+
+// eventFilterJoins são os JOINs de cada alias que os filtros podem referenciar a partir de events e
+var eventFilterJoins = []struct {
+	alias string
+	join  string
+}{
+	{"u", "LEFT JOIN users u ON e.user_id = u.user_id"},
+	{"s", "LEFT JOIN sessions s ON e.session_id = s.session_id"},
+	{"professions", "LEFT JOIN professions ON e.profession_id = professions.profession_id"},
+	{"products", "LEFT JOIN products ON e.product_id = products.product_id"},
+	{"funnels", "LEFT JOIN funnels ON e.funnel_id = funnels.funnel_id"},
+}
+
+// utmFilterColumns mapeia os campos UTM sem prefixo para as colunas do usuário (first touch) e da sessão
+var utmFilterColumns = map[string][2]string{
+	"utm_source":   {`u."initialUtmSource"`, `s."utmSource"`},
+	"utm_medium":   {`u."initialUtmMedium"`, `s."utmMedium"`},
+	"utm_campaign": {`u."initialUtmCampaign"`, `s."utmCampaign"`},
+	"utm_content":  {`u."initialUtmContent"`, `s."utmContent"`},
+	"utm_term":     {`u."initialUtmTerm"`, `s."utmTerm"`},
+}
+
+// FilterNode representa um nó da árvore de filtros de eventos. Um nó com Property é uma condição simples;
+// os demais são grupos que combinam Filters com AND, OR ou NOT (NOT nega a conjunção dos filhos).
+//
+// Exemplo: (utm_source = fb OR utm_source = ig) AND user.country = BR
+//
+//	{"condition": "AND", "filters": [
+//	  {"condition": "OR", "filters": [
+//	    {"property": "utm_source", "operator": "equals", "value": "fb"},
+//	    {"property": "utm_source", "operator": "equals", "value": "ig"}]},
+//	  {"property": "user.country", "operator": "equals", "value": "BR"}]}
+type FilterNode struct {
+	Condition string       `json:"condition,omitempty"`
+	Filters   []FilterNode `json:"filters,omitempty"`
+	AdvancedFilter
+}
+
+// NewFlatFilterTree converte a lista plana de filtros avançados, unida por filter_condition, em uma árvore
+func NewFlatFilterTree(filters []AdvancedFilter, filterCondition string) *FilterNode {
+	if len(filters) == 0 {
+		return nil
+	}
+	condition := FilterConditionAnd
+	if strings.EqualFold(filterCondition, FilterConditionOr) {
+		condition = FilterConditionOr
+	}
+	node := &FilterNode{Condition: condition}
+	for _, filter := range filters {
+		node.Filters = append(node.Filters, FilterNode{AdvancedFilter: filter})
+	}
+	return node
+}
+
+// IsLeaf indica se o nó é uma condição simples
+func (n FilterNode) IsLeaf() bool {
+	return n.Property != ""
+}
+
+// Validate verifica a árvore sem executar consultas
+func (n *FilterNode) Validate() error {
+	_, err := compileEventFilter(n)
+	return err
+}
+
+// compiledEventFilter é a árvore de filtros compilada em uma condição SQL parametrizada
+type compiledEventFilter struct {
+	sql     string
+	args    []interface{}
+	aliases map[string]bool
+}
+
+// isEmpty indica se a árvore não gerou nenhuma condição
+func (f compiledEventFilter) isEmpty() bool {
+	return f.sql == ""
+}
+
+// apply adiciona à consulta (events com alias e) os JOINs ainda ausentes e a condição compilada
+func (f compiledEventFilter) apply(query *gorm.DB, joined map[string]bool) *gorm.DB {
+	if f.isEmpty() {
+		return query
+	}
+	for _, j := range eventFilterJoins {
+		if f.aliases[j.alias] && !joined[j.alias] {
+			query = query.Joins(j.join)
+		}
+	}
+	return query.Where(f.sql, f.args...)
+}
+
+// compileEventFilter compila a árvore de filtros; uma árvore nula ou vazia não gera condição
+func compileEventFilter(root *FilterNode) (compiledEventFilter, error) {
+	compiled := compiledEventFilter{aliases: make(map[string]bool)}
+	if root == nil {
+		return compiled, nil
+	}
+	leafs := 0
+	sql, args, err := compileFilterNode(*root, 1, &leafs, compiled.aliases)
+	if err != nil {
+		return compiledEventFilter{}, err
+	}
+	compiled.sql = sql
+	compiled.args = args
+	return compiled, nil
+}
+
+// compileFilterNode compila um nó e seus filhos recursivamente
+func compileFilterNode(node FilterNode, depth int, leafs *int, aliases map[string]bool) (string, []interface{}, error) {
+	if depth > maxFilterDepth {
+		return "", nil, fmt.Errorf("árvore de filtros excede %d níveis", maxFilterDepth)
+	}
+
+	if node.IsLeaf() {
+		if len(node.Filters) > 0 {
+			return "", nil, fmt.Errorf("filtro %q não pode ter property e filters ao mesmo tempo", node.Property)
+		}
+		*leafs++
+		if *leafs > maxFilterLeafs {
+			return "", nil, fmt.Errorf("árvore de filtros excede %d condições", maxFilterLeafs)
+		}
+		return compileFilterLeaf(node.AdvancedFilter, aliases)
+	}
+
+	condition := strings.ToUpper(strings.TrimSpace(node.Condition))
+	if condition == "" {
+		condition = FilterConditionAnd
+	}
+	if condition != FilterConditionAnd && condition != FilterConditionOr && condition != FilterConditionNot {
+		return "", nil, fmt.Errorf("condição de grupo inválida: %s (use AND, OR ou NOT)", node.Condition)
+	}
+
+	var parts []string
+	var args []interface{}
+	for _, child := range node.Filters {
+		sql, childArgs, err := compileFilterNode(child, depth+1, leafs, aliases)
+		if err != nil {
+			return "", nil, err
+		}
+		if sql == "" {
+			continue
+		}
+		parts = append(parts, sql)
+		args = append(args, childArgs...)
+	}
+	if len(parts) == 0 {
+		return "", nil, nil
+	}
+
+	switch condition {
+	case FilterConditionOr:
+		return "(" + strings.Join(parts, " OR ") + ")", args, nil
+	case FilterConditionNot:
+		return "NOT (" + strings.Join(parts, " AND ") + ")", args, nil
+	default:
+		return "(" + strings.Join(parts, " AND ") + ")", args, nil
+	}
+}
+
+// compileFilterLeaf compila uma condição simples, mapeando a propriedade para a coluna da consulta de eventos
+func compileFilterLeaf(filter AdvancedFilter, aliases map[string]bool) (string, []interface{}, error) {
+	property := strings.TrimSpace(filter.Property)
+
+	// UTM sem prefixo comparado com vazio: considerar preenchido no usuário ou na sessão
+	if columns, ok := utmFilterColumns[property]; ok && filter.Operator == "not_equals" && filter.Value == "" {
+		aliases["u"] = true
+		aliases["s"] = true
+		return fmt.Sprintf("(COALESCE(%s, '') != ? OR COALESCE(%s, '') != ?)", columns[0], columns[1]), []interface{}{"", ""}, nil
+	}
+
+	column, alias, columnType, err := filterColumn(property)
+	if err != nil {
+		return "", nil, err
+	}
+	aliases[alias] = true
+
+	allowed, ok := filterOperatorTypes[filter.Operator]
+	if !ok {
+		return "", nil, fmt.Errorf("operador não suportado no filtro %q: %s", filter.Property, filter.Operator)
+	}
+	if !containsString(allowed, columnType) {
+		return "", nil, fmt.Errorf("operador %s não se aplica a %q (tipo %s)", filter.Operator, filter.Property, columnType)
+	}
+
+	sql, args, err := compileFilterOperator(column, columnType, filter)
+	if err != nil {
+		return "", nil, fmt.Errorf("filtro %q: %w", filter.Property, err)
+	}
+	return sql, args, nil
+}
+
+// compileFilterOperator gera a condição do operador já validado para o tipo da coluna
+func compileFilterOperator(column, columnType string, filter AdvancedFilter) (string, []interface{}, error) {
+	switch filter.Operator {
+	case "equals", "not_equals":
+		sqlOperator := "="
+		if filter.Operator == "not_equals" {
+			sqlOperator = "!="
+			if filter.Value == "" && isUtmColumn(column) {
+				return fmt.Sprintf("COALESCE(%s, '') != ?", column), []interface{}{""}, nil
+			}
+		}
+		if columnType == filterTypeDate {
+			value, dateOnly, err := parseFilterDate(filter.Value)
+			if err != nil {
+				return "", nil, err
+			}
+			if dateOnly {
+				return fmt.Sprintf("(%s AT TIME ZONE 'America/Sao_Paulo')::date %s CAST(? AS date)", column, sqlOperator), []interface{}{value.Format("2006-01-02")}, nil
+			}
+			return fmt.Sprintf("%s %s ?", column, sqlOperator), []interface{}{value}, nil
+		}
+		value, err := parseFilterValue(columnType, filter.Value)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("%s %s ?", column, sqlOperator), []interface{}{value}, nil
+
+	case "contains":
+		return column + " LIKE ?", []interface{}{"%" + escapeLikePattern(filter.Value) + "%"}, nil
+	case "not_contains":
+		return column + " NOT LIKE ?", []interface{}{"%" + escapeLikePattern(filter.Value) + "%"}, nil
+	case "starts_with":
+		return column + " LIKE ?", []interface{}{escapeLikePattern(filter.Value) + "%"}, nil
+	case "ends_with":
+		return column + " LIKE ?", []interface{}{"%" + escapeLikePattern(filter.Value)}, nil
+
+	case "regex", "not_regex":
+		if err := validateFilterRegex(filter.Value); err != nil {
+			return "", nil, err
+		}
+		if filter.Operator == "not_regex" {
+			return column + " !~* ?", []interface{}{filter.Value}, nil
+		}
+		return column + " ~* ?", []interface{}{filter.Value}, nil
+
+	case "in", "not_in":
+		rawValues := filterValues(filter)
+		if len(rawValues) == 0 || len(rawValues) > maxFilterListValues {
+			return "", nil, fmt.Errorf("%s requer entre 1 e %d valores", filter.Operator, maxFilterListValues)
+		}
+		values := make([]interface{}, 0, len(rawValues))
+		for _, raw := range rawValues {
+			value, err := parseFilterValue(columnType, raw)
+			if err != nil {
+				return "", nil, err
+			}
+			values = append(values, value)
+		}
+		if filter.Operator == "not_in" {
+			return column + " NOT IN ?", []interface{}{values}, nil
+		}
+		return column + " IN ?", []interface{}{values}, nil
+
+	case "gt", "gte", "lt", "lte":
+		if columnType == filterTypeDate {
+			value, dateOnly, err := parseFilterDate(filter.Value)
+			if err != nil {
+				return "", nil, err
+			}
+			return dateComparisonSQL(column, filter.Operator, value, dateOnly), []interface{}{value}, nil
+		}
+		value, err := parseFilterValue(columnType, filter.Value)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("%s %s ?", column, filterComparisonOperators[filter.Operator]), []interface{}{value}, nil
+
+	case "between":
+		rawValues := filterValues(filter)
+		if len(rawValues) != 2 {
+			return "", nil, fmt.Errorf("between requer exatamente 2 valores")
+		}
+		if columnType == filterTypeDate {
+			from, _, err := parseFilterDate(rawValues[0])
+			if err != nil {
+				return "", nil, err
+			}
+			to, toDateOnly, err := parseFilterDate(rawValues[1])
+			if err != nil {
+				return "", nil, err
+			}
+			return "(" + column + " >= ? AND " + dateComparisonSQL(column, "lte", to, toDateOnly) + ")", []interface{}{from, to}, nil
+		}
+		from, err := parseFilterValue(columnType, rawValues[0])
+		if err != nil {
+			return "", nil, err
+		}
+		to, err := parseFilterValue(columnType, rawValues[1])
+		if err != nil {
+			return "", nil, err
+		}
+		return column + " BETWEEN ? AND ?", []interface{}{from, to}, nil
+
+	case "is_empty", "is_not_empty":
+		if columnType == filterTypeText {
+			if filter.Operator == "is_empty" {
+				return fmt.Sprintf("COALESCE(%s, '') = ''", column), nil, nil
+			}
+			return fmt.Sprintf("COALESCE(%s, '') != ''", column), nil, nil
+		}
+		if filter.Operator == "is_empty" {
+			return column + " IS NULL", nil, nil
+		}
+		return column + " IS NOT NULL", nil, nil
+
+	case "in_last_days", "not_in_last_days", "in_last_hours":
+		unit, limit := "day", maxFilterRelativeDays
+		if filter.Operator == "in_last_hours" {
+			unit, limit = "hour", maxFilterRelativeDays*24
+		}
+		amount, err := strconv.Atoi(strings.TrimSpace(filter.Value))
+		if err != nil || amount < 1 || amount > limit {
+			return "", nil, fmt.Errorf("%s requer um inteiro entre 1 e %d", filter.Operator, limit)
+		}
+		if filter.Operator == "not_in_last_days" {
+			return fmt.Sprintf("%s < NOW() - (? * INTERVAL '1 %s')", column, unit), []interface{}{amount}, nil
+		}
+		return fmt.Sprintf("%s >= NOW() - (? * INTERVAL '1 %s')", column, unit), []interface{}{amount}, nil
+	}
+
+	return "", nil, fmt.Errorf("operador não suportado: %s", filter.Operator)
+}
+
+// dateComparisonSQL compara uma coluna de data; datas sem horário cobrem o dia inteiro em São Paulo
+// (lte 2024-01-31 inclui todo o dia 31 e gt 2024-01-31 começa em 01/02)
+func dateComparisonSQL(column, operator string, value time.Time, dateOnly bool) string {
+	if dateOnly {
+		switch operator {
+		case "gt":
+			return column + " >= CAST(? AS timestamptz) + INTERVAL '1 day'"
+		case "lte":
+			return column + " < CAST(? AS timestamptz) + INTERVAL '1 day'"
+		}
+	}
+	return fmt.Sprintf("%s %s ?", column, filterComparisonOperators[operator])
+}
+
+// parseFilterValue converte o valor do filtro para o tipo da coluna
+func parseFilterValue(columnType, raw string) (interface{}, error) {
+	raw = strings.TrimSpace(raw)
+	switch columnType {
+	case filterTypeNumber:
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("valor numérico inválido: %q", raw)
+		}
+		return value, nil
+	case filterTypeBoolean:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("valor booleano inválido: %q", raw)
+		}
+		return value, nil
+	case filterTypeDate:
+		value, _, err := parseFilterDate(raw)
+		return value, err
+	default:
+		return raw, nil
+	}
+}
+
+// parseFilterDate aceita YYYY-MM-DD (dia inteiro em São Paulo), "YYYY-MM-DD HH:MM:SS" (São Paulo) ou RFC3339
+func parseFilterDate(raw string) (time.Time, bool, error) {
+	raw = strings.TrimSpace(raw)
+	location := utils.GetBrasilLocation()
+	if value, err := time.ParseInLocation("2006-01-02", raw, location); err == nil {
+		return value, true, nil
+	}
+	if value, err := time.ParseInLocation("2006-01-02 15:04:05", raw, location); err == nil {
+		return value, false, nil
+	}
+	if value, err := time.Parse(time.RFC3339, raw); err == nil {
+		return value, false, nil
+	}
+	return time.Time{}, false, fmt.Errorf("data inválida: %q (use YYYY-MM-DD ou RFC3339)", raw)
+}
+
+// filterValues retorna os valores de operadores de lista: Values ou Value separado por vírgulas
+func filterValues(filter AdvancedFilter) []string {
+	source := filter.Values
+	if len(source) == 0 && filter.Value != "" {
+		source = strings.Split(filter.Value, ",")
+	}
+	values := make([]string, 0, len(source))
+	for _, value := range source {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// filterColumnType retorna o tipo da coluna mapeada (texto quando não listada)
+func filterColumnType(column string) string {
+	if columnType, ok := filterColumnTypes[column]; ok {
+		return columnType
+	}
+	return filterTypeText
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// filterColumn mapeia a propriedade do filtro para uma coluna (ou expressão) segura para interpolação,
+// o alias da tabela usada e o tipo da coluna
+func filterColumn(property string) (string, string, string, error) {
+	if property == "" {
+		return "", "", "", fmt.Errorf("filtro sem property")
+	}
+
+	// Propriedade do JSON do evento, com tipo opcional (ex.: event.properties.value:number)
+	if strings.HasPrefix(property, EventPropertiesPrefix) {
+		path, columnType := property, filterTypeText
+		if idx := strings.LastIndex(property, ":"); idx > 0 {
+			path, columnType = property[:idx], property[idx+1:]
+		}
+		expr, err := EventPropertySQL("e", strings.TrimPrefix(path, EventPropertiesPrefix), columnType)
+		if err != nil {
+			return "", "", "", err
+		}
+		return expr, "e", columnType, nil
+	}
+
+	column := processPropertyName(property)
+
+	// UTMs não existem em events: usar as colunas da sessão
+	if strings.HasPrefix(column, "e.") {
+		for name, columns := range utmFilterColumns {
+			camel := strings.TrimPrefix(columns[1], "s.")
+			if column == "e."+name || column == "e."+strings.Trim(camel, `"`) || column == "e."+camel {
+				column = columns[1]
+				break
+			}
+		}
+	}
+
+	// Corrigir aspas para colunas case-sensitive
+	if !strings.Contains(column, `"`) {
+		parts := strings.SplitN(column, ".", 2)
+		if len(parts) == 2 && needsQuotesForColumn(parts[0], parts[1]) {
+			column = fmt.Sprintf(`%s."%s"`, parts[0], parts[1])
+		}
+	}
+
+	if !filterColumnPattern.MatchString(column) {
+		return "", "", "", fmt.Errorf("property inválida no filtro: %s", property)
+	}
+	return column, column[:strings.Index(column, ".")], filterColumnType(column), nil
+}
+
+// EventPropertySQL retorna a expressão que lê o caminho (chaves separadas por ponto) do JSON event_propeties
+// do evento com o alias informado, convertida para o tipo pedido (text, number, date ou boolean).
+// Valores que não correspondem ao tipo viram NULL em vez de gerar erro de conversão: números precisam
+// casar com numericTextPattern, datas com dateTextPattern (e existir no calendário) e booleanos ser
+// "true" ou "false".
+func EventPropertySQL(alias, path, columnType string) (string, error) {
+	keys := strings.Split(path, ".")
+	if path == "" || len(keys) > maxEventPropertyDepth {
+		return "", fmt.Errorf("caminho de propriedade inválido: %q (use até %d chaves)", path, maxEventPropertyDepth)
+	}
+	for _, key := range keys {
+		if !eventPropertyKeyPattern.MatchString(key) {
+			return "", fmt.Errorf("chave de propriedade inválida: %q", key)
+		}
+	}
+
+	// Chaves validadas acima: seguro para interpolar no literal do caminho
+	text := fmt.Sprintf("(%s.event_propeties::jsonb #>> '{%s}')", alias, strings.Join(keys, ","))
+
+	switch columnType {
+	case filterTypeText:
+		return text, nil
+	case filterTypeNumber:
+		return fmt.Sprintf("(CASE WHEN %s ~ %s THEN %s::numeric END)", text, numericTextPattern, text), nil
+	case filterTypeDate:
+		return dateTextSQL(text), nil
+	case filterTypeBoolean:
+		return fmt.Sprintf("(CASE WHEN lower(%s) IN ('true', 'false') THEN %s::boolean END)", text, text), nil
+	default:
+		return "", fmt.Errorf("tipo de propriedade inválido: %q (use text, number, date ou boolean)", columnType)
+	}
+}
+
+// validateFilterRegex valida a regex dos operadores regex e not_regex. Ela é executada pelo Postgres (~*),
+// então só é aceito o subconjunto com o mesmo significado no RE2 e no Postgres: literais, classes [...],
+// ".", "^", "$", grupos (...) e (?:...), "|", quantificadores *, +, ? e {n,m} (até 255) e os escapes
+// \d, \s, \w, suas negações e de pontuação. Escapes como \b, que no Postgres é backspace, e flags
+// como (?i) são recusados.
+func validateFilterRegex(pattern string) error {
+	if pattern == "" || len(pattern) > maxFilterRegexLength {
+		return fmt.Errorf("%w: a regex deve ter entre 1 e %d caracteres", ErrInvalidFilterRegex, maxFilterRegexLength)
+	}
+
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\':
+			i++
+			if i < len(pattern) && isASCIIAlphanumeric(pattern[i]) && !strings.ContainsRune("dDsSwW", rune(pattern[i])) {
+				return fmt.Errorf("%w: escape \\%c não suportado", ErrInvalidFilterRegex, pattern[i])
+			}
+		case inClass:
+			// "]" logo após "[" ou "[^" é literal; [:alpha:] e afins são classes POSIX aceitas pelos dois
+			if strings.HasPrefix(pattern[i:], "[:") {
+				if end := strings.Index(pattern[i+2:], ":]"); end >= 0 {
+					i += end + 3
+				}
+			} else if c == ']' && pattern[i-1] != '[' && !strings.HasSuffix(pattern[:i], "[^") {
+				inClass = false
+			}
+		case c == '[':
+			inClass = true
+		case c == '(' && strings.HasPrefix(pattern[i:], "(?") && !strings.HasPrefix(pattern[i:], "(?:"):
+			return fmt.Errorf("%w: flags e grupos nomeados não são suportados", ErrInvalidFilterRegex)
+		}
+	}
+
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFilterRegex, err)
+	}
+	return checkFilterRegexRepeats(re)
+}
+
+// checkFilterRegexRepeats recusa contagens {n,m} acima do limite do Postgres
+func checkFilterRegexRepeats(re *syntax.Regexp) error {
+	if re.Op == syntax.OpRepeat && (re.Min > maxFilterRegexRepeat || re.Max > maxFilterRegexRepeat) {
+		return fmt.Errorf("%w: repetições {n,m} aceitam até %d", ErrInvalidFilterRegex, maxFilterRegexRepeat)
+	}
+	for _, sub := range re.Sub {
+		if err := checkFilterRegexRepeats(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isASCIIAlphanumeric(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// filterQueryError converte o erro de regex inválida do Postgres (SQLSTATE 2201B), que escapou da validação,
+// em ErrInvalidFilterRegex
+func filterQueryError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgInvalidRegularExpression {
+		return fmt.Errorf("%w: %s", ErrInvalidFilterRegex, pgErr.Message)
+	}
+	return err
+}
+
+// pgInvalidRegularExpression é o SQLSTATE invalid_regular_expression
+const pgInvalidRegularExpression = "2201B"
+
+// isUtmColumn indica se a coluna mapeada é uma UTM do usuário ou da sessão
+func isUtmColumn(column string) bool {
+	for _, columns := range utmFilterColumns {
+		if column == columns[0] || column == columns[1] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// EventPropertyDiscoveryQuery define a amostra de eventos usada para descobrir as propriedades do JSON
+type EventPropertyDiscoveryQuery struct {
+	// EventType restringe a um tipo de evento (vazio considera todos)
+	EventType string
+	// SampleSize é o número de eventos mais recentes amostrados por tipo de evento
+	SampleSize int
+	// Days restringe a amostra aos últimos N dias
+	Days int
+	// TopValues é o número de valores mais frequentes retornados por propriedade
+	TopValues int
+}
+
+// Tipos de linha retornados pela descoberta de propriedades
+const (
+	EventPropertyRowSample = "sample"
+	EventPropertyRowKey    = "key"
+	EventPropertyRowValue  = "value"
+)
+
+// EventPropertyDiscoveryRow é uma linha da descoberta de propriedades. Kind indica o conteúdo:
+// "sample" traz o total amostrado do tipo de evento; "key" a contagem de um caminho por tipo JSON;
+// "value" um dos valores mais frequentes do caminho.
+type EventPropertyDiscoveryRow struct {
+	Kind           string `gorm:"column:kind"`
+	EventType      string `gorm:"column:event_type"`
+	Path           string `gorm:"column:path"`
+	JSONType       string `gorm:"column:json_type"`
+	Value          string `gorm:"column:value"`
+	Occurrences    int64  `gorm:"column:occurrences"`
+	NumericStrings int64  `gorm:"column:numeric_strings"`
+	DateStrings    int64  `gorm:"column:date_strings"`
+}
+
+// EventPropertyRepository interface para descoberta das propriedades do JSON dos eventos
+type EventPropertyRepository interface {
+	// DiscoverProperties amostra os eventos recentes de cada tipo e agrega os caminhos observados em
+	// event_propeties (objetos aninhados até a profundidade máxima), seus tipos JSON e valores mais frequentes
+	DiscoverProperties(query EventPropertyDiscoveryQuery) ([]EventPropertyDiscoveryRow, error)
+}
+
+type eventPropertyRepository struct {
+	db *gorm.DB
+}
+
+func NewEventPropertyRepository(db *gorm.DB) EventPropertyRepository {
+	return &eventPropertyRepository{db}
+}
+
+func (r *eventPropertyRepository) DiscoverProperties(query EventPropertyDiscoveryQuery) ([]EventPropertyDiscoveryRow, error) {
+	eventTypeFilter := ""
+	if query.EventType != "" {
+		eventTypeFilter = " AND e.event_type = @event_type"
+	}
+
+	// Chaves fora de eventPropertyKeyPattern são ignoradas, pois não poderiam ser usadas em filtros
+	sql := `
+		WITH RECURSIVE sample AS (
+			SELECT event_type, props
+			FROM (
+				SELECT e.event_type, e.event_propeties::jsonb AS props,
+					ROW_NUMBER() OVER (PARTITION BY e.event_type ORDER BY e.event_time DESC) AS rn
+				FROM events e
+				WHERE e.event_time >= NOW() - (@days * INTERVAL '1 day')
+					AND jsonb_typeof(e.event_propeties::jsonb) = 'object'` + eventTypeFilter + `
+			) ranked
+			WHERE rn <= @sample_size
+		),
+		props AS (
+			SELECT s.event_type, kv.key AS path, kv.value, 1 AS depth
+			FROM sample s, jsonb_each(s.props) kv
+			WHERE kv.key ~ '^[A-Za-z0-9_-]+$'
+			UNION ALL
+			SELECT p.event_type, p.path || '.' || kv.key, kv.value, p.depth + 1
+			FROM props p,
+				jsonb_each(CASE WHEN jsonb_typeof(p.value) = 'object' THEN p.value ELSE '{}'::jsonb END) kv
+			WHERE p.depth < @max_depth AND kv.key ~ '^[A-Za-z0-9_-]+$'
+		),
+		leafs AS (
+			SELECT event_type, path, value, jsonb_typeof(value) AS json_type, value #>> '{}' AS text_value
+			FROM props
+			WHERE jsonb_typeof(value) <> 'object'
+		),
+		value_counts AS (
+			SELECT event_type, path, LEFT(text_value, 200) AS value, COUNT(*) AS occurrences
+			FROM leafs
+			WHERE json_type NOT IN ('array', 'null')
+			GROUP BY event_type, path, LEFT(text_value, 200)
+		),
+		ranked_values AS (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY event_type, path ORDER BY occurrences DESC, value) AS rank
+			FROM value_counts
+		)
+		SELECT 'sample' AS kind, event_type, '' AS path, '' AS json_type, '' AS value,
+			COUNT(*) AS occurrences, 0 AS numeric_strings, 0 AS date_strings
+		FROM sample
+		GROUP BY event_type
+		UNION ALL
+		SELECT 'key', event_type, path, json_type, '', COUNT(*),
+			COUNT(*) FILTER (WHERE json_type = 'string' AND text_value ~ ` + numericTextPattern + `),
+			COUNT(*) FILTER (WHERE json_type = 'string' AND text_value ~ ` + dateTextPattern + `)
+		FROM leafs
+		GROUP BY event_type, path, json_type
+		UNION ALL
+		SELECT 'value', event_type, path, '', value, occurrences, 0, 0
+		FROM ranked_values
+		WHERE rank <= @top_values`
+
+	params := map[string]interface{}{
+		"event_type":  query.EventType,
+		"days":        query.Days,
+		"sample_size": query.SampleSize,
+		"top_values":  query.TopValues,
+		"max_depth":   maxEventPropertyDepth,
+	}
+
+	var rows []EventPropertyDiscoveryRow
+	if err := r.db.Raw(sql, params).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("erro ao descobrir propriedades dos eventos: %w", err)
+	}
+	return rows, nil
+}
@@ -13,20 +13,67 @@ import (
 	"gorm.io/gorm"
 )
 
-// AdvancedFilter representa um filtro avançado com propriedade, operador e valor
+// AdvancedFilter representa um filtro avançado com propriedade, operador e valor.
+// Os operadores de lista (in, not_in, between) aceitam Values ou Value separado por vírgulas.
 type AdvancedFilter struct {
-	ID       string `json:"id"`
-	Property string `json:"property"`
-	Operator string `json:"operator"`
-	Value    string `json:"value"`
+	ID       string   `json:"id"`
+	Property string   `json:"property"`
+	Operator string   `json:"operator"`
+	Value    string   `json:"value"`
+	Values   []string `json:"values,omitempty"`
+}
+
+// EventSortColumns mapeia os valores de sortBy aceitos na listagem e na exportação de eventos para as colunas
+var EventSortColumns = map[string]string{
+	// Event fields
+	"event_id":     "e.event_id",
+	"event_name":   "e.event_name",
+	"pageview_id":  "e.pageview_id",
+	"session_id":   "e.session_id",
+	"event_time":   "e.event_time",
+	"event_source": "e.event_source",
+	"event_type":   "e.event_type",
+
+	// User fields
+	"fullname":  "u.fullname",
+	"email":     "u.email",
+	"phone":     "u.phone",
+	"is_client": "u.isClient",
+
+	// UTM fields (agora vindo da tabela users)
+	"utm_source":   "u.\"initialUtmSource\"",
+	"utm_medium":   "u.\"initialUtmMedium\"",
+	"utm_campaign": "u.\"initialUtmCampaign\"",
+	"utm_content":  "u.\"initialUtmContent\"",
+	"utm_term":     "u.\"initialUtmTerm\"",
+
+	// Session fields
+	"country": "u.\"initialCountry\"",
+	"state":   "u.\"initialRegion\"",
+	"city":    "u.\"initialCity\"",
+	"ip":      "u.\"initialIp\"",
+
+	// Profession fields
+	"profession_name": "professions.profession_name",
+	"meta_pixel":      "professions.meta_pixel",
+	"meta_token":      "professions.meta_token",
+
+	// Product fields
+	"product_name": "products.product_name",
+
+	// Funnel fields
+	"funnel_name": "funnels.funnel_name",
+	"funnel_tag":  "funnels.funnel_tag",
+	"global":      "funnels.global",
 }
 
 type EventRepository interface {
-	GetEvents(ctx context.Context, page, limit int, orderBy string, from, to time.Time, timeFrom, timeTo string, professionIDs, funnelIDs []int, advancedFilters []AdvancedFilter, filterCondition string) ([]entities.Event, int64, error)
-	CountEvents(from, to time.Time, timeFrom, timeTo string, eventType string, professionIDs, funnelIDs []int, advancedFilters []AdvancedFilter, filterCondition string) (int64, error)
-	CountEventsByPeriods(periods []string, eventType string, advancedFilters []AdvancedFilter, funnelID int, professionID int) (map[string]int64, error)
+	GetEvents(ctx context.Context, page PageRequest, orderBy string, from, to time.Time, timeFrom, timeTo string, professionIDs, funnelIDs []int, filter *FilterNode, segment SegmentFilter) ([]entities.Event, PageResult, error)
+	ExportEvents(ctx context.Context, columns []ExportColumn, orderBy string, from, to time.Time, timeFrom, timeTo string, professionIDs, funnelIDs []int, filter *FilterNode, segment SegmentFilter, fn ExportRowFunc) error
+	CountEvents(from, to time.Time, timeFrom, timeTo string, eventType string, professionIDs, funnelIDs []int, filter *FilterNode, segment SegmentFilter) (int64, error)
+	CountEventsByPeriods(periods []string, eventType string, filter *FilterNode, funnelID int, professionID int, segment SegmentFilter) (map[string]int64, error)
 	GetEventsDateRange(eventType string) (time.Time, time.Time, error)
-	CountEventsByDateRange(from, to time.Time, timeFrom, timeTo string, eventType string, professionIDs, funnelIDs []int, logicalOperator string) (int64, error)
+	CountEventsByDateRange(from, to time.Time, timeFrom, timeTo string, eventType string, professionIDs, funnelIDs []int, logicalOperator string, filter *FilterNode) (int64, error)
 	GetEventsByHours(date time.Time, eventType, userID, professionID, productID, funnelID string, landingPage string) (map[string]int64, error)
 }
 
@@ -38,9 +85,9 @@ func NewEventRepository(db *gorm.DB) EventRepository {
 	return &eventRepository{db}
 }
 
-func (r *eventRepository) GetEvents(ctx context.Context, page, limit int, orderBy string, from, to time.Time, timeFrom, timeTo string, professionIDs, funnelIDs []int, advancedFilters []AdvancedFilter, filterCondition string) ([]entities.Event, int64, error) {
+func (r *eventRepository) GetEvents(ctx context.Context, page PageRequest, orderBy string, from, to time.Time, timeFrom, timeTo string, professionIDs, funnelIDs []int, filter *FilterNode, segment SegmentFilter) ([]entities.Event, PageResult, error) {
 	var events []entities.Event
-	var total int64
+	result := PageResult{TotalMode: page.Total}
 
 	// Obter localização de Brasília usando a função centralizada
 	brazilLocation := utils.GetBrasilLocation()
@@ -53,56 +100,20 @@ func (r *eventRepository) GetEvents(ctx context.Context, page, limit int, orderB
 		to = to.In(brazilLocation)
 	}
 
-	// Verificar se estamos buscando apenas eventos PURCHASE
-	isPurchaseOnlyQuery := false
-	needsSessionJoin := false
-	needsUserJoin := false
-	needsProfessionJoin := false
-	needsProductJoin := false
-	needsFunnelJoin := false
-	needsSurveyJoin := false
-
-	// Análise de filtros para determinar JOINs necessários
-	for _, filter := range advancedFilters {
-		if (filter.Property == "event_type" || filter.Property == "events.event_type" || filter.Property == "e.event_type") &&
-			filter.Operator == "equals" &&
-			filter.Value == "PURCHASE" {
-			isPurchaseOnlyQuery = true
-		}
-
-		// Verificar se precisamos de JOIN com sessions
-		if strings.Contains(filter.Property, "session.") ||
-			strings.Contains(filter.Property, "utm") ||
-			filter.Property == "utmSource" ||
-			filter.Property == "utmMedium" ||
-			filter.Property == "utmCampaign" ||
-			filter.Property == "utmContent" ||
-			filter.Property == "utmTerm" {
-			needsSessionJoin = true
-		}
-
-		// Verificar se precisamos de JOIN com users
-		if strings.Contains(filter.Property, "user.") ||
-			strings.Contains(filter.Property, "initial") {
-			needsUserJoin = true
-		}
-
-		// Verificar se precisamos de JOIN com professions
-		if strings.Contains(filter.Property, "profession.") {
-			needsProfessionJoin = true
-		}
-
-		// Verificar se precisamos de JOIN com products
-		if strings.Contains(filter.Property, "product.") {
-			needsProductJoin = true
-		}
-
-		// Verificar se precisamos de JOIN com funnels
-		if strings.Contains(filter.Property, "funnel.") {
-			needsFunnelJoin = true
-		}
+	// Compilar a árvore de filtros avançados uma única vez
+	compiledFilter, err := compileEventFilter(filter)
+	if err != nil {
+		return nil, PageResult{}, err
 	}
 
+	// JOINs necessários para as colunas referenciadas pelos filtros
+	needsSessionJoin := compiledFilter.aliases["s"]
+	needsUserJoin := compiledFilter.aliases["u"]
+	needsProfessionJoin := compiledFilter.aliases["professions"]
+	needsProductJoin := compiledFilter.aliases["products"]
+	needsFunnelJoin := compiledFilter.aliases["funnels"]
+	needsSurveyJoin := false
+
 	// Se temos filtros de profissão, precisamos do JOIN
 	if len(professionIDs) > 0 {
 		needsProfessionJoin = true
@@ -113,8 +124,8 @@ func (r *eventRepository) GetEvents(ctx context.Context, page, limit int, orderB
 		needsFunnelJoin = true
 	}
 
-	// Na página 1, sempre incluímos todos os JOINs para dar dados completos
-	if page == 1 {
+	// Na primeira página, sempre incluímos todos os JOINs para dar dados completos
+	if page.IsFirst() {
 		needsUserJoin = true
 		needsSessionJoin = true
 		needsProfessionJoin = true
@@ -123,445 +134,27 @@ func (r *eventRepository) GetEvents(ctx context.Context, page, limit int, orderB
 		needsSurveyJoin = true
 	}
 
-	// Inicializar a consulta base - sempre usar alias 'e' para eventos
-	baseQuery := r.db.Model(&entities.Event{}).Table("events e")
-
-	// JOIN com users (obrigatório para UTMs do usuário)
-	if needsUserJoin {
-		baseQuery = baseQuery.Joins("JOIN users u ON e.user_id = u.user_id")
-	} else {
-		// Se não for necessário o JOIN completo, usar LEFT JOIN apenas para campos básicos
-		baseQuery = baseQuery.Joins("LEFT JOIN users u ON e.user_id = u.user_id")
-	}
-
-	// Aplicar outros JOINs apenas se necessários
-	if needsSessionJoin {
-		baseQuery = baseQuery.Joins("LEFT JOIN sessions s ON e.session_id = s.session_id")
-	}
-
-	if needsProfessionJoin {
-		baseQuery = baseQuery.Joins("LEFT JOIN professions ON e.profession_id = professions.profession_id")
-	}
-
-	if needsProductJoin {
-		baseQuery = baseQuery.Joins("LEFT JOIN products ON e.product_id = products.product_id")
-	}
-
-	if needsFunnelJoin {
-		baseQuery = baseQuery.Joins("LEFT JOIN funnels ON e.funnel_id = funnels.funnel_id")
-	}
-
-	// JOINs para surveys apenas se necessários
-	if needsSurveyJoin {
-		baseQuery = baseQuery.
-			// Join com surveys baseado no funnel_id
-			Joins("LEFT JOIN surveys sv ON sv.funnel_id = funnels.funnel_id").
-			// Join com survey_responses baseado no event_id e survey_id
-			Joins("LEFT JOIN survey_responses sr ON (sr.event_id = e.event_id AND sr.survey_id = sv.survey_id)")
-	}
-
-	// Se for uma consulta específica para PURCHASE, aplicar diretamente
-	if isPurchaseOnlyQuery {
-		fmt.Printf("DEBUG: Aplicando filtro direto para PURCHASE\n")
-		baseQuery = baseQuery.Where("e.event_type = ?", "PURCHASE")
-	}
-
-	// Aplicar filtro de data em UTC diretamente, sem conversão de timezone no SQL
-	if !from.IsZero() && !to.IsZero() {
-		fromTime := from
-		toTime := to
-
-		// Ajustar hora e minuto se timeFrom fornecido
-		if timeFrom != "" {
-			timeParts := strings.Split(timeFrom, ":")
-			if len(timeParts) >= 2 {
-				hour, _ := strconv.Atoi(timeParts[0])
-				min, _ := strconv.Atoi(timeParts[1])
-				fromTime = time.Date(from.Year(), from.Month(), from.Day(), hour, min, 0, 0, from.Location())
-				fmt.Printf("Events: Ajustando horário de início para: %s\n", fromTime.Format("2006-01-02 15:04:05"))
-			}
-		} else {
-			// Se não fornecido, usar o início do dia
-			fromTime = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
-		}
-
-		// Ajustar hora e minuto se timeTo fornecido
-		if timeTo != "" {
-			timeParts := strings.Split(timeTo, ":")
-			if len(timeParts) >= 2 {
-				hour, _ := strconv.Atoi(timeParts[0])
-				min, _ := strconv.Atoi(timeParts[1])
-				toTime = time.Date(to.Year(), to.Month(), to.Day(), hour, min, 59, 999999999, to.Location())
-				fmt.Printf("Events: Ajustando horário de fim para: %s\n", toTime.Format("2006-01-02 15:04:05"))
-			}
-		} else {
-			// Se não fornecido, usar o fim do dia
-			toTime = time.Date(to.Year(), to.Month(), to.Day(), 23, 59, 59, 999999999, to.Location())
-		}
-
-		// Aplicar filtro usando AT TIME ZONE em São Paulo
-		fromStr := fromTime.Format("2006-01-02 15:04:05")
-		toStr := toTime.Format("2006-01-02 15:04:05")
-
-		baseQuery = baseQuery.Where("(e.event_time AT TIME ZONE 'America/Sao_Paulo') BETWEEN ? AND ?",
-			fromStr, toStr)
-
-		fmt.Printf("Events: Filtro de data aplicado com timezone São Paulo: %s até %s\n",
-			fromStr, toStr)
-	}
-
-	// Adicionar filtro de profissão se fornecido
-	if len(professionIDs) > 0 {
-		baseQuery = baseQuery.Where("e.profession_id IN ?", professionIDs)
-	}
-
-	// Adicionar filtro de funil se fornecido
-	if len(funnelIDs) > 0 {
-		baseQuery = baseQuery.Where("e.funnel_id IN ?", funnelIDs)
-	}
-
-	// Aplicar filtros avançados
-	if len(advancedFilters) > 0 {
-		fmt.Printf("DEBUG REPO: Aplicando %d filtros avançados com condição '%s'\n", len(advancedFilters), filterCondition)
-		for i, filter := range advancedFilters {
-			fmt.Printf("DEBUG REPO: Filtro #%d: property=%s, operator=%s, value=%s\n",
-				i+1, filter.Property, filter.Operator, filter.Value)
-		}
-
-		// Definir operador lógico entre filtros (AND/OR)
-		condition := "AND"
-		if filterCondition == "OR" {
-			condition = "OR"
-		}
-
-		// Para filtros OR, precisamos construir uma cláusula completa para não interferir nos outros filtros
-		if condition == "OR" {
-			orConditions := []string{}
-			orValues := []interface{}{}
-
-			for _, filter := range advancedFilters {
-				// Mapear os operadores do frontend para operadores SQL
-				var sqlOperator string
-				var sqlValue interface{}
-				property := processPropertyName(filter.Property)
-
-				fmt.Printf("DEBUG REPO: Property '%s' mapeada para '%s'\n", filter.Property, property)
-
-				// Verificar se estamos tentando filtrar UTMs direto em events
-				if strings.Contains(property, "e.utm") || strings.Contains(property, "utmCampaign") ||
-					strings.Contains(property, "utmSource") || strings.Contains(property, "utmMedium") ||
-					strings.Contains(property, "utmContent") || strings.Contains(property, "utmTerm") {
-
-					// Converter para usar a tabela de sessões
-					if strings.Contains(property, "e.utmSource") {
-						property = "s.\"utmSource\""
-					} else if strings.Contains(property, "e.utmMedium") {
-						property = "s.\"utmMedium\""
-					} else if strings.Contains(property, "e.utmCampaign") {
-						property = "s.\"utmCampaign\""
-					} else if strings.Contains(property, "e.utmContent") {
-						property = "s.\"utmContent\""
-					} else if strings.Contains(property, "e.utmTerm") {
-						property = "s.\"utmTerm\""
-					} else if property == "utmSource" || property == "e.\"utmSource\"" {
-						property = "s.\"utmSource\""
-					} else if property == "utmMedium" || property == "e.\"utmMedium\"" {
-						property = "s.\"utmMedium\""
-					} else if property == "utmCampaign" || property == "e.\"utmCampaign\"" {
-						property = "s.\"utmCampaign\""
-					} else if property == "utmContent" || property == "e.\"utmContent\"" {
-						property = "s.\"utmContent\""
-					} else if property == "utmTerm" || property == "e.\"utmTerm\"" {
-						property = "s.\"utmTerm\""
-					}
-
-					fmt.Printf("DEBUG REPO: Corrigindo UTM para usar sessão: %s -> %s\n", filter.Property, property)
-				}
-
-				// Verificar casos especiais para PURCHASE
-				if (filter.Property == "event_type" || filter.Property == "events.event_type" || filter.Property == "e.event_type") &&
-					filter.Operator == "equals" &&
-					filter.Value == "PURCHASE" {
-					// Este é um filtro para event_type = 'PURCHASE'
-					fmt.Printf("DEBUG REPO: Detectado filtro para event_type = 'PURCHASE'\n")
-				}
-
-				// Corrigir aspas para colunas case-sensitive
-				if strings.Contains(property, ".") && !strings.Contains(property, "\"") {
-					parts := strings.Split(property, ".")
-					if len(parts) == 2 && needsQuotesForColumn(parts[0], parts[1]) {
-						property = fmt.Sprintf("%s.\"%s\"", parts[0], parts[1])
-						fmt.Printf("DEBUG REPO: Adicionando aspas ao campo: %s\n", property)
-					}
-				}
-
-				switch filter.Operator {
-				case "equals":
-					sqlOperator = "="
-					sqlValue = filter.Value
-				case "not_equals":
-					// Tratamento especial para comparação com string vazia em campos UTM
-					if filter.Value == "" && isUtmField(filter.Property) {
-						// Determinar qual tabela usar baseado na propriedade
-						var whereClause string
-						if strings.HasPrefix(filter.Property, "user.") {
-							// Para campos UTM do usuário
-							switch {
-							case strings.Contains(filter.Property, "utm_source"):
-								whereClause = "COALESCE(u.\"initialUtmSource\", '') != ?"
-							case strings.Contains(filter.Property, "utm_medium"):
-								whereClause = "COALESCE(u.\"initialUtmMedium\", '') != ?"
-							case strings.Contains(filter.Property, "utm_campaign"):
-								whereClause = "COALESCE(u.\"initialUtmCampaign\", '') != ?"
-							case strings.Contains(filter.Property, "utm_content"):
-								whereClause = "COALESCE(u.\"initialUtmContent\", '') != ?"
-							case strings.Contains(filter.Property, "utm_term"):
-								whereClause = "COALESCE(u.\"initialUtmTerm\", '') != ?"
-							default:
-								whereClause = fmt.Sprintf("COALESCE(%s, '') != ?", property)
-							}
-						} else if strings.HasPrefix(filter.Property, "session.") {
-							// Para campos UTM da sessão
-							switch {
-							case strings.Contains(filter.Property, "utm_source"):
-								whereClause = "COALESCE(s.\"utmSource\", '') != ?"
-							case strings.Contains(filter.Property, "utm_medium"):
-								whereClause = "COALESCE(s.\"utmMedium\", '') != ?"
-							case strings.Contains(filter.Property, "utm_campaign"):
-								whereClause = "COALESCE(s.\"utmCampaign\", '') != ?"
-							case strings.Contains(filter.Property, "utm_content"):
-								whereClause = "COALESCE(s.\"utmContent\", '') != ?"
-							case strings.Contains(filter.Property, "utm_term"):
-								whereClause = "COALESCE(s.\"utmTerm\", '') != ?"
-							default:
-								whereClause = fmt.Sprintf("COALESCE(%s, '') != ?", property)
-							}
-						} else {
-							// Para UTMs sem prefixo explícito, usamos tanto do usuário quanto da sessão
-							whereClause = "(COALESCE(u.\"initialUtmSource\", '') != ? OR COALESCE(s.\"utmSource\", '') != ?)"
-							baseQuery = baseQuery.Where(whereClause, "", "")
-							continue
-						}
-
-						fmt.Printf("DEBUG REPO: Condição especial para UTM vazio: %s\n", whereClause)
-						baseQuery = baseQuery.Where(whereClause, "")
-						continue
-					}
-					sqlOperator = "!="
-					sqlValue = filter.Value
-				case "contains":
-					sqlOperator = "LIKE"
-					sqlValue = "%" + filter.Value + "%"
-				case "not_contains":
-					sqlOperator = "NOT LIKE"
-					sqlValue = "%" + filter.Value + "%"
-				default:
-					// Operador não suportado, pular este filtro
-					fmt.Printf("DEBUG REPO: Operador não suportado: %s\n", filter.Operator)
-					continue
-				}
-
-				// Adicionar à lista de condições OR
-				clauseStr := fmt.Sprintf("%s %s ?", property, sqlOperator)
-
-				// Tratamento especial para colunas case-sensitive
-				if strings.Contains(clauseStr, "initialUtm") && !strings.Contains(clauseStr, "\"") {
-					// Assegurar que colunas initialUtm* tenham aspas duplas
-					clauseStr = strings.Replace(clauseStr, "u.initialUtmSource", "u.\"initialUtmSource\"", -1)
-					clauseStr = strings.Replace(clauseStr, "u.initialUtmMedium", "u.\"initialUtmMedium\"", -1)
-					clauseStr = strings.Replace(clauseStr, "u.initialUtmCampaign", "u.\"initialUtmCampaign\"", -1)
-					clauseStr = strings.Replace(clauseStr, "u.initialUtmContent", "u.\"initialUtmContent\"", -1)
-					clauseStr = strings.Replace(clauseStr, "u.initialUtmTerm", "u.\"initialUtmTerm\"", -1)
-				}
-
-				// Tratamento especial para colunas UTM da sessão
-				if strings.Contains(clauseStr, "utmSource") && !strings.Contains(clauseStr, "\"") {
-					clauseStr = strings.Replace(clauseStr, "s.utmSource", "s.\"utmSource\"", -1)
-					clauseStr = strings.Replace(clauseStr, "s.utmMedium", "s.\"utmMedium\"", -1)
-					clauseStr = strings.Replace(clauseStr, "s.utmCampaign", "s.\"utmCampaign\"", -1)
-					clauseStr = strings.Replace(clauseStr, "s.utmContent", "s.\"utmContent\"", -1)
-					clauseStr = strings.Replace(clauseStr, "s.utmTerm", "s.\"utmTerm\"", -1)
-				}
-
-				orConditions = append(orConditions, clauseStr)
-				orValues = append(orValues, sqlValue)
-				fmt.Printf("DEBUG REPO: Adicionada condição OR: %s com valor %v\n", clauseStr, sqlValue)
-			}
-
-			// Se temos condições OR, adicioná-las à consulta dentro de parênteses
-			if len(orConditions) > 0 {
-				orClause := "(" + strings.Join(orConditions, " OR ") + ")"
-				fmt.Printf("DEBUG REPO: Aplicando cláusula OR completa: %s\n", orClause)
-				baseQuery = baseQuery.Where(orClause, orValues...)
-			}
-		} else {
-			// Caso AND - podemos aplicar os filtros sequencialmente
-			for _, filter := range advancedFilters {
-				// Mapear os operadores do frontend para operadores SQL
-				var sqlOperator string
-				var sqlValue interface{}
-				property := processPropertyName(filter.Property)
-
-				fmt.Printf("DEBUG REPO: Property '%s' mapeada para '%s'\n", filter.Property, property)
-
-				// Verificar se estamos tentando filtrar UTMs direto em events
-				if strings.Contains(property, "e.utm") || strings.Contains(property, "utmCampaign") ||
-					strings.Contains(property, "utmSource") || strings.Contains(property, "utmMedium") ||
-					strings.Contains(property, "utmContent") || strings.Contains(property, "utmTerm") {
-
-					// Converter para usar a tabela de sessões
-					if strings.Contains(property, "e.utmSource") {
-						property = "s.\"utmSource\""
-					} else if strings.Contains(property, "e.utmMedium") {
-						property = "s.\"utmMedium\""
-					} else if strings.Contains(property, "e.utmCampaign") {
-						property = "s.\"utmCampaign\""
-					} else if strings.Contains(property, "e.utmContent") {
-						property = "s.\"utmContent\""
-					} else if strings.Contains(property, "e.utmTerm") {
-						property = "s.\"utmTerm\""
-					} else if property == "utmSource" || property == "e.\"utmSource\"" {
-						property = "s.\"utmSource\""
-					} else if property == "utmMedium" || property == "e.\"utmMedium\"" {
-						property = "s.\"utmMedium\""
-					} else if property == "utmCampaign" || property == "e.\"utmCampaign\"" {
-						property = "s.\"utmCampaign\""
-					} else if property == "utmContent" || property == "e.\"utmContent\"" {
-						property = "s.\"utmContent\""
-					} else if property == "utmTerm" || property == "e.\"utmTerm\"" {
-						property = "s.\"utmTerm\""
-					}
-
-					fmt.Printf("DEBUG REPO: Corrigindo UTM para usar sessão: %s -> %s\n", filter.Property, property)
-				}
-
-				// Verificar casos especiais para PURCHASE
-				if (filter.Property == "event_type" || filter.Property == "events.event_type" || filter.Property == "e.event_type") &&
-					filter.Operator == "equals" &&
-					filter.Value == "PURCHASE" {
-					// Este é um filtro para event_type = 'PURCHASE'
-					fmt.Printf("DEBUG REPO: Detectado filtro para event_type = 'PURCHASE'\n")
-				}
-
-				// Corrigir aspas para colunas case-sensitive
-				if strings.Contains(property, ".") && !strings.Contains(property, "\"") {
-					parts := strings.Split(property, ".")
-					if len(parts) == 2 && needsQuotesForColumn(parts[0], parts[1]) {
-						property = fmt.Sprintf("%s.\"%s\"", parts[0], parts[1])
-						fmt.Printf("DEBUG REPO: Adicionando aspas ao campo: %s\n", property)
-					}
-				}
-
-				switch filter.Operator {
-				case "equals":
-					sqlOperator = "="
-					sqlValue = filter.Value
-				case "not_equals":
-					// Tratamento especial para comparação com string vazia em campos UTM
-					if filter.Value == "" && isUtmField(filter.Property) {
-						// Determinar qual tabela usar baseado na propriedade
-						var whereClause string
-						if strings.HasPrefix(filter.Property, "user.") {
-							// Para campos UTM do usuário
-							switch {
-							case strings.Contains(filter.Property, "utm_source"):
-								whereClause = "COALESCE(u.\"initialUtmSource\", '') != ?"
-							case strings.Contains(filter.Property, "utm_medium"):
-								whereClause = "COALESCE(u.\"initialUtmMedium\", '') != ?"
-							case strings.Contains(filter.Property, "utm_campaign"):
-								whereClause = "COALESCE(u.\"initialUtmCampaign\", '') != ?"
-							case strings.Contains(filter.Property, "utm_content"):
-								whereClause = "COALESCE(u.\"initialUtmContent\", '') != ?"
-							case strings.Contains(filter.Property, "utm_term"):
-								whereClause = "COALESCE(u.\"initialUtmTerm\", '') != ?"
-							default:
-								whereClause = fmt.Sprintf("COALESCE(%s, '') != ?", property)
-							}
-						} else if strings.HasPrefix(filter.Property, "session.") {
-							// Para campos UTM da sessão
-							switch {
-							case strings.Contains(filter.Property, "utm_source"):
-								whereClause = "COALESCE(s.\"utmSource\", '') != ?"
-							case strings.Contains(filter.Property, "utm_medium"):
-								whereClause = "COALESCE(s.\"utmMedium\", '') != ?"
-							case strings.Contains(filter.Property, "utm_campaign"):
-								whereClause = "COALESCE(s.\"utmCampaign\", '') != ?"
-							case strings.Contains(filter.Property, "utm_content"):
-								whereClause = "COALESCE(s.\"utmContent\", '') != ?"
-							case strings.Contains(filter.Property, "utm_term"):
-								whereClause = "COALESCE(s.\"utmTerm\", '') != ?"
-							default:
-								whereClause = fmt.Sprintf("COALESCE(%s, '') != ?", property)
-							}
-						} else {
-							// Para UTMs sem prefixo explícito, usamos tanto do usuário quanto da sessão
-							whereClause = "(COALESCE(u.\"initialUtmSource\", '') != ? OR COALESCE(s.\"utmSource\", '') != ?)"
-							baseQuery = baseQuery.Where(whereClause, "", "")
-							continue
-						}
-
-						fmt.Printf("DEBUG REPO: Condição especial para UTM vazio: %s\n", whereClause)
-						baseQuery = baseQuery.Where(whereClause, "")
-						continue
-					}
-					sqlOperator = "!="
-					sqlValue = filter.Value
-				case "contains":
-					sqlOperator = "LIKE"
-					sqlValue = "%" + filter.Value + "%"
-				case "not_contains":
-					sqlOperator = "NOT LIKE"
-					sqlValue = "%" + filter.Value + "%"
-				default:
-					// Operador não suportado, pular este filtro
-					fmt.Printf("DEBUG REPO: Operador não suportado: %s\n", filter.Operator)
-					continue
-				}
-
-				// Adicionar o filtro à consulta
-				whereClause := fmt.Sprintf("%s %s ?", property, sqlOperator)
-				fmt.Printf("DEBUG REPO: Aplicando filtro AND: %s com valor %v\n", whereClause, sqlValue)
-
-				// Tratamento especial para colunas case-sensitive
-				if strings.Contains(whereClause, "initialUtm") && !strings.Contains(whereClause, "\"") {
-					// Assegurar que colunas initialUtm* tenham aspas duplas
-					whereClause = strings.Replace(whereClause, "u.initialUtmSource", "u.\"initialUtmSource\"", -1)
-					whereClause = strings.Replace(whereClause, "u.initialUtmMedium", "u.\"initialUtmMedium\"", -1)
-					whereClause = strings.Replace(whereClause, "u.initialUtmCampaign", "u.\"initialUtmCampaign\"", -1)
-					whereClause = strings.Replace(whereClause, "u.initialUtmContent", "u.\"initialUtmContent\"", -1)
-					whereClause = strings.Replace(whereClause, "u.initialUtmTerm", "u.\"initialUtmTerm\"", -1)
-				}
-
-				// Tratamento especial para colunas UTM da sessão
-				if strings.Contains(whereClause, "utmSource") && !strings.Contains(whereClause, "\"") {
-					whereClause = strings.Replace(whereClause, "s.utmSource", "s.\"utmSource\"", -1)
-					whereClause = strings.Replace(whereClause, "s.utmMedium", "s.\"utmMedium\"", -1)
-					whereClause = strings.Replace(whereClause, "s.utmCampaign", "s.\"utmCampaign\"", -1)
-					whereClause = strings.Replace(whereClause, "s.utmContent", "s.\"utmContent\"", -1)
-					whereClause = strings.Replace(whereClause, "s.utmTerm", "s.\"utmTerm\"", -1)
-				}
-
-				baseQuery = baseQuery.Where(whereClause, sqlValue)
-			}
-		}
-	} else {
-		fmt.Printf("DEBUG REPO: Nenhum filtro avançado para aplicar\n")
-	}
+	baseQuery := r.eventListQuery(from, to, timeFrom, timeTo, professionIDs, funnelIDs, compiledFilter, segment, eventListJoins{
+		user:       needsUserJoin,
+		session:    needsSessionJoin,
+		profession: needsProfessionJoin,
+		product:    needsProductJoin,
+		funnel:     needsFunnelJoin,
+		survey:     needsSurveyJoin,
+	})
 
 	// Obter contagem total numa consulta separada para melhorar performance
-	countQuery := baseQuery
-	if err := countQuery.Count(&total).Error; err != nil {
-		return nil, 0, fmt.Errorf("erro ao contar eventos: %w", err)
+	total, err := countTotal(ctx, baseQuery, page.Total)
+	if err != nil {
+		return nil, PageResult{}, fmt.Errorf("erro ao contar eventos: %w", filterQueryError(err))
 	}
+	result.Total = total
 
-	// Calcular offset para paginação
-	offset := (page - 1) * limit
-
-	// Executar a consulta com paginação e ordenação de forma eficiente
-	query := baseQuery.Order(orderBy).Offset(offset).Limit(limit)
+	// Executar a consulta com paginação (offset ou keyset em event_time, event_id) e ordenação
+	query := page.apply(baseQuery, orderBy, "e.event_time", "e.event_id")
 
 	// Para consultas comuns, usar SELECT mais enxuto
-	if page > 1 || (!needsUserJoin && !needsSessionJoin && !needsSurveyJoin) {
+	if !page.IsFirst() || (!needsUserJoin && !needsSessionJoin && !needsSurveyJoin) {
 		// SELECT básico apenas com campos essenciais
 		query = query.Select(`
 			e.event_id, 
@@ -650,7 +243,7 @@ func (r *eventRepository) GetEvents(ctx context.Context, page, limit int, orderB
 
 	// Executar a consulta para obter eventos
 	if err := query.Find(&events).Error; err != nil {
-		return nil, 0, fmt.Errorf("erro ao buscar eventos: %w", err)
+		return nil, PageResult{}, fmt.Errorf("erro ao buscar eventos: %w", filterQueryError(err))
 	}
 
 	// Adicionar log para verificar os dados brutos retornados pela consulta
@@ -664,9 +257,14 @@ func (r *eventRepository) GetEvents(ctx context.Context, page, limit int, orderB
 		}
 	}
 
+	// Descartar o registro excedente do modo keyset e gerar o cursor da próxima página
+	events = trimKeysetPage(events, page, &result, func(event entities.Event) (time.Time, string) {
+		return event.EventTime, event.EventID.String()
+	})
+
 	// Se não temos eventos, retornar imediatamente
 	if len(events) == 0 {
-		return events, total, nil
+		return events, result, nil
 	}
 
 	// Debug: Verificar dados geo e UTM do primeiro evento logo após a consulta
@@ -1100,47 +698,6 @@ func (r *eventRepository) GetEvents(ctx context.Context, page, limit int, orderB
 		}
 	}
 
-	if len(advancedFilters) > 0 {
-		hasUtmSourceNotEmptyFilter := false
-		for _, filter := range advancedFilters {
-			// Verificar apenas filtros explícitos de session.utm_source, ignorando user.utm_*
-			if filter.Property == "session.utm_source" && filter.Operator == "not_equals" && filter.Value == "" {
-				hasUtmSourceNotEmptyFilter = true
-				break
-			}
-		}
-
-		if hasUtmSourceNotEmptyFilter {
-			// Filtragem final: manter apenas eventos com UTM não vazio
-			fmt.Println("DEBUG: Aplicando filtro final para remover eventos com session.utm_source vazio")
-			filteredEvents := []entities.Event{}
-			for _, event := range events {
-				// Verificar UTM EXCLUSIVAMENTE na sessão (utmSource)
-				hasValidUtm := false
-
-				// Apenas sessões com utmSource preenchido passam no filtro
-				if event.Session.ID != uuid.Nil && event.Session.UtmSource != "" {
-					hasValidUtm = true
-					fmt.Printf("DEBUG: Evento %s passou no filtro com session.utm_source=%s\n",
-						event.EventID, event.Session.UtmSource)
-				}
-
-				if hasValidUtm {
-					filteredEvents = append(filteredEvents, event)
-				} else {
-					fmt.Printf("DEBUG: Removendo evento %s com session.utm_source vazio ou sem sessão\n", event.EventID)
-				}
-			}
-
-			fmt.Printf("DEBUG: Após filtro final, eventos: %d -> %d\n", len(events), len(filteredEvents))
-			events = filteredEvents
-			// Ajustar o total se necessário
-			if int64(len(filteredEvents)) < total {
-				total = int64(len(filteredEvents))
-			}
-		}
-	}
-
 	// Verificar a estrutura final do primeiro evento (se houver)
 	if len(events) > 0 {
 		event := events[0]
@@ -1156,7 +713,147 @@ func (r *eventRepository) GetEvents(ctx context.Context, page, limit int, orderB
 			event.User.InitialUtmSource, event.User.InitialUtmMedium)
 	}
 
-	return events, total, nil
+	return events, result, nil
+}
+
+// eventListJoins indica as tabelas ligadas aos eventos na consulta da listagem
+type eventListJoins struct {
+	user, session, profession, product, funnel, survey bool
+}
+
+// eventListQuery monta a consulta filtrada da listagem de eventos (alias e), com os JOINs pedidos.
+// users é sempre ligado: com INNER JOIN quando joins.user, senão com LEFT JOIN.
+func (r *eventRepository) eventListQuery(from, to time.Time, timeFrom, timeTo string, professionIDs, funnelIDs []int, compiledFilter compiledEventFilter, segment SegmentFilter, joins eventListJoins) *gorm.DB {
+	// Inicializar a consulta base - sempre usar alias 'e' para eventos
+	baseQuery := r.db.Model(&entities.Event{}).Table("events e")
+
+	// JOIN com users (obrigatório para UTMs do usuário)
+	if joins.user {
+		baseQuery = baseQuery.Joins("JOIN users u ON e.user_id = u.user_id")
+	} else {
+		// Se não for necessário o JOIN completo, usar LEFT JOIN apenas para campos básicos
+		baseQuery = baseQuery.Joins("LEFT JOIN users u ON e.user_id = u.user_id")
+	}
+
+	// Aplicar outros JOINs apenas se necessários
+	if joins.session {
+		baseQuery = baseQuery.Joins("LEFT JOIN sessions s ON e.session_id = s.session_id")
+	}
+
+	if joins.profession {
+		baseQuery = baseQuery.Joins("LEFT JOIN professions ON e.profession_id = professions.profession_id")
+	}
+
+	if joins.product {
+		baseQuery = baseQuery.Joins("LEFT JOIN products ON e.product_id = products.product_id")
+	}
+
+	if joins.funnel {
+		baseQuery = baseQuery.Joins("LEFT JOIN funnels ON e.funnel_id = funnels.funnel_id")
+	}
+
+	// JOINs para surveys apenas se necessários
+	if joins.survey {
+		baseQuery = baseQuery.
+			// Join com surveys baseado no funnel_id
+			Joins("LEFT JOIN surveys sv ON sv.funnel_id = funnels.funnel_id").
+			// Join com survey_responses baseado no event_id e survey_id
+			Joins("LEFT JOIN survey_responses sr ON (sr.event_id = e.event_id AND sr.survey_id = sv.survey_id)")
+	}
+
+	// Aplicar filtro de data em UTC diretamente, sem conversão de timezone no SQL
+	if !from.IsZero() && !to.IsZero() {
+		fromTime := from
+		toTime := to
+
+		// Ajustar hora e minuto se timeFrom fornecido
+		if timeFrom != "" {
+			timeParts := strings.Split(timeFrom, ":")
+			if len(timeParts) >= 2 {
+				hour, _ := strconv.Atoi(timeParts[0])
+				min, _ := strconv.Atoi(timeParts[1])
+				fromTime = time.Date(from.Year(), from.Month(), from.Day(), hour, min, 0, 0, from.Location())
+				fmt.Printf("Events: Ajustando horário de início para: %s\n", fromTime.Format("2006-01-02 15:04:05"))
+			}
+		} else {
+			// Se não fornecido, usar o início do dia
+			fromTime = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+		}
+
+		// Ajustar hora e minuto se timeTo fornecido
+		if timeTo != "" {
+			timeParts := strings.Split(timeTo, ":")
+			if len(timeParts) >= 2 {
+				hour, _ := strconv.Atoi(timeParts[0])
+				min, _ := strconv.Atoi(timeParts[1])
+				toTime = time.Date(to.Year(), to.Month(), to.Day(), hour, min, 59, 999999999, to.Location())
+				fmt.Printf("Events: Ajustando horário de fim para: %s\n", toTime.Format("2006-01-02 15:04:05"))
+			}
+		} else {
+			// Se não fornecido, usar o fim do dia
+			toTime = time.Date(to.Year(), to.Month(), to.Day(), 23, 59, 59, 999999999, to.Location())
+		}
+
+		// Aplicar filtro usando AT TIME ZONE em São Paulo
+		fromStr := fromTime.Format("2006-01-02 15:04:05")
+		toStr := toTime.Format("2006-01-02 15:04:05")
+
+		baseQuery = baseQuery.Where("(e.event_time AT TIME ZONE 'America/Sao_Paulo') BETWEEN ? AND ?",
+			fromStr, toStr)
+
+		fmt.Printf("Events: Filtro de data aplicado com timezone São Paulo: %s até %s\n",
+			fromStr, toStr)
+	}
+
+	// Adicionar filtro de profissão se fornecido
+	if len(professionIDs) > 0 {
+		baseQuery = baseQuery.Where("e.profession_id IN ?", professionIDs)
+	}
+
+	// Adicionar filtro de funil se fornecido
+	if len(funnelIDs) > 0 {
+		baseQuery = baseQuery.Where("e.funnel_id IN ?", funnelIDs)
+	}
+
+	// Aplicar a árvore de filtros avançados (os JOINs já foram incluídos acima)
+	if !compiledFilter.isEmpty() {
+		baseQuery = baseQuery.Where(compiledFilter.sql, compiledFilter.args...)
+	}
+
+	// Restringir aos membros do segmento, se informado
+	baseQuery = segment.Apply(baseQuery, "e.user_id")
+
+	return baseQuery
+}
+
+// ExportEvents percorre os eventos com os mesmos filtros da listagem, entregando as colunas pedidas
+// linha a linha a fn direto do cursor do banco
+func (r *eventRepository) ExportEvents(ctx context.Context, columns []ExportColumn, orderBy string, from, to time.Time, timeFrom, timeTo string, professionIDs, funnelIDs []int, filter *FilterNode, segment SegmentFilter, fn ExportRowFunc) error {
+	brazilLocation := utils.GetBrasilLocation()
+	if !from.IsZero() {
+		from = from.In(brazilLocation)
+	}
+	if !to.IsZero() {
+		to = to.In(brazilLocation)
+	}
+
+	compiledFilter, err := compileEventFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	// Todas as colunas exportáveis ficam disponíveis; users usa LEFT JOIN para não omitir eventos
+	query := r.eventListQuery(from, to, timeFrom, timeTo, professionIDs, funnelIDs, compiledFilter, segment, eventListJoins{
+		session:    compiledFilter.aliases["s"],
+		profession: true,
+		product:    true,
+		funnel:     true,
+	})
+	if orderBy == "" {
+		orderBy = "e.event_time desc"
+	}
+
+	return filterQueryError(streamExportRows(query.WithContext(ctx).Order(orderBy), columns, fn))
 }
 
 // Função auxiliar para processar o nome da propriedade
@@ -1241,7 +938,7 @@ func processPropertyName(rawProperty string) string {
 				property = "products." + columnName
 			case "funnel":
 				property = "funnels." + columnName
-			case "event", "events":
+			case "e", "event", "events":
 				property = "e." + columnName
 			default:
 				property = "e." + property
@@ -1333,7 +1030,12 @@ func needsQuotesForColumn(table, column string) bool {
 }
 
 // CountEvents conta eventos com filtros aplicados, incluindo tipo específico
-func (r *eventRepository) CountEvents(from, to time.Time, timeFrom, timeTo string, eventType string, professionIDs, funnelIDs []int, advancedFilters []AdvancedFilter, filterCondition string) (int64, error) {
+func (r *eventRepository) CountEvents(from, to time.Time, timeFrom, timeTo string, eventType string, professionIDs, funnelIDs []int, filter *FilterNode, segment SegmentFilter) (int64, error) {
+	compiledFilter, err := compileEventFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+
 	// Obter localização de Brasília usando a função centralizada
 	brazilLocation := utils.GetBrasilLocation()
 
@@ -1411,35 +1113,30 @@ func (r *eventRepository) CountEvents(from, to time.Time, timeFrom, timeTo strin
 		query = query.Where("e.funnel_id IN ?", funnelIDs)
 	}
 
-	// Aplicar filtros avançados (reutilizando a mesma lógica usada em GetEvents)
-	if len(advancedFilters) > 0 {
-		// Definir operador lógico entre filtros (AND/OR)
-		if filterCondition == "OR" {
-			// TODO: Implementar lógica de filtros OR
-			fmt.Println("Filtros OR para CountEvents não implementados completamente")
-		}
+	// Aplicar a árvore de filtros avançados (mesma compilação usada em GetEvents)
+	query = compiledFilter.apply(query, map[string]bool{"u": true})
 
-		// Aplicar cada filtro individualmente como AND por padrão
-		for _, filter := range advancedFilters {
-			// Seria necessário implementar a lógica completa de filtros aqui
-			// Implementar a lógica de filtros avançados similar à GetEvents
-			fmt.Printf("Aplicando filtro avançado: %s %s %s\n", filter.Property, filter.Operator, filter.Value)
-		}
-	}
+	// Restringir aos membros do segmento, se informado
+	query = segment.Apply(query, "e.user_id")
 
 	// Contar resultados
 	var count int64
 	if err := query.Count(&count).Error; err != nil {
-		return 0, err
+		return 0, filterQueryError(err)
 	}
 
 	return count, nil
 }
 
 // CountEventsByPeriods conta eventos agrupados por períodos (dias)
-func (r *eventRepository) CountEventsByPeriods(periods []string, eventType string, advancedFilters []AdvancedFilter, funnelID int, professionID int) (map[string]int64, error) {
+func (r *eventRepository) CountEventsByPeriods(periods []string, eventType string, filter *FilterNode, funnelID int, professionID int, segment SegmentFilter) (map[string]int64, error) {
 	result := make(map[string]int64)
 
+	compiledFilter, err := compileEventFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
 	// Obter localização de Brasília usando a função centralizada
 	brazilLocation := utils.GetBrasilLocation()
 
@@ -1481,15 +1178,16 @@ func (r *eventRepository) CountEventsByPeriods(periods []string, eventType strin
 			query = query.Where("e.profession_id = ?", professionID)
 		}
 
-		// Aplicar filtros avançados se existirem
-		if len(advancedFilters) > 0 {
-			// Implementar a lógica de filtros avançados similar à CountEvents
-		}
+		// Aplicar a árvore de filtros avançados
+		query = compiledFilter.apply(query, map[string]bool{"u": true})
+
+		// Restringir aos membros do segmento, se informado
+		query = segment.Apply(query, "e.user_id")
 
 		// Contar eventos para este período
 		var count int64
 		if err := query.Count(&count).Error; err != nil {
-			return nil, err
+			return nil, filterQueryError(err)
 		}
 
 		result[period] = count
@@ -1562,9 +1260,15 @@ func (r *eventRepository) CountEventsByDateRange(
 	eventType string,
 	professionIDs, funnelIDs []int,
 	logicalOperator string,
+	filter *FilterNode,
 ) (int64, error) {
 	var count int64
 
+	compiledFilter, err := compileEventFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+
 	// Ajustar datas para incluir o dia inteiro quando é o mesmo dia
 	isSameDay := from.Year() == to.Year() && from.Month() == to.Month() && from.Day() == to.Day()
 
@@ -1592,12 +1296,12 @@ func (r *eventRepository) CountEventsByDateRange(
 		toStr = fmt.Sprintf("%s %s", to.Format("2006-01-02"), toHour)
 	}
 
-	// Criar query base
-	query := r.db.Model(&entities.Event{})
+	// Criar query base (alias e, usado também pelos filtros avançados)
+	query := r.db.Model(&entities.Event{}).Table("events e")
 
 	// Aplicar filtro de tipo de evento
 	if eventType != "" {
-		query = query.Where("event_type = ?", eventType)
+		query = query.Where("e.event_type = ?", eventType)
 	}
 
 	// Aplicar filtro de data com timezone São Paulo e DATE_TRUNC
@@ -1607,11 +1311,11 @@ func (r *eventRepository) CountEventsByDateRange(
 
 		if isSameDay {
 			// Se for o mesmo dia, usar DATE_TRUNC diretamente
-			query = query.Where("DATE_TRUNC('day', \"event_time\" AT TIME ZONE 'America/Sao_Paulo') = DATE_TRUNC('day', ? AT TIME ZONE 'America/Sao_Paulo')",
+			query = query.Where("DATE_TRUNC('day', e.event_time AT TIME ZONE 'America/Sao_Paulo') = DATE_TRUNC('day', ? AT TIME ZONE 'America/Sao_Paulo')",
 				fromStr)
 		} else {
 			// Para intervalo de datas, usar BETWEEN com timezone
-			query = query.Where("(\"event_time\" AT TIME ZONE 'America/Sao_Paulo') BETWEEN ? AND ?",
+			query = query.Where("(e.event_time AT TIME ZONE 'America/Sao_Paulo') BETWEEN ? AND ?",
 				fromStr, toStr)
 		}
 	}
@@ -1622,20 +1326,23 @@ func (r *eventRepository) CountEventsByDateRange(
 		if len(professionIDs) > 0 && len(funnelIDs) > 0 {
 			if logicalOperator == "OR" {
 				// OR: pelo menos um dos filtros deve corresponder
-				query = query.Where("profession_id IN ? OR funnel_id IN ?", professionIDs, funnelIDs)
+				query = query.Where("(e.profession_id IN ? OR e.funnel_id IN ?)", professionIDs, funnelIDs)
 			} else {
 				// AND: ambos os filtros devem corresponder (padrão)
-				query = query.Where("profession_id IN ? AND funnel_id IN ?", professionIDs, funnelIDs)
+				query = query.Where("e.profession_id IN ? AND e.funnel_id IN ?", professionIDs, funnelIDs)
 			}
 		} else if len(professionIDs) > 0 {
 			// Somente filtro de profissão
-			query = query.Where("profession_id IN ?", professionIDs)
+			query = query.Where("e.profession_id IN ?", professionIDs)
 		} else {
 			// Somente filtro de funil
-			query = query.Where("funnel_id IN ?", funnelIDs)
+			query = query.Where("e.funnel_id IN ?", funnelIDs)
 		}
 	}
 
+	// Aplicar a árvore de filtros avançados (mesma compilação usada em GetEvents)
+	query = compiledFilter.apply(query, nil)
+
 	// Obter SQL para debug
 	stmt := query.Statement
 	sql := stmt.SQL.String()
@@ -1643,12 +1350,12 @@ func (r *eventRepository) CountEventsByDateRange(
 	fmt.Printf("SQL para CountEventsByDateRange: %s, Vars: %v\n", sql, vars)
 
 	// Executar consulta de contagem diretamente
-	err := query.Count(&count).Error
+	err = query.Count(&count).Error
 
 	// Log para debug
 	fmt.Printf("Contagem de eventos para %s - %s, tipo %s: %d\n", fromStr, toStr, eventType, count)
 
-	return count, err
+	return count, filterQueryError(err)
 }
 
 // GetEventsByHours retorna a contagem de eventos por hora para um dia específico
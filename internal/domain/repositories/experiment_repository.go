@@ -0,0 +1,267 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"gorm.io/gorm"
+)
+
+// ExperimentVariantMetricsRow representa os resultados agregados de uma variante do experimento
+type ExperimentVariantMetricsRow struct {
+	VariantID  int64   `gorm:"column:variant_id"`
+	Sessions   int64   `gorm:"column:sessions"`
+	Visitors   int64   `gorm:"column:visitors"`
+	Leads      int64   `gorm:"column:leads"`
+	Purchasers int64   `gorm:"column:purchasers"`
+	Purchases  int64   `gorm:"column:purchases"`
+	Revenue    float64 `gorm:"column:revenue"`
+	// RevenueSquares é a soma dos quadrados da receita por comprador, usada na variância da receita por visitante
+	RevenueSquares float64 `gorm:"column:revenue_squares"`
+}
+
+// ExperimentDailyVisitorsRow representa a quantidade de novos visitantes do experimento por dia
+type ExperimentDailyVisitorsRow struct {
+	Day      time.Time `gorm:"column:day"`
+	Visitors int64     `gorm:"column:visitors"`
+}
+
+// ExperimentLandingPageRow representa um caminho de landing page com tráfego no funil
+type ExperimentLandingPageRow struct {
+	LandingPagePath string `json:"landing_page_path" gorm:"column:landing_page_path"`
+	Sessions        int64  `json:"sessions" gorm:"column:sessions"`
+}
+
+// ExperimentRepository interface para operações de experimentos de landing page
+type ExperimentRepository interface {
+	ListExperiments(funnelID int) ([]entities.Experiment, error)
+	GetExperiment(experimentID int64) (*entities.Experiment, error)
+	// CreateExperiment cria o experimento com suas variantes
+	CreateExperiment(experiment *entities.Experiment) error
+	// UpdateExperiment altera o experimento; com replaceVariants, substitui também suas variantes
+	UpdateExperiment(experiment *entities.Experiment, replaceVariants bool) error
+	DeleteExperiment(experimentID int64) error
+
+	// GetExperimentMetrics agrega sessões, leads e compras por variante. Cada visitante é atribuído à variante
+	// da sua primeira sessão no período; leads e compras contam a partir dessa sessão até o fim da janela de conversão.
+	GetExperimentMetrics(experiment *entities.Experiment) ([]ExperimentVariantMetricsRow, error)
+	// GetExperimentDailyVisitors retorna os novos visitantes atribuídos às variantes por dia
+	GetExperimentDailyVisitors(experiment *entities.Experiment) ([]ExperimentDailyVisitorsRow, error)
+	// GetLandingPages lista os caminhos de landing page do funil no período, para escolha das variantes
+	GetLandingPages(funnelID int, from, to time.Time) ([]ExperimentLandingPageRow, error)
+}
+
+type experimentRepository struct {
+	db *gorm.DB
+}
+
+func NewExperimentRepository(db *gorm.DB) ExperimentRepository {
+	return &experimentRepository{db}
+}
+
+func (r *experimentRepository) ListExperiments(funnelID int) ([]entities.Experiment, error) {
+	var experiments []entities.Experiment
+	query := r.db.Preload("Variants", func(tx *gorm.DB) *gorm.DB { return tx.Order("is_control DESC, variant_id") }).
+		Order("start_date DESC, experiment_id DESC")
+	if funnelID > 0 {
+		query = query.Where("funnel_id = ?", funnelID)
+	}
+	if err := query.Find(&experiments).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar experimentos: %w", err)
+	}
+	return experiments, nil
+}
+
+func (r *experimentRepository) GetExperiment(experimentID int64) (*entities.Experiment, error) {
+	var experiment entities.Experiment
+	if err := r.db.Preload("Variants", func(tx *gorm.DB) *gorm.DB { return tx.Order("is_control DESC, variant_id") }).
+		First(&experiment, "experiment_id = ?", experimentID).Error; err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+func (r *experimentRepository) CreateExperiment(experiment *entities.Experiment) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Variants").Create(experiment).Error; err != nil {
+			return fmt.Errorf("erro ao criar experimento: %w", err)
+		}
+		return createExperimentVariants(tx, experiment)
+	})
+}
+
+func (r *experimentRepository) UpdateExperiment(experiment *entities.Experiment, replaceVariants bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Variants", "CreatedAt").Save(experiment).Error; err != nil {
+			return fmt.Errorf("erro ao atualizar experimento: %w", err)
+		}
+		if !replaceVariants {
+			return nil
+		}
+		if err := tx.Where("experiment_id = ?", experiment.ExperimentID).Delete(&entities.ExperimentVariant{}).Error; err != nil {
+			return fmt.Errorf("erro ao remover variantes do experimento: %w", err)
+		}
+		return createExperimentVariants(tx, experiment)
+	})
+}
+
+// createExperimentVariants grava as variantes do experimento
+func createExperimentVariants(tx *gorm.DB, experiment *entities.Experiment) error {
+	for i := range experiment.Variants {
+		variant := &experiment.Variants[i]
+		variant.VariantID = 0
+		variant.ExperimentID = experiment.ExperimentID
+		if err := tx.Create(variant).Error; err != nil {
+			return fmt.Errorf("erro ao criar variante %s: %w", variant.LandingPagePath, err)
+		}
+	}
+	return nil
+}
+
+func (r *experimentRepository) DeleteExperiment(experimentID int64) error {
+	result := r.db.Where("experiment_id = ?", experimentID).Delete(&entities.Experiment{})
+	if result.Error != nil {
+		return fmt.Errorf("erro ao remover experimento: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// landingPagePathSQL normaliza o caminho da landing page da sessão como normalizeLandingPagePath:
+// minúsculo, sem query string nem fragmento e sem barras finais, exceto na raiz. O "?" é escrito como
+// chr(63) porque as consultas o tratariam como placeholder.
+func landingPagePathSQL(column string) string {
+	return `regexp_replace(split_part(split_part(lower(btrim(` + column + `)), chr(63), 1), '#', 1), '(.)/+$', '\1')`
+}
+
+// experimentVisitorsCTE atribui cada visitante à variante da sua primeira sessão no funil e no período,
+// comparando o caminho normalizado da landing page
+var experimentVisitorsCTE = `
+	WITH variant_sessions AS (
+		SELECT v.variant_id, s.user_id, s."sessionStart" AS session_start
+		FROM sessions s
+		JOIN experiment_variants v
+			ON v.experiment_id = @experiment_id
+			AND ` + landingPagePathSQL(`s."landingPagePath"`) + ` = v.landing_page_path
+		WHERE s.funnel_id = @funnel_id
+			AND s."sessionStart" >= @start_date
+			AND s."sessionStart" < @end_date
+	),
+	visitors AS (
+		SELECT DISTINCT ON (user_id) user_id, variant_id, session_start AS first_seen
+		FROM variant_sessions
+		WHERE user_id IS NOT NULL
+		ORDER BY user_id, session_start
+	)`
+
+// experimentParams monta os parâmetros das consultas do experimento
+func experimentParams(experiment *entities.Experiment) map[string]interface{} {
+	return map[string]interface{}{
+		"experiment_id":     experiment.ExperimentID,
+		"funnel_id":         experiment.FunnelID,
+		"start_date":        experiment.StartDate,
+		"end_date":          experiment.EndDate,
+		"conversion_window": experiment.EndDate.AddDate(0, 0, experiment.ConversionWindowDays),
+	}
+}
+
+func (r *experimentRepository) GetExperimentMetrics(experiment *entities.Experiment) ([]ExperimentVariantMetricsRow, error) {
+	query := experimentVisitorsCTE + `,
+	session_counts AS (
+		SELECT variant_id, COUNT(*) AS sessions
+		FROM variant_sessions
+		GROUP BY variant_id
+	),
+	leads AS (
+		SELECT vi.variant_id, COUNT(DISTINCT vi.user_id) AS leads
+		FROM visitors vi
+		JOIN events e ON e.user_id = vi.user_id
+		WHERE e.event_type = 'LEAD'
+			AND e.funnel_id = @funnel_id
+			AND e.event_time >= vi.first_seen
+			AND e.event_time < @conversion_window
+		GROUP BY vi.variant_id
+	),
+	purchase_users AS (
+		SELECT
+			vi.variant_id,
+			vi.user_id,
+			COUNT(*) AS purchases,
+			COALESCE(SUM(` + purchaseValueSQL("e") + `), 0) AS revenue
+		FROM visitors vi
+		JOIN events e ON e.user_id = vi.user_id
+		WHERE e.event_type = 'PURCHASE'
+			AND e.event_propeties->>'product_type' = 'main'
+			AND e.funnel_id = @funnel_id
+			AND e.event_time >= vi.first_seen
+			AND e.event_time < @conversion_window
+		GROUP BY vi.variant_id, vi.user_id
+	),
+	purchases AS (
+		SELECT
+			variant_id,
+			COUNT(*) AS purchasers,
+			SUM(purchases) AS purchases,
+			SUM(revenue) AS revenue,
+			SUM(revenue * revenue) AS revenue_squares
+		FROM purchase_users
+		GROUP BY variant_id
+	)
+	SELECT
+		v.variant_id,
+		COALESCE(sc.sessions, 0) AS sessions,
+		(SELECT COUNT(*) FROM visitors vi WHERE vi.variant_id = v.variant_id) AS visitors,
+		COALESCE(l.leads, 0) AS leads,
+		COALESCE(p.purchasers, 0) AS purchasers,
+		COALESCE(p.purchases, 0) AS purchases,
+		COALESCE(p.revenue, 0) AS revenue,
+		COALESCE(p.revenue_squares, 0) AS revenue_squares
+	FROM experiment_variants v
+	LEFT JOIN session_counts sc ON sc.variant_id = v.variant_id
+	LEFT JOIN leads l ON l.variant_id = v.variant_id
+	LEFT JOIN purchases p ON p.variant_id = v.variant_id
+	WHERE v.experiment_id = @experiment_id
+	ORDER BY v.variant_id`
+
+	var rows []ExperimentVariantMetricsRow
+	if err := r.db.Raw(query, experimentParams(experiment)).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("erro ao calcular resultados do experimento: %w", err)
+	}
+	return rows, nil
+}
+
+func (r *experimentRepository) GetExperimentDailyVisitors(experiment *entities.Experiment) ([]ExperimentDailyVisitorsRow, error) {
+	query := experimentVisitorsCTE + `
+	SELECT date_trunc('day', first_seen) AS day, COUNT(*) AS visitors
+	FROM visitors
+	GROUP BY 1
+	ORDER BY 1`
+
+	var rows []ExperimentDailyVisitorsRow
+	if err := r.db.Raw(query, experimentParams(experiment)).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("erro ao calcular visitantes diários do experimento: %w", err)
+	}
+	return rows, nil
+}
+
+func (r *experimentRepository) GetLandingPages(funnelID int, from, to time.Time) ([]ExperimentLandingPageRow, error) {
+	var rows []ExperimentLandingPageRow
+	err := r.db.Raw(`
+		SELECT `+landingPagePathSQL(`"landingPagePath"`)+` AS landing_page_path, COUNT(*) AS sessions
+		FROM sessions
+		WHERE funnel_id = ?
+			AND "sessionStart" >= ?
+			AND "sessionStart" < ?
+			AND COALESCE("landingPagePath", '') <> ''
+		GROUP BY 1
+		ORDER BY sessions DESC
+		LIMIT 100
+	`, funnelID, from, to).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar landing pages do funil: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,282 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidExportColumns indica colunas de exportação desconhecidas ou repetidas
+var ErrInvalidExportColumns = errors.New("colunas de exportação inválidas")
+
+// ErrInvalidExportSort indica uma chave de ordenação não aceita pela exportação
+var ErrInvalidExportSort = errors.New("ordenação de exportação inválida")
+
+// Tipos de dado pessoal das colunas exportáveis, usados pelo mascaramento
+const (
+	PIINone  = ""
+	PIIName  = "name"
+	PIIEmail = "email"
+	PIIPhone = "phone"
+	PIIIP    = "ip"
+)
+
+// ExportColumn é uma coluna exportável: o nome na saída, a expressão SQL que a produz e o tipo
+// de dado pessoal que contém (PIINone quando não há)
+type ExportColumn struct {
+	Name string
+	SQL  string
+	PII  string
+}
+
+// ExportRowFunc recebe os valores de uma linha exportada, na ordem das colunas (vazio para NULL)
+type ExportRowFunc func(values []string) error
+
+// exportTime formata um timestamp no horário de São Paulo, como nas respostas JSON
+func exportTime(column string) string {
+	return fmt.Sprintf(`to_char(%s AT TIME ZONE 'America/Sao_Paulo', 'YYYY-MM-DD HH24:MI:SS')`, column)
+}
+
+// EventExportColumns são as colunas disponíveis na exportação de eventos
+var EventExportColumns = []ExportColumn{
+	{Name: "event_id", SQL: "e.event_id"},
+	{Name: "event_time", SQL: exportTime("e.event_time")},
+	{Name: "event_name", SQL: "e.event_name"},
+	{Name: "event_type", SQL: "e.event_type"},
+	{Name: "event_source", SQL: "e.event_source"},
+	{Name: "user_id", SQL: "e.user_id"},
+	{Name: "session_id", SQL: "e.session_id"},
+	{Name: "fullname", SQL: "u.fullname", PII: PIIName},
+	{Name: "email", SQL: "u.email", PII: PIIEmail},
+	{Name: "phone", SQL: "u.phone", PII: PIIPhone},
+	{Name: "is_client", SQL: `u."isClient"`},
+	{Name: "country", SQL: `u."initialCountry"`},
+	{Name: "state", SQL: `u."initialRegion"`},
+	{Name: "city", SQL: `u."initialCity"`},
+	{Name: "ip", SQL: `u."initialIp"`, PII: PIIIP},
+	{Name: "utm_source", SQL: `u."initialUtmSource"`},
+	{Name: "utm_medium", SQL: `u."initialUtmMedium"`},
+	{Name: "utm_campaign", SQL: `u."initialUtmCampaign"`},
+	{Name: "utm_content", SQL: `u."initialUtmContent"`},
+	{Name: "utm_term", SQL: `u."initialUtmTerm"`},
+	{Name: "profession_id", SQL: "e.profession_id"},
+	{Name: "profession_name", SQL: "professions.profession_name"},
+	{Name: "product_id", SQL: "e.product_id"},
+	{Name: "product_name", SQL: "products.product_name"},
+	{Name: "funnel_id", SQL: "e.funnel_id"},
+	{Name: "funnel_name", SQL: "funnels.funnel_name"},
+	{Name: "event_properties", SQL: "e.event_propeties"},
+}
+
+// SessionExportColumns são as colunas disponíveis na exportação de sessões
+var SessionExportColumns = []ExportColumn{
+	{Name: "session_id", SQL: "sessions.session_id"},
+	{Name: "user_id", SQL: "sessions.user_id"},
+	{Name: "session_start", SQL: exportTime(`sessions."sessionStart"`)},
+	{Name: "last_activity", SQL: exportTime(`sessions."lastActivity"`)},
+	{Name: "session_end", SQL: exportTime(`sessions."sessionEnd"`)},
+	{Name: "is_active", SQL: `sessions."isActive"`},
+	{Name: "duration", SQL: "sessions.duration"},
+	{Name: "country", SQL: "sessions.country"},
+	{Name: "state", SQL: "sessions.state"},
+	{Name: "city", SQL: "sessions.city"},
+	{Name: "ip_address", SQL: `sessions."ipAddress"`, PII: PIIIP},
+	{Name: "user_agent", SQL: `sessions."userAgent"`},
+	{Name: "landing_page", SQL: `sessions."landingPage"`},
+	{Name: "utm_source", SQL: `sessions."utmSource"`},
+	{Name: "utm_medium", SQL: `sessions."utmMedium"`},
+	{Name: "utm_campaign", SQL: `sessions."utmCampaign"`},
+	{Name: "utm_content", SQL: `sessions."utmContent"`},
+	{Name: "utm_term", SQL: `sessions."utmTerm"`},
+	{Name: "profession_id", SQL: "sessions.profession_id"},
+	{Name: "product_id", SQL: "sessions.product_id"},
+	{Name: "funnel_id", SQL: "sessions.funnel_id"},
+}
+
+// userExportColumns são as colunas comuns às exportações de leads e clientes
+var userExportColumns = []ExportColumn{
+	{Name: "user_id", SQL: "users.user_id"},
+	{Name: "fullname", SQL: "users.fullname", PII: PIIName},
+	{Name: "email", SQL: "users.email", PII: PIIEmail},
+	{Name: "phone", SQL: "users.phone", PII: PIIPhone},
+	{Name: "created_at", SQL: exportTime("users.created_at")},
+	{Name: "is_identified", SQL: `users."isIdentified"`},
+	{Name: "is_client", SQL: `users."isClient"`},
+	{Name: "country", SQL: `users."initialCountry"`},
+	{Name: "state", SQL: `users."initialRegion"`},
+	{Name: "city", SQL: `users."initialCity"`},
+	{Name: "ip", SQL: `users."initialIp"`, PII: PIIIP},
+	{Name: "utm_source", SQL: `users."initialUtmSource"`},
+	{Name: "utm_medium", SQL: `users."initialUtmMedium"`},
+	{Name: "utm_campaign", SQL: `users."initialUtmCampaign"`},
+	{Name: "utm_content", SQL: `users."initialUtmContent"`},
+	{Name: "utm_term", SQL: `users."initialUtmTerm"`},
+	{Name: "initial_profession", SQL: `users."initialProfession"`},
+	{Name: "initial_funnel", SQL: `users."initialFunnel"`},
+}
+
+// LeadExportColumns são as colunas disponíveis na exportação de leads (com a pontuação da versão ativa)
+var LeadExportColumns = append(append([]ExportColumn{}, userExportColumns...),
+	ExportColumn{Name: "lead_score", SQL: "ls.score"},
+	ExportColumn{Name: "lead_tier", SQL: "ls.tier"},
+)
+
+// leadExportSortColumns mapeia as chaves de sortBy aceitas na exportação de leads para as colunas.
+// score e lead_score ordenam pela pontuação da versão ativa.
+var leadExportSortColumns = map[string]string{
+	"created_at": "users.created_at",
+	"user_id":    "users.user_id",
+	"fullname":   "users.fullname",
+	"email":      "users.email",
+	"score":      "ls.score",
+	"lead_score": "ls.score",
+}
+
+// clientExportSortColumns mapeia as chaves de sortBy aceitas na exportação de clientes para as colunas
+var clientExportSortColumns = map[string]string{
+	"created_at": "users.created_at",
+	"user_id":    "users.user_id",
+	"fullname":   "users.fullname",
+	"email":      "users.email",
+}
+
+// LeadExportOrder monta a ordenação da exportação de leads; valores nulos (leads sem pontuação) ficam por último
+func LeadExportOrder(sortBy, sortDirection string) (string, error) {
+	return exportOrder(leadExportSortColumns, "leads", sortBy, sortDirection)
+}
+
+// exportOrder monta a ordenação pela coluna da chave sortBy em columns, com os nulos por último
+func exportOrder(columns map[string]string, dataset, sortBy, sortDirection string) (string, error) {
+	column, ok := columns[sortBy]
+	if !ok {
+		return "", fmt.Errorf("%w: sortBy=%s não é aceito na exportação de %s", ErrInvalidExportSort, sortBy, dataset)
+	}
+	direction := "DESC"
+	if strings.EqualFold(sortDirection, "asc") {
+		direction = "ASC"
+	}
+	return column + " " + direction + " NULLS LAST", nil
+}
+
+// ClientExportOrder monta a ordenação da exportação de clientes
+func ClientExportOrder(sortBy, sortDirection string) (string, error) {
+	return exportOrder(clientExportSortColumns, "clientes", sortBy, sortDirection)
+}
+
+// ClientExportColumns são as colunas disponíveis na exportação de clientes
+var ClientExportColumns = userExportColumns
+
+// SelectExportColumns resolve as colunas pedidas (na ordem informada) entre as disponíveis.
+// Sem colunas pedidas, exporta todas.
+func SelectExportColumns(available []ExportColumn, requested []string) ([]ExportColumn, error) {
+	if len(requested) == 0 {
+		return available, nil
+	}
+
+	byName := make(map[string]ExportColumn, len(available))
+	for _, column := range available {
+		byName[column.Name] = column
+	}
+
+	selected := make([]ExportColumn, 0, len(requested))
+	seen := make(map[string]bool, len(requested))
+	for _, name := range requested {
+		column, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: coluna desconhecida: %s", ErrInvalidExportColumns, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%w: coluna repetida: %s", ErrInvalidExportColumns, name)
+		}
+		seen[name] = true
+		selected = append(selected, column)
+	}
+	return selected, nil
+}
+
+// ExportColumnNames retorna os nomes das colunas, para mensagens e cabeçalhos
+func ExportColumnNames(columns []ExportColumn) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+// streamExportRows executa a consulta selecionando as colunas como texto e entrega cada linha a fn
+// enquanto lê o cursor do banco, sem carregar o resultado em memória
+func streamExportRows(query *gorm.DB, columns []ExportColumn, fn ExportRowFunc) error {
+	selects := make([]string, len(columns))
+	for i, column := range columns {
+		selects[i] = fmt.Sprintf("(%s)::text", column.SQL)
+	}
+
+	rows, err := query.Select(strings.Join(selects, ", ")).Rows()
+	if err != nil {
+		return fmt.Errorf("erro ao consultar dados da exportação: %w", err)
+	}
+	defer rows.Close()
+
+	raw := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range raw {
+		dest[i] = &raw[i]
+	}
+	values := make([]string, len(columns))
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("erro ao ler linha da exportação: %w", err)
+		}
+		for i, value := range raw {
+			values[i] = value.String
+		}
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// MaskPII mascara um valor exportado conforme o tipo de dado pessoal da coluna:
+// e-mails mantêm a inicial e o domínio, telefones os quatro últimos dígitos, nomes as iniciais
+// e IPs os dois primeiros octetos (IPv4) ou os dois primeiros grupos (IPv6)
+func MaskPII(kind, value string) string {
+	if value == "" {
+		return value
+	}
+
+	switch kind {
+	case PIIEmail:
+		at := strings.LastIndex(value, "@")
+		if at <= 0 {
+			return "***"
+		}
+		return value[:1] + "***" + value[at:]
+	case PIIPhone:
+		runes := []rune(value)
+		if len(runes) <= 4 {
+			return strings.Repeat("*", len(runes))
+		}
+		return strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-4:])
+	case PIIName:
+		words := strings.Fields(value)
+		for i, word := range words {
+			words[i] = string([]rune(word)[:1]) + "***"
+		}
+		return strings.Join(words, " ")
+	case PIIIP:
+		if parts := strings.Split(value, "."); len(parts) == 4 {
+			return parts[0] + "." + parts[1] + ".*.*"
+		}
+		if parts := strings.Split(value, ":"); len(parts) > 2 {
+			return parts[0] + ":" + parts[1] + ":*"
+		}
+		return "***"
+	default:
+		return value
+	}
+}
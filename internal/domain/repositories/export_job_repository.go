@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ExportJobRepository mantém a fila de exportações assíncronas no Postgres, para que os jobs
+// sobrevivam a reinícios e possam ser executados por mais de uma instância da API
+type ExportJobRepository interface {
+	CreateJob(job *entities.ExportJob) error
+	GetJob(jobID uuid.UUID) (*entities.ExportJob, error)
+	// ListJobs lista os jobs mais recentes
+	ListJobs(limit int) ([]entities.ExportJob, error)
+
+	// ClaimJob reserva o próximo job pendente (ou em execução com prazo vencido) por lease.
+	// Retorna nil quando a fila está vazia. Jobs interrompidos maxAttempts vezes são marcados como falhos.
+	ClaimJob(lease time.Duration, maxAttempts int) (*entities.ExportJob, error)
+	// Heartbeat registra o andamento e renova o prazo do job. Retorna false se o job não pertence mais
+	// a esta execução (attempt), por exemplo porque o prazo venceu e outro worker o assumiu.
+	Heartbeat(jobID uuid.UUID, attempt int, rows, bytes int64, lease time.Duration) (bool, error)
+	CompleteJob(jobID uuid.UUID, attempt int, rows, bytes int64, filePath, fileName string, expiresAt time.Time) error
+	FailJob(jobID uuid.UUID, attempt int, message string) error
+	// ReleaseJob devolve à fila um job interrompido pelo encerramento do worker, sem contar a tentativa
+	ReleaseJob(jobID uuid.UUID, attempt int) error
+
+	// ListExpiredJobs lista os jobs concluídos cujo arquivo passou do prazo de retenção
+	ListExpiredJobs(now time.Time) ([]entities.ExportJob, error)
+	MarkExpired(jobID uuid.UUID) error
+}
+
+type exportJobRepository struct {
+	db *gorm.DB
+}
+
+func NewExportJobRepository(db *gorm.DB) ExportJobRepository {
+	return &exportJobRepository{db}
+}
+
+func (r *exportJobRepository) CreateJob(job *entities.ExportJob) error {
+	if err := r.db.Create(job).Error; err != nil {
+		return fmt.Errorf("erro ao criar job de exportação: %w", err)
+	}
+	return nil
+}
+
+func (r *exportJobRepository) GetJob(jobID uuid.UUID) (*entities.ExportJob, error) {
+	var job entities.ExportJob
+	if err := r.db.First(&job, "job_id = ?", jobID).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *exportJobRepository) ListJobs(limit int) ([]entities.ExportJob, error) {
+	var jobs []entities.ExportJob
+	if err := r.db.Order("created_at DESC").Limit(limit).Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar jobs de exportação: %w", err)
+	}
+	return jobs, nil
+}
+
+func (r *exportJobRepository) ClaimJob(lease time.Duration, maxAttempts int) (*entities.ExportJob, error) {
+	// Jobs cujo worker parou (restart, queda) e que já esgotaram as tentativas
+	if err := r.db.Exec(`
+		UPDATE export_jobs
+		SET status = ?, error = 'exportação interrompida em todas as tentativas', locked_until = NULL,
+			finished_at = NOW(), updated_at = NOW()
+		WHERE status = ? AND locked_until < NOW() AND attempts >= ?`,
+		entities.ExportJobFailed, entities.ExportJobRunning, maxAttempts).Error; err != nil {
+		return nil, fmt.Errorf("erro ao encerrar jobs de exportação interrompidos: %w", err)
+	}
+
+	var jobs []entities.ExportJob
+	err := r.db.Raw(`
+		UPDATE export_jobs
+		SET status = @running, attempts = attempts + 1, rows = 0, bytes = 0, error = '',
+			locked_until = NOW() + make_interval(secs => @lease), started_at = NOW(), updated_at = NOW()
+		WHERE job_id = (
+			SELECT job_id FROM export_jobs
+			WHERE status = @queued OR (status = @running AND locked_until < NOW())
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING *`,
+		map[string]interface{}{
+			"running": entities.ExportJobRunning,
+			"queued":  entities.ExportJobQueued,
+			"lease":   lease.Seconds(),
+		}).Scan(&jobs).Error
+	if err != nil {
+		return nil, fmt.Errorf("erro ao reservar job de exportação: %w", err)
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return &jobs[0], nil
+}
+
+func (r *exportJobRepository) Heartbeat(jobID uuid.UUID, attempt int, rows, bytes int64, lease time.Duration) (bool, error) {
+	result := r.db.Exec(`
+		UPDATE export_jobs
+		SET rows = ?, bytes = ?, locked_until = NOW() + make_interval(secs => ?), updated_at = NOW()
+		WHERE job_id = ? AND status = ? AND attempts = ?`,
+		rows, bytes, lease.Seconds(), jobID, entities.ExportJobRunning, attempt)
+	if result.Error != nil {
+		return false, fmt.Errorf("erro ao atualizar andamento do job de exportação: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *exportJobRepository) CompleteJob(jobID uuid.UUID, attempt int, rows, bytes int64, filePath, fileName string, expiresAt time.Time) error {
+	result := r.db.Exec(`
+		UPDATE export_jobs
+		SET status = ?, rows = ?, bytes = ?, file_path = ?, file_name = ?, expires_at = ?,
+			locked_until = NULL, finished_at = NOW(), updated_at = NOW()
+		WHERE job_id = ? AND status = ? AND attempts = ?`,
+		entities.ExportJobDone, rows, bytes, filePath, fileName, expiresAt,
+		jobID, entities.ExportJobRunning, attempt)
+	if result.Error != nil {
+		return fmt.Errorf("erro ao concluir job de exportação: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("job de exportação %s assumido por outra execução", jobID)
+	}
+	return nil
+}
+
+func (r *exportJobRepository) FailJob(jobID uuid.UUID, attempt int, message string) error {
+	if err := r.db.Exec(`
+		UPDATE export_jobs
+		SET status = ?, error = ?, locked_until = NULL, finished_at = NOW(), updated_at = NOW()
+		WHERE job_id = ? AND status = ? AND attempts = ?`,
+		entities.ExportJobFailed, message, jobID, entities.ExportJobRunning, attempt).Error; err != nil {
+		return fmt.Errorf("erro ao registrar falha do job de exportação: %w", err)
+	}
+	return nil
+}
+
+func (r *exportJobRepository) ReleaseJob(jobID uuid.UUID, attempt int) error {
+	if err := r.db.Exec(`
+		UPDATE export_jobs
+		SET status = ?, attempts = attempts - 1, rows = 0, bytes = 0, locked_until = NULL, updated_at = NOW()
+		WHERE job_id = ? AND status = ? AND attempts = ?`,
+		entities.ExportJobQueued, jobID, entities.ExportJobRunning, attempt).Error; err != nil {
+		return fmt.Errorf("erro ao devolver job de exportação à fila: %w", err)
+	}
+	return nil
+}
+
+func (r *exportJobRepository) ListExpiredJobs(now time.Time) ([]entities.ExportJob, error) {
+	var jobs []entities.ExportJob
+	if err := r.db.Where("status = ? AND expires_at < ?", entities.ExportJobDone, now).Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar exportações vencidas: %w", err)
+	}
+	return jobs, nil
+}
+
+func (r *exportJobRepository) MarkExpired(jobID uuid.UUID) error {
+	if err := r.db.Exec(`UPDATE export_jobs SET status = ?, updated_at = NOW() WHERE job_id = ? AND status = ?`,
+		entities.ExportJobExpired, jobID, entities.ExportJobDone).Error; err != nil {
+		return fmt.Errorf("erro ao marcar exportação como vencida: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// CycleHourlyCount representa a contagem de eventos de um ciclo em uma hora relativa ao início da captação
+type CycleHourlyCount struct {
+	Hour      int     `gorm:"column:hour"`
+	EventType string  `gorm:"column:event_type"`
+	Count     int64   `gorm:"column:count"`
+	Revenue   float64 `gorm:"column:revenue"`
+}
+
+// ForecastRepository interface para leitura das curvas horárias dos ciclos de webinar
+type ForecastRepository interface {
+	// GetCycleHourlyCounts retorna leads e respostas de pesquisa da janela de captação e
+	// compras da janela de vendas, agrupados por hora desde pesquisa_inicio
+	GetCycleHourlyCounts(cycle utils.WebinarCycleDates, professionID, funnelID int) ([]CycleHourlyCount, error)
+}
+
+type forecastRepository struct {
+	db *gorm.DB
+}
+
+func NewForecastRepository(db *gorm.DB) ForecastRepository {
+	return &forecastRepository{db}
+}
+
+func (r *forecastRepository) GetCycleHourlyCounts(cycle utils.WebinarCycleDates, professionID, funnelID int) ([]CycleHourlyCount, error) {
+	var results []CycleHourlyCount
+
+	conditions := []string{
+		`((e.event_type IN ('LEAD', 'PESQUISA_LEAD') AND e.event_time BETWEEN ? AND ?)
+		OR (e.event_type = 'PURCHASE' AND e.event_time BETWEEN ? AND ?))`,
+	}
+	args := []interface{}{
+		cycle.PesquisaInicio,
+		cycle.PesquisaInicio, cycle.PesquisaFim,
+		cycle.VendaInicio, cycle.VendaFim,
+	}
+
+	if professionID > 0 {
+		conditions = append(conditions, "e.profession_id = ?")
+		args = append(args, professionID)
+	}
+	if funnelID > 0 {
+		conditions = append(conditions, "e.funnel_id = ?")
+		args = append(args, funnelID)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			FLOOR(EXTRACT(EPOCH FROM (e.event_time - ?)) / 3600)::int AS hour,
+			e.event_type,
+			COUNT(*) AS count,
+			COALESCE(SUM(CASE WHEN e.event_type = 'PURCHASE' THEN %s END), 0) AS revenue
+		FROM events e
+		WHERE %s
+		GROUP BY 1, e.event_type
+		ORDER BY 1
+	`, purchaseValueSQL("e"), strings.Join(conditions, " AND "))
+
+	if err := r.db.Raw(query, args...).Scan(&results).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar curva horária do ciclo: %w", err)
+	}
+
+	return results, nil
+}
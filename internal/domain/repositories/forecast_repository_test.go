@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+)
+
+func TestGetCycleHourlyCountsPlaceholders(t *testing.T) {
+	start := time.Date(2024, 6, 3, 20, 0, 0, 0, utils.GetBrasilLocation())
+	cycle := utils.WebinarCycleDates{
+		PesquisaInicio: start,
+		PesquisaFim:    start.AddDate(0, 0, 8),
+		VendaInicio:    start.AddDate(0, 0, 8).Add(30 * time.Minute),
+		VendaFim:       start.AddDate(0, 0, 13),
+	}
+	tests := []struct {
+		name         string
+		professionID int
+		funnelID     int
+		vars         int
+	}{
+		{"sem filtros", 0, 0, 5},
+		{"profissão", 3, 0, 6},
+		{"profissão e funil", 3, 7, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, statements := newDryRunDB(t)
+			NewForecastRepository(db).GetCycleHourlyCounts(cycle, tt.professionID, tt.funnelID)
+			if len(*statements) != 1 {
+				t.Fatalf("%d statements gerados; esperado 1", len(*statements))
+			}
+			stmt := (*statements)[0]
+			checkPlaceholders(t, stmt)
+			if len(stmt.Vars) != tt.vars {
+				t.Errorf("%d valores; esperado %d", len(stmt.Vars), tt.vars)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LeadScoringFeatures representa os sinais de um lead usados no cálculo da pontuação
+type LeadScoringFeatures struct {
+	UserID        string  `gorm:"column:user_id"`
+	UtmSource     string  `gorm:"column:utm_source"`
+	SessionCount  int64   `gorm:"column:session_count"`
+	TotalDuration float64 `gorm:"column:total_duration"`
+	// SurveyScores contém a pontuação da resposta mais recente do lead por question_id
+	SurveyScores map[string]int `gorm:"-"`
+}
+
+// LeadScoreRepository interface para operações de regras e pontuação de leads
+type LeadScoreRepository interface {
+	ListRuleSets() ([]entities.LeadScoringRuleSet, error)
+	GetRuleSet(version int64) (*entities.LeadScoringRuleSet, error)
+	// GetActiveRuleSet retorna a versão ativa das regras, ou nil se nenhuma estiver ativa
+	GetActiveRuleSet() (*entities.LeadScoringRuleSet, error)
+	CreateRuleSet(ruleSet *entities.LeadScoringRuleSet) error
+	ActivateRuleSet(version int64) error
+
+	// GetLeadFeatures retorna os sinais dos leads com user_id maior que afterUserID, em ordem de user_id
+	GetLeadFeatures(afterUserID string, limit int) ([]LeadScoringFeatures, error)
+	SaveLeadScores(scores []entities.LeadScore) error
+	// DeleteStaleScores remove as pontuações da versão que não foram recalculadas desde before
+	DeleteStaleScores(version int64, before time.Time) (int64, error)
+	GetLeadScore(userID string, version int64) (*entities.LeadScore, error)
+}
+
+type leadScoreRepository struct {
+	db *gorm.DB
+}
+
+func NewLeadScoreRepository(db *gorm.DB) LeadScoreRepository {
+	return &leadScoreRepository{db}
+}
+
+func (r *leadScoreRepository) ListRuleSets() ([]entities.LeadScoringRuleSet, error) {
+	var ruleSets []entities.LeadScoringRuleSet
+	if err := r.db.Order("version DESC").Find(&ruleSets).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar regras de pontuação: %w", err)
+	}
+	return ruleSets, nil
+}
+
+func (r *leadScoreRepository) GetRuleSet(version int64) (*entities.LeadScoringRuleSet, error) {
+	var ruleSet entities.LeadScoringRuleSet
+	if err := r.db.First(&ruleSet, "version = ?", version).Error; err != nil {
+		return nil, err
+	}
+	return &ruleSet, nil
+}
+
+func (r *leadScoreRepository) GetActiveRuleSet() (*entities.LeadScoringRuleSet, error) {
+	var ruleSet entities.LeadScoringRuleSet
+	err := r.db.Where("is_active = ?", true).First(&ruleSet).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar regras ativas: %w", err)
+	}
+	return &ruleSet, nil
+}
+
+func (r *leadScoreRepository) CreateRuleSet(ruleSet *entities.LeadScoringRuleSet) error {
+	active := ruleSet.IsActive
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// A versão é criada inativa e ativada em seguida para respeitar o índice de versão ativa única
+		ruleSet.IsActive = false
+		if err := tx.Create(ruleSet).Error; err != nil {
+			return fmt.Errorf("erro ao criar regras de pontuação: %w", err)
+		}
+		if !active {
+			return nil
+		}
+		ruleSet.IsActive = true
+		return activateRuleSet(tx, ruleSet.Version)
+	})
+}
+
+func (r *leadScoreRepository) ActivateRuleSet(version int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return activateRuleSet(tx, version)
+	})
+}
+
+func activateRuleSet(tx *gorm.DB, version int64) error {
+	if err := tx.Model(&entities.LeadScoringRuleSet{}).
+		Where("is_active = ? AND version <> ?", true, version).
+		Update("is_active", false).Error; err != nil {
+		return fmt.Errorf("erro ao desativar regras: %w", err)
+	}
+
+	result := tx.Model(&entities.LeadScoringRuleSet{}).
+		Where("version = ?", version).
+		Update("is_active", true)
+	if result.Error != nil {
+		return fmt.Errorf("erro ao ativar regras: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *leadScoreRepository) GetLeadFeatures(afterUserID string, limit int) ([]LeadScoringFeatures, error) {
+	var rows []struct {
+		LeadScoringFeatures
+		SurveyScoresJSON string `gorm:"column:survey_scores"`
+	}
+
+	query := `
+		SELECT
+			u.user_id,
+			COALESCE(u."initialUtmSource", '') AS utm_source,
+			COALESCE(s.session_count, 0) AS session_count,
+			COALESCE(s.total_duration, 0) AS total_duration,
+			COALESCE(a.survey_scores::text, '{}') AS survey_scores
+		FROM users u
+		LEFT JOIN LATERAL (
+			SELECT COUNT(*) AS session_count, SUM(COALESCE(sessions.duration, 0)) AS total_duration
+			FROM sessions
+			WHERE sessions.user_id = u.user_id
+		) s ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT json_object_agg(q.question_id, q.score) AS survey_scores
+			FROM (
+				SELECT DISTINCT ON (sa.question_id) sa.question_id, sa.score
+				FROM survey_answers sa
+				JOIN survey_responses sr ON sa.survey_response_id = sr.id
+				JOIN events e ON sr.event_id = e.event_id
+				WHERE e.user_id = u.user_id
+				ORDER BY sa.question_id, sa."timestamp" DESC
+			) q
+		) a ON TRUE
+		WHERE u."isIdentified" = true AND u."isClient" = false
+	`
+	args := []interface{}{}
+
+	if afterUserID != "" {
+		query += " AND u.user_id > ?"
+		args = append(args, afterUserID)
+	}
+	query += " ORDER BY u.user_id LIMIT ?"
+	args = append(args, limit)
+
+	if err := r.db.Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar sinais dos leads: %w", err)
+	}
+
+	features := make([]LeadScoringFeatures, len(rows))
+	for i, row := range rows {
+		features[i] = row.LeadScoringFeatures
+		features[i].SurveyScores = map[string]int{}
+		if err := json.Unmarshal([]byte(row.SurveyScoresJSON), &features[i].SurveyScores); err != nil {
+			return nil, fmt.Errorf("erro ao ler respostas do lead %s: %w", row.UserID, err)
+		}
+	}
+	return features, nil
+}
+
+func (r *leadScoreRepository) SaveLeadScores(scores []entities.LeadScore) error {
+	if len(scores) == 0 {
+		return nil
+	}
+
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "rule_version"}},
+		DoUpdates: clause.AssignmentColumns([]string{"score", "tier", "breakdown", "computed_at"}),
+	}).CreateInBatches(scores, 500).Error
+	if err != nil {
+		return fmt.Errorf("erro ao salvar pontuações: %w", err)
+	}
+	return nil
+}
+
+func (r *leadScoreRepository) DeleteStaleScores(version int64, before time.Time) (int64, error) {
+	result := r.db.Where("rule_version = ? AND computed_at < ?", version, before).Delete(&entities.LeadScore{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("erro ao remover pontuações antigas: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+func (r *leadScoreRepository) GetLeadScore(userID string, version int64) (*entities.LeadScore, error) {
+	var score entities.LeadScore
+	if err := r.db.First(&score, "user_id = ? AND rule_version = ?", userID, version).Error; err != nil {
+		return nil, err
+	}
+	return &score, nil
+}
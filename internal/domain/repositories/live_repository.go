@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LiveFilter restringe o feed ao vivo a uma profissão e/ou funil (zero considera todos)
+type LiveFilter struct {
+	ProfessionID int
+	FunnelID     int
+}
+
+// Key identifica o filtro, para agrupar os clientes que recebem os mesmos contadores
+func (f LiveFilter) Key() string {
+	return fmt.Sprintf("%d:%d", f.ProfessionID, f.FunnelID)
+}
+
+// Matches indica se o evento passa pelo filtro
+func (f LiveFilter) Matches(event entities.LiveEvent) bool {
+	return (f.ProfessionID == 0 || event.ProfessionID == f.ProfessionID) &&
+		(f.FunnelID == 0 || event.FunnelID == f.FunnelID)
+}
+
+func (f LiveFilter) apply(query *gorm.DB, alias string) *gorm.DB {
+	if f.ProfessionID > 0 {
+		query = query.Where(alias+".profession_id = ?", f.ProfessionID)
+	}
+	if f.FunnelID > 0 {
+		query = query.Where(alias+".funnel_id = ?", f.FunnelID)
+	}
+	return query
+}
+
+// LiveRepository consulta os eventos e contadores do feed ao vivo
+type LiveRepository interface {
+	// LatestCursor retorna a posição (event_time, event_id) do evento mais recente até until.
+	// Sem eventos, retorna until com o menor event_id possível.
+	LatestCursor(ctx context.Context, until time.Time) (PageCursor, error)
+	// ListEventsAfter lista, em ordem (event_time, event_id), os eventos após a posição after e até until
+	ListEventsAfter(ctx context.Context, after PageCursor, until time.Time, filter LiveFilter, limit int) ([]entities.LiveEvent, error)
+	// GetCounters calcula os contadores desde dayStart (leads, pesquisas, vendas e faturamento) e desde
+	// recentSince (leads recentes e sessões ativas)
+	GetCounters(ctx context.Context, filter LiveFilter, dayStart, recentSince time.Time) (*entities.LiveCounters, error)
+}
+
+type liveRepository struct {
+	db *gorm.DB
+}
+
+func NewLiveRepository(db *gorm.DB) LiveRepository {
+	return &liveRepository{db}
+}
+
+func (r *liveRepository) LatestCursor(ctx context.Context, until time.Time) (PageCursor, error) {
+	var latest struct {
+		EventTime time.Time `gorm:"column:event_time"`
+		EventID   string    `gorm:"column:event_id"`
+	}
+	err := r.db.WithContext(ctx).Table("events").
+		Select("event_time, event_id").
+		Where("event_type IN ?", entities.LiveEventTypes).
+		Where("event_time <= ?", until).
+		Order("event_time DESC, event_id DESC").
+		Limit(1).
+		Take(&latest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return PageCursor{Time: until, ID: uuid.Nil.String()}, nil
+	}
+	if err != nil {
+		return PageCursor{}, fmt.Errorf("erro ao buscar o evento mais recente: %w", err)
+	}
+	return PageCursor{Time: latest.EventTime, ID: latest.EventID}, nil
+}
+
+func (r *liveRepository) ListEventsAfter(ctx context.Context, after PageCursor, until time.Time, filter LiveFilter, limit int) ([]entities.LiveEvent, error) {
+	query := r.db.WithContext(ctx).Table("events e").
+		Select(`
+			e.event_id,
+			e.event_type,
+			e.event_time,
+			COALESCE(e.user_id::text, '') AS user_id,
+			COALESCE(e.profession_id, 0) AS profession_id,
+			COALESCE(prof.profession_name, '') AS profession_name,
+			COALESCE(e.funnel_id, 0) AS funnel_id,
+			COALESCE(f.funnel_name, '') AS funnel_name,
+			COALESCE(e.product_id, 0) AS product_id,
+			COALESCE(prod.product_name, '') AS product_name,
+			CASE WHEN e.event_type = 'PURCHASE' THEN `+purchaseValueSQL("e")+` END AS value`).
+		Joins("LEFT JOIN professions prof ON prof.profession_id = e.profession_id").
+		Joins("LEFT JOIN funnels f ON f.funnel_id = e.funnel_id").
+		Joins("LEFT JOIN products prod ON prod.product_id = e.product_id").
+		Where("e.event_type IN ?", entities.LiveEventTypes).
+		Where("(e.event_time, e.event_id) > (?, ?)", after.Time, after.ID).
+		Where("e.event_time <= ?", until)
+	query = filter.apply(query, "e")
+
+	var events []entities.LiveEvent
+	if err := query.Order("e.event_time, e.event_id").Limit(limit).Scan(&events).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar eventos do feed ao vivo: %w", err)
+	}
+	return events, nil
+}
+
+func (r *liveRepository) GetCounters(ctx context.Context, filter LiveFilter, dayStart, recentSince time.Time) (*entities.LiveCounters, error) {
+	since := dayStart
+	if recentSince.Before(since) {
+		since = recentSince
+	}
+
+	query := r.db.WithContext(ctx).Table("events e").
+		Select(`
+			COUNT(*) FILTER (WHERE e.event_type = 'LEAD' AND e.event_time >= ?) AS leads_last_5_minutes,
+			COUNT(*) FILTER (WHERE e.event_type = 'LEAD' AND e.event_time >= ?) AS leads_today,
+			COUNT(*) FILTER (WHERE e.event_type = 'PESQUISA_LEAD' AND e.event_time >= ?) AS survey_leads_today,
+			COUNT(*) FILTER (WHERE e.event_type = 'PURCHASE' AND e.event_time >= ?) AS purchases_today,
+			COALESCE(SUM(CASE WHEN e.event_type = 'PURCHASE' AND e.event_time >= ?
+				THEN `+purchaseValueSQL("e")+` END), 0) AS revenue_today`,
+			recentSince, dayStart, dayStart, dayStart, dayStart).
+		Where("e.event_type IN ?", entities.LiveEventTypes).
+		Where("e.event_time >= ?", since)
+	query = filter.apply(query, "e")
+
+	var counters entities.LiveCounters
+	if err := query.Scan(&counters).Error; err != nil {
+		return nil, fmt.Errorf("erro ao calcular contadores do feed ao vivo: %w", err)
+	}
+
+	sessions := r.db.WithContext(ctx).Table("sessions s").
+		Where(`s."isActive" = ?`, true).
+		Where(`s."lastActivity" >= ?`, recentSince)
+	sessions = filter.apply(sessions, "s")
+	if err := sessions.Count(&counters.ActiveSessions).Error; err != nil {
+		return nil, fmt.Errorf("erro ao contar sessões ativas: %w", err)
+	}
+	return &counters, nil
+}
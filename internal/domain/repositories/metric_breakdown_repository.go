@@ -0,0 +1,283 @@
+package repositories
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// breakdownDimension descreve como uma dimensão é obtida das sessões (s) e dos eventos (e, com a sessão
+// do evento em s). label, quando informado, traduz o valor agregado em um nome legível.
+type breakdownDimension struct {
+	session   string
+	event     string
+	label     string
+	needsUser bool
+}
+
+// Marcadores substituídos pela coluna de tempo da fonte (sessionStart ou event_time) e pelo valor agregado
+const (
+	breakdownTimeColumn  = "{time}"
+	breakdownValueColumn = "{value}"
+)
+
+var breakdownDimensions = map[string]breakdownDimension{
+	"profession": {
+		session: "s.profession_id::text",
+		event:   "e.profession_id::text",
+		label:   "(SELECT p.profession_name FROM professions p WHERE p.profession_id::text = {value})",
+	},
+	"product": {
+		session: "s.product_id::text",
+		event:   "e.product_id::text",
+		label:   "(SELECT p.product_name FROM products p WHERE p.product_id::text = {value})",
+	},
+	"funnel": {
+		session: "s.funnel_id::text",
+		event:   "e.funnel_id::text",
+		label:   "(SELECT f.funnel_name FROM funnels f WHERE f.funnel_id::text = {value})",
+	},
+	"landing_page":      {session: `s."landingPage"`, event: `s."landingPage"`},
+	"utm_source":        {session: `s."utmSource"`, event: `s."utmSource"`},
+	"utm_medium":        {session: `s."utmMedium"`, event: `s."utmMedium"`},
+	"utm_campaign":      {session: `s."utmCampaign"`, event: `s."utmCampaign"`},
+	"utm_content":       {session: `s."utmContent"`, event: `s."utmContent"`},
+	"utm_term":          {session: `s."utmTerm"`, event: `s."utmTerm"`},
+	"marketing_channel": {session: `s."marketingChannel"`, event: `s."marketingChannel"`},
+	"country":           {session: "s.country", event: "s.country"},
+	"state":             {session: "s.state", event: "s.state"},
+	"city":              {session: "s.city", event: "s.city"},
+	"device_type":       {session: `u."initialDeviceType"`, event: `u."initialDeviceType"`, needsUser: true},
+	"browser":           {session: `u."initialBrowser"`, event: `u."initialBrowser"`, needsUser: true},
+	"weekday": {
+		session: "EXTRACT(ISODOW FROM {time} AT TIME ZONE 'America/Sao_Paulo')::int::text",
+		event:   "EXTRACT(ISODOW FROM {time} AT TIME ZONE 'America/Sao_Paulo')::int::text",
+	},
+	"hour": {
+		session: "EXTRACT(HOUR FROM {time} AT TIME ZONE 'America/Sao_Paulo')::int::text",
+		event:   "EXTRACT(HOUR FROM {time} AT TIME ZONE 'America/Sao_Paulo')::int::text",
+	},
+}
+
+// lookupBreakdownDimension retorna a dimensão pelo nome. Além das dimensões fixas, aceita qualquer
+// propriedade do JSON do evento (event.properties.<caminho>), disponível apenas para métricas de eventos.
+func lookupBreakdownDimension(name string) (breakdownDimension, bool) {
+	if dim, ok := breakdownDimensions[name]; ok {
+		return dim, true
+	}
+	if strings.HasPrefix(name, EventPropertiesPrefix) {
+		expr, err := EventPropertySQL("e", strings.TrimPrefix(name, EventPropertiesPrefix), filterTypeText)
+		if err != nil {
+			return breakdownDimension{}, false
+		}
+		return breakdownDimension{event: expr}, true
+	}
+	return breakdownDimension{}, false
+}
+
+// IsBreakdownDimension indica se o nome é uma dimensão de breakdown suportada
+func IsBreakdownDimension(name string) bool {
+	_, ok := lookupBreakdownDimension(name)
+	return ok
+}
+
+// IsEventOnlyBreakdownDimension indica se a dimensão existe apenas nos eventos (não se aplica a sessões)
+func IsEventOnlyBreakdownDimension(name string) bool {
+	dim, ok := lookupBreakdownDimension(name)
+	return ok && dim.session == ""
+}
+
+// BreakdownDimensionNames retorna as dimensões de breakdown fixas, em ordem alfabética
+func BreakdownDimensionNames() []string {
+	names := make([]string, 0, len(breakdownDimensions))
+	for name := range breakdownDimensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MetricBreakdownQuery define um breakdown de métricas por uma ou duas dimensões em dois períodos
+type MetricBreakdownQuery struct {
+	Dimensions   []string
+	CurrentFrom  time.Time
+	CurrentTo    time.Time
+	PreviousFrom time.Time
+	PreviousTo   time.Time
+	// Filtros (zero não filtra)
+	ProfessionID int
+	ProductID    int
+	FunnelID     int
+	// Fontes necessárias para as métricas pedidas
+	IncludeSessions bool
+	IncludeEvents   bool
+}
+
+// MetricBreakdownRow representa as métricas de uma combinação de dimensões em um período
+type MetricBreakdownRow struct {
+	Dim1      string  `gorm:"column:dim1"`
+	Label1    string  `gorm:"column:label1"`
+	Dim2      string  `gorm:"column:dim2"`
+	Label2    string  `gorm:"column:label2"`
+	Period    string  `gorm:"column:period"`
+	Sessions  int64   `gorm:"column:sessions"`
+	Leads     int64   `gorm:"column:leads"`
+	Clients   int64   `gorm:"column:clients"`
+	Purchases int64   `gorm:"column:purchases"`
+	Revenue   float64 `gorm:"column:revenue"`
+}
+
+// MetricBreakdownRepository interface para breakdowns dimensionais de métricas
+type MetricBreakdownRepository interface {
+	// GetBreakdown agrega sessões, leads, clientes, compras e faturamento por dimensão e período
+	// ("current" ou "previous"). Leads e compras herdam da sessão do evento as dimensões de sessão.
+	GetBreakdown(query MetricBreakdownQuery) ([]MetricBreakdownRow, error)
+}
+
+type metricBreakdownRepository struct {
+	db *gorm.DB
+}
+
+func NewMetricBreakdownRepository(db *gorm.DB) MetricBreakdownRepository {
+	return &metricBreakdownRepository{db}
+}
+
+func (r *metricBreakdownRepository) GetBreakdown(query MetricBreakdownQuery) ([]MetricBreakdownRow, error) {
+	if len(query.Dimensions) == 0 || len(query.Dimensions) > 2 {
+		return nil, fmt.Errorf("breakdown requer uma ou duas dimensões")
+	}
+	dims := make([]breakdownDimension, len(query.Dimensions))
+	needsUser := false
+	for i, name := range query.Dimensions {
+		dim, ok := lookupBreakdownDimension(name)
+		if !ok {
+			return nil, fmt.Errorf("dimensão de breakdown desconhecida: %s", name)
+		}
+		if dim.session == "" && query.IncludeSessions {
+			return nil, fmt.Errorf("a dimensão %s não se aplica a sessões", name)
+		}
+		dims[i] = dim
+		needsUser = needsUser || dim.needsUser
+	}
+
+	// Expressões das dimensões em cada fonte; sem segunda dimensão, dim2 é vazia
+	dimColumns := func(source string, timeColumn string) string {
+		columns := make([]string, 2)
+		for i := range columns {
+			columns[i] = "''"
+			if i < len(dims) {
+				expr := dims[i].session
+				if source == "event" {
+					expr = dims[i].event
+				}
+				columns[i] = "COALESCE(" + strings.ReplaceAll(expr, breakdownTimeColumn, timeColumn) + ", '')"
+			}
+		}
+		return columns[0] + " AS dim1, " + columns[1] + " AS dim2"
+	}
+	periodColumn := func(timeColumn string) string {
+		return "CASE WHEN " + timeColumn + " BETWEEN @current_from AND @current_to THEN 'current' ELSE 'previous' END AS period"
+	}
+	periodFilter := func(timeColumn string) string {
+		return "(" + timeColumn + " BETWEEN @current_from AND @current_to OR " + timeColumn + " BETWEEN @previous_from AND @previous_to)"
+	}
+	filters := func(alias string) string {
+		filter := ""
+		if query.ProfessionID > 0 {
+			filter += " AND " + alias + ".profession_id = @profession_id"
+		}
+		if query.ProductID > 0 {
+			filter += " AND " + alias + ".product_id = @product_id"
+		}
+		if query.FunnelID > 0 {
+			filter += " AND " + alias + ".funnel_id = @funnel_id"
+		}
+		return filter
+	}
+
+	var parts []string
+	if query.IncludeSessions {
+		userJoin := ""
+		if needsUser {
+			userJoin = " LEFT JOIN users u ON u.user_id = s.user_id"
+		}
+		parts = append(parts, `
+			SELECT `+dimColumns("session", `s."sessionStart"`)+`, `+periodColumn(`s."sessionStart"`)+`,
+				COUNT(*) AS sessions, 0 AS leads, 0 AS clients, 0 AS purchases, 0::NUMERIC AS revenue
+			FROM sessions s`+userJoin+`
+			WHERE `+periodFilter(`s."sessionStart"`)+filters("s")+`
+			GROUP BY 1, 2, 3`)
+	}
+	if query.IncludeEvents {
+		userJoin := ""
+		if needsUser {
+			userJoin = " LEFT JOIN users u ON u.user_id = e.user_id"
+		}
+		parts = append(parts, `
+			SELECT dim1, dim2, period,
+				0 AS sessions,
+				COUNT(*) FILTER (WHERE event_type = 'LEAD') AS leads,
+				COUNT(*) FILTER (WHERE first_purchase) AS clients,
+				COUNT(*) FILTER (WHERE valid_purchase) AS purchases,
+				COALESCE(SUM(CASE WHEN valid_purchase THEN value END), 0) AS revenue
+			FROM (
+				SELECT `+dimColumns("event", "e.event_time")+`, `+periodColumn("e.event_time")+`,
+					e.event_type,
+					`+purchaseValueSQL("e")+` AS value,
+					e.event_type = 'PURCHASE' AND `+purchaseValueSQL("e")+` IS NOT NULL AS valid_purchase,
+					e.event_type = 'PURCHASE' AND NOT EXISTS (
+						SELECT 1 FROM events p
+						WHERE p.user_id = e.user_id
+							AND p.event_type = 'PURCHASE'
+							AND p.event_time < e.event_time
+					) AS first_purchase
+				FROM events e
+				LEFT JOIN sessions s ON s.session_id = e.session_id`+userJoin+`
+				WHERE e.event_type IN ('LEAD', 'PURCHASE')
+					AND `+periodFilter("e.event_time")+filters("e")+`
+			) ev
+			GROUP BY 1, 2, 3`)
+	}
+	if len(parts) == 0 {
+		return nil, nil
+	}
+
+	labels := make([]string, 2)
+	for i := range labels {
+		labels[i] = "''"
+		if i < len(dims) && dims[i].label != "" {
+			column := fmt.Sprintf("f.dim%d", i+1)
+			labels[i] = "COALESCE(" + strings.ReplaceAll(dims[i].label, breakdownValueColumn, column) + ", '')"
+		}
+	}
+
+	sql := `
+		SELECT f.dim1, ` + labels[0] + ` AS label1, f.dim2, ` + labels[1] + ` AS label2, f.period,
+			SUM(f.sessions) AS sessions,
+			SUM(f.leads) AS leads,
+			SUM(f.clients) AS clients,
+			SUM(f.purchases) AS purchases,
+			SUM(f.revenue) AS revenue
+		FROM (` + strings.Join(parts, "\n\t\t\tUNION ALL") + `
+		) f
+		GROUP BY f.dim1, f.dim2, f.period`
+
+	params := map[string]interface{}{
+		"current_from":  query.CurrentFrom,
+		"current_to":    query.CurrentTo,
+		"previous_from": query.PreviousFrom,
+		"previous_to":   query.PreviousTo,
+		"profession_id": query.ProfessionID,
+		"product_id":    query.ProductID,
+		"funnel_id":     query.FunnelID,
+	}
+
+	var rows []MetricBreakdownRow
+	if err := r.db.Raw(sql, params).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("erro ao calcular breakdown de métricas: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,327 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// Granularidades de leitura dos rollups
+const (
+	MetricRollupHour = "hour"
+	MetricRollupDay  = "day"
+)
+
+// Nome do registro de estado dos rollups e chave do lock que impede atualizações concorrentes
+const (
+	metricRollupStateName = "metric_rollups"
+	metricRollupLockKey   = 7310037
+)
+
+// MetricRollupQuery define uma leitura dos rollups. From e To devem estar alinhados à hora.
+type MetricRollupQuery struct {
+	From        time.Time // início (inclusivo)
+	To          time.Time // fim (exclusivo)
+	Granularity string
+	// Filtros de dimensão (vazios/zero não filtram)
+	ProfessionIDs []int
+	ProductID     int
+	FunnelID      int
+	LandingPage   string
+	UtmSource     string
+	// GroupByProfession separa as linhas por profissão
+	GroupByProfession bool
+}
+
+// MetricRollupRow representa as métricas de um bucket (hora ou dia)
+type MetricRollupRow struct {
+	Bucket         time.Time `gorm:"column:bucket"`
+	ProfessionID   int       `gorm:"column:profession_id"`
+	ProfessionName string    `gorm:"column:profession_name"`
+	Sessions       int64     `gorm:"column:sessions"`
+	Leads          int64     `gorm:"column:leads"`
+	Clients        int64     `gorm:"column:clients"`
+	Purchases      int64     `gorm:"column:purchases"`
+	Revenue        float64   `gorm:"column:revenue"`
+}
+
+// MetricRollupRepository interface para atualização e leitura dos rollups de métricas
+type MetricRollupRepository interface {
+	// GetState retorna o estado dos rollups, ou nil se ainda não foram calculados
+	GetState() (*entities.MetricRollupState, error)
+	// Refresh recalcula os buckets por hora de [from, to) a partir de sessions e events, refaz os dias afetados
+	// e avança a marca d'água para to. Retorna false sem alterar nada se outra instância estiver atualizando.
+	Refresh(from, to, coveredFrom time.Time) (bool, error)
+	// GetSeries lê as métricas por bucket: dias completos vêm dos rollups diários, o restante até rolledUntil dos
+	// rollups por hora e o período posterior a rolledUntil diretamente de sessions e events
+	GetSeries(query MetricRollupQuery, rolledUntil time.Time) ([]MetricRollupRow, error)
+}
+
+type metricRollupRepository struct {
+	db *gorm.DB
+}
+
+func NewMetricRollupRepository(db *gorm.DB) MetricRollupRepository {
+	return &metricRollupRepository{db}
+}
+
+// metricRollupSourceSQL agrega sessions e events por hora e dimensão no intervalo [@source_from, @source_to).
+// Leads e compras herdam a landing page e a origem UTM da sessão do evento; clientes são as primeiras
+// compras de cada usuário.
+var metricRollupSourceSQL = `
+	SELECT bucket, profession_id, product_id, funnel_id, landing_page, utm_source,
+		SUM(sessions) AS sessions,
+		SUM(leads) AS leads,
+		SUM(clients) AS clients,
+		SUM(purchases) AS purchases,
+		SUM(revenue) AS revenue
+	FROM (
+		SELECT
+			date_trunc('hour', s."sessionStart") AS bucket,
+			COALESCE(s.profession_id, 0) AS profession_id,
+			COALESCE(s.product_id, 0) AS product_id,
+			COALESCE(s.funnel_id, 0) AS funnel_id,
+			COALESCE(s."landingPage", '') AS landing_page,
+			COALESCE(s."utmSource", '') AS utm_source,
+			COUNT(*) AS sessions,
+			0 AS leads, 0 AS clients, 0 AS purchases, 0::NUMERIC AS revenue
+		FROM sessions s
+		WHERE s."sessionStart" >= @source_from AND s."sessionStart" < @source_to
+		GROUP BY 1, 2, 3, 4, 5, 6
+		UNION ALL
+		SELECT
+			date_trunc('hour', ev.event_time) AS bucket,
+			ev.profession_id, ev.product_id, ev.funnel_id, ev.landing_page, ev.utm_source,
+			0 AS sessions,
+			COUNT(*) FILTER (WHERE ev.event_type = 'LEAD') AS leads,
+			COUNT(*) FILTER (WHERE ev.first_purchase) AS clients,
+			COUNT(*) FILTER (WHERE ev.valid_purchase) AS purchases,
+			COALESCE(SUM(CASE WHEN ev.valid_purchase THEN ev.value END), 0) AS revenue
+		FROM (
+			SELECT
+				e.event_time,
+				e.event_type,
+				COALESCE(e.profession_id, 0) AS profession_id,
+				COALESCE(e.product_id, 0) AS product_id,
+				COALESCE(e.funnel_id, 0) AS funnel_id,
+				COALESCE(s."landingPage", '') AS landing_page,
+				COALESCE(s."utmSource", '') AS utm_source,
+				` + purchaseValueSQL("e") + ` AS value,
+				e.event_type = 'PURCHASE' AND ` + purchaseValueSQL("e") + ` IS NOT NULL AS valid_purchase,
+				e.event_type = 'PURCHASE' AND NOT EXISTS (
+					SELECT 1 FROM events p
+					WHERE p.user_id = e.user_id
+						AND p.event_type = 'PURCHASE'
+						AND p.event_time < e.event_time
+				) AS first_purchase
+			FROM events e
+			LEFT JOIN sessions s ON s.session_id = e.session_id
+			WHERE e.event_time >= @source_from AND e.event_time < @source_to
+				AND e.event_type IN ('LEAD', 'PURCHASE')
+		) ev
+		GROUP BY 1, 2, 3, 4, 5, 6
+	) source
+	GROUP BY bucket, profession_id, product_id, funnel_id, landing_page, utm_source`
+
+func (r *metricRollupRepository) GetState() (*entities.MetricRollupState, error) {
+	var state entities.MetricRollupState
+	err := r.db.Where("name = ?", metricRollupStateName).First(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar estado dos rollups: %w", err)
+	}
+	return &state, nil
+}
+
+func (r *metricRollupRepository) Refresh(from, to, coveredFrom time.Time) (bool, error) {
+	loc := utils.GetBrasilLocation()
+	from = from.Truncate(time.Hour)
+	fromDay := from.In(loc)
+	dayFrom := time.Date(fromDay.Year(), fromDay.Month(), fromDay.Day(), 0, 0, 0, 0, loc)
+	toDay := to.In(loc)
+	dayTo := time.Date(toDay.Year(), toDay.Month(), toDay.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+
+	params := map[string]interface{}{
+		"source_from":  from,
+		"source_to":    to,
+		"hour_to":      to.Truncate(time.Hour).Add(time.Hour),
+		"day_from":     dayFrom,
+		"day_to":       dayTo,
+		"date_from":    dayFrom.Format("2006-01-02"),
+		"date_to":      dayTo.Format("2006-01-02"),
+		"name":         metricRollupStateName,
+		"covered_from": coveredFrom,
+	}
+
+	acquired := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", metricRollupLockKey).Scan(&acquired).Error; err != nil {
+			return fmt.Errorf("erro ao obter lock dos rollups: %w", err)
+		}
+		if !acquired {
+			return nil
+		}
+
+		if err := tx.Exec(`DELETE FROM metric_rollups_hourly WHERE bucket >= @source_from AND bucket < @hour_to`, params).Error; err != nil {
+			return fmt.Errorf("erro ao limpar rollups por hora: %w", err)
+		}
+		if err := tx.Exec(`
+			INSERT INTO metric_rollups_hourly
+				(bucket, profession_id, product_id, funnel_id, landing_page, utm_source, sessions, leads, clients, purchases, revenue)
+			`+metricRollupSourceSQL, params).Error; err != nil {
+			return fmt.Errorf("erro ao calcular rollups por hora: %w", err)
+		}
+
+		// Os dias tocados pela janela são refeitos a partir dos rollups por hora
+		if err := tx.Exec(`DELETE FROM metric_rollups_daily WHERE bucket >= CAST(@date_from AS DATE) AND bucket < CAST(@date_to AS DATE)`, params).Error; err != nil {
+			return fmt.Errorf("erro ao limpar rollups diários: %w", err)
+		}
+		if err := tx.Exec(`
+			INSERT INTO metric_rollups_daily
+				(bucket, profession_id, product_id, funnel_id, landing_page, utm_source, sessions, leads, clients, purchases, revenue)
+			SELECT (bucket AT TIME ZONE 'America/Sao_Paulo')::date, profession_id, product_id, funnel_id, landing_page, utm_source,
+				SUM(sessions), SUM(leads), SUM(clients), SUM(purchases), SUM(revenue)
+			FROM metric_rollups_hourly
+			WHERE bucket >= @day_from AND bucket < @day_to
+			GROUP BY 1, 2, 3, 4, 5, 6`, params).Error; err != nil {
+			return fmt.Errorf("erro ao calcular rollups diários: %w", err)
+		}
+
+		if err := tx.Exec(`
+			INSERT INTO metric_rollup_state (name, covered_from, watermark, updated_at)
+			VALUES (@name, @covered_from, @source_to, NOW())
+			ON CONFLICT (name) DO UPDATE SET
+				covered_from = LEAST(metric_rollup_state.covered_from, EXCLUDED.covered_from),
+				watermark = GREATEST(metric_rollup_state.watermark, EXCLUDED.watermark),
+				updated_at = NOW()`, params).Error; err != nil {
+			return fmt.Errorf("erro ao atualizar marca d'água dos rollups: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return acquired, nil
+}
+
+func (r *metricRollupRepository) GetSeries(query MetricRollupQuery, rolledUntil time.Time) ([]MetricRollupRow, error) {
+	loc := utils.GetBrasilLocation()
+	split := rolledUntil.Truncate(time.Hour)
+	if split.After(query.To) {
+		split = query.To
+	}
+	if split.Before(query.From) {
+		split = query.From
+	}
+
+	params := map[string]interface{}{
+		"from":           query.From,
+		"split":          split,
+		"to":             query.To,
+		"source_from":    split,
+		"source_to":      query.To,
+		"profession_ids": query.ProfessionIDs,
+		"product_id":     query.ProductID,
+		"funnel_id":      query.FunnelID,
+		"landing_page":   query.LandingPage,
+		"utm_source":     query.UtmSource,
+	}
+
+	var conditions []string
+	if len(query.ProfessionIDs) > 0 {
+		conditions = append(conditions, "profession_id IN @profession_ids")
+	}
+	if query.ProductID > 0 {
+		conditions = append(conditions, "product_id = @product_id")
+	}
+	if query.FunnelID > 0 {
+		conditions = append(conditions, "funnel_id = @funnel_id")
+	}
+	if query.LandingPage != "" {
+		conditions = append(conditions, "landing_page = @landing_page")
+	}
+	if query.UtmSource != "" {
+		conditions = append(conditions, "utm_source = @utm_source")
+	}
+	filter := ""
+	if len(conditions) > 0 {
+		filter = " AND " + strings.Join(conditions, " AND ")
+	}
+
+	hourBucket := "bucket"
+	if query.Granularity == MetricRollupDay {
+		hourBucket = "(date_trunc('day', bucket AT TIME ZONE 'America/Sao_Paulo') AT TIME ZONE 'America/Sao_Paulo')"
+	}
+	const metrics = "profession_id, sessions, leads, clients, purchases, revenue"
+
+	var parts []string
+	hourlyRanges := [][2]string{{"@from", "@split"}}
+	if query.Granularity == MetricRollupDay {
+		// Dias inteiros dentro de [from, split) vêm dos rollups diários; as bordas, dos rollups por hora
+		first := query.From.In(loc)
+		dayFrom := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, loc)
+		if dayFrom.Before(query.From) {
+			dayFrom = dayFrom.AddDate(0, 0, 1)
+		}
+		last := split.In(loc)
+		dayTo := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, loc)
+		if dayFrom.Before(dayTo) {
+			params["day_from"] = dayFrom
+			params["day_to"] = dayTo
+			params["date_from"] = dayFrom.Format("2006-01-02")
+			params["date_to"] = dayTo.Format("2006-01-02")
+			parts = append(parts, `
+				SELECT (bucket::timestamp AT TIME ZONE 'America/Sao_Paulo') AS bucket, `+metrics+`
+				FROM metric_rollups_daily
+				WHERE bucket >= CAST(@date_from AS DATE) AND bucket < CAST(@date_to AS DATE)`+filter)
+			hourlyRanges = [][2]string{{"@from", "@day_from"}, {"@day_to", "@split"}}
+		}
+	}
+	for _, hourly := range hourlyRanges {
+		parts = append(parts, `
+			SELECT `+hourBucket+` AS bucket, `+metrics+`
+			FROM metric_rollups_hourly
+			WHERE bucket >= `+hourly[0]+` AND bucket < `+hourly[1]+filter)
+	}
+	if split.Before(query.To) {
+		parts = append(parts, `
+			SELECT `+hourBucket+` AS bucket, `+metrics+`
+			FROM (`+metricRollupSourceSQL+`) raw
+			WHERE TRUE`+filter)
+	}
+
+	groupColumns := "u.bucket"
+	selectProfession := "0 AS profession_id, '' AS profession_name"
+	join := ""
+	if query.GroupByProfession {
+		groupColumns = "u.bucket, u.profession_id, prof.profession_name"
+		selectProfession = "u.profession_id, COALESCE(prof.profession_name, 'Profissão ' || u.profession_id) AS profession_name"
+		join = "LEFT JOIN professions prof ON prof.profession_id = u.profession_id"
+	}
+
+	sql := `
+		SELECT u.bucket, ` + selectProfession + `,
+			SUM(u.sessions) AS sessions,
+			SUM(u.leads) AS leads,
+			SUM(u.clients) AS clients,
+			SUM(u.purchases) AS purchases,
+			SUM(u.revenue) AS revenue
+		FROM (` + strings.Join(parts, "\n\t\t\tUNION ALL") + `
+		) u
+		` + join + `
+		GROUP BY ` + groupColumns + `
+		ORDER BY u.bucket`
+
+	var rows []MetricRollupRow
+	if err := r.db.Raw(sql, params).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("erro ao ler rollups de métricas: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,208 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCursor indica um cursor de paginação malformado ou incompatível com a consulta
+var ErrInvalidCursor = errors.New("cursor de paginação inválido")
+
+// TotalMode define como o total de registros de uma listagem é calculado
+type TotalMode string
+
+// Modos de cálculo do total
+const (
+	// TotalExact executa um COUNT(*) com os mesmos filtros da listagem
+	TotalExact TotalMode = "exact"
+	// TotalEstimated usa a estimativa de linhas do planejador do Postgres (EXPLAIN), sem varrer a tabela
+	TotalEstimated TotalMode = "estimated"
+	// TotalNone não calcula o total
+	TotalNone TotalMode = "none"
+)
+
+// ParseTotalMode valida o modo de total informado; vazio retorna o padrão
+func ParseTotalMode(raw string, fallback TotalMode) (TotalMode, error) {
+	switch mode := TotalMode(strings.ToLower(strings.TrimSpace(raw))); mode {
+	case "":
+		return fallback, nil
+	case TotalExact, TotalEstimated, TotalNone:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("count inválido: %s (use exact, estimated ou none)", raw)
+	}
+}
+
+// PageCursor é a chave de ordenação do último registro entregue, usada para buscar a página seguinte
+type PageCursor struct {
+	Time time.Time `json:"t"`
+	ID   string    `json:"id"`
+	Desc bool      `json:"d"`
+}
+
+// EncodeCursor serializa o cursor em um token opaco (JSON em base64 URL-safe)
+func EncodeCursor(cursor PageCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor interpreta um token gerado por EncodeCursor
+func DecodeCursor(token string) (*PageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: codificação inválida", ErrInvalidCursor)
+	}
+	var cursor PageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: conteúdo inválido", ErrInvalidCursor)
+	}
+	if cursor.Time.IsZero() || cursor.ID == "" {
+		return nil, fmt.Errorf("%w: posição incompleta", ErrInvalidCursor)
+	}
+	return &cursor, nil
+}
+
+// PageRequest descreve a paginação de uma listagem. No modo offset usa Page e Limit; no modo keyset
+// (Keyset) busca os registros após Cursor na ordem (tempo, id), sem OFFSET.
+type PageRequest struct {
+	Page   int
+	Limit  int
+	Keyset bool
+	// Cursor é a posição de início no modo keyset (nil na primeira página)
+	Cursor *PageCursor
+	// Desc é a direção da ordenação no modo keyset
+	Desc  bool
+	Total TotalMode
+}
+
+// NewKeysetPageRequest cria uma requisição no modo keyset, validando a direção do cursor
+func NewKeysetPageRequest(limit int, token string, desc bool, total TotalMode) (PageRequest, error) {
+	page := PageRequest{Page: 1, Limit: limit, Keyset: true, Desc: desc, Total: total}
+	if token != "" {
+		cursor, err := DecodeCursor(token)
+		if err != nil {
+			return PageRequest{}, err
+		}
+		if cursor.Desc != desc {
+			return PageRequest{}, fmt.Errorf("%w: o cursor foi gerado com outra direção de ordenação", ErrInvalidCursor)
+		}
+		page.Cursor = cursor
+	}
+	return page, nil
+}
+
+// IsFirst indica se é a primeira página da listagem
+func (p PageRequest) IsFirst() bool {
+	if p.Keyset {
+		return p.Cursor == nil
+	}
+	return p.Page <= 1
+}
+
+// Key identifica a página em chaves de cache
+func (p PageRequest) Key() string {
+	if !p.Keyset {
+		return fmt.Sprintf("offset:%d:%d:%s", p.Page, p.Limit, p.Total)
+	}
+	position := ""
+	if p.Cursor != nil {
+		position = EncodeCursor(*p.Cursor)
+	}
+	return fmt.Sprintf("keyset:%d:%v:%s:%s", p.Limit, p.Desc, position, p.Total)
+}
+
+// PageResult traz os metadados de paginação de uma listagem
+type PageResult struct {
+	// Total é o número de registros (estimado em TotalEstimated); -1 em TotalNone
+	Total      int64
+	TotalMode  TotalMode
+	HasMore    bool
+	NextCursor string
+}
+
+// apply aplica ordenação e paginação à consulta. No modo keyset a ordenação é sempre (timeColumn,
+// idColumn) e busca um registro a mais para saber se há próxima página; orderBy vale só no modo offset.
+func (p PageRequest) apply(query *gorm.DB, orderBy, timeColumn, idColumn string) *gorm.DB {
+	if !p.Keyset {
+		if orderBy != "" {
+			query = query.Order(orderBy)
+		}
+		return query.Offset((p.Page - 1) * p.Limit).Limit(p.Limit)
+	}
+
+	direction, comparison := "ASC", ">"
+	if p.Desc {
+		direction, comparison = "DESC", "<"
+	}
+	if p.Cursor != nil {
+		query = query.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", timeColumn, idColumn, comparison), p.Cursor.Time, p.Cursor.ID)
+	}
+	return query.
+		Order(fmt.Sprintf("%s %s, %s %s", timeColumn, direction, idColumn, direction)).
+		Limit(p.Limit + 1)
+}
+
+// trimKeysetPage descarta o registro excedente buscado pelo modo keyset e gera o cursor da próxima página
+func trimKeysetPage[T any](items []T, page PageRequest, result *PageResult, position func(T) (time.Time, string)) []T {
+	if !page.Keyset || len(items) <= page.Limit {
+		return items
+	}
+	items = items[:page.Limit]
+	lastTime, lastID := position(items[len(items)-1])
+	result.HasMore = true
+	result.NextCursor = EncodeCursor(PageCursor{Time: lastTime, ID: lastID, Desc: page.Desc})
+	return items
+}
+
+// countTotal calcula o total da consulta (antes de ordenação e paginação) conforme o modo pedido
+func countTotal(ctx context.Context, query *gorm.DB, mode TotalMode) (int64, error) {
+	switch mode {
+	case TotalNone:
+		return -1, nil
+	case TotalEstimated:
+		return estimateRows(ctx, query)
+	default:
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			return 0, err
+		}
+		return total, nil
+	}
+}
+
+// estimateRows obtém a estimativa de linhas do planejador do Postgres (EXPLAIN, sem executar a consulta).
+// A estimativa vem das estatísticas da tabela e pode divergir do total real, principalmente com filtros combinados.
+func estimateRows(ctx context.Context, query *gorm.DB) (int64, error) {
+	var rows []map[string]interface{}
+	stmt := query.Session(&gorm.Session{DryRun: true}).Find(&rows).Statement
+	if stmt.Error != nil {
+		return 0, stmt.Error
+	}
+
+	sqlDB, err := query.DB()
+	if err != nil {
+		return 0, err
+	}
+
+	var plan string
+	if err := sqlDB.QueryRowContext(ctx, "EXPLAIN (FORMAT JSON) "+stmt.SQL.String(), stmt.Vars...).Scan(&plan); err != nil {
+		return 0, fmt.Errorf("erro ao estimar total: %w", err)
+	}
+
+	var explain []struct {
+		Plan struct {
+			PlanRows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(plan), &explain); err != nil || len(explain) == 0 {
+		return 0, fmt.Errorf("erro ao interpretar a estimativa do total: %v", err)
+	}
+	return int64(explain[0].Plan.PlanRows), nil
+}
@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// PropensitySample representa um lead captado em um ciclo, com seus sinais e se comprou na janela de vendas
+type PropensitySample struct {
+	UserID        string  `json:"user_id" gorm:"column:user_id"`
+	Fullname      string  `json:"fullname" gorm:"column:fullname"`
+	Email         string  `json:"email" gorm:"column:email"`
+	Phone         string  `json:"phone" gorm:"column:phone"`
+	Faixa         string  `json:"faixa" gorm:"column:faixa"`
+	Channel       string  `json:"channel" gorm:"column:channel"`
+	Device        string  `json:"device" gorm:"column:device"`
+	Region        string  `json:"region" gorm:"column:region"`
+	SessionCount  int64   `json:"session_count" gorm:"column:session_count"`
+	TotalDuration float64 `json:"total_duration" gorm:"column:total_duration"`
+	Purchased     bool    `json:"purchased" gorm:"column:purchased"`
+	// Answers contém o valor respondido por question_id na pesquisa do ciclo
+	Answers map[string]string `json:"-" gorm:"-"`
+}
+
+// PropensityRepository interface para dados de treino e persistência dos modelos de propensão
+type PropensityRepository interface {
+	// GetCycleSamples retorna os leads captados no ciclo. Sessões são consideradas até engagementCutoff
+	// para que o treino use apenas o que era conhecido antes das vendas.
+	GetCycleSamples(cycle utils.WebinarCycleDates, professionID, funnelID int, engagementCutoff time.Time) ([]PropensitySample, error)
+
+	ListModels(professionID int) ([]entities.PropensityModel, error)
+	GetModel(modelID int64) (*entities.PropensityModel, error)
+	// GetActiveModel retorna o modelo ativo da profissão/funil, ou nil se não houver
+	GetActiveModel(professionID, funnelID int) (*entities.PropensityModel, error)
+	CreateModel(model *entities.PropensityModel) error
+}
+
+type propensityRepository struct {
+	db *gorm.DB
+}
+
+func NewPropensityRepository(db *gorm.DB) PropensityRepository {
+	return &propensityRepository{db}
+}
+
+func (r *propensityRepository) GetCycleSamples(cycle utils.WebinarCycleDates, professionID, funnelID int, engagementCutoff time.Time) ([]PropensitySample, error) {
+	var rows []struct {
+		PropensitySample
+		AnswersJSON string `gorm:"column:answers"`
+	}
+
+	funnelFilter := ""
+	if funnelID > 0 {
+		funnelFilter = " AND e.funnel_id = @funnel_id"
+	}
+
+	query := `
+		WITH cohort AS (
+			SELECT DISTINCT e.user_id
+			FROM events e
+			WHERE e.event_type IN ('LEAD', 'PESQUISA_LEAD')
+			AND e.event_time BETWEEN @pesquisa_inicio AND @pesquisa_fim
+			AND e.profession_id = @profession_id` + funnelFilter + `
+		)
+		SELECT
+			u.user_id,
+			COALESCE(u.fullname, '') AS fullname,
+			COALESCE(u.email, '') AS email,
+			COALESCE(u.phone, '') AS phone,
+			COALESCE(sv.faixa, '') AS faixa,
+			COALESCE(u."initialMarketingChannel", '') AS channel,
+			COALESCE(u."initialDeviceType", '') AS device,
+			COALESCE(u."initialRegion", '') AS region,
+			COALESCE(s.session_count, 0) AS session_count,
+			COALESCE(s.total_duration, 0) AS total_duration,
+			COALESCE(sv.answers::text, '{}') AS answers,
+			EXISTS (
+				SELECT 1 FROM events e
+				WHERE e.user_id = c.user_id
+				AND e.event_type = 'PURCHASE'
+				AND e.event_time BETWEEN @venda_inicio AND @venda_fim
+				AND e.profession_id = @profession_id` + funnelFilter + `
+			) AS purchased
+		FROM cohort c
+		JOIN users u ON u.user_id = c.user_id
+		LEFT JOIN LATERAL (
+			SELECT COUNT(*) AS session_count, SUM(COALESCE(sessions.duration, 0)) AS total_duration
+			FROM sessions
+			WHERE sessions.user_id = c.user_id
+			AND sessions."sessionStart" <= @cutoff
+		) s ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT
+				sr.faixa,
+				(SELECT json_object_agg(sa.question_id, sa.value)
+				 FROM survey_answers sa
+				 WHERE sa.survey_response_id = sr.id) AS answers
+			FROM survey_responses sr
+			JOIN events e ON sr.event_id = e.event_id
+			WHERE e.user_id = c.user_id
+			AND e.event_type = 'PESQUISA_LEAD'
+			AND e.event_time BETWEEN @pesquisa_inicio AND @pesquisa_fim
+			ORDER BY e.event_time DESC
+			LIMIT 1
+		) sv ON TRUE
+		ORDER BY u.user_id
+	`
+
+	args := map[string]interface{}{
+		"pesquisa_inicio": cycle.PesquisaInicio,
+		"pesquisa_fim":    cycle.PesquisaFim,
+		"venda_inicio":    cycle.VendaInicio,
+		"venda_fim":       cycle.VendaFim,
+		"profession_id":   professionID,
+		"funnel_id":       funnelID,
+		"cutoff":          engagementCutoff,
+	}
+
+	if err := r.db.Raw(query, args).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar leads do ciclo: %w", err)
+	}
+
+	samples := make([]PropensitySample, len(rows))
+	for i, row := range rows {
+		samples[i] = row.PropensitySample
+		samples[i].Answers = map[string]string{}
+		if err := json.Unmarshal([]byte(row.AnswersJSON), &samples[i].Answers); err != nil {
+			return nil, fmt.Errorf("erro ao ler respostas do lead %s: %w", row.UserID, err)
+		}
+	}
+	return samples, nil
+}
+
+func (r *propensityRepository) ListModels(professionID int) ([]entities.PropensityModel, error) {
+	var models []entities.PropensityModel
+	query := r.db.Order("trained_at DESC")
+	if professionID > 0 {
+		query = query.Where("profession_id = ?", professionID)
+	}
+	if err := query.Find(&models).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar modelos de propensão: %w", err)
+	}
+	return models, nil
+}
+
+func (r *propensityRepository) GetModel(modelID int64) (*entities.PropensityModel, error) {
+	var model entities.PropensityModel
+	if err := r.db.First(&model, "model_id = ?", modelID).Error; err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+func (r *propensityRepository) GetActiveModel(professionID, funnelID int) (*entities.PropensityModel, error) {
+	var model entities.PropensityModel
+
+	query := r.db.Where("is_active = ? AND profession_id = ?", true, professionID)
+	if funnelID > 0 {
+		query = query.Where("funnel_id = ?", funnelID)
+	} else {
+		query = query.Where("funnel_id IS NULL")
+	}
+
+	err := query.First(&model).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar modelo ativo: %w", err)
+	}
+	return &model, nil
+}
+
+// CreateModel salva o modelo; quando ativo, desativa o modelo anterior da mesma profissão/funil
+func (r *propensityRepository) CreateModel(model *entities.PropensityModel) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if model.IsActive {
+			query := tx.Model(&entities.PropensityModel{}).
+				Where("is_active = ? AND profession_id = ?", true, model.ProfessionID)
+			if model.FunnelID != nil {
+				query = query.Where("funnel_id = ?", *model.FunnelID)
+			} else {
+				query = query.Where("funnel_id IS NULL")
+			}
+			if err := query.Update("is_active", false).Error; err != nil {
+				return fmt.Errorf("erro ao desativar modelo anterior: %w", err)
+			}
+		}
+
+		if err := tx.Create(model).Error; err != nil {
+			return fmt.Errorf("erro ao salvar modelo de propensão: %w", err)
+		}
+		return nil
+	})
+}
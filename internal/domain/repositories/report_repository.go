@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"gorm.io/gorm"
+)
+
+// ReportRepository define a persistência dos relatórios agendados
+type ReportRepository interface {
+	ListReports() ([]entities.ScheduledReport, error)
+	GetReport(reportID int64) (*entities.ScheduledReport, error)
+	CreateReport(report *entities.ScheduledReport) error
+	UpdateReport(report *entities.ScheduledReport) error
+	DeleteReport(reportID int64) error
+
+	// ListDueReports retorna os relatórios ativos com envio vencido em now
+	ListDueReports(now time.Time) ([]entities.ScheduledReport, error)
+	// ClaimRun avança o próximo envio de scheduledFor para next; retorna false se outra instância já avançou
+	ClaimRun(reportID int64, scheduledFor time.Time, next *time.Time) (bool, error)
+	// RecordRun registra o resultado de um envio
+	RecordRun(reportID int64, at time.Time, status, errMsg string) error
+}
+
+type reportRepository struct {
+	db *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportRepository{db: db}
+}
+
+func (r *reportRepository) ListReports() ([]entities.ScheduledReport, error) {
+	var reports []entities.ScheduledReport
+	if err := r.db.Order("name, report_id").Find(&reports).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar relatórios: %w", err)
+	}
+	return reports, nil
+}
+
+func (r *reportRepository) GetReport(reportID int64) (*entities.ScheduledReport, error) {
+	var report entities.ScheduledReport
+	if err := r.db.First(&report, "report_id = ?", reportID).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *reportRepository) CreateReport(report *entities.ScheduledReport) error {
+	if err := r.db.Create(report).Error; err != nil {
+		return fmt.Errorf("erro ao criar relatório: %w", err)
+	}
+	return nil
+}
+
+// UpdateReport grava a configuração do relatório; o resultado do último envio é mantido
+func (r *reportRepository) UpdateReport(report *entities.ScheduledReport) error {
+	if err := r.db.Model(report).
+		Select("name", "schedule", "period", "filters", "recipients", "enabled", "next_run_at", "updated_at").
+		Updates(report).Error; err != nil {
+		return fmt.Errorf("erro ao atualizar relatório: %w", err)
+	}
+	return nil
+}
+
+func (r *reportRepository) DeleteReport(reportID int64) error {
+	result := r.db.Where("report_id = ?", reportID).Delete(&entities.ScheduledReport{})
+	if result.Error != nil {
+		return fmt.Errorf("erro ao remover relatório: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *reportRepository) ListDueReports(now time.Time) ([]entities.ScheduledReport, error) {
+	var reports []entities.ScheduledReport
+	if err := r.db.Where("enabled AND next_run_at <= ?", now).Order("next_run_at").Find(&reports).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar relatórios a enviar: %w", err)
+	}
+	return reports, nil
+}
+
+func (r *reportRepository) ClaimRun(reportID int64, scheduledFor time.Time, next *time.Time) (bool, error) {
+	result := r.db.Model(&entities.ScheduledReport{}).
+		Where("report_id = ? AND enabled AND next_run_at = ?", reportID, scheduledFor).
+		Update("next_run_at", next)
+	if result.Error != nil {
+		return false, fmt.Errorf("erro ao reservar envio do relatório: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *reportRepository) RecordRun(reportID int64, at time.Time, status, errMsg string) error {
+	if err := r.db.Model(&entities.ScheduledReport{}).
+		Where("report_id = ?", reportID).
+		Updates(map[string]interface{}{
+			"last_run_at": at,
+			"last_status": status,
+			"last_error":  errMsg,
+		}).Error; err != nil {
+		return fmt.Errorf("erro ao registrar envio do relatório: %w", err)
+	}
+	return nil
+}
@@ -72,6 +72,12 @@ type RevenueComparisonData struct {
 
 	// NOVO: Resumo por profissão (apenas para dados gerais)
 	ProfessionSummary []ProfessionSummary `json:"profession_summary,omitempty"`
+
+	// Série agrupada conforme time_frame (dia, semana ISO, mês ou ano)
+	ChartData []RevenuePeriodData `json:"chart_data,omitempty"`
+
+	// Séries atual e de comparação alinhadas ponto a ponto
+	AlignedChartData []RevenueAlignedPeriod `json:"aligned_chart_data,omitempty"`
 }
 
 // PreviousRevenueData representa dados detalhados do período anterior
@@ -80,6 +86,27 @@ type PreviousRevenueData struct {
 	PurchasesByDay map[string]int64      `json:"purchases_by_day"`
 	RevenueByDay   map[string]float64    `json:"revenue_by_day"`
 	HourlyData     *HourlyRevenueMetrics `json:"hourly_data,omitempty"`
+	ChartData      []RevenuePeriodData   `json:"chart_data,omitempty"`
+}
+
+// RevenueAlignedPeriod alinha o i-ésimo bucket do período atual ao i-ésimo bucket do período de comparação.
+// Current ou Previous é nil quando um dos períodos tem menos buckets.
+type RevenueAlignedPeriod struct {
+	Index           int                `json:"index"`
+	Current         *RevenuePeriodData `json:"current"`
+	Previous        *RevenuePeriodData `json:"previous"`
+	LeadsChange     float64            `json:"leads_change"`
+	PurchasesChange float64            `json:"purchases_change"`
+	RevenueChange   float64            `json:"revenue_change"`
+}
+
+// RevenuePeriodData representa leads, compras e faturamento de um bucket da série (dia, semana, mês ou ano)
+type RevenuePeriodData struct {
+	Period        string  `json:"period"`
+	DisplayPeriod string  `json:"display_period"`
+	Leads         int64   `json:"leads"`
+	Purchases     int64   `json:"purchases"`
+	Revenue       float64 `json:"revenue"`
 }
 
 // ProfessionResult representa dados de uma profissão para comparação
@@ -0,0 +1,324 @@
+package repositories
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strings"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"gorm.io/gorm"
+)
+
+// Atributos filtráveis do usuário (seg_u) e das sessões (seg_s) nas condições de segmento
+var segmentUserFields = map[string]string{
+	"is_client":         `seg_u."isClient"`,
+	"is_identified":     `seg_u."isIdentified"`,
+	"fullname":          "seg_u.fullname",
+	"email":             "seg_u.email",
+	"phone":             "seg_u.phone",
+	"device_type":       `seg_u."initialDeviceType"`,
+	"browser":           `seg_u."initialBrowser"`,
+	"platform":          `seg_u."initialPlatform"`,
+	"country":           `seg_u."initialCountry"`,
+	"state":             `seg_u."initialRegion"`,
+	"city":              `seg_u."initialCity"`,
+	"landing_page":      `seg_u."initialLandingPage"`,
+	"marketing_channel": `seg_u."initialMarketingChannel"`,
+	"referrer_domain":   `seg_u."initialReferrerDomain"`,
+	"utm_source":        `seg_u."initialUtmSource"`,
+	"utm_medium":        `seg_u."initialUtmMedium"`,
+	"utm_campaign":      `seg_u."initialUtmCampaign"`,
+	"utm_content":       `seg_u."initialUtmContent"`,
+	"utm_term":          `seg_u."initialUtmTerm"`,
+}
+
+var segmentSessionFields = map[string]string{
+	"landing_page":      `seg_s."landingPage"`,
+	"utm_source":        `seg_s."utmSource"`,
+	"utm_medium":        `seg_s."utmMedium"`,
+	"utm_campaign":      `seg_s."utmCampaign"`,
+	"utm_content":       `seg_s."utmContent"`,
+	"utm_term":          `seg_s."utmTerm"`,
+	"marketing_channel": `seg_s."marketingChannel"`,
+	"country":           "seg_s.country",
+	"state":             "seg_s.state",
+	"city":              "seg_s.city",
+	"profession_id":     "seg_s.profession_id",
+	"product_id":        "seg_s.product_id",
+	"funnel_id":         "seg_s.funnel_id",
+}
+
+var segmentEventFields = map[string]string{
+	"profession_id": "seg_e.profession_id",
+	"product_id":    "seg_e.product_id",
+	"funnel_id":     "seg_e.funnel_id",
+}
+
+// SegmentFilter restringe uma consulta aos usuários de um segmento. O valor zero não filtra.
+type SegmentFilter struct {
+	SegmentID int64
+	usersSQL  string
+	args      []interface{}
+}
+
+// NewSegmentFilter traduz a definição do segmento em uma subconsulta parametrizada dos user_id membros
+func NewSegmentFilter(segmentID int64, definition entities.SegmentDefinition) (SegmentFilter, error) {
+	if len(definition.Conditions) == 0 {
+		return SegmentFilter{}, fmt.Errorf("o segmento precisa de ao menos uma condição")
+	}
+
+	joiner := " AND "
+	switch definition.Match {
+	case "", entities.SegmentMatchAll:
+	case entities.SegmentMatchAny:
+		joiner = " OR "
+	default:
+		return SegmentFilter{}, fmt.Errorf("match inválido: %s (use all ou any)", definition.Match)
+	}
+
+	var conditions []string
+	var args []interface{}
+	for i, condition := range definition.Conditions {
+		sql, conditionArgs, err := segmentConditionSQL(condition)
+		if err != nil {
+			return SegmentFilter{}, fmt.Errorf("condição %d: %w", i+1, err)
+		}
+		if condition.Negate {
+			sql = "NOT " + sql
+		}
+		conditions = append(conditions, sql)
+		args = append(args, conditionArgs...)
+	}
+
+	return SegmentFilter{
+		SegmentID: segmentID,
+		usersSQL:  "SELECT seg_u.user_id FROM users seg_u WHERE (" + strings.Join(conditions, joiner) + ")",
+		args:      args,
+	}, nil
+}
+
+// IsSet indica se o filtro restringe a consulta a um segmento
+func (f SegmentFilter) IsSet() bool {
+	return f.usersSQL != ""
+}
+
+// Key identifica o filtro em chaves de cache; muda quando a definição do segmento muda
+func (f SegmentFilter) Key() string {
+	if !f.IsSet() {
+		return ""
+	}
+	return fmt.Sprintf("segment=%d:%x", f.SegmentID, sha1.Sum([]byte(fmt.Sprintf("%s|%v", f.usersSQL, f.args))))
+}
+
+// Condition retorna a condição "<userColumn> IN (membros do segmento)" e seus argumentos posicionais
+func (f SegmentFilter) Condition(userColumn string) (string, []interface{}) {
+	return userColumn + " IN (" + f.usersSQL + ")", f.args
+}
+
+// Apply restringe a consulta aos membros do segmento pela coluna de usuário informada
+func (f SegmentFilter) Apply(query *gorm.DB, userColumn string) *gorm.DB {
+	if !f.IsSet() {
+		return query
+	}
+	condition, args := f.Condition(userColumn)
+	return query.Where(condition, args...)
+}
+
+// segmentConditionSQL traduz uma condição do segmento, sem a negação, para SQL correlacionado a seg_u
+func segmentConditionSQL(condition entities.SegmentCondition) (string, []interface{}, error) {
+	switch condition.Source {
+	case entities.SegmentSourceUser:
+		column, ok := segmentUserFields[condition.Field]
+		if !ok {
+			return "", nil, fmt.Errorf("campo de usuário desconhecido: %s", condition.Field)
+		}
+		return segmentFieldSQL(column, condition.Operator, condition.Value)
+
+	case entities.SegmentSourceSession:
+		column, ok := segmentSessionFields[condition.Field]
+		if !ok {
+			return "", nil, fmt.Errorf("campo de sessão desconhecido: %s", condition.Field)
+		}
+		predicate, args, err := segmentFieldSQL(column, condition.Operator, condition.Value)
+		if err != nil {
+			return "", nil, err
+		}
+		return "EXISTS (SELECT 1 FROM sessions seg_s WHERE seg_s.user_id = seg_u.user_id AND " + predicate + ")", args, nil
+
+	case entities.SegmentSourceEvent:
+		sql := "EXISTS (SELECT 1 FROM events seg_e WHERE seg_e.user_id = seg_u.user_id"
+		var args []interface{}
+		if condition.EventType != "" {
+			sql += " AND seg_e.event_type = ?"
+			args = append(args, strings.ToUpper(condition.EventType))
+		}
+		if condition.WithinDays < 0 {
+			return "", nil, fmt.Errorf("within_days não pode ser negativo")
+		}
+		if condition.WithinDays > 0 {
+			sql += " AND seg_e.event_time >= NOW() - (? * INTERVAL '1 day')"
+			args = append(args, condition.WithinDays)
+		}
+		if condition.Field != "" {
+			column, ok := segmentEventFields[condition.Field]
+			if !ok {
+				return "", nil, fmt.Errorf("campo de evento desconhecido: %s", condition.Field)
+			}
+			predicate, fieldArgs, err := segmentFieldSQL(column, condition.Operator, condition.Value)
+			if err != nil {
+				return "", nil, err
+			}
+			sql += " AND " + predicate
+			args = append(args, fieldArgs...)
+		}
+		return sql + ")", args, nil
+
+	case entities.SegmentSourceSurvey:
+		if condition.SurveyID <= 0 {
+			return "", nil, fmt.Errorf("informe o survey_id")
+		}
+		if condition.Answer != "" && condition.QuestionID == "" {
+			return "", nil, fmt.Errorf("answer requer question_id")
+		}
+		sql := `EXISTS (
+			SELECT 1 FROM survey_responses seg_sr
+			JOIN events seg_e ON seg_e.event_id = seg_sr.event_id
+			WHERE seg_e.user_id = seg_u.user_id AND seg_sr.survey_id = ?`
+		args := []interface{}{condition.SurveyID}
+		if condition.Faixa != "" {
+			sql += " AND seg_sr.faixa = ?"
+			args = append(args, condition.Faixa)
+		}
+		if condition.WithinDays < 0 {
+			return "", nil, fmt.Errorf("within_days não pode ser negativo")
+		}
+		if condition.WithinDays > 0 {
+			sql += " AND seg_sr.created_at >= NOW() - (? * INTERVAL '1 day')"
+			args = append(args, condition.WithinDays)
+		}
+		if condition.QuestionID != "" {
+			sql += " AND EXISTS (SELECT 1 FROM survey_answers seg_sa WHERE seg_sa.survey_response_id = seg_sr.id AND seg_sa.question_id = ?"
+			args = append(args, condition.QuestionID)
+			if condition.Answer != "" {
+				sql += " AND seg_sa.value = ?"
+				args = append(args, condition.Answer)
+			}
+			sql += ")"
+		}
+		return sql + ")", args, nil
+
+	default:
+		return "", nil, fmt.Errorf("fonte desconhecida: %s (use user, session, event ou survey)", condition.Source)
+	}
+}
+
+// segmentFieldSQL compara um atributo como texto; nulos equivalem a vazio
+func segmentFieldSQL(column, operator, value string) (string, []interface{}, error) {
+	expr := "COALESCE(" + column + "::text, '')"
+	switch operator {
+	case "", "equals":
+		return expr + " = ?", []interface{}{value}, nil
+	case "not_equals":
+		return expr + " <> ?", []interface{}{value}, nil
+	case "contains":
+		return expr + " ILIKE ?", []interface{}{"%" + escapeLikePattern(value) + "%"}, nil
+	case "not_contains":
+		return expr + " NOT ILIKE ?", []interface{}{"%" + escapeLikePattern(value) + "%"}, nil
+	case "is_empty":
+		return expr + " = ''", nil, nil
+	case "is_not_empty":
+		return expr + " <> ''", nil, nil
+	default:
+		return "", nil, fmt.Errorf("operador desconhecido: %s", operator)
+	}
+}
+
+// escapeLikePattern escapa os curingas de LIKE em um valor literal
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// SegmentRepository interface para segmentos salvos de usuários
+type SegmentRepository interface {
+	ListSegments() ([]entities.Segment, error)
+	GetSegment(segmentID int64) (*entities.Segment, error)
+	CreateSegment(segment *entities.Segment) error
+	UpdateSegment(segment *entities.Segment) error
+	DeleteSegment(segmentID int64) error
+
+	// CountMembers conta os usuários do segmento no momento da consulta
+	CountMembers(filter SegmentFilter) (int64, error)
+	// ListMembers lista os usuários do segmento, dos mais recentes para os mais antigos
+	ListMembers(filter SegmentFilter, page, limit int) ([]entities.User, error)
+}
+
+type segmentRepository struct {
+	db *gorm.DB
+}
+
+func NewSegmentRepository(db *gorm.DB) SegmentRepository {
+	return &segmentRepository{db}
+}
+
+func (r *segmentRepository) ListSegments() ([]entities.Segment, error) {
+	var segments []entities.Segment
+	if err := r.db.Order("name").Find(&segments).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar segmentos: %w", err)
+	}
+	return segments, nil
+}
+
+func (r *segmentRepository) GetSegment(segmentID int64) (*entities.Segment, error) {
+	var segment entities.Segment
+	if err := r.db.First(&segment, "segment_id = ?", segmentID).Error; err != nil {
+		return nil, err
+	}
+	return &segment, nil
+}
+
+func (r *segmentRepository) CreateSegment(segment *entities.Segment) error {
+	if err := r.db.Create(segment).Error; err != nil {
+		return fmt.Errorf("erro ao criar segmento: %w", err)
+	}
+	return nil
+}
+
+func (r *segmentRepository) UpdateSegment(segment *entities.Segment) error {
+	if err := r.db.Omit("CreatedAt").Save(segment).Error; err != nil {
+		return fmt.Errorf("erro ao atualizar segmento: %w", err)
+	}
+	return nil
+}
+
+func (r *segmentRepository) DeleteSegment(segmentID int64) error {
+	result := r.db.Where("segment_id = ?", segmentID).Delete(&entities.Segment{})
+	if result.Error != nil {
+		return fmt.Errorf("erro ao remover segmento: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *segmentRepository) CountMembers(filter SegmentFilter) (int64, error) {
+	var count int64
+	if err := filter.Apply(r.db.Model(&entities.User{}), "user_id").Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("erro ao contar membros do segmento: %w", err)
+	}
+	return count, nil
+}
+
+func (r *segmentRepository) ListMembers(filter SegmentFilter, page, limit int) ([]entities.User, error) {
+	var users []entities.User
+	query := r.db.Model(&entities.User{}).
+		Select(`user_id, fullname, email, phone, created_at, "isIdentified", "isClient"`)
+	if err := filter.Apply(query, "user_id").
+		Order("created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar membros do segmento: %w", err)
+	}
+	return users, nil
+}
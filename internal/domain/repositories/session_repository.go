@@ -14,10 +14,11 @@ import (
 )
 
 type ISessionRepository interface {
-	GetSessions(ctx context.Context, page, limit int, orderBy string, from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string) ([]entities.Session, int64, error)
+	GetSessions(ctx context.Context, page PageRequest, orderBy string, from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string, segment SegmentFilter) ([]entities.Session, PageResult, error)
+	ExportSessions(ctx context.Context, columns []ExportColumn, orderBy string, from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string, segment SegmentFilter, fn ExportRowFunc) error
 	FindSessionByID(ctx context.Context, id string) (*entities.Session, error)
-	CountSessions(from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string) (int64, error)
-	CountSessionsByPeriods(periods []string, landingPage string, funnelID string, professionID string) (map[string]int64, error)
+	CountSessions(from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string, segment SegmentFilter) (int64, error)
+	CountSessionsByPeriods(periods []string, landingPage string, funnelID string, professionID string, segment SegmentFilter) (map[string]int64, error)
 	FindActiveSessions(page, limit int, orderBy string, landingPage string, funnelID string, professionID string) ([]entities.Session, int64, error)
 	GetSessionsDateRange() (time.Time, time.Time, error)
 	CountActiveSessions(professionID string, funnelID string, landingPage string) (int64, error)
@@ -38,17 +39,24 @@ func NewSessionRepository(db *gorm.DB) *SessionRepository {
 	}
 }
 
-func (r *SessionRepository) GetSessions(ctx context.Context, page, limit int, orderBy string, from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string) ([]entities.Session, int64, error) {
+// sessionPage é a página de sessões mantida em cache com seus metadados de paginação
+type sessionPage struct {
+	sessions []entities.Session
+	result   PageResult
+}
+
+func (r *SessionRepository) GetSessions(ctx context.Context, page PageRequest, orderBy string, from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string, segment SegmentFilter) ([]entities.Session, PageResult, error) {
 	// Gerar chave de cache baseada nos parâmetros
-	cacheKey := fmt.Sprintf("sessions:%d:%d:%s:%v:%v:%s:%s:%s:%s:%s:%s:%v:%s",
-		page, limit, orderBy, from, to, timeFrom, timeTo, userID, professionID, productID, funnelID, isActive, landingPage)
+	cacheKey := fmt.Sprintf("sessions:%s:%s:%v:%v:%s:%s:%s:%s:%s:%s:%v:%s:%s",
+		page.Key(), orderBy, from, to, timeFrom, timeTo, userID, professionID, productID, funnelID, isActive, landingPage, segment.Key())
 
 	fmt.Printf("GetSessions chamado com from=%v, to=%v\n", from, to)
 
 	// Tentar obter do cache
 	if cached, found := r.cache.Get(cacheKey); found {
 		fmt.Println("Retornando dados do cache para sessões")
-		return cached.([]entities.Session), 0, nil
+		cachedPage := cached.(sessionPage)
+		return cachedPage.sessions, cachedPage.result, nil
 	}
 
 	// Adicionar timeout ao contexto
@@ -56,97 +64,13 @@ func (r *SessionRepository) GetSessions(ctx context.Context, page, limit int, or
 	defer cancel()
 
 	var sessions []entities.Session
-	var total int64
-
-	offset := (page - 1) * limit
+	result := PageResult{TotalMode: page.Total}
 
 	// Otimizar query selecionando apenas campos necessários
-	query := r.db.WithContext(ctx).Model(&entities.Session{}).Select(
+	query := r.sessionListQuery(ctx, from, to, timeFrom, timeTo, userID, professionID, productID, funnelID, isActive, landingPage, segment).Select(
 		"\"session_id\", \"user_id\", \"sessionStart\", \"isActive\", \"lastActivity\", \"country\", \"city\", \"state\", \"ipAddress\", \"userAgent\", \"duration\", \"landingPage\"",
 	)
 
-	// Aplicar filtros
-	if userID != "" {
-		query = query.Where("user_id = ?", userID)
-	}
-
-	if professionID != "" {
-		profID, err := strconv.Atoi(professionID)
-		if err == nil {
-			query = query.Where("profession_id = ?", profID)
-		}
-	}
-
-	if productID != "" {
-		prodID, err := strconv.Atoi(productID)
-		if err == nil {
-			query = query.Where("product_id = ?", prodID)
-		}
-	}
-
-	if funnelID != "" {
-		funID, err := strconv.Atoi(funnelID)
-		if err == nil {
-			query = query.Where("funnel_id = ?", funID)
-		}
-	}
-
-	if landingPage != "" {
-		query = query.Where("\"landingPage\" = ?", landingPage)
-	}
-
-	if isActive != nil {
-		query = query.Where("\"isActive\" = ?", *isActive)
-	}
-
-	// Verificar se há filtro de período
-	hasDateFilter := !from.IsZero() && !to.IsZero()
-	fmt.Printf("hasDateFilter=%v, from=%v, to=%v\n", hasDateFilter, from, to)
-
-	// Aplicar filtro de data com timezone explícito (se houver)
-	if !from.IsZero() && !to.IsZero() {
-		fromTime := from
-		toTime := to
-
-		// Ajustar hora e minuto se timeFrom fornecido
-		if timeFrom != "" {
-			timeParts := strings.Split(timeFrom, ":")
-			if len(timeParts) >= 2 {
-				hour, _ := strconv.Atoi(timeParts[0])
-				min, _ := strconv.Atoi(timeParts[1])
-				fromTime = time.Date(from.Year(), from.Month(), from.Day(), hour, min, 0, 0, from.Location())
-				fmt.Printf("Sessions: Ajustando horário de início para: %s\n", fromTime.Format("2006-01-02 15:04:05"))
-			}
-		} else {
-			// Se não fornecido, usar o início do dia
-			fromTime = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
-		}
-
-		// Ajustar hora e minuto se timeTo fornecido
-		if timeTo != "" {
-			timeParts := strings.Split(timeTo, ":")
-			if len(timeParts) >= 2 {
-				hour, _ := strconv.Atoi(timeParts[0])
-				min, _ := strconv.Atoi(timeParts[1])
-				toTime = time.Date(to.Year(), to.Month(), to.Day(), hour, min, 59, 999999999, to.Location())
-				fmt.Printf("Sessions: Ajustando horário de fim para: %s\n", toTime.Format("2006-01-02 15:04:05"))
-			}
-		} else {
-			// Se não fornecido, usar o fim do dia
-			toTime = time.Date(to.Year(), to.Month(), to.Day(), 23, 59, 59, 999999999, to.Location())
-		}
-
-		// Formatar as datas como strings no formato de timestamp SQL
-		fromStr := fromTime.Format("2006-01-02 15:04:05")
-		toStr := toTime.Format("2006-01-02 15:04:05")
-
-		// Aplicar filtro usando a sintaxe com AT TIME ZONE e TIMESTAMP
-		query = query.Where("(\"sessionStart\" AT TIME ZONE 'America/Sao_Paulo') BETWEEN ? AND ?",
-			fromStr, toStr)
-
-		fmt.Printf("Sessions: Filtro de data aplicado com timestamptz: %s até %s\n", fromStr, toStr)
-	}
-
 	// Get SQL for debug
 	stmt := query.Statement
 	sql := stmt.SQL.String()
@@ -154,26 +78,27 @@ func (r *SessionRepository) GetSessions(ctx context.Context, page, limit int, or
 	fmt.Printf("SQL para GetSessions: %s, Vars: %v\n", sql, vars)
 
 	// Get total count in a separate query
-	countQuery := query
-	if err := countQuery.Count(&total).Error; err != nil {
-		return nil, 0, err
+	total, err := countTotal(ctx, query, page.Total)
+	if err != nil {
+		return nil, PageResult{}, err
 	}
+	result.Total = total
 
-	// Apply ordering
-	if orderBy != "" {
-		query = query.Order(orderBy)
-	} else {
-		query = query.Order("\"sessionStart\" DESC")
+	// Apply ordering and pagination (offset or keyset on sessionStart, session_id)
+	if orderBy == "" {
+		orderBy = "\"sessionStart\" DESC"
 	}
-
-	// Apply pagination
-	query = query.Offset(offset).Limit(limit)
+	query = page.apply(query, orderBy, "\"sessionStart\"", "session_id")
 
 	// Execute query
 	if err := query.Find(&sessions).Error; err != nil {
-		return nil, 0, err
+		return nil, PageResult{}, err
 	}
 
+	sessions = trimKeysetPage(sessions, page, &result, func(session entities.Session) (time.Time, string) {
+		return session.SessionStart, session.ID.String()
+	})
+
 	// Se tivermos sessões, carregar dados relacionados de forma otimizada
 	if len(sessions) > 0 {
 		var sessionIDs []uuid.UUID
@@ -188,19 +113,19 @@ func (r *SessionRepository) GetSessions(ctx context.Context, page, limit int, or
 		var funnels []entities.Funnel
 
 		if err := r.db.Where("user_id IN ?", sessionIDs).Find(&users).Error; err != nil {
-			return nil, 0, err
+			return nil, PageResult{}, err
 		}
 
 		if err := r.db.Where("profession_id IN ?", sessionIDs).Find(&professions).Error; err != nil {
-			return nil, 0, err
+			return nil, PageResult{}, err
 		}
 
 		if err := r.db.Where("product_id IN ?", sessionIDs).Find(&products).Error; err != nil {
-			return nil, 0, err
+			return nil, PageResult{}, err
 		}
 
 		if err := r.db.Where("funnel_id IN ?", sessionIDs).Find(&funnels).Error; err != nil {
-			return nil, 0, err
+			return nil, PageResult{}, err
 		}
 
 		// Criar maps para acesso rápido
@@ -257,9 +182,111 @@ func (r *SessionRepository) GetSessions(ctx context.Context, page, limit int, or
 	}
 
 	// Store in cache
-	r.cache.Set(cacheKey, sessions, cache.DefaultExpiration)
+	r.cache.Set(cacheKey, sessionPage{sessions: sessions, result: result}, cache.DefaultExpiration)
 
-	return sessions, total, nil
+	return sessions, result, nil
+}
+
+// sessionListQuery monta a consulta filtrada da listagem de sessões
+func (r *SessionRepository) sessionListQuery(ctx context.Context, from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string, segment SegmentFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&entities.Session{})
+
+	// Aplicar filtros
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	if professionID != "" {
+		profID, err := strconv.Atoi(professionID)
+		if err == nil {
+			query = query.Where("profession_id = ?", profID)
+		}
+	}
+
+	if productID != "" {
+		prodID, err := strconv.Atoi(productID)
+		if err == nil {
+			query = query.Where("product_id = ?", prodID)
+		}
+	}
+
+	if funnelID != "" {
+		funID, err := strconv.Atoi(funnelID)
+		if err == nil {
+			query = query.Where("funnel_id = ?", funID)
+		}
+	}
+
+	if landingPage != "" {
+		query = query.Where("\"landingPage\" = ?", landingPage)
+	}
+
+	if isActive != nil {
+		query = query.Where("\"isActive\" = ?", *isActive)
+	}
+
+	// Restringir às sessões dos membros do segmento, se informado
+	query = segment.Apply(query, "sessions.user_id")
+
+	// Verificar se há filtro de período
+	hasDateFilter := !from.IsZero() && !to.IsZero()
+	fmt.Printf("hasDateFilter=%v, from=%v, to=%v\n", hasDateFilter, from, to)
+
+	// Aplicar filtro de data com timezone explícito (se houver)
+	if !from.IsZero() && !to.IsZero() {
+		fromTime := from
+		toTime := to
+
+		// Ajustar hora e minuto se timeFrom fornecido
+		if timeFrom != "" {
+			timeParts := strings.Split(timeFrom, ":")
+			if len(timeParts) >= 2 {
+				hour, _ := strconv.Atoi(timeParts[0])
+				min, _ := strconv.Atoi(timeParts[1])
+				fromTime = time.Date(from.Year(), from.Month(), from.Day(), hour, min, 0, 0, from.Location())
+				fmt.Printf("Sessions: Ajustando horário de início para: %s\n", fromTime.Format("2006-01-02 15:04:05"))
+			}
+		} else {
+			// Se não fornecido, usar o início do dia
+			fromTime = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+		}
+
+		// Ajustar hora e minuto se timeTo fornecido
+		if timeTo != "" {
+			timeParts := strings.Split(timeTo, ":")
+			if len(timeParts) >= 2 {
+				hour, _ := strconv.Atoi(timeParts[0])
+				min, _ := strconv.Atoi(timeParts[1])
+				toTime = time.Date(to.Year(), to.Month(), to.Day(), hour, min, 59, 999999999, to.Location())
+				fmt.Printf("Sessions: Ajustando horário de fim para: %s\n", toTime.Format("2006-01-02 15:04:05"))
+			}
+		} else {
+			// Se não fornecido, usar o fim do dia
+			toTime = time.Date(to.Year(), to.Month(), to.Day(), 23, 59, 59, 999999999, to.Location())
+		}
+
+		// Formatar as datas como strings no formato de timestamp SQL
+		fromStr := fromTime.Format("2006-01-02 15:04:05")
+		toStr := toTime.Format("2006-01-02 15:04:05")
+
+		// Aplicar filtro usando a sintaxe com AT TIME ZONE e TIMESTAMP
+		query = query.Where("(\"sessionStart\" AT TIME ZONE 'America/Sao_Paulo') BETWEEN ? AND ?",
+			fromStr, toStr)
+
+		fmt.Printf("Sessions: Filtro de data aplicado com timestamptz: %s até %s\n", fromStr, toStr)
+	}
+
+	return query
+}
+
+// ExportSessions percorre as sessões com os mesmos filtros da listagem, entregando as colunas pedidas
+// linha a linha a fn direto do cursor do banco
+func (r *SessionRepository) ExportSessions(ctx context.Context, columns []ExportColumn, orderBy string, from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string, segment SegmentFilter, fn ExportRowFunc) error {
+	if orderBy == "" {
+		orderBy = "\"sessionStart\" DESC"
+	}
+	query := r.sessionListQuery(ctx, from, to, timeFrom, timeTo, userID, professionID, productID, funnelID, isActive, landingPage, segment)
+	return streamExportRows(query.Order(orderBy), columns, fn)
 }
 
 func (r *SessionRepository) FindSessionByID(ctx context.Context, id string) (*entities.Session, error) {
@@ -280,10 +307,10 @@ func (r *SessionRepository) FindSessionByID(ctx context.Context, id string) (*en
 	return &session, nil
 }
 
-func (r *SessionRepository) CountSessions(from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string) (int64, error) {
+func (r *SessionRepository) CountSessions(from, to time.Time, timeFrom, timeTo string, userID, professionID, productID, funnelID string, isActive *bool, landingPage string, segment SegmentFilter) (int64, error) {
 	// Gerar chave de cache baseada nos parâmetros
-	cacheKey := fmt.Sprintf("count_sessions:%v:%v:%s:%s:%s:%s:%s:%s:%v:%s",
-		from, to, timeFrom, timeTo, userID, professionID, productID, funnelID, isActive, landingPage)
+	cacheKey := fmt.Sprintf("count_sessions:%v:%v:%s:%s:%s:%s:%s:%s:%v:%s:%s",
+		from, to, timeFrom, timeTo, userID, professionID, productID, funnelID, isActive, landingPage, segment.Key())
 
 	fmt.Printf("CountSessions chamado com from=%v, to=%v, landingPage=%s\n", from, to, landingPage)
 
@@ -330,6 +357,9 @@ func (r *SessionRepository) CountSessions(from, to time.Time, timeFrom, timeTo s
 		query = query.Where(`"isActive" = ?`, *isActive)
 	}
 
+	// Restringir às sessões dos membros do segmento, se informado
+	query = segment.Apply(query, "sessions.user_id")
+
 	// Verificar se há filtro de período
 	if !from.IsZero() && !to.IsZero() {
 		fromTime := from
@@ -382,9 +412,9 @@ func (r *SessionRepository) CountSessions(from, to time.Time, timeFrom, timeTo s
 	return count, nil
 }
 
-func (r *SessionRepository) CountSessionsByPeriods(periods []string, landingPage string, funnelID string, professionID string) (map[string]int64, error) {
+func (r *SessionRepository) CountSessionsByPeriods(periods []string, landingPage string, funnelID string, professionID string, segment SegmentFilter) (map[string]int64, error) {
 	// Gerar chave de cache baseada nos períodos
-	cacheKey := fmt.Sprintf("count_sessions_periods:%v:%s:%s:%s", periods, landingPage, funnelID, professionID)
+	cacheKey := fmt.Sprintf("count_sessions_periods:%v:%s:%s:%s:%s", periods, landingPage, funnelID, professionID, segment.Key())
 
 	// Tentar obter do cache
 	if cached, found := r.cache.Get(cacheKey); found {
@@ -395,7 +425,7 @@ func (r *SessionRepository) CountSessionsByPeriods(periods []string, landingPage
 
 	for _, period := range periods {
 		// Gerar chave de cache para o período específico
-		periodCacheKey := fmt.Sprintf("count_sessions_period:%s:%s:%s:%s", period, landingPage, funnelID, professionID)
+		periodCacheKey := fmt.Sprintf("count_sessions_period:%s:%s:%s:%s:%s", period, landingPage, funnelID, professionID, segment.Key())
 
 		// Tentar obter do cache do período
 		if cached, found := r.cache.Get(periodCacheKey); found {
@@ -441,6 +471,9 @@ func (r *SessionRepository) CountSessionsByPeriods(periods []string, landingPage
 			}
 		}
 
+		// Restringir às sessões dos membros do segmento, se informado
+		query = segment.Apply(query, "sessions.user_id")
+
 		// Contar sessões no período usando timezone
 		var count int64
 		err = query.Where("(\"sessionStart\" AT TIME ZONE 'America/Sao_Paulo') BETWEEN ? AND ?",
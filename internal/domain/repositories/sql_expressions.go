@@ -0,0 +1,30 @@
+package repositories
+
+// Os padrões abaixo usam {0,1} em vez de "?", que as consultas com parâmetros (posicionais ou nomeados)
+// tratariam como placeholder.
+
+// numericTextPattern reconhece textos com um número decimal, como "-12" ou "3.5"
+const numericTextPattern = `'^-{0,1}[0-9]+(\.[0-9]+){0,1}$'`
+
+// purchaseValueSQL retorna o valor (value) do evento com o alias informado como DECIMAL(10,2), ou NULL
+// quando não é numérico. É a mesma regra de valor válido usada nos endpoints de receita.
+func purchaseValueSQL(alias string) string {
+	value := alias + ".event_propeties->>'value'"
+	return `(CASE WHEN ` + value + ` ~ '^[0-9]+\.{0,1}[0-9]*$' THEN CAST(` + value + ` AS DECIMAL(10,2)) END)`
+}
+
+// dateTextPattern reconhece datas YYYY-MM-DD, opcionalmente com hora (HH:MM, segundos e frações opcionais)
+// e fuso (Z, +HH, +HHMM ou +HH:MM). Mês, dia, hora e minuto são limitados às faixas válidas; o dia
+// ainda pode não existir no mês (ex.: 2024-02-30), o que dateTextSQL verifica.
+const dateTextPattern = `'^[1-9][0-9]{3}-(0[1-9]|1[0-2])-(0[1-9]|[12][0-9]|3[01])` +
+	`([T ]([01][0-9]|2[0-3]):[0-5][0-9](:[0-5][0-9](\.[0-9]{1,6}){0,1}){0,1}` +
+	`(Z|[+-]([01][0-9]|2[0-3])(:{0,1}[0-5][0-9]){0,1}){0,1}){0,1}$'`
+
+// dateTextSQL converte o texto para timestamptz, ou NULL quando não é uma data válida. Os CASE aninhados
+// garantem que o dia só é conferido (e o texto só é convertido) depois que o padrão casou.
+func dateTextSQL(text string) string {
+	lastDay := "extract(day from make_date(substr(" + text + ", 1, 4)::int, substr(" + text + ", 6, 2)::int, 1)" +
+		" + interval '1 month' - interval '1 day')"
+	return "(CASE WHEN " + text + " ~ " + dateTextPattern + " THEN CASE WHEN substr(" + text + ", 9, 2)::int <= " +
+		lastDay + " THEN " + text + "::timestamptz END END)"
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// dryRunStatement guarda o SQL gerado por uma consulta em DryRun e os valores dos placeholders
+type dryRunStatement struct {
+	SQL  string
+	Vars []interface{}
+}
+
+// newDryRunDB abre um banco em DryRun, sem conexão, que registra os statements gerados por Raw/Scan
+func newDryRunDB(t *testing.T) (*gorm.DB, *[]dryRunStatement) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=dryrun"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+		Logger:               logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	statements := &[]dryRunStatement{}
+	capture := func(tx *gorm.DB) {
+		*statements = append(*statements, dryRunStatement{SQL: tx.Statement.SQL.String(), Vars: tx.Statement.Vars})
+	}
+	if err := db.Callback().Row().After("gorm:row").Register("test:capture", capture); err != nil {
+		t.Fatalf("registrar callback: %v", err)
+	}
+	return db, statements
+}
+
+var (
+	sqlStringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlPlaceholder   = regexp.MustCompile(`\$(\d+)`)
+)
+
+// checkPlaceholders falha se algum "?" não virou placeholder, se um placeholder caiu dentro de um literal
+// ou se a quantidade de placeholders difere da de valores
+func checkPlaceholders(t *testing.T, stmt dryRunStatement) {
+	t.Helper()
+	for _, literal := range sqlStringLiteral.FindAllString(stmt.SQL, -1) {
+		if sqlPlaceholder.MatchString(literal) {
+			t.Errorf("placeholder dentro do literal %s", literal)
+		}
+	}
+	code := sqlStringLiteral.ReplaceAllString(stmt.SQL, "''")
+	if strings.Contains(code, "?") {
+		t.Errorf("\"?\" sem valor no SQL gerado:\n%s", stmt.SQL)
+	}
+	if got := len(sqlPlaceholder.FindAllString(code, -1)); got != len(stmt.Vars) {
+		t.Errorf("%d placeholders para %d valores no SQL gerado:\n%s", got, len(stmt.Vars), stmt.SQL)
+	}
+}
+
+func TestPurchaseValueSQLPlaceholders(t *testing.T) {
+	db, statements := newDryRunDB(t)
+	var rows []struct{ Value float64 }
+	db.Raw("SELECT "+purchaseValueSQL("e")+" AS value FROM events e WHERE e.event_time >= ? AND e.profession_id = ?", "2024-06-01", 3).Scan(&rows)
+	if len(*statements) != 1 {
+		t.Fatalf("%d statements gerados; esperado 1", len(*statements))
+	}
+	checkPlaceholders(t, (*statements)[0])
+}
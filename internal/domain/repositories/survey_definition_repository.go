@@ -0,0 +1,171 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SurveyDefinitionRepository interface para operações de definições versionadas de pesquisa
+type SurveyDefinitionRepository interface {
+	SurveyExists(surveyID int64) (bool, error)
+	ListDefinitions(surveyID int64) ([]entities.SurveyDefinition, error)
+	GetDefinition(surveyID int64, version int) (*entities.SurveyDefinition, error)
+	// GetActiveDefinition retorna a versão ativa da pesquisa, ou nil se nenhuma estiver ativa
+	GetActiveDefinition(surveyID int64) (*entities.SurveyDefinition, error)
+	// CreateDefinition cria a próxima versão da pesquisa com suas perguntas, opções e faixas
+	CreateDefinition(definition *entities.SurveyDefinition) error
+	ActivateDefinition(surveyID int64, version int) error
+	DeleteDefinition(surveyID int64, version int) error
+	// CountResponsesByVersion retorna a quantidade de respostas coletadas em cada versão
+	CountResponsesByVersion(surveyID int64) (map[int]int64, error)
+}
+
+type surveyDefinitionRepository struct {
+	db *gorm.DB
+}
+
+func NewSurveyDefinitionRepository(db *gorm.DB) SurveyDefinitionRepository {
+	return &surveyDefinitionRepository{db}
+}
+
+func (r *surveyDefinitionRepository) SurveyExists(surveyID int64) (bool, error) {
+	var count int64
+	if err := r.db.Model(&entities.Survey{}).Where("survey_id = ?", surveyID).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("erro ao buscar pesquisa: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *surveyDefinitionRepository) ListDefinitions(surveyID int64) ([]entities.SurveyDefinition, error) {
+	var definitions []entities.SurveyDefinition
+	if err := r.preload(r.db).
+		Where("survey_id = ?", surveyID).
+		Order("version DESC").
+		Find(&definitions).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar definições da pesquisa: %w", err)
+	}
+	return definitions, nil
+}
+
+func (r *surveyDefinitionRepository) GetDefinition(surveyID int64, version int) (*entities.SurveyDefinition, error) {
+	var definition entities.SurveyDefinition
+	if err := r.preload(r.db).First(&definition, "survey_id = ? AND version = ?", surveyID, version).Error; err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+func (r *surveyDefinitionRepository) GetActiveDefinition(surveyID int64) (*entities.SurveyDefinition, error) {
+	var definition entities.SurveyDefinition
+	err := r.preload(r.db).Where("survey_id = ? AND is_active = ?", surveyID, true).First(&definition).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar definição ativa: %w", err)
+	}
+	return &definition, nil
+}
+
+func (r *surveyDefinitionRepository) CreateDefinition(definition *entities.SurveyDefinition) error {
+	active := definition.IsActive
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Bloqueia a pesquisa para que duas criações simultâneas não gerem a mesma versão
+		var survey entities.Survey
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("survey_id = ?", definition.SurveyID).
+			First(&survey).Error; err != nil {
+			return err
+		}
+
+		var lastVersion int
+		if err := tx.Model(&entities.SurveyDefinition{}).
+			Where("survey_id = ?", definition.SurveyID).
+			Select("COALESCE(MAX(version), 0)").
+			Scan(&lastVersion).Error; err != nil {
+			return fmt.Errorf("erro ao calcular versão da definição: %w", err)
+		}
+
+		// A versão é criada inativa e ativada em seguida para respeitar o índice de versão ativa única
+		definition.Version = lastVersion + 1
+		definition.IsActive = false
+		if err := tx.Create(definition).Error; err != nil {
+			return fmt.Errorf("erro ao criar definição da pesquisa: %w", err)
+		}
+		if !active {
+			return nil
+		}
+		definition.IsActive = true
+		return activateDefinition(tx, definition.SurveyID, definition.Version)
+	})
+}
+
+func (r *surveyDefinitionRepository) ActivateDefinition(surveyID int64, version int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return activateDefinition(tx, surveyID, version)
+	})
+}
+
+func activateDefinition(tx *gorm.DB, surveyID int64, version int) error {
+	if err := tx.Model(&entities.SurveyDefinition{}).
+		Where("survey_id = ? AND is_active = ? AND version <> ?", surveyID, true, version).
+		Update("is_active", false).Error; err != nil {
+		return fmt.Errorf("erro ao desativar definição: %w", err)
+	}
+
+	result := tx.Model(&entities.SurveyDefinition{}).
+		Where("survey_id = ? AND version = ?", surveyID, version).
+		Update("is_active", true)
+	if result.Error != nil {
+		return fmt.Errorf("erro ao ativar definição: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *surveyDefinitionRepository) DeleteDefinition(surveyID int64, version int) error {
+	// Perguntas, opções e faixas são removidas em cascata
+	result := r.db.Where("survey_id = ? AND version = ?", surveyID, version).Delete(&entities.SurveyDefinition{})
+	if result.Error != nil {
+		return fmt.Errorf("erro ao remover definição: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *surveyDefinitionRepository) CountResponsesByVersion(surveyID int64) (map[int]int64, error) {
+	var rows []struct {
+		Version int   `gorm:"column:definition_version"`
+		Total   int64 `gorm:"column:total"`
+	}
+	if err := r.db.Raw(`
+		SELECT definition_version, COUNT(*) AS total
+		FROM survey_responses
+		WHERE survey_id = ? AND definition_version IS NOT NULL
+		GROUP BY definition_version
+	`, surveyID).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("erro ao contar respostas por versão: %w", err)
+	}
+
+	counts := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Version] = row.Total
+	}
+	return counts, nil
+}
+
+// preload carrega perguntas, opções e faixas na ordem da definição
+func (r *surveyDefinitionRepository) preload(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Questions", func(tx *gorm.DB) *gorm.DB { return tx.Order("position") }).
+		Preload("Questions.Options", func(tx *gorm.DB) *gorm.DB { return tx.Order("position") }).
+		Preload("Faixas", func(tx *gorm.DB) *gorm.DB { return tx.Order("min_score DESC") })
+}
@@ -441,6 +441,12 @@ ORDER BY s.survey_name
 		}, nil
 	}
 
+	// Respostas coletadas em uma versão específica da definição da pesquisa
+	versionFilter := ""
+	if version, ok := params["definition_version"].(int); ok && version > 0 {
+		versionFilter = fmt.Sprintf("AND sr.definition_version = %d", version)
+	}
+
 	// Consulta para análise detalhada por questão/resposta
 	detailQuery := fmt.Sprintf(`
 WITH parametros AS (
@@ -496,6 +502,7 @@ respostas_compradores AS (
         WHERE
             e.event_type = 'PESQUISA_LEAD'
             AND sr.survey_id = %d
+            %s
             AND (
                 sa.question_id = (SELECT filtro_pergunta FROM parametros)
                 OR (SELECT filtro_pergunta FROM parametros) IS NULL
@@ -519,6 +526,7 @@ todas_respostas AS (
     WHERE
         e.event_type = 'PESQUISA_LEAD'
         AND sr.survey_id = %d
+        %s
         AND e.event_time BETWEEN (SELECT pesquisa_inicio FROM parametros) AND (SELECT pesquisa_fim FROM parametros)
         AND (
             sa.question_id = (SELECT filtro_pergunta FROM parametros)
@@ -604,7 +612,7 @@ ORDER BY
 `,
 		pesquisaInicioStr, pesquisaFimStr,
 		vendaInicioStr, vendaFimStr,
-		survey.FunnelID, surveyID, surveyID)
+		survey.FunnelID, surveyID, versionFilter, surveyID, versionFilter)
 
 	fmt.Printf("DETAIL ANALYTICS QUERY: %s\n", detailQuery)
 
@@ -665,3 +673,300 @@ ORDER BY
 
 	return mainResults, nil
 }
+
+// SurveyCrosstabFaixa é a dimensão especial do cruzamento que usa a faixa da resposta em vez de uma pergunta
+const SurveyCrosstabFaixa = "faixa"
+
+// SurveyCrosstabRow representa os respondentes e compradores de uma combinação de respostas
+type SurveyCrosstabRow struct {
+	RowValue    string `gorm:"column:row_value"`
+	ColValue    string `gorm:"column:col_value"`
+	Respondents int64  `gorm:"column:respondents"`
+	Purchases   int64  `gorm:"column:purchases"`
+}
+
+// GetQuestionText retorna o texto mais recente da pergunta na pesquisa, ou gorm.ErrRecordNotFound
+// se a pergunta nunca foi respondida
+func (r *SurveyRepository) GetQuestionText(surveyID int64, questionID string) (string, error) {
+	var texts []string
+	err := r.db.Raw(`
+		SELECT sa.question_text
+		FROM survey_answers sa
+		JOIN survey_responses sr ON sa.survey_response_id = sr.id
+		WHERE sr.survey_id = ? AND sa.question_id = ?
+		ORDER BY sa."timestamp" DESC
+		LIMIT 1
+	`, surveyID, questionID).Scan(&texts).Error
+	if err != nil {
+		return "", fmt.Errorf("erro ao buscar pergunta: %w", err)
+	}
+	if len(texts) == 0 {
+		return "", gorm.ErrRecordNotFound
+	}
+	return texts[0], nil
+}
+
+// GetSurveyCrosstab cruza as respostas de duas perguntas (ou a faixa) da pesquisa. Considera a resposta
+// mais recente de cada lead na janela de pesquisa e as compras do funil da pesquisa na janela de vendas.
+func (r *SurveyRepository) GetSurveyCrosstab(surveyID int64, rowDimension, colDimension string, params map[string]interface{}) ([]SurveyCrosstabRow, error) {
+	var survey entities.Survey
+	if err := r.db.Where("survey_id = ?", surveyID).First(&survey).Error; err != nil {
+		return nil, fmt.Errorf("pesquisa não encontrada: %w", err)
+	}
+
+	args := map[string]interface{}{
+		"survey_id":       surveyID,
+		"funnel_id":       survey.FunnelID,
+		"row_question":    rowDimension,
+		"col_question":    colDimension,
+		"pesquisa_inicio": time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC),
+		"pesquisa_fim":    time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC),
+		"venda_inicio":    time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC),
+		"venda_fim":       time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC),
+	}
+	for _, key := range []string{"pesquisa_inicio", "pesquisa_fim", "venda_inicio", "venda_fim"} {
+		if value, ok := params[key].(time.Time); ok {
+			args[key] = value
+		}
+	}
+
+	dimension := func(value, param string) string {
+		if value == SurveyCrosstabFaixa {
+			return "r.faixa"
+		}
+		return fmt.Sprintf(`(
+			SELECT sa.value
+			FROM survey_answers sa
+			WHERE sa.survey_response_id = r.response_id AND sa.question_id = @%s
+			ORDER BY sa."timestamp" DESC
+			LIMIT 1
+		)`, param)
+	}
+
+	query := `
+		WITH respondentes AS (
+			SELECT DISTINCT ON (e.user_id)
+				e.user_id,
+				sr.id AS response_id,
+				COALESCE(NULLIF(sr.faixa, ''), 'sem_faixa') AS faixa
+			FROM survey_responses sr
+			JOIN events e ON sr.event_id = e.event_id
+			WHERE e.event_type = 'PESQUISA_LEAD'
+			AND sr.survey_id = @survey_id
+			AND e.event_time BETWEEN @pesquisa_inicio AND @pesquisa_fim
+			ORDER BY e.user_id, e.event_time DESC
+		),
+		compradores AS (
+			SELECT DISTINCT v.user_id
+			FROM events v
+			WHERE v.funnel_id = @funnel_id
+			AND v.event_type = 'PURCHASE'
+			AND v.event_time BETWEEN @venda_inicio AND @venda_fim
+			AND v.event_propeties->>'product_type' = 'main'
+		),
+		dimensoes AS (
+			SELECT
+				r.user_id,
+				` + dimension(rowDimension, "row_question") + ` AS row_value,
+				` + dimension(colDimension, "col_question") + ` AS col_value
+			FROM respondentes r
+		)
+		SELECT
+			d.row_value,
+			d.col_value,
+			COUNT(*) AS respondents,
+			COUNT(c.user_id) AS purchases
+		FROM dimensoes d
+		LEFT JOIN compradores c ON c.user_id = d.user_id
+		WHERE d.row_value IS NOT NULL AND d.col_value IS NOT NULL
+		GROUP BY d.row_value, d.col_value
+		ORDER BY d.row_value, d.col_value
+	`
+
+	var rows []SurveyCrosstabRow
+	if err := r.db.Raw(query, args).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("erro ao cruzar respostas da pesquisa: %w", err)
+	}
+	return rows, nil
+}
+
+// SurveyQuestionFunnelRow representa as estatísticas de uma pergunta no funil da pesquisa
+type SurveyQuestionFunnelRow struct {
+	QuestionID   string  `gorm:"column:question_id"`
+	QuestionText string  `gorm:"column:question_text"`
+	Reached      int64   `gorm:"column:reached"`
+	AvgPosition  float64 `gorm:"column:avg_position"`
+	DroppedAfter int64   `gorm:"column:dropped_after"`
+	MedianTime   float64 `gorm:"column:median_time"`
+	P90Time      float64 `gorm:"column:p90_time"`
+	ChangedRate  float64 `gorm:"column:changed_rate"`
+}
+
+// SurveyFunnelTotals representa o total de respostas iniciadas e concluídas no período
+type SurveyFunnelTotals struct {
+	Started   int64 `gorm:"column:started"`
+	Completed int64 `gorm:"column:completed"`
+}
+
+// GetSurveyQuestionFunnel retorna, por pergunta, quantas respostas chegaram até ela, quantas foram
+// abandonadas logo após, os tempos de resposta (mediana e p90) e a taxa de respostas alteradas.
+// Considera as respostas de pesquisa (PESQUISA_LEAD) da janela de pesquisa.
+func (r *SurveyRepository) GetSurveyQuestionFunnel(surveyID int64, params map[string]interface{}) ([]SurveyQuestionFunnelRow, SurveyFunnelTotals, error) {
+	var totals SurveyFunnelTotals
+
+	var survey entities.Survey
+	if err := r.db.Where("survey_id = ?", surveyID).First(&survey).Error; err != nil {
+		return nil, totals, fmt.Errorf("pesquisa não encontrada: %w", err)
+	}
+
+	args := map[string]interface{}{
+		"survey_id":       surveyID,
+		"pesquisa_inicio": time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC),
+		"pesquisa_fim":    time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC),
+	}
+	for _, key := range []string{"pesquisa_inicio", "pesquisa_fim"} {
+		if value, ok := params[key].(time.Time); ok {
+			args[key] = value
+		}
+	}
+
+	respostas := `
+		WITH respostas AS (
+			SELECT sr.id, COALESCE(sr.completed, false) AS completed
+			FROM survey_responses sr
+			JOIN events e ON sr.event_id = e.event_id
+			WHERE sr.survey_id = @survey_id
+			AND e.event_type = 'PESQUISA_LEAD'
+			AND e.event_time BETWEEN @pesquisa_inicio AND @pesquisa_fim
+		)`
+
+	if err := r.db.Raw(respostas+`
+		SELECT COUNT(*) AS started, COUNT(*) FILTER (WHERE completed) AS completed
+		FROM respostas
+	`, args).Scan(&totals).Error; err != nil {
+		return nil, totals, fmt.Errorf("erro ao contar respostas da pesquisa: %w", err)
+	}
+
+	query := respostas + `,
+		ordenadas AS (
+			SELECT
+				sa.survey_response_id,
+				sa.question_id,
+				sa.question_text,
+				sa.time_to_answer,
+				COALESCE(sa.changed, false) AS changed,
+				r.completed,
+				ROW_NUMBER() OVER (PARTITION BY sa.survey_response_id ORDER BY sa."timestamp", sa.id) AS posicao,
+				ROW_NUMBER() OVER (PARTITION BY sa.survey_response_id ORDER BY sa."timestamp" DESC, sa.id DESC) AS posicao_reversa
+			FROM survey_answers sa
+			JOIN respostas r ON r.id = sa.survey_response_id
+		)
+		SELECT
+			question_id,
+			MAX(question_text) AS question_text,
+			COUNT(DISTINCT survey_response_id) AS reached,
+			AVG(posicao) AS avg_position,
+			COUNT(DISTINCT survey_response_id) FILTER (WHERE posicao_reversa = 1 AND NOT completed) AS dropped_after,
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY time_to_answer), 0) AS median_time,
+			COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY time_to_answer), 0) AS p90_time,
+			AVG(CASE WHEN changed THEN 1.0 ELSE 0 END) AS changed_rate
+		FROM ordenadas
+		GROUP BY question_id
+		ORDER BY avg_position
+	`
+
+	var rows []SurveyQuestionFunnelRow
+	if err := r.db.Raw(query, args).Scan(&rows).Error; err != nil {
+		return nil, totals, fmt.Errorf("erro ao calcular funil de perguntas: %w", err)
+	}
+	return rows, totals, nil
+}
+
+// SurveyVariantMetricsRow representa os totais de uma variante (pesquisa) no período
+type SurveyVariantMetricsRow struct {
+	SurveyID    int64 `gorm:"column:survey_id"`
+	Respondents int64 `gorm:"column:respondents"`
+	Completed   int64 `gorm:"column:completed"`
+	Purchasers  int64 `gorm:"column:purchasers"`
+}
+
+// SurveyVariantFaixaRow representa a quantidade de respondentes de uma variante em uma faixa
+type SurveyVariantFaixaRow struct {
+	SurveyID    int64  `gorm:"column:survey_id"`
+	Faixa       string `gorm:"column:faixa"`
+	Respondents int64  `gorm:"column:respondents"`
+}
+
+// GetSurveyVariantMetrics retorna, por pesquisa do funil, os respondentes (PESQUISA_LEAD na janela de
+// pesquisa), quantos finalizaram a resposta e quantos compraram o produto principal na janela de vendas,
+// além da distribuição de faixas. Cada usuário conta uma vez por pesquisa, pela sua resposta mais recente a ela;
+// quem respondeu a mais de uma variante conta em cada uma.
+func (r *SurveyRepository) GetSurveyVariantMetrics(funnelID int, surveyIDs []int64, params map[string]interface{}) ([]SurveyVariantMetricsRow, []SurveyVariantFaixaRow, error) {
+	args := map[string]interface{}{
+		"funnel_id":       funnelID,
+		"survey_ids":      surveyIDs,
+		"pesquisa_inicio": params["pesquisa_inicio"],
+		"pesquisa_fim":    params["pesquisa_fim"],
+		"venda_inicio":    params["venda_inicio"],
+		"venda_fim":       params["venda_fim"],
+	}
+
+	respostas := `
+		WITH respostas AS (
+			SELECT DISTINCT ON (e.user_id, sr.survey_id)
+				sr.survey_id,
+				e.user_id,
+				COALESCE(sr.completed, false) AS completed,
+				COALESCE(NULLIF(sr.faixa, ''), 'Sem faixa') AS faixa
+			FROM survey_responses sr
+			JOIN events e ON sr.event_id = e.event_id
+			WHERE sr.survey_id IN @survey_ids
+			AND e.funnel_id = @funnel_id
+			AND e.event_type = 'PESQUISA_LEAD'
+			AND e.event_time BETWEEN @pesquisa_inicio AND @pesquisa_fim
+			ORDER BY e.user_id, sr.survey_id, e.event_time DESC
+		),
+		compradores AS (
+			SELECT DISTINCT user_id
+			FROM events
+			WHERE funnel_id = @funnel_id
+			AND event_type = 'PURCHASE'
+			AND event_time BETWEEN @venda_inicio AND @venda_fim
+			AND event_propeties->>'product_type' = 'main'
+		)`
+
+	var metrics []SurveyVariantMetricsRow
+	if err := r.db.Raw(respostas+`
+		SELECT
+			r.survey_id,
+			COUNT(*) AS respondents,
+			COUNT(*) FILTER (WHERE r.completed) AS completed,
+			COUNT(c.user_id) AS purchasers
+		FROM respostas r
+		LEFT JOIN compradores c ON c.user_id = r.user_id
+		GROUP BY r.survey_id
+	`, args).Scan(&metrics).Error; err != nil {
+		return nil, nil, fmt.Errorf("erro ao calcular métricas das variantes: %w", err)
+	}
+
+	var faixas []SurveyVariantFaixaRow
+	if err := r.db.Raw(respostas+`
+		SELECT survey_id, faixa, COUNT(*) AS respondents
+		FROM respostas
+		GROUP BY survey_id, faixa
+		ORDER BY survey_id, faixa
+	`, args).Scan(&faixas).Error; err != nil {
+		return nil, nil, fmt.Errorf("erro ao calcular faixas das variantes: %w", err)
+	}
+
+	return metrics, faixas, nil
+}
+
+// GetSurvey retorna a pesquisa pelo ID, ou gorm.ErrRecordNotFound
+func (r *SurveyRepository) GetSurvey(surveyID int64) (*entities.Survey, error) {
+	var survey entities.Survey
+	if err := r.db.Where("survey_id = ?", surveyID).First(&survey).Error; err != nil {
+		return nil, err
+	}
+	return &survey, nil
+}
@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"gorm.io/gorm"
+)
+
+// SurveyResponseRepository interface para gravação de respostas de pesquisa
+type SurveyResponseRepository interface {
+	GetSurvey(surveyID int64) (*entities.Survey, error)
+	// GetResponse retorna a resposta com suas respostas por pergunta
+	GetResponse(responseID string) (*entities.SurveyResponse, error)
+	// GetResponseByEvent retorna a resposta vinculada ao evento, ou nil se não houver
+	GetResponseByEvent(surveyID int64, eventID string) (*entities.SurveyResponse, error)
+	// GetPesquisaEvent retorna o evento PESQUISA_LEAD informado
+	GetPesquisaEvent(eventID string) (*entities.Event, error)
+	// GetLatestPesquisaEvent retorna o evento PESQUISA_LEAD mais recente do usuário no funil
+	GetLatestPesquisaEvent(userID string, funnelID int) (*entities.Event, error)
+	// SaveResponse grava a resposta e as respostas por pergunta informadas (inseridas ou atualizadas pelo id)
+	SaveResponse(response *entities.SurveyResponse, answers []entities.SurveyAnswer) error
+}
+
+type surveyResponseRepository struct {
+	db *gorm.DB
+}
+
+func NewSurveyResponseRepository(db *gorm.DB) SurveyResponseRepository {
+	return &surveyResponseRepository{db}
+}
+
+func (r *surveyResponseRepository) GetSurvey(surveyID int64) (*entities.Survey, error) {
+	var survey entities.Survey
+	if err := r.db.Where("survey_id = ?", surveyID).First(&survey).Error; err != nil {
+		return nil, err
+	}
+	return &survey, nil
+}
+
+func (r *surveyResponseRepository) GetResponse(responseID string) (*entities.SurveyResponse, error) {
+	var response entities.SurveyResponse
+	if err := r.db.
+		Preload("Answers", func(tx *gorm.DB) *gorm.DB { return tx.Order(`"timestamp"`) }).
+		First(&response, "id = ?", responseID).Error; err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func (r *surveyResponseRepository) GetResponseByEvent(surveyID int64, eventID string) (*entities.SurveyResponse, error) {
+	var response entities.SurveyResponse
+	err := r.db.
+		Preload("Answers", func(tx *gorm.DB) *gorm.DB { return tx.Order(`"timestamp"`) }).
+		Where("survey_id = ? AND event_id = ?", surveyID, eventID).
+		Order("created_at DESC").
+		First(&response).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar resposta do evento: %w", err)
+	}
+	return &response, nil
+}
+
+func (r *surveyResponseRepository) GetPesquisaEvent(eventID string) (*entities.Event, error) {
+	var event entities.Event
+	if err := r.db.
+		Select("event_id", "event_time", "user_id", "funnel_id", "event_type").
+		Where("event_id = ? AND event_type = ?", eventID, "PESQUISA_LEAD").
+		First(&event).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *surveyResponseRepository) GetLatestPesquisaEvent(userID string, funnelID int) (*entities.Event, error) {
+	var event entities.Event
+	if err := r.db.
+		Select("event_id", "event_time", "user_id", "funnel_id", "event_type").
+		Where("user_id = ? AND funnel_id = ? AND event_type = ?", userID, funnelID, "PESQUISA_LEAD").
+		Order("event_time DESC").
+		First(&event).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *surveyResponseRepository) SaveResponse(response *entities.SurveyResponse, answers []entities.SurveyAnswer) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Survey", "Answers").Save(response).Error; err != nil {
+			return fmt.Errorf("erro ao salvar resposta da pesquisa: %w", err)
+		}
+		for i := range answers {
+			if err := tx.Save(&answers[i]).Error; err != nil {
+				return fmt.Errorf("erro ao salvar resposta da pergunta %s: %w", answers[i].QuestionID, err)
+			}
+		}
+		return nil
+	})
+}
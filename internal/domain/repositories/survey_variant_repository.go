@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"gorm.io/gorm"
+)
+
+// SurveyVariantRepository interface para operações de variantes de pesquisa
+type SurveyVariantRepository interface {
+	ListVariants(funnelID int) ([]entities.SurveyVariant, error)
+	GetVariant(variantID int64) (*entities.SurveyVariant, error)
+	// CreateVariant cria a variante; se for o controle, o controle anterior do funil deixa de ser
+	CreateVariant(variant *entities.SurveyVariant) error
+	UpdateVariant(variant *entities.SurveyVariant) error
+	DeleteVariant(variantID int64) error
+	// GetUserVariant retorna a variante em que o usuário já respondeu a pesquisa no funil, ou nil
+	GetUserVariant(funnelID int, userID string) (*entities.SurveyVariant, error)
+}
+
+type surveyVariantRepository struct {
+	db *gorm.DB
+}
+
+func NewSurveyVariantRepository(db *gorm.DB) SurveyVariantRepository {
+	return &surveyVariantRepository{db}
+}
+
+func (r *surveyVariantRepository) ListVariants(funnelID int) ([]entities.SurveyVariant, error) {
+	var variants []entities.SurveyVariant
+	if err := r.db.Where("funnel_id = ?", funnelID).
+		Order("is_control DESC, variant_id").
+		Find(&variants).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar variantes de pesquisa: %w", err)
+	}
+	return variants, nil
+}
+
+func (r *surveyVariantRepository) GetVariant(variantID int64) (*entities.SurveyVariant, error) {
+	var variant entities.SurveyVariant
+	if err := r.db.First(&variant, "variant_id = ?", variantID).Error; err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func (r *surveyVariantRepository) CreateVariant(variant *entities.SurveyVariant) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := clearControl(tx, variant); err != nil {
+			return err
+		}
+		if err := tx.Create(variant).Error; err != nil {
+			return fmt.Errorf("erro ao criar variante de pesquisa: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *surveyVariantRepository) UpdateVariant(variant *entities.SurveyVariant) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := clearControl(tx, variant); err != nil {
+			return err
+		}
+		if err := tx.Save(variant).Error; err != nil {
+			return fmt.Errorf("erro ao atualizar variante de pesquisa: %w", err)
+		}
+		return nil
+	})
+}
+
+// clearControl remove a marcação de controle das outras variantes do funil quando a variante é o controle
+func clearControl(tx *gorm.DB, variant *entities.SurveyVariant) error {
+	if !variant.IsControl {
+		return nil
+	}
+	if err := tx.Model(&entities.SurveyVariant{}).
+		Where("funnel_id = ? AND is_control = ? AND variant_id <> ?", variant.FunnelID, true, variant.VariantID).
+		Update("is_control", false).Error; err != nil {
+		return fmt.Errorf("erro ao atualizar controle do funil: %w", err)
+	}
+	return nil
+}
+
+func (r *surveyVariantRepository) DeleteVariant(variantID int64) error {
+	result := r.db.Where("variant_id = ?", variantID).Delete(&entities.SurveyVariant{})
+	if result.Error != nil {
+		return fmt.Errorf("erro ao remover variante de pesquisa: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *surveyVariantRepository) GetUserVariant(funnelID int, userID string) (*entities.SurveyVariant, error) {
+	var variant entities.SurveyVariant
+	err := r.db.Raw(`
+		SELECT v.*
+		FROM survey_variants v
+		JOIN survey_responses sr ON sr.survey_id = v.survey_id
+		JOIN events e ON sr.event_id = e.event_id
+		WHERE v.funnel_id = ? AND e.user_id = ? AND e.funnel_id = v.funnel_id
+		ORDER BY sr.created_at DESC
+		LIMIT 1
+	`, funnelID, userID).Scan(&variant).Error
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar variante do usuário: %w", err)
+	}
+	if variant.VariantID == 0 {
+		return nil, nil
+	}
+	return &variant, nil
+}
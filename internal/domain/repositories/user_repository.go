@@ -14,11 +14,13 @@ import (
 
 type IUserRepository interface {
 	GetUsers(ctx context.Context, page, limit int, orderBy string, from, to time.Time, timeFrom, timeTo string) ([]entities.User, int64, error)
-	FindLeads(ctx context.Context, page, limit int, orderBy string, from, to time.Time, timeFrom, timeTo string) ([]entities.User, int64, error)
-	FindClients(page, limit int, orderBy string, from, to time.Time, timeFrom, timeTo string) ([]entities.User, int64, error)
+	FindLeads(ctx context.Context, page PageRequest, orderBy string, from, to time.Time, timeFrom, timeTo string, scoreFilter LeadScoreFilter, segment SegmentFilter) ([]entities.User, PageResult, error)
+	FindClients(ctx context.Context, page PageRequest, orderBy string, from, to time.Time, timeFrom, timeTo string) ([]entities.User, PageResult, error)
+	ExportLeads(ctx context.Context, columns []ExportColumn, sortBy, sortDirection string, from, to time.Time, timeFrom, timeTo string, scoreFilter LeadScoreFilter, segment SegmentFilter, fn ExportRowFunc) error
+	ExportClients(ctx context.Context, columns []ExportColumn, sortBy, sortDirection string, from, to time.Time, timeFrom, timeTo string, fn ExportRowFunc) error
 	FindAnonymous(page, limit int, orderBy string, from, to time.Time, timeFrom, timeTo string) ([]entities.User, int64, error)
-	CountLeads(from, to time.Time, timeFrom, timeTo string) (int64, error)
-	CountLeadsByPeriods(periods []string) (map[string]int64, error)
+	CountLeads(from, to time.Time, timeFrom, timeTo string, segment SegmentFilter) (int64, error)
+	CountLeadsByPeriods(periods []string, segment SegmentFilter) (map[string]int64, error)
 	CountClients(from, to time.Time, timeFrom, timeTo string) (int64, error)
 	CountClientsByPeriods(periods []string) (map[string]int64, error)
 	CountAnonymous(from, to time.Time, timeFrom, timeTo string) (int64, error)
@@ -27,6 +29,39 @@ type IUserRepository interface {
 	GetClientsDateRange() (time.Time, time.Time, error)
 }
 
+// LeadScoreFilter representa os filtros de pontuação da listagem de leads (versão ativa das regras)
+type LeadScoreFilter struct {
+	MinScore *float64
+	MaxScore *float64
+	Tier     string
+}
+
+// IsSet indica se algum filtro de pontuação foi informado
+func (f LeadScoreFilter) IsSet() bool {
+	return f.MinScore != nil || f.MaxScore != nil || f.Tier != ""
+}
+
+// leadScoreJoin expõe a pontuação e o tier de cada lead na versão ativa das regras
+const leadScoreJoin = `LEFT JOIN (
+	SELECT user_id AS scored_user_id, score, tier
+	FROM lead_scores
+	WHERE rule_version = (SELECT version FROM lead_scoring_rules WHERE is_active LIMIT 1)
+) ls ON ls.scored_user_id = users.user_id`
+
+// applyLeadScoreFilter aplica os filtros de pontuação sobre o join de leadScoreJoin
+func applyLeadScoreFilter(query *gorm.DB, filter LeadScoreFilter) *gorm.DB {
+	if filter.MinScore != nil {
+		query = query.Where("ls.score >= ?", *filter.MinScore)
+	}
+	if filter.MaxScore != nil {
+		query = query.Where("ls.score <= ?", *filter.MaxScore)
+	}
+	if filter.Tier != "" {
+		query = query.Where("LOWER(ls.tier) = LOWER(?)", filter.Tier)
+	}
+	return query
+}
+
 type UserRepository struct {
 	db    *gorm.DB
 	cache *cache.Cache
@@ -100,17 +135,29 @@ func (r *UserRepository) GetUsers(ctx context.Context, page, limit int, orderBy
 }
 
 // FindLeads retorna todos os usuários que são leads com paginação, ordenação e filtro de período
-func (r *UserRepository) FindLeads(ctx context.Context, page, limit int, orderBy string, from, to time.Time, timeFrom, timeTo string) ([]entities.User, int64, error) {
+// userPage é a página de usuários mantida em cache com seus metadados de paginação
+type userPage struct {
+	users  []entities.User
+	result PageResult
+}
+
+// userPagePosition é a chave de ordenação (created_at, user_id) usada pelo modo keyset
+func userPagePosition(user entities.User) (time.Time, string) {
+	return user.CreatedAt, user.UserID
+}
+
+func (r *UserRepository) FindLeads(ctx context.Context, page PageRequest, orderBy string, from, to time.Time, timeFrom, timeTo string, scoreFilter LeadScoreFilter, segment SegmentFilter) ([]entities.User, PageResult, error) {
 	// Gerar chave de cache baseada nos parâmetros
-	cacheKey := fmt.Sprintf("leads:%d:%d:%s:%v:%v:%s:%s",
-		page, limit, orderBy, from, to, timeFrom, timeTo)
+	cacheKey := fmt.Sprintf("leads:%s:%s:%v:%v:%s:%s:%s:%s",
+		page.Key(), orderBy, from, to, timeFrom, timeTo, scoreFilterKey(scoreFilter), segment.Key())
 
 	fmt.Printf("FindLeads chamado com from=%v, to=%v\n", from, to)
 
 	// Tentar obter do cache
 	if cached, found := r.cache.Get(cacheKey); found {
 		fmt.Println("Retornando dados do cache para leads")
-		return cached.([]entities.User), 0, nil
+		cachedPage := cached.(userPage)
+		return cachedPage.users, cachedPage.result, nil
 	}
 
 	// Adicionar timeout ao contexto
@@ -118,40 +165,19 @@ func (r *UserRepository) FindLeads(ctx context.Context, page, limit int, orderBy
 	defer cancel()
 
 	var users []entities.User
-	var total int64
-
-	offset := (page - 1) * limit
+	result := PageResult{TotalMode: page.Total}
 
 	// Query otimizada para contagem
 	countQuery := r.db.WithContext(ctx).Model(&entities.User{}).
 		Where(`"isIdentified" = ? AND "isClient" = ?`, true, false)
 
 	// Aplicar filtros de data
-	if !from.IsZero() && !to.IsZero() {
-		fromTime := from
-		toTime := to
-
-		if timeFrom != "" {
-			timeParts := strings.Split(timeFrom, ":")
-			if len(timeParts) >= 2 {
-				hour, _ := strconv.Atoi(timeParts[0])
-				min, _ := strconv.Atoi(timeParts[1])
-				fromTime = time.Date(from.Year(), from.Month(), from.Day(), hour, min, 0, 0, from.Location())
-			}
-		}
-
-		if timeTo != "" {
-			timeParts := strings.Split(timeTo, ":")
-			if len(timeParts) >= 2 {
-				hour, _ := strconv.Atoi(timeParts[0])
-				min, _ := strconv.Atoi(timeParts[1])
-				toTime = time.Date(to.Year(), to.Month(), to.Day(), hour, min, 59, 999999999, to.Location())
-			}
-		}
+	countQuery = applyCreatedAtFilter(countQuery, from, to, timeFrom, timeTo)
 
-		countQuery = countQuery.Where("created_at >= ?::timestamptz AND created_at <= ?::timestamptz", fromTime, toTime)
-		fmt.Printf("Aplicando filtro de data no CountQuery: %v até %v\n", fromTime, toTime)
+	if scoreFilter.IsSet() {
+		countQuery = applyLeadScoreFilter(countQuery.Joins(leadScoreJoin), scoreFilter)
 	}
+	countQuery = segment.Apply(countQuery, "users.user_id")
 
 	// Get SQL for debug (countQuery)
 	countStmt := countQuery.Statement
@@ -160,41 +186,22 @@ func (r *UserRepository) FindLeads(ctx context.Context, page, limit int, orderBy
 	fmt.Printf("SQL para contagem de leads: %s, Vars: %v\n", countSQL, countVars)
 
 	// Obter total
-	if err := countQuery.Count(&total).Error; err != nil {
-		return nil, 0, err
+	total, err := countTotal(ctx, countQuery, page.Total)
+	if err != nil {
+		return nil, PageResult{}, err
 	}
+	result.Total = total
 
 	// Query principal otimizada
 	query := r.db.WithContext(ctx).Model(&entities.User{}).
-		Select("user_id, name, email, phone, created_at, \"isIdentified\", \"isClient\"").
+		Select("user_id, name, email, phone, created_at, \"isIdentified\", \"isClient\", ls.score AS lead_score, ls.tier AS lead_tier").
+		Joins(leadScoreJoin).
 		Where(`"isIdentified" = ? AND "isClient" = ?`, true, false)
+	query = applyLeadScoreFilter(query, scoreFilter)
+	query = segment.Apply(query, "users.user_id")
 
 	// Aplicar filtros de data
-	if !from.IsZero() && !to.IsZero() {
-		fromTime := from
-		toTime := to
-
-		if timeFrom != "" {
-			timeParts := strings.Split(timeFrom, ":")
-			if len(timeParts) >= 2 {
-				hour, _ := strconv.Atoi(timeParts[0])
-				min, _ := strconv.Atoi(timeParts[1])
-				fromTime = time.Date(from.Year(), from.Month(), from.Day(), hour, min, 0, 0, from.Location())
-			}
-		}
-
-		if timeTo != "" {
-			timeParts := strings.Split(timeTo, ":")
-			if len(timeParts) >= 2 {
-				hour, _ := strconv.Atoi(timeParts[0])
-				min, _ := strconv.Atoi(timeParts[1])
-				toTime = time.Date(to.Year(), to.Month(), to.Day(), hour, min, 59, 999999999, to.Location())
-			}
-		}
-
-		query = query.Where("created_at >= ?::timestamptz AND created_at <= ?::timestamptz", fromTime, toTime)
-		fmt.Printf("Aplicando filtro de data no Query principal: %v até %v\n", fromTime, toTime)
-	}
+	query = applyCreatedAtFilter(query, from, to, timeFrom, timeTo)
 
 	// Get SQL for debug (main query)
 	stmt := query.Statement
@@ -202,84 +209,130 @@ func (r *UserRepository) FindLeads(ctx context.Context, page, limit int, orderBy
 	vars := stmt.Vars
 	fmt.Printf("SQL para busca de leads: %s, Vars: %v\n", sql, vars)
 
-	// Aplicar ordenação
-	if orderBy != "" {
-		query = query.Order(orderBy)
-	} else {
-		query = query.Order("created_at DESC")
+	// Aplicar ordenação e paginação (offset ou keyset em created_at, user_id)
+	if orderBy == "" {
+		orderBy = "created_at DESC"
 	}
-
-	// Aplicar paginação
-	query = query.Offset(offset).Limit(limit)
+	query = page.apply(query, orderBy, "users.created_at", "users.user_id")
 
 	// Executar query
 	if err := query.Find(&users).Error; err != nil {
-		return nil, 0, err
+		return nil, PageResult{}, err
 	}
 
+	users = trimKeysetPage(users, page, &result, userPagePosition)
+
 	// Armazenar no cache por 5 minutos
-	r.cache.Set(cacheKey, users, 5*time.Minute)
+	r.cache.Set(cacheKey, userPage{users: users, result: result}, 5*time.Minute)
 
-	return users, total, nil
+	return users, result, nil
 }
 
-// FindClients retorna todos os usuários que são clientes com paginação, ordenação e filtro de período
-func (r *UserRepository) FindClients(page, limit int, orderBy string, from, to time.Time, timeFrom, timeTo string) ([]entities.User, int64, error) {
-	var clients []entities.User
-	var total int64
-	offset := (page - 1) * limit
-
-	// Use the isClient index
-	query := r.db.Where(`"isClient" = ?`, true)
+func scoreFilterKey(filter LeadScoreFilter) string {
+	key := filter.Tier
+	if filter.MinScore != nil {
+		key += fmt.Sprintf(":min=%v", *filter.MinScore)
+	}
+	if filter.MaxScore != nil {
+		key += fmt.Sprintf(":max=%v", *filter.MaxScore)
+	}
+	return key
+}
 
-	// Verificar se há filtro de período
-	hasDateFilter := !from.IsZero() && !to.IsZero()
+// applyCreatedAtFilter restringe a consulta aos usuários criados no período, com time_from/time_to
+// (HH:MM) ajustando o horário do primeiro e do último dia
+func applyCreatedAtFilter(query *gorm.DB, from, to time.Time, timeFrom, timeTo string) *gorm.DB {
+	if from.IsZero() || to.IsZero() {
+		return query
+	}
 
-	if hasDateFilter {
-		fromTime := from
-		toTime := to
+	fromTime := from
+	toTime := to
 
-		if timeFrom != "" {
-			timeParts := strings.Split(timeFrom, ":")
-			if len(timeParts) >= 2 {
-				hour, _ := strconv.Atoi(timeParts[0])
-				min, _ := strconv.Atoi(timeParts[1])
-				fromTime = time.Date(from.Year(), from.Month(), from.Day(), hour, min, 0, 0, from.Location())
-			}
+	if timeFrom != "" {
+		timeParts := strings.Split(timeFrom, ":")
+		if len(timeParts) >= 2 {
+			hour, _ := strconv.Atoi(timeParts[0])
+			min, _ := strconv.Atoi(timeParts[1])
+			fromTime = time.Date(from.Year(), from.Month(), from.Day(), hour, min, 0, 0, from.Location())
 		}
+	}
 
-		if timeTo != "" {
-			timeParts := strings.Split(timeTo, ":")
-			if len(timeParts) >= 2 {
-				hour, _ := strconv.Atoi(timeParts[0])
-				min, _ := strconv.Atoi(timeParts[1])
-				toTime = time.Date(to.Year(), to.Month(), to.Day(), hour, min, 59, 999999999, to.Location())
-			}
+	if timeTo != "" {
+		timeParts := strings.Split(timeTo, ":")
+		if len(timeParts) >= 2 {
+			hour, _ := strconv.Atoi(timeParts[0])
+			min, _ := strconv.Atoi(timeParts[1])
+			toTime = time.Date(to.Year(), to.Month(), to.Day(), hour, min, 59, 999999999, to.Location())
 		}
+	}
 
-		query = query.Where("created_at >= ?::timestamptz AND created_at <= ?::timestamptz", fromTime, toTime)
+	return query.Where("created_at >= ?::timestamptz AND created_at <= ?::timestamptz", fromTime, toTime)
+}
+
+// ExportLeads percorre os leads com os mesmos filtros da listagem, entregando as colunas pedidas
+// linha a linha a fn direto do cursor do banco
+func (r *UserRepository) ExportLeads(ctx context.Context, columns []ExportColumn, sortBy, sortDirection string, from, to time.Time, timeFrom, timeTo string, scoreFilter LeadScoreFilter, segment SegmentFilter, fn ExportRowFunc) error {
+	query := r.db.WithContext(ctx).Model(&entities.User{}).
+		Joins(leadScoreJoin).
+		Where(`"isIdentified" = ? AND "isClient" = ?`, true, false)
+	query = applyLeadScoreFilter(query, scoreFilter)
+	query = segment.Apply(query, "users.user_id")
+	query = applyCreatedAtFilter(query, from, to, timeFrom, timeTo)
+
+	orderBy, err := LeadExportOrder(sortBy, sortDirection)
+	if err != nil {
+		return err
 	}
+	return streamExportRows(query.Order(orderBy), columns, fn)
+}
+
+// ExportClients percorre os clientes com os mesmos filtros da listagem, entregando as colunas pedidas
+// linha a linha a fn direto do cursor do banco
+func (r *UserRepository) ExportClients(ctx context.Context, columns []ExportColumn, sortBy, sortDirection string, from, to time.Time, timeFrom, timeTo string, fn ExportRowFunc) error {
+	query := r.db.WithContext(ctx).Model(&entities.User{}).Where(`"isClient" = ?`, true)
+	query = applyCreatedAtFilter(query, from, to, timeFrom, timeTo)
+
+	orderBy, err := ClientExportOrder(sortBy, sortDirection)
+	if err != nil {
+		return err
+	}
+	return streamExportRows(query.Order(orderBy), columns, fn)
+}
+
+// FindClients retorna todos os usuários que são clientes com paginação, ordenação e filtro de período
+func (r *UserRepository) FindClients(ctx context.Context, page PageRequest, orderBy string, from, to time.Time, timeFrom, timeTo string) ([]entities.User, PageResult, error) {
+	var clients []entities.User
+	result := PageResult{TotalMode: page.Total}
+
+	// Use the isClient index
+	query := r.db.WithContext(ctx).Where(`"isClient" = ?`, true)
+
+	// Aplicar filtro de período
+	query = applyCreatedAtFilter(query, from, to, timeFrom, timeTo)
 
 	// Separate count query
-	countQuery := query
-	if err := countQuery.Model(&entities.User{}).Count(&total).Error; err != nil {
-		return nil, 0, err
+	total, err := countTotal(ctx, query.Model(&entities.User{}), page.Total)
+	if err != nil {
+		return nil, PageResult{}, err
 	}
+	result.Total = total
 
 	if orderBy == "" {
 		orderBy = "user_id asc"
 	}
-	query = query.Order(orderBy)
 
-	// Use pagination for efficiency
-	query = query.Offset(offset).Limit(limit)
+	// Use pagination for efficiency (offset or keyset on created_at, user_id)
+	query = page.apply(query, orderBy, "created_at", "user_id")
 
-	err := query.Find(&clients).Error
+	err = query.Find(&clients).Error
 	if err != nil {
-		return nil, 0, err
+		return nil, PageResult{}, err
 	}
 
-	return clients, total, nil
+	clients = trimKeysetPage(clients, page, &result, userPagePosition)
+
+	return clients, result, nil
 }
 
 // FindAnonymous retorna todos os usuários anônimos com paginação, ordenação e filtro de período
@@ -335,11 +388,12 @@ func (r *UserRepository) FindAnonymous(page, limit int, orderBy string, from, to
 	return anonymous, total, err
 }
 
-func (r *UserRepository) CountLeads(from, to time.Time, timeFrom, timeTo string) (int64, error) {
+func (r *UserRepository) CountLeads(from, to time.Time, timeFrom, timeTo string, segment SegmentFilter) (int64, error) {
 	fmt.Printf("CountLeads chamado com from=%v, to=%v\n", from, to)
 
 	var count int64
 	query := r.db.Model(&entities.User{}).Where(`"isIdentified" = ? AND "isClient" = ?`, true, false)
+	query = segment.Apply(query, "user_id")
 
 	// Apply date filter
 	if !from.IsZero() && !to.IsZero() {
@@ -383,7 +437,7 @@ func (r *UserRepository) CountLeads(from, to time.Time, timeFrom, timeTo string)
 }
 
 // CountLeadsByPeriods counts leads for multiple periods
-func (r *UserRepository) CountLeadsByPeriods(periods []string) (map[string]int64, error) {
+func (r *UserRepository) CountLeadsByPeriods(periods []string, segment SegmentFilter) (map[string]int64, error) {
 	result := make(map[string]int64)
 
 	for _, period := range periods {
@@ -399,10 +453,10 @@ func (r *UserRepository) CountLeadsByPeriods(periods []string) (map[string]int64
 
 		// Contar leads no período - use os mesmos filtros de CountLeads
 		var count int64
-		err = r.db.Model(&entities.User{}).
+		query := r.db.Model(&entities.User{}).
 			Where(`"isIdentified" = ? AND "isClient" = ?`, true, false).
-			Where("created_at >= ?::timestamptz AND created_at <= ?::timestamptz", startOfDay, endOfDay).
-			Count(&count).Error
+			Where("created_at >= ?::timestamptz AND created_at <= ?::timestamptz", startOfDay, endOfDay)
+		err = segment.Apply(query, "user_id").Count(&count).Error
 
 		if err != nil {
 			return nil, err
@@ -0,0 +1,414 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WarehouseSchemaVersion é a versão dos schemas dos datasets do data warehouse. Colunas só são adicionadas
+// ao final; renomear, remover ou mudar o tipo de uma coluna exige uma nova versão.
+const WarehouseSchemaVersion = 1
+
+// Tipos das colunas dos datasets do data warehouse
+const (
+	WarehouseBoolean   = "boolean"
+	WarehouseInt32     = "int32"
+	WarehouseDouble    = "double"
+	WarehouseString    = "string"
+	WarehouseJSON      = "json"
+	WarehouseTimestamp = "timestamp"
+)
+
+// Chave do lock que impede exportações do data warehouse concorrentes entre instâncias
+const warehouseExportLockKey = 7310048
+
+// WarehouseColumn é uma coluna de um dataset do data warehouse: o nome no schema, a expressão SQL e o tipo.
+// Colunas com dados pessoais (PII) são exportadas mascaradas com MaskPII, como nas exportações com mask_pii.
+type WarehouseColumn struct {
+	Name string `json:"name"`
+	SQL  string `json:"-"`
+	Type string `json:"type"`
+	PII  string `json:"pii,omitempty"`
+}
+
+// WarehouseDataset define um dataset exportado para o data warehouse. As linhas são particionadas e
+// exportadas incrementalmente por TimeColumn.
+type WarehouseDataset struct {
+	Name       string            `json:"dataset"`
+	TimeColumn string            `json:"time_column"`
+	Columns    []WarehouseColumn `json:"columns"`
+	from       string
+	timeSQL    string
+	idSQL      string
+}
+
+// WarehouseDatasets são os datasets exportáveis, com schemas que espelham entities.Event, entities.Session,
+// entities.User e entities.SurveyAnswer (com o contexto da resposta)
+var WarehouseDatasets = []WarehouseDataset{
+	{
+		Name:       "events",
+		TimeColumn: "event_time",
+		from:       "events e",
+		timeSQL:    "e.event_time",
+		idSQL:      "e.event_id",
+		Columns: []WarehouseColumn{
+			{Name: "event_id", SQL: "e.event_id", Type: WarehouseString},
+			{Name: "event_name", SQL: "e.event_name", Type: WarehouseString},
+			{Name: "pageview_id", SQL: "e.pageview_id", Type: WarehouseString},
+			{Name: "session_id", SQL: "e.session_id", Type: WarehouseString},
+			{Name: "event_time", SQL: "e.event_time", Type: WarehouseTimestamp},
+			{Name: "user_id", SQL: "e.user_id", Type: WarehouseString},
+			{Name: "profession_id", SQL: "e.profession_id", Type: WarehouseInt32},
+			{Name: "product_id", SQL: "e.product_id", Type: WarehouseInt32},
+			{Name: "funnel_id", SQL: "e.funnel_id", Type: WarehouseInt32},
+			{Name: "event_source", SQL: "e.event_source", Type: WarehouseString},
+			{Name: "event_type", SQL: "e.event_type", Type: WarehouseString},
+			{Name: "event_properties", SQL: "e.event_propeties", Type: WarehouseJSON},
+		},
+	},
+	{
+		Name:       "sessions",
+		TimeColumn: "session_start",
+		from:       "sessions s",
+		timeSQL:    `s."sessionStart"`,
+		idSQL:      "s.session_id",
+		Columns: []WarehouseColumn{
+			{Name: "session_id", SQL: "s.session_id", Type: WarehouseString},
+			{Name: "user_id", SQL: "s.user_id", Type: WarehouseString},
+			{Name: "session_start", SQL: `s."sessionStart"`, Type: WarehouseTimestamp},
+			{Name: "is_active", SQL: `s."isActive"`, Type: WarehouseBoolean},
+			{Name: "last_activity", SQL: `s."lastActivity"`, Type: WarehouseTimestamp},
+			{Name: "country", SQL: "s.country", Type: WarehouseString},
+			{Name: "country_code", SQL: `s."countryCode"`, Type: WarehouseString},
+			{Name: "city", SQL: "s.city", Type: WarehouseString},
+			{Name: "state", SQL: "s.state", Type: WarehouseString},
+			{Name: "state_code", SQL: `s."stateCode"`, Type: WarehouseString},
+			{Name: "zip", SQL: "s.zip", Type: WarehouseString},
+			{Name: "ip_address", SQL: `s."ipAddress"`, Type: WarehouseString, PII: PIIIP},
+			{Name: "utm_source", SQL: `s."utmSource"`, Type: WarehouseString},
+			{Name: "utm_medium", SQL: `s."utmMedium"`, Type: WarehouseString},
+			{Name: "utm_campaign", SQL: `s."utmCampaign"`, Type: WarehouseString},
+			{Name: "utm_content", SQL: `s."utmContent"`, Type: WarehouseString},
+			{Name: "utm_term", SQL: `s."utmTerm"`, Type: WarehouseString},
+			{Name: "user_agent", SQL: `s."userAgent"`, Type: WarehouseString},
+			{Name: "session_end", SQL: `s."sessionEnd"`, Type: WarehouseTimestamp},
+			{Name: "duration", SQL: "s.duration", Type: WarehouseInt32},
+			{Name: "fbp", SQL: "s.fbp", Type: WarehouseString},
+			{Name: "fbc", SQL: "s.fbc", Type: WarehouseString},
+			{Name: "marketing_channel", SQL: `s."marketingChannel"`, Type: WarehouseString},
+			{Name: "referrer_path", SQL: `s."referrerPath"`, Type: WarehouseString},
+			{Name: "referrer_hostname", SQL: `s."referrerHostname"`, Type: WarehouseString},
+			{Name: "referrer_query", SQL: `s."referrerQuery"`, Type: WarehouseString},
+			{Name: "referrer", SQL: "s.referrer", Type: WarehouseString},
+			{Name: "landing_page_path", SQL: `s."landingPagePath"`, Type: WarehouseString},
+			{Name: "landing_page_query", SQL: `s."landingPageQuery"`, Type: WarehouseString},
+			{Name: "landing_page", SQL: `s."landingPage"`, Type: WarehouseString},
+			{Name: "profession_id", SQL: "s.profession_id", Type: WarehouseInt32},
+			{Name: "product_id", SQL: "s.product_id", Type: WarehouseInt32},
+			{Name: "funnel_id", SQL: "s.funnel_id", Type: WarehouseInt32},
+			{Name: "is_first_session", SQL: "s.is_first_session", Type: WarehouseBoolean},
+			{Name: "is_first_session_in_funnel", SQL: "s.is_first_session_in_funnel", Type: WarehouseBoolean},
+		},
+	},
+	{
+		Name:       "users",
+		TimeColumn: "created_at",
+		from:       "users u",
+		timeSQL:    "u.created_at",
+		idSQL:      "u.user_id",
+		Columns: []WarehouseColumn{
+			{Name: "user_id", SQL: "u.user_id", Type: WarehouseString},
+			{Name: "fullname", SQL: "u.fullname", Type: WarehouseString, PII: PIIName},
+			{Name: "email", SQL: "u.email", Type: WarehouseString, PII: PIIEmail},
+			{Name: "phone", SQL: "u.phone", Type: WarehouseString, PII: PIIPhone},
+			{Name: "is_client", SQL: `u."isClient"`, Type: WarehouseBoolean},
+			{Name: "fbc", SQL: "u.fbc", Type: WarehouseString},
+			{Name: "fbp", SQL: "u.fbp", Type: WarehouseString},
+			{Name: "created_at", SQL: "u.created_at", Type: WarehouseTimestamp},
+			{Name: "initial_country", SQL: `u."initialCountry"`, Type: WarehouseString},
+			{Name: "initial_country_code", SQL: `u."initialCountryCode"`, Type: WarehouseString},
+			{Name: "initial_region", SQL: `u."initialRegion"`, Type: WarehouseString},
+			{Name: "initial_city", SQL: `u."initialCity"`, Type: WarehouseString},
+			{Name: "initial_zip", SQL: `u."initialZip"`, Type: WarehouseString},
+			{Name: "initial_ip", SQL: `u."initialIp"`, Type: WarehouseString, PII: PIIIP},
+			{Name: "initial_user_agent", SQL: `u."initialUserAgent"`, Type: WarehouseString},
+			{Name: "initial_referrer", SQL: `u."initialReferrer"`, Type: WarehouseString},
+			{Name: "initial_timezone", SQL: `u."initialTimezone"`, Type: WarehouseString},
+			{Name: "is_identified", SQL: `u."isIdentified"`, Type: WarehouseBoolean},
+			{Name: "initial_device_type", SQL: `u."initialDeviceType"`, Type: WarehouseString},
+			{Name: "initial_platform", SQL: `u."initialPlatform"`, Type: WarehouseString},
+			{Name: "initial_browser", SQL: `u."initialBrowser"`, Type: WarehouseString},
+			{Name: "initial_landing_page", SQL: `u."initialLandingPage"`, Type: WarehouseString},
+			{Name: "initial_marketing_channel", SQL: `u."initialMarketingChannel"`, Type: WarehouseString},
+			{Name: "initial_profession", SQL: `u."initialProfession"`, Type: WarehouseString},
+			{Name: "initial_funnel", SQL: `u."initialFunnel"`, Type: WarehouseString},
+			{Name: "initial_utm_source", SQL: `u."initialUtmSource"`, Type: WarehouseString},
+			{Name: "initial_utm_medium", SQL: `u."initialUtmMedium"`, Type: WarehouseString},
+			{Name: "initial_utm_campaign", SQL: `u."initialUtmCampaign"`, Type: WarehouseString},
+			{Name: "initial_utm_content", SQL: `u."initialUtmContent"`, Type: WarehouseString},
+			{Name: "initial_utm_term", SQL: `u."initialUtmTerm"`, Type: WarehouseString},
+			{Name: "initial_landing_special_path", SQL: `u."initialLandingSpecialPath"`, Type: WarehouseString},
+			{Name: "initial_referrer_domain", SQL: `u."initialReferrerDomain"`, Type: WarehouseString},
+			{Name: "initial_referrer_query", SQL: `u."initialReferrerQuery"`, Type: WarehouseString},
+			{Name: "initial_referrer_hostname", SQL: `u."initialReferrerHostname"`, Type: WarehouseString},
+			{Name: "initial_referrer_path", SQL: `u."initialReferrerPath"`, Type: WarehouseString},
+		},
+	},
+	{
+		Name:       "survey_answers",
+		TimeColumn: "timestamp",
+		from:       "survey_answers sa JOIN survey_responses sr ON sr.id = sa.survey_response_id",
+		timeSQL:    `sa."timestamp"`,
+		idSQL:      "sa.id",
+		Columns: []WarehouseColumn{
+			{Name: "id", SQL: "sa.id", Type: WarehouseString},
+			{Name: "survey_response_id", SQL: "sa.survey_response_id", Type: WarehouseString},
+			{Name: "question_id", SQL: "sa.question_id", Type: WarehouseString},
+			{Name: "question_text", SQL: "sa.question_text", Type: WarehouseString},
+			{Name: "value", SQL: "sa.value", Type: WarehouseString},
+			{Name: "score", SQL: "sa.score", Type: WarehouseInt32},
+			{Name: "time_to_answer", SQL: "sa.time_to_answer", Type: WarehouseDouble},
+			{Name: "changed", SQL: "sa.changed", Type: WarehouseBoolean},
+			{Name: "timestamp", SQL: `sa."timestamp"`, Type: WarehouseTimestamp},
+			{Name: "survey_id", SQL: "sr.survey_id", Type: WarehouseInt32},
+			{Name: "event_id", SQL: "sr.event_id", Type: WarehouseString},
+			{Name: "total_score", SQL: "sr.total_score", Type: WarehouseInt32},
+			{Name: "completed", SQL: "sr.completed", Type: WarehouseBoolean},
+			{Name: "faixa", SQL: "sr.faixa", Type: WarehouseString},
+			{Name: "definition_version", SQL: "sr.definition_version", Type: WarehouseInt32},
+			{Name: "response_created_at", SQL: "sr.created_at", Type: WarehouseTimestamp},
+		},
+	},
+}
+
+// FindWarehouseDataset retorna o dataset pelo nome
+func FindWarehouseDataset(name string) (WarehouseDataset, bool) {
+	for _, dataset := range WarehouseDatasets {
+		if dataset.Name == name {
+			return dataset, true
+		}
+	}
+	return WarehouseDataset{}, false
+}
+
+// WarehouseRowFunc recebe os valores de uma linha na ordem das colunas do dataset: bool, int64, float64,
+// string ou time.Time conforme o tipo, ou nil para NULL
+type WarehouseRowFunc func(values []interface{}) error
+
+// WarehouseRepository lê os datasets do data warehouse e mantém o estado e o manifesto das exportações
+type WarehouseRepository interface {
+	// EarliestTime retorna o menor valor da coluna de tempo do dataset, ou nil se ele estiver vazio
+	EarliestTime(ctx context.Context, dataset WarehouseDataset) (*time.Time, error)
+	// StreamRows percorre as linhas com a coluna de tempo em [from, to), ordenadas por tempo e id
+	StreamRows(ctx context.Context, dataset WarehouseDataset, from, to time.Time, fn WarehouseRowFunc) error
+
+	GetStates() ([]entities.WarehouseExportState, error)
+	// GetState retorna o estado do dataset, ou nil se ele ainda não foi exportado
+	GetState(dataset string) (*entities.WarehouseExportState, error)
+	// SavePartition registra o arquivo gerado (nil quando o intervalo não tinha linhas) e avança a marca
+	// d'água do dataset até watermark. A marca d'água nunca recua.
+	SavePartition(dataset string, file *entities.WarehouseExportFile, watermark time.Time) error
+
+	// ListFiles lista os arquivos criados após since, do mais antigo para o mais recente (dataset vazio lista todos)
+	ListFiles(dataset string, since time.Time, limit int) ([]entities.WarehouseExportFile, error)
+	GetFile(fileID int64) (*entities.WarehouseExportFile, error)
+
+	// TryLock obtém o lock das exportações do warehouse em uma conexão dedicada. Retorna false se outra
+	// instância estiver exportando; unlock libera o lock e a conexão.
+	TryLock(ctx context.Context) (unlock func(), acquired bool, err error)
+}
+
+type warehouseRepository struct {
+	db *gorm.DB
+}
+
+func NewWarehouseRepository(db *gorm.DB) WarehouseRepository {
+	return &warehouseRepository{db}
+}
+
+func (r *warehouseRepository) EarliestTime(ctx context.Context, dataset WarehouseDataset) (*time.Time, error) {
+	var earliest sql.NullTime
+	query := fmt.Sprintf("SELECT MIN(%s) FROM %s", dataset.timeSQL, dataset.from)
+	if err := r.db.WithContext(ctx).Raw(query).Row().Scan(&earliest); err != nil {
+		return nil, fmt.Errorf("erro ao buscar início do dataset %s: %w", dataset.Name, err)
+	}
+	if !earliest.Valid {
+		return nil, nil
+	}
+	return &earliest.Time, nil
+}
+
+func (r *warehouseRepository) StreamRows(ctx context.Context, dataset WarehouseDataset, from, to time.Time, fn WarehouseRowFunc) error {
+	selects := make([]string, len(dataset.Columns))
+	for i, column := range dataset.Columns {
+		switch column.Type {
+		case WarehouseBoolean:
+			selects[i] = fmt.Sprintf("(%s)::boolean", column.SQL)
+		case WarehouseInt32:
+			selects[i] = fmt.Sprintf("(%s)::integer", column.SQL)
+		case WarehouseDouble:
+			selects[i] = fmt.Sprintf("(%s)::double precision", column.SQL)
+		case WarehouseTimestamp:
+			selects[i] = column.SQL
+		default:
+			selects[i] = fmt.Sprintf("(%s)::text", column.SQL)
+		}
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s >= ? AND %s < ? ORDER BY %s, %s",
+		strings.Join(selects, ", "), dataset.from, dataset.timeSQL, dataset.timeSQL, dataset.timeSQL, dataset.idSQL)
+
+	rows, err := r.db.WithContext(ctx).Raw(query, from, to).Rows()
+	if err != nil {
+		return fmt.Errorf("erro ao consultar dataset %s: %w", dataset.Name, err)
+	}
+	defer rows.Close()
+
+	dest := make([]interface{}, len(dataset.Columns))
+	for i, column := range dataset.Columns {
+		switch column.Type {
+		case WarehouseBoolean:
+			dest[i] = new(sql.NullBool)
+		case WarehouseInt32:
+			dest[i] = new(sql.NullInt64)
+		case WarehouseDouble:
+			dest[i] = new(sql.NullFloat64)
+		case WarehouseTimestamp:
+			dest[i] = new(sql.NullTime)
+		default:
+			dest[i] = new(sql.NullString)
+		}
+	}
+	values := make([]interface{}, len(dataset.Columns))
+
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("erro ao ler linha do dataset %s: %w", dataset.Name, err)
+		}
+		for i, value := range dest {
+			values[i] = nil
+			switch v := value.(type) {
+			case *sql.NullBool:
+				if v.Valid {
+					values[i] = v.Bool
+				}
+			case *sql.NullInt64:
+				if v.Valid {
+					values[i] = v.Int64
+				}
+			case *sql.NullFloat64:
+				if v.Valid {
+					values[i] = v.Float64
+				}
+			case *sql.NullTime:
+				if v.Valid {
+					values[i] = v.Time
+				}
+			case *sql.NullString:
+				if v.Valid {
+					values[i] = MaskPII(dataset.Columns[i].PII, v.String)
+				}
+			}
+		}
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *warehouseRepository) GetStates() ([]entities.WarehouseExportState, error) {
+	var states []entities.WarehouseExportState
+	if err := r.db.Order("dataset").Find(&states).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar estado das exportações do warehouse: %w", err)
+	}
+	return states, nil
+}
+
+func (r *warehouseRepository) GetState(dataset string) (*entities.WarehouseExportState, error) {
+	var states []entities.WarehouseExportState
+	if err := r.db.Where("dataset = ?", dataset).Limit(1).Find(&states).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar estado da exportação de %s: %w", dataset, err)
+	}
+	if len(states) == 0 {
+		return nil, nil
+	}
+	return &states[0], nil
+}
+
+func (r *warehouseRepository) SavePartition(dataset string, file *entities.WarehouseExportFile, watermark time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if file != nil {
+			// O mesmo intervalo gera o mesmo caminho: reexportar substitui o registro
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "path"}},
+				DoUpdates: clause.AssignmentColumns([]string{"rows", "bytes", "created_at"}),
+			}).Create(file).Error; err != nil {
+				return fmt.Errorf("erro ao registrar arquivo do warehouse: %w", err)
+			}
+		}
+
+		if err := tx.Exec(`
+			INSERT INTO warehouse_export_state (dataset, watermark, updated_at)
+			VALUES (?, ?, NOW())
+			ON CONFLICT (dataset) DO UPDATE
+			SET watermark = GREATEST(warehouse_export_state.watermark, EXCLUDED.watermark), updated_at = NOW()`,
+			dataset, watermark).Error; err != nil {
+			return fmt.Errorf("erro ao atualizar marca d'água de %s: %w", dataset, err)
+		}
+		return nil
+	})
+}
+
+func (r *warehouseRepository) ListFiles(dataset string, since time.Time, limit int) ([]entities.WarehouseExportFile, error) {
+	query := r.db.Where("created_at > ?", since)
+	if dataset != "" {
+		query = query.Where("dataset = ?", dataset)
+	}
+
+	var files []entities.WarehouseExportFile
+	if err := query.Order("created_at, file_id").Limit(limit).Find(&files).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar arquivos do warehouse: %w", err)
+	}
+	return files, nil
+}
+
+func (r *warehouseRepository) GetFile(fileID int64) (*entities.WarehouseExportFile, error) {
+	var file entities.WarehouseExportFile
+	if err := r.db.First(&file, "file_id = ?", fileID).Error; err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (r *warehouseRepository) TryLock(ctx context.Context) (func(), bool, error) {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return nil, false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("erro ao abrir conexão para o lock do warehouse: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", warehouseExportLockKey).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("erro ao obter lock do warehouse: %w", err)
+	}
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	return func() {
+		_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", warehouseExportLockKey)
+		conn.Close()
+	}, true, nil
+}
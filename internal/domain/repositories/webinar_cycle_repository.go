@@ -0,0 +1,262 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+	"gorm.io/gorm"
+)
+
+// WebinarCycleFilter representa os filtros de listagem de ciclos
+type WebinarCycleFilter struct {
+	ProfessionID int
+	FunnelID     int
+	// ProfessionWide restringe aos ciclos sem funil (ciclo geral da profissão)
+	ProfessionWide bool
+	From           time.Time
+	To             time.Time
+}
+
+// WebinarCycleMetrics representa os totais de um ciclo: leads e respostas da captação, vendas da janela de vendas
+type WebinarCycleMetrics struct {
+	CycleID         int64   `json:"-" gorm:"column:cycle_id"`
+	Leads           int64   `json:"leads" gorm:"column:leads"`
+	SurveyResponses int64   `json:"survey_responses" gorm:"column:survey_responses"`
+	Purchases       int64   `json:"purchases" gorm:"column:purchases"`
+	Revenue         float64 `json:"revenue" gorm:"column:revenue"`
+}
+
+// WebinarCycleRepository interface para operações de ciclos de webinar
+type WebinarCycleRepository interface {
+	ListCycles(filter WebinarCycleFilter, page, limit int) ([]entities.WebinarCycle, int64, error)
+	GetCycleByID(cycleID int64) (*entities.WebinarCycle, error)
+	GetCycleByName(professionID int, name string) (*entities.WebinarCycle, error)
+	GetPreviousCycle(cycle *entities.WebinarCycle) (*entities.WebinarCycle, error)
+	CreateCycle(cycle *entities.WebinarCycle) error
+	UpdateCycle(cycle *entities.WebinarCycle) error
+	DeleteCycle(cycleID int64) error
+	// CreateGeneratedCycle insere o ciclo apenas se não existir outro da mesma profissão/funil na mesma semana
+	CreateGeneratedCycle(cycle *entities.WebinarCycle) (bool, error)
+	GetCycleMetrics(cycleIDs []int64) (map[int64]WebinarCycleMetrics, error)
+	// GetFaixaDistribution retorna a quantidade de respostas de pesquisa por faixa na janela de captação
+	GetFaixaDistribution(cycle utils.WebinarCycleDates, professionID, funnelID int) (map[string]int64, error)
+}
+
+type webinarCycleRepository struct {
+	db *gorm.DB
+}
+
+func NewWebinarCycleRepository(db *gorm.DB) WebinarCycleRepository {
+	return &webinarCycleRepository{db}
+}
+
+func (r *webinarCycleRepository) applyFilter(query *gorm.DB, filter WebinarCycleFilter) *gorm.DB {
+	if filter.ProfessionID > 0 {
+		query = query.Where("profession_id = ?", filter.ProfessionID)
+	}
+	if filter.FunnelID > 0 {
+		query = query.Where("funnel_id = ?", filter.FunnelID)
+	} else if filter.ProfessionWide {
+		query = query.Where("funnel_id IS NULL")
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("venda_fim >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("pesquisa_inicio <= ?", filter.To)
+	}
+	return query
+}
+
+func (r *webinarCycleRepository) ListCycles(filter WebinarCycleFilter, page, limit int) ([]entities.WebinarCycle, int64, error) {
+	var cycles []entities.WebinarCycle
+	var total int64
+
+	if err := r.applyFilter(r.db.Model(&entities.WebinarCycle{}), filter).Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("erro ao contar ciclos: %w", err)
+	}
+
+	offset := (page - 1) * limit
+	if err := r.applyFilter(r.db.Model(&entities.WebinarCycle{}), filter).
+		Order("venda_inicio DESC, cycle_id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&cycles).Error; err != nil {
+		return nil, 0, fmt.Errorf("erro ao buscar ciclos: %w", err)
+	}
+
+	return cycles, total, nil
+}
+
+func (r *webinarCycleRepository) GetCycleByID(cycleID int64) (*entities.WebinarCycle, error) {
+	var cycle entities.WebinarCycle
+	if err := r.db.First(&cycle, "cycle_id = ?", cycleID).Error; err != nil {
+		return nil, err
+	}
+	return &cycle, nil
+}
+
+// GetCycleByName retorna o ciclo mais recente da profissão com o nome informado (sem diferenciar maiúsculas)
+func (r *webinarCycleRepository) GetCycleByName(professionID int, name string) (*entities.WebinarCycle, error) {
+	var cycle entities.WebinarCycle
+	query := r.db.Where("LOWER(name) = LOWER(?)", name)
+	if professionID > 0 {
+		query = query.Where("profession_id = ?", professionID)
+	}
+	if err := query.Order("venda_inicio DESC").First(&cycle).Error; err != nil {
+		return nil, err
+	}
+	return &cycle, nil
+}
+
+// GetPreviousCycle retorna o ciclo imediatamente anterior da mesma profissão/funil, ou nil se não existir
+func (r *webinarCycleRepository) GetPreviousCycle(cycle *entities.WebinarCycle) (*entities.WebinarCycle, error) {
+	var previous entities.WebinarCycle
+
+	query := r.db.Where("profession_id = ? AND venda_inicio < ?", cycle.ProfessionID, cycle.VendaInicio)
+	if cycle.FunnelID != nil {
+		query = query.Where("funnel_id = ?", *cycle.FunnelID)
+	} else {
+		query = query.Where("funnel_id IS NULL")
+	}
+
+	err := query.Order("venda_inicio DESC").First(&previous).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar ciclo anterior: %w", err)
+	}
+	return &previous, nil
+}
+
+func (r *webinarCycleRepository) CreateCycle(cycle *entities.WebinarCycle) error {
+	return r.db.Create(cycle).Error
+}
+
+func (r *webinarCycleRepository) UpdateCycle(cycle *entities.WebinarCycle) error {
+	cycle.UpdatedAt = time.Now()
+	return r.db.Save(cycle).Error
+}
+
+func (r *webinarCycleRepository) DeleteCycle(cycleID int64) error {
+	result := r.db.Delete(&entities.WebinarCycle{}, "cycle_id = ?", cycleID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *webinarCycleRepository) CreateGeneratedCycle(cycle *entities.WebinarCycle) (bool, error) {
+	// Um ciclo sobrescrito pode ter sido movido alguns dias (feriado); considerar a semana inteira
+	weekStart := cycle.VendaInicio.AddDate(0, 0, -3)
+	weekEnd := cycle.VendaInicio.AddDate(0, 0, 4)
+
+	var existing int64
+	query := r.db.Model(&entities.WebinarCycle{}).
+		Where("profession_id = ? AND venda_inicio >= ? AND venda_inicio < ?", cycle.ProfessionID, weekStart, weekEnd)
+	if cycle.FunnelID != nil {
+		query = query.Where("funnel_id = ?", *cycle.FunnelID)
+	} else {
+		query = query.Where("funnel_id IS NULL")
+	}
+	if err := query.Count(&existing).Error; err != nil {
+		return false, fmt.Errorf("erro ao verificar ciclo existente: %w", err)
+	}
+	if existing > 0 {
+		return false, nil
+	}
+
+	if err := r.db.Create(cycle).Error; err != nil {
+		return false, fmt.Errorf("erro ao criar ciclo: %w", err)
+	}
+	return true, nil
+}
+
+func (r *webinarCycleRepository) GetCycleMetrics(cycleIDs []int64) (map[int64]WebinarCycleMetrics, error) {
+	metrics := make(map[int64]WebinarCycleMetrics, len(cycleIDs))
+	if len(cycleIDs) == 0 {
+		return metrics, nil
+	}
+
+	var rows []WebinarCycleMetrics
+	query := `
+		SELECT
+			wc.cycle_id,
+			COALESCE(m.leads, 0) AS leads,
+			COALESCE(m.survey_responses, 0) AS survey_responses,
+			COALESCE(m.purchases, 0) AS purchases,
+			COALESCE(m.revenue, 0) AS revenue
+		FROM webinar_cycles wc
+		LEFT JOIN LATERAL (
+			SELECT
+				COUNT(*) FILTER (WHERE e.event_type = 'LEAD'
+					AND e.event_time BETWEEN wc.pesquisa_inicio AND wc.pesquisa_fim) AS leads,
+				COUNT(*) FILTER (WHERE e.event_type = 'PESQUISA_LEAD'
+					AND e.event_time BETWEEN wc.pesquisa_inicio AND wc.pesquisa_fim) AS survey_responses,
+				COUNT(*) FILTER (WHERE e.event_type = 'PURCHASE'
+					AND e.event_time BETWEEN wc.venda_inicio AND wc.venda_fim) AS purchases,
+				SUM(CASE WHEN e.event_type = 'PURCHASE'
+					AND e.event_time BETWEEN wc.venda_inicio AND wc.venda_fim
+				THEN ` + purchaseValueSQL("e") + `
+				ELSE 0 END) AS revenue
+			FROM events e
+			WHERE e.profession_id = wc.profession_id
+			AND (wc.funnel_id IS NULL OR e.funnel_id = wc.funnel_id)
+			AND e.event_type IN ('LEAD', 'PESQUISA_LEAD', 'PURCHASE')
+			AND e.event_time BETWEEN LEAST(wc.pesquisa_inicio, wc.venda_inicio) AND GREATEST(wc.pesquisa_fim, wc.venda_fim)
+		) m ON TRUE
+		WHERE wc.cycle_id IN ?
+	`
+
+	if err := r.db.Raw(query, cycleIDs).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar métricas dos ciclos: %w", err)
+	}
+
+	for _, row := range rows {
+		metrics[row.CycleID] = row
+	}
+	return metrics, nil
+}
+
+func (r *webinarCycleRepository) GetFaixaDistribution(cycle utils.WebinarCycleDates, professionID, funnelID int) (map[string]int64, error) {
+	var rows []struct {
+		Faixa string `gorm:"column:faixa"`
+		Count int64  `gorm:"column:count"`
+	}
+
+	query := `
+		SELECT COALESCE(NULLIF(sr.faixa, ''), 'sem_faixa') AS faixa, COUNT(DISTINCT sr.id) AS count
+		FROM survey_responses sr
+		JOIN events e ON e.event_id = sr.event_id
+		WHERE e.event_type = 'PESQUISA_LEAD'
+		AND e.event_time BETWEEN ? AND ?
+	`
+	args := []interface{}{cycle.PesquisaInicio, cycle.PesquisaFim}
+
+	if professionID > 0 {
+		query += " AND e.profession_id = ?"
+		args = append(args, professionID)
+	}
+	if funnelID > 0 {
+		query += " AND e.funnel_id = ?"
+		args = append(args, funnelID)
+	}
+	query += " GROUP BY 1"
+
+	if err := r.db.Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("erro ao buscar distribuição de faixas: %w", err)
+	}
+
+	distribution := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		distribution[row.Faixa] = row.Count
+	}
+	return distribution, nil
+}
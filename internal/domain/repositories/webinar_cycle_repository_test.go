@@ -0,0 +1,16 @@
+package repositories
+
+import "testing"
+
+func TestGetCycleMetricsPlaceholders(t *testing.T) {
+	db, statements := newDryRunDB(t)
+	NewWebinarCycleRepository(db).GetCycleMetrics([]int64{4, 5})
+	if len(*statements) != 1 {
+		t.Fatalf("%d statements gerados; esperado 1", len(*statements))
+	}
+	stmt := (*statements)[0]
+	checkPlaceholders(t, stmt)
+	if len(stmt.Vars) != 2 {
+		t.Errorf("%d valores; esperado 2 (um por ciclo)", len(stmt.Vars))
+	}
+}
@@ -69,5 +69,65 @@ func SetupDatabase() (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to add optimized indexes: %w", err)
 	}
 
+	// Create webinar cycles table
+	if err := migrations.CreateWebinarCyclesTable(db); err != nil {
+		return nil, fmt.Errorf("failed to create webinar cycles table: %w", err)
+	}
+
+	// Create lead scoring tables
+	if err := migrations.CreateLeadScoringTables(db); err != nil {
+		return nil, fmt.Errorf("failed to create lead scoring tables: %w", err)
+	}
+
+	// Create propensity models table
+	if err := migrations.CreatePropensityModelsTable(db); err != nil {
+		return nil, fmt.Errorf("failed to create propensity models table: %w", err)
+	}
+
+	// Create survey definition tables
+	if err := migrations.CreateSurveyDefinitionTables(db); err != nil {
+		return nil, fmt.Errorf("failed to create survey definition tables: %w", err)
+	}
+
+	// Create survey variants table
+	if err := migrations.CreateSurveyVariantsTable(db); err != nil {
+		return nil, fmt.Errorf("failed to create survey variants table: %w", err)
+	}
+
+	// Create landing page experiment tables
+	if err := migrations.CreateExperimentTables(db); err != nil {
+		return nil, fmt.Errorf("failed to create experiment tables: %w", err)
+	}
+
+	// Create metric rollup tables
+	if err := migrations.CreateMetricRollupTables(db); err != nil {
+		return nil, fmt.Errorf("failed to create metric rollup tables: %w", err)
+	}
+
+	// Create saved segments table
+	if err := migrations.CreateSegmentsTable(db); err != nil {
+		return nil, fmt.Errorf("failed to create segments table: %w", err)
+	}
+
+	// Create asynchronous export jobs table
+	if err := migrations.CreateExportJobsTable(db); err != nil {
+		return nil, fmt.Errorf("failed to create export jobs table: %w", err)
+	}
+
+	// Create data warehouse export tables
+	if err := migrations.CreateWarehouseExportTables(db); err != nil {
+		return nil, fmt.Errorf("failed to create warehouse export tables: %w", err)
+	}
+
+	// Create scheduled email reports table
+	if err := migrations.CreateScheduledReportsTable(db); err != nil {
+		return nil, fmt.Errorf("failed to create scheduled reports table: %w", err)
+	}
+
+	// Create live feed indexes
+	if err := migrations.AddLiveFeedIndexes(db); err != nil {
+		return nil, fmt.Errorf("failed to create live feed indexes: %w", err)
+	}
+
 	return db, nil
 }
@@ -0,0 +1,23 @@
+package migrations
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// AddLiveFeedIndexes cria os índices consultados a cada poucos segundos pelo feed ao vivo (/live/stream)
+func AddLiveFeedIndexes(db *gorm.DB) error {
+	log.Println("Criando índices do feed ao vivo...")
+
+	// Novos eventos após a última posição entregue, na ordem (event_time, event_id)
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_events_live ON events (event_time, event_id) WHERE event_type IN ('LEAD', 'PESQUISA_LEAD', 'PURCHASE')`).Error; err != nil {
+		return err
+	}
+	// Sessões ativas com atividade recente
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_sessions_active_last_activity ON sessions ("lastActivity") WHERE "isActive" = true`).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
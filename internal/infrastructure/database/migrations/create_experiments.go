@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// CreateExperimentTables cria as tabelas de experimentos de landing page e suas variantes
+func CreateExperimentTables(db *gorm.DB) error {
+	log.Println("Criando tabelas de experimentos...")
+
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS experiments (
+			experiment_id BIGSERIAL PRIMARY KEY,
+			name TEXT NOT NULL,
+			funnel_id INTEGER NOT NULL,
+			start_date TIMESTAMPTZ NOT NULL,
+			end_date TIMESTAMPTZ NOT NULL,
+			conversion_window_days INTEGER NOT NULL DEFAULT 7,
+			notes TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			CHECK (start_date < end_date AND conversion_window_days >= 0)
+		)`).Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS experiment_variants (
+			variant_id BIGSERIAL PRIMARY KEY,
+			experiment_id BIGINT NOT NULL REFERENCES experiments (experiment_id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			landing_page_path TEXT NOT NULL,
+			is_control BOOLEAN NOT NULL DEFAULT FALSE,
+			UNIQUE (experiment_id, landing_page_path)
+		)`).Error; err != nil {
+		return err
+	}
+
+	// Atribuição das sessões às variantes pelo caminho normalizado da landing page
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_sessions_funnel_landing_path ON sessions (funnel_id, (regexp_replace(lower("landingPagePath"), '(.)/+$', '\1')), "sessionStart")`).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
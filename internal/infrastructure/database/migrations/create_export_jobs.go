@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// CreateExportJobsTable cria a tabela da fila de exportações assíncronas
+func CreateExportJobsTable(db *gorm.DB) error {
+	log.Println("Criando tabela de jobs de exportação...")
+
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS export_jobs (
+			job_id UUID PRIMARY KEY,
+			dataset TEXT NOT NULL,
+			format TEXT NOT NULL,
+			query TEXT NOT NULL DEFAULT '',
+			columns TEXT NOT NULL DEFAULT '',
+			mask_pii BOOLEAN NOT NULL DEFAULT TRUE,
+			status TEXT NOT NULL DEFAULT 'queued',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			rows BIGINT NOT NULL DEFAULT 0,
+			bytes BIGINT NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT '',
+			file_path TEXT NOT NULL DEFAULT '',
+			file_name TEXT NOT NULL DEFAULT '',
+			download_token TEXT NOT NULL DEFAULT '',
+			locked_until TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			started_at TIMESTAMPTZ,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			finished_at TIMESTAMPTZ,
+			expires_at TIMESTAMPTZ
+		)`).Error; err != nil {
+		return err
+	}
+
+	// Busca do próximo job da fila (pendentes e execuções com prazo vencido)
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_export_jobs_pending ON export_jobs (created_at) WHERE status IN ('queued', 'running')`).Error; err != nil {
+		return err
+	}
+
+	// Limpeza dos arquivos vencidos
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_export_jobs_expires ON export_jobs (expires_at) WHERE status = 'done'`).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package migrations
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// CreateLeadScoringTables cria as tabelas de regras versionadas e de pontuação de leads
+func CreateLeadScoringTables(db *gorm.DB) error {
+	log.Println("Criando tabelas de pontuação de leads...")
+
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS lead_scoring_rules (
+			version BIGSERIAL PRIMARY KEY,
+			name TEXT NOT NULL DEFAULT '',
+			is_active BOOLEAN NOT NULL DEFAULT FALSE,
+			rules JSONB NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`).Error; err != nil {
+		return err
+	}
+
+	// Apenas uma versão ativa por vez
+	if err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_lead_scoring_rules_active ON lead_scoring_rules (is_active) WHERE is_active`).Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS lead_scores (
+			user_id UUID NOT NULL,
+			rule_version BIGINT NOT NULL REFERENCES lead_scoring_rules (version) ON DELETE CASCADE,
+			score NUMERIC(10,2) NOT NULL DEFAULT 0,
+			tier TEXT NOT NULL DEFAULT '',
+			breakdown JSONB NOT NULL DEFAULT '{}',
+			computed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (user_id, rule_version)
+		)`).Error; err != nil {
+		return err
+	}
+
+	// Ordenação e filtro por pontuação na listagem de leads
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_lead_scores_version_score ON lead_scores (rule_version, score DESC)`).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
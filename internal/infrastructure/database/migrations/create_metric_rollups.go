@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// CreateMetricRollupTables cria as tabelas de agregados por hora e por dia usadas pelo dashboard e pelo faturamento
+func CreateMetricRollupTables(db *gorm.DB) error {
+	log.Println("Criando tabelas de rollups de métricas...")
+
+	// Agregados por hora (bucket = início da hora) e por dia (bucket = data em America/Sao_Paulo),
+	// com as mesmas dimensões e métricas
+	for _, table := range []struct{ name, bucketType string }{
+		{"metric_rollups_hourly", "TIMESTAMPTZ"},
+		{"metric_rollups_daily", "DATE"},
+	} {
+		if err := db.Exec(`
+			CREATE TABLE IF NOT EXISTS ` + table.name + ` (
+				bucket ` + table.bucketType + ` NOT NULL,
+				profession_id INTEGER NOT NULL DEFAULT 0,
+				product_id INTEGER NOT NULL DEFAULT 0,
+				funnel_id INTEGER NOT NULL DEFAULT 0,
+				landing_page TEXT NOT NULL DEFAULT '',
+				utm_source TEXT NOT NULL DEFAULT '',
+				sessions BIGINT NOT NULL DEFAULT 0,
+				leads BIGINT NOT NULL DEFAULT 0,
+				clients BIGINT NOT NULL DEFAULT 0,
+				purchases BIGINT NOT NULL DEFAULT 0,
+				revenue NUMERIC(14,2) NOT NULL DEFAULT 0,
+				PRIMARY KEY (bucket, profession_id, product_id, funnel_id, landing_page, utm_source)
+			)`).Error; err != nil {
+			return err
+		}
+		if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_` + table.name + `_profession ON ` + table.name + ` (profession_id, bucket)`).Error; err != nil {
+			return err
+		}
+		if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_` + table.name + `_funnel ON ` + table.name + ` (funnel_id, bucket)`).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS metric_rollup_state (
+			name TEXT PRIMARY KEY,
+			covered_from TIMESTAMPTZ NOT NULL,
+			watermark TIMESTAMPTZ NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`).Error; err != nil {
+		return err
+	}
+
+	// Usado no cálculo de clientes (primeira compra do usuário)
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_events_user_purchase_time ON events (user_id, event_time) WHERE event_type = 'PURCHASE'`).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
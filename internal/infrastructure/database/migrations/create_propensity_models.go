@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// CreatePropensityModelsTable cria a tabela de modelos de propensão de compra
+func CreatePropensityModelsTable(db *gorm.DB) error {
+	log.Println("Criando tabela de modelos de propensão...")
+
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS propensity_models (
+			model_id BIGSERIAL PRIMARY KEY,
+			profession_id INTEGER NOT NULL,
+			funnel_id INTEGER,
+			is_active BOOLEAN NOT NULL DEFAULT FALSE,
+			cycles_used INTEGER NOT NULL DEFAULT 0,
+			training_from TIMESTAMPTZ NOT NULL,
+			training_to TIMESTAMPTZ NOT NULL,
+			samples INTEGER NOT NULL DEFAULT 0,
+			positives INTEGER NOT NULL DEFAULT 0,
+			coefficients JSONB NOT NULL,
+			metrics JSONB NOT NULL DEFAULT '{}',
+			trained_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`).Error; err != nil {
+		return err
+	}
+
+	// Apenas um modelo ativo por profissão/funil
+	if err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_propensity_models_active ON propensity_models (profession_id, COALESCE(funnel_id, 0)) WHERE is_active`).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// CreateScheduledReportsTable cria a tabela dos relatórios enviados por e-mail
+func CreateScheduledReportsTable(db *gorm.DB) error {
+	log.Println("Criando tabela de relatórios agendados...")
+
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS scheduled_reports (
+			report_id BIGSERIAL PRIMARY KEY,
+			name TEXT NOT NULL,
+			schedule TEXT NOT NULL,
+			period TEXT NOT NULL,
+			filters JSONB NOT NULL DEFAULT '{}',
+			recipients JSONB NOT NULL DEFAULT '[]',
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			next_run_at TIMESTAMPTZ,
+			last_run_at TIMESTAMPTZ,
+			last_status TEXT NOT NULL DEFAULT '',
+			last_error TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`).Error; err != nil {
+		return err
+	}
+
+	// Busca dos relatórios com envio vencido
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_scheduled_reports_due ON scheduled_reports (next_run_at) WHERE enabled`).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// CreateSegmentsTable cria a tabela de segmentos salvos de usuários
+func CreateSegmentsTable(db *gorm.DB) error {
+	log.Println("Criando tabela de segmentos...")
+
+	return db.Exec(`
+		CREATE TABLE IF NOT EXISTS segments (
+			segment_id BIGSERIAL PRIMARY KEY,
+			name TEXT NOT NULL,
+			description TEXT NOT NULL DEFAULT '',
+			definition JSONB NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`).Error
+}
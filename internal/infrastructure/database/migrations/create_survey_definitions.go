@@ -0,0 +1,80 @@
+package migrations
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// CreateSurveyDefinitionTables cria as tabelas de definições versionadas de pesquisa e vincula
+// as respostas à versão em que foram coletadas
+func CreateSurveyDefinitionTables(db *gorm.DB) error {
+	log.Println("Criando tabelas de definições de pesquisa...")
+
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS survey_definitions (
+			definition_id BIGSERIAL PRIMARY KEY,
+			survey_id BIGINT NOT NULL,
+			version INTEGER NOT NULL,
+			is_active BOOLEAN NOT NULL DEFAULT FALSE,
+			notes TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			UNIQUE (survey_id, version)
+		)`).Error; err != nil {
+		return err
+	}
+
+	// Apenas uma versão ativa por pesquisa
+	if err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_survey_definitions_active ON survey_definitions (survey_id) WHERE is_active`).Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS survey_definition_questions (
+			id BIGSERIAL PRIMARY KEY,
+			definition_id BIGINT NOT NULL REFERENCES survey_definitions (definition_id) ON DELETE CASCADE,
+			question_id TEXT NOT NULL,
+			question_text TEXT NOT NULL,
+			type TEXT NOT NULL DEFAULT 'single_choice',
+			position INTEGER NOT NULL,
+			required BOOLEAN NOT NULL DEFAULT TRUE,
+			UNIQUE (definition_id, question_id)
+		)`).Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS survey_question_options (
+			id BIGSERIAL PRIMARY KEY,
+			question_row_id BIGINT NOT NULL REFERENCES survey_definition_questions (id) ON DELETE CASCADE,
+			value TEXT NOT NULL,
+			label TEXT NOT NULL DEFAULT '',
+			score INTEGER NOT NULL DEFAULT 0,
+			position INTEGER NOT NULL,
+			UNIQUE (question_row_id, value)
+		)`).Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS survey_faixas (
+			id BIGSERIAL PRIMARY KEY,
+			definition_id BIGINT NOT NULL REFERENCES survey_definitions (definition_id) ON DELETE CASCADE,
+			name TEXT NOT NULL,
+			min_score INTEGER NOT NULL,
+			UNIQUE (definition_id, name)
+		)`).Error; err != nil {
+		return err
+	}
+
+	// Versão da definição em que a resposta foi coletada (nula para respostas anteriores às definições)
+	if err := db.Exec(`ALTER TABLE survey_responses ADD COLUMN IF NOT EXISTS definition_version INTEGER`).Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_survey_responses_definition_version ON survey_responses (survey_id, definition_version)`).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// CreateSurveyVariantsTable cria a tabela de variantes de pesquisa por funil
+func CreateSurveyVariantsTable(db *gorm.DB) error {
+	log.Println("Criando tabela de variantes de pesquisa...")
+
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS survey_variants (
+			variant_id BIGSERIAL PRIMARY KEY,
+			funnel_id INTEGER NOT NULL,
+			survey_id BIGINT NOT NULL,
+			name TEXT NOT NULL DEFAULT '',
+			weight INTEGER NOT NULL DEFAULT 1 CHECK (weight >= 0),
+			is_control BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			UNIQUE (funnel_id, survey_id)
+		)`).Error; err != nil {
+		return err
+	}
+
+	// Apenas um controle por funil
+	if err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_survey_variants_control ON survey_variants (funnel_id) WHERE is_control`).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// CreateWarehouseExportTables cria as tabelas de estado e de arquivos das exportações Parquet do data warehouse
+func CreateWarehouseExportTables(db *gorm.DB) error {
+	log.Println("Criando tabelas de exportação do data warehouse...")
+
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS warehouse_export_state (
+			dataset TEXT PRIMARY KEY,
+			watermark TIMESTAMPTZ NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`).Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS warehouse_export_files (
+			file_id BIGSERIAL PRIMARY KEY,
+			dataset TEXT NOT NULL,
+			partition_date TEXT NOT NULL,
+			path TEXT NOT NULL UNIQUE,
+			rows BIGINT NOT NULL,
+			bytes BIGINT NOT NULL,
+			range_from TIMESTAMPTZ NOT NULL,
+			range_to TIMESTAMPTZ NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)`).Error; err != nil {
+		return err
+	}
+
+	// Manifesto incremental: arquivos de um dataset criados após um instante
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_warehouse_export_files_dataset ON warehouse_export_files (dataset, created_at)`).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// CreateWebinarCyclesTable cria a tabela de ciclos de webinar por profissão/funil
+func CreateWebinarCyclesTable(db *gorm.DB) error {
+	log.Println("Criando tabela de ciclos de webinar...")
+
+	if err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS webinar_cycles (
+			cycle_id BIGSERIAL PRIMARY KEY,
+			profession_id INTEGER NOT NULL,
+			funnel_id INTEGER,
+			name TEXT NOT NULL DEFAULT '',
+			pesquisa_inicio TIMESTAMPTZ NOT NULL,
+			pesquisa_fim TIMESTAMPTZ NOT NULL,
+			venda_inicio TIMESTAMPTZ NOT NULL,
+			venda_fim TIMESTAMPTZ NOT NULL,
+			is_override BOOLEAN NOT NULL DEFAULT FALSE,
+			notes TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			CHECK (pesquisa_inicio < pesquisa_fim AND venda_inicio < venda_fim)
+		)`).Error; err != nil {
+		return err
+	}
+
+	// Um ciclo por profissão/funil e dia de vendas
+	if err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_webinar_cycles_unique ON webinar_cycles (profession_id, COALESCE(funnel_id, 0), venda_inicio)`).Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_webinar_cycles_venda_inicio ON webinar_cycles (venda_inicio)`).Error; err != nil {
+		return err
+	}
+
+	return nil
+}
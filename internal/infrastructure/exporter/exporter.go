@@ -0,0 +1,170 @@
+// Package exporter escreve os arquivos das exportações de listagens (CSV, XLSX e NDJSON) em streaming,
+// com memória constante. É usado pelas exportações síncronas e pelo worker das exportações assíncronas.
+package exporter
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+)
+
+// Formatos de arquivo aceitos
+const (
+	FormatCSV    = "csv"
+	FormatXLSX   = "xlsx"
+	FormatNDJSON = "ndjson"
+)
+
+// Formats são os formatos aceitos, na ordem das mensagens de erro
+var Formats = []string{FormatCSV, FormatXLSX, FormatNDJSON}
+
+// FlushRows é o número de linhas de cada lote enviado ao writer
+const FlushRows = 500
+
+// Producer percorre os dados de uma exportação (normalmente o cursor do banco) chamando fn a cada linha
+type Producer func(ctx context.Context, fn repositories.ExportRowFunc) error
+
+// Write escreve o arquivo da exportação em w: cabeçalho, linhas (mascaradas quando maskPII) e finalização
+// do formato. onFlush é chamado a cada lote de FlushRows linhas, com o total escrito. Em caso de erro,
+// o arquivo é finalizado com as linhas já escritas (ver fileWriter.Abort). Retorna o total de linhas.
+func Write(ctx context.Context, w io.Writer, format string, columns []repositories.ExportColumn, maskPII bool, produce Producer, onFlush func(rows int64) error) (int64, error) {
+	writer := newFileWriter(format, w)
+	var rows int64
+	err := writer.WriteHeader(repositories.ExportColumnNames(columns))
+	if err == nil {
+		masked := make([]string, len(columns))
+		err = produce(ctx, func(values []string) error {
+			for i, value := range values {
+				if maskPII {
+					value = repositories.MaskPII(columns[i].PII, value)
+				}
+				masked[i] = value
+			}
+			if err := writer.WriteRow(masked); err != nil {
+				return err
+			}
+			rows++
+			if rows%FlushRows == 0 {
+				if err := writer.Flush(); err != nil {
+					return err
+				}
+				return onFlush(rows)
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		writer.Abort(err)
+	}
+	if closeErr := writer.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("erro ao finalizar o arquivo: %w", closeErr)
+	}
+	return rows, err
+}
+
+// ContentType retorna o Content-Type do formato
+func ContentType(format string) string {
+	switch format {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatNDJSON:
+		return "application/x-ndjson; charset=utf-8"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// fileWriter serializa as linhas de uma exportação em um formato de arquivo
+type fileWriter interface {
+	WriteHeader(columns []string) error
+	WriteRow(values []string) error
+	// Flush envia ao writer subjacente o que estiver em buffer no formato
+	Flush() error
+	// Abort registra no arquivo, quando o formato permite, que a exportação foi interrompida
+	Abort(err error)
+	Close() error
+}
+
+func newFileWriter(format string, w io.Writer) fileWriter {
+	switch format {
+	case FormatXLSX:
+		return newXLSXWriter(w, "Export")
+	case FormatNDJSON:
+		return &ndjsonWriter{w: w}
+	default:
+		return &csvWriter{w: w, csv: csv.NewWriter(w)}
+	}
+}
+
+// csvWriter escreve CSV com BOM UTF-8, para que o Excel reconheça a acentuação
+type csvWriter struct {
+	w   io.Writer
+	csv *csv.Writer
+}
+
+func (cw *csvWriter) WriteHeader(columns []string) error {
+	if _, err := io.WriteString(cw.w, "\ufeff"); err != nil {
+		return err
+	}
+	return cw.csv.Write(columns)
+}
+
+func (cw *csvWriter) WriteRow(values []string) error {
+	return cw.csv.Write(values)
+}
+
+func (cw *csvWriter) Flush() error {
+	cw.csv.Flush()
+	return cw.csv.Error()
+}
+
+func (cw *csvWriter) Abort(error) {}
+
+func (cw *csvWriter) Close() error {
+	return cw.Flush()
+}
+
+// ndjsonWriter escreve um objeto JSON por linha, com as chaves na ordem das colunas
+type ndjsonWriter struct {
+	w       io.Writer
+	columns [][]byte
+	buf     []byte
+}
+
+func (nw *ndjsonWriter) WriteHeader(columns []string) error {
+	nw.columns = make([][]byte, len(columns))
+	for i, column := range columns {
+		nw.columns[i], _ = json.Marshal(column)
+	}
+	return nil
+}
+
+func (nw *ndjsonWriter) WriteRow(values []string) error {
+	nw.buf = append(nw.buf[:0], '{')
+	for i, value := range values {
+		if i > 0 {
+			nw.buf = append(nw.buf, ',')
+		}
+		encoded, _ := json.Marshal(value)
+		nw.buf = append(nw.buf, nw.columns[i]...)
+		nw.buf = append(nw.buf, ':')
+		nw.buf = append(nw.buf, encoded...)
+	}
+	nw.buf = append(nw.buf, '}', '\n')
+	_, err := nw.w.Write(nw.buf)
+	return err
+}
+
+func (nw *ndjsonWriter) Flush() error { return nil }
+
+// Abort adiciona uma linha {"error": ...}, para o cliente não confundir o arquivo parcial com o completo
+func (nw *ndjsonWriter) Abort(err error) {
+	encoded, _ := json.Marshal(map[string]string{"error": err.Error()})
+	_, _ = nw.w.Write(append(encoded, '\n'))
+}
+
+func (nw *ndjsonWriter) Close() error { return nil }
@@ -0,0 +1,115 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+)
+
+var testColumns = []repositories.ExportColumn{
+	{Name: "user_id", SQL: "u.user_id"},
+	{Name: "email", SQL: "u.email", PII: repositories.PIIEmail},
+}
+
+// rowsProducer produz as linhas informadas e, se err não for nil, falha depois delas
+func rowsProducer(rows [][]string, err error) Producer {
+	return func(ctx context.Context, fn repositories.ExportRowFunc) error {
+		for _, row := range rows {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+}
+
+func TestWrite(t *testing.T) {
+	rows := [][]string{{"1", "ana@example.com"}, {"2", `bruno "b"@example.com`}}
+	email := repositories.MaskPII(repositories.PIIEmail, "ana@example.com")
+	tests := []struct {
+		name    string
+		format  string
+		maskPII bool
+		err     error
+		want    string
+	}{
+		{"csv", FormatCSV, false, nil, "\ufeffuser_id,email\n1,ana@example.com\n2,\"bruno \"\"b\"\"@example.com\"\n"},
+		{"csv mascarado", FormatCSV, true, nil, "\ufeffuser_id,email\n1," + email + "\n"},
+		{"ndjson", FormatNDJSON, false, nil, `{"user_id":"1","email":"ana@example.com"}` + "\n" + `{"user_id":"2","email":"bruno \"b\"@example.com"}` + "\n"},
+		{"ndjson interrompido", FormatNDJSON, false, errors.New("conexão perdida"), `{"user_id":"1","email":"ana@example.com"}` + "\n" + `{"user_id":"2","email":"bruno \"b\"@example.com"}` + "\n" + `{"error":"conexão perdida"}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			input := rows
+			if tt.maskPII {
+				input = rows[:1]
+			}
+			n, err := Write(context.Background(), &buf, tt.format, testColumns, tt.maskPII, rowsProducer(input, tt.err), func(int64) error { return nil })
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Write() erro = %v; esperado %v", err, tt.err)
+			}
+			if n != int64(len(input)) {
+				t.Errorf("Write() = %d linhas; esperado %d", n, len(input))
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("arquivo = %q; esperado %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	rows := [][]string{{"1", "a<b>&c"}}
+	if _, err := Write(context.Background(), &buf, FormatXLSX, testColumns, false, rowsProducer(rows, nil), func(int64) error { return nil }); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("zip.NewReader: %v", err)
+	}
+	var sheet string
+	for _, file := range archive.File {
+		if file.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		f, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var content bytes.Buffer
+		content.ReadFrom(f)
+		f.Close()
+		sheet = content.String()
+	}
+	for _, cell := range []string{">user_id<", ">email<", ">1<", ">a&lt;b&gt;&amp;c<"} {
+		if !strings.Contains(sheet, cell) {
+			t.Errorf("planilha sem a célula %s:\n%s", cell, sheet)
+		}
+	}
+}
+
+func TestWriteFlush(t *testing.T) {
+	rows := make([][]string, FlushRows*2+1)
+	for i := range rows {
+		rows[i] = []string{"1", "ana@example.com"}
+	}
+	var flushed []int64
+	var buf bytes.Buffer
+	if _, err := Write(context.Background(), &buf, FormatCSV, testColumns, false, rowsProducer(rows, nil), func(n int64) error {
+		flushed = append(flushed, n)
+		return nil
+	}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if len(flushed) != 2 || flushed[0] != FlushRows || flushed[1] != 2*FlushRows {
+		t.Errorf("lotes = %v; esperado [%d %d]", flushed, FlushRows, 2*FlushRows)
+	}
+}
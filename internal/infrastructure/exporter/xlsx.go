@@ -0,0 +1,113 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xlsxMaxRows é o limite de linhas de uma planilha do Excel
+const xlsxMaxRows = 1048576
+
+// Partes fixas do pacote XLSX (uma única planilha, sem estilos)
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// xlsxWriter gera um XLSX em streaming: as partes fixas são escritas primeiro e a planilha por último,
+// com células de texto inline (sem tabela de strings compartilhadas), para não manter linhas em memória
+type xlsxWriter struct {
+	zip       *zip.Writer
+	sheet     *bufio.Writer
+	sheetName string
+	rows      int
+}
+
+func newXLSXWriter(w io.Writer, sheetName string) *xlsxWriter {
+	return &xlsxWriter{zip: zip.NewWriter(w), sheetName: sheetName}
+}
+
+func (xw *xlsxWriter) WriteHeader(columns []string) error {
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, xmlEscape(xw.sheetName))},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		f, err := xw.zip.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return err
+		}
+	}
+
+	sheet, err := xw.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	xw.sheet = bufio.NewWriter(sheet)
+	if _, err := xw.sheet.WriteString(xlsxSheetStart); err != nil {
+		return err
+	}
+	return xw.WriteRow(columns)
+}
+
+func (xw *xlsxWriter) WriteRow(values []string) error {
+	if xw.rows >= xlsxMaxRows {
+		return fmt.Errorf("limite de %d linhas do XLSX atingido; use csv ou ndjson", xlsxMaxRows)
+	}
+	xw.rows++
+
+	xw.sheet.WriteString(`<row>`)
+	for _, value := range values {
+		xw.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+		xml.EscapeText(xw.sheet, []byte(value))
+		xw.sheet.WriteString(`</t></is></c>`)
+	}
+	_, err := xw.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (xw *xlsxWriter) Flush() error {
+	if err := xw.sheet.Flush(); err != nil {
+		return err
+	}
+	return xw.zip.Flush()
+}
+
+// Abort não altera a planilha: o arquivo é finalizado com as linhas já escritas
+func (xw *xlsxWriter) Abort(error) {}
+
+func (xw *xlsxWriter) Close() error {
+	if xw.sheet != nil {
+		if _, err := xw.sheet.WriteString(xlsxSheetEnd); err != nil {
+			return err
+		}
+		if err := xw.sheet.Flush(); err != nil {
+			return err
+		}
+	}
+	return xw.zip.Close()
+}
+
+func xmlEscape(value string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(value))
+	return escaped.String()
+}
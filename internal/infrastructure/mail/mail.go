@@ -0,0 +1,337 @@
+// Package mail monta e envia e-mails por SMTP: corpo HTML com imagens embutidas (referenciadas por cid:)
+// e anexos, como nos relatórios agendados.
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotConfigured indica que SMTP_HOST ou SMTP_FROM não foram definidos
+var ErrNotConfigured = errors.New("envio de e-mail não configurado (defina SMTP_HOST e SMTP_FROM)")
+
+// Modos de TLS da conexão SMTP
+const (
+	// TLSStartTLS usa STARTTLS quando o servidor oferece (padrão)
+	TLSStartTLS = "starttls"
+	// TLSImplicit abre a conexão já com TLS (padrão na porta 465)
+	TLSImplicit = "tls"
+	// TLSNone nunca usa TLS; indicado apenas para servidores locais de teste
+	TLSNone = "none"
+)
+
+const (
+	defaultSMTPPort = 587
+	// smtpTimeout limita a conexão e a conversa inteira com o servidor
+	smtpTimeout = 30 * time.Second
+	// base64LineLength é o comprimento máximo das linhas em base64 (RFC 2045)
+	base64LineLength = 76
+)
+
+// Config representa a configuração do servidor SMTP
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	TLS      string
+}
+
+// ConfigFromEnv lê SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM e SMTP_TLS
+func ConfigFromEnv() Config {
+	config := Config{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     defaultSMTPPort,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+		TLS:      strings.ToLower(os.Getenv("SMTP_TLS")),
+	}
+	if port, err := strconv.Atoi(os.Getenv("SMTP_PORT")); err == nil && port > 0 {
+		config.Port = port
+	}
+	if config.TLS == "" {
+		config.TLS = TLSStartTLS
+		if config.Port == 465 {
+			config.TLS = TLSImplicit
+		}
+	}
+	return config
+}
+
+// Attachment é um arquivo anexado à mensagem. Com ContentID, é uma imagem embutida no HTML (src="cid:<ContentID>").
+type Attachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string
+	Data        []byte
+}
+
+// Message representa um e-mail com corpo HTML
+type Message struct {
+	To          []string
+	Subject     string
+	HTML        string
+	Text        string
+	Inline      []Attachment
+	Attachments []Attachment
+}
+
+// Sender envia mensagens pelo servidor SMTP configurado
+type Sender struct {
+	config Config
+}
+
+// NewSender cria um Sender com a configuração informada
+func NewSender(config Config) *Sender {
+	return &Sender{config: config}
+}
+
+// Configured indica se há servidor e remetente configurados
+func (s *Sender) Configured() bool {
+	return s.config.Host != "" && s.config.From != ""
+}
+
+// Send monta a mensagem e a entrega ao servidor SMTP
+func (s *Sender) Send(msg *Message) error {
+	if !s.Configured() {
+		return ErrNotConfigured
+	}
+	from, err := netmail.ParseAddress(s.config.From)
+	if err != nil {
+		return fmt.Errorf("SMTP_FROM inválido: %w", err)
+	}
+	if len(msg.To) == 0 {
+		return errors.New("a mensagem não tem destinatários")
+	}
+	recipients := make([]string, len(msg.To))
+	for i, to := range msg.To {
+		address, err := netmail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("destinatário inválido %q: %w", to, err)
+		}
+		recipients[i] = address.Address
+	}
+
+	body, err := s.build(from, msg)
+	if err != nil {
+		return err
+	}
+	return s.deliver(from.Address, recipients, body)
+}
+
+// build monta a mensagem MIME: multipart/mixed com o corpo (multipart/related, com HTML, texto e imagens
+// embutidas) seguido dos anexos
+func (s *Sender) build(from *netmail.Address, msg *Message) ([]byte, error) {
+	var buf bytes.Buffer
+	mixed := multipart.NewWriter(&buf)
+
+	header := textproto.MIMEHeader{}
+	header.Set("From", from.String())
+	header.Set("To", strings.Join(msg.To, ", "))
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("Message-ID", messageID(from.Address))
+	header.Set("MIME-Version", "1.0")
+	header.Set("Content-Type", "multipart/mixed; boundary="+mixed.Boundary())
+	writeHeader(&buf, header)
+
+	// Corpo: HTML (e texto alternativo) com as imagens embutidas
+	var related bytes.Buffer
+	relatedWriter := multipart.NewWriter(&related)
+	if err := writeBody(relatedWriter, msg); err != nil {
+		return nil, err
+	}
+	for _, inline := range msg.Inline {
+		if err := writeAttachment(relatedWriter, inline, "inline"); err != nil {
+			return nil, err
+		}
+	}
+	if err := relatedWriter.Close(); err != nil {
+		return nil, err
+	}
+	part, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {`multipart/related; type="multipart/alternative"; boundary=` + relatedWriter.Boundary()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(related.Bytes()); err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range msg.Attachments {
+		if err := writeAttachment(mixed, attachment, "attachment"); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBody grava o HTML e, se houver, a versão em texto como multipart/alternative
+func writeBody(related *multipart.Writer, msg *Message) error {
+	var alternative bytes.Buffer
+	alternativeWriter := multipart.NewWriter(&alternative)
+	if msg.Text != "" {
+		if err := writeText(alternativeWriter, "text/plain; charset=utf-8", msg.Text); err != nil {
+			return err
+		}
+	}
+	if err := writeText(alternativeWriter, "text/html; charset=utf-8", msg.HTML); err != nil {
+		return err
+	}
+	if err := alternativeWriter.Close(); err != nil {
+		return err
+	}
+
+	part, err := related.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alternativeWriter.Boundary()},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(alternative.Bytes())
+	return err
+}
+
+func writeText(w *multipart.Writer, contentType, text string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write([]byte(text)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func writeAttachment(w *multipart.Writer, attachment Attachment, disposition string) error {
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	filename := mime.QEncoding.Encode("utf-8", attachment.Filename)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", fmt.Sprintf("%s; name=%q", contentType, filename))
+	header.Set("Content-Transfer-Encoding", "base64")
+	header.Set("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, filename))
+	if attachment.ContentID != "" {
+		header.Set("Content-ID", "<"+attachment.ContentID+">")
+	}
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+	for len(encoded) > base64LineLength {
+		if _, err := part.Write([]byte(encoded[:base64LineLength] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[base64LineLength:]
+	}
+	_, err = part.Write([]byte(encoded + "\r\n"))
+	return err
+}
+
+func writeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	for _, key := range []string{"From", "To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type"} {
+		fmt.Fprintf(buf, "%s: %s\r\n", key, header.Get(key))
+	}
+	buf.WriteString("\r\n")
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = from[i+1:]
+	}
+	random := make([]byte, 12)
+	_, _ = rand.Read(random)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), domain)
+}
+
+// deliver conversa com o servidor SMTP: TLS conforme a configuração, autenticação quando há usuário,
+// remetente, destinatários e a mensagem
+func (s *Sender) deliver(from string, recipients []string, body []byte) error {
+	address := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	tlsConfig := &tls.Config{ServerName: s.config.Host}
+
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	var conn net.Conn
+	var err error
+	if s.config.TLS == TLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao conectar ao servidor SMTP %s: %w", address, err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("erro ao iniciar conversa SMTP: %w", err)
+	}
+	defer client.Close()
+
+	if s.config.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("erro no STARTTLS: %w", err)
+			}
+		}
+	}
+	if s.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)); err != nil {
+			return fmt.Errorf("erro na autenticação SMTP: %w", err)
+		}
+	}
+
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("remetente recusado: %w", err)
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("destinatário %s recusado: %w", recipient, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("erro ao enviar a mensagem: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("erro ao enviar a mensagem: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("mensagem recusada: %w", err)
+	}
+	return client.Quit()
+}
@@ -0,0 +1,205 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	netmail "net/mail"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/infrastructure/mail/mailtest"
+)
+
+// mimePart é uma parte folha da mensagem recebida, já decodificada
+type mimePart struct {
+	contentType string
+	disposition string
+	filename    string
+	contentID   string
+	data        []byte
+}
+
+func testSender(server *mailtest.Server, username string) *Sender {
+	return NewSender(Config{
+		Host:     server.Host(),
+		Port:     server.Port(),
+		Username: username,
+		Password: "segredo",
+		From:     "Relatórios <relatorios@example.com>",
+		TLS:      TLSNone,
+	})
+}
+
+func TestSenderSend(t *testing.T) {
+	server := mailtest.NewServer(t)
+	csv := []byte(strings.Repeat("data,leads,vendas\n2024-06-01,120,7\n", 10))
+	png := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a}
+
+	err := testSender(server, "").Send(&Message{
+		To:          []string{"Ana <ana@example.com>", "bruno@example.com"},
+		Subject:     "Relatório diário – junho",
+		HTML:        `<p>Olá</p><img src="cid:grafico">`,
+		Text:        "Olá",
+		Inline:      []Attachment{{Filename: "grafico.png", ContentType: "image/png", ContentID: "grafico", Data: png}},
+		Attachments: []Attachment{{Filename: "relatório.csv", ContentType: "text/csv", Data: csv}},
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	messages := server.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("%d mensagens recebidas; esperado 1", len(messages))
+	}
+	received := messages[0]
+	if received.From != "relatorios@example.com" {
+		t.Errorf("MAIL FROM = %q; esperado relatorios@example.com", received.From)
+	}
+	if want := []string{"ana@example.com", "bruno@example.com"}; !reflect.DeepEqual(received.To, want) {
+		t.Errorf("RCPT TO = %v; esperado %v", received.To, want)
+	}
+	if received.Username != "" {
+		t.Errorf("autenticado como %q sem usuário configurado", received.Username)
+	}
+
+	msg, err := netmail.ReadMessage(bytes.NewReader(received.Data))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	decoder := new(mime.WordDecoder)
+	subject, err := decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Relatório diário – junho" {
+		t.Errorf("Subject = %q (%v); esperado Relatório diário – junho", subject, err)
+	}
+	from, err := msg.Header.AddressList("From")
+	if err != nil || len(from) != 1 || from[0].Name != "Relatórios" || from[0].Address != "relatorios@example.com" {
+		t.Errorf("From = %v (%v); esperado Relatórios <relatorios@example.com>", from, err)
+	}
+	to, err := msg.Header.AddressList("To")
+	if err != nil || len(to) != 2 || to[0].Address != "ana@example.com" || to[1].Address != "bruno@example.com" {
+		t.Errorf("To = %v (%v); esperado ana@example.com e bruno@example.com", to, err)
+	}
+	if !strings.HasSuffix(msg.Header.Get("Message-ID"), "@example.com>") {
+		t.Errorf("Message-ID = %q; esperado domínio do remetente", msg.Header.Get("Message-ID"))
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("Date inválido: %v", err)
+	}
+
+	parts := readParts(t, msg.Header.Get("Content-Type"), msg.Body)
+	byType := map[string]mimePart{}
+	for _, part := range parts {
+		byType[part.contentType] = part
+	}
+	if len(parts) != 4 {
+		t.Fatalf("%d partes na mensagem; esperado 4 (texto, HTML, imagem e anexo)", len(parts))
+	}
+	if got := string(byType["text/plain"].data); got != "Olá" {
+		t.Errorf("texto = %q; esperado Olá", got)
+	}
+	if got := string(byType["text/html"].data); got != `<p>Olá</p><img src="cid:grafico">` {
+		t.Errorf("HTML = %q", got)
+	}
+
+	image := byType["image/png"]
+	if image.disposition != "inline" || image.contentID != "<grafico>" || !bytes.Equal(image.data, png) {
+		t.Errorf("imagem embutida = %s %s %x; esperado inline <grafico> %x", image.disposition, image.contentID, image.data, png)
+	}
+	attachment := byType["text/csv"]
+	if attachment.disposition != "attachment" || attachment.filename != "relatório.csv" {
+		t.Errorf("anexo = %s %q; esperado attachment relatório.csv", attachment.disposition, attachment.filename)
+	}
+	if !bytes.Equal(attachment.data, csv) {
+		t.Errorf("conteúdo do anexo = %q; esperado %q", attachment.data, csv)
+	}
+}
+
+func TestSenderSendAuth(t *testing.T) {
+	server := mailtest.NewServer(t)
+	if err := testSender(server, "api").Send(&Message{To: []string{"ana@example.com"}, Subject: "Teste", HTML: "<p>ok</p>"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	messages := server.Messages()
+	if len(messages) != 1 || messages[0].Username != "api" {
+		t.Fatalf("mensagens = %+v; esperado uma, autenticada como api", messages)
+	}
+}
+
+func TestSenderSendInvalid(t *testing.T) {
+	server := mailtest.NewServer(t)
+	tests := []struct {
+		name   string
+		sender *Sender
+		to     []string
+	}{
+		{"sem configuração", NewSender(Config{}), []string{"ana@example.com"}},
+		{"sem destinatários", testSender(server, ""), nil},
+		{"destinatário inválido", testSender(server, ""), []string{"ana"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.sender.Send(&Message{To: tt.to, Subject: "Teste", HTML: "<p>ok</p>"}); err == nil {
+				t.Error("Send sem erro")
+			}
+		})
+	}
+	if !errors.Is(NewSender(Config{}).Send(&Message{}), ErrNotConfigured) {
+		t.Error("Send sem configuração não retornou ErrNotConfigured")
+	}
+	if got := len(server.Messages()); got != 0 {
+		t.Errorf("%d mensagens recebidas; esperado 0", got)
+	}
+}
+
+// readParts percorre a árvore multipart e retorna as partes folha, decodificando base64
+func readParts(t *testing.T, contentType string, body io.Reader) []mimePart {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("Content-Type %q: %v", contentType, err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		t.Fatalf("Content-Type %q não é multipart", contentType)
+	}
+
+	var parts []mimePart
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		if err != nil {
+			t.Fatalf("NextPart: %v", err)
+		}
+		partType := part.Header.Get("Content-Type")
+		if strings.HasPrefix(partType, "multipart/") {
+			parts = append(parts, readParts(t, partType, part)...)
+			continue
+		}
+
+		var data io.Reader = part
+		if part.Header.Get("Content-Transfer-Encoding") == "base64" {
+			data = base64.NewDecoder(base64.StdEncoding, part)
+		}
+		content, err := io.ReadAll(data)
+		if err != nil {
+			t.Fatalf("conteúdo da parte %s: %v", partType, err)
+		}
+		mediaType, _, _ := mime.ParseMediaType(partType)
+		disposition, dispositionParams, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+		filename, _ := new(mime.WordDecoder).DecodeHeader(dispositionParams["filename"])
+		parts = append(parts, mimePart{
+			contentType: mediaType,
+			disposition: disposition,
+			filename:    filename,
+			contentID:   part.Header.Get("Content-ID"),
+			data:        content,
+		})
+	}
+}
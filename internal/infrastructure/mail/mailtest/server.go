@@ -0,0 +1,179 @@
+// Package mailtest implementa um servidor SMTP local para testes: aceita as mensagens sem TLS,
+// com ou sem AUTH PLAIN, e as guarda em memória para inspeção.
+package mailtest
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Message é uma mensagem recebida pelo servidor
+type Message struct {
+	// Username é o usuário informado em AUTH PLAIN (vazio sem autenticação)
+	Username string
+	From     string
+	To       []string
+	// Data é a mensagem como entregue no DATA, com quebras de linha \n
+	Data []byte
+}
+
+// Server é um servidor SMTP em 127.0.0.1, em uma porta livre
+type Server struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	messages []Message
+	conns    map[net.Conn]struct{}
+}
+
+// NewServer inicia o servidor; ele é encerrado ao final do teste
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("mailtest: erro ao abrir porta: %v", err)
+	}
+	s := &Server{listener: listener, conns: map[net.Conn]struct{}{}}
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Host retorna o endereço do servidor
+func (s *Server) Host() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port retorna a porta do servidor
+func (s *Server) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Messages retorna as mensagens recebidas até o momento
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Close encerra o servidor e as conexões abertas
+func (s *Server) Close() {
+	s.listener.Close()
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+// handle conduz a conversa SMTP de uma conexão
+func (s *Server) handle(conn net.Conn) {
+	text := textproto.NewConn(conn)
+	reply := func(line string) bool {
+		return text.PrintfLine("%s", line) == nil
+	}
+	if !reply("220 mailtest ESMTP") {
+		return
+	}
+
+	var msg Message
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			if !reply("250-mailtest") || !reply("250-8BITMIME") || !reply("250 AUTH PLAIN") {
+				return
+			}
+		case "HELO":
+			reply("250 mailtest")
+		case "AUTH":
+			mechanism, credentials, _ := strings.Cut(arg, " ")
+			decoded, err := base64.StdEncoding.DecodeString(credentials)
+			parts := strings.Split(string(decoded), "\x00")
+			if !strings.EqualFold(mechanism, "PLAIN") || err != nil || len(parts) != 3 {
+				reply("504 apenas AUTH PLAIN com credenciais na mesma linha")
+				continue
+			}
+			msg.Username = parts[1]
+			reply("235 autenticado")
+		case "MAIL":
+			msg.From = smtpAddress(arg)
+			msg.To = nil
+			reply("250 ok")
+		case "RCPT":
+			msg.To = append(msg.To, smtpAddress(arg))
+			reply("250 ok")
+		case "DATA":
+			if !reply("354 envie a mensagem terminada em .") {
+				return
+			}
+			data, err := io.ReadAll(bufio.NewReader(text.DotReader()))
+			if err != nil {
+				return
+			}
+			msg.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = Message{Username: msg.Username}
+			reply("250 mensagem aceita")
+		case "RSET":
+			msg = Message{Username: msg.Username}
+			reply("250 ok")
+		case "NOOP":
+			reply("250 ok")
+		case "QUIT":
+			reply("221 tchau")
+			return
+		default:
+			reply("502 comando não implementado")
+		}
+	}
+}
+
+// smtpAddress extrai o endereço de "FROM:<a@b>" ou "TO:<a@b>"
+func smtpAddress(arg string) string {
+	if _, value, ok := strings.Cut(arg, ":"); ok {
+		arg = value
+	}
+	if i := strings.Index(arg, ">"); i >= 0 {
+		arg = arg[:i]
+	}
+	return strings.TrimPrefix(strings.TrimSpace(arg), "<")
+}
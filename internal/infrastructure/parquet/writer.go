@@ -0,0 +1,218 @@
+// Package parquet escreve os arquivos Parquet das exportações do data warehouse com a biblioteca
+// github.com/parquet-go/parquet-go: colunas planas e opcionais, com compressão GZIP.
+package parquet
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	pq "github.com/parquet-go/parquet-go"
+)
+
+// ColumnType é o tipo de uma coluna do arquivo
+type ColumnType int
+
+const (
+	Boolean ColumnType = iota
+	Int32
+	Double
+	// String é texto UTF-8
+	String
+	// JSON é um documento JSON em texto UTF-8
+	JSON
+	// Timestamp é um instante em microssegundos, ajustado para UTC
+	Timestamp
+)
+
+// Column é uma coluna do arquivo. Todas as colunas aceitam nulos.
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Limites de um row group: ele é gravado ao atingir o número de linhas ou o tamanho dos valores em memória
+const (
+	DefaultRowGroupRows  = 100000
+	DefaultRowGroupBytes = 64 << 20
+)
+
+// Writer escreve um arquivo Parquet linha a linha. As linhas ficam em memória até completar um row group.
+//
+// O schema é um grupo do Parquet, que guarda as colunas em ordem alfabética; os leitores devem
+// acessá-las pelo nome. Write continua recebendo os valores na ordem de columns.
+type Writer struct {
+	writer       *pq.Writer
+	columns      []Column
+	leaves       []int // índice da coluna de columns em cada posição do arquivo
+	row          pq.Row
+	rows         int
+	rowBytes     int
+	numRows      int64
+	rowGroupRows int
+}
+
+// NewWriter cria um escritor com as colunas informadas. metadata é gravado no rodapé do arquivo
+// como pares chave/valor (ex.: versão do schema).
+func NewWriter(w io.Writer, columns []Column, metadata map[string]string) *Writer {
+	group := pq.Group{}
+	for _, column := range columns {
+		group[column.Name] = pq.Optional(columnNode(column.Type))
+	}
+	schema := pq.NewSchema("schema", group)
+
+	options := []pq.WriterOption{
+		schema,
+		pq.Compression(&pq.Gzip),
+		pq.CreatedBy("beta-intelligence-api", "", ""),
+	}
+	for key, value := range metadata {
+		options = append(options, pq.KeyValueMetadata(key, value))
+	}
+
+	positions := make(map[string]int, len(columns))
+	for i, column := range columns {
+		positions[column.Name] = i
+	}
+	leaves := make([]int, 0, len(columns))
+	for _, path := range schema.Columns() {
+		leaves = append(leaves, positions[path[0]])
+	}
+
+	return &Writer{
+		writer:       pq.NewWriter(w, options...),
+		columns:      columns,
+		leaves:       leaves,
+		row:          make(pq.Row, len(columns)),
+		rowGroupRows: DefaultRowGroupRows,
+	}
+}
+
+// columnNode retorna o tipo Parquet de uma coluna
+func columnNode(kind ColumnType) pq.Node {
+	switch kind {
+	case Boolean:
+		return pq.Leaf(pq.BooleanType)
+	case Int32:
+		return pq.Int(32)
+	case Double:
+		return pq.Leaf(pq.DoubleType)
+	case JSON:
+		return pq.JSON()
+	case Timestamp:
+		return pq.Timestamp(pq.Microsecond)
+	default:
+		return pq.String()
+	}
+}
+
+// Write adiciona uma linha, com um valor por coluna na ordem do schema (nil para nulo).
+// Aceita bool, int/int32/int64, float64, string/[]byte e time.Time, conforme o tipo da coluna.
+func (pw *Writer) Write(values []interface{}) error {
+	if len(values) != len(pw.columns) {
+		return fmt.Errorf("linha com %d valores para %d colunas", len(values), len(pw.columns))
+	}
+
+	for leaf, i := range pw.leaves {
+		value, size, err := columnValue(pw.columns[i].Type, values[i])
+		if err != nil {
+			return fmt.Errorf("coluna %s: %w", pw.columns[i].Name, err)
+		}
+		definition := 1
+		if values[i] == nil {
+			definition = 0
+		}
+		pw.row[leaf] = value.Level(0, definition, leaf)
+		pw.rowBytes += size
+	}
+	if _, err := pw.writer.WriteRows([]pq.Row{pw.row}); err != nil {
+		return err
+	}
+
+	pw.rows++
+	if pw.rows >= pw.rowGroupRows || pw.rowBytes >= DefaultRowGroupBytes {
+		return pw.flushRowGroup()
+	}
+	return nil
+}
+
+// columnValue converte um valor da linha no valor Parquet da coluna, com o seu tamanho aproximado em memória
+func columnValue(kind ColumnType, value interface{}) (pq.Value, int, error) {
+	if value == nil {
+		return pq.NullValue(), 0, nil
+	}
+
+	switch kind {
+	case Boolean:
+		v, ok := value.(bool)
+		if !ok {
+			return pq.Value{}, 0, fmt.Errorf("valor %T inválido para boolean", value)
+		}
+		return pq.BooleanValue(v), 1, nil
+	case Int32:
+		var v int64
+		switch n := value.(type) {
+		case int:
+			v = int64(n)
+		case int32:
+			v = int64(n)
+		case int64:
+			v = n
+		default:
+			return pq.Value{}, 0, fmt.Errorf("valor %T inválido para int32", value)
+		}
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return pq.Value{}, 0, fmt.Errorf("valor %d fora do intervalo de int32", v)
+		}
+		return pq.Int32Value(int32(v)), 4, nil
+	case Double:
+		v, ok := value.(float64)
+		if !ok {
+			return pq.Value{}, 0, fmt.Errorf("valor %T inválido para double", value)
+		}
+		return pq.DoubleValue(v), 8, nil
+	case String, JSON:
+		var v []byte
+		switch s := value.(type) {
+		case string:
+			v = []byte(s)
+		case []byte:
+			v = s
+		default:
+			return pq.Value{}, 0, fmt.Errorf("valor %T inválido para texto", value)
+		}
+		return pq.ByteArrayValue(v), 4 + len(v), nil
+	case Timestamp:
+		v, ok := value.(time.Time)
+		if !ok {
+			return pq.Value{}, 0, fmt.Errorf("valor %T inválido para timestamp", value)
+		}
+		return pq.Int64Value(v.UnixMicro()), 8, nil
+	default:
+		return pq.Value{}, 0, fmt.Errorf("tipo de coluna desconhecido: %d", kind)
+	}
+}
+
+// Close grava as linhas pendentes e o rodapé do arquivo. Não fecha o writer subjacente.
+func (pw *Writer) Close() error {
+	pw.numRows += int64(pw.rows)
+	pw.rows = 0
+	return pw.writer.Close()
+}
+
+// NumRows retorna o número de linhas escritas
+func (pw *Writer) NumRows() int64 {
+	return pw.numRows + int64(pw.rows)
+}
+
+// flushRowGroup grava as linhas em memória como um row group
+func (pw *Writer) flushRowGroup() error {
+	if err := pw.writer.Flush(); err != nil {
+		return err
+	}
+	pw.numRows += int64(pw.rows)
+	pw.rows = 0
+	pw.rowBytes = 0
+	return nil
+}
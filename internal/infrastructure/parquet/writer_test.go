@@ -0,0 +1,199 @@
+package parquet
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	pq "github.com/parquet-go/parquet-go"
+)
+
+var testColumns = []Column{
+	{Name: "event_id", Type: String},
+	{Name: "count", Type: Int32},
+	{Name: "value", Type: Double},
+	{Name: "active", Type: Boolean},
+	{Name: "properties", Type: JSON},
+	{Name: "event_time", Type: Timestamp},
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	base := time.Date(2024, 6, 1, 12, 30, 0, 123456000, time.UTC)
+	rows := [][]interface{}{
+		{"a", 1, 9.5, true, `{"x":1}`, base},
+		{nil, nil, nil, nil, nil, nil},
+		{[]byte("c"), int32(-7), 0.0, false, []byte(`[]`), base.Add(time.Hour)},
+		{"d", int64(2147483647), -1.25, nil, nil, base.In(time.FixedZone("BRT", -3*3600))},
+		{"", 0, nil, true, "{}", nil},
+	}
+
+	var out bytes.Buffer
+	writer := NewWriter(&out, testColumns, map[string]string{"dataset": "events", "schema_version": "1"})
+	writer.rowGroupRows = 2
+	for _, row := range rows {
+		if err := writer.Write(row); err != nil {
+			t.Fatalf("Write(%v): %v", row, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if writer.NumRows() != int64(len(rows)) {
+		t.Errorf("NumRows() = %d; esperado %d", writer.NumRows(), len(rows))
+	}
+
+	file, err := pq.OpenFile(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if file.NumRows() != int64(len(rows)) {
+		t.Errorf("arquivo com %d linhas; esperado %d", file.NumRows(), len(rows))
+	}
+	if got := len(file.RowGroups()); got != 3 {
+		t.Errorf("arquivo com %d row groups; esperado 3", got)
+	}
+	if value, ok := file.Lookup("schema_version"); !ok || value != "1" {
+		t.Errorf("metadado schema_version = %q, %v; esperado 1", value, ok)
+	}
+
+	// Tipos físicos e lógicos de cada coluna
+	types := map[string]struct {
+		kind    pq.Kind
+		logical string
+	}{
+		"event_id":   {pq.ByteArray, "STRING"},
+		"count":      {pq.Int32, "INT(32,true)"},
+		"value":      {pq.Double, ""},
+		"active":     {pq.Boolean, ""},
+		"properties": {pq.ByteArray, "JSON"},
+		"event_time": {pq.Int64, "TIMESTAMP(isAdjustedToUTC=true,unit=MICROS)"},
+	}
+	schema := file.Schema()
+	for name, want := range types {
+		leaf, ok := schema.Lookup(name)
+		if !ok {
+			t.Fatalf("coluna %s ausente do arquivo", name)
+		}
+		if !leaf.Node.Optional() {
+			t.Errorf("coluna %s não é opcional", name)
+		}
+		typ := leaf.Node.Type()
+		logical := ""
+		if lt := typ.LogicalType(); lt != nil {
+			logical = lt.String()
+		}
+		if typ.Kind() != want.kind || logical != want.logical {
+			t.Errorf("coluna %s com tipo %v %q; esperado %v %q", name, typ.Kind(), logical, want.kind, want.logical)
+		}
+	}
+
+	// Valores lidos de volta, pelo nome da coluna
+	names := make([]string, len(schema.Columns()))
+	for i, path := range schema.Columns() {
+		names[i] = path[0]
+	}
+	var got []map[string]pq.Value
+	for _, group := range file.RowGroups() {
+		reader := group.Rows()
+		buf := make([]pq.Row, 10)
+		for {
+			n, err := reader.ReadRows(buf)
+			for _, row := range buf[:n] {
+				values := map[string]pq.Value{}
+				for _, value := range row {
+					values[names[value.Column()]] = value.Clone()
+				}
+				got = append(got, values)
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("ReadRows: %v", err)
+			}
+		}
+		reader.Close()
+	}
+	if len(got) != len(rows) {
+		t.Fatalf("lidas %d linhas; esperado %d", len(got), len(rows))
+	}
+
+	for r, row := range rows {
+		for i, column := range testColumns {
+			value := got[r][column.Name]
+			if row[i] == nil {
+				if !value.IsNull() {
+					t.Errorf("linha %d, coluna %s = %v; esperado nulo", r, column.Name, value)
+				}
+				continue
+			}
+			if value.IsNull() {
+				t.Errorf("linha %d, coluna %s nula; esperado %v", r, column.Name, row[i])
+				continue
+			}
+			if want := expectedValue(row[i]); !valueEquals(column.Type, value, want) {
+				t.Errorf("linha %d, coluna %s = %v; esperado %v", r, column.Name, value, want)
+			}
+		}
+	}
+}
+
+func TestWriterRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		column Column
+		value  interface{}
+	}{
+		{"texto em int32", Column{"n", Int32}, "1"},
+		{"int32 fora do intervalo", Column{"n", Int32}, int64(1) << 40},
+		{"int em double", Column{"v", Double}, 1},
+		{"texto em boolean", Column{"b", Boolean}, "true"},
+		{"número em texto", Column{"s", String}, 3},
+		{"texto em timestamp", Column{"t", Timestamp}, "2024-01-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := NewWriter(io.Discard, []Column{tt.column}, nil)
+			if err := writer.Write([]interface{}{tt.value}); err == nil {
+				t.Errorf("Write(%v) sem erro", tt.value)
+			}
+		})
+	}
+
+	writer := NewWriter(io.Discard, testColumns, nil)
+	if err := writer.Write([]interface{}{"a"}); err == nil {
+		t.Error("Write com menos valores que colunas sem erro")
+	}
+}
+
+// expectedValue normaliza um valor de entrada para comparação com o valor lido
+func expectedValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case time.Time:
+		return v.UnixMicro()
+	}
+	return value
+}
+
+func valueEquals(kind ColumnType, value pq.Value, want interface{}) bool {
+	switch kind {
+	case Boolean:
+		return value.Boolean() == want
+	case Int32:
+		return int64(value.Int32()) == want
+	case Double:
+		return value.Double() == want
+	case Timestamp:
+		return value.Int64() == want
+	default:
+		return string(value.ByteArray()) == want
+	}
+}
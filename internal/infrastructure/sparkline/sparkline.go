@@ -0,0 +1,158 @@
+// Package sparkline desenha minigráficos de linha em PNG para relatórios enviados por e-mail,
+// onde não é possível usar JavaScript nem imagens SVG.
+package sparkline
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+)
+
+// Tamanho padrão, em pixels, dos minigráficos
+const (
+	DefaultWidth  = 120
+	DefaultHeight = 32
+)
+
+var (
+	lineColor = color.RGBA{R: 0x25, G: 0x63, B: 0xeb, A: 0xff}
+	fillColor = color.RGBA{R: 0xdb, G: 0xea, B: 0xfe, A: 0xff}
+	lastColor = color.RGBA{R: 0x1e, G: 0x3a, B: 0x8a, A: 0xff}
+)
+
+// padding deixa espaço para a espessura da linha e o marcador do último ponto
+const padding = 2
+
+// PNG desenha a série como uma linha com a área abaixo preenchida e um marcador no último ponto.
+// Uma série vazia gera uma imagem em branco; um único valor gera uma linha horizontal.
+func PNG(values []float64, width, height int) ([]byte, error) {
+	if width <= 2*padding {
+		width = DefaultWidth
+	}
+	if height <= 2*padding {
+		height = DefaultHeight
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	if len(values) > 0 {
+		points := scale(values, width, height)
+		for i := 1; i < len(points); i++ {
+			fillBelow(img, points[i-1], points[i], height-padding)
+		}
+		if len(points) == 1 {
+			fillBelow(img, image.Point{X: padding, Y: points[0].Y}, image.Point{X: width - padding - 1, Y: points[0].Y}, height-padding)
+			line(img, image.Point{X: padding, Y: points[0].Y}, image.Point{X: width - padding - 1, Y: points[0].Y})
+		}
+		for i := 1; i < len(points); i++ {
+			line(img, points[i-1], points[i])
+		}
+		last := points[len(points)-1]
+		for dx := -1; dx <= 1; dx++ {
+			for dy := -1; dy <= 1; dy++ {
+				img.Set(last.X+dx, last.Y+dy, lastColor)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scale converte os valores em pontos da imagem; o menor valor fica na base e o maior no topo
+func scale(values []float64, width, height int) []image.Point {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, value := range values {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		low = math.Min(low, value)
+		high = math.Max(high, value)
+	}
+	if math.IsInf(low, 1) {
+		low, high = 0, 0
+	}
+
+	innerWidth := float64(width - 2*padding - 1)
+	innerHeight := float64(height - 2*padding - 1)
+	points := make([]image.Point, len(values))
+	for i, value := range values {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			value = low
+		}
+		x := float64(padding)
+		if len(values) > 1 {
+			x += innerWidth * float64(i) / float64(len(values)-1)
+		}
+		// Série constante: linha no meio da altura
+		ratio := 0.5
+		if high > low {
+			ratio = (value - low) / (high - low)
+		}
+		y := float64(padding) + innerHeight*(1-ratio)
+		points[i] = image.Point{X: int(math.Round(x)), Y: int(math.Round(y))}
+	}
+	return points
+}
+
+// line desenha um segmento de dois pixels de espessura (algoritmo de Bresenham)
+func line(img *image.RGBA, from, to image.Point) {
+	dx, dy := abs(to.X-from.X), -abs(to.Y-from.Y)
+	sx, sy := sign(to.X-from.X), sign(to.Y-from.Y)
+	err := dx + dy
+	x, y := from.X, from.Y
+	for {
+		img.Set(x, y, lineColor)
+		img.Set(x, y+1, lineColor)
+		if x == to.X && y == to.Y {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y += sy
+		}
+	}
+}
+
+// fillBelow preenche a área entre o segmento e a base
+func fillBelow(img *image.RGBA, from, to image.Point, base int) {
+	if to.X < from.X {
+		from, to = to, from
+	}
+	for x := from.X; x <= to.X; x++ {
+		y := from.Y
+		if to.X > from.X {
+			y = from.Y + (to.Y-from.Y)*(x-from.X)/(to.X-from.X)
+		}
+		for yy := y; yy <= base; yy++ {
+			img.Set(x, yy, fillColor)
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	default:
+		return 0
+	}
+}
@@ -9,18 +9,23 @@ import (
 	"crypto/md5"
 
 	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
 	"github.com/gofiber/fiber/v2"
 )
 
 // DashboardHandler lida com requisições relacionadas a dashboards
 type DashboardHandler struct {
 	dashboardUseCase usecases.DashboardUseCase
+	cycleUseCase     usecases.WebinarCycleUseCase
+	segmentUseCase   usecases.SegmentUseCase
 }
 
 // NewDashboardHandler cria uma nova instância de DashboardHandler
-func NewDashboardHandler(dashboardUseCase usecases.DashboardUseCase) *DashboardHandler {
+func NewDashboardHandler(dashboardUseCase usecases.DashboardUseCase, cycleUseCase usecases.WebinarCycleUseCase, segmentUseCase usecases.SegmentUseCase) *DashboardHandler {
 	return &DashboardHandler{
 		dashboardUseCase: dashboardUseCase,
+		cycleUseCase:     cycleUseCase,
+		segmentUseCase:   segmentUseCase,
 	}
 }
 
@@ -34,12 +39,17 @@ func NewDashboardHandler(dashboardUseCase usecases.DashboardUseCase) *DashboardH
 // @Param funnel_id query string false "ID do funil"
 // @Param from query string false "Data inicial (formato: 2006-01-02)"
 // @Param to query string false "Data final (formato: 2006-01-02)"
+// @Param cycle_id query int false "ID do ciclo de webinar (substitui from/to; compara com o ciclo anterior)"
 // @Param time_from query string false "Hora inicial (formato: 00:00)"
 // @Param time_to query string false "Hora final (formato: 23:59)"
-// @Param time_frame query string false "Granularidade da série temporal (hourly, daily, weekly, monthly)" default(daily)
+// @Param time_frame query string false "Granularidade de chart_data: daily, weekly (semana ISO), monthly ou yearly; também define o período anterior" default(daily)
+// @Param compare query string false "Período de comparação: previous, previous_year, same_weekday_last_week ou custom" default(previous)
+// @Param compare_from query string false "Início da comparação com compare=custom (YYYY-MM-DD ou RFC3339)"
+// @Param compare_to query string false "Fim da comparação com compare=custom (YYYY-MM-DD ou RFC3339)"
 // @Param product_id query string false "ID do produto"
 // @Param user_id query string false "ID do usuário"
 // @Param landingPage query string false "URL da página de destino"
+// @Param segment_id query int false "ID de um segmento salvo: conta apenas sessões e leads dos membros do segmento"
 // @Success 200 {object} map[string]interface{} "Dados consolidados do dashboard"
 // @Failure 400 {object} map[string]interface{} "Erro de parâmetros"
 // @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
@@ -60,82 +70,121 @@ func (h *DashboardHandler) GetUnifiedDashboard(c *fiber.Ctx) error {
 	}
 	params["user_id"] = c.Query("user_id", "")
 	params["product_id"] = c.Query("product_id", "")
-	params["time_frame"] = c.Query("time_frame", "daily") // daily, weekly, monthly
-
-	// Extrair parâmetros de data
-	from := c.Query("from", "")
-	to := c.Query("to", "")
-	timeFrom := c.Query("time_from", "00:00")
-	timeTo := c.Query("time_to", "23:59")
-
-	// Adicionar datas ao mapa de parâmetros
-	params["from"] = from
-	params["to"] = to
-	params["time_from"] = timeFrom
-	params["time_to"] = timeTo
-
-	// Validar datas
-	if from == "" || to == "" {
+	params["time_frame"] = c.Query("time_frame", "daily") // daily, weekly, monthly, yearly
+	if _, ok := entities.NormalizeTimeFrame(params["time_frame"]); !ok {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Os parâmetros 'from' e 'to' são obrigatórios",
+			"error": "Parâmetro 'time_frame' inválido (use daily, weekly, monthly ou yearly)",
 		})
 	}
 
-	// Processar período atual
-	currentFromDate, err := time.Parse("2006-01-02", from)
+	// Segmento salvo informado: restringir sessões e leads aos membros do segmento
+	segment, err := resolveSegmentParam(c, h.segmentUseCase)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": fmt.Sprintf("Formato de data inválido para 'from': %s", err.Error()),
+		return c.Status(segmentParamStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
+	params["segment_id"] = c.Query("segment_id", "")
+
+	var currentPeriod, previousPeriod usecases.DatePeriod
 
-	currentToDate, err := time.Parse("2006-01-02", to)
+	// Ciclo de webinar informado: usar os limites do ciclo e comparar com o ciclo anterior
+	cycle, cycleDates, previousCycleDates, err := resolveCycleParam(c, h.cycleUseCase)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": fmt.Sprintf("Formato de data inválido para 'to': %s", err.Error()),
+		return c.Status(cycleParamStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
 
-	// Ajustar currentToDate para incluir o dia completo (23:59:59)
-	currentToDate = currentToDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+	if cycle != nil {
+		applyCycleFilters(params, cycle)
+		params["from"] = cycleDates.PesquisaInicio.Format(time.RFC3339)
+		params["to"] = cycleDates.VendaFim.Format(time.RFC3339)
 
-	// Configurar período atual
-	currentPeriod := usecases.DatePeriod{
-		From:     currentFromDate,
-		To:       currentToDate,
-		TimeFrom: timeFrom,
-		TimeTo:   timeTo,
+		currentPeriod = usecases.CycleDatePeriod(cycleDates)
+		previousPeriod = usecases.CycleDatePeriod(previousCycleDates)
+		params["time_from"] = currentPeriod.TimeFrom
+		params["time_to"] = currentPeriod.TimeTo
+	} else {
+		// Extrair parâmetros de data
+		from := c.Query("from", "")
+		to := c.Query("to", "")
+		timeFrom := c.Query("time_from", "00:00")
+		timeTo := c.Query("time_to", "23:59")
+
+		// Adicionar datas ao mapa de parâmetros
+		params["from"] = from
+		params["to"] = to
+		params["time_from"] = timeFrom
+		params["time_to"] = timeTo
+
+		// Validar datas
+		if from == "" || to == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Os parâmetros 'from' e 'to' são obrigatórios",
+			})
+		}
+
+		// Processar período atual
+		currentFromDate, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Formato de data inválido para 'from': %s", err.Error()),
+			})
+		}
+
+		currentToDate, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Formato de data inválido para 'to': %s", err.Error()),
+			})
+		}
+
+		// Ajustar currentToDate para incluir o dia completo (23:59:59)
+		currentToDate = currentToDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
+
+		// Configurar período atual
+		currentPeriod = usecases.DatePeriod{
+			From:     currentFromDate,
+			To:       currentToDate,
+			TimeFrom: timeFrom,
+			TimeTo:   timeTo,
+		}
+
+		// Calcular período anterior: mesma quantidade de dias, semanas, meses ou anos, imediatamente antes
+		previousFromDate, previousToDate := usecases.PreviousPeriod(currentFromDate, currentToDate, params["time_frame"])
+
+		// Configurar período anterior
+		previousPeriod = usecases.DatePeriod{
+			From:     previousFromDate,
+			To:       previousToDate,
+			TimeFrom: timeFrom,
+			TimeTo:   timeTo,
+		}
 	}
 
-	// Calcular período anterior (mesmo número de dias, imediatamente anterior)
-	daysDiff := int(currentToDate.Sub(currentFromDate).Hours() / 24)
-
-	// Calcular período anterior: mesmo número de dias, terminando 1 dia antes do período atual
-	previousToDate := currentFromDate.AddDate(0, 0, -1)
-	previousFromDate := previousToDate.AddDate(0, 0, -daysDiff)
-
-	// Ajustar previousToDate para incluir o dia completo (23:59:59)
-	previousToDate = previousToDate.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-
-	// Log para debug das datas calculadas
-	fmt.Printf("DEBUG: Período atual: %s até %s (duração: %d dias)\n",
-		currentFromDate.Format("2006-01-02 15:04:05"),
-		currentToDate.Format("2006-01-02 15:04:05"),
-		daysDiff)
-	fmt.Printf("DEBUG: Período anterior: %s até %s\n",
-		previousFromDate.Format("2006-01-02 15:04:05"),
-		previousToDate.Format("2006-01-02 15:04:05"))
-
-	// Configurar período anterior
-	previousPeriod := usecases.DatePeriod{
-		From:     previousFromDate,
-		To:       previousToDate,
-		TimeFrom: timeFrom,
-		TimeTo:   timeTo,
+	// Período de comparação (compare): anterior, ano anterior, mesmo dia da semana ou personalizado
+	compareFrom, compareTo, compareMode, err := resolveComparisonPeriod(c,
+		currentPeriod.From, currentPeriod.To, previousPeriod.From, previousPeriod.To)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
+	if compareMode != usecases.CompareModePrevious {
+		previousPeriod = usecases.DatePeriod{
+			From:     compareFrom,
+			To:       compareTo,
+			TimeFrom: compareFrom.Format("15:04"),
+			TimeTo:   compareTo.Format("15:04"),
+		}
+	}
+	params["compare"] = compareMode
+	params["compare_from"] = previousPeriod.From.Format(time.RFC3339)
+	params["compare_to"] = previousPeriod.To.Format(time.RFC3339)
 
 	// Obter dados otimizados do dashboard
-	result, err := h.dashboardUseCase.GetUnifiedDashboard(params, currentPeriod, previousPeriod)
+	result, err := h.dashboardUseCase.GetUnifiedDashboard(params, currentPeriod, previousPeriod, segment)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": fmt.Sprintf("Erro ao obter dados do dashboard: %s", err.Error()),
@@ -263,23 +312,44 @@ func ParseTimeString(timeStr string) *TimeComponents {
 	}
 }
 
-// calculatePreviousPeriod determina o período anterior para comparação
-func calculatePreviousPeriod(from, to time.Time, timeFrame string) (time.Time, time.Time) {
-	duration := to.Sub(from)
+// resolveComparisonPeriod calcula o período de comparação conforme o parâmetro compare (ver
+// usecases.ComparisonPeriod); com compare=custom, usa compare_from e compare_to (YYYY-MM-DD ou RFC3339)
+func resolveComparisonPeriod(c *fiber.Ctx, from, to, defaultFrom, defaultTo time.Time) (time.Time, time.Time, string, error) {
+	mode := strings.ToLower(c.Query("compare", usecases.CompareModePrevious))
+	if mode != usecases.CompareModeCustom {
+		compareFrom, compareTo, err := usecases.ComparisonPeriod(mode, from, to, defaultFrom, defaultTo)
+		return compareFrom, compareTo, mode, err
+	}
 
-	switch timeFrame {
-	case "Daily":
-		return from.AddDate(0, 0, -1), to.AddDate(0, 0, -1)
-	case "Weekly":
-		return from.AddDate(0, 0, -7), to.AddDate(0, 0, -7)
-	case "Monthly":
-		return from.AddDate(0, -1, 0), to.AddDate(0, -1, 0)
-	case "Yearly":
-		return from.AddDate(-1, 0, 0), to.AddDate(-1, 0, 0)
-	default:
-		// Usar a duração exata do período atual
-		return from.Add(-duration), to.Add(-duration)
+	fromStr, toStr := c.Query("compare_from", ""), c.Query("compare_to", "")
+	if fromStr == "" || toStr == "" {
+		return time.Time{}, time.Time{}, mode, fmt.Errorf("os parâmetros 'compare_from' e 'compare_to' são obrigatórios com compare=custom")
 	}
+	compareFrom, err := parseComparisonDate(fromStr, false)
+	if err != nil {
+		return time.Time{}, time.Time{}, mode, fmt.Errorf("formato de data inválido para 'compare_from': %w", err)
+	}
+	compareTo, err := parseComparisonDate(toStr, true)
+	if err != nil {
+		return time.Time{}, time.Time{}, mode, fmt.Errorf("formato de data inválido para 'compare_to': %w", err)
+	}
+	if compareTo.Before(compareFrom) {
+		return time.Time{}, time.Time{}, mode, fmt.Errorf("'compare_to' deve ser posterior a 'compare_from'")
+	}
+	return compareFrom, compareTo, mode, nil
+}
+
+// parseComparisonDate interpreta uma data de compare_from/compare_to como as datas do período atual:
+// YYYY-MM-DD (fim do dia quando endOfDay) ou RFC3339
+func parseComparisonDate(value string, endOfDay bool) (time.Time, error) {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Parse(time.RFC3339, value)
+	}
+	if endOfDay {
+		date = date.Add(24*time.Hour - time.Second)
+	}
+	return date, nil
 }
 
 // GetProfessionConversionRates retorna taxas de conversão para todas as profissões
@@ -292,6 +362,7 @@ func calculatePreviousPeriod(from, to time.Time, timeFrame string) (time.Time, t
 // @Param to query string false "Data final (formato: 2023-01-31)"
 // @Param timeFrom query string false "Hora inicial (formato: 08:00)"
 // @Param timeTo query string false "Hora final (formato: 18:00)"
+// @Param cycle_id query int false "ID do ciclo de webinar (substitui from/to)"
 // @Success 200 {object} map[string]interface{} "Dados de conversão por profissão"
 // @Failure 400 {object} map[string]string "Erro de validação"
 // @Failure 500 {object} map[string]string "Erro interno"
@@ -305,10 +376,20 @@ func (h *DashboardHandler) GetProfessionConversionRates(c *fiber.Ctx) error {
 
 	// Definir período atual e anterior
 	var currentPeriod, previousPeriod usecases.DatePeriod
-	var err error
 
-	// Se não forem fornecidas datas, usar dia atual comparado com o dia anterior
-	if fromStr == "" || toStr == "" {
+	// Ciclo de webinar informado: comparar com o ciclo anterior
+	cycle, cycleDates, previousCycleDates, err := resolveCycleParam(c, h.cycleUseCase)
+	if err != nil {
+		return c.Status(cycleParamStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if cycle != nil {
+		currentPeriod = usecases.CycleDatePeriod(cycleDates)
+		previousPeriod = usecases.CycleDatePeriod(previousCycleDates)
+	} else if fromStr == "" || toStr == "" {
+		// Se não forem fornecidas datas, usar dia atual comparado com o dia anterior
 		// Dia atual
 		now := time.Now()
 		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -14,11 +16,12 @@ import (
 )
 
 type EventHandler struct {
-	eventUseCase usecases.EventUseCase
+	eventUseCase   usecases.EventUseCase
+	segmentUseCase usecases.SegmentUseCase
 }
 
-func NewEventHandler(eventUseCase usecases.EventUseCase) *EventHandler {
-	return &EventHandler{eventUseCase}
+func NewEventHandler(eventUseCase usecases.EventUseCase, segmentUseCase usecases.SegmentUseCase) *EventHandler {
+	return &EventHandler{eventUseCase, segmentUseCase}
 }
 
 func (h *EventHandler) GetEvents(c *fiber.Ctx) error {
@@ -148,51 +151,8 @@ func (h *EventHandler) GetEvents(c *fiber.Ctx) error {
 	}
 
 	// Validate sortBy field and build orderBy
-	validSortFields := map[string]string{
-		// Event fields
-		"event_id":     "e.event_id",
-		"event_name":   "e.event_name",
-		"pageview_id":  "e.pageview_id",
-		"session_id":   "e.session_id",
-		"event_time":   "e.event_time",
-		"event_source": "e.event_source",
-		"event_type":   "e.event_type",
-
-		// User fields
-		"fullname":  "u.fullname",
-		"email":     "u.email",
-		"phone":     "u.phone",
-		"is_client": "u.isClient",
-
-		// UTM fields (agora vindo da tabela users)
-		"utm_source":   "u.\"initialUtmSource\"",
-		"utm_medium":   "u.\"initialUtmMedium\"",
-		"utm_campaign": "u.\"initialUtmCampaign\"",
-		"utm_content":  "u.\"initialUtmContent\"",
-		"utm_term":     "u.\"initialUtmTerm\"",
-
-		// Session fields
-		"country": "u.\"initialCountry\"",
-		"state":   "u.\"initialRegion\"",
-		"city":    "u.\"initialCity\"",
-		"ip":      "u.\"initialIp\"",
-
-		// Profession fields
-		"profession_name": "professions.profession_name",
-		"meta_pixel":      "professions.meta_pixel",
-		"meta_token":      "professions.meta_token",
-
-		// Product fields
-		"product_name": "products.product_name",
-
-		// Funnel fields
-		"funnel_name": "funnels.funnel_name",
-		"funnel_tag":  "funnels.funnel_tag",
-		"global":      "funnels.global",
-	}
-
 	orderBy := "e.event_time desc" // default ordering
-	if field, ok := validSortFields[sortBy]; ok {
+	if field, ok := repositories.EventSortColumns[sortBy]; ok {
 		orderBy = field + " " + sortDirection
 	}
 
@@ -265,59 +225,39 @@ func (h *EventHandler) GetEvents(c *fiber.Ctx) error {
 	fmt.Printf("From time: %v (Brasília: %v)\n", fromTime, fromTime.In(brazilLocation))
 	fmt.Printf("To time: %v (Brasília: %v)\n", toTime, toTime.In(brazilLocation))
 
-	// Processar filtros avançados
-	var advancedFilters []repositories.AdvancedFilter
-
-	// Obter condição de filtro (AND/OR)
+	// Obter condição de filtro (AND/OR) usada pelo formato plano de filtros avançados
 	filterCondition := c.Query("filter_condition", "AND")
 	if filterCondition != "AND" && filterCondition != "OR" {
 		filterCondition = "AND" // valor padrão
 	}
 
-	// Obter e processar os filtros avançados
-	advancedFiltersStr := c.Query("advanced_filters", "")
-	if advancedFiltersStr != "" {
-		fmt.Printf("DEBUG: Filtros avançados recebidos (raw): %s\n", advancedFiltersStr)
-
-		// Decodificar o JSON
-		err := json.Unmarshal([]byte(advancedFiltersStr), &advancedFilters)
-		if err != nil {
-			fmt.Printf("Error parsing advanced filters: %v\n", err)
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid advanced_filters format. Expected JSON array.",
-			})
-		}
-
-		fmt.Printf("DEBUG: Filtros avançados decodificados: %+v\n", advancedFilters)
-		for i, filter := range advancedFilters {
-			fmt.Printf("DEBUG: Filtro #%d: property=%s, operator=%s, value=%s\n",
-				i+1, filter.Property, filter.Operator, filter.Value)
-		}
-
-		// IMPORTANTE: Verificar se há filtros usando session.utm_*
-		for _, filter := range advancedFilters {
-			if strings.HasPrefix(filter.Property, "session.utm_") {
-				fmt.Printf("AVISO: Filtro usando session.utm_* (%s) não é mais suportado. Use user.utm_* em vez disso.\n",
-					filter.Property)
-			}
-		}
-	} else {
-		fmt.Printf("DEBUG: Nenhum filtro avançado fornecido\n")
+	// Obter e processar os filtros avançados: lista plana (unida por filter_condition) ou árvore de grupos
+	filter, err := parseAdvancedFilters(c.Query("advanced_filters", ""), filterCondition)
+	if err != nil {
+		fmt.Printf("Error parsing advanced filters: %v\n", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
 	// Após processar os parâmetros de data
 	fmt.Printf("From time: %v (Brasília: %v)\n", fromTime, fromTime.In(brazilLocation))
 	fmt.Printf("To time: %v (Brasília: %v)\n", toTime, toTime.In(brazilLocation))
-	fmt.Printf("Advanced filters: %+v\n", advancedFilters)
 	fmt.Printf("Filter condition: %s\n", filterCondition)
 
+	// Restrição aos eventos dos membros de um segmento salvo
+	segment, err := resolveSegmentParam(c, h.segmentUseCase)
+	if err != nil {
+		return c.Status(segmentParamStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// Como removemos a implementação de filtros por horário, vamos passar valores vazios para esses parâmetros
 	timeFrom := ""
 	timeTo := ""
 
 	// Imprimir em log os parâmetros da requisição para debug
 	fmt.Printf("Parâmetros da requisição: page=%d, limit=%d, sortBy=%s, advancedFilters=%+v\n",
-		page, limit, sortBy, advancedFilters)
+		page, limit, sortBy, filter)
 
 	// Verificar período e all_data
 	period := c.Query("period", "false") == "true"
@@ -360,9 +300,9 @@ func (h *EventHandler) GetEvents(c *fiber.Ctx) error {
 
 				// Gerar array de todas as datas no intervalo
 				dateRange := GenerateDateRange(firstDateOnly, lastDateOnly)
-				result, err := h.eventUseCase.CountEventsByPeriods(dateRange, eventType, advancedFilters, funnelID, professionID)
+				result, err := h.eventUseCase.CountEventsByPeriods(dateRange, eventType, filter, funnelID, professionID, segment)
 				if err != nil {
-					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					return c.Status(eventQueryStatus(err)).JSON(fiber.Map{
 						"error": fmt.Sprintf("Erro ao contar LEADs por períodos: %v", err),
 					})
 				}
@@ -391,9 +331,9 @@ func (h *EventHandler) GetEvents(c *fiber.Ctx) error {
 
 				// Gerar array de datas no intervalo from-to
 				dateRange := GenerateDateRange(fromTime, toTime)
-				result, err := h.eventUseCase.CountEventsByPeriods(dateRange, eventType, advancedFilters, funnelID, professionID)
+				result, err := h.eventUseCase.CountEventsByPeriods(dateRange, eventType, filter, funnelID, professionID, segment)
 				if err != nil {
-					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					return c.Status(eventQueryStatus(err)).JSON(fiber.Map{
 						"error": fmt.Sprintf("Erro ao contar LEADs por períodos: %v", err),
 					})
 				}
@@ -420,9 +360,9 @@ func (h *EventHandler) GetEvents(c *fiber.Ctx) error {
 				}
 
 				periods := strings.Split(periodsParam, ",")
-				result, err := h.eventUseCase.CountEventsByPeriods(periods, eventType, advancedFilters, funnelID, professionID)
+				result, err := h.eventUseCase.CountEventsByPeriods(periods, eventType, filter, funnelID, professionID, segment)
 				if err != nil {
-					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					return c.Status(eventQueryStatus(err)).JSON(fiber.Map{
 						"error": fmt.Sprintf("Erro ao contar LEADs por períodos: %v", err),
 					})
 				}
@@ -435,7 +375,7 @@ func (h *EventHandler) GetEvents(c *fiber.Ctx) error {
 			}
 
 			// Contagem normal de LEADs
-			count, err := h.eventUseCase.CountEvents(fromTime, toTime, timeFrom, timeTo, eventType, professionIDs, funnelIDs, advancedFilters, filterCondition)
+			count, err := h.eventUseCase.CountEvents(fromTime, toTime, timeFrom, timeTo, eventType, professionIDs, funnelIDs, filter, segment)
 			if err != nil {
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 					"error": fmt.Sprintf("Erro ao contar LEADs: %v", err),
@@ -452,9 +392,9 @@ func (h *EventHandler) GetEvents(c *fiber.Ctx) error {
 		}
 
 		// Para outros tipos de contagem de eventos
-		count, err := h.eventUseCase.CountEvents(fromTime, toTime, timeFrom, timeTo, eventType, professionIDs, funnelIDs, advancedFilters, filterCondition)
+		count, err := h.eventUseCase.CountEvents(fromTime, toTime, timeFrom, timeTo, eventType, professionIDs, funnelIDs, filter, segment)
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			return c.Status(eventQueryStatus(err)).JSON(fiber.Map{
 				"error": fmt.Sprintf("Erro ao contar eventos: %v", err),
 			})
 		}
@@ -466,11 +406,34 @@ func (h *EventHandler) GetEvents(c *fiber.Ctx) error {
 		})
 	}
 
+	// Exportação em arquivo (format=csv|xlsx|ndjson) com os mesmos filtros da listagem
+	export, err := parseExportRequest(c, "events", repositories.EventExportColumns)
+	if err != nil {
+		return c.Status(exportStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	if export != nil {
+		return streamExport(c, export, func(ctx context.Context, fn repositories.ExportRowFunc) error {
+			return h.eventUseCase.ExportEvents(ctx, export.columns, orderBy, fromTime, toTime, timeFrom, timeTo, professionIDs, funnelIDs, filter, segment, fn)
+		})
+	}
+
+	// Paginação por offset (page) ou por cursor em (event_time, event_id)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	pageRequest, err := parsePageRequest(c, page, limit, sortBy, sortDirection, "event_time")
+	if err != nil {
+		return c.Status(paginationStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// Código existente para buscar eventos quando não é count_only
-	events, total, err := h.eventUseCase.GetEvents(c.Context(), page, limit, orderBy, fromTime, toTime, timeFrom, timeTo, professionIDs, funnelIDs, advancedFilters, filterCondition)
+	events, pageResult, err := h.eventUseCase.GetEvents(c.Context(), pageRequest, orderBy, fromTime, toTime, timeFrom, timeTo, professionIDs, funnelIDs, filter, segment)
 	if err != nil {
 		fmt.Printf("Error fetching events: %v\n", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		return c.Status(eventQueryStatus(err)).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
@@ -501,24 +464,27 @@ func (h *EventHandler) GetEvents(c *fiber.Ctx) error {
 		fmt.Printf("Nenhum evento retornado!\n")
 	}
 
+	meta := fiber.Map{
+		"from":              fromTime.Format(time.RFC3339),
+		"to":                toTime.Format(time.RFC3339),
+		"sort_by":           sortBy,
+		"sort_direction":    sortDirection,
+		"profession_ids":    professionIDs,
+		"funnel_ids":        funnelIDs,
+		"filter_condition":  filterCondition,
+		"advanced_filters":  filter,
+		"segment_id":        c.Query("segment_id", ""),
+		"valid_sort_fields": getKeys(repositories.EventSortColumns),
+		"timezone":          "America/Sao_Paulo", // Adicionar informação sobre o timezone
+	}
+	for key, value := range paginationFields(pageRequest, pageResult) {
+		meta[key] = value
+	}
+	meta["last_page"] = meta["totalPages"]
+
 	return c.JSON(fiber.Map{
 		"events": events,
-		"meta": fiber.Map{
-			"total":             total,
-			"page":              page,
-			"limit":             limit,
-			"last_page":         (total + int64(limit) - 1) / int64(limit),
-			"from":              fromTime.Format(time.RFC3339),
-			"to":                toTime.Format(time.RFC3339),
-			"sort_by":           sortBy,
-			"sort_direction":    sortDirection,
-			"profession_ids":    professionIDs,
-			"funnel_ids":        funnelIDs,
-			"filter_condition":  filterCondition,
-			"advanced_filters":  advancedFilters,
-			"valid_sort_fields": getKeys(validSortFields),
-			"timezone":          "America/Sao_Paulo", // Adicionar informação sobre o timezone
-		},
+		"meta":   meta,
 	})
 }
 
@@ -530,3 +496,43 @@ func getKeys(m map[string]string) []string {
 	}
 	return keys
 }
+
+// errInvalidAdvancedFilters indica um advanced_filters que não é JSON válido
+var errInvalidAdvancedFilters = errors.New("Invalid advanced_filters format. Expected JSON array or filter tree object.")
+
+// parseAdvancedFilters lê advanced_filters: uma lista JSON de filtros (formato antigo, unida por filterCondition)
+// ou um objeto com a árvore de grupos AND/OR/NOT. Retorna nil quando não há filtros.
+func parseAdvancedFilters(raw, filterCondition string) (*repositories.FilterNode, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	var filter *repositories.FilterNode
+	if strings.HasPrefix(raw, "[") {
+		var flat []repositories.AdvancedFilter
+		if err := json.Unmarshal([]byte(raw), &flat); err != nil {
+			return nil, errInvalidAdvancedFilters
+		}
+		filter = repositories.NewFlatFilterTree(flat, filterCondition)
+	} else {
+		filter = &repositories.FilterNode{}
+		if err := json.Unmarshal([]byte(raw), filter); err != nil {
+			return nil, errInvalidAdvancedFilters
+		}
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, fmt.Errorf("Invalid advanced_filters: %w", err)
+	}
+	return filter, nil
+}
+
+// eventQueryStatus retorna o status HTTP adequado para um erro na consulta de eventos: a regex de um
+// filtro rejeitada pelo Postgres é erro do cliente
+func eventQueryStatus(err error) int {
+	if errors.Is(err, repositories.ErrInvalidFilterRegex) {
+		return fiber.StatusBadRequest
+	}
+	return fiber.StatusInternalServerError
+}
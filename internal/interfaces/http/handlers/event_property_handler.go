@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+// EventPropertyHandler lida com a descoberta das propriedades do JSON dos eventos
+type EventPropertyHandler struct {
+	propertyUseCase usecases.EventPropertyUseCase
+}
+
+// NewEventPropertyHandler cria uma nova instância de EventPropertyHandler
+func NewEventPropertyHandler(propertyUseCase usecases.EventPropertyUseCase) *EventPropertyHandler {
+	return &EventPropertyHandler{
+		propertyUseCase: propertyUseCase,
+	}
+}
+
+// GetProperties lista as propriedades observadas em event_propeties por tipo de evento
+// @Summary Descoberta de propriedades dos eventos
+// @Description Amostra os eventos mais recentes de cada tipo e reporta os caminhos do JSON, tipos inferidos e valores mais frequentes, para montar filtros (event.properties.<caminho>)
+// @Tags events
+// @Produce json
+// @Param event_type query string false "Tipo de evento (ex.: LEAD, PURCHASE); vazio considera todos"
+// @Param sample query int false "Eventos amostrados por tipo (máximo 10000)" default(1000)
+// @Param days query int false "Considerar eventos dos últimos N dias (máximo 365)" default(30)
+// @Param top query int false "Valores mais frequentes por propriedade (máximo 50)" default(10)
+// @Success 200 {object} usecases.EventPropertyDiscoveryResult "Propriedades por tipo de evento"
+// @Failure 400 {object} map[string]interface{} "Parâmetros inválidos"
+// @Router /events/properties [get]
+func (h *EventPropertyHandler) GetProperties(c *fiber.Ctx) error {
+	input := usecases.EventPropertyDiscoveryInput{
+		EventType: strings.ToUpper(strings.TrimSpace(c.Query("event_type", ""))),
+	}
+
+	for name, target := range map[string]*int{
+		"sample": &input.SampleSize,
+		"days":   &input.Days,
+		"top":    &input.TopValues,
+	} {
+		if raw := c.Query(name, ""); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil || value <= 0 {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Parâmetro '" + name + "' inválido",
+				})
+			}
+			*target = value
+		}
+	}
+
+	result, err := h.propertyUseCase.DiscoverProperties(input)
+	if err != nil {
+		if errors.Is(err, usecases.ErrInvalidPropertyDiscovery) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"data": result})
+}
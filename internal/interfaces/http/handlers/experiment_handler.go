@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ExperimentHandler lida com requisições de experimentos de landing page
+type ExperimentHandler struct {
+	experimentUseCase usecases.ExperimentUseCase
+}
+
+// NewExperimentHandler cria uma nova instância de ExperimentHandler
+func NewExperimentHandler(experimentUseCase usecases.ExperimentUseCase) *ExperimentHandler {
+	return &ExperimentHandler{
+		experimentUseCase: experimentUseCase,
+	}
+}
+
+// GetExperiments lista os experimentos, opcionalmente de um funil
+// @Summary Lista experimentos de landing page
+// @Tags experiments
+// @Produce json
+// @Param funnel_id query int false "ID do funil"
+// @Success 200 {object} map[string]interface{} "Experimentos com suas variantes"
+// @Router /experiments [get]
+func (h *ExperimentHandler) GetExperiments(c *fiber.Ctx) error {
+	funnelID, err := strconv.Atoi(c.Query("funnel_id", "0"))
+	if err != nil || funnelID < 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Parâmetro 'funnel_id' inválido"})
+	}
+
+	experiments, err := h.experimentUseCase.ListExperiments(funnelID)
+	if err != nil {
+		return experimentErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  experiments,
+		"total": len(experiments),
+	})
+}
+
+// GetExperiment retorna um experimento
+// @Summary Retorna um experimento de landing page
+// @Tags experiments
+// @Produce json
+// @Param id path int true "ID do experimento"
+// @Success 200 {object} entities.Experiment "Experimento"
+// @Failure 404 {object} map[string]interface{} "Experimento não encontrado"
+// @Router /experiments/{id} [get]
+func (h *ExperimentHandler) GetExperiment(c *fiber.Ctx) error {
+	experimentID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || experimentID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de experimento inválido"})
+	}
+
+	experiment, err := h.experimentUseCase.GetExperiment(experimentID)
+	if err != nil {
+		return experimentErrorResponse(c, err)
+	}
+
+	return c.JSON(experiment)
+}
+
+// CreateExperiment cria um experimento com suas variantes de landing page
+// @Summary Cria um experimento de landing page
+// @Description Cada variante é um caminho de landing page do funil. Sem variante marcada como controle, a primeira é o controle.
+// @Tags experiments
+// @Accept json
+// @Produce json
+// @Success 201 {object} entities.Experiment "Experimento criado"
+// @Failure 400 {object} map[string]interface{} "Experimento inválido"
+// @Router /experiments [post]
+func (h *ExperimentHandler) CreateExperiment(c *fiber.Ctx) error {
+	var input usecases.ExperimentInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+	}
+
+	experiment, err := h.experimentUseCase.CreateExperiment(input)
+	if err != nil {
+		return experimentErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(experiment)
+}
+
+// UpdateExperiment altera um experimento; variants, se informado, substitui as variantes
+// @Summary Altera um experimento de landing page
+// @Tags experiments
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do experimento"
+// @Success 200 {object} entities.Experiment "Experimento alterado"
+// @Failure 400 {object} map[string]interface{} "Experimento inválido"
+// @Failure 404 {object} map[string]interface{} "Experimento não encontrado"
+// @Router /experiments/{id} [put]
+func (h *ExperimentHandler) UpdateExperiment(c *fiber.Ctx) error {
+	experimentID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || experimentID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de experimento inválido"})
+	}
+
+	var input usecases.ExperimentInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+	}
+
+	experiment, err := h.experimentUseCase.UpdateExperiment(experimentID, input)
+	if err != nil {
+		return experimentErrorResponse(c, err)
+	}
+
+	return c.JSON(experiment)
+}
+
+// DeleteExperiment remove um experimento e suas variantes
+// @Summary Remove um experimento de landing page
+// @Tags experiments
+// @Param id path int true "ID do experimento"
+// @Success 204
+// @Failure 404 {object} map[string]interface{} "Experimento não encontrado"
+// @Router /experiments/{id} [delete]
+func (h *ExperimentHandler) DeleteExperiment(c *fiber.Ctx) error {
+	experimentID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || experimentID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de experimento inválido"})
+	}
+
+	if err := h.experimentUseCase.DeleteExperiment(experimentID); err != nil {
+		return experimentErrorResponse(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetExperimentResults retorna os resultados do experimento por variante
+// @Summary Resultados do experimento de landing page
+// @Description Sessões, visitantes, leads, conversão visitante → lead, compras e receita por variante, com intervalos de confiança de 95%, testes contra o controle, veredito e orientação de tamanho de amostra/poder
+// @Tags experiments
+// @Produce json
+// @Param id path int true "ID do experimento"
+// @Param mde query number false "Efeito mínimo detectável relativo para o cálculo de amostra (padrão 0.1 = 10%)"
+// @Success 200 {object} usecases.ExperimentResults "Resultados"
+// @Failure 404 {object} map[string]interface{} "Experimento não encontrado"
+// @Router /experiments/{id}/results [get]
+func (h *ExperimentHandler) GetExperimentResults(c *fiber.Ctx) error {
+	experimentID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || experimentID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de experimento inválido"})
+	}
+
+	mde := 0.0
+	if value := c.Query("mde", ""); value != "" {
+		mde, err = strconv.ParseFloat(value, 64)
+		if err != nil || mde <= 0 || mde > 10 {
+			return c.Status(400).JSON(fiber.Map{"error": "Parâmetro 'mde' inválido (use um valor relativo, ex.: 0.1 para 10%)"})
+		}
+	}
+
+	results, err := h.experimentUseCase.GetResults(experimentID, mde)
+	if err != nil {
+		return experimentErrorResponse(c, err)
+	}
+
+	return c.JSON(results)
+}
+
+// GetLandingPages lista os caminhos de landing page com tráfego no funil, para montar as variantes
+// @Summary Lista landing pages do funil
+// @Tags experiments
+// @Produce json
+// @Param id path int true "ID do funil"
+// @Param from query string false "Data inicial (YYYY-MM-DD ou RFC3339, padrão 30 dias atrás)"
+// @Param to query string false "Data final (YYYY-MM-DD ou RFC3339, padrão agora)"
+// @Success 200 {object} map[string]interface{} "Caminhos normalizados e quantidade de sessões"
+// @Router /funnels/{id}/landing-pages [get]
+func (h *ExperimentHandler) GetLandingPages(c *fiber.Ctx) error {
+	funnelID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || funnelID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de funil inválido"})
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+	if value := c.Query("from", ""); value != "" {
+		if from, err = parseCycleDate(value); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Parâmetro 'from' inválido"})
+		}
+	}
+	if value := c.Query("to", ""); value != "" {
+		if to, err = parseCycleDate(value); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Parâmetro 'to' inválido"})
+		}
+	}
+
+	pages, err := h.experimentUseCase.ListLandingPages(funnelID, from, to)
+	if err != nil {
+		return experimentErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  pages,
+		"total": len(pages),
+	})
+}
+
+// experimentErrorResponse traduz erros de experimentos para respostas HTTP
+func experimentErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, usecases.ErrInvalidExperiment):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, usecases.ErrExperimentNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/infrastructure/exporter"
+	"github.com/gofiber/fiber/v2"
+)
+
+// errInvalidExport indica parâmetros de exportação inválidos
+var errInvalidExport = errors.New("parâmetros de exportação inválidos")
+
+const (
+	// exportTimeout limita a duração total de uma exportação
+	exportTimeout = 30 * time.Minute
+	// exportWriteTimeout é o prazo de cada escrita; é renovado a cada lote, para que exportações longas
+	// não esbarrem no WriteTimeout do servidor enquanto continuam enviando dados
+	exportWriteTimeout = 60 * time.Second
+	// exportFileChunk é o tamanho de cada escrita no envio de um arquivo já gerado
+	exportFileChunk = 1 << 20
+)
+
+// exportRequest é uma exportação pedida com format=csv|xlsx|ndjson
+type exportRequest struct {
+	format   string
+	name     string
+	columns  []repositories.ExportColumn
+	maskPII  bool
+	filename string
+}
+
+// parseExportRequest lê format, columns e mask_pii de uma listagem. Retorna nil quando a resposta
+// é JSON (format vazio ou json). Os dados pessoais são mascarados, exceto com mask_pii=false.
+func parseExportRequest(c *fiber.Ctx, name string, available []repositories.ExportColumn) (*exportRequest, error) {
+	format := strings.ToLower(strings.TrimSpace(c.Query("format", "")))
+	switch format {
+	case "", "json":
+		return nil, nil
+	case exporter.FormatCSV, exporter.FormatXLSX, exporter.FormatNDJSON:
+	default:
+		return nil, fmt.Errorf("%w: format deve ser json, csv, xlsx ou ndjson", errInvalidExport)
+	}
+
+	var requested []string
+	for _, column := range strings.Split(c.Query("columns", ""), ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			requested = append(requested, column)
+		}
+	}
+	columns, err := repositories.SelectExportColumns(available, requested)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v (disponíveis: %s)", errInvalidExport, err,
+			strings.Join(repositories.ExportColumnNames(available), ", "))
+	}
+
+	maskPII := true
+	switch c.Query("mask_pii", "") {
+	case "", "true":
+	case "false":
+		maskPII = false
+	default:
+		return nil, fmt.Errorf("%w: mask_pii deve ser true ou false", errInvalidExport)
+	}
+
+	return &exportRequest{
+		format:   format,
+		name:     name,
+		columns:  columns,
+		maskPII:  maskPII,
+		filename: fmt.Sprintf("%s-%s.%s", name, time.Now().In(GetBrasilLocation()).Format("20060102-150405"), format),
+	}, nil
+}
+
+// streamExport envia o arquivo da exportação em streaming. As linhas são mascaradas, escritas e enviadas
+// em lotes, com memória constante. Erros depois do início do envio só podem ser registrados, pois o status já foi enviado.
+func streamExport(c *fiber.Ctx, req *exportRequest, produce exporter.Producer) error {
+	c.Set(fiber.HeaderContentType, exporter.ContentType(req.format))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, req.filename))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	conn := c.Context().Conn()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+
+		extendDeadline := func() {
+			if conn != nil {
+				_ = conn.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+			}
+		}
+		extendDeadline()
+
+		rows, err := exporter.Write(ctx, w, req.format, req.columns, req.maskPII, produce, func(int64) error {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			extendDeadline()
+			return nil
+		})
+		if err != nil {
+			fmt.Printf("Erro na exportação de %s (%s) após %d linhas: %v\n", req.name, req.format, rows, err)
+		}
+		_ = w.Flush()
+	})
+	return nil
+}
+
+// sendExportFile envia um arquivo gerado em segundo plano (jobs de exportação, arquivos do warehouse)
+// em blocos, renovando o prazo de escrita a cada bloco, para que downloads grandes não esbarrem no WriteTimeout
+func sendExportFile(c *fiber.Ctx, path, contentType, filename string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c.Status(fiber.StatusGone).JSON(fiber.Map{"error": "arquivo não está disponível nesta instância"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	conn := c.Context().Conn()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer file.Close()
+		buf := make([]byte, exportFileChunk)
+		for {
+			if conn != nil {
+				_ = conn.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+			}
+			n, err := file.Read(buf)
+			if n > 0 {
+				if _, werr := w.Write(buf[:n]); werr != nil {
+					return
+				}
+				if werr := w.Flush(); werr != nil {
+					return
+				}
+			}
+			if err != nil {
+				if err != io.EOF {
+					fmt.Printf("Erro ao enviar o arquivo %s: %v\n", path, err)
+				}
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// exportStatus retorna o status HTTP adequado para um erro de exportação
+func exportStatus(err error) int {
+	if errors.Is(err, errInvalidExport) || errors.Is(err, repositories.ErrInvalidExportSort) {
+		return fiber.StatusBadRequest
+	}
+	return fiber.StatusInternalServerError
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/infrastructure/exporter"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// ExportJobHandler lida com as exportações assíncronas. Os filtros e as colunas são validados pelo
+// ExportJobUseCase; o arquivo é gerado pelo ExportJobWorker e apenas enviado no download.
+type ExportJobHandler struct {
+	jobUseCase usecases.ExportJobUseCase
+}
+
+// NewExportJobHandler cria uma nova instância de ExportJobHandler
+func NewExportJobHandler(jobUseCase usecases.ExportJobUseCase) *ExportJobHandler {
+	return &ExportJobHandler{
+		jobUseCase: jobUseCase,
+	}
+}
+
+// exportJobBody é o corpo de POST /exports. Filters recebe os filtros da listagem do dataset (ver usecases.ExportJobFilters).
+type exportJobBody struct {
+	Dataset string          `json:"dataset"`
+	Format  string          `json:"format"`
+	Columns []string        `json:"columns"`
+	MaskPII *bool           `json:"mask_pii"`
+	Filters json.RawMessage `json:"filters"`
+}
+
+// CreateJob enfileira uma exportação
+// @Summary Enfileira uma exportação assíncrona
+// @Description Os filtros são os mesmos da listagem do dataset (events, sessions, leads ou clients)
+// @Tags exports
+// @Accept json
+// @Produce json
+// @Success 202 {object} map[string]interface{} "Job enfileirado"
+// @Failure 400 {object} map[string]interface{} "Pedido inválido"
+// @Router /exports [post]
+func (h *ExportJobHandler) CreateJob(c *fiber.Ctx) error {
+	var body exportJobBody
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+	}
+
+	job, err := h.jobUseCase.CreateJob(usecases.ExportJobInput{
+		Dataset: body.Dataset,
+		Format:  body.Format,
+		Filters: body.Filters,
+		Columns: body.Columns,
+		// Como nas exportações síncronas, os dados pessoais só saem sem máscara com mask_pii=false
+		MaskPII: body.MaskPII == nil || *body.MaskPII,
+	})
+	if err != nil {
+		return exportJobErrorResponse(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(h.jobResponse(c, job, true))
+}
+
+// GetJobs lista as exportações mais recentes
+// @Summary Lista as exportações assíncronas mais recentes
+// @Tags exports
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Jobs"
+// @Router /exports [get]
+func (h *ExportJobHandler) GetJobs(c *fiber.Ctx) error {
+	jobs, err := h.jobUseCase.ListJobs()
+	if err != nil {
+		return exportJobErrorResponse(c, err)
+	}
+
+	data := make([]fiber.Map, len(jobs))
+	for i := range jobs {
+		data[i] = h.jobResponse(c, &jobs[i], false)
+	}
+	return c.JSON(fiber.Map{
+		"data":  data,
+		"total": len(data),
+	})
+}
+
+// GetJob retorna o andamento de uma exportação e, para quem informa o token do job, o link de download
+// @Summary Retorna uma exportação assíncrona
+// @Tags exports
+// @Produce json
+// @Param id path string true "ID do job"
+// @Param token query string false "Token do job, retornado na criação"
+// @Success 200 {object} map[string]interface{} "Job"
+// @Failure 404 {object} map[string]interface{} "Job não encontrado"
+// @Router /exports/{id} [get]
+func (h *ExportJobHandler) GetJob(c *fiber.Ctx) error {
+	jobID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "ID de exportação inválido"})
+	}
+
+	job, err := h.jobUseCase.GetJob(jobID)
+	if err != nil {
+		return exportJobErrorResponse(c, err)
+	}
+	return c.JSON(h.jobResponse(c, job, usecases.HasDownloadToken(job, c.Query("token", ""))))
+}
+
+// DownloadJob envia o arquivo de uma exportação concluída
+// @Summary Baixa o arquivo de uma exportação assíncrona
+// @Tags exports
+// @Produce octet-stream
+// @Param id path string true "ID do job"
+// @Param token query string true "Token do link de download"
+// @Success 200 {file} file "Arquivo"
+// @Failure 404 {object} map[string]interface{} "Job não encontrado"
+// @Failure 409 {object} map[string]interface{} "Exportação não concluída"
+// @Failure 410 {object} map[string]interface{} "Exportação expirada"
+// @Router /exports/{id}/download [get]
+func (h *ExportJobHandler) DownloadJob(c *fiber.Ctx) error {
+	jobID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "ID de exportação inválido"})
+	}
+
+	job, err := h.jobUseCase.GetDownload(jobID, c.Query("token", ""))
+	if err != nil {
+		return exportJobErrorResponse(c, err)
+	}
+
+	return sendExportFile(c, job.FilePath, exporter.ContentType(job.Format), job.FileName)
+}
+
+// jobResponse monta a resposta de um job. O token e o link de download vão apenas para quem criou o job
+// (owner): na criação e em GET /exports/:id com o token; nunca na listagem.
+func (h *ExportJobHandler) jobResponse(c *fiber.Ctx, job *entities.ExportJob, owner bool) fiber.Map {
+	response := fiber.Map{
+		"job_id":      job.JobID,
+		"dataset":     job.Dataset,
+		"format":      job.Format,
+		"filters":     jobFilters(job),
+		"columns":     job.Columns,
+		"mask_pii":    job.MaskPII,
+		"status":      job.Status,
+		"attempts":    job.Attempts,
+		"rows":        job.Rows,
+		"bytes":       job.Bytes,
+		"created_at":  job.CreatedAt,
+		"started_at":  job.StartedAt,
+		"finished_at": job.FinishedAt,
+		"expires_at":  job.ExpiresAt,
+		"status_url":  fmt.Sprintf("%s/exports/%s", c.BaseURL(), job.JobID),
+	}
+	if job.Error != "" {
+		response["error"] = job.Error
+	}
+	if job.Status == entities.ExportJobDone && job.ExpiresAt != nil && time.Now().Before(*job.ExpiresAt) {
+		response["file_name"] = job.FileName
+	}
+	if owner {
+		token := url.QueryEscape(job.DownloadToken)
+		response["token"] = job.DownloadToken
+		response["status_url"] = fmt.Sprintf("%s/exports/%s?token=%s", c.BaseURL(), job.JobID, token)
+		response["download_url"] = fmt.Sprintf("%s/exports/%s/download?token=%s", c.BaseURL(), job.JobID, token)
+	}
+	return response
+}
+
+// jobFilters retorna os filtros guardados do job como JSON (texto, se não forem JSON válido)
+func jobFilters(job *entities.ExportJob) interface{} {
+	if json.Valid([]byte(job.Query)) {
+		return json.RawMessage(job.Query)
+	}
+	return job.Query
+}
+
+// exportJobErrorResponse responde com o status HTTP adequado para um erro de exportação assíncrona
+func exportJobErrorResponse(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, usecases.ErrInvalidExportJob), errors.Is(err, usecases.ErrInvalidSegment),
+		errors.Is(err, usecases.ErrSegmentNotFound):
+		// Um segment_id inexistente nos filtros é um erro do pedido, não um job não encontrado
+		status = fiber.StatusBadRequest
+	case errors.Is(err, usecases.ErrExportJobNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, usecases.ErrExportJobNotReady):
+		status = fiber.StatusConflict
+	case errors.Is(err, usecases.ErrExportJobExpired):
+		status = fiber.StatusGone
+	}
+	return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultForecastHistoryCycles = 8
+	maxForecastHistoryCycles     = 52
+	defaultForecastConfidence    = 0.9
+)
+
+// ForecastHandler lida com requisições de projeção dos ciclos de webinar
+type ForecastHandler struct {
+	forecastUseCase usecases.ForecastUseCase
+	cycleUseCase    usecases.WebinarCycleUseCase
+}
+
+// NewForecastHandler cria uma nova instância de ForecastHandler
+func NewForecastHandler(forecastUseCase usecases.ForecastUseCase, cycleUseCase usecases.WebinarCycleUseCase) *ForecastHandler {
+	return &ForecastHandler{
+		forecastUseCase: forecastUseCase,
+		cycleUseCase:    cycleUseCase,
+	}
+}
+
+// GetCycleForecast retorna a projeção de leads, respostas de pesquisa e faturamento do ciclo em andamento
+// @Summary Projeção do ciclo de webinar
+// @Description Projeta onde o ciclo vai fechar (terça 20:00 para captação, 23:59 para vendas) usando as curvas acumuladas dos ciclos anteriores da mesma profissão e o ritmo horário atual
+// @Tags forecast
+// @Produce json
+// @Param profession_id query int false "ID da profissão (obrigatório sem cycle_id; com cycle_id, vem do ciclo)"
+// @Param funnel_id query int false "ID do funil"
+// @Param cycle_id query int false "ID do ciclo de webinar (substitui venda_inicio); informe profession_id ou cycle_id"
+// @Param venda_inicio query string false "Dia de vendas do ciclo (ISO8601). Padrão: ciclo em andamento"
+// @Param history query int false "Quantidade de ciclos anteriores usados como referência" default(8)
+// @Param confidence query number false "Nível de confiança das bandas" default(0.9)
+// @Success 200 {object} map[string]interface{} "Projeção do ciclo"
+// @Failure 400 {object} map[string]interface{} "Erro de parâmetros"
+// @Failure 500 {object} map[string]interface{} "Erro interno do servidor"
+// @Router /forecast/cycle [get]
+func (h *ForecastHandler) GetCycleForecast(c *fiber.Ctx) error {
+	cycleEntity, cycleDates, _, err := resolveCycleParam(c, h.cycleUseCase)
+	if err != nil {
+		return c.Status(cycleParamStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	professionID, err := strconv.Atoi(c.Query("profession_id", "0"))
+	if err == nil && professionID == 0 && cycleEntity != nil {
+		professionID = cycleEntity.ProfessionID
+	}
+	if err != nil || professionID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Informe 'profession_id' ou 'cycle_id'",
+		})
+	}
+
+	funnelID, err := strconv.Atoi(c.Query("funnel_id", "0"))
+	if err == nil && funnelID == 0 && cycleEntity != nil && cycleEntity.FunnelID != nil {
+		funnelID = *cycleEntity.FunnelID
+	}
+	if err != nil || funnelID < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Parâmetro 'funnel_id' inválido",
+		})
+	}
+
+	historyCycles, err := strconv.Atoi(c.Query("history", strconv.Itoa(defaultForecastHistoryCycles)))
+	if err != nil || historyCycles < 1 || historyCycles > maxForecastHistoryCycles {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Parâmetro 'history' deve estar entre 1 e " + strconv.Itoa(maxForecastHistoryCycles),
+		})
+	}
+
+	confidence, err := strconv.ParseFloat(c.Query("confidence", strconv.FormatFloat(defaultForecastConfidence, 'f', -1, 64)), 64)
+	if err != nil || confidence <= 0 || confidence >= 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Parâmetro 'confidence' deve estar entre 0 e 1",
+		})
+	}
+
+	// Ciclo em andamento por padrão, ou o ciclo cujo dia de vendas foi informado
+	cycle := utils.CurrentWebinarCycleDates(time.Now().In(utils.GetBrasilLocation()))
+	if cycleEntity != nil {
+		cycle = cycleDates
+	} else if vendaInicioStr := c.Query("venda_inicio", ""); vendaInicioStr != "" {
+		vendaInicio, err := parseCycleDate(vendaInicioStr)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Formato de data inválido para 'venda_inicio'",
+			})
+		}
+		if vendaInicio.Weekday() != time.Tuesday {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "'venda_inicio' deve ser uma terça-feira",
+			})
+		}
+		cycle = utils.CalculateWebinarCycleDates(vendaInicio)
+	}
+
+	forecast, err := h.forecastUseCase.ForecastCycle(cycle, professionID, funnelID, historyCycles, confidence)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Erro ao calcular projeção do ciclo: " + err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    forecast,
+	})
+}
+
+// parseCycleDate interpreta uma data de ciclo (RFC3339 ou YYYY-MM-DD) no fuso de São Paulo
+func parseCycleDate(value string) (time.Time, error) {
+	loc := utils.GetBrasilLocation()
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.In(loc), nil
+	}
+	return time.ParseInLocation("2006-01-02", value, loc)
+}
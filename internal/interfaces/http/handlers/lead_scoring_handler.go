@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+// LeadScoringHandler lida com requisições de regras e pontuação de leads
+type LeadScoringHandler struct {
+	leadScoringUseCase usecases.LeadScoringUseCase
+}
+
+// NewLeadScoringHandler cria uma nova instância de LeadScoringHandler
+func NewLeadScoringHandler(leadScoringUseCase usecases.LeadScoringUseCase) *LeadScoringHandler {
+	return &LeadScoringHandler{
+		leadScoringUseCase: leadScoringUseCase,
+	}
+}
+
+// GetRuleSets lista as versões das regras de pontuação
+// @Summary Lista versões das regras de pontuação de leads
+// @Tags lead-scoring
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Versões das regras"
+// @Router /lead/scoring/rules [get]
+func (h *LeadScoringHandler) GetRuleSets(c *fiber.Ctx) error {
+	ruleSets, err := h.leadScoringUseCase.ListRuleSets()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  ruleSets,
+		"total": len(ruleSets),
+	})
+}
+
+// GetRuleSet retorna uma versão das regras de pontuação
+// @Summary Retorna uma versão das regras de pontuação
+// @Tags lead-scoring
+// @Produce json
+// @Param version path int true "Versão das regras"
+// @Success 200 {object} entities.LeadScoringRuleSet "Regras"
+// @Failure 404 {object} map[string]interface{} "Versão não encontrada"
+// @Router /lead/scoring/rules/{version} [get]
+func (h *LeadScoringHandler) GetRuleSet(c *fiber.Ctx) error {
+	version, err := strconv.ParseInt(c.Params("version"), 10, 64)
+	if err != nil || version <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Versão inválida"})
+	}
+
+	ruleSet, err := h.leadScoringUseCase.GetRuleSet(version)
+	if err != nil {
+		return leadScoringErrorResponse(c, err)
+	}
+
+	return c.JSON(ruleSet)
+}
+
+// CreateRuleSet cria uma nova versão das regras de pontuação.
+// As versões anteriores são mantidas para auditoria e podem ser reativadas.
+// @Summary Cria uma versão das regras de pontuação
+// @Tags lead-scoring
+// @Accept json
+// @Produce json
+// @Success 201 {object} entities.LeadScoringRuleSet "Regras criadas"
+// @Failure 400 {object} map[string]interface{} "Regras inválidas"
+// @Router /lead/scoring/rules [post]
+func (h *LeadScoringHandler) CreateRuleSet(c *fiber.Ctx) error {
+	var input usecases.LeadScoringRuleSetInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+	}
+
+	ruleSet, err := h.leadScoringUseCase.CreateRuleSet(input)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(ruleSet)
+}
+
+// ActivateRuleSet define a versão ativa das regras, usada na listagem de leads
+// @Summary Ativa uma versão das regras de pontuação
+// @Tags lead-scoring
+// @Produce json
+// @Param version path int true "Versão das regras"
+// @Success 200 {object} entities.LeadScoringRuleSet "Regras ativadas"
+// @Failure 404 {object} map[string]interface{} "Versão não encontrada"
+// @Router /lead/scoring/rules/{version}/activate [post]
+func (h *LeadScoringHandler) ActivateRuleSet(c *fiber.Ctx) error {
+	version, err := strconv.ParseInt(c.Params("version"), 10, 64)
+	if err != nil || version <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Versão inválida"})
+	}
+
+	ruleSet, err := h.leadScoringUseCase.ActivateRuleSet(version)
+	if err != nil {
+		return leadScoringErrorResponse(c, err)
+	}
+
+	return c.JSON(ruleSet)
+}
+
+// RecomputeScores recalcula a pontuação de todos os leads
+// @Summary Recalcula a pontuação dos leads
+// @Tags lead-scoring
+// @Produce json
+// @Param version query int false "Versão das regras (padrão: versão ativa)"
+// @Success 200 {object} usecases.LeadScoreRecomputeResult "Resumo do recálculo"
+// @Failure 404 {object} map[string]interface{} "Versão não encontrada"
+// @Router /lead/scoring/recompute [post]
+func (h *LeadScoringHandler) RecomputeScores(c *fiber.Ctx) error {
+	version, err := strconv.ParseInt(c.Query("version", "0"), 10, 64)
+	if err != nil || version < 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Parâmetro 'version' inválido"})
+	}
+
+	result, err := h.leadScoringUseCase.RecomputeScores(version)
+	if err != nil {
+		return leadScoringErrorResponse(c, err)
+	}
+
+	return c.JSON(result)
+}
+
+// GetLeadScore retorna a pontuação, o tier e o detalhamento de um lead na versão ativa
+// @Summary Pontuação de um lead
+// @Tags lead-scoring
+// @Produce json
+// @Param id path string true "ID do lead"
+// @Success 200 {object} entities.LeadScore "Pontuação do lead"
+// @Failure 404 {object} map[string]interface{} "Pontuação não encontrada"
+// @Router /lead/{id}/score [get]
+func (h *LeadScoringHandler) GetLeadScore(c *fiber.Ctx) error {
+	userID := c.Params("id")
+	if userID == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "ID do lead é obrigatório"})
+	}
+
+	score, err := h.leadScoringUseCase.GetLeadScore(userID)
+	if err != nil {
+		return leadScoringErrorResponse(c, err)
+	}
+
+	return c.JSON(score)
+}
+
+// leadScoringErrorResponse traduz erros de pontuação para respostas HTTP
+func leadScoringErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, usecases.ErrLeadScoringRulesNotFound),
+		errors.Is(err, usecases.ErrLeadScoreNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, usecases.ErrNoActiveLeadScoringRules):
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+}
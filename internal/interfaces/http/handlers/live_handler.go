@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// liveRetryMillis é o intervalo de reconexão sugerido ao EventSource
+	liveRetryMillis = 3000
+	// liveHeartbeat é o intervalo dos comentários que mantêm a conexão aberta em proxies sem mensagens
+	liveHeartbeat = 15 * time.Second
+	// liveWriteTimeout é o prazo de cada escrita; um cliente que não lê por esse tempo é desconectado
+	liveWriteTimeout = 30 * time.Second
+)
+
+// LiveHandler lida com o feed ao vivo de leads e vendas
+type LiveHandler struct {
+	liveUseCase usecases.LiveUseCase
+}
+
+// NewLiveHandler cria uma nova instância de LiveHandler
+func NewLiveHandler(liveUseCase usecases.LiveUseCase) *LiveHandler {
+	return &LiveHandler{
+		liveUseCase: liveUseCase,
+	}
+}
+
+// Stream envia por Server-Sent Events os novos eventos LEAD, PESQUISA_LEAD e PURCHASE e os contadores do dia
+// @Summary Feed ao vivo de leads e vendas
+// @Description Eventos SSE: lead, pesquisa_lead e purchase (com id para retomar via Last-Event-ID), counters e reset
+// @Tags live
+// @Produce text/event-stream
+// @Param profession_id query int false "ID da profissão"
+// @Param funnel_id query int false "ID do funil"
+// @Param last_event_id query string false "Posição de retomada, para clientes que não enviam o cabeçalho Last-Event-ID"
+// @Success 200 {string} string "Stream de eventos"
+// @Failure 400 {object} map[string]interface{} "Parâmetros inválidos"
+// @Failure 503 {object} map[string]interface{} "Limite de conexões atingido"
+// @Router /live/stream [get]
+func (h *LiveHandler) Stream(c *fiber.Ctx) error {
+	professionID, err := strconv.Atoi(c.Query("profession_id", "0"))
+	if err != nil || professionID < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Parâmetro 'profession_id' inválido"})
+	}
+	funnelID, err := strconv.Atoi(c.Query("funnel_id", "0"))
+	if err != nil || funnelID < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Parâmetro 'funnel_id' inválido"})
+	}
+
+	lastEventID := c.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	filter := repositories.LiveFilter{ProfessionID: professionID, FunnelID: funnelID}
+	sub, err := h.liveUseCase.Subscribe(c.UserContext(), filter, lastEventID)
+	if err != nil {
+		status := fiber.StatusInternalServerError
+		switch {
+		case errors.Is(err, repositories.ErrInvalidCursor):
+			status = fiber.StatusBadRequest
+		case errors.Is(err, usecases.ErrLiveUnavailable), errors.Is(err, usecases.ErrLiveStopped):
+			status = fiber.StatusServiceUnavailable
+		}
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream; charset=utf-8")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// Desativa o buffer de proxies como o nginx
+	c.Set("X-Accel-Buffering", "no")
+
+	conn := c.Context().Conn()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		// flush envia o que foi escrito; um erro indica que o cliente desconectou
+		flush := func() bool {
+			if conn != nil {
+				_ = conn.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+			}
+			return w.Flush() == nil
+		}
+
+		fmt.Fprintf(w, "retry: %d\n\n", liveRetryMillis)
+		for _, msg := range sub.Replay {
+			writeLiveMessage(w, msg)
+		}
+		if !flush() {
+			return
+		}
+
+		heartbeat := time.NewTicker(liveHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case msg, ok := <-sub.Messages:
+				// Canal fechado: o cliente ficou para trás ou o feed parou; o EventSource reconecta
+				// com o Last-Event-ID e recebe o que perdeu
+				if !ok {
+					return
+				}
+				if sub.Delivered(msg) {
+					continue
+				}
+				writeLiveMessage(w, msg)
+				// Agrupa as mensagens que já estão na fila em um único flush
+				for pending := len(sub.Messages); pending > 0; pending-- {
+					if msg, ok = <-sub.Messages; !ok {
+						break
+					}
+					if !sub.Delivered(msg) {
+						writeLiveMessage(w, msg)
+					}
+				}
+			case <-heartbeat.C:
+				w.WriteString(": ping\n\n")
+			}
+			if !flush() {
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// writeLiveMessage escreve a mensagem no formato SSE. Eventos levam id (a posição para o Last-Event-ID)
+// e o nome do tipo em minúsculas; contadores e reset não alteram o Last-Event-ID.
+func writeLiveMessage(w *bufio.Writer, msg usecases.LiveMessage) {
+	var name string
+	var payload interface{}
+	switch msg.Kind {
+	case usecases.LiveMessageEvent:
+		name, payload = strings.ToLower(msg.Event.EventType), msg.Event
+	case usecases.LiveMessageCounters:
+		name, payload = "counters", msg.Counters
+	default:
+		name, payload = msg.Kind, fiber.Map{}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	if msg.ID != "" {
+		fmt.Fprintf(w, "id: %s\n", msg.ID)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/gofiber/fiber/v2"
+)
+
+// MetricBreakdownHandler lida com requisições de breakdown dimensional de métricas
+type MetricBreakdownHandler struct {
+	breakdownUseCase usecases.MetricBreakdownUseCase
+	cycleUseCase     usecases.WebinarCycleUseCase
+}
+
+// NewMetricBreakdownHandler cria uma nova instância de MetricBreakdownHandler
+func NewMetricBreakdownHandler(breakdownUseCase usecases.MetricBreakdownUseCase, cycleUseCase usecases.WebinarCycleUseCase) *MetricBreakdownHandler {
+	return &MetricBreakdownHandler{
+		breakdownUseCase: breakdownUseCase,
+		cycleUseCase:     cycleUseCase,
+	}
+}
+
+// GetBreakdown retorna métricas por uma ou duas dimensões, com totais, participação e variação
+// @Summary Breakdown de métricas por dimensão
+// @Description Tabela ordenada de sessões, leads, clientes, compras, faturamento e conversão por até duas dimensões, comparando com o período anterior
+// @Tags metrics
+// @Produce json
+// @Param metrics query string false "Métricas separadas por vírgula: sessions, leads, clients, purchases, revenue, conversion" default(sessions,leads,conversion)
+// @Param dimensions query string true "Uma ou duas dimensões separadas por vírgula (profession, product, funnel, landing_page, utm_source, utm_medium, utm_campaign, utm_content, utm_term, marketing_channel, country, state, city, device_type, browser, weekday, hour ou event.properties.<caminho>, só para métricas de eventos)"
+// @Param from query string false "Data inicial (YYYY-MM-DD ou RFC3339)"
+// @Param to query string false "Data final (YYYY-MM-DD ou RFC3339)"
+// @Param cycle_id query int false "ID do ciclo de webinar (substitui from/to; compara com o ciclo anterior)"
+// @Param compare query string false "Período de comparação: previous, previous_year, same_weekday_last_week ou custom" default(previous)
+// @Param compare_from query string false "Início da comparação com compare=custom"
+// @Param compare_to query string false "Fim da comparação com compare=custom"
+// @Param profession_id query int false "ID da profissão"
+// @Param product_id query int false "ID do produto"
+// @Param funnel_id query int false "ID do funil"
+// @Param sort query string false "Métrica de ordenação ou 'dimension' (padrão: primeira métrica)"
+// @Param order query string false "asc ou desc (padrão: desc para métricas, asc para dimensão)"
+// @Param limit query int false "Máximo de linhas (padrão 100, máximo 1000)"
+// @Success 200 {object} usecases.MetricBreakdownResult "Tabela de breakdown"
+// @Failure 400 {object} map[string]interface{} "Parâmetros inválidos"
+// @Router /metrics/breakdown [get]
+func (h *MetricBreakdownHandler) GetBreakdown(c *fiber.Ctx) error {
+	input := usecases.MetricBreakdownInput{
+		Metrics:    splitQueryList(c.Query("metrics", "")),
+		Dimensions: splitQueryList(c.Query("dimensions", "")),
+		SortBy:     c.Query("sort", ""),
+		Order:      strings.ToLower(c.Query("order", "")),
+	}
+
+	for name, target := range map[string]*int{
+		"profession_id": &input.ProfessionID,
+		"product_id":    &input.ProductID,
+		"funnel_id":     &input.FunnelID,
+		"limit":         &input.Limit,
+	} {
+		value, err := strconv.Atoi(c.Query(name, "0"))
+		if err != nil || value < 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Parâmetro '" + name + "' inválido"})
+		}
+		*target = value
+	}
+
+	cycle, cycleDates, previousCycleDates, err := resolveCycleParam(c, h.cycleUseCase)
+	if err != nil {
+		return c.Status(cycleParamStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var previousFrom, previousTo time.Time
+	if cycle != nil {
+		if input.ProfessionID == 0 {
+			input.ProfessionID = cycle.ProfessionID
+		}
+		input.CurrentFrom, input.CurrentTo = cycleDates.PesquisaInicio, cycleDates.VendaFim
+		previousFrom, previousTo = previousCycleDates.PesquisaInicio, previousCycleDates.VendaFim
+	} else {
+		fromStr, toStr := c.Query("from", ""), c.Query("to", "")
+		if fromStr == "" || toStr == "" {
+			return c.Status(400).JSON(fiber.Map{"error": "Os parâmetros 'from' e 'to' são obrigatórios"})
+		}
+		if input.CurrentFrom, err = parseComparisonDate(fromStr, false); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Formato de data inválido para 'from'"})
+		}
+		if input.CurrentTo, err = parseComparisonDate(toStr, true); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Formato de data inválido para 'to'"})
+		}
+		previousFrom, previousTo = usecases.PreviousPeriod(input.CurrentFrom, input.CurrentTo, entities.TimeFrameDaily)
+	}
+
+	input.PreviousFrom, input.PreviousTo, _, err = resolveComparisonPeriod(c, input.CurrentFrom, input.CurrentTo, previousFrom, previousTo)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	result, err := h.breakdownUseCase.GetBreakdown(input)
+	if err != nil {
+		if errors.Is(err, usecases.ErrInvalidBreakdown) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": result,
+		"current_period": fiber.Map{
+			"from": input.CurrentFrom.Format(time.RFC3339),
+			"to":   input.CurrentTo.Format(time.RFC3339),
+		},
+		"previous_period": fiber.Map{
+			"from": input.PreviousFrom.Format(time.RFC3339),
+			"to":   input.PreviousTo.Format(time.RFC3339),
+		},
+	})
+}
+
+// splitQueryList separa uma lista da query string por vírgulas, ignorando itens vazios
+func splitQueryList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
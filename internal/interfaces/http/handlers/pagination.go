@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/gofiber/fiber/v2"
+)
+
+// errInvalidPagination indica parâmetros de paginação inválidos
+var errInvalidPagination = errors.New("parâmetros de paginação inválidos")
+
+// parsePageRequest lê o modo de paginação de uma listagem. O modo cursor é ativado por pagination=cursor
+// ou pela presença de cursor, e só aceita a ordenação pelo campo de tempo da listagem (timeSortFields).
+// count escolhe o total: exact (padrão no modo offset), estimated ou none (padrão no modo cursor).
+func parsePageRequest(c *fiber.Ctx, page, limit int, sortBy, sortDirection string, timeSortFields ...string) (repositories.PageRequest, error) {
+	mode := strings.ToLower(c.Query("pagination", ""))
+	cursor := c.Query("cursor", "")
+	if mode != "" && mode != "offset" && mode != "cursor" {
+		return repositories.PageRequest{}, fmt.Errorf("%w: pagination deve ser offset ou cursor", errInvalidPagination)
+	}
+	keyset := mode == "cursor" || (mode == "" && cursor != "")
+	if mode == "offset" && cursor != "" {
+		return repositories.PageRequest{}, fmt.Errorf("%w: cursor não é aceito com pagination=offset", errInvalidPagination)
+	}
+
+	defaultTotal := repositories.TotalExact
+	if keyset {
+		defaultTotal = repositories.TotalNone
+	}
+	total, err := repositories.ParseTotalMode(c.Query("count", ""), defaultTotal)
+	if err != nil {
+		return repositories.PageRequest{}, fmt.Errorf("%w: %v", errInvalidPagination, err)
+	}
+
+	if !keyset {
+		return repositories.PageRequest{Page: page, Limit: limit, Total: total}, nil
+	}
+
+	if !containsField(timeSortFields, sortBy) {
+		return repositories.PageRequest{}, fmt.Errorf("%w: o modo cursor só aceita sortBy=%s", errInvalidPagination, timeSortFields[0])
+	}
+	return repositories.NewKeysetPageRequest(limit, cursor, !strings.EqualFold(sortDirection, "asc"), total)
+}
+
+// paginationStatus retorna o status HTTP adequado para um erro de paginação
+func paginationStatus(err error) int {
+	if errors.Is(err, errInvalidPagination) || errors.Is(err, repositories.ErrInvalidCursor) {
+		return fiber.StatusBadRequest
+	}
+	return fiber.StatusInternalServerError
+}
+
+// paginationFields monta os campos de paginação da resposta. total e totalPages são nulos quando
+// count=none; no modo cursor, next_cursor leva à página seguinte e é vazio na última.
+func paginationFields(page repositories.PageRequest, result repositories.PageResult) fiber.Map {
+	fields := fiber.Map{
+		"limit":      page.Limit,
+		"count":      result.TotalMode,
+		"total":      nil,
+		"totalPages": nil,
+	}
+	if result.TotalMode != repositories.TotalNone {
+		fields["total"] = result.Total
+		fields["totalPages"] = (result.Total + int64(page.Limit) - 1) / int64(page.Limit)
+	}
+
+	if page.Keyset {
+		fields["pagination"] = "cursor"
+		fields["has_more"] = result.HasMore
+		fields["next_cursor"] = result.NextCursor
+	} else {
+		fields["pagination"] = "offset"
+		fields["page"] = page.Page
+	}
+	return fields
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
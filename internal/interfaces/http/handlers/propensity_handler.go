@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+const defaultPropensityTrainingCycles = 12
+
+// PropensityHandler lida com requisições do modelo de propensão de compra
+type PropensityHandler struct {
+	propensityUseCase usecases.PropensityUseCase
+	cycleUseCase      usecases.WebinarCycleUseCase
+}
+
+// NewPropensityHandler cria uma nova instância de PropensityHandler
+func NewPropensityHandler(propensityUseCase usecases.PropensityUseCase, cycleUseCase usecases.WebinarCycleUseCase) *PropensityHandler {
+	return &PropensityHandler{
+		propensityUseCase: propensityUseCase,
+		cycleUseCase:      cycleUseCase,
+	}
+}
+
+// TrainModel treina um novo modelo de propensão nos ciclos encerrados da profissão/funil
+// @Summary Treina o modelo de propensão de compra
+// @Description Regressão logística treinada nos últimos ciclos encerrados. Features: respostas da pesquisa, faixa, canal, dispositivo, região e engajamento nas sessões. Rótulo: compra na janela de vendas.
+// @Tags propensity
+// @Accept json
+// @Produce json
+// @Success 201 {object} map[string]interface{} "Modelo treinado com coeficientes e métricas (AUC, calibração)"
+// @Failure 400 {object} map[string]interface{} "Erro de parâmetros"
+// @Failure 422 {object} map[string]interface{} "Dados insuficientes"
+// @Router /propensity/train [post]
+func (h *PropensityHandler) TrainModel(c *fiber.Ctx) error {
+	params := usecases.PropensityTrainParams{
+		Cycles:   defaultPropensityTrainingCycles,
+		Activate: true,
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&params); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Corpo da requisição inválido: " + err.Error(),
+			})
+		}
+	}
+
+	if params.ProfessionID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Parâmetro 'profession_id' é obrigatório",
+		})
+	}
+	if params.FunnelID < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Parâmetro 'funnel_id' inválido",
+		})
+	}
+	if params.Cycles < 2 || params.Cycles > maxForecastHistoryCycles {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Parâmetro 'cycles' deve estar entre 2 e " + strconv.Itoa(maxForecastHistoryCycles),
+		})
+	}
+
+	model, err := h.propensityUseCase.TrainModel(params)
+	if err != nil {
+		return propensityErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    model,
+	})
+}
+
+// GetModels lista os modelos treinados
+// @Summary Lista modelos de propensão
+// @Tags propensity
+// @Produce json
+// @Param profession_id query int false "ID da profissão"
+// @Success 200 {object} map[string]interface{} "Modelos"
+// @Router /propensity/models [get]
+func (h *PropensityHandler) GetModels(c *fiber.Ctx) error {
+	professionID, err := strconv.Atoi(c.Query("profession_id", "0"))
+	if err != nil || professionID < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Parâmetro 'profession_id' inválido",
+		})
+	}
+
+	models, err := h.propensityUseCase.ListModels(professionID)
+	if err != nil {
+		return propensityErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    models,
+	})
+}
+
+// GetModel retorna um modelo com seus coeficientes e métricas
+// @Summary Retorna um modelo de propensão
+// @Tags propensity
+// @Produce json
+// @Param id path int true "ID do modelo"
+// @Success 200 {object} map[string]interface{} "Modelo"
+// @Failure 404 {object} map[string]interface{} "Modelo não encontrado"
+// @Router /propensity/models/{id} [get]
+func (h *PropensityHandler) GetModel(c *fiber.Ctx) error {
+	modelID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || modelID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "ID de modelo inválido",
+		})
+	}
+
+	model, err := h.propensityUseCase.GetModel(modelID)
+	if err != nil {
+		return propensityErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    model,
+	})
+}
+
+// GetLeadScores retorna os leads do ciclo ranqueados pela probabilidade de compra
+// @Summary Ranking de leads por propensão de compra
+// @Tags propensity
+// @Produce json
+// @Param profession_id query int true "ID da profissão (opcional quando cycle_id é informado)"
+// @Param funnel_id query int false "ID do funil"
+// @Param cycle_id query int false "ID do ciclo de webinar"
+// @Param venda_inicio query string false "Dia de vendas do ciclo (ISO8601). Padrão: ciclo em andamento"
+// @Param page query int false "Página atual" default(1)
+// @Param limit query int false "Itens por página" default(50)
+// @Success 200 {object} map[string]interface{} "Leads ranqueados"
+// @Failure 404 {object} map[string]interface{} "Nenhum modelo ativo"
+// @Router /propensity/scores [get]
+func (h *PropensityHandler) GetLeadScores(c *fiber.Ctx) error {
+	cycleEntity, cycleDates, _, err := resolveCycleParam(c, h.cycleUseCase)
+	if err != nil {
+		return c.Status(cycleParamStatus(err)).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	professionID, err := strconv.Atoi(c.Query("profession_id", "0"))
+	if err == nil && professionID == 0 && cycleEntity != nil {
+		professionID = cycleEntity.ProfessionID
+	}
+	if err != nil || professionID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Parâmetro 'profession_id' é obrigatório",
+		})
+	}
+
+	funnelID, err := strconv.Atoi(c.Query("funnel_id", "0"))
+	if err == nil && funnelID == 0 && cycleEntity != nil && cycleEntity.FunnelID != nil {
+		funnelID = *cycleEntity.FunnelID
+	}
+	if err != nil || funnelID < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Parâmetro 'funnel_id' inválido",
+		})
+	}
+
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Parâmetro 'page' inválido",
+		})
+	}
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit < 1 || limit > 1000 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Parâmetro 'limit' deve estar entre 1 e 1000",
+		})
+	}
+
+	cycle := utils.CurrentWebinarCycleDates(time.Now().In(utils.GetBrasilLocation()))
+	if cycleEntity != nil {
+		cycle = cycleDates
+	} else if vendaInicioStr := c.Query("venda_inicio", ""); vendaInicioStr != "" {
+		vendaInicio, err := parseCycleDate(vendaInicioStr)
+		if err != nil || vendaInicio.Weekday() != time.Tuesday {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "'venda_inicio' deve ser uma terça-feira no formato YYYY-MM-DD ou ISO8601",
+			})
+		}
+		cycle = utils.CalculateWebinarCycleDates(vendaInicio)
+	}
+
+	result, err := h.propensityUseCase.ScoreLeads(professionID, funnelID, cycle, page, limit)
+	if err != nil {
+		return propensityErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// propensityErrorResponse traduz erros do modelo de propensão para respostas HTTP
+func propensityErrorResponse(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, usecases.ErrPropensityModelNotFound),
+		errors.Is(err, usecases.ErrNoActivePropensityModel):
+		status = fiber.StatusNotFound
+	case errors.Is(err, usecases.ErrInsufficientTrainingData):
+		status = fiber.StatusUnprocessableEntity
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
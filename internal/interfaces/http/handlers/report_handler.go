@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	mailer "github.com/PavaniTiago/beta-intelligence-api/internal/infrastructure/mail"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReportHandler lida com os relatórios de KPIs enviados por e-mail
+type ReportHandler struct {
+	reportUseCase usecases.ReportUseCase
+}
+
+// NewReportHandler cria uma nova instância de ReportHandler
+func NewReportHandler(reportUseCase usecases.ReportUseCase) *ReportHandler {
+	return &ReportHandler{
+		reportUseCase: reportUseCase,
+	}
+}
+
+// GetReports lista os relatórios agendados
+// @Summary Lista os relatórios agendados
+// @Tags reports
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Relatórios"
+// @Router /reports [get]
+func (h *ReportHandler) GetReports(c *fiber.Ctx) error {
+	reports, err := h.reportUseCase.ListReports()
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  reports,
+		"total": len(reports),
+	})
+}
+
+// GetReport retorna um relatório agendado
+// @Summary Retorna um relatório agendado
+// @Tags reports
+// @Produce json
+// @Param id path int true "ID do relatório"
+// @Success 200 {object} entities.ScheduledReport "Relatório"
+// @Failure 404 {object} map[string]interface{} "Relatório não encontrado"
+// @Router /reports/{id} [get]
+func (h *ReportHandler) GetReport(c *fiber.Ctx) error {
+	reportID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || reportID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "ID de relatório inválido"})
+	}
+
+	report, err := h.reportUseCase.GetReport(reportID)
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+	return c.JSON(report)
+}
+
+// CreateReport cria um relatório agendado
+// @Summary Cria um relatório agendado
+// @Description Envia por e-mail os KPIs de /dashboard/unified e /dashboard/revenue-by-profession conforme uma expressão cron (America/Sao_Paulo)
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param report body usecases.ReportInput true "Relatório"
+// @Success 201 {object} entities.ScheduledReport "Relatório criado"
+// @Failure 400 {object} map[string]interface{} "Relatório inválido"
+// @Router /reports [post]
+func (h *ReportHandler) CreateReport(c *fiber.Ctx) error {
+	var input usecases.ReportInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+	}
+
+	report, err := h.reportUseCase.CreateReport(input)
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(report)
+}
+
+// UpdateReport altera um relatório agendado
+// @Summary Altera um relatório agendado
+// @Description Apenas os campos informados são alterados
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do relatório"
+// @Param report body usecases.ReportInput true "Campos alterados"
+// @Success 200 {object} entities.ScheduledReport "Relatório alterado"
+// @Failure 400 {object} map[string]interface{} "Relatório inválido"
+// @Failure 404 {object} map[string]interface{} "Relatório não encontrado"
+// @Router /reports/{id} [put]
+func (h *ReportHandler) UpdateReport(c *fiber.Ctx) error {
+	reportID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || reportID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "ID de relatório inválido"})
+	}
+
+	var input usecases.ReportInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+	}
+
+	report, err := h.reportUseCase.UpdateReport(reportID, input)
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+	return c.JSON(report)
+}
+
+// DeleteReport remove um relatório agendado
+// @Summary Remove um relatório agendado
+// @Tags reports
+// @Param id path int true "ID do relatório"
+// @Success 204 "Relatório removido"
+// @Failure 404 {object} map[string]interface{} "Relatório não encontrado"
+// @Router /reports/{id} [delete]
+func (h *ReportHandler) DeleteReport(c *fiber.Ctx) error {
+	reportID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || reportID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "ID de relatório inválido"})
+	}
+
+	if err := h.reportUseCase.DeleteReport(reportID); err != nil {
+		return reportErrorResponse(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// SendReport envia o relatório imediatamente, com o período calculado no momento do pedido
+// @Summary Envia um relatório agora
+// @Tags reports
+// @Produce json
+// @Param id path int true "ID do relatório"
+// @Success 200 {object} usecases.ReportDelivery "Relatório enviado"
+// @Failure 404 {object} map[string]interface{} "Relatório não encontrado"
+// @Failure 503 {object} map[string]interface{} "Envio de e-mail não configurado"
+// @Router /reports/{id}/send [post]
+func (h *ReportHandler) SendReport(c *fiber.Ctx) error {
+	reportID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || reportID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "ID de relatório inválido"})
+	}
+
+	delivery, err := h.reportUseCase.SendReport(reportID)
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+	return c.JSON(delivery)
+}
+
+// PreviewReport retorna o HTML do relatório com o período calculado no momento do pedido, sem enviar
+// @Summary Pré-visualiza um relatório
+// @Tags reports
+// @Produce html
+// @Param id path int true "ID do relatório"
+// @Success 200 {string} string "HTML do relatório"
+// @Failure 404 {object} map[string]interface{} "Relatório não encontrado"
+// @Router /reports/{id}/preview [get]
+func (h *ReportHandler) PreviewReport(c *fiber.Ctx) error {
+	reportID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || reportID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "ID de relatório inválido"})
+	}
+
+	html, err := h.reportUseCase.PreviewReport(reportID)
+	if err != nil {
+		return reportErrorResponse(c, err)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.SendString(html)
+}
+
+// reportErrorResponse converte os erros dos relatórios em respostas HTTP. Os demais erros de envio
+// vêm do servidor SMTP ou dos endpoints de KPIs.
+func reportErrorResponse(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, usecases.ErrInvalidReport):
+		status = fiber.StatusBadRequest
+	case errors.Is(err, usecases.ErrReportNotFound), errors.Is(err, usecases.ErrWebinarCycleNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, mailer.ErrNotConfigured):
+		status = fiber.StatusServiceUnavailable
+	}
+	return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+}
@@ -1,31 +1,47 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
 	"github.com/gofiber/fiber/v2"
 )
 
 type RevenueHandler struct {
 	revenueUseCase usecases.RevenueUseCase
+	cycleUseCase   usecases.WebinarCycleUseCase
 }
 
-func NewRevenueHandler(revenueUseCase usecases.RevenueUseCase) *RevenueHandler {
+func NewRevenueHandler(revenueUseCase usecases.RevenueUseCase, cycleUseCase usecases.WebinarCycleUseCase) *RevenueHandler {
 	return &RevenueHandler{
 		revenueUseCase: revenueUseCase,
+		cycleUseCase:   cycleUseCase,
 	}
 }
 
 // GetUnifiedDataGeneral retorna dados gerais unificados de leads e faturamento com comparação
 func (h *RevenueHandler) GetUnifiedDataGeneral(c *fiber.Ctx) error {
+	timeFrame, ok := entities.NormalizeTimeFrame(c.Query("time_frame", "daily"))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Parâmetro 'time_frame' inválido (use daily, weekly, monthly ou yearly)",
+		})
+	}
+
 	// Parse dos parâmetros de data
-	currentFrom, currentTo, previousFrom, previousTo, err := h.parseDateParamsWithComparison(c)
+	cycle, currentFrom, currentTo, previousFrom, previousTo, err := h.parseDateParamsWithComparison(c, timeFrame)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		status := fiber.StatusBadRequest
+		if errors.Is(err, usecases.ErrWebinarCycleNotFound) {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
 			"success": false,
 			"error":   err.Error(),
 		})
@@ -35,7 +51,7 @@ func (h *RevenueHandler) GetUnifiedDataGeneral(c *fiber.Ctx) error {
 	isSingleDay := h.isSingleDay(currentFrom, currentTo)
 
 	// Buscar dados de comparação
-	data, err := h.revenueUseCase.GetRevenueComparisonGeneral(currentFrom, currentTo, previousFrom, previousTo)
+	data, err := h.revenueUseCase.GetRevenueComparisonGeneral(currentFrom, currentTo, previousFrom, previousTo, timeFrame)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -68,6 +84,11 @@ func (h *RevenueHandler) GetUnifiedDataGeneral(c *fiber.Ctx) error {
 			"to":   previousTo.Format("2006-01-02"),
 		},
 		"is_single_day": isSingleDay,
+		"time_frame":    timeFrame,
+		"compare":       strings.ToLower(c.Query("compare", usecases.CompareModePrevious)),
+	}
+	if cycle != nil {
+		appliedFilters["cycle_id"] = cycle.CycleID
 	}
 
 	return c.JSON(fiber.Map{
@@ -79,10 +100,22 @@ func (h *RevenueHandler) GetUnifiedDataGeneral(c *fiber.Ctx) error {
 
 // GetUnifiedDataByProfession retorna dados unificados de leads e faturamento por profissão com comparação
 func (h *RevenueHandler) GetUnifiedDataByProfession(c *fiber.Ctx) error {
+	timeFrame, ok := entities.NormalizeTimeFrame(c.Query("time_frame", "daily"))
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Parâmetro 'time_frame' inválido (use daily, weekly, monthly ou yearly)",
+		})
+	}
+
 	// Parse dos parâmetros de data
-	currentFrom, currentTo, previousFrom, previousTo, err := h.parseDateParamsWithComparison(c)
+	cycle, currentFrom, currentTo, previousFrom, previousTo, err := h.parseDateParamsWithComparison(c, timeFrame)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		status := fiber.StatusBadRequest
+		if errors.Is(err, usecases.ErrWebinarCycleNotFound) {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(fiber.Map{
 			"success": false,
 			"error":   err.Error(),
 		})
@@ -97,6 +130,11 @@ func (h *RevenueHandler) GetUnifiedDataByProfession(c *fiber.Ctx) error {
 		})
 	}
 
+	// Sem profissões informadas, o ciclo define a profissão
+	if cycle != nil && len(professionIDs) == 0 {
+		professionIDs = []int{cycle.ProfessionID}
+	}
+
 	// Debug: log dos filtros recebidos
 	fmt.Printf("GetUnifiedDataByProfession - ProfessionIDs recebidos: %v\n", professionIDs)
 	fmt.Printf("GetUnifiedDataByProfession - Query profession_ids: %s\n", c.Query("profession_ids", ""))
@@ -105,7 +143,7 @@ func (h *RevenueHandler) GetUnifiedDataByProfession(c *fiber.Ctx) error {
 	isSingleDay := h.isSingleDay(currentFrom, currentTo)
 
 	// Buscar dados de comparação por profissão
-	data, err := h.revenueUseCase.GetRevenueComparisonByProfession(currentFrom, currentTo, previousFrom, previousTo, professionIDs)
+	data, err := h.revenueUseCase.GetRevenueComparisonByProfession(currentFrom, currentTo, previousFrom, previousTo, professionIDs, timeFrame)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
@@ -145,10 +183,15 @@ func (h *RevenueHandler) GetUnifiedDataByProfession(c *fiber.Ctx) error {
 			"to":   previousTo.Format("2006-01-02"),
 		},
 		"is_single_day": isSingleDay,
+		"time_frame":    timeFrame,
+		"compare":       strings.ToLower(c.Query("compare", usecases.CompareModePrevious)),
 	}
 	if len(professionIDs) > 0 {
 		appliedFilters["profession_ids"] = professionIDs
 	}
+	if cycle != nil {
+		appliedFilters["cycle_id"] = cycle.CycleID
+	}
 
 	return c.JSON(fiber.Map{
 		"success":         true,
@@ -157,10 +200,25 @@ func (h *RevenueHandler) GetUnifiedDataByProfession(c *fiber.Ctx) error {
 	})
 }
 
-// parseDateParamsWithComparison extrai e calcula períodos atual e anterior
-func (h *RevenueHandler) parseDateParamsWithComparison(c *fiber.Ctx) (time.Time, time.Time, time.Time, time.Time, error) {
+// parseDateParamsWithComparison extrai e calcula períodos atual e anterior; o anterior tem a mesma quantidade
+// de buckets do timeFrame. Quando cycle_id é informado, usa o ciclo (captação até fim das vendas) e o ciclo anterior.
+// O parâmetro compare substitui o período anterior (ver resolveComparisonPeriod).
+func (h *RevenueHandler) parseDateParamsWithComparison(c *fiber.Ctx, timeFrame string) (*entities.WebinarCycle, time.Time, time.Time, time.Time, time.Time, error) {
 	var currentFrom, currentTo, previousFrom, previousTo time.Time
-	var err error
+
+	cycle, cycleDates, previousCycleDates, err := resolveCycleParam(c, h.cycleUseCase)
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, time.Time{}, time.Time{}, err
+	}
+	if cycle != nil {
+		currentFrom, currentTo = cycleDates.PesquisaInicio, cycleDates.VendaFim
+		previousFrom, previousTo, _, err = resolveComparisonPeriod(c, currentFrom, currentTo,
+			previousCycleDates.PesquisaInicio, previousCycleDates.VendaFim)
+		if err != nil {
+			return nil, time.Time{}, time.Time{}, time.Time{}, time.Time{}, err
+		}
+		return cycle, currentFrom, currentTo, previousFrom, previousTo, nil
+	}
 
 	fromStr := c.Query("from", "")
 	toStr := c.Query("to", "")
@@ -182,7 +240,7 @@ func (h *RevenueHandler) parseDateParamsWithComparison(c *fiber.Ctx) (time.Time,
 		if err != nil {
 			currentFrom, err = time.Parse("2006-01-02T15:04:05Z07:00", fromStr)
 			if err != nil {
-				return time.Time{}, time.Time{}, time.Time{}, time.Time{}, err
+				return nil, time.Time{}, time.Time{}, time.Time{}, time.Time{}, err
 			}
 		}
 
@@ -190,7 +248,7 @@ func (h *RevenueHandler) parseDateParamsWithComparison(c *fiber.Ctx) (time.Time,
 		if err != nil {
 			currentTo, err = time.Parse("2006-01-02T15:04:05Z07:00", toStr)
 			if err != nil {
-				return time.Time{}, time.Time{}, time.Time{}, time.Time{}, err
+				return nil, time.Time{}, time.Time{}, time.Time{}, time.Time{}, err
 			}
 		}
 
@@ -199,16 +257,17 @@ func (h *RevenueHandler) parseDateParamsWithComparison(c *fiber.Ctx) (time.Time,
 			currentTo = time.Date(currentTo.Year(), currentTo.Month(), currentTo.Day(), 23, 59, 59, 999999999, currentTo.Location())
 		}
 
-		// Calcular período anterior (mesmo número de dias, imediatamente anterior)
-		daysDiff := int(currentTo.Sub(currentFrom).Hours() / 24)
-		previousTo = currentFrom.AddDate(0, 0, -1)
-		previousFrom = previousTo.AddDate(0, 0, -daysDiff)
+		// Calcular período anterior: mesma quantidade de dias, semanas, meses ou anos, imediatamente antes
+		previousFrom, previousTo = usecases.PreviousPeriod(currentFrom, currentTo, timeFrame)
+	}
 
-		// Ajustar previousTo para incluir o dia completo
-		previousTo = time.Date(previousTo.Year(), previousTo.Month(), previousTo.Day(), 23, 59, 59, 999999999, previousTo.Location())
+	// Período de comparação (compare): anterior, ano anterior, mesmo dia da semana ou personalizado
+	previousFrom, previousTo, _, err = resolveComparisonPeriod(c, currentFrom, currentTo, previousFrom, previousTo)
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, time.Time{}, time.Time{}, err
 	}
 
-	return currentFrom, currentTo, previousFrom, previousTo, nil
+	return nil, currentFrom, currentTo, previousFrom, previousTo, nil
 }
 
 // isSingleDay verifica se o período é de um único dia
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/gofiber/fiber/v2"
+)
+
+// errInvalidSegmentID indica um segment_id mal formatado na query
+var errInvalidSegmentID = errors.New("parâmetro 'segment_id' inválido")
+
+// SegmentHandler lida com requisições de segmentos salvos de usuários
+type SegmentHandler struct {
+	segmentUseCase usecases.SegmentUseCase
+}
+
+// NewSegmentHandler cria uma nova instância de SegmentHandler
+func NewSegmentHandler(segmentUseCase usecases.SegmentUseCase) *SegmentHandler {
+	return &SegmentHandler{
+		segmentUseCase: segmentUseCase,
+	}
+}
+
+// GetSegments lista os segmentos salvos
+// @Summary Lista segmentos de usuários
+// @Tags segments
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Segmentos"
+// @Router /segments [get]
+func (h *SegmentHandler) GetSegments(c *fiber.Ctx) error {
+	segments, err := h.segmentUseCase.ListSegments()
+	if err != nil {
+		return segmentErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  segments,
+		"total": len(segments),
+	})
+}
+
+// GetSegment retorna um segmento
+// @Summary Retorna um segmento de usuários
+// @Tags segments
+// @Produce json
+// @Param id path int true "ID do segmento"
+// @Success 200 {object} entities.Segment "Segmento"
+// @Failure 404 {object} map[string]interface{} "Segmento não encontrado"
+// @Router /segments/{id} [get]
+func (h *SegmentHandler) GetSegment(c *fiber.Ctx) error {
+	segmentID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || segmentID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de segmento inválido"})
+	}
+
+	segment, err := h.segmentUseCase.GetSegment(segmentID)
+	if err != nil {
+		return segmentErrorResponse(c, err)
+	}
+
+	return c.JSON(segment)
+}
+
+// CreateSegment cria um segmento
+// @Summary Cria um segmento de usuários
+// @Description A definição combina condições sobre o usuário, suas sessões, seus eventos e suas respostas de pesquisa
+// @Tags segments
+// @Accept json
+// @Produce json
+// @Success 201 {object} entities.Segment "Segmento criado"
+// @Failure 400 {object} map[string]interface{} "Segmento inválido"
+// @Router /segments [post]
+func (h *SegmentHandler) CreateSegment(c *fiber.Ctx) error {
+	var input usecases.SegmentInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+	}
+
+	segment, err := h.segmentUseCase.CreateSegment(input)
+	if err != nil {
+		return segmentErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(segment)
+}
+
+// UpdateSegment altera um segmento
+// @Summary Altera um segmento de usuários
+// @Tags segments
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do segmento"
+// @Success 200 {object} entities.Segment "Segmento alterado"
+// @Failure 400 {object} map[string]interface{} "Segmento inválido"
+// @Failure 404 {object} map[string]interface{} "Segmento não encontrado"
+// @Router /segments/{id} [put]
+func (h *SegmentHandler) UpdateSegment(c *fiber.Ctx) error {
+	segmentID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || segmentID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de segmento inválido"})
+	}
+
+	var input usecases.SegmentInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+	}
+
+	segment, err := h.segmentUseCase.UpdateSegment(segmentID, input)
+	if err != nil {
+		return segmentErrorResponse(c, err)
+	}
+
+	return c.JSON(segment)
+}
+
+// DeleteSegment remove um segmento
+// @Summary Remove um segmento de usuários
+// @Tags segments
+// @Param id path int true "ID do segmento"
+// @Success 204
+// @Failure 404 {object} map[string]interface{} "Segmento não encontrado"
+// @Router /segments/{id} [delete]
+func (h *SegmentHandler) DeleteSegment(c *fiber.Ctx) error {
+	segmentID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || segmentID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de segmento inválido"})
+	}
+
+	if err := h.segmentUseCase.DeleteSegment(segmentID); err != nil {
+		return segmentErrorResponse(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetSegmentSize conta os membros do segmento no momento da consulta
+// @Summary Tamanho do segmento
+// @Tags segments
+// @Produce json
+// @Param id path int true "ID do segmento"
+// @Success 200 {object} usecases.SegmentSize "Tamanho"
+// @Failure 404 {object} map[string]interface{} "Segmento não encontrado"
+// @Router /segments/{id}/size [get]
+func (h *SegmentHandler) GetSegmentSize(c *fiber.Ctx) error {
+	segmentID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || segmentID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de segmento inválido"})
+	}
+
+	size, err := h.segmentUseCase.GetSize(segmentID)
+	if err != nil {
+		return segmentErrorResponse(c, err)
+	}
+
+	return c.JSON(size)
+}
+
+// GetSegmentMembers lista os membros do segmento
+// @Summary Membros do segmento
+// @Tags segments
+// @Produce json
+// @Param id path int true "ID do segmento"
+// @Param page query int false "Página" default(1)
+// @Param limit query int false "Itens por página (máximo 1000)" default(50)
+// @Success 200 {object} map[string]interface{} "Usuários do segmento"
+// @Failure 404 {object} map[string]interface{} "Segmento não encontrado"
+// @Router /segments/{id}/members [get]
+func (h *SegmentHandler) GetSegmentMembers(c *fiber.Ctx) error {
+	segmentID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || segmentID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de segmento inválido"})
+	}
+
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid 'page' parameter"})
+	}
+	limit, err := strconv.Atoi(c.Query("limit", "50"))
+	if err != nil || limit < 1 || limit > 1000 {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid 'limit' parameter"})
+	}
+
+	members, err := h.segmentUseCase.GetMembers(segmentID, page, limit)
+	if err != nil {
+		return segmentErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  members,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// PreviewSegment conta os membros de uma definição sem salvá-la
+// @Summary Pré-visualiza o tamanho de uma definição de segmento
+// @Tags segments
+// @Accept json
+// @Produce json
+// @Success 200 {object} usecases.SegmentSize "Tamanho"
+// @Failure 400 {object} map[string]interface{} "Definição inválida"
+// @Router /segments/preview [post]
+func (h *SegmentHandler) PreviewSegment(c *fiber.Ctx) error {
+	var definition entities.SegmentDefinition
+	if err := c.BodyParser(&definition); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+	}
+
+	size, err := h.segmentUseCase.PreviewSize(definition)
+	if err != nil {
+		return segmentErrorResponse(c, err)
+	}
+
+	return c.JSON(size)
+}
+
+// segmentErrorResponse traduz erros de segmentos para respostas HTTP
+func segmentErrorResponse(c *fiber.Ctx, err error) error {
+	return c.Status(segmentParamStatus(err)).JSON(fiber.Map{"error": err.Error()})
+}
+
+// resolveSegmentParam lê o segment_id da query e retorna o filtro de membros do segmento (vazio sem segment_id)
+func resolveSegmentParam(c *fiber.Ctx, segmentUseCase usecases.SegmentUseCase) (repositories.SegmentFilter, error) {
+	segmentIDStr := c.Query("segment_id", "")
+	if segmentIDStr == "" || segmentUseCase == nil {
+		return repositories.SegmentFilter{}, nil
+	}
+
+	segmentID, err := strconv.ParseInt(segmentIDStr, 10, 64)
+	if err != nil || segmentID <= 0 {
+		return repositories.SegmentFilter{}, errInvalidSegmentID
+	}
+
+	return segmentUseCase.ResolveFilter(segmentID)
+}
+
+// segmentParamStatus retorna o status HTTP adequado para um erro de segmento
+func segmentParamStatus(err error) int {
+	switch {
+	case errors.Is(err, errInvalidSegmentID), errors.Is(err, usecases.ErrInvalidSegment):
+		return fiber.StatusBadRequest
+	case errors.Is(err, usecases.ErrSegmentNotFound):
+		return fiber.StatusNotFound
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
 	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -16,11 +17,13 @@ import (
 
 type SessionHandler struct {
 	sessionUseCase *usecases.SessionUseCase
+	segmentUseCase usecases.SegmentUseCase
 }
 
-func NewSessionHandler(sessionUseCase *usecases.SessionUseCase) *SessionHandler {
+func NewSessionHandler(sessionUseCase *usecases.SessionUseCase, segmentUseCase usecases.SegmentUseCase) *SessionHandler {
 	return &SessionHandler{
 		sessionUseCase: sessionUseCase,
+		segmentUseCase: segmentUseCase,
 	}
 }
 
@@ -38,6 +41,12 @@ func (h *SessionHandler) GetSessions(c *fiber.Ctx) error {
 	funnelID := c.Query("funnel_id", "")
 	professionID := c.Query("profession_id", "")
 
+	// Restrição às sessões dos membros de um segmento salvo
+	segment, err := resolveSegmentParam(c, h.segmentUseCase)
+	if err != nil {
+		return c.Status(segmentParamStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// Obter localização de Brasília
 	brazilLocation := GetBrasilLocation()
 
@@ -145,7 +154,7 @@ func (h *SessionHandler) GetSessions(c *fiber.Ctx) error {
 		}
 
 		// Executar a consulta com os períodos determinados
-		results, err = h.sessionUseCase.CountSessionsByPeriods(periods, landingPage, funnelID, professionID)
+		results, err = h.sessionUseCase.CountSessionsByPeriods(periods, landingPage, funnelID, professionID, segment)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{
 				"error": "Failed to count sessions by periods",
@@ -202,7 +211,7 @@ func (h *SessionHandler) GetSessions(c *fiber.Ctx) error {
 			// Gerar array de todas as datas no intervalo
 			dateRange := GenerateDateRange(firstDateOnly, lastDateOnly)
 
-			result, err := h.sessionUseCase.CountSessionsByPeriods(dateRange, landingPage, funnelID, professionID)
+			result, err := h.sessionUseCase.CountSessionsByPeriods(dateRange, landingPage, funnelID, professionID, segment)
 			if err != nil {
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 					"error": fmt.Sprintf("Error counting sessions by periods: %v", err),
@@ -222,7 +231,7 @@ func (h *SessionHandler) GetSessions(c *fiber.Ctx) error {
 			// Gerar array de datas no intervalo from-to
 			dateRange := GenerateDateRange(fromTime, toTime)
 
-			result, err := h.sessionUseCase.CountSessionsByPeriods(dateRange, landingPage, funnelID, professionID)
+			result, err := h.sessionUseCase.CountSessionsByPeriods(dateRange, landingPage, funnelID, professionID, segment)
 			if err != nil {
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 					"error": fmt.Sprintf("Error counting sessions by periods: %v", err),
@@ -239,7 +248,7 @@ func (h *SessionHandler) GetSessions(c *fiber.Ctx) error {
 			})
 		} else if periodsParam != "" {
 			periods := strings.Split(periodsParam, ",")
-			result, err := h.sessionUseCase.CountSessionsByPeriods(periods, landingPage, funnelID, professionID)
+			result, err := h.sessionUseCase.CountSessionsByPeriods(periods, landingPage, funnelID, professionID, segment)
 			if err != nil {
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 					"error": fmt.Sprintf("Error counting sessions by periods: %v", err),
@@ -255,7 +264,7 @@ func (h *SessionHandler) GetSessions(c *fiber.Ctx) error {
 		}
 
 		// Contagem normal
-		count, err := h.sessionUseCase.CountSessions(fromTime, toTime, timeFrom, timeTo, userID, professionID, productID, funnelID, isActive, landingPage)
+		count, err := h.sessionUseCase.CountSessions(fromTime, toTime, timeFrom, timeTo, userID, professionID, productID, funnelID, isActive, landingPage, segment)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": fmt.Sprintf("Error counting sessions: %v", err),
@@ -271,11 +280,31 @@ func (h *SessionHandler) GetSessions(c *fiber.Ctx) error {
 		})
 	}
 
+	// Exportação em arquivo (format=csv|xlsx|ndjson) com os mesmos filtros da listagem, por sessionStart
+	export, err := parseExportRequest(c, "sessions", repositories.SessionExportColumns)
+	if err != nil {
+		return c.Status(exportStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	if export != nil {
+		exportOrder := `"sessionStart" DESC`
+		if strings.EqualFold(sortDirection, "asc") {
+			exportOrder = `"sessionStart" ASC`
+		}
+		return streamExport(c, export, func(ctx context.Context, fn repositories.ExportRowFunc) error {
+			return h.sessionUseCase.ExportSessions(ctx, export.columns, exportOrder, fromTime, toTime, timeFrom, timeTo, userID, professionID, productID, funnelID, isActive, landingPage, segment, fn)
+		})
+	}
+
+	// Paginação por offset (page) ou por cursor em (sessionStart, session_id)
+	pageRequest, err := parsePageRequest(c, page, limit, sortBy, sortDirection, "sessionStart")
+	if err != nil {
+		return c.Status(paginationStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// Buscar sessões com filtros
-	sessions, total, err := h.sessionUseCase.GetSessions(
+	sessions, pageResult, err := h.sessionUseCase.GetSessions(
 		c.Context(),
-		page,
-		limit,
+		pageRequest,
 		orderBy,
 		fromTime,
 		toTime,
@@ -287,6 +316,7 @@ func (h *SessionHandler) GetSessions(c *fiber.Ctx) error {
 		funnelID,
 		isActive,
 		landingPage,
+		segment,
 	)
 
 	if err != nil {
@@ -300,22 +330,22 @@ func (h *SessionHandler) GetSessions(c *fiber.Ctx) error {
 		sessions = []entities.Session{}
 	}
 
-	totalPages := (total + int64(limit) - 1) / int64(limit)
-
-	return c.JSON(fiber.Map{
+	response := fiber.Map{
 		"sessions":      sessions,
-		"page":          page,
-		"limit":         limit,
-		"total":         total,
-		"totalPages":    totalPages,
 		"sortBy":        sortBy,
 		"sortDirection": sortDirection,
 		"from":          fromTime.Format(time.RFC3339),
 		"to":            toTime.Format(time.RFC3339),
 		"time_from":     timeFrom,
 		"time_to":       timeTo,
+		"segment_id":    c.Query("segment_id", ""),
 		"limitApplied":  hasDateFilter, // Indica se o limite foi aplicado (apenas com filtro de data)
-	})
+	}
+	for key, value := range paginationFields(pageRequest, pageResult) {
+		response[key] = value
+	}
+
+	return c.JSON(response)
 }
 
 // GetActiveSessions retorna todas as sessões ativas
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SurveyDefinitionHandler lida com requisições de definições versionadas de pesquisa
+type SurveyDefinitionHandler struct {
+	definitionUseCase usecases.SurveyDefinitionUseCase
+}
+
+// NewSurveyDefinitionHandler cria uma nova instância de SurveyDefinitionHandler
+func NewSurveyDefinitionHandler(definitionUseCase usecases.SurveyDefinitionUseCase) *SurveyDefinitionHandler {
+	return &SurveyDefinitionHandler{
+		definitionUseCase: definitionUseCase,
+	}
+}
+
+// GetDefinitions lista as versões da definição de uma pesquisa
+// @Summary Lista versões da definição da pesquisa
+// @Tags surveys
+// @Produce json
+// @Param id path int true "ID da pesquisa"
+// @Success 200 {object} map[string]interface{} "Versões com perguntas, opções, faixas e quantidade de respostas"
+// @Failure 404 {object} map[string]interface{} "Pesquisa não encontrada"
+// @Router /surveys/{id}/definitions [get]
+func (h *SurveyDefinitionHandler) GetDefinitions(c *fiber.Ctx) error {
+	surveyID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || surveyID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de pesquisa inválido"})
+	}
+
+	definitions, err := h.definitionUseCase.ListDefinitions(surveyID)
+	if err != nil {
+		return surveyDefinitionErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  definitions,
+		"total": len(definitions),
+	})
+}
+
+// GetActiveDefinition retorna a versão ativa da definição, usada para validar novas respostas
+// @Summary Retorna a versão ativa da definição da pesquisa
+// @Tags surveys
+// @Produce json
+// @Param id path int true "ID da pesquisa"
+// @Success 200 {object} entities.SurveyDefinition "Versão ativa"
+// @Failure 404 {object} map[string]interface{} "Pesquisa não encontrada"
+// @Failure 409 {object} map[string]interface{} "Nenhuma versão ativa"
+// @Router /surveys/{id}/definitions/active [get]
+func (h *SurveyDefinitionHandler) GetActiveDefinition(c *fiber.Ctx) error {
+	surveyID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || surveyID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de pesquisa inválido"})
+	}
+
+	definition, err := h.definitionUseCase.GetActiveDefinition(surveyID)
+	if err != nil {
+		return surveyDefinitionErrorResponse(c, err)
+	}
+
+	return c.JSON(definition)
+}
+
+// GetDefinition retorna uma versão da definição da pesquisa
+// @Summary Retorna uma versão da definição da pesquisa
+// @Tags surveys
+// @Produce json
+// @Param id path int true "ID da pesquisa"
+// @Param version path int true "Versão da definição"
+// @Success 200 {object} usecases.SurveyDefinitionWithUsage "Versão"
+// @Failure 404 {object} map[string]interface{} "Versão não encontrada"
+// @Router /surveys/{id}/definitions/{version} [get]
+func (h *SurveyDefinitionHandler) GetDefinition(c *fiber.Ctx) error {
+	surveyID, version, err := parseSurveyDefinitionParams(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	definition, err := h.definitionUseCase.GetDefinition(surveyID, version)
+	if err != nil {
+		return surveyDefinitionErrorResponse(c, err)
+	}
+
+	return c.JSON(definition)
+}
+
+// CreateDefinition cria uma nova versão da definição da pesquisa.
+// As versões anteriores são mantidas e continuam associadas às respostas coletadas nelas.
+// @Summary Cria uma versão da definição da pesquisa
+// @Tags surveys
+// @Accept json
+// @Produce json
+// @Param id path int true "ID da pesquisa"
+// @Success 201 {object} entities.SurveyDefinition "Versão criada"
+// @Failure 400 {object} map[string]interface{} "Definição inválida"
+// @Failure 404 {object} map[string]interface{} "Pesquisa não encontrada"
+// @Router /surveys/{id}/definitions [post]
+func (h *SurveyDefinitionHandler) CreateDefinition(c *fiber.Ctx) error {
+	surveyID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || surveyID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de pesquisa inválido"})
+	}
+
+	var input usecases.SurveyDefinitionInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+	}
+
+	definition, err := h.definitionUseCase.CreateDefinition(surveyID, input)
+	if err != nil {
+		return surveyDefinitionErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(definition)
+}
+
+// ActivateDefinition define a versão ativa da pesquisa
+// @Summary Ativa uma versão da definição da pesquisa
+// @Tags surveys
+// @Produce json
+// @Param id path int true "ID da pesquisa"
+// @Param version path int true "Versão da definição"
+// @Success 200 {object} entities.SurveyDefinition "Versão ativada"
+// @Failure 404 {object} map[string]interface{} "Versão não encontrada"
+// @Router /surveys/{id}/definitions/{version}/activate [post]
+func (h *SurveyDefinitionHandler) ActivateDefinition(c *fiber.Ctx) error {
+	surveyID, version, err := parseSurveyDefinitionParams(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	definition, err := h.definitionUseCase.ActivateDefinition(surveyID, version)
+	if err != nil {
+		return surveyDefinitionErrorResponse(c, err)
+	}
+
+	return c.JSON(definition)
+}
+
+// DeleteDefinition remove uma versão que nunca foi usada
+// @Summary Remove uma versão da definição da pesquisa
+// @Description Apenas versões inativas e sem respostas coletadas podem ser removidas
+// @Tags surveys
+// @Param id path int true "ID da pesquisa"
+// @Param version path int true "Versão da definição"
+// @Success 204
+// @Failure 404 {object} map[string]interface{} "Versão não encontrada"
+// @Failure 409 {object} map[string]interface{} "Versão ativa ou com respostas"
+// @Router /surveys/{id}/definitions/{version} [delete]
+func (h *SurveyDefinitionHandler) DeleteDefinition(c *fiber.Ctx) error {
+	surveyID, version, err := parseSurveyDefinitionParams(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := h.definitionUseCase.DeleteDefinition(surveyID, version); err != nil {
+		return surveyDefinitionErrorResponse(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ValidateAnswers valida e pontua respostas contra a versão ativa da pesquisa
+// @Summary Valida respostas contra a versão ativa
+// @Description Retorna as respostas pontuadas, os erros encontrados, a pontuação total e a faixa. Com "completed", as perguntas obrigatórias não respondidas também são rejeitadas.
+// @Tags surveys
+// @Accept json
+// @Produce json
+// @Param id path int true "ID da pesquisa"
+// @Success 200 {object} usecases.SurveyAnswerValidation "Resultado da validação"
+// @Failure 404 {object} map[string]interface{} "Pesquisa não encontrada"
+// @Failure 409 {object} map[string]interface{} "Nenhuma versão ativa"
+// @Router /surveys/{id}/definitions/validate [post]
+func (h *SurveyDefinitionHandler) ValidateAnswers(c *fiber.Ctx) error {
+	surveyID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || surveyID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de pesquisa inválido"})
+	}
+
+	var input struct {
+		Answers   []usecases.SurveyAnswerInput `json:"answers"`
+		Completed bool                         `json:"completed"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+	}
+
+	result, err := h.definitionUseCase.ValidateAnswers(surveyID, input.Answers, input.Completed)
+	if err != nil {
+		return surveyDefinitionErrorResponse(c, err)
+	}
+
+	return c.JSON(result)
+}
+
+// parseSurveyDefinitionParams lê o ID da pesquisa e a versão da definição da rota
+func parseSurveyDefinitionParams(c *fiber.Ctx) (int64, int, error) {
+	surveyID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || surveyID <= 0 {
+		return 0, 0, errors.New("ID de pesquisa inválido")
+	}
+	version, err := strconv.Atoi(c.Params("version"))
+	if err != nil || version <= 0 {
+		return 0, 0, errors.New("Versão inválida")
+	}
+	return surveyID, version, nil
+}
+
+// surveyDefinitionErrorResponse traduz erros de definições de pesquisa para respostas HTTP
+func surveyDefinitionErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, usecases.ErrInvalidSurveyDefinition):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, usecases.ErrSurveyNotFound),
+		errors.Is(err, usecases.ErrSurveyDefinitionNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, usecases.ErrNoActiveSurveyDefinition),
+		errors.Is(err, usecases.ErrSurveyDefinitionInUse):
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+}
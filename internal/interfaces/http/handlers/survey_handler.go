@@ -1,23 +1,32 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
 	"github.com/gofiber/fiber/v2"
 )
 
 // SurveyHandler lida com requisições relacionadas a pesquisas
 type SurveyHandler struct {
-	surveyUseCase *usecases.SurveyUseCase
+	surveyUseCase  *usecases.SurveyUseCase
+	cycleUseCase   usecases.WebinarCycleUseCase
+	variantUseCase usecases.SurveyVariantUseCase
 }
 
 // NewSurveyHandler cria uma nova instância de SurveyHandler
-func NewSurveyHandler(surveyUseCase *usecases.SurveyUseCase) *SurveyHandler {
+func NewSurveyHandler(surveyUseCase *usecases.SurveyUseCase, cycleUseCase usecases.WebinarCycleUseCase, variantUseCase usecases.SurveyVariantUseCase) *SurveyHandler {
 	return &SurveyHandler{
-		surveyUseCase: surveyUseCase,
+		surveyUseCase:  surveyUseCase,
+		cycleUseCase:   cycleUseCase,
+		variantUseCase: variantUseCase,
 	}
 }
 
@@ -39,26 +48,64 @@ func (h *SurveyHandler) calculateDateParams(vendasInicioStr string) (map[string]
 	}
 
 	// Normalizar para garantir que estamos usando o horário 20:30:00, INDEPENDENTE do que foi enviado
-	dataBase := time.Date(vendasInicio.Year(), vendasInicio.Month(), vendasInicio.Day(), 0, 0, 0, 0, vendasInicio.Location())
+	// (vendas 20:30-23:59:59, pesquisa da terça anterior 20:00 até 20:00 do dia)
+	for key, value := range utils.CalculateWebinarCycleDates(vendasInicio).AsParams() {
+		dateParams[key] = value
+	}
 
-	// 1. vendas_inicio = dataBase às 20:30
-	vendasInicioNorm := time.Date(dataBase.Year(), dataBase.Month(), dataBase.Day(), 20, 30, 0, 0, dataBase.Location())
-	dateParams["venda_inicio"] = vendasInicioNorm
+	return dateParams, nil
+}
+
+// applySurveyPeriod preenche os limites de pesquisa/vendas em params a partir de cycle_id, venda_inicio
+// ou da terça-feira atual/anterior. Em caso de erro retorna o status HTTP a ser respondido.
+func (h *SurveyHandler) applySurveyPeriod(c *fiber.Ctx, params map[string]interface{}) (int, error) {
+	vendaInicioStr := c.Query("venda_inicio", "")
 
-	// 2. vendas_fim = dataBase às 23:59:59
-	vendasFim := time.Date(dataBase.Year(), dataBase.Month(), dataBase.Day(), 23, 59, 59, 999999999, dataBase.Location())
-	dateParams["venda_fim"] = vendasFim
+	// Ciclo de webinar informado: usar os limites cadastrados (inclusive sobrescritos)
+	cycle, cycleDates, _, err := resolveCycleParam(c, h.cycleUseCase)
+	if err != nil {
+		return cycleParamStatus(err), err
+	}
 
-	// 3. pesquisa_fim = dataBase às 20:00
-	pesquisaFim := time.Date(dataBase.Year(), dataBase.Month(), dataBase.Day(), 20, 0, 0, 0, dataBase.Location())
-	dateParams["pesquisa_fim"] = pesquisaFim
+	if cycle != nil {
+		for key, value := range cycleDates.AsParams() {
+			params[key] = value
+		}
+		params["profissao"] = cycle.ProfessionID
+		if cycle.FunnelID != nil {
+			params["funil"] = *cycle.FunnelID
+		}
+		return 0, nil
+	}
 
-	// 4. pesquisa_inicio = dataBase - 7 dias, às 20:00
-	pesquisaInicio := dataBase.AddDate(0, 0, -7)
-	pesquisaInicio = time.Date(pesquisaInicio.Year(), pesquisaInicio.Month(), pesquisaInicio.Day(), 20, 0, 0, 0, pesquisaInicio.Location())
-	dateParams["pesquisa_inicio"] = pesquisaInicio
+	if vendaInicioStr == "" {
+		// Se nenhuma data foi fornecida, use a terça-feira atual ou a terça-feira anterior
+		dataEscolhida := time.Now()
+		for dataEscolhida.Weekday() != time.Tuesday {
+			dataEscolhida = dataEscolhida.AddDate(0, 0, -1)
+		}
+		vendaInicioStr = time.Date(dataEscolhida.Year(), dataEscolhida.Month(), dataEscolhida.Day(), 20, 30, 0, 0, dataEscolhida.Location()).Format(time.RFC3339)
 
-	return dateParams, nil
+		dateParams, err := h.calculateDateParams(vendaInicioStr)
+		if err != nil {
+			return 500, errors.New("Erro ao calcular datas padrão")
+		}
+		for key, value := range dateParams {
+			params[key] = value
+		}
+		return 0, nil
+	}
+
+	// Se data de venda_inicio fornecida, calcular todos os parâmetros de data
+	dateParams, err := h.calculateDateParams(vendaInicioStr)
+	if err != nil {
+		return 400, errors.New("Horário de início de vendas inválido. As vendas sempre iniciam às 20:30.")
+	}
+
+	for key, value := range dateParams {
+		params[key] = value
+	}
+	return 0, nil
 }
 
 // GetSurveys retorna todas as pesquisas com opção de filtros
@@ -115,6 +162,7 @@ func (h *SurveyHandler) GetSurveys(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param venda_inicio query string false "Data de início para vendas (ISO8601 com timezone)"
+// @Param cycle_id query int false "ID do ciclo de webinar (substitui venda_inicio)"
 // @Param profissao query int false "Filtrar por profissão"
 // @Param funil query int false "Filtrar por funil"
 // @Param pesquisa_id query int false "Filtrar por ID da pesquisa"
@@ -126,41 +174,9 @@ func (h *SurveyHandler) GetSurveyMetrics(c *fiber.Ctx) error {
 	// Obter parâmetros e filtros
 	params := make(map[string]interface{})
 
-	// Parâmetros de filtro de data
-	vendaInicioStr := c.Query("venda_inicio", "")
-
-	// Se data de venda_inicio fornecida, calcular todos os parâmetros de data
-	if vendaInicioStr != "" {
-		dateParams, err := h.calculateDateParams(vendaInicioStr)
-		if err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "Horário de início de vendas inválido. As vendas sempre iniciam às 20:30."})
-		}
-
-		// Adicionar todos os parâmetros de data ao mapa de parâmetros
-		for key, value := range dateParams {
-			params[key] = value
-		}
-	} else {
-		// Se nenhuma data foi fornecida, use a terça-feira atual ou a terça-feira anterior
-		dataEscolhida := time.Now()
-		// Ajustar para a terça-feira atual ou anterior
-		for dataEscolhida.Weekday() != time.Tuesday {
-			dataEscolhida = dataEscolhida.AddDate(0, 0, -1)
-		}
-
-		// Formatar como string ISO8601
-		vendaInicioStr = time.Date(dataEscolhida.Year(), dataEscolhida.Month(), dataEscolhida.Day(), 20, 30, 0, 0, dataEscolhida.Location()).Format(time.RFC3339)
-
-		// Calcular parâmetros de data
-		dateParams, err := h.calculateDateParams(vendaInicioStr)
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Erro ao calcular datas padrão"})
-		}
-
-		// Adicionar todos os parâmetros de data ao mapa de parâmetros
-		for key, value := range dateParams {
-			params[key] = value
-		}
+	// Período da pesquisa (ciclo, venda_inicio ou terça-feira atual)
+	if status, err := h.applySurveyPeriod(c, params); err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	// Outros filtros
@@ -197,6 +213,8 @@ func (h *SurveyHandler) GetSurveyMetrics(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path int true "ID da pesquisa"
 // @Param venda_inicio query string false "Data de início para vendas (ISO8601 com timezone)"
+// @Param cycle_id query int false "ID do ciclo de webinar (substitui venda_inicio)"
+// @Param definition_version query int false "Considerar apenas respostas coletadas nesta versão da definição"
 // @Success 200 {object} []map[string]interface{} "Detalhes da pesquisa"
 // @Failure 400 {object} map[string]interface{} "Erro de parâmetros"
 // @Failure 404 {object} map[string]interface{} "Pesquisa não encontrada"
@@ -215,41 +233,24 @@ func (h *SurveyHandler) GetSurveyDetails(c *fiber.Ctx) error {
 	// Adicionar o surveyID aos parâmetros
 	params["pesquisa_id"] = surveyID
 
-	// Parâmetros de filtro de data - usar a mesma lógica que GetSurveyMetrics
-	vendaInicioStr := c.Query("venda_inicio", "")
-
-	// Se data de venda_inicio fornecida, calcular todos os parâmetros de data
-	if vendaInicioStr != "" {
-		dateParams, err := h.calculateDateParams(vendaInicioStr)
-		if err != nil {
-			return c.Status(400).JSON(fiber.Map{"error": "Horário de início de vendas inválido. As vendas sempre iniciam às 20:30."})
-		}
-
-		// Adicionar todos os parâmetros de data ao mapa de parâmetros
-		for key, value := range dateParams {
-			params[key] = value
-		}
-	} else {
-		// Se nenhuma data foi fornecida, use a terça-feira atual ou a terça-feira anterior
-		dataEscolhida := time.Now()
-		// Ajustar para a terça-feira atual ou anterior
-		for dataEscolhida.Weekday() != time.Tuesday {
-			dataEscolhida = dataEscolhida.AddDate(0, 0, -1)
-		}
-
-		// Formatar como string ISO8601
-		vendaInicioStr = time.Date(dataEscolhida.Year(), dataEscolhida.Month(), dataEscolhida.Day(), 20, 30, 0, 0, dataEscolhida.Location()).Format(time.RFC3339)
+	// Período da pesquisa - mesma lógica que GetSurveyMetrics
+	if status, err := h.applySurveyPeriod(c, params); err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
 
-		// Calcular parâmetros de data
-		dateParams, err := h.calculateDateParams(vendaInicioStr)
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": "Erro ao calcular datas padrão"})
+	// Respostas coletadas em uma versão específica da definição da pesquisa
+	if versionStr := c.Query("definition_version", ""); versionStr != "" {
+		version, err := strconv.Atoi(versionStr)
+		if err != nil || version <= 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "Parâmetro 'definition_version' inválido"})
 		}
+		params["definition_version"] = version
+	}
 
-		// Adicionar todos os parâmetros de data ao mapa de parâmetros
-		for key, value := range dateParams {
-			params[key] = value
-		}
+	// Exportação em arquivo (format=csv|xlsx|ndjson): uma linha por pergunta e opção de resposta
+	export, err := parseExportRequest(c, fmt.Sprintf("survey-%d", surveyID), surveyDetailExportColumns)
+	if err != nil {
+		return c.Status(exportStatus(err)).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	// Log dos parâmetros para debug
@@ -276,6 +277,12 @@ func (h *SurveyHandler) GetSurveyDetails(c *fiber.Ctx) error {
 
 	// Verificar se recebemos resultados
 	detailsArray, ok := details.([]map[string]interface{})
+	if export != nil {
+		// Os detalhes já vêm agregados (poucas linhas), então são exportados a partir da memória
+		return streamExport(c, export, func(ctx context.Context, fn repositories.ExportRowFunc) error {
+			return exportSurveyDetails(detailsArray, export.columns, fn)
+		})
+	}
 	if !ok || len(detailsArray) == 0 {
 		// Retornar uma resposta vazia, mas não um erro
 		return c.JSON([]map[string]interface{}{
@@ -290,3 +297,198 @@ func (h *SurveyHandler) GetSurveyDetails(c *fiber.Ctx) error {
 	// Retornar resposta
 	return c.JSON(details)
 }
+
+// surveyDetailExportColumns são as colunas da exportação dos detalhes da pesquisa
+var surveyDetailExportColumns = []repositories.ExportColumn{
+	{Name: "pergunta_id"},
+	{Name: "texto_pergunta"},
+	{Name: "texto_opcao"},
+	{Name: "score_peso"},
+	{Name: "num_respostas"},
+	{Name: "percentual_participacao"},
+	{Name: "num_vendas"},
+	{Name: "taxa_conversao_percentual"},
+	{Name: "percentual_vendas"},
+	{Name: "tempo_medio_resposta"},
+}
+
+// lessQuestionID ordena pergunta_id numericamente ("2" antes de "10"); IDs não numéricos vêm depois,
+// em ordem alfabética
+func lessQuestionID(a, b string) bool {
+	numA, errA := strconv.ParseInt(a, 10, 64)
+	numB, errB := strconv.ParseInt(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		return numA < numB
+	case errA == nil || errB == nil:
+		return errA == nil
+	default:
+		return a < b
+	}
+}
+
+// exportSurveyDetails achata os detalhes da pesquisa em uma linha por pergunta e opção de resposta,
+// com as perguntas ordenadas por pergunta_id
+func exportSurveyDetails(details []map[string]interface{}, columns []repositories.ExportColumn, fn repositories.ExportRowFunc) error {
+	if len(details) == 0 {
+		return nil
+	}
+	questions, _ := details[0]["questoes"].([]map[string]interface{})
+	sort.SliceStable(questions, func(i, j int) bool {
+		return lessQuestionID(fmt.Sprint(questions[i]["pergunta_id"]), fmt.Sprint(questions[j]["pergunta_id"]))
+	})
+
+	values := make([]string, len(columns))
+	for _, question := range questions {
+		answers, _ := question["respostas"].([]map[string]interface{})
+		for _, answer := range answers {
+			for i, column := range columns {
+				value, ok := answer[column.Name]
+				if !ok {
+					value = question[column.Name]
+				}
+				values[i] = ""
+				if value != nil {
+					values[i] = fmt.Sprint(value)
+				}
+			}
+			if err := fn(values); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetSurveyCrosstab cruza as respostas de duas perguntas da pesquisa
+// @Summary Cruzamento de respostas da pesquisa
+// @Description Cruza duas perguntas (ou a faixa, com "faixa") com contagens, percentuais por linha/coluna e conversão em compra por célula, nas janelas de pesquisa e vendas
+// @Tags surveys
+// @Produce json
+// @Param id path int true "ID da pesquisa"
+// @Param row query string true "question_id da pergunta nas linhas (ou 'faixa')"
+// @Param col query string true "question_id da pergunta nas colunas (ou 'faixa')"
+// @Param venda_inicio query string false "Data de início para vendas (ISO8601 com timezone)"
+// @Param cycle_id query int false "ID do ciclo de webinar (substitui venda_inicio)"
+// @Success 200 {object} usecases.SurveyCrosstab "Cruzamento"
+// @Failure 400 {object} map[string]interface{} "Erro de parâmetros"
+// @Failure 404 {object} map[string]interface{} "Pesquisa ou pergunta não encontrada"
+// @Router /metrics/surveys/{id}/crosstab [get]
+func (h *SurveyHandler) GetSurveyCrosstab(c *fiber.Ctx) error {
+	surveyID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || surveyID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de pesquisa inválido"})
+	}
+
+	row := c.Query("row", "")
+	col := c.Query("col", "")
+	if row == "" || col == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Os parâmetros 'row' e 'col' são obrigatórios"})
+	}
+	if row == col {
+		return c.Status(400).JSON(fiber.Map{"error": "Os parâmetros 'row' e 'col' devem ser diferentes"})
+	}
+
+	params := make(map[string]interface{})
+	if status, err := h.applySurveyPeriod(c, params); err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	crosstab, err := h.surveyUseCase.GetSurveyCrosstab(surveyID, row, col, params)
+	if err != nil {
+		if errors.Is(err, usecases.ErrSurveyNotFound) || errors.Is(err, usecases.ErrSurveyQuestionNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Erro ao cruzar respostas da pesquisa: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":            crosstab,
+		"pesquisa_inicio": params["pesquisa_inicio"],
+		"pesquisa_fim":    params["pesquisa_fim"],
+		"venda_inicio":    params["venda_inicio"],
+		"venda_fim":       params["venda_fim"],
+	})
+}
+
+// GetSurveyFunnel retorna o funil por pergunta da pesquisa
+// @Summary Funil por pergunta da pesquisa
+// @Description Quantos respondentes chegaram a cada pergunta, abandono em cada etapa, mediana e p90 do tempo de resposta e taxa de respostas alteradas. Perguntas lentas, confusas ou com abandono alto são sinalizadas em "flags"
+// @Tags surveys
+// @Produce json
+// @Param id path int true "ID da pesquisa"
+// @Param venda_inicio query string false "Data de início para vendas (ISO8601 com timezone)"
+// @Param cycle_id query int false "ID do ciclo de webinar (substitui venda_inicio)"
+// @Success 200 {object} usecases.SurveyQuestionFunnel "Funil por pergunta"
+// @Failure 400 {object} map[string]interface{} "Erro de parâmetros"
+// @Failure 404 {object} map[string]interface{} "Pesquisa não encontrada"
+// @Router /metrics/surveys/{id}/funnel [get]
+func (h *SurveyHandler) GetSurveyFunnel(c *fiber.Ctx) error {
+	surveyID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || surveyID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de pesquisa inválido"})
+	}
+
+	params := make(map[string]interface{})
+	if status, err := h.applySurveyPeriod(c, params); err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	funnel, err := h.surveyUseCase.GetSurveyQuestionFunnel(surveyID, params)
+	if err != nil {
+		if errors.Is(err, usecases.ErrSurveyNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Erro ao calcular funil da pesquisa: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":            funnel,
+		"pesquisa_inicio": params["pesquisa_inicio"],
+		"pesquisa_fim":    params["pesquisa_fim"],
+	})
+}
+
+// GetSurveyVariantMetrics compara as variantes de pesquisa de um funil
+// @Summary Experimento de variantes de pesquisa
+// @Description Por variante: taxa de conclusão, distribuição de faixas e conversão em compra do produto principal, com teste z de duas proporções e probabilidade bayesiana de superar o controle
+// @Tags surveys
+// @Produce json
+// @Param funil query int true "ID do funil"
+// @Param venda_inicio query string false "Data de início para vendas (ISO8601 com timezone)"
+// @Param cycle_id query int false "ID do ciclo de webinar (substitui venda_inicio)"
+// @Success 200 {object} usecases.SurveyVariantExperiment "Métricas por variante"
+// @Failure 400 {object} map[string]interface{} "Erro de parâmetros"
+// @Failure 404 {object} map[string]interface{} "Funil sem variantes"
+// @Router /metrics/surveys/variants [get]
+func (h *SurveyHandler) GetSurveyVariantMetrics(c *fiber.Ctx) error {
+	params := make(map[string]interface{})
+	if status, err := h.applySurveyPeriod(c, params); err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// O funil do ciclo é usado quando 'funil' não é informado
+	funnelID, err := strconv.Atoi(c.Query("funil", "0"))
+	if err == nil && funnelID == 0 {
+		funnelID, _ = params["funil"].(int)
+	}
+	if err != nil || funnelID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Parâmetro 'funil' é obrigatório"})
+	}
+
+	experiment, err := h.variantUseCase.CompareVariants(funnelID, params)
+	if err != nil {
+		if errors.Is(err, usecases.ErrNoSurveyVariants) {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Erro ao comparar variantes de pesquisa: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":            experiment,
+		"pesquisa_inicio": params["pesquisa_inicio"],
+		"pesquisa_fim":    params["pesquisa_fim"],
+		"venda_inicio":    params["venda_inicio"],
+		"venda_fim":       params["venda_fim"],
+	})
+}
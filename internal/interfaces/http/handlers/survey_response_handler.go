@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// SurveyResponseHandler lida com o envio de respostas de pesquisa
+type SurveyResponseHandler struct {
+	responseUseCase usecases.SurveyResponseUseCase
+}
+
+// NewSurveyResponseHandler cria uma nova instância de SurveyResponseHandler
+func NewSurveyResponseHandler(responseUseCase usecases.SurveyResponseUseCase) *SurveyResponseHandler {
+	return &SurveyResponseHandler{
+		responseUseCase: responseUseCase,
+	}
+}
+
+// SubmitResponse grava (parcial ou totalmente) uma resposta de pesquisa
+// @Summary Envia respostas de uma pesquisa
+// @Description Valida as respostas contra a definição da pesquisa e calcula total_score e faixa no servidor. A resposta é vinculada ao evento PESQUISA_LEAD (event_id, ou o mais recente do user_id). Envios parciais são continuados com response_id até o envio com completed=true.
+// @Tags surveys
+// @Accept json
+// @Produce json
+// @Param id path int true "ID da pesquisa"
+// @Success 200 {object} usecases.SurveyResponseResult "Resposta parcial gravada"
+// @Success 201 {object} usecases.SurveyResponseResult "Resposta finalizada"
+// @Failure 400 {object} map[string]interface{} "Erro de parâmetros"
+// @Failure 404 {object} map[string]interface{} "Pesquisa, resposta ou evento não encontrado"
+// @Failure 409 {object} map[string]interface{} "Resposta já finalizada ou pesquisa sem versão ativa"
+// @Failure 422 {object} map[string]interface{} "Respostas inválidas para a definição"
+// @Router /surveys/{id}/responses [post]
+func (h *SurveyResponseHandler) SubmitResponse(c *fiber.Ctx) error {
+	surveyID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || surveyID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de pesquisa inválido"})
+	}
+
+	var input usecases.SurveyResponseInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+	}
+
+	if input.ResponseID == "" && input.EventID == "" && input.UserID == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Informe 'response_id', 'event_id' ou 'user_id'"})
+	}
+	for field, value := range map[string]string{"response_id": input.ResponseID, "event_id": input.EventID} {
+		if value == "" {
+			continue
+		}
+		if _, err := uuid.Parse(value); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Parâmetro '" + field + "' deve ser um UUID"})
+		}
+	}
+	if len(input.Answers) == 0 && !input.Completed {
+		return c.Status(400).JSON(fiber.Map{"error": "Nenhuma resposta enviada"})
+	}
+	for _, answer := range input.Answers {
+		if answer.TimeToAnswer < 0 || math.IsNaN(answer.TimeToAnswer) || math.IsInf(answer.TimeToAnswer, 0) {
+			return c.Status(400).JSON(fiber.Map{"error": "'time_to_answer' inválido na pergunta " + answer.QuestionID})
+		}
+	}
+
+	result, err := h.responseUseCase.SubmitResponse(surveyID, input)
+	if err != nil {
+		if errors.Is(err, usecases.ErrInvalidSurveyAnswers) && result != nil {
+			return c.Status(422).JSON(fiber.Map{
+				"error":      err.Error(),
+				"validation": result.Validation,
+			})
+		}
+		return surveyResponseErrorResponse(c, err)
+	}
+
+	status := fiber.StatusOK
+	if result.Response.Completed {
+		status = fiber.StatusCreated
+	}
+	return c.Status(status).JSON(result)
+}
+
+// GetResponse retorna uma resposta de pesquisa com suas respostas por pergunta, para continuar envios parciais
+// @Summary Retorna uma resposta de pesquisa
+// @Tags surveys
+// @Produce json
+// @Param id path int true "ID da pesquisa"
+// @Param response_id path string true "ID da resposta"
+// @Success 200 {object} entities.SurveyResponse "Resposta"
+// @Failure 404 {object} map[string]interface{} "Resposta não encontrada"
+// @Router /surveys/{id}/responses/{response_id} [get]
+func (h *SurveyResponseHandler) GetResponse(c *fiber.Ctx) error {
+	surveyID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || surveyID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de pesquisa inválido"})
+	}
+	responseID := c.Params("response_id")
+	if _, err := uuid.Parse(responseID); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de resposta inválido"})
+	}
+
+	response, err := h.responseUseCase.GetResponse(surveyID, responseID)
+	if err != nil {
+		return surveyResponseErrorResponse(c, err)
+	}
+
+	return c.JSON(response)
+}
+
+// surveyResponseErrorResponse traduz erros de envio de respostas para respostas HTTP
+func surveyResponseErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, usecases.ErrSurveyNotFound),
+		errors.Is(err, usecases.ErrSurveyResponseNotFound),
+		errors.Is(err, usecases.ErrPesquisaEventNotFound),
+		errors.Is(err, usecases.ErrSurveyDefinitionNotFound):
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, usecases.ErrSurveyResponseClosed),
+		errors.Is(err, usecases.ErrNoActiveSurveyDefinition):
+		return c.Status(409).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, usecases.ErrInvalidSurveyAnswers):
+		return c.Status(422).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+}
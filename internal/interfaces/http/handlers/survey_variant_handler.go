@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SurveyVariantHandler lida com requisições de variantes de pesquisa
+type SurveyVariantHandler struct {
+	variantUseCase usecases.SurveyVariantUseCase
+}
+
+// NewSurveyVariantHandler cria uma nova instância de SurveyVariantHandler
+func NewSurveyVariantHandler(variantUseCase usecases.SurveyVariantUseCase) *SurveyVariantHandler {
+	return &SurveyVariantHandler{
+		variantUseCase: variantUseCase,
+	}
+}
+
+// GetVariants lista as variantes de pesquisa de um funil
+// @Summary Lista variantes de pesquisa do funil
+// @Tags surveys
+// @Produce json
+// @Param id path int true "ID do funil"
+// @Success 200 {object} map[string]interface{} "Variantes"
+// @Router /funnels/{id}/survey-variants [get]
+func (h *SurveyVariantHandler) GetVariants(c *fiber.Ctx) error {
+	funnelID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || funnelID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de funil inválido"})
+	}
+
+	variants, err := h.variantUseCase.ListVariants(funnelID)
+	if err != nil {
+		return surveyVariantErrorResponse(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  variants,
+		"total": len(variants),
+	})
+}
+
+// CreateVariant adiciona uma pesquisa do funil como variante
+// @Summary Cria uma variante de pesquisa
+// @Description A pesquisa deve pertencer ao funil. A primeira variante do funil é o controle.
+// @Tags surveys
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do funil"
+// @Success 201 {object} entities.SurveyVariant "Variante criada"
+// @Failure 400 {object} map[string]interface{} "Variante inválida"
+// @Failure 404 {object} map[string]interface{} "Pesquisa não encontrada"
+// @Router /funnels/{id}/survey-variants [post]
+func (h *SurveyVariantHandler) CreateVariant(c *fiber.Ctx) error {
+	funnelID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || funnelID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de funil inválido"})
+	}
+
+	var input usecases.SurveyVariantInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+	}
+
+	variant, err := h.variantUseCase.CreateVariant(funnelID, input)
+	if err != nil {
+		return surveyVariantErrorResponse(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(variant)
+}
+
+// UpdateVariant altera nome, peso de tráfego ou controle de uma variante
+// @Summary Altera uma variante de pesquisa
+// @Tags surveys
+// @Accept json
+// @Produce json
+// @Param id path int true "ID da variante"
+// @Success 200 {object} entities.SurveyVariant "Variante alterada"
+// @Failure 404 {object} map[string]interface{} "Variante não encontrada"
+// @Router /survey-variants/{id} [put]
+func (h *SurveyVariantHandler) UpdateVariant(c *fiber.Ctx) error {
+	variantID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || variantID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de variante inválido"})
+	}
+
+	var input usecases.SurveyVariantInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+	}
+
+	variant, err := h.variantUseCase.UpdateVariant(variantID, input)
+	if err != nil {
+		return surveyVariantErrorResponse(c, err)
+	}
+
+	return c.JSON(variant)
+}
+
+// DeleteVariant remove uma variante (as respostas coletadas são mantidas)
+// @Summary Remove uma variante de pesquisa
+// @Tags surveys
+// @Param id path int true "ID da variante"
+// @Success 204
+// @Failure 404 {object} map[string]interface{} "Variante não encontrada"
+// @Router /survey-variants/{id} [delete]
+func (h *SurveyVariantHandler) DeleteVariant(c *fiber.Ctx) error {
+	variantID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || variantID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de variante inválido"})
+	}
+
+	if err := h.variantUseCase.DeleteVariant(variantID); err != nil {
+		return surveyVariantErrorResponse(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// AssignVariant retorna a variante de pesquisa que deve ser exibida ao usuário
+// @Summary Sorteia a variante de pesquisa do usuário
+// @Description Sorteio determinístico pelo peso de tráfego; usuários que já responderam uma variante do funil continuam nela
+// @Tags surveys
+// @Produce json
+// @Param id path int true "ID do funil"
+// @Param user_id query string true "ID do usuário"
+// @Success 200 {object} entities.SurveyVariant "Variante"
+// @Failure 404 {object} map[string]interface{} "Funil sem variantes"
+// @Router /funnels/{id}/survey-variants/assign [get]
+func (h *SurveyVariantHandler) AssignVariant(c *fiber.Ctx) error {
+	funnelID, err := strconv.Atoi(c.Params("id"))
+	if err != nil || funnelID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de funil inválido"})
+	}
+	userID := c.Query("user_id", "")
+	if userID == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Parâmetro 'user_id' é obrigatório"})
+	}
+
+	variant, err := h.variantUseCase.AssignVariant(funnelID, userID)
+	if err != nil {
+		return surveyVariantErrorResponse(c, err)
+	}
+
+	return c.JSON(variant)
+}
+
+// surveyVariantErrorResponse traduz erros de variantes de pesquisa para respostas HTTP
+func surveyVariantErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, usecases.ErrInvalidSurveyVariant):
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, usecases.ErrSurveyNotFound),
+		errors.Is(err, usecases.ErrSurveyVariantNotFound),
+		errors.Is(err, usecases.ErrNoSurveyVariants):
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	default:
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+}
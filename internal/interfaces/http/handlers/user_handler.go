@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -14,14 +15,16 @@ import (
 )
 
 type UserHandler struct {
-	userUseCase *usecases.UserUseCase
-	userRepo    *repositories.UserRepository
+	userUseCase    *usecases.UserUseCase
+	userRepo       *repositories.UserRepository
+	segmentUseCase usecases.SegmentUseCase
 }
 
-func NewUserHandler(userUseCase *usecases.UserUseCase, userRepo *repositories.UserRepository) *UserHandler {
+func NewUserHandler(userUseCase *usecases.UserUseCase, userRepo *repositories.UserRepository, segmentUseCase usecases.SegmentUseCase) *UserHandler {
 	return &UserHandler{
-		userUseCase: userUseCase,
-		userRepo:    userRepo,
+		userUseCase:    userUseCase,
+		userRepo:       userRepo,
+		segmentUseCase: segmentUseCase,
 	}
 }
 
@@ -139,6 +142,39 @@ func (h *UserHandler) GetLeads(c *fiber.Ctx) error {
 	sortDirection := c.Query("sortDirection", "desc")
 	orderBy := fmt.Sprintf("%s %s", sortBy, sortDirection)
 
+	// Ordenação pela pontuação da versão ativa das regras; leads sem pontuação ficam por último
+	if sortBy == "score" || sortBy == "lead_score" {
+		sortBy = "lead_score"
+		if strings.ToLower(sortDirection) == "asc" {
+			orderBy = "lead_score ASC NULLS LAST"
+		} else {
+			orderBy = "lead_score DESC NULLS LAST"
+		}
+	}
+
+	// Filtros de pontuação (min_score, max_score, tier)
+	scoreFilter := repositories.LeadScoreFilter{Tier: c.Query("tier", "")}
+	if minScoreStr := c.Query("min_score", ""); minScoreStr != "" {
+		minScore, err := strconv.ParseFloat(minScoreStr, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid 'min_score' parameter"})
+		}
+		scoreFilter.MinScore = &minScore
+	}
+	if maxScoreStr := c.Query("max_score", ""); maxScoreStr != "" {
+		maxScore, err := strconv.ParseFloat(maxScoreStr, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid 'max_score' parameter"})
+		}
+		scoreFilter.MaxScore = &maxScore
+	}
+
+	// Restrição aos membros de um segmento salvo
+	segment, err := resolveSegmentParam(c, h.segmentUseCase)
+	if err != nil {
+		return c.Status(segmentParamStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
 	// Parse date filters usando timezone de Brasília
 	from := time.Time{}
 	to := time.Now().In(brazilLocation)
@@ -193,7 +229,7 @@ func (h *UserHandler) GetLeads(c *fiber.Ctx) error {
 
 			// Gerar array de todas as datas no intervalo
 			dateRange := GenerateDateRange(firstDateOnly, lastDateOnly)
-			result, err := h.userRepo.CountLeadsByPeriods(dateRange)
+			result, err := h.userRepo.CountLeadsByPeriods(dateRange, segment)
 			if err != nil {
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 					"error": fmt.Sprintf("Erro ao contar leads por períodos: %v", err),
@@ -209,7 +245,7 @@ func (h *UserHandler) GetLeads(c *fiber.Ctx) error {
 		} else if period && hasDateFilter {
 			// Gerar array de datas no intervalo from-to
 			dateRange := GenerateDateRange(from, to)
-			result, err := h.userRepo.CountLeadsByPeriods(dateRange)
+			result, err := h.userRepo.CountLeadsByPeriods(dateRange, segment)
 			if err != nil {
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 					"error": fmt.Sprintf("Erro ao contar leads por períodos: %v", err),
@@ -223,7 +259,7 @@ func (h *UserHandler) GetLeads(c *fiber.Ctx) error {
 			})
 		} else if periodsParam != "" {
 			periods := strings.Split(periodsParam, ",")
-			result, err := h.userRepo.CountLeadsByPeriods(periods)
+			result, err := h.userRepo.CountLeadsByPeriods(periods, segment)
 			if err != nil {
 				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 					"error": fmt.Sprintf("Erro ao contar leads por períodos: %v", err),
@@ -235,7 +271,7 @@ func (h *UserHandler) GetLeads(c *fiber.Ctx) error {
 			})
 		}
 
-		count, err := h.userRepo.CountLeads(from, to, timeFrom, timeTo)
+		count, err := h.userRepo.CountLeads(from, to, timeFrom, timeTo, segment)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": fmt.Sprintf("Erro ao contar leads: %v", err),
@@ -251,7 +287,27 @@ func (h *UserHandler) GetLeads(c *fiber.Ctx) error {
 		})
 	}
 
-	leads, total, err := h.userRepo.FindLeads(c.Context(), page, limit, orderBy, from, to, timeFrom, timeTo)
+	// Exportação em arquivo (format=csv|xlsx|ndjson) com os mesmos filtros da listagem
+	export, err := parseExportRequest(c, "leads", repositories.LeadExportColumns)
+	if err != nil {
+		return c.Status(exportStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	if export != nil {
+		if _, err := repositories.LeadExportOrder(sortBy, sortDirection); err != nil {
+			return c.Status(exportStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+		return streamExport(c, export, func(ctx context.Context, fn repositories.ExportRowFunc) error {
+			return h.userRepo.ExportLeads(ctx, export.columns, sortBy, sortDirection, from, to, timeFrom, timeTo, scoreFilter, segment, fn)
+		})
+	}
+
+	// Paginação por offset (page) ou por cursor em (created_at, user_id)
+	pageRequest, err := parsePageRequest(c, page, limit, sortBy, sortDirection, "created_at")
+	if err != nil {
+		return c.Status(paginationStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	leads, pageResult, err := h.userRepo.FindLeads(c.Context(), pageRequest, orderBy, from, to, timeFrom, timeTo, scoreFilter, segment)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": fmt.Sprintf("Erro ao buscar leads: %v", err),
@@ -262,22 +318,25 @@ func (h *UserHandler) GetLeads(c *fiber.Ctx) error {
 		leads = []entities.User{}
 	}
 
-	totalPages := (total + int64(limit) - 1) / int64(limit)
-
-	return c.JSON(fiber.Map{
+	response := fiber.Map{
 		"leads":         leads,
-		"page":          page,
-		"limit":         limit,
-		"total":         total,
-		"totalPages":    totalPages,
 		"sortBy":        sortBy,
 		"sortDirection": sortDirection,
 		"from":          fromStr,
 		"to":            toStr,
 		"time_from":     timeFrom,
 		"time_to":       timeTo,
+		"min_score":     scoreFilter.MinScore,
+		"max_score":     scoreFilter.MaxScore,
+		"tier":          scoreFilter.Tier,
+		"segment_id":    c.Query("segment_id", ""),
 		"limitApplied":  hasDateFilter, // Indica se o limite foi aplicado (apenas com filtro de data)
-	})
+	}
+	for key, value := range paginationFields(pageRequest, pageResult) {
+		response[key] = value
+	}
+
+	return c.JSON(response)
 }
 
 func (h *UserHandler) GetClients(c *fiber.Ctx) error {
@@ -413,21 +472,35 @@ func (h *UserHandler) GetClients(c *fiber.Ctx) error {
 		})
 	}
 
-	clients, total, err := h.userRepo.FindClients(page, limit, orderBy, from, to, timeFrom, timeTo)
+	// Exportação em arquivo (format=csv|xlsx|ndjson) com os mesmos filtros da listagem
+	export, err := parseExportRequest(c, "clients", repositories.ClientExportColumns)
+	if err != nil {
+		return c.Status(exportStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+	if export != nil {
+		if _, err := repositories.ClientExportOrder(sortBy, sortDirection); err != nil {
+			return c.Status(exportStatus(err)).JSON(fiber.Map{"error": err.Error()})
+		}
+		return streamExport(c, export, func(ctx context.Context, fn repositories.ExportRowFunc) error {
+			return h.userRepo.ExportClients(ctx, export.columns, sortBy, sortDirection, from, to, timeFrom, timeTo, fn)
+		})
+	}
+
+	// Paginação por offset (page) ou por cursor em (created_at, user_id)
+	pageRequest, err := parsePageRequest(c, page, limit, sortBy, sortDirection, "created_at")
+	if err != nil {
+		return c.Status(paginationStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	clients, pageResult, err := h.userRepo.FindClients(c.Context(), pageRequest, orderBy, from, to, timeFrom, timeTo)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Erro ao buscar clientes",
 		})
 	}
 
-	totalPages := (total + int64(limit) - 1) / int64(limit)
-
-	return c.JSON(fiber.Map{
+	response := fiber.Map{
 		"clients":       clients,
-		"page":          page,
-		"limit":         limit,
-		"total":         total,
-		"totalPages":    totalPages,
 		"sortBy":        sortBy,
 		"sortDirection": sortDirection,
 		"from":          fromStr,
@@ -435,7 +508,12 @@ func (h *UserHandler) GetClients(c *fiber.Ctx) error {
 		"time_from":     timeFrom,
 		"time_to":       timeTo,
 		"limitApplied":  hasDateFilter, // Indica se o limite foi aplicado (apenas com filtro de data)
-	})
+	}
+	for key, value := range paginationFields(pageRequest, pageResult) {
+		response[key] = value
+	}
+
+	return c.JSON(response)
 }
 
 func (h *UserHandler) GetAnonymous(c *fiber.Ctx) error {
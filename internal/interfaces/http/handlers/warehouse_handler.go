@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/gofiber/fiber/v2"
+)
+
+// parquetContentType é o tipo de mídia dos arquivos Parquet do warehouse
+const parquetContentType = "application/vnd.apache.parquet"
+
+// WarehouseHandler lida com as exportações Parquet para o data warehouse
+type WarehouseHandler struct {
+	warehouseUseCase *usecases.WarehouseExportUseCase
+}
+
+// NewWarehouseHandler cria uma nova instância de WarehouseHandler
+func NewWarehouseHandler(warehouseUseCase *usecases.WarehouseExportUseCase) *WarehouseHandler {
+	return &WarehouseHandler{warehouseUseCase: warehouseUseCase}
+}
+
+// GetSchema retorna o schema dos datasets exportados
+// @Summary Retorna o schema dos datasets do warehouse
+// @Tags warehouse
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Schema"
+// @Router /warehouse/schema [get]
+func (h *WarehouseHandler) GetSchema(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"schema_version": repositories.WarehouseSchemaVersion,
+		"datasets":       repositories.WarehouseDatasets,
+	})
+}
+
+// GetStatus retorna a execução em andamento, a última execução e as marcas d'água de cada dataset
+// @Summary Retorna o estado das exportações do warehouse
+// @Tags warehouse
+// @Produce json
+// @Success 200 {object} usecases.WarehouseExportStatus "Estado"
+// @Router /warehouse/exports [get]
+func (h *WarehouseHandler) GetStatus(c *fiber.Ctx) error {
+	status, err := h.warehouseUseCase.Status()
+	if err != nil {
+		return warehouseErrorResponse(c, err)
+	}
+	return c.JSON(status)
+}
+
+// warehouseExportBody é o corpo de POST /warehouse/exports
+type warehouseExportBody struct {
+	Datasets []string `json:"datasets"`
+	Since    string   `json:"since"`
+}
+
+// TriggerExport inicia uma exportação sob demanda
+// @Summary Inicia uma exportação do warehouse
+// @Description Exporta os datasets informados (todos se vazio) a partir da marca d'água, ou de since (RFC3339) para reexportar
+// @Tags warehouse
+// @Accept json
+// @Produce json
+// @Success 202 {object} map[string]interface{} "Exportação iniciada"
+// @Failure 400 {object} map[string]interface{} "Pedido inválido"
+// @Failure 409 {object} map[string]interface{} "Exportação já em andamento"
+// @Router /warehouse/exports [post]
+func (h *WarehouseHandler) TriggerExport(c *fiber.Ctx) error {
+	var body warehouseExportBody
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+		}
+	}
+
+	var since *time.Time
+	if body.Since != "" {
+		parsed, err := time.Parse(time.RFC3339, body.Since)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "since deve estar no formato RFC3339"})
+		}
+		since = &parsed
+	}
+	for i := range body.Datasets {
+		body.Datasets[i] = strings.ToLower(strings.TrimSpace(body.Datasets[i]))
+	}
+
+	if err := h.warehouseUseCase.Trigger(body.Datasets, since); err != nil {
+		return warehouseErrorResponse(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message":    "exportação do warehouse iniciada",
+		"status_url": fmt.Sprintf("%s/warehouse/exports", c.BaseURL()),
+	})
+}
+
+// GetFiles retorna o manifesto dos arquivos gerados
+// @Summary Lista os arquivos Parquet do warehouse
+// @Tags warehouse
+// @Produce json
+// @Param dataset query string false "Dataset (events, sessions, users ou survey_answers)"
+// @Param since query string false "Somente arquivos criados após este instante (RFC3339)"
+// @Param token query string false "Token de download (WAREHOUSE_TOKEN); com ele, o manifesto inclui os links de download"
+// @Success 200 {object} map[string]interface{} "Manifesto"
+// @Router /warehouse/files [get]
+func (h *WarehouseHandler) GetFiles(c *fiber.Ctx) error {
+	var since time.Time
+	if value := c.Query("since", ""); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "since deve estar no formato RFC3339"})
+		}
+		since = parsed
+	}
+
+	files, err := h.warehouseUseCase.ListFiles(strings.ToLower(c.Query("dataset", "")), since)
+	if err != nil {
+		return warehouseErrorResponse(c, err)
+	}
+
+	// Os links de download levam o token, por isso só vão para quem o informou
+	token := c.Query("token", "")
+	if !h.warehouseUseCase.HasDownloadToken(token) {
+		token = ""
+	}
+	data := make([]fiber.Map, len(files))
+	for i := range files {
+		data[i] = warehouseFileResponse(c, &files[i], token)
+	}
+	return c.JSON(fiber.Map{
+		"schema_version": repositories.WarehouseSchemaVersion,
+		"data":           data,
+		"total":          len(data),
+	})
+}
+
+// DownloadFile envia um arquivo Parquet do manifesto
+// @Summary Baixa um arquivo Parquet do warehouse
+// @Tags warehouse
+// @Produce octet-stream
+// @Param id path int true "ID do arquivo"
+// @Param token query string true "Token de download (WAREHOUSE_TOKEN)"
+// @Success 200 {file} file "Arquivo Parquet"
+// @Failure 404 {object} map[string]interface{} "Arquivo não encontrado ou token inválido"
+// @Router /warehouse/files/{id} [get]
+func (h *WarehouseHandler) DownloadFile(c *fiber.Ctx) error {
+	fileID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "ID de arquivo inválido"})
+	}
+
+	file, filePath, err := h.warehouseUseCase.OpenFile(fileID, c.Query("token", ""))
+	if err != nil {
+		return warehouseErrorResponse(c, err)
+	}
+	return sendExportFile(c, filePath, parquetContentType, fmt.Sprintf("%s-%s", file.Dataset, path.Base(file.Path)))
+}
+
+// warehouseFileResponse monta a entrada do manifesto; o link de download só é incluído com o token
+func warehouseFileResponse(c *fiber.Ctx, file *entities.WarehouseExportFile, token string) fiber.Map {
+	response := fiber.Map{
+		"file_id":    file.FileID,
+		"dataset":    file.Dataset,
+		"partition":  file.Partition,
+		"path":       file.Path,
+		"rows":       file.Rows,
+		"bytes":      file.Bytes,
+		"range_from": file.RangeFrom,
+		"range_to":   file.RangeTo,
+		"created_at": file.CreatedAt,
+	}
+	if token != "" {
+		response["download_url"] = fmt.Sprintf("%s/warehouse/files/%d?token=%s", c.BaseURL(), file.FileID, url.QueryEscape(token))
+	}
+	return response
+}
+
+// warehouseErrorResponse converte os erros das exportações do warehouse em respostas HTTP
+func warehouseErrorResponse(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, usecases.ErrInvalidWarehouseExport):
+		status = fiber.StatusBadRequest
+	case errors.Is(err, usecases.ErrWarehouseFileNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, usecases.ErrWarehouseExportRunning):
+		status = fiber.StatusConflict
+	}
+	return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+}
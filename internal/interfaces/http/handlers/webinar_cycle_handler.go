@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/entities"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// errInvalidCycleID indica um cycle_id mal formatado na query
+var errInvalidCycleID = errors.New("parâmetro 'cycle_id' inválido")
+
+// WebinarCycleHandler lida com requisições relacionadas aos ciclos de webinar
+type WebinarCycleHandler struct {
+	cycleUseCase  usecases.WebinarCycleUseCase
+	reportUseCase usecases.CycleReportUseCase
+}
+
+// NewWebinarCycleHandler cria uma nova instância de WebinarCycleHandler
+func NewWebinarCycleHandler(cycleUseCase usecases.WebinarCycleUseCase, reportUseCase usecases.CycleReportUseCase) *WebinarCycleHandler {
+	return &WebinarCycleHandler{
+		cycleUseCase:  cycleUseCase,
+		reportUseCase: reportUseCase,
+	}
+}
+
+// GetCycles lista os ciclos de webinar com seus leads, respostas de pesquisa, vendas e faturamento
+// @Summary Lista ciclos de webinar
+// @Tags cycles
+// @Produce json
+// @Param profession_id query int false "ID da profissão"
+// @Param funnel_id query int false "ID do funil"
+// @Param from query string false "Ciclos que terminam a partir desta data (YYYY-MM-DD)"
+// @Param to query string false "Ciclos que começam até esta data (YYYY-MM-DD)"
+// @Param include_metrics query bool false "Incluir métricas do ciclo" default(true)
+// @Param page query int false "Página atual" default(1)
+// @Param limit query int false "Itens por página" default(20)
+// @Success 200 {object} map[string]interface{} "Lista de ciclos"
+// @Router /cycles [get]
+func (h *WebinarCycleHandler) GetCycles(c *fiber.Ctx) error {
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		return c.Status(400).JSON(fiber.Map{"error": "Parâmetro 'page' inválido"})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "20"))
+	if err != nil || limit < 1 || limit > 200 {
+		return c.Status(400).JSON(fiber.Map{"error": "Parâmetro 'limit' inválido"})
+	}
+
+	filter := repositories.WebinarCycleFilter{}
+	filter.ProfessionID, _ = strconv.Atoi(c.Query("profession_id", "0"))
+	filter.FunnelID, _ = strconv.Atoi(c.Query("funnel_id", "0"))
+
+	if fromStr := c.Query("from", ""); fromStr != "" {
+		if filter.From, err = parseCycleDate(fromStr); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Formato de data inválido para 'from'"})
+		}
+	}
+	if toStr := c.Query("to", ""); toStr != "" {
+		if filter.To, err = parseCycleDate(toStr); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Formato de data inválido para 'to'"})
+		}
+		if len(toStr) == 10 {
+			filter.To = filter.To.Add(24*time.Hour - time.Nanosecond)
+		}
+	}
+
+	includeMetrics := c.Query("include_metrics", "true") != "false"
+
+	cycles, total, err := h.cycleUseCase.ListCycles(filter, page, limit, includeMetrics)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Erro ao buscar ciclos: " + err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":       cycles,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	})
+}
+
+// GetCycleByID retorna um ciclo com suas métricas
+// @Summary Retorna um ciclo de webinar
+// @Tags cycles
+// @Produce json
+// @Param id path int true "ID do ciclo"
+// @Success 200 {object} map[string]interface{} "Ciclo"
+// @Failure 404 {object} map[string]interface{} "Ciclo não encontrado"
+// @Router /cycles/{id} [get]
+func (h *WebinarCycleHandler) GetCycleByID(c *fiber.Ctx) error {
+	cycleID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || cycleID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de ciclo inválido"})
+	}
+
+	cycle, err := h.cycleUseCase.GetCycle(cycleID, c.Query("include_metrics", "true") != "false")
+	if err != nil {
+		return cycleErrorResponse(c, err)
+	}
+
+	return c.JSON(cycle)
+}
+
+// CreateCycle cria um ciclo manualmente (ex.: ciclo fora do cronograma padrão)
+// @Summary Cria um ciclo de webinar
+// @Tags cycles
+// @Accept json
+// @Produce json
+// @Success 201 {object} entities.WebinarCycle "Ciclo criado"
+// @Failure 400 {object} map[string]interface{} "Erro de parâmetros"
+// @Router /cycles [post]
+func (h *WebinarCycleHandler) CreateCycle(c *fiber.Ctx) error {
+	var input usecases.WebinarCycleInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+	}
+
+	cycle, err := h.cycleUseCase.CreateCycle(input)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(cycle)
+}
+
+// UpdateCycle sobrescreve os limites ou dados de um ciclo
+// @Summary Atualiza um ciclo de webinar
+// @Tags cycles
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do ciclo"
+// @Success 200 {object} entities.WebinarCycle "Ciclo atualizado"
+// @Failure 404 {object} map[string]interface{} "Ciclo não encontrado"
+// @Router /cycles/{id} [put]
+func (h *WebinarCycleHandler) UpdateCycle(c *fiber.Ctx) error {
+	cycleID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || cycleID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de ciclo inválido"})
+	}
+
+	var input usecases.WebinarCycleInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+	}
+
+	cycle, err := h.cycleUseCase.UpdateCycle(cycleID, input)
+	if err != nil {
+		if errors.Is(err, usecases.ErrWebinarCycleNotFound) {
+			return cycleErrorResponse(c, err)
+		}
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(cycle)
+}
+
+// DeleteCycle remove um ciclo
+// @Summary Remove um ciclo de webinar
+// @Tags cycles
+// @Param id path int true "ID do ciclo"
+// @Success 204
+// @Failure 404 {object} map[string]interface{} "Ciclo não encontrado"
+// @Router /cycles/{id} [delete]
+func (h *WebinarCycleHandler) DeleteCycle(c *fiber.Ctx) error {
+	cycleID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || cycleID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "ID de ciclo inválido"})
+	}
+
+	if err := h.cycleUseCase.DeleteCycle(cycleID); err != nil {
+		return cycleErrorResponse(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GenerateCycles gera os ciclos do cronograma padrão (terça-feira) para uma profissão/funil
+// @Summary Gera ciclos de webinar
+// @Tags cycles
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Ciclos criados"
+// @Failure 400 {object} map[string]interface{} "Erro de parâmetros"
+// @Router /cycles/generate [post]
+func (h *WebinarCycleHandler) GenerateCycles(c *fiber.Ctx) error {
+	var input struct {
+		ProfessionID int    `json:"profession_id"`
+		FunnelID     *int   `json:"funnel_id"`
+		From         string `json:"from"`
+		To           string `json:"to"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Corpo da requisição inválido: " + err.Error()})
+	}
+
+	if input.From == "" || input.To == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Os campos 'from' e 'to' são obrigatórios"})
+	}
+	from, err := parseCycleDate(input.From)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Formato de data inválido para 'from'"})
+	}
+	to, err := parseCycleDate(input.To)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Formato de data inválido para 'to'"})
+	}
+
+	created, err := h.cycleUseCase.GenerateCycles(input.ProfessionID, input.FunnelID, from, to)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"created": len(created),
+		"data":    created,
+	})
+}
+
+// CompareCycles retorna o relatório comparativo entre ciclos em uma linha do tempo normalizada
+// @Summary Comparativo entre ciclos de webinar
+// @Description Alinha os últimos N ciclos da profissão (hora 0 = início da captação) com leads acumulados, taxa de resposta da pesquisa, distribuição de faixas, conversão em vendas e faturamento
+// @Tags cycles
+// @Produce json
+// @Param profession_id query int true "ID da profissão"
+// @Param funnel_id query int false "ID do funil"
+// @Param cycles query int false "Quantidade de ciclos encerrados" default(4)
+// @Param include_current query bool false "Incluir o ciclo em andamento" default(true)
+// @Param step query int false "Intervalo entre pontos da linha do tempo, em horas" default(1)
+// @Param benchmark_cycle_id query int false "ID do ciclo benchmark"
+// @Param benchmark query string false "Nome do ciclo benchmark"
+// @Success 200 {object} map[string]interface{} "Relatório comparativo"
+// @Failure 400 {object} map[string]interface{} "Erro de parâmetros"
+// @Failure 404 {object} map[string]interface{} "Benchmark não encontrado"
+// @Router /cycles/compare [get]
+func (h *WebinarCycleHandler) CompareCycles(c *fiber.Ctx) error {
+	professionID, err := strconv.Atoi(c.Query("profession_id", "0"))
+	if err != nil || professionID <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Parâmetro 'profession_id' é obrigatório"})
+	}
+
+	funnelID, err := strconv.Atoi(c.Query("funnel_id", "0"))
+	if err != nil || funnelID < 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Parâmetro 'funnel_id' inválido"})
+	}
+
+	cycles, err := strconv.Atoi(c.Query("cycles", "4"))
+	if err != nil || cycles < 1 || cycles > 26 {
+		return c.Status(400).JSON(fiber.Map{"error": "Parâmetro 'cycles' deve estar entre 1 e 26"})
+	}
+
+	step, err := strconv.Atoi(c.Query("step", "1"))
+	if err != nil || step < 1 || step > 24 {
+		return c.Status(400).JSON(fiber.Map{"error": "Parâmetro 'step' deve estar entre 1 e 24"})
+	}
+
+	benchmarkID, err := strconv.ParseInt(c.Query("benchmark_cycle_id", "0"), 10, 64)
+	if err != nil || benchmarkID < 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "Parâmetro 'benchmark_cycle_id' inválido"})
+	}
+
+	report, err := h.reportUseCase.CompareCycles(usecases.CycleComparisonParams{
+		ProfessionID:       professionID,
+		FunnelID:           funnelID,
+		Cycles:             cycles,
+		IncludeCurrent:     c.Query("include_current", "true") != "false",
+		StepHours:          step,
+		BenchmarkCycleID:   benchmarkID,
+		BenchmarkCycleName: c.Query("benchmark", ""),
+	})
+	if err != nil {
+		if errors.Is(err, usecases.ErrBenchmarkCycleNotFound) {
+			return c.Status(404).JSON(fiber.Map{"error": "Ciclo benchmark não encontrado"})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": "Erro ao gerar comparativo de ciclos: " + err.Error()})
+	}
+
+	return c.JSON(report)
+}
+
+// cycleErrorResponse traduz erros de ciclo para respostas HTTP
+func cycleErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, usecases.ErrWebinarCycleNotFound) {
+		return c.Status(404).JSON(fiber.Map{"error": "Ciclo não encontrado"})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+}
+
+// resolveCycleParam resolve o parâmetro cycle_id da query, usado pelos endpoints de dashboard
+// no lugar de from/to. Retorna nil quando o parâmetro não foi informado.
+func resolveCycleParam(c *fiber.Ctx, cycleUseCase usecases.WebinarCycleUseCase) (*entities.WebinarCycle, utils.WebinarCycleDates, utils.WebinarCycleDates, error) {
+	cycleIDStr := c.Query("cycle_id", "")
+	if cycleIDStr == "" || cycleUseCase == nil {
+		return nil, utils.WebinarCycleDates{}, utils.WebinarCycleDates{}, nil
+	}
+
+	cycleID, err := strconv.ParseInt(cycleIDStr, 10, 64)
+	if err != nil || cycleID <= 0 {
+		return nil, utils.WebinarCycleDates{}, utils.WebinarCycleDates{}, errInvalidCycleID
+	}
+
+	cycle, previous, err := cycleUseCase.ResolveCycle(cycleID)
+	if err != nil {
+		return nil, utils.WebinarCycleDates{}, utils.WebinarCycleDates{}, err
+	}
+
+	return cycle, usecases.CycleDates(cycle), previous, nil
+}
+
+// cycleParamStatus retorna o status HTTP adequado para um erro de resolveCycleParam
+func cycleParamStatus(err error) int {
+	switch {
+	case errors.Is(err, errInvalidCycleID):
+		return fiber.StatusBadRequest
+	case errors.Is(err, usecases.ErrWebinarCycleNotFound):
+		return fiber.StatusNotFound
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// applyCycleFilters preenche profissão/funil a partir do ciclo quando não informados na query
+func applyCycleFilters(params map[string]string, cycle *entities.WebinarCycle) {
+	params["cycle_id"] = strconv.FormatInt(cycle.CycleID, 10)
+	if params["profession_id"] == "" {
+		params["profession_id"] = strconv.Itoa(cycle.ProfessionID)
+	}
+	if params["funnel_id"] == "" && cycle.FunnelID != nil {
+		params["funnel_id"] = strconv.Itoa(*cycle.FunnelID)
+	}
+}
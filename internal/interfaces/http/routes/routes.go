@@ -1,8 +1,12 @@
 package routes
 
 import (
+	"context"
+	"strings"
+
 	"github.com/PavaniTiago/beta-intelligence-api/internal/application/usecases"
 	"github.com/PavaniTiago/beta-intelligence-api/internal/domain/repositories"
+	"github.com/PavaniTiago/beta-intelligence-api/internal/infrastructure/mail"
 	"github.com/PavaniTiago/beta-intelligence-api/internal/interfaces/http/handlers"
 	"github.com/PavaniTiago/beta-intelligence-api/internal/interfaces/http/middleware"
 
@@ -17,14 +21,18 @@ func authMiddleware(c *fiber.Ctx) error {
 	return c.Next()
 }
 
-func SetupRoutes(app *fiber.App, db *gorm.DB) {
+// SetupRoutes registra as rotas da API e retorna os workers em segundo plano, que main executa até o encerramento
+func SetupRoutes(app *fiber.App, db *gorm.DB) []func(ctx context.Context) {
 	// Add performance middleware
 	app.Use(compress.New(compress.Config{
+		Next:  isEventStream,
 		Level: compress.LevelBestSpeed,
 	}))
 
 	// Add ETag support for efficient caching
-	app.Use(etag.New())
+	app.Use(etag.New(etag.Config{
+		Next: isEventStream,
+	}))
 
 	// Health check
 	app.Get("/health", func(c *fiber.Ctx) error {
@@ -43,6 +51,22 @@ func SetupRoutes(app *fiber.App, db *gorm.DB) {
 	productRepo := repositories.NewProductRepository(db)
 	surveyRepo := repositories.NewSurveyRepository(db)
 	revenueRepo := repositories.NewRevenueRepository(db)
+	forecastRepo := repositories.NewForecastRepository(db)
+	cycleRepo := repositories.NewWebinarCycleRepository(db)
+	leadScoreRepo := repositories.NewLeadScoreRepository(db)
+	propensityRepo := repositories.NewPropensityRepository(db)
+	surveyDefinitionRepo := repositories.NewSurveyDefinitionRepository(db)
+	surveyResponseRepo := repositories.NewSurveyResponseRepository(db)
+	surveyVariantRepo := repositories.NewSurveyVariantRepository(db)
+	experimentRepo := repositories.NewExperimentRepository(db)
+	metricRollupRepo := repositories.NewMetricRollupRepository(db)
+	metricBreakdownRepo := repositories.NewMetricBreakdownRepository(db)
+	segmentRepo := repositories.NewSegmentRepository(db)
+	eventPropertyRepo := repositories.NewEventPropertyRepository(db)
+	exportJobRepo := repositories.NewExportJobRepository(db)
+	warehouseRepo := repositories.NewWarehouseRepository(db)
+	reportRepo := repositories.NewReportRepository(db)
+	liveRepo := repositories.NewLiveRepository(db)
 
 	// Use Cases
 	userUseCase := usecases.NewUserUseCase(userRepo)
@@ -51,20 +75,60 @@ func SetupRoutes(app *fiber.App, db *gorm.DB) {
 	funnelUseCase := usecases.NewFunnelUseCase(funnelRepo)
 	sessionUseCase := usecases.NewSessionUseCase(sessionRepo)
 	productUseCase := usecases.NewProductUseCase(productRepo)
-	dashboardUseCase := usecases.NewDashboardUseCase(sessionRepo, eventRepo, db)
+	dashboardUseCase := usecases.NewDashboardUseCase(sessionRepo, eventRepo, db, metricRollupRepo)
 	surveyUseCase := usecases.NewSurveyUseCase(surveyRepo)
-	revenueUseCase := usecases.NewRevenueUseCase(revenueRepo)
+	surveyVariantUseCase := usecases.NewSurveyVariantUseCase(surveyVariantRepo, surveyRepo)
+	revenueUseCase := usecases.NewRevenueUseCase(revenueRepo, metricRollupRepo)
+	forecastUseCase := usecases.NewForecastUseCase(forecastRepo)
+	cycleUseCase := usecases.NewWebinarCycleUseCase(cycleRepo)
+	cycleReportUseCase := usecases.NewCycleReportUseCase(cycleRepo, forecastRepo)
+	leadScoringUseCase := usecases.NewLeadScoringUseCase(leadScoreRepo)
+	propensityUseCase := usecases.NewPropensityUseCase(propensityRepo, cycleRepo)
+	surveyDefinitionUseCase := usecases.NewSurveyDefinitionUseCase(surveyDefinitionRepo)
+	surveyResponseUseCase := usecases.NewSurveyResponseUseCase(surveyResponseRepo, surveyDefinitionRepo)
+	experimentUseCase := usecases.NewExperimentUseCase(experimentRepo)
+	metricBreakdownUseCase := usecases.NewMetricBreakdownUseCase(metricBreakdownRepo)
+	segmentUseCase := usecases.NewSegmentUseCase(segmentRepo)
+	eventPropertyUseCase := usecases.NewEventPropertyUseCase(eventPropertyRepo)
+	exportJobUseCase := usecases.NewExportJobUseCase(exportJobRepo, eventRepo, sessionRepo, userRepo, segmentUseCase)
+	warehouseUseCase := usecases.NewWarehouseExportUseCase(warehouseRepo)
 
 	// Handlers
-	userHandler := handlers.NewUserHandler(userUseCase, userRepo)
-	eventHandler := handlers.NewEventHandler(eventUseCase)
+	userHandler := handlers.NewUserHandler(userUseCase, userRepo, segmentUseCase)
+	eventHandler := handlers.NewEventHandler(eventUseCase, segmentUseCase)
 	professionHandler := handlers.NewProfessionHandler(professionUseCase)
 	funnelHandler := handlers.NewFunnelHandler(funnelUseCase)
-	sessionHandler := handlers.NewSessionHandler(sessionUseCase)
+	sessionHandler := handlers.NewSessionHandler(sessionUseCase, segmentUseCase)
 	productHandler := handlers.NewProductHandler(productUseCase)
-	dashboardHandler := handlers.NewDashboardHandler(dashboardUseCase)
-	surveyHandler := handlers.NewSurveyHandler(surveyUseCase)
-	revenueHandler := handlers.NewRevenueHandler(revenueUseCase)
+	dashboardHandler := handlers.NewDashboardHandler(dashboardUseCase, cycleUseCase, segmentUseCase)
+	surveyHandler := handlers.NewSurveyHandler(surveyUseCase, cycleUseCase, surveyVariantUseCase)
+	revenueHandler := handlers.NewRevenueHandler(revenueUseCase, cycleUseCase)
+	forecastHandler := handlers.NewForecastHandler(forecastUseCase, cycleUseCase)
+	cycleHandler := handlers.NewWebinarCycleHandler(cycleUseCase, cycleReportUseCase)
+	leadScoringHandler := handlers.NewLeadScoringHandler(leadScoringUseCase)
+	propensityHandler := handlers.NewPropensityHandler(propensityUseCase, cycleUseCase)
+	surveyDefinitionHandler := handlers.NewSurveyDefinitionHandler(surveyDefinitionUseCase)
+	surveyResponseHandler := handlers.NewSurveyResponseHandler(surveyResponseUseCase)
+	surveyVariantHandler := handlers.NewSurveyVariantHandler(surveyVariantUseCase)
+	experimentHandler := handlers.NewExperimentHandler(experimentUseCase)
+	metricBreakdownHandler := handlers.NewMetricBreakdownHandler(metricBreakdownUseCase, cycleUseCase)
+	segmentHandler := handlers.NewSegmentHandler(segmentUseCase)
+	eventPropertyHandler := handlers.NewEventPropertyHandler(eventPropertyUseCase)
+	exportJobHandler := handlers.NewExportJobHandler(exportJobUseCase)
+	warehouseHandler := handlers.NewWarehouseHandler(warehouseUseCase)
+
+	// Worker das exportações assíncronas: grava os arquivos nos mesmos formatos das exportações síncronas
+	exportJobWorker := usecases.NewExportJobWorker(exportJobRepo, exportJobUseCase)
+
+	// Relatórios agendados por e-mail: os KPIs vêm dos usecases do dashboard e do faturamento
+	reportKPISource := usecases.NewReportKPISource(dashboardUseCase, revenueUseCase, cycleUseCase, segmentUseCase)
+	reportUseCase := usecases.NewReportUseCase(reportRepo, cycleUseCase, reportKPISource, mail.NewSender(mail.ConfigFromEnv()))
+	reportHandler := handlers.NewReportHandler(reportUseCase)
+	reportScheduler := usecases.NewReportScheduler(reportUseCase)
+
+	// Feed ao vivo (SSE) de leads e vendas
+	liveUseCase := usecases.NewLiveUseCase(liveRepo)
+	liveHandler := handlers.NewLiveHandler(liveUseCase)
 
 	// Create handlers struct
 	handlersStruct := handlers.NewHandlers(nil, db)
@@ -77,6 +141,7 @@ func SetupRoutes(app *fiber.App, db *gorm.DB) {
 
 	// Rotas para leads
 	groups.Lead.Get("/", userHandler.GetLeads)
+	setupLeadScoringRoutes(groups.Lead, leadScoringHandler)
 
 	// Rotas para clientes
 	groups.Client.Get("/", userHandler.GetClients)
@@ -86,6 +151,7 @@ func SetupRoutes(app *fiber.App, db *gorm.DB) {
 
 	// Events routes
 	groups.Public.Get("/events", eventHandler.GetEvents)
+	groups.Public.Get("/events/properties", eventPropertyHandler.GetProperties)
 
 	// Professions routes
 	groups.Public.Get("/professions", professionHandler.GetProfessions)
@@ -110,11 +176,75 @@ func SetupRoutes(app *fiber.App, db *gorm.DB) {
 	groups.Public.Get("/dashboard/revenue", revenueHandler.GetUnifiedDataGeneral)
 	groups.Public.Get("/dashboard/revenue-by-profession", revenueHandler.GetUnifiedDataByProfession)
 
+	// Breakdown de métricas por dimensão
+	groups.Public.Get("/metrics/breakdown", metricBreakdownHandler.GetBreakdown)
+
+	// Projeção do ciclo de webinar em andamento
+	groups.Public.Get("/forecast/cycle", forecastHandler.GetCycleForecast)
+
+	// Modelo de propensão de compra
+	groups.Public.Post("/propensity/train", propensityHandler.TrainModel)
+	groups.Public.Get("/propensity/models", propensityHandler.GetModels)
+	groups.Public.Get("/propensity/models/:id", propensityHandler.GetModel)
+	groups.Public.Get("/propensity/scores", propensityHandler.GetLeadScores)
+
+	// Rotas de ciclos de webinar
+	setupCycleRoutes(groups.Public, cycleHandler)
+
 	// Rotas de Performance
 	setupPerformanceRoutes(groups.Public, handlersStruct.Performance)
 
 	// Rotas de pesquisas (surveys)
 	setupSurveyRoutes(groups.Public, surveyHandler)
+	setupSurveyDefinitionRoutes(groups.Public, surveyDefinitionHandler)
+
+	// Envio de respostas de pesquisa
+	groups.Public.Post("/surveys/:id/responses", surveyResponseHandler.SubmitResponse)
+	groups.Public.Get("/surveys/:id/responses/:response_id", surveyResponseHandler.GetResponse)
+
+	// Variantes de pesquisa (testes A/B) por funil
+	setupSurveyVariantRoutes(groups.Public, surveyVariantHandler)
+
+	// Experimentos de landing page (testes A/B)
+	setupExperimentRoutes(groups.Public, experimentHandler)
+
+	// Segmentos salvos de usuários
+	setupSegmentRoutes(groups.Public, segmentHandler)
+
+	// Exportações assíncronas
+	setupExportJobRoutes(groups.Public, exportJobHandler)
+
+	// Exportações Parquet para o data warehouse
+	setupWarehouseRoutes(groups.Public, warehouseHandler)
+
+	// Relatórios agendados por e-mail
+	setupReportRoutes(groups.Public, reportHandler)
+
+	// Feed ao vivo de leads e vendas
+	setupLiveRoutes(groups.Public, liveHandler)
+
+	return []func(ctx context.Context){
+		exportJobWorker.Run,
+		warehouseUseCase.Run,
+		reportScheduler.Run,
+		liveUseCase.Run,
+	}
+}
+
+// isEventStream indica as rotas de Server-Sent Events, que não passam pela compressão nem pelo ETag:
+// ambos leem a resposta inteira antes de enviá-la, e um stream SSE não termina
+func isEventStream(c *fiber.Ctx) bool {
+	return strings.HasPrefix(c.Path(), "/live/")
+}
+
+// setupLeadScoringRoutes configura as rotas de regras e pontuação de leads
+func setupLeadScoringRoutes(router fiber.Router, leadScoringHandler *handlers.LeadScoringHandler) {
+	router.Get("/scoring/rules", leadScoringHandler.GetRuleSets)
+	router.Post("/scoring/rules", leadScoringHandler.CreateRuleSet)
+	router.Get("/scoring/rules/:version", leadScoringHandler.GetRuleSet)
+	router.Post("/scoring/rules/:version/activate", leadScoringHandler.ActivateRuleSet)
+	router.Post("/scoring/recompute", leadScoringHandler.RecomputeScores)
+	router.Get("/:id/score", leadScoringHandler.GetLeadScore)
 }
 
 // setupPerformanceRoutes configura as rotas de teste de performance
@@ -134,6 +264,102 @@ func setupSurveyRoutes(router fiber.Router, surveyHandler *handlers.SurveyHandle
 	// Rota para métricas agregadas de pesquisas
 	router.Get("/metrics/surveys", surveyHandler.GetSurveyMetrics)
 
+	// Rota para o experimento de variantes de um funil (antes de /:id)
+	router.Get("/metrics/surveys/variants", surveyHandler.GetSurveyVariantMetrics)
+
 	// Rota para detalhes de uma pesquisa específica
 	router.Get("/metrics/surveys/:id", surveyHandler.GetSurveyDetails)
+
+	// Rota para cruzamento de respostas entre duas perguntas
+	router.Get("/metrics/surveys/:id/crosstab", surveyHandler.GetSurveyCrosstab)
+
+	// Rota para o funil por pergunta (alcance, abandono e tempo de resposta)
+	router.Get("/metrics/surveys/:id/funnel", surveyHandler.GetSurveyFunnel)
+}
+
+// setupSurveyDefinitionRoutes configura as rotas de definições versionadas de pesquisa
+func setupSurveyDefinitionRoutes(router fiber.Router, definitionHandler *handlers.SurveyDefinitionHandler) {
+	router.Get("/surveys/:id/definitions", definitionHandler.GetDefinitions)
+	router.Post("/surveys/:id/definitions", definitionHandler.CreateDefinition)
+	router.Get("/surveys/:id/definitions/active", definitionHandler.GetActiveDefinition)
+	router.Post("/surveys/:id/definitions/validate", definitionHandler.ValidateAnswers)
+	router.Get("/surveys/:id/definitions/:version", definitionHandler.GetDefinition)
+	router.Delete("/surveys/:id/definitions/:version", definitionHandler.DeleteDefinition)
+	router.Post("/surveys/:id/definitions/:version/activate", definitionHandler.ActivateDefinition)
+}
+
+// setupSurveyVariantRoutes configura as rotas de variantes de pesquisa
+func setupSurveyVariantRoutes(router fiber.Router, variantHandler *handlers.SurveyVariantHandler) {
+	router.Get("/funnels/:id/survey-variants", variantHandler.GetVariants)
+	router.Post("/funnels/:id/survey-variants", variantHandler.CreateVariant)
+	router.Get("/funnels/:id/survey-variants/assign", variantHandler.AssignVariant)
+	router.Put("/survey-variants/:id", variantHandler.UpdateVariant)
+	router.Delete("/survey-variants/:id", variantHandler.DeleteVariant)
+}
+
+// setupExperimentRoutes configura as rotas de experimentos de landing page
+func setupExperimentRoutes(router fiber.Router, experimentHandler *handlers.ExperimentHandler) {
+	router.Get("/experiments", experimentHandler.GetExperiments)
+	router.Post("/experiments", experimentHandler.CreateExperiment)
+	router.Get("/experiments/:id", experimentHandler.GetExperiment)
+	router.Put("/experiments/:id", experimentHandler.UpdateExperiment)
+	router.Delete("/experiments/:id", experimentHandler.DeleteExperiment)
+	router.Get("/experiments/:id/results", experimentHandler.GetExperimentResults)
+	router.Get("/funnels/:id/landing-pages", experimentHandler.GetLandingPages)
+}
+
+// setupCycleRoutes configura as rotas de ciclos de webinar
+func setupCycleRoutes(router fiber.Router, cycleHandler *handlers.WebinarCycleHandler) {
+	router.Get("/cycles", cycleHandler.GetCycles)
+	router.Post("/cycles", cycleHandler.CreateCycle)
+	router.Post("/cycles/generate", cycleHandler.GenerateCycles)
+	router.Get("/cycles/compare", cycleHandler.CompareCycles)
+	router.Get("/cycles/:id", cycleHandler.GetCycleByID)
+	router.Put("/cycles/:id", cycleHandler.UpdateCycle)
+	router.Delete("/cycles/:id", cycleHandler.DeleteCycle)
+}
+
+// setupSegmentRoutes configura as rotas de segmentos salvos de usuários
+func setupSegmentRoutes(router fiber.Router, segmentHandler *handlers.SegmentHandler) {
+	router.Get("/segments", segmentHandler.GetSegments)
+	router.Post("/segments", segmentHandler.CreateSegment)
+	router.Post("/segments/preview", segmentHandler.PreviewSegment)
+	router.Get("/segments/:id", segmentHandler.GetSegment)
+	router.Put("/segments/:id", segmentHandler.UpdateSegment)
+	router.Delete("/segments/:id", segmentHandler.DeleteSegment)
+	router.Get("/segments/:id/size", segmentHandler.GetSegmentSize)
+	router.Get("/segments/:id/members", segmentHandler.GetSegmentMembers)
+}
+
+// setupExportJobRoutes configura as rotas de exportações assíncronas
+func setupExportJobRoutes(router fiber.Router, exportJobHandler *handlers.ExportJobHandler) {
+	router.Get("/exports", exportJobHandler.GetJobs)
+	router.Post("/exports", exportJobHandler.CreateJob)
+	router.Get("/exports/:id", exportJobHandler.GetJob)
+	router.Get("/exports/:id/download", exportJobHandler.DownloadJob)
+}
+
+// setupWarehouseRoutes configura as rotas das exportações para o data warehouse
+func setupWarehouseRoutes(router fiber.Router, warehouseHandler *handlers.WarehouseHandler) {
+	router.Get("/warehouse/schema", warehouseHandler.GetSchema)
+	router.Get("/warehouse/exports", warehouseHandler.GetStatus)
+	router.Post("/warehouse/exports", warehouseHandler.TriggerExport)
+	router.Get("/warehouse/files", warehouseHandler.GetFiles)
+	router.Get("/warehouse/files/:id", warehouseHandler.DownloadFile)
+}
+
+// setupReportRoutes configura as rotas dos relatórios agendados por e-mail
+func setupReportRoutes(router fiber.Router, reportHandler *handlers.ReportHandler) {
+	router.Get("/reports", reportHandler.GetReports)
+	router.Post("/reports", reportHandler.CreateReport)
+	router.Get("/reports/:id", reportHandler.GetReport)
+	router.Put("/reports/:id", reportHandler.UpdateReport)
+	router.Delete("/reports/:id", reportHandler.DeleteReport)
+	router.Post("/reports/:id/send", reportHandler.SendReport)
+	router.Get("/reports/:id/preview", reportHandler.PreviewReport)
+}
+
+// setupLiveRoutes configura as rotas do feed ao vivo
+func setupLiveRoutes(router fiber.Router, liveHandler *handlers.LiveHandler) {
+	router.Get("/live/stream", liveHandler.Stream)
 }
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronMacros são os atalhos aceitos no lugar das cinco colunas
+var cronMacros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// cronSearchLimit limita a busca da próxima execução, para expressões que nunca ocorrem (ex.: 31 de fevereiro)
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// CronSchedule é uma expressão cron de cinco colunas: minuto, hora, dia do mês, mês e dia da semana.
+// Cada coluna aceita *, valores, intervalos (1-5), listas (1,3,5) e passos (*/15, 8-18/2); o dia da semana
+// vai de 0 (domingo) a 7 (também domingo). Como no cron, quando dia do mês e dia da semana são restritos
+// basta um dos dois coincidir.
+type CronSchedule struct {
+	expr     string
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+	anyDay   bool
+	anyWeek  bool
+}
+
+// ParseCronSchedule interpreta uma expressão cron de cinco colunas ou um dos atalhos @hourly, @daily,
+// @weekly e @monthly
+func ParseCronSchedule(expr string) (*CronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	fields := strings.Fields(expr)
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		fields = strings.Fields(macro)
+	}
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expressão cron %q deve ter 5 colunas (minuto hora dia mês dia-da-semana)", expr)
+	}
+
+	schedule := &CronSchedule{expr: expr}
+	var err error
+	if schedule.minutes, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minuto inválido em %q: %w", expr, err)
+	}
+	if schedule.hours, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hora inválida em %q: %w", expr, err)
+	}
+	if schedule.days, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("dia do mês inválido em %q: %w", expr, err)
+	}
+	if schedule.months, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("mês inválido em %q: %w", expr, err)
+	}
+	if schedule.weekdays, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("dia da semana inválido em %q: %w", expr, err)
+	}
+	// 7 também é domingo
+	if schedule.weekdays&(1<<7) != 0 {
+		schedule.weekdays |= 1
+	}
+	schedule.anyDay = strings.HasPrefix(fields[2], "*")
+	schedule.anyWeek = strings.HasPrefix(fields[4], "*")
+	return schedule, nil
+}
+
+// parseCronField converte uma coluna em um bitmap dos valores aceitos
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			value, err := strconv.Atoi(part[i+1:])
+			if err != nil || value <= 0 {
+				return 0, fmt.Errorf("passo inválido: %s", part)
+			}
+			step = value
+			part = part[:i]
+		}
+
+		low, high := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("intervalo inválido: %s", part)
+			}
+			if high, err = strconv.Atoi(bounds[1]); err != nil {
+				return 0, fmt.Errorf("intervalo inválido: %s", part)
+			}
+		default:
+			value, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("valor inválido: %s", part)
+			}
+			low, high = value, value
+			// Com passo, um valor único vale como início (ex.: 5/15)
+			if step > 1 {
+				high = max
+			}
+		}
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("%s fora do intervalo %d-%d", part, min, max)
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+// String retorna a expressão original
+func (s *CronSchedule) String() string {
+	return s.expr
+}
+
+// Next retorna o primeiro instante posterior a after em que a expressão ocorre, no fuso de after.
+// Retorna o instante zero se não houver ocorrência nos próximos cinco anos.
+//
+// A busca percorre o horário local (sem fuso), para que o horário de verão não trave, pule nem repita
+// execuções: um horário que não existe (início do horário de verão) ocorre logo após o salto, e um horário
+// que se repete (fim do horário de verão) ocorre uma vez só.
+func (s *CronSchedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	wall := wallClock(after).Add(time.Minute)
+	limit := wall.Add(cronSearchLimit)
+
+	for wall.Before(limit) {
+		if s.months&(1<<uint(wall.Month())) == 0 {
+			wall = time.Date(wall.Year(), wall.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.matchesDay(wall) {
+			wall = time.Date(wall.Year(), wall.Month(), wall.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hours&(1<<uint(wall.Hour())) == 0 {
+			wall = wall.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minutes&(1<<uint(wall.Minute())) == 0 {
+			wall = wall.Add(time.Minute)
+			continue
+		}
+
+		t := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), 0, 0, loc)
+		// Um horário inexistente pode ser convertido para antes do salto; avança a diferença
+		if gap := wall.Sub(wallClock(t)); gap > 0 {
+			t = t.Add(gap)
+		}
+		// Um horário repetido pode ser convertido na ocorrência anterior, que já passou
+		if t.After(after) {
+			return t
+		}
+		wall = wall.Add(time.Minute)
+	}
+	return time.Time{}
+}
+
+// wallClock retorna o horário local de t, sem fuso
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+}
+
+func (s *CronSchedule) matchesDay(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+	if s.anyDay || s.anyWeek {
+		return day && weekday
+	}
+	return day || weekday
+}
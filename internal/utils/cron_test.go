@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func saoPaulo(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	return loc
+}
+
+func TestParseCronScheduleInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"1,,2 * * * *",
+		"@yearly",
+	}
+	for _, expr := range tests {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := ParseCronSchedule(expr); err == nil {
+				t.Errorf("ParseCronSchedule(%q) sem erro", expr)
+			}
+		})
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	loc := saoPaulo(t)
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  []time.Time
+	}{
+		{
+			name:  "passo de minutos",
+			expr:  "*/15 * * * *",
+			after: at(2024, 6, 3, 8, 7),
+			want:  []time.Time{at(2024, 6, 3, 8, 15), at(2024, 6, 3, 8, 30), at(2024, 6, 3, 8, 45), at(2024, 6, 3, 9, 0)},
+		},
+		{
+			name:  "passo a partir de um valor",
+			expr:  "5/20 * * * *",
+			after: at(2024, 6, 3, 8, 5),
+			want:  []time.Time{at(2024, 6, 3, 8, 25), at(2024, 6, 3, 8, 45), at(2024, 6, 3, 9, 5)},
+		},
+		{
+			name:  "intervalo com passo",
+			expr:  "0 8-18/4 * * *",
+			after: at(2024, 6, 3, 9, 0),
+			want:  []time.Time{at(2024, 6, 3, 12, 0), at(2024, 6, 3, 16, 0), at(2024, 6, 4, 8, 0)},
+		},
+		{
+			name:  "intervalo de dias úteis",
+			expr:  "30 9 * * 1-5",
+			after: at(2024, 6, 7, 10, 0), // sexta-feira
+			want:  []time.Time{at(2024, 6, 10, 9, 30), at(2024, 6, 11, 9, 30)},
+		},
+		{
+			name:  "lista",
+			expr:  "0 8,12,18 * * *",
+			after: at(2024, 6, 3, 12, 0),
+			want:  []time.Time{at(2024, 6, 3, 18, 0), at(2024, 6, 4, 8, 0), at(2024, 6, 4, 12, 0)},
+		},
+		{
+			name:  "domingo como 7",
+			expr:  "0 9 * * 7",
+			after: at(2024, 6, 3, 0, 0),
+			want:  []time.Time{at(2024, 6, 9, 9, 0), at(2024, 6, 16, 9, 0)},
+		},
+		{
+			name:  "dia do mês ou dia da semana",
+			expr:  "0 9 1 * 1",
+			after: at(2024, 6, 25, 0, 0), // terça-feira
+			want:  []time.Time{at(2024, 7, 1, 9, 0), at(2024, 7, 8, 9, 0)},
+		},
+		{
+			name:  "mês com menos dias",
+			expr:  "0 0 31 * *",
+			after: at(2024, 1, 31, 0, 0),
+			want:  []time.Time{at(2024, 3, 31, 0, 0), at(2024, 5, 31, 0, 0)},
+		},
+		{
+			name:  "atalho",
+			expr:  "@monthly",
+			after: at(2024, 12, 15, 0, 0),
+			want:  []time.Time{at(2025, 1, 1, 0, 0), at(2025, 2, 1, 0, 0)},
+		},
+		{
+			// Início do horário de verão em 2018: 00:00 de 4/11 pulou para 01:00
+			name:  "horário que não existe ocorre após o salto",
+			expr:  "30 0 * * *",
+			after: at(2018, 11, 3, 12, 0),
+			want:  []time.Time{time.Date(2018, 11, 4, 1, 30, 0, 0, loc), at(2018, 11, 5, 0, 30)},
+		},
+		{
+			name:  "passo atravessando o início do horário de verão",
+			expr:  "*/30 * * * *",
+			after: at(2018, 11, 3, 23, 20),
+			want:  []time.Time{at(2018, 11, 3, 23, 30), time.Date(2018, 11, 4, 1, 0, 0, 0, loc), time.Date(2018, 11, 4, 1, 30, 0, 0, loc)},
+		},
+		{
+			// Fim do horário de verão em 2019: 00:00 de 17/02 voltou para 23:00 de 16/02
+			name:  "horário repetido ocorre uma vez",
+			expr:  "30 23 * * *",
+			after: at(2019, 2, 16, 22, 0),
+			want:  []time.Time{time.Date(2019, 2, 16, 23, 30, 0, 0, time.FixedZone("-02", -2*3600)), at(2019, 2, 17, 23, 30)},
+		},
+		{
+			name:  "31 de fevereiro nunca ocorre",
+			expr:  "0 0 31 2 *",
+			after: at(2024, 1, 1, 0, 0),
+			want:  []time.Time{{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCronSchedule(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCronSchedule(%q): %v", tt.expr, err)
+			}
+			after := tt.after
+			for i, want := range tt.want {
+				got := schedule.Next(after)
+				if !got.Equal(want) {
+					t.Fatalf("ocorrência %d depois de %v = %v; esperado %v", i+1, after, got, want)
+				}
+				after = got
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package utils
+
+import "time"
+
+// WebinarCycleDates contém os limites de um ciclo de webinar.
+// A captação (pesquisa) vai de terça 20:00 até a terça seguinte 20:00 e
+// as vendas acontecem na terça de fechamento, das 20:30 às 23:59:59.
+type WebinarCycleDates struct {
+	PesquisaInicio time.Time `json:"pesquisa_inicio"`
+	PesquisaFim    time.Time `json:"pesquisa_fim"`
+	VendaInicio    time.Time `json:"venda_inicio"`
+	VendaFim       time.Time `json:"venda_fim"`
+}
+
+// CalculateWebinarCycleDates calcula os limites do ciclo cuja venda acontece no dia de vendaDate.
+// O horário de vendaDate é ignorado: as vendas sempre iniciam às 20:30.
+func CalculateWebinarCycleDates(vendaDate time.Time) WebinarCycleDates {
+	dataBase := time.Date(vendaDate.Year(), vendaDate.Month(), vendaDate.Day(), 0, 0, 0, 0, vendaDate.Location())
+	pesquisaInicio := dataBase.AddDate(0, 0, -7)
+
+	return WebinarCycleDates{
+		PesquisaInicio: time.Date(pesquisaInicio.Year(), pesquisaInicio.Month(), pesquisaInicio.Day(), 20, 0, 0, 0, pesquisaInicio.Location()),
+		PesquisaFim:    time.Date(dataBase.Year(), dataBase.Month(), dataBase.Day(), 20, 0, 0, 0, dataBase.Location()),
+		VendaInicio:    time.Date(dataBase.Year(), dataBase.Month(), dataBase.Day(), 20, 30, 0, 0, dataBase.Location()),
+		VendaFim:       time.Date(dataBase.Year(), dataBase.Month(), dataBase.Day(), 23, 59, 59, 999999999, dataBase.Location()),
+	}
+}
+
+// CurrentWebinarCycleDates retorna o ciclo em andamento no instante now.
+// Na terça-feira o ciclo que fecha no dia continua em andamento até o fim da janela de vendas.
+func CurrentWebinarCycleDates(now time.Time) WebinarCycleDates {
+	vendaDate := now
+	for vendaDate.Weekday() != time.Tuesday {
+		vendaDate = vendaDate.AddDate(0, 0, 1)
+	}
+	return CalculateWebinarCycleDates(vendaDate)
+}
+
+// PreviousCycle retorna o ciclo imediatamente anterior (uma semana antes)
+func (d WebinarCycleDates) PreviousCycle() WebinarCycleDates {
+	return CalculateWebinarCycleDates(d.VendaInicio.AddDate(0, 0, -7))
+}
+
+// AsParams retorna os limites no formato de parâmetros usado pelos repositórios de pesquisa
+func (d WebinarCycleDates) AsParams() map[string]time.Time {
+	return map[string]time.Time{
+		"pesquisa_inicio": d.PesquisaInicio,
+		"pesquisa_fim":    d.PesquisaFim,
+		"venda_inicio":    d.VendaInicio,
+		"venda_fim":       d.VendaFim,
+	}
+}